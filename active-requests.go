@@ -0,0 +1,247 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Subresources naming the API of a request, along with its method.
+var apiSubresources = []string{
+	"attributes", "checksum", "clone", "compose", "defaults", "delete",
+	"legal-hold", "location", "object-lock", "origin", "overwrite",
+	"patch", "policy", "replica", "replicate", "retention", "rewrite",
+	"snapshot", "tagging", "uploadId", "uploads", "versioning",
+	"versions",
+}
+
+// Returned by reads and writes of aborted requests.
+var errRequestAborted = errors.New("Request aborted by administrator.")
+
+// activeRequest - a request currently served.
+type activeRequest struct {
+	ID       uint64        `json:"id"`
+	API      string        `json:"api"`
+	Bucket   string        `json:"bucket,omitempty"`
+	Object   string        `json:"object,omitempty"`
+	ClientIP string        `json:"clientIP"`
+	Started  time.Time     `json:"started"`
+	Elapsed  time.Duration `json:"elapsed"`
+	BytesIn  int64         `json:"bytesIn"`
+	BytesOut int64         `json:"bytesOut"`
+	// Set to 1 once aborted.
+	aborted int32
+	// Set to 1 once the response started.
+	responded int32
+}
+
+// isAborted - returns true if the request was aborted.
+func (req *activeRequest) isAborted() bool {
+	return atomic.LoadInt32(&req.aborted) == 1
+}
+
+// respond - records latency of S3 requests once their response starts.
+func (req *activeRequest) respond() {
+	if atomic.CompareAndSwapInt32(&req.responded, 0, 1) && req.Bucket != "" {
+		globalClientLatency.record(time.Since(req.Started))
+	}
+}
+
+// activeRequestsMonitor - keeps all requests currently served,
+// updated by the handlers serving them.
+type activeRequestsMonitor struct {
+	mutex    *sync.Mutex
+	nextID   uint64
+	requests map[uint64]*activeRequest
+}
+
+// Requests currently served by this node.
+var globalActiveRequests = &activeRequestsMonitor{
+	mutex:    &sync.Mutex{},
+	requests: make(map[uint64]*activeRequest),
+}
+
+// add - adds a request, assigning its id.
+func (m *activeRequestsMonitor) add(req *activeRequest) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.nextID++
+	req.ID = m.nextID
+	m.requests[req.ID] = req
+}
+
+// remove - removes a request once served.
+func (m *activeRequestsMonitor) remove(id uint64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	delete(m.requests, id)
+}
+
+// abort - aborts a request, its next read of the request body or
+// write of the response fails. Returns false if no such request is
+// served.
+func (m *activeRequestsMonitor) abort(id uint64) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	req, ok := m.requests[id]
+	if !ok {
+		return false
+	}
+	atomic.StoreInt32(&req.aborted, 1)
+	return true
+}
+
+// GetRequests - returns all requests currently served, longest
+// running first.
+func (m *activeRequestsMonitor) GetRequests() []activeRequest {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	now := time.Now().UTC()
+	requests := make([]activeRequest, 0, len(m.requests))
+	for _, req := range m.requests {
+		requests = append(requests, activeRequest{
+			ID:       req.ID,
+			API:      req.API,
+			Bucket:   req.Bucket,
+			Object:   req.Object,
+			ClientIP: req.ClientIP,
+			Started:  req.Started,
+			Elapsed:  now.Sub(req.Started),
+			BytesIn:  atomic.LoadInt64(&req.BytesIn),
+			BytesOut: atomic.LoadInt64(&req.BytesOut),
+		})
+	}
+	sort.Sort(activeRequestsByStarted(requests))
+	return requests
+}
+
+// activeRequestsByStarted - sorts requests by start time.
+type activeRequestsByStarted []activeRequest
+
+func (r activeRequestsByStarted) Len() int           { return len(r) }
+func (r activeRequestsByStarted) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r activeRequestsByStarted) Less(i, j int) bool { return r[i].Started.Before(r[j].Started) }
+
+// getRequestAPI - returns name of the API a request calls, method and
+// subresource for S3 requests, method and path for all other requests.
+func getRequestAPI(r *http.Request, bucket, object string) string {
+	if strings.HasPrefix(r.URL.Path, reservedBucket) {
+		return r.Method + " " + r.URL.Path
+	}
+	api := r.Method + " service"
+	if object != "" {
+		api = r.Method + " object"
+	} else if bucket != "" {
+		api = r.Method + " bucket"
+	}
+	query := r.URL.Query()
+	for _, subresource := range apiSubresources {
+		if _, ok := query[subresource]; ok {
+			return api + "?" + subresource
+		}
+	}
+	return api
+}
+
+// activeRequestReader - counts bytes read from a request body, fails
+// once the request is aborted.
+type activeRequestReader struct {
+	io.ReadCloser
+	req *activeRequest
+}
+
+func (r activeRequestReader) Read(p []byte) (int, error) {
+	if r.req.isAborted() {
+		return 0, errRequestAborted
+	}
+	n, err := r.ReadCloser.Read(p)
+	atomic.AddInt64(&r.req.BytesIn, int64(n))
+	return n, err
+}
+
+// activeRequestResponseWriter - counts bytes written to a response,
+// fails once the request is aborted.
+type activeRequestResponseWriter struct {
+	http.ResponseWriter
+	req *activeRequest
+}
+
+func (w activeRequestResponseWriter) Write(p []byte) (int, error) {
+	if w.req.isAborted() {
+		return 0, errRequestAborted
+	}
+	w.req.respond()
+	n, err := w.ResponseWriter.Write(p)
+	atomic.AddInt64(&w.req.BytesOut, int64(n))
+	return n, err
+}
+
+func (w activeRequestResponseWriter) WriteHeader(code int) {
+	w.req.respond()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Flush - implements http.Flusher, if supported by the wrapped writer.
+func (w activeRequestResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// activeRequestsHandler - keeps track of requests while they are
+// served.
+type activeRequestsHandler struct {
+	handler http.Handler
+}
+
+// setActiveRequestsHandler to list requests currently served.
+func setActiveRequestsHandler(h http.Handler) http.Handler {
+	return activeRequestsHandler{h}
+}
+
+func (h activeRequestsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Storage and peer RPC connections are hijacked and served for
+	// as long as they are open.
+	if r.URL.Path == storageRPCPath || r.URL.Path == peerRPCPath {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	bucket, object := urlPath2BucketObjectName(r.URL)
+	if strings.HasPrefix(r.URL.Path, reservedBucket) {
+		bucket, object = "", ""
+	}
+	req := &activeRequest{
+		API:      getRequestAPI(r, bucket, object),
+		Bucket:   bucket,
+		Object:   object,
+		ClientIP: getRequestClientIP(r),
+		Started:  time.Now().UTC(),
+	}
+	globalActiveRequests.add(req)
+	defer globalActiveRequests.remove(req.ID)
+	if r.Body != nil {
+		r.Body = activeRequestReader{ReadCloser: r.Body, req: req}
+	}
+	h.handler.ServeHTTP(activeRequestResponseWriter{ResponseWriter: w, req: req}, r)
+}
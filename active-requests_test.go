@@ -0,0 +1,165 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests API names of requests.
+func TestGetRequestAPI(t *testing.T) {
+	testCases := []struct {
+		method      string
+		url         string
+		expectedAPI string
+	}{
+		// Test case - 1.
+		{"GET", "/", "GET service"},
+		// Test case - 2.
+		{"PUT", "/bucket", "PUT bucket"},
+		// Test case - 3.
+		{"GET", "/bucket?policy", "GET bucket?policy"},
+		// Test case - 4.
+		// Listing parameters are not subresources.
+		{"GET", "/bucket?prefix=photos&max-keys=10", "GET bucket"},
+		// Test case - 5.
+		{"PUT", "/bucket/photos/1.jpg", "PUT object"},
+		// Test case - 6.
+		{"PUT", "/bucket/object?partNumber=1&uploadId=abc", "PUT object?uploadId"},
+		// Test case - 7.
+		{"GET", "/minio/admin/active-requests", "GET /minio/admin/active-requests"},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(testCase.method, "http://localhost:9000"+testCase.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bucket, object := urlPath2BucketObjectName(req.URL)
+		if api := getRequestAPI(req, bucket, object); api != testCase.expectedAPI {
+			t.Errorf("Test %d: Expected API %s, got %s", i+1, testCase.expectedAPI, api)
+		}
+	}
+}
+
+// Tests requests are listed with bytes transferred while served.
+func TestActiveRequestsHandler(t *testing.T) {
+	served := make(chan struct{})
+	release := make(chan struct{})
+	handler := setActiveRequestsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.CopyN(ioutil.Discard, r.Body, 3); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte("hello"))
+		served <- struct{}{}
+		<-release
+	}))
+
+	req, err := http.NewRequest("PUT", "http://localhost:9000/bucket/object", bytes.NewReader([]byte("hello, world")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.RemoteAddr = "10.0.0.1:51234"
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	<-served
+
+	requests := globalActiveRequests.GetRequests()
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 active request, got %d", len(requests))
+	}
+	expected := activeRequest{
+		API:      "PUT object",
+		Bucket:   "bucket",
+		Object:   "object",
+		ClientIP: "10.0.0.1",
+		BytesIn:  3,
+		BytesOut: 5,
+	}
+	request := requests[0]
+	if request.ID == 0 {
+		t.Error("Expected request id")
+	}
+	request.ID = 0
+	if request.Started.IsZero() || request.Elapsed < 0 {
+		t.Errorf("Expected start time and elapsed time, got %v and %v", request.Started, request.Elapsed)
+	}
+	request.Started, request.Elapsed = expected.Started, expected.Elapsed
+	if request != expected {
+		t.Errorf("Expected active request %+v, got %+v", expected, request)
+	}
+
+	close(release)
+	<-done
+	if requests = globalActiveRequests.GetRequests(); len(requests) != 0 {
+		t.Errorf("Expected no active requests once served, got %d", len(requests))
+	}
+}
+
+// Tests reads and writes of aborted requests fail.
+func TestAbortActiveRequest(t *testing.T) {
+	served := make(chan struct{})
+	aborted := make(chan struct{})
+	handler := setActiveRequestsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		buf := make([]byte, 3)
+		if _, err := io.ReadFull(r.Body, buf); err != nil {
+			t.Error(err)
+		}
+		served <- struct{}{}
+		<-aborted
+		if _, err := io.ReadFull(r.Body, buf); err != errRequestAborted {
+			t.Errorf("Expected read to fail with %s, got %v", errRequestAborted, err)
+		}
+		if _, err := w.Write(buf); err != errRequestAborted {
+			t.Errorf("Expected write to fail with %s, got %v", errRequestAborted, err)
+		}
+	}))
+
+	req, err := http.NewRequest("GET", "http://localhost:9000/bucket/object", bytes.NewReader([]byte("hello, world")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan struct{})
+	go func() {
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+		close(done)
+	}()
+	<-served
+
+	requests := globalActiveRequests.GetRequests()
+	if len(requests) != 1 {
+		t.Fatalf("Expected 1 active request, got %d", len(requests))
+	}
+	if globalActiveRequests.abort(requests[0].ID + 1) {
+		t.Error("Expected abort of unknown request to fail")
+	}
+	if !globalActiveRequests.abort(requests[0].ID) {
+		t.Error("Expected abort of active request to succeed")
+	}
+	close(aborted)
+	<-done
+	if globalActiveRequests.abort(requests[0].ID) {
+		t.Error("Expected abort of served request to fail")
+	}
+}
@@ -0,0 +1,1218 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Maximum size of fault rules document.
+const maxFaultRulesSize = 1 * 1024 * 1024 // 1MiB.
+
+// UpdateResponse - format for update response.
+type UpdateResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ UpdateResult" json:"-"`
+
+	Nodes []updateNodeStatus `xml:"Node"`
+}
+
+// AuditLogResponse - format for audit log response.
+type AuditLogResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ AuditLogResult" json:"-"`
+
+	Entries []auditEntry `xml:"Entry"`
+}
+
+// isAdminReqAuthenticated - admin APIs are only allowed for requests
+// signed with the server credential.
+func isAdminReqAuthenticated(r *http.Request) APIErrorCode {
+	switch getRequestAuthType(r) {
+	case authTypePresigned, authTypeSigned:
+		// Tenant credentials are not allowed.
+		if getRequestAccessKey(r) != serverConfig.GetCredential().AccessKeyID {
+			return ErrAccessDenied
+		}
+		return isReqAuthenticated(r)
+	}
+	return ErrAccessDenied
+}
+
+// UpdateHandler - POST /minio/admin/update?url=<url>&sha256=<sum>
+// ----------
+// This operation downloads the binary at url on all nodes, verifies
+// its sha256 checksum and restarts nodes one at a time maintaining
+// write quorum.
+func (admin adminAPIHandlers) UpdateHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	updateURL := r.URL.Query().Get("url")
+	sha256Hex := r.URL.Query().Get("sha256")
+	nodes, err := rollingUpdate(updateURL, sha256Hex)
+	if err != nil {
+		errorIf(err, "Unable to perform rolling update.")
+		writeErrorResponse(w, r, ErrAdminUpdateAborted, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, encodeResponse(UpdateResponse{Nodes: nodes}))
+}
+
+// AuditLogHandler - GET /minio/admin/audit?bucket=<bucket>&type=<type>&since=<time>
+// ----------
+// This operation returns recorded bucket config changes, all query
+// parameters are optional and filter the entries returned.
+func (admin adminAPIHandlers) AuditLogHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	var since time.Time
+	if sinceStr := r.URL.Query().Get("since"); sinceStr != "" {
+		var err error
+		if since, err = time.Parse(time.RFC3339, sinceStr); err != nil {
+			writeErrorResponse(w, r, ErrMalformedDate, r.URL.Path)
+			return
+		}
+	}
+	entries, err := readAuditEntries(r.URL.Query().Get("bucket"), r.URL.Query().Get("type"), since)
+	if err != nil {
+		errorIf(err, "Unable to read audit log.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, encodeResponse(AuditLogResponse{Entries: entries}))
+}
+
+// PutFaultsHandler - PUT /minio/admin/faults
+// ----------
+// This operation replaces all fault rules with the JSON list of rules
+// in the request body, only available if server was started with
+// fault injection enabled.
+func (admin adminAPIHandlers) PutFaultsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if !globalDebugFaults {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+
+	var rules []faultRule
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxFaultRulesSize)).Decode(&rules); err != nil {
+		writeErrorResponse(w, r, ErrAdminInvalidFaultRules, r.URL.Path)
+		return
+	}
+	if err := globalFaultInjector.SetRules(rules); err != nil {
+		errorIf(err, "Unable to set fault rules.")
+		writeErrorResponse(w, r, ErrAdminInvalidFaultRules, r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// GetFaultsHandler - GET /minio/admin/faults
+// ----------
+// This operation returns JSON list of active fault rules.
+func (admin adminAPIHandlers) GetFaultsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if !globalDebugFaults {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+
+	rulesBytes, err := json.Marshal(globalFaultInjector.GetRules())
+	if err != nil {
+		errorIf(err, "Unable to marshal fault rules.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, rulesBytes)
+}
+
+// DeleteFaultsHandler - DELETE /minio/admin/faults
+// ----------
+// This operation removes all fault rules.
+func (admin adminAPIHandlers) DeleteFaultsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if !globalDebugFaults {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+
+	globalFaultInjector.SetRules(nil)
+	writeSuccessNoContent(w)
+}
+
+// PutBucketLimitsHandler - PUT /minio/admin/bucket-limits
+// ----------
+// This operation replaces bucket creation limits with the JSON
+// document in the request body.
+func (admin adminAPIHandlers) PutBucketLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	limitsBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBucketLimitsSize))
+	if err != nil {
+		errorIf(err, "Unable to read bucket limits.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	limits, err := parseBucketLimits(limitsBuf)
+	if err != nil {
+		errorIf(err, "Unable to parse bucket limits.")
+		writeErrorResponse(w, r, ErrAdminInvalidBucketLimits, r.URL.Path)
+		return
+	}
+	if err = writeBucketLimits(limits); err != nil {
+		errorIf(err, "Unable to save bucket limits.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// GetBucketLimitsHandler - GET /minio/admin/bucket-limits
+// ----------
+// This operation returns JSON document of bucket creation limits.
+func (admin adminAPIHandlers) GetBucketLimitsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	limits, err := readBucketLimits()
+	if err != nil {
+		errorIf(err, "Unable to read bucket limits.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	limitsBuf, err := json.Marshal(limits)
+	if err != nil {
+		errorIf(err, "Unable to marshal bucket limits.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, limitsBuf)
+}
+
+// CapabilitiesHandler - GET /minio/admin/capabilities
+// ----------
+// This operation returns JSON document of optional features supported
+// by the backend.
+func (admin adminAPIHandlers) CapabilitiesHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	capabilitiesBuf, err := json.Marshal(admin.ObjectAPI.Capabilities())
+	if err != nil {
+		errorIf(err, "Unable to marshal backend capabilities.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, capabilitiesBuf)
+}
+
+// serverInfo - represents the version and backend of the server, with
+// the effective quorum of XL.
+type serverInfo struct {
+	Version string        `json:"version"`
+	Backend string        `json:"backend"`
+	Quorum  *xlQuorumInfo `json:"quorum,omitempty"`
+}
+
+// InfoHandler - GET /minio/admin/info
+// ----------
+// This operation returns JSON document of the server version, backend
+// and the read and write quorum in effect for XL.
+func (admin adminAPIHandlers) InfoHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	info := serverInfo{
+		Version: minioVersion,
+		Backend: admin.ObjectAPI.Capabilities().Backend,
+	}
+	if info.Backend == "XL" {
+		info.Quorum = globalXLQuorum
+	}
+	infoBuf, err := json.Marshal(info)
+	if err != nil {
+		errorIf(err, "Unable to marshal server info.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, infoBuf)
+}
+
+// ClockSkewHandler - GET /minio/admin/clock-skew
+// ----------
+// This operation returns JSON list of last measured clock skew of all
+// peers.
+func (admin adminAPIHandlers) ClockSkewHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	skewsBuf, err := json.Marshal(globalClockSkew.GetSkews())
+	if err != nil {
+		errorIf(err, "Unable to marshal clock skew.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, skewsBuf)
+}
+
+// ErasureWorkersHandler - GET /minio/admin/erasure-workers
+// ----------
+// This operation returns JSON utilization statistics of erasure
+// encode/decode and shard I/O workers.
+func (admin adminAPIHandlers) ErasureWorkersHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	statsBuf, err := json.Marshal(globalErasureWorkers.stats())
+	if err != nil {
+		errorIf(err, "Unable to marshal erasure workers statistics.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, statsBuf)
+}
+
+// ScrubStatusHandler - GET /minio/admin/scrub-status
+// ----------
+// This operation returns JSON progress of the current or last scrub of
+// each XL disk.
+func (admin adminAPIHandlers) ScrubStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	statusBuf, err := json.Marshal(globalScrubStatus.GetStatus())
+	if err != nil {
+		errorIf(err, "Unable to marshal scrub status.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, statusBuf)
+}
+
+// VerifyStatusHandler - GET /minio/admin/verify-status
+// ----------
+// This operation returns JSON integrity report of objects verified by
+// sampling today and yesterday.
+func (admin adminAPIHandlers) VerifyStatusHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	statusBuf, err := json.Marshal(globalSampleVerifier.GetStatus())
+	if err != nil {
+		errorIf(err, "Unable to marshal verify status.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, statusBuf)
+}
+
+// DegradedObjectsHandler - GET /minio/admin/degraded-objects
+// ----------
+// This operation returns JSON counts of XL objects written with disks
+// missing, pending and caught up, and of reads reconstructing them.
+func (admin adminAPIHandlers) DegradedObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	statsBuf, err := json.Marshal(globalDegradedObjects.stats())
+	if err != nil {
+		errorIf(err, "Unable to marshal degraded objects statistics.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, statsBuf)
+}
+
+// DiskStatsHandler - GET /minio/admin/disk-stats
+// ----------
+// This operation returns JSON I/O statistics of each disk and their
+// total, bytes read and written, calls, failed calls and time spent.
+func (admin adminAPIHandlers) DiskStatsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	statsBuf, err := json.Marshal(globalStorageStats.stats())
+	if err != nil {
+		errorIf(err, "Unable to marshal disk statistics.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, statsBuf)
+}
+
+// DisksHandler - GET /minio/admin/disks
+// ----------
+// This operation returns JSON state of each XL disk, online or offline
+// and since when.
+func (admin adminAPIHandlers) DisksHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	statesBuf, err := json.Marshal(globalDiskMonitor.states())
+	if err != nil {
+		errorIf(err, "Unable to marshal disk states.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, statesBuf)
+}
+
+// FailureDomainsHandler - GET /minio/admin/failure-domains
+// ----------
+// This operation returns JSON failure domains of XL disks with the
+// parity blocks of new objects, and whether objects survive losing
+// each domain.
+func (admin adminAPIHandlers) FailureDomainsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	domainsBuf, err := json.Marshal(globalFailureDomains)
+	if err != nil {
+		errorIf(err, "Unable to marshal failure domains.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, domainsBuf)
+}
+
+// HealHandler - POST /minio/admin/heal?bucket=<bucket>[&object=<object>][&dryRun=true]
+// ----------
+// This operation rebuilds missing and corrupted shards of an object, or
+// of all objects of a bucket if no object is given, and returns JSON
+// report of the disks holding them. Nothing is healed in dry-run.
+func (admin adminAPIHandlers) HealHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	bucket := r.URL.Query().Get("bucket")
+	object := r.URL.Query().Get("object")
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	var info interface{}
+	var err error
+	if object == "" {
+		info, err = admin.ObjectAPI.HealBucket(bucket, dryRun)
+	} else {
+		info, err = admin.ObjectAPI.HealObject(bucket, object, dryRun)
+	}
+	if err != nil {
+		errorIf(err, "Unable to heal %s/%s.", bucket, object)
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	infoBuf, err := json.Marshal(info)
+	if err != nil {
+		errorIf(err, "Unable to marshal heal report.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, infoBuf)
+}
+
+// ExportChecksumsHandler - GET /minio/admin/checksums?bucket=<bucket>
+// ----------
+// This operation returns JSON manifest of the checksums of the shards
+// of each part of all objects of a bucket, signed with the server
+// credential for later verification.
+func (admin adminAPIHandlers) ExportChecksumsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	bucket := r.URL.Query().Get("bucket")
+	manifest, err := exportChecksumManifest(admin.ObjectAPI, bucket)
+	if err != nil {
+		errorIf(err, "Unable to export checksums of %s.", bucket)
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	manifestBuf, err := json.Marshal(manifest)
+	if err != nil {
+		errorIf(err, "Unable to marshal checksum manifest.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, manifestBuf)
+}
+
+// VerifyChecksumsHandler - POST /minio/admin/checksums
+// ----------
+// This operation verifies the live data of all objects of the checksum
+// manifest in the request body, and returns JSON report of the objects
+// which are missing, changed or damaged since it was exported.
+func (admin adminAPIHandlers) VerifyChecksumsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	manifestBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxChecksumManifestSize))
+	if err != nil {
+		errorIf(err, "Unable to read checksum manifest.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	manifest, err := parseChecksumManifest(manifestBuf)
+	if err != nil {
+		errorIf(err, "Unable to parse checksum manifest.")
+		writeErrorResponse(w, r, ErrAdminInvalidChecksumManifest, r.URL.Path)
+		return
+	}
+	if !manifest.isValidSignature(serverConfig.GetCredential().SecretAccessKey) {
+		writeErrorResponse(w, r, ErrAdminChecksumManifestSignature, r.URL.Path)
+		return
+	}
+	if _, err = admin.ObjectAPI.GetBucketInfo(manifest.Bucket); err != nil {
+		errorIf(err, "Unable to verify checksums of %s.", manifest.Bucket)
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	reportBuf, err := json.Marshal(verifyChecksumManifest(admin.ObjectAPI, manifest))
+	if err != nil {
+		errorIf(err, "Unable to marshal checksum report.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, reportBuf)
+}
+
+// PutHealThrottleHandler - PUT /minio/admin/heal-throttle
+// ----------
+// This operation replaces the heal throttle with the JSON document in
+// the request body, objects currently healed continue with the new
+// limits.
+func (admin adminAPIHandlers) PutHealThrottleHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	configBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxHealThrottleSize))
+	if err != nil {
+		errorIf(err, "Unable to read heal throttle.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	config, err := parseHealThrottle(configBuf)
+	if err != nil {
+		errorIf(err, "Unable to parse heal throttle.")
+		writeErrorResponse(w, r, ErrAdminInvalidHealThrottle, r.URL.Path)
+		return
+	}
+	if err = writeHealThrottle(config); err != nil {
+		errorIf(err, "Unable to save heal throttle.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	globalHealThrottle.Set(config)
+	writeSuccessNoContent(w)
+}
+
+// GetHealThrottleHandler - GET /minio/admin/heal-throttle
+// ----------
+// This operation returns JSON document of the heal throttle, along with
+// objects currently healed and whether healing waits for client
+// traffic.
+func (admin adminAPIHandlers) GetHealThrottleHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	statusBuf, err := json.Marshal(globalHealThrottle.GetStatus())
+	if err != nil {
+		errorIf(err, "Unable to marshal heal throttle.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, statusBuf)
+}
+
+// EraseBucketHandler - POST /minio/admin/erase-bucket?bucket=<bucket>[&overwrite=true]
+// ----------
+// This operation deletes a bucket along with all of its objects,
+// multipart uploads, snapshots and configs. With overwrite, data is
+// overwritten with zeros before it is deleted. Every erase is recorded
+// in the audit log.
+func (admin adminAPIHandlers) EraseBucketHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	bucket := r.URL.Query().Get("bucket")
+	overwrite := r.URL.Query().Get("overwrite") == "true"
+	accessKey := getRequestAccessKey(r)
+	err := admin.ObjectAPI.EraseBucket(bucket, overwrite)
+	auditBucketErase(bucket, accessKey, overwrite, err)
+	if err != nil {
+		errorIf(err, "Unable to erase bucket %s.", bucket)
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	removeBucketConfigs(bucket, accessKey)
+	writeSuccessNoContent(w)
+}
+
+// ExpireHandler - POST /minio/admin/expire[?dryRun=true]
+// ----------
+// This operation deletes all expired objects now and returns JSON plan
+// of the deleted objects. Nothing is deleted in dry-run.
+func (admin adminAPIHandlers) ExpireHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	plan := adminPlan{
+		DryRun:  dryRun,
+		Entries: expireObjects(admin.ObjectAPI, time.Now().UTC(), dryRun),
+	}
+	writeAdminPlan(w, r, plan)
+}
+
+// DemoteHandler - POST /minio/admin/demote[?dryRun=true]
+// ----------
+// This operation moves all objects older than MINIO_TIER_DEMOTE_AFTER
+// from hot to cold tier now and returns JSON plan of the moved objects.
+// Nothing is moved in dry-run.
+func (admin adminAPIHandlers) DemoteHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	tier, ok := getStorageObjectLayer(admin.ObjectAPI).(tierObjects)
+	if !ok || tier.demoteAfter == 0 {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	plan := adminPlan{
+		DryRun:  dryRun,
+		Entries: tier.demoteObjects(time.Now().UTC().Add(-tier.demoteAfter), dryRun),
+	}
+	writeAdminPlan(w, r, plan)
+}
+
+// RebalanceHandler - POST /minio/admin/rebalance[?bucket=name][&dryRun=true]
+// ----------
+// This operation moves objects of the bucket, or of all buckets, onto
+// the erasure set their name hashes to and returns JSON plan of the
+// moved objects. Nothing is moved in dry-run.
+func (admin adminAPIHandlers) RebalanceHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	objAPI := getStorageObjectLayer(admin.ObjectAPI)
+	if tier, ok := objAPI.(tierObjects); ok {
+		objAPI = tier.hot
+	}
+	sets, ok := objAPI.(setsObjects)
+	if !ok {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	entries, err := sets.rebalanceObjects(r.URL.Query().Get("bucket"), dryRun)
+	if err != nil {
+		errorIf(err, "Unable to rebalance objects.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	plan := adminPlan{
+		DryRun:  dryRun,
+		Entries: entries,
+	}
+	writeAdminPlan(w, r, plan)
+}
+
+// RetentionHoldHandler - POST /minio/admin/retention-hold[?dryRun=true]
+// ----------
+// This operation retains, or puts a legal hold on, all objects of a
+// locked bucket matching the retention hold in the request body, and
+// returns JSON plan of the held objects with their retention. Nothing
+// is changed in dry-run.
+func (admin adminAPIHandlers) RetentionHoldHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	holdBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxRetentionHoldSize))
+	if err != nil {
+		errorIf(err, "Unable to read retention hold.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	hold, err := parseRetentionHold(holdBuf, time.Now().UTC())
+	if err != nil {
+		writeErrorResponse(w, r, ErrAdminInvalidRetentionHold, r.URL.Path)
+		return
+	}
+	if _, locked := getBucketObjectLock(hold.Bucket); !locked {
+		if _, err = admin.ObjectAPI.GetBucketInfo(hold.Bucket); err != nil {
+			errorIf(err, "Unable to fetch bucket info.")
+			writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+			return
+		}
+		writeErrorResponse(w, r, ErrObjectLockNotEnabled, r.URL.Path)
+		return
+	}
+	dryRun := r.URL.Query().Get("dryRun") == "true"
+	entries, err := holdObjects(admin.ObjectAPI, hold, dryRun)
+	if err != nil {
+		errorIf(err, "Unable to hold objects of %s.", hold.Bucket)
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	plan := adminPlan{
+		DryRun:  dryRun,
+		Entries: entries,
+	}
+	writeAdminPlan(w, r, plan)
+}
+
+// writeAdminPlan - writes JSON plan of a destructive admin operation.
+func writeAdminPlan(w http.ResponseWriter, r *http.Request, plan adminPlan) {
+	if plan.Entries == nil {
+		plan.Entries = []planEntry{}
+	}
+	planBuf, err := json.Marshal(plan)
+	if err != nil {
+		errorIf(err, "Unable to marshal plan.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, planBuf)
+}
+
+// ActiveRequestsHandler - GET /minio/admin/active-requests
+// ----------
+// This operation returns JSON list of requests currently served by
+// this node, longest running first.
+func (admin adminAPIHandlers) ActiveRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	requestsBuf, err := json.Marshal(globalActiveRequests.GetRequests())
+	if err != nil {
+		errorIf(err, "Unable to marshal active requests.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, requestsBuf)
+}
+
+// AbortActiveRequestHandler - DELETE /minio/admin/active-requests?id=<id>
+// ----------
+// This operation aborts a request currently served by this node, its
+// next read of the request body or write of the response fails.
+func (admin adminAPIHandlers) AbortActiveRequestHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		writeErrorResponse(w, r, ErrInvalidQueryParams, r.URL.Path)
+		return
+	}
+	if !globalActiveRequests.abort(id) {
+		writeErrorResponse(w, r, ErrNoSuchActiveRequest, r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// nsLocksResponse - held name space locks and their contention
+// statistics.
+type nsLocksResponse struct {
+	Node  string       `json:"node"`
+	Stats nsLockStats  `json:"stats"`
+	Locks []nsLockInfo `json:"locks"`
+}
+
+// LocksHandler - GET /minio/admin/locks?node=<peer>
+// ----------
+// This operation returns JSON contention statistics of name space
+// locks of this node and the locks currently held, longest held
+// first. Requests for a peer are proxied to it.
+func (admin adminAPIHandlers) LocksHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if proxyToNode(w, r) {
+		return
+	}
+
+	locksBuf, err := json.Marshal(nsLocksResponse{
+		Node:  globalNodeName,
+		Stats: nsMutex.getStats(),
+		Locks: nsMutex.getLocks(),
+	})
+	if err != nil {
+		errorIf(err, "Unable to marshal locks.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, locksBuf)
+}
+
+// ForceReleaseLocksHandler - DELETE /minio/admin/locks?older-than=<duration>&node=<peer>
+// ----------
+// This operation releases name space locks of this node held longer
+// than the duration, or the stuck lock timeout if not given, and
+// returns JSON list of the locks released. Requests for a peer are
+// proxied to it.
+func (admin adminAPIHandlers) ForceReleaseLocksHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if proxyToNode(w, r) {
+		return
+	}
+
+	olderThan := globalStuckLockTimeout
+	if olderThanStr := r.URL.Query().Get("older-than"); olderThanStr != "" {
+		var err error
+		if olderThan, err = time.ParseDuration(olderThanStr); err != nil {
+			writeErrorResponse(w, r, ErrInvalidQueryParams, r.URL.Path)
+			return
+		}
+	}
+	if olderThan <= 0 {
+		writeErrorResponse(w, r, ErrInvalidQueryParams, r.URL.Path)
+		return
+	}
+	locksBuf, err := json.Marshal(nsMutex.forceRelease(olderThan))
+	if err != nil {
+		errorIf(err, "Unable to marshal locks.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, locksBuf)
+}
+
+// PutTenantsHandler - PUT /minio/admin/tenants
+// ----------
+// This operation replaces all tenants with the JSON document in the
+// request body, tenants take effect immediately on this node.
+func (admin adminAPIHandlers) PutTenantsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	configBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxTenantsSize))
+	if err != nil {
+		errorIf(err, "Unable to read tenants.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	config, err := parseTenantsConfig(configBuf)
+	if err != nil {
+		errorIf(err, "Unable to parse tenants.")
+		writeErrorResponse(w, r, ErrAdminInvalidTenants, r.URL.Path)
+		return
+	}
+	if err = writeTenantsConfig(config); err != nil {
+		errorIf(err, "Unable to save tenants.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	globalTenants.Set(config.Tenants)
+	go measureTenantUsage(admin.ObjectAPI)
+	writeSuccessNoContent(w)
+}
+
+// GetTenantsHandler - GET /minio/admin/tenants
+// ----------
+// This operation returns JSON document of all tenants, secret keys are
+// not returned.
+func (admin adminAPIHandlers) GetTenantsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	config := tenantsConfig{Tenants: globalTenants.List()}
+	for i := range config.Tenants {
+		config.Tenants[i].Credential.SecretAccessKey = ""
+	}
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		errorIf(err, "Unable to marshal tenants.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, configBuf)
+}
+
+// TenantUsageHandler - GET /minio/admin/tenant-usage
+// ----------
+// This operation returns JSON list of storage used by all tenants
+// along with their labels and quotas.
+func (admin adminAPIHandlers) TenantUsageHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	usageBuf, err := json.Marshal(globalTenants.GetUsage())
+	if err != nil {
+		errorIf(err, "Unable to marshal tenant usage.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, usageBuf)
+}
+
+// PutMeteringHandler - PUT /minio/admin/metering
+// ----------
+// This operation replaces the metering config with the JSON document
+// in the request body, it takes effect from the next export.
+func (admin adminAPIHandlers) PutMeteringHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	configBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxMeteringConfigSize))
+	if err != nil {
+		errorIf(err, "Unable to read metering config.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	config, err := parseMeteringConfig(configBuf)
+	if err != nil {
+		errorIf(err, "Unable to parse metering config.")
+		writeErrorResponse(w, r, ErrAdminInvalidMeteringConfig, r.URL.Path)
+		return
+	}
+	if err = writeMeteringConfig(config); err != nil {
+		errorIf(err, "Unable to save metering config.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// GetMeteringHandler - GET /minio/admin/metering
+// ----------
+// This operation returns JSON document of the metering config.
+func (admin adminAPIHandlers) GetMeteringHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	config, err := readMeteringConfig()
+	if err != nil {
+		errorIf(err, "Unable to read metering config.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		errorIf(err, "Unable to marshal metering config.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, configBuf)
+}
+
+// BucketRatesHandler - GET /minio/admin/bucket-rates
+// ----------
+// This operation returns JSON rates of reads and writes of objects per
+// bucket served by this node, over sliding windows.
+func (admin adminAPIHandlers) BucketRatesHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	ratesBuf, err := json.Marshal(globalBucketRates.GetRates(time.Now().UTC()))
+	if err != nil {
+		errorIf(err, "Unable to marshal bucket rates.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, ratesBuf)
+}
+
+// PutBucketRateHookHandler - PUT /minio/admin/bucket-rate-hook
+// ----------
+// This operation replaces the bucket rate hook with the JSON document
+// in the request body, it takes effect from the next check.
+func (admin adminAPIHandlers) PutBucketRateHookHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	hookBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBucketRateHookSize))
+	if err != nil {
+		errorIf(err, "Unable to read bucket rate hook.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	hook, err := parseBucketRateHook(hookBuf)
+	if err != nil {
+		errorIf(err, "Unable to parse bucket rate hook.")
+		writeErrorResponse(w, r, ErrAdminInvalidBucketRateHook, r.URL.Path)
+		return
+	}
+	if err = writeBucketRateHook(hook); err != nil {
+		errorIf(err, "Unable to save bucket rate hook.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// GetBucketRateHookHandler - GET /minio/admin/bucket-rate-hook
+// ----------
+// This operation returns JSON document of the bucket rate hook.
+func (admin adminAPIHandlers) GetBucketRateHookHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	hook, err := readBucketRateHook()
+	if err != nil {
+		errorIf(err, "Unable to read bucket rate hook.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	hookBuf, err := json.Marshal(hook)
+	if err != nil {
+		errorIf(err, "Unable to marshal bucket rate hook.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, hookBuf)
+}
+
+// MeteringRecordsHandler - GET /minio/admin/metering-records?format=<csv|json>
+// ----------
+// This operation returns records of the current metering period of this
+// node up to now, without ending the period. Bytes stored are not
+// measured and reported as zero.
+func (admin adminAPIHandlers) MeteringRecordsHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = meteringFormatCSV
+	}
+	if format != meteringFormatCSV && format != meteringFormatJSON {
+		writeErrorResponse(w, r, ErrInvalidQueryParams, r.URL.Path)
+		return
+	}
+	start, counters := globalMeter.current()
+	recordsBuf, err := encodeMeteringRecords(getMeteringRecords(start, time.Now().UTC(), counters, nil), format)
+	if err != nil {
+		errorIf(err, "Unable to encode metering records.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	w.Header().Set("Content-Type", getMeteringContentType(format))
+	writeSuccessResponse(w, recordsBuf)
+}
+
+// MetadataQueryHandler - POST /minio/admin/metadata-query
+// ----------
+// This operation returns JSON list of objects matching the metadata
+// query in the request body, served from the metadata index which is
+// only available if the server was started with MINIO_METADATA_INDEX=1.
+func (admin adminAPIHandlers) MetadataQueryHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if !globalMetadataIndexEnabled {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+
+	queryBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxMetadataQuerySize))
+	if err != nil {
+		errorIf(err, "Unable to read metadata query.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	query, err := parseMetadataQuery(queryBuf)
+	if err != nil {
+		writeErrorResponse(w, r, ErrAdminInvalidMetadataQuery, r.URL.Path)
+		return
+	}
+	resultBuf, err := json.Marshal(globalMetadataIndex.query(query))
+	if err != nil {
+		errorIf(err, "Unable to marshal metadata query result.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, resultBuf)
+}
+
+// LifecyclePreviewHandler - POST /minio/admin/lifecycle-preview?bucket=<bucket>[&at=<time>]
+// ----------
+// This operation returns JSON counts and sample keys of the objects of
+// the bucket each rule of the lifecycle configuration in the request
+// body would expire or transition, now or at the RFC 3339 time given.
+// Objects are taken from the metadata index which is only available
+// if the server was started with MINIO_METADATA_INDEX=1.
+func (admin adminAPIHandlers) LifecyclePreviewHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	if !globalMetadataIndexEnabled {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+
+	bucket := r.URL.Query().Get("bucket")
+	if _, err := admin.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	at := time.Now().UTC()
+	if atStr := r.URL.Query().Get("at"); atStr != "" {
+		var err error
+		if at, err = time.Parse(time.RFC3339, atStr); err != nil {
+			writeErrorResponse(w, r, ErrAdminInvalidLifecycleConfig, r.URL.Path)
+			return
+		}
+	}
+
+	configBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxLifecycleConfigSize))
+	if err != nil {
+		errorIf(err, "Unable to read lifecycle configuration.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	config, err := parseLifecycleConfiguration(configBuf)
+	if err != nil {
+		writeErrorResponse(w, r, ErrAdminInvalidLifecycleConfig, r.URL.Path)
+		return
+	}
+	previewBuf, err := json.Marshal(globalMetadataIndex.previewLifecycle(bucket, config, at.UTC()))
+	if err != nil {
+		errorIf(err, "Unable to marshal lifecycle preview.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, previewBuf)
+}
+
+// SimulatePolicyHandler - POST /minio/admin/simulate-policy
+// ----------
+// This operation evaluates the hypothetical request in the request
+// body against the current bucket policy, returns JSON decision with
+// the statement deciding it.
+func (admin adminAPIHandlers) SimulatePolicyHandler(w http.ResponseWriter, r *http.Request) {
+	if s3Error := isAdminReqAuthenticated(r); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	simBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxPolicySimulationSize))
+	if err != nil {
+		errorIf(err, "Unable to read policy simulation.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	sim, err := parsePolicySimulation(simBuf)
+	if err != nil {
+		writeErrorResponse(w, r, ErrAdminInvalidPolicySimulation, r.URL.Path)
+		return
+	}
+	result, err := simulateBucketPolicy(sim)
+	if err != nil {
+		errorIf(err, "Unable to simulate bucket policy.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	resultBuf, err := json.Marshal(result)
+	if err != nil {
+		errorIf(err, "Unable to marshal policy simulation result.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, resultBuf)
+}
@@ -0,0 +1,59 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+// Actions of plan entries.
+const (
+	planActionDelete = "delete"
+	planActionDemote = "demote"
+	planActionMove   = "move"
+	planActionHold   = "hold"
+)
+
+// planEntry - an object modified by a destructive admin operation.
+type planEntry struct {
+	Action string `json:"action"`
+	Bucket string `json:"bucket"`
+	Object string `json:"object"`
+	Size   int64  `json:"size"`
+	// Set if modifying the object failed, never set in dry-run.
+	Error string `json:"error,omitempty"`
+	// Retention of held objects once held.
+	Retention *objectRetention `json:"retention,omitempty"`
+}
+
+// adminPlan - machine-readable report of objects a destructive admin
+// operation modified, or would modify in dry-run.
+type adminPlan struct {
+	DryRun  bool        `json:"dryRun"`
+	Entries []planEntry `json:"entries"`
+}
+
+// newPlanEntry - returns plan entry of an object, with the error of
+// modifying it if any.
+func newPlanEntry(action, bucket string, objInfo ObjectInfo, err error) planEntry {
+	entry := planEntry{
+		Action: action,
+		Bucket: bucket,
+		Object: objInfo.Name,
+		Size:   objInfo.Size,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	return entry
+}
@@ -0,0 +1,118 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import router "github.com/gorilla/mux"
+
+// adminAPIHandlers implements and provides http handlers for admin API.
+type adminAPIHandlers struct {
+	ObjectAPI ObjectLayer
+}
+
+// registerAdminRouter - registers admin APIs under reserved bucket.
+func registerAdminRouter(mux *router.Router, admin adminAPIHandlers) {
+	// Admin router.
+	adminRouter := mux.NewRoute().PathPrefix(reservedBucket + "/admin").Subrouter()
+
+	// GetBucketLimits
+	adminRouter.Methods("GET").Path("/bucket-limits").HandlerFunc(admin.GetBucketLimitsHandler)
+	// PutBucketLimits
+	adminRouter.Methods("PUT").Path("/bucket-limits").HandlerFunc(admin.PutBucketLimitsHandler)
+	// AuditLog
+	adminRouter.Methods("GET").Path("/audit").HandlerFunc(admin.AuditLogHandler)
+	// Capabilities
+	adminRouter.Methods("GET").Path("/capabilities").HandlerFunc(admin.CapabilitiesHandler)
+	// Info
+	adminRouter.Methods("GET").Path("/info").HandlerFunc(admin.InfoHandler)
+	// ClockSkew
+	adminRouter.Methods("GET").Path("/clock-skew").HandlerFunc(admin.ClockSkewHandler)
+	// GetFaults
+	adminRouter.Methods("GET").Path("/faults").HandlerFunc(admin.GetFaultsHandler)
+	// PutFaults
+	adminRouter.Methods("PUT").Path("/faults").HandlerFunc(admin.PutFaultsHandler)
+	// DeleteFaults
+	adminRouter.Methods("DELETE").Path("/faults").HandlerFunc(admin.DeleteFaultsHandler)
+	// GetTenants
+	adminRouter.Methods("GET").Path("/tenants").HandlerFunc(admin.GetTenantsHandler)
+	// PutTenants
+	adminRouter.Methods("PUT").Path("/tenants").HandlerFunc(admin.PutTenantsHandler)
+	// TenantUsage
+	adminRouter.Methods("GET").Path("/tenant-usage").HandlerFunc(admin.TenantUsageHandler)
+	// GetMetering
+	adminRouter.Methods("GET").Path("/metering").HandlerFunc(admin.GetMeteringHandler)
+	// PutMetering
+	adminRouter.Methods("PUT").Path("/metering").HandlerFunc(admin.PutMeteringHandler)
+	// MeteringRecords
+	adminRouter.Methods("GET").Path("/metering-records").HandlerFunc(admin.MeteringRecordsHandler)
+	// BucketRates
+	adminRouter.Methods("GET").Path("/bucket-rates").HandlerFunc(admin.BucketRatesHandler)
+	// GetBucketRateHook
+	adminRouter.Methods("GET").Path("/bucket-rate-hook").HandlerFunc(admin.GetBucketRateHookHandler)
+	// PutBucketRateHook
+	adminRouter.Methods("PUT").Path("/bucket-rate-hook").HandlerFunc(admin.PutBucketRateHookHandler)
+	// MetadataQuery
+	adminRouter.Methods("POST").Path("/metadata-query").HandlerFunc(admin.MetadataQueryHandler)
+	// LifecyclePreview
+	adminRouter.Methods("POST").Path("/lifecycle-preview").HandlerFunc(admin.LifecyclePreviewHandler).Queries("bucket", "{bucket:.+}")
+	// SimulatePolicy
+	adminRouter.Methods("POST").Path("/simulate-policy").HandlerFunc(admin.SimulatePolicyHandler)
+	// ErasureWorkers
+	adminRouter.Methods("GET").Path("/erasure-workers").HandlerFunc(admin.ErasureWorkersHandler)
+	// ScrubStatus
+	adminRouter.Methods("GET").Path("/scrub-status").HandlerFunc(admin.ScrubStatusHandler)
+	// VerifyStatus
+	adminRouter.Methods("GET").Path("/verify-status").HandlerFunc(admin.VerifyStatusHandler)
+	// DegradedObjects
+	adminRouter.Methods("GET").Path("/degraded-objects").HandlerFunc(admin.DegradedObjectsHandler)
+	// Disks
+	adminRouter.Methods("GET").Path("/disks").HandlerFunc(admin.DisksHandler)
+	// DiskStats
+	adminRouter.Methods("GET").Path("/disk-stats").HandlerFunc(admin.DiskStatsHandler)
+	// FailureDomains
+	adminRouter.Methods("GET").Path("/failure-domains").HandlerFunc(admin.FailureDomainsHandler)
+	// Heal
+	adminRouter.Methods("POST").Path("/heal").HandlerFunc(admin.HealHandler).Queries("bucket", "{bucket:.+}")
+	// ExportChecksums
+	adminRouter.Methods("GET").Path("/checksums").HandlerFunc(admin.ExportChecksumsHandler).Queries("bucket", "{bucket:.+}")
+	// VerifyChecksums
+	adminRouter.Methods("POST").Path("/checksums").HandlerFunc(admin.VerifyChecksumsHandler)
+	// GetHealThrottle
+	adminRouter.Methods("GET").Path("/heal-throttle").HandlerFunc(admin.GetHealThrottleHandler)
+	// PutHealThrottle
+	adminRouter.Methods("PUT").Path("/heal-throttle").HandlerFunc(admin.PutHealThrottleHandler)
+	// EraseBucket
+	adminRouter.Methods("POST").Path("/erase-bucket").HandlerFunc(admin.EraseBucketHandler).Queries("bucket", "{bucket:.+}")
+	// Expire
+	adminRouter.Methods("POST").Path("/expire").HandlerFunc(admin.ExpireHandler)
+	// Demote
+	adminRouter.Methods("POST").Path("/demote").HandlerFunc(admin.DemoteHandler)
+
+	// Rebalance
+	adminRouter.Methods("POST").Path("/rebalance").HandlerFunc(admin.RebalanceHandler)
+	// RetentionHold
+	adminRouter.Methods("POST").Path("/retention-hold").HandlerFunc(admin.RetentionHoldHandler)
+	// ActiveRequests
+	adminRouter.Methods("GET").Path("/active-requests").HandlerFunc(admin.ActiveRequestsHandler)
+	// AbortActiveRequest
+	adminRouter.Methods("DELETE").Path("/active-requests").HandlerFunc(admin.AbortActiveRequestHandler).Queries("id", "{id:[0-9]+}")
+	// Locks
+	adminRouter.Methods("GET").Path("/locks").HandlerFunc(admin.LocksHandler)
+	// ForceReleaseLocks
+	adminRouter.Methods("DELETE").Path("/locks").HandlerFunc(admin.ForceReleaseLocksHandler)
+	// Update
+	adminRouter.Methods("POST").Path("/update").HandlerFunc(admin.UpdateHandler).Queries("url", "{url:.+}", "sha256", "{sha256:[0-9a-fA-F]{64}}")
+}
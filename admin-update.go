@@ -0,0 +1,241 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// Suffix of the downloaded binary waiting to be applied.
+	updateStagedSuffix = ".update"
+
+	// Maximum time to wait for a restarted node to come back online.
+	updateRestartTimeout = 2 * time.Minute
+
+	// Interval at which a restarted node is polled.
+	updatePollInterval = time.Second
+)
+
+// getBinaryPath - returns absolute path of the running binary.
+func getBinaryPath() (string, error) {
+	binaryPath, err := exec.LookPath(os.Args[0])
+	if err != nil {
+		return "", err
+	}
+	return filepath.Abs(binaryPath)
+}
+
+// getStagedUpdatePath - returns path of the staged update binary.
+func getStagedUpdatePath() (string, error) {
+	binaryPath, err := getBinaryPath()
+	if err != nil {
+		return "", err
+	}
+	return binaryPath + updateStagedSuffix, nil
+}
+
+// isUpdateStaged - returns true if an update binary has been staged.
+func isUpdateStaged() bool {
+	stagedPath, err := getStagedUpdatePath()
+	if err != nil {
+		return false
+	}
+	st, err := os.Stat(stagedPath)
+	return err == nil && st.Mode().IsRegular()
+}
+
+// stageUpdate - downloads the new binary next to the running binary
+// and verifies its sha256 checksum, the running binary is untouched.
+func stageUpdate(updateURL, sha256Hex string) error {
+	stagedPath, err := getStagedUpdatePath()
+	if err != nil {
+		return err
+	}
+	resp, err := http.Get(updateURL)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return errors.New("Unable to download update, " + resp.Status)
+	}
+	return writeStagedUpdate(stagedPath, resp.Body, sha256Hex)
+}
+
+// writeStagedUpdate - writes the binary to stagedPath, staged binary
+// is removed if the checksum doesn't match.
+func writeStagedUpdate(stagedPath string, reader io.Reader, sha256Hex string) error {
+	file, err := os.OpenFile(stagedPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0755)
+	if err != nil {
+		return err
+	}
+	hasher := sha256.New()
+	_, err = io.Copy(io.MultiWriter(file, hasher), reader)
+	file.Close()
+	if err != nil {
+		os.Remove(stagedPath)
+		return err
+	}
+	if hex.EncodeToString(hasher.Sum(nil)) != strings.ToLower(sha256Hex) {
+		os.Remove(stagedPath)
+		return errUpdateChecksumMismatch
+	}
+	return nil
+}
+
+// restartWithUpdate - replaces the running binary with the staged
+// update and re-executes the process with the same arguments.
+func restartWithUpdate() {
+	// Give enough time for the caller to receive its reply.
+	time.Sleep(time.Second)
+
+	binaryPath, err := getBinaryPath()
+	if err != nil {
+		errorIf(err, "Unable to find binary path.")
+		return
+	}
+	if err = os.Rename(binaryPath+updateStagedSuffix, binaryPath); err != nil {
+		errorIf(err, "Unable to apply staged update.")
+		return
+	}
+	err = syscall.Exec(binaryPath, os.Args, os.Environ())
+	errorIf(err, "Unable to restart with updated binary.")
+}
+
+// getWriteQuorum - returns the write quorum XL enforces for the total
+// number of disks, computed the same way for layouts not initialized.
+func getWriteQuorum(diskCount int) int {
+	if globalXLQuorum != nil && globalXLQuorum.Disks == diskCount {
+		return globalXLQuorum.WriteQuorum
+	}
+	readQuorum, writeQuorum := getConfiguredQuorum()
+	_, writeQuorum, err := getXLQuorum(diskCount, diskCount/2, readQuorum, writeQuorum)
+	if err != nil {
+		// Invalid configured quorum, XL refuses to start with it.
+		return diskCount
+	}
+	return writeQuorum
+}
+
+// isRestartSafe - returns true if taking targetDisks offline still
+// leaves enough onlineDisks to satisfy write quorum.
+func isRestartSafe(diskCount, onlineDisks, targetDisks int) bool {
+	// Single disk setups have no quorum to maintain.
+	if diskCount <= 1 {
+		return true
+	}
+	return onlineDisks-targetDisks >= getWriteQuorum(diskCount)
+}
+
+// getOnlineDiskCount - returns the number of disks on this node and
+// all reachable peers.
+func getOnlineDiskCount(args *PeerAuthArgs) int {
+	onlineDisks := globalDiskCount
+	for _, peer := range globalPeers {
+		onlineDisks -= peer.diskCount
+	}
+	for _, peer := range globalPeers {
+		if err := peer.Call("Peer.PingHandler", args, &PingPeerReply{}); err == nil {
+			onlineDisks += peer.diskCount
+		}
+	}
+	return onlineDisks
+}
+
+// waitForPeerRestart - waits until the peer reports a boot time later
+// than prevBootTime.
+func waitForPeerRestart(peer *peerClient, args *PeerAuthArgs, prevBootTime time.Time) error {
+	deadline := time.Now().Add(updateRestartTimeout)
+	for time.Now().Before(deadline) {
+		time.Sleep(updatePollInterval)
+		reply := PingPeerReply{}
+		if err := peer.Call("Peer.PingHandler", args, &reply); err == nil && reply.BootTime.After(prevBootTime) {
+			return nil
+		}
+	}
+	return errUpdateNodeOffline
+}
+
+// updateNodeStatus - rolling update status of a node.
+type updateNodeStatus struct {
+	Address string
+	Status  string
+}
+
+// rollingUpdate - downloads and verifies the new binary on all nodes,
+// then restarts peers one at a time waiting for each to come back
+// before proceeding. A node is only restarted if write quorum is
+// maintained without it, otherwise the update is aborted leaving the
+// remaining nodes on the current version. This node is restarted last.
+func rollingUpdate(updateURL, sha256Hex string) ([]updateNodeStatus, error) {
+	token, err := newPeerToken(serverConfig.GetCredential())
+	if err != nil {
+		return nil, err
+	}
+	authArgs := &PeerAuthArgs{Token: token}
+	updateArgs := &UpdatePeerArgs{Token: token, URL: updateURL, SHA256: sha256Hex}
+
+	// Download and verify on all nodes before restarting any of them.
+	if err = stageUpdate(updateURL, sha256Hex); err != nil {
+		return nil, err
+	}
+	for _, peer := range globalPeers {
+		if err = peer.Call("Peer.DownloadUpdateHandler", updateArgs, &GenericReply{}); err != nil {
+			return nil, errors.New(peer.addr + ": " + err.Error())
+		}
+	}
+
+	var nodes []updateNodeStatus
+	for _, peer := range globalPeers {
+		if !isRestartSafe(globalDiskCount, getOnlineDiskCount(authArgs), peer.diskCount) {
+			return nodes, errors.New(peer.addr + ": " + errUpdateQuorumLost.Error())
+		}
+		reply := PingPeerReply{}
+		if err = peer.Call("Peer.PingHandler", authArgs, &reply); err != nil {
+			return nodes, errors.New(peer.addr + ": " + err.Error())
+		}
+		if err = peer.Call("Peer.RestartHandler", authArgs, &GenericReply{}); err != nil {
+			return nodes, errors.New(peer.addr + ": " + err.Error())
+		}
+		if err = waitForPeerRestart(peer, authArgs, reply.BootTime); err != nil {
+			return nodes, errors.New(peer.addr + ": " + err.Error())
+		}
+		nodes = append(nodes, updateNodeStatus{Address: peer.addr, Status: "restarted"})
+	}
+
+	localDisks := globalDiskCount
+	for _, peer := range globalPeers {
+		localDisks -= peer.diskCount
+	}
+	if !isRestartSafe(globalDiskCount, getOnlineDiskCount(authArgs), localDisks) {
+		return nodes, errUpdateQuorumLost
+	}
+	go restartWithUpdate()
+	nodes = append(nodes, updateNodeStatus{Address: "local", Status: "restarting"})
+	return nodes, nil
+}
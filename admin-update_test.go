@@ -0,0 +1,124 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tests validate restart safety with respect to write quorum.
+func TestIsRestartSafe(t *testing.T) {
+	testCases := []struct {
+		diskCount   int
+		onlineDisks int
+		targetDisks int
+		expected    bool
+	}{
+		// Test case - 1.
+		// Single disk setup is always safe.
+		{1, 1, 1, true},
+		// Test case - 2.
+		// 16 disks over 4 nodes, all online, write quorum is 10.
+		{16, 16, 4, true},
+		// Test case - 3.
+		// One node already offline, restarting another leaves 8 disks.
+		{16, 12, 4, false},
+		// Test case - 4.
+		// 4 disks need all disks for write quorum.
+		{4, 4, 1, false},
+		// Test case - 5.
+		// 8 disks over 8 nodes, write quorum is 6.
+		{8, 7, 1, true},
+		{8, 6, 1, false},
+	}
+	for i, testCase := range testCases {
+		actual := isRestartSafe(testCase.diskCount, testCase.onlineDisks, testCase.targetDisks)
+		if actual != testCase.expected {
+			t.Errorf("Test %d: Expected %t, got %t", i+1, testCase.expected, actual)
+		}
+	}
+
+	// Effective quorum of XL takes precedence.
+	savedQuorum := globalXLQuorum
+	defer func() { globalXLQuorum = savedQuorum }()
+	globalXLQuorum = &xlQuorumInfo{Disks: 16, DataBlocks: 8, ParityBlocks: 8, ReadQuorum: 8, WriteQuorum: 12}
+	quorumCases := []struct {
+		diskCount   int
+		onlineDisks int
+		targetDisks int
+		expected    bool
+	}{
+		// Test case - 1.
+		// Restarting a node leaves 12 disks, write quorum is 12.
+		{16, 16, 4, true},
+		// Test case - 2.
+		// Restarting a node leaves 11 disks.
+		{16, 15, 4, false},
+		// Test case - 3.
+		// Other layouts are computed, write quorum is 6.
+		{8, 7, 1, true},
+	}
+	for i, testCase := range quorumCases {
+		actual := isRestartSafe(testCase.diskCount, testCase.onlineDisks, testCase.targetDisks)
+		if actual != testCase.expected {
+			t.Errorf("Test %d: Expected %t, got %t", i+1, testCase.expected, actual)
+		}
+	}
+}
+
+// Tests validate staged update checksum verification.
+func TestWriteStagedUpdate(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+
+	binary := []byte("minio binary")
+	stagedPath := filepath.Join(root, "minio"+updateStagedSuffix)
+
+	testCases := []struct {
+		sha256Hex   string
+		expectedErr error
+	}{
+		// Test case - 1.
+		// Checksum matches, binary is staged.
+		{"8adeb1687576c49bbfcef60592c14231b65298b547b21a14d50279b7b9ec920e", nil},
+		// Test case - 2.
+		// Upper case checksum is accepted.
+		{"8ADEB1687576C49BBFCEF60592C14231B65298B547B21A14D50279B7B9EC920E", nil},
+		// Test case - 3.
+		// Checksum mismatch, staged binary is removed.
+		{"a6d2f9ab7c2ee1c2c3d5a36a5e7ce4a0e3d3ac8e1ab2d5b34e0a4ae30b75c9b5", errUpdateChecksumMismatch},
+	}
+	for i, testCase := range testCases {
+		err = writeStagedUpdate(stagedPath, bytes.NewReader(binary), testCase.sha256Hex)
+		if err != testCase.expectedErr {
+			t.Fatalf("Test %d: Expected %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		_, err = os.Stat(stagedPath)
+		if testCase.expectedErr == nil && err != nil {
+			t.Errorf("Test %d: Expected staged binary, got %s", i+1, err)
+		}
+		if testCase.expectedErr != nil && !os.IsNotExist(err) {
+			t.Errorf("Test %d: Expected staged binary to be removed, got %v", i+1, err)
+		}
+	}
+}
@@ -102,6 +102,9 @@ const (
 	ErrInvalidQuerySignatureAlgo
 	ErrInvalidQueryParams
 	ErrBucketAlreadyOwnedByYou
+	ErrPreconditionFailed
+	ErrTooManyBuckets
+	ErrSlowDown
 	// Add new error codes here.
 
 	// Minio extended errors.
@@ -110,6 +113,64 @@ const (
 	ErrStorageFull
 	ErrObjectExistsAsDirectory
 	ErrPolicyNesting
+	ErrAdminUpdateAborted
+	ErrNoSuchBucketRewrite
+	ErrMalformedRewriteConfig
+	ErrAdminInvalidFaultRules
+	ErrNoSuchBucketSnapshot
+	ErrInvalidCloneSource
+	ErrBucketNameReserved
+	ErrAdminInvalidBucketLimits
+	ErrTooManyUploads
+	ErrPresignConstraintMismatch
+	ErrMalformedPresignConstraints
+	ErrObjectAlreadyExists
+	ErrMalformedOverwriteConfig
+	ErrMalformedDefaultsConfig
+	ErrInvalidExpiresAfter
+	ErrNoSuchBucketReplica
+	ErrMalformedReplicaConfig
+	ErrNoSuchBucketOrigin
+	ErrMalformedOriginConfig
+	ErrTenantQuotaExceeded
+	ErrAdminInvalidTenants
+	ErrAdminInvalidMeteringConfig
+	ErrInvalidMetadataFilter
+	ErrAdminInvalidMetadataQuery
+	ErrTooManyComposeSources
+	ErrComposeTiersMixed
+	ErrAdminInvalidPolicySimulation
+	ErrMalformedChecksumConfig
+	ErrMissingRequiredChecksum
+	ErrNoSuchActiveRequest
+	ErrAdminInvalidBucketRateHook
+	ErrAdminInvalidHealThrottle
+	ErrTooManyGetMultipleObjects
+	ErrInvalidCSEMetadata
+	ErrCSEObjectNotModifiable
+	ErrCSEMetadataNotSupported
+	ErrInvalidResumableSize
+	ErrInvalidResumableOffset
+	ErrContentSHA256Mismatch
+	ErrNoSuchBucketReplication
+	ErrMalformedReplicationConfig
+	ErrIncorrectContinuationToken
+	ErrAdminInvalidChecksumManifest
+	ErrAdminChecksumManifestSignature
+	ErrInvalidMetadataDirective
+	ErrInvalidObjectTags
+	ErrInvalidTaggingDirective
+	ErrNoSuchVersion
+	ErrIllegalVersioningConfig
+	ErrAdminInvalidLifecycleConfig
+	ErrObjectLocked
+	ErrInvalidObjectLockConfig
+	ErrNoSuchObjectLockConfig
+	ErrObjectLockNotEnabled
+	ErrInvalidObjectRetention
+	ErrNoSuchObjectRetention
+	ErrInvalidLegalHold
+	ErrAdminInvalidRetentionHold
 )
 
 // error code to APIError structure, these fields carry respective
@@ -117,7 +178,7 @@ const (
 var errorCodeResponse = map[APIErrorCode]APIError{
 	ErrInvalidCopyDest: {
 		Code:           "InvalidRequest",
-		Description:    "This copy request is illegal because it is trying to copy an object to itself.",
+		Description:    "This copy request is illegal because it is trying to copy an object to itself without changing the object's metadata.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
 	ErrInvalidCopySource: {
@@ -395,6 +456,21 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Your previous request to create the named bucket succeeded and you already own it.",
 		HTTPStatusCode: http.StatusConflict,
 	},
+	ErrPreconditionFailed: {
+		Code:           "PreconditionFailed",
+		Description:    "At least one of the pre-conditions you specified did not hold.",
+		HTTPStatusCode: http.StatusPreconditionFailed,
+	},
+	ErrTooManyBuckets: {
+		Code:           "TooManyBuckets",
+		Description:    "You have attempted to create more buckets than allowed.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrSlowDown: {
+		Code:           "SlowDown",
+		Description:    "Please reduce your request rate.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
 	/// Minio extensions.
 	ErrStorageFull: {
 		Code:           "XMinioStorageFull",
@@ -421,6 +497,296 @@ var errorCodeResponse = map[APIErrorCode]APIError{
 		Description:    "Policy nesting conflict has occurred.",
 		HTTPStatusCode: http.StatusConflict,
 	},
+	ErrAdminUpdateAborted: {
+		Code:           "XMinioAdminUpdateAborted",
+		Description:    "Rolling update was aborted, nodes which are not yet restarted continue running the current version.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	ErrNoSuchBucketRewrite: {
+		Code:           "XMinioNoSuchBucketRewrite",
+		Description:    "The bucket rewrite rules do not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrMalformedRewriteConfig: {
+		Code:           "XMinioMalformedRewriteConfig",
+		Description:    "The rewrite rules you provided are not well-formed or did not validate.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminInvalidFaultRules: {
+		Code:           "XMinioAdminInvalidFaultRules",
+		Description:    "The fault rules you provided are not well-formed or did not validate.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchBucketSnapshot: {
+		Code:           "XMinioNoSuchBucketSnapshot",
+		Description:    "The specified bucket snapshot does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidCloneSource: {
+		Code:           "XMinioInvalidCloneSource",
+		Description:    "Clone source must be of the form bucket/snapshot.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrBucketNameReserved: {
+		Code:           "XMinioBucketNameReserved",
+		Description:    "The specified bucket name is reserved and cannot be created.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminInvalidBucketLimits: {
+		Code:           "XMinioAdminInvalidBucketLimits",
+		Description:    "The bucket limits document is malformed or contains invalid limits.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrTooManyUploads: {
+		Code:           "XMinioTooManyUploads",
+		Description:    "Too many multipart uploads are in progress for this object, complete or abort some of them first.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	ErrPresignConstraintMismatch: {
+		Code:           "AccessDenied",
+		Description:    "Request does not satisfy the constraints of the presigned URL.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrMalformedPresignConstraints: {
+		Code:           "XMinioMalformedPresignConstraints",
+		Description:    "X-Minio-Max-Size must be a non-negative integer.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrObjectAlreadyExists: {
+		Code:           "XMinioObjectAlreadyExists",
+		Description:    "The object already exists and the bucket is protected against overwrites.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrMalformedOverwriteConfig: {
+		Code:           "XMinioMalformedOverwriteConfig",
+		Description:    "The overwrite protection config you provided is not well-formed.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrMalformedDefaultsConfig: {
+		Code:           "XMinioMalformedDefaultsConfig",
+		Description:    "The default metadata you provided is not well-formed or is not user defined metadata.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidExpiresAfter: {
+		Code:           "XMinioInvalidExpiresAfter",
+		Description:    "X-Amz-Expires-After must be a positive number of seconds.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchBucketReplica: {
+		Code:           "XMinioNoSuchBucketReplica",
+		Description:    "The bucket replica config does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrMalformedReplicaConfig: {
+		Code:           "XMinioMalformedReplicaConfig",
+		Description:    "The replica config you provided is not well-formed or has an invalid primary.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchBucketOrigin: {
+		Code:           "XMinioNoSuchBucketOrigin",
+		Description:    "The bucket origin config does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrMalformedOriginConfig: {
+		Code:           "XMinioMalformedOriginConfig",
+		Description:    "The origin config you provided is not well-formed or has an invalid URL or credentials.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrTenantQuotaExceeded: {
+		Code:           "XMinioTenantQuotaExceeded",
+		Description:    "The upload exceeds the storage quota of the tenant.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrAdminInvalidTenants: {
+		Code:           "XMinioAdminInvalidTenants",
+		Description:    "The tenants document is malformed or contains invalid or overlapping tenants.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminInvalidMeteringConfig: {
+		Code:           "XMinioAdminInvalidMeteringConfig",
+		Description:    "The metering config is malformed or has an invalid interval, format, bucket or URL.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidMetadataFilter: {
+		Code:           "XMinioInvalidMetadataFilter",
+		Description:    "The metadata filter is invalid, expected key or key=value.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminInvalidMetadataQuery: {
+		Code:           "XMinioAdminInvalidMetadataQuery",
+		Description:    "The metadata query is malformed or has an invalid predicate, sort order or marker.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrTooManyComposeSources: {
+		Code:           "XMinioTooManyComposeSources",
+		Description:    "Objects can be composed of at most 32 source objects.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrComposeTiersMixed: {
+		Code:           "XMinioComposeTiersMixed",
+		Description:    "Source objects live on different storage tiers, move them to one tier before composing.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminInvalidPolicySimulation: {
+		Code:           "XMinioAdminInvalidPolicySimulation",
+		Description:    "The policy simulation is malformed or has an unsupported action, invalid bucket or object name.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrMalformedChecksumConfig: {
+		Code:           "XMinioMalformedChecksumConfig",
+		Description:    "The required checksum config you provided is not well-formed or names an unsupported checksum.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrMissingRequiredChecksum: {
+		Code:           "XMinioMissingRequiredChecksum",
+		Description:    "Uploads to this bucket must carry the checksum required by the bucket.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchActiveRequest: {
+		Code:           "XMinioNoSuchActiveRequest",
+		Description:    "The specified request is not served by this node.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrAdminInvalidBucketRateHook: {
+		Code:           "XMinioAdminInvalidBucketRateHook",
+		Description:    "The bucket rate hook is malformed or has an invalid URL, window or threshold.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminInvalidHealThrottle: {
+		Code:           "XMinioAdminInvalidHealThrottle",
+		Description:    "The heal throttle is malformed or has a negative limit or an invalid client latency.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrTooManyGetMultipleObjects: {
+		Code:           "XMinioTooManyGetMultipleObjects",
+		Description:    "At most 1000 objects can be read with one request.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidCSEMetadata: {
+		Code:           "XMinioInvalidClientSideEncryption",
+		Description:    "The client side encryption metadata is incomplete or malformed, the object could not be decrypted.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrCSEObjectNotModifiable: {
+		Code:           "XMinioClientSideEncryptedObject",
+		Description:    "Client side encrypted objects cannot be patched or composed, they can only be replaced as a whole.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrCSEMetadataNotSupported: {
+		Code:           "XMinioClientSideEncryptionNotSupported",
+		Description:    "Client side encrypted objects cannot be saved, this backend does not save their metadata.",
+		HTTPStatusCode: http.StatusNotImplemented,
+	},
+	ErrInvalidResumableSize: {
+		Code:           "XMinioInvalidResumableSize",
+		Description:    "X-Minio-Resumable-Size is missing or invalid, it has to be the total size of the object.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidResumableOffset: {
+		Code:           "XMinioInvalidResumableOffset",
+		Description:    "The upload has to resume at the offset returned in X-Minio-Resumable-Offset.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	ErrContentSHA256Mismatch: {
+		Code:           "XAmzContentSHA256Mismatch",
+		Description:    "The provided 'x-amz-content-sha256' header does not match what was computed.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchBucketReplication: {
+		Code:           "XMinioNoSuchBucketReplication",
+		Description:    "The bucket replication config does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrMalformedReplicationConfig: {
+		Code:           "XMinioMalformedReplicationConfig",
+		Description:    "The replication config you provided is not well-formed or has an invalid target, credentials or timeout.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrIncorrectContinuationToken: {
+		Code:           "InvalidArgument",
+		Description:    "The continuation token provided is incorrect",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminInvalidChecksumManifest: {
+		Code:           "XMinioAdminInvalidChecksumManifest",
+		Description:    "The checksum manifest is malformed or has an unsupported version.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminChecksumManifestSignature: {
+		Code:           "XMinioAdminChecksumManifestSignature",
+		Description:    "The checksum manifest signature does not match, the manifest was altered or signed with other credentials.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrInvalidMetadataDirective: {
+		Code:           "InvalidArgument",
+		Description:    "Unknown metadata directive.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidObjectTags: {
+		Code:           "InvalidTag",
+		Description:    "The tags are malformed, there are at most 10 tags with keys of up to 128 and values of up to 256 characters.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidTaggingDirective: {
+		Code:           "InvalidArgument",
+		Description:    "Unknown tagging directive.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchVersion: {
+		Code:           "NoSuchVersion",
+		Description:    "The specified version does not exist.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrIllegalVersioningConfig: {
+		Code:           "IllegalVersioningConfigurationException",
+		Description:    "The versioning status must be Enabled or Suspended.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminInvalidLifecycleConfig: {
+		Code:           "XMinioAdminInvalidLifecycleConfig",
+		Description:    "The lifecycle configuration is malformed or has an invalid rule or evaluation time.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrObjectLocked: {
+		Code:           "AccessDenied",
+		Description:    "Access Denied because object protected by object lock.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
+	ErrInvalidObjectLockConfig: {
+		Code:           "InvalidArgument",
+		Description:    "Object lock must be Enabled, with a default retention in COMPLIANCE mode of either Days or Years.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchObjectLockConfig: {
+		Code:           "ObjectLockConfigurationNotFoundError",
+		Description:    "Object Lock configuration does not exist for this bucket.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrObjectLockNotEnabled: {
+		Code:           "InvalidRequest",
+		Description:    "Bucket is missing Object Lock Configuration.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrInvalidObjectRetention: {
+		Code:           "InvalidArgument",
+		Description:    "Retention must be in COMPLIANCE mode until a future date, and cannot be shortened.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrNoSuchObjectRetention: {
+		Code:           "NoSuchObjectLockConfiguration",
+		Description:    "The specified object does not have a ObjectLock configuration.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	ErrInvalidLegalHold: {
+		Code:           "InvalidArgument",
+		Description:    "Legal hold status must be ON or OFF.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ErrAdminInvalidRetentionHold: {
+		Code:           "XMinioAdminInvalidRetentionHold",
+		Description:    "The retention hold is malformed, holds nothing or has an invalid retention or time range.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	// Add your error structure here.
 }
 
@@ -435,6 +801,10 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 	if err == errSignatureMismatch {
 		return ErrSignatureDoesNotMatch
 	}
+	// Verify if the body exceeded the presigned maximum size.
+	if err == errPresignMaxSize {
+		return ErrEntityTooLarge
+	}
 	switch err.(type) {
 	case StorageFull:
 		apiErr = ErrStorageFull
@@ -466,6 +836,40 @@ func toAPIErrorCode(err error) (apiErr APIErrorCode) {
 		apiErr = ErrReadQuorum
 	case PartTooSmall:
 		apiErr = ErrEntityTooSmall
+	case BucketRewriteNotFound:
+		apiErr = ErrNoSuchBucketRewrite
+	case BucketReplicaNotFound:
+		apiErr = ErrNoSuchBucketReplica
+	case BucketOriginNotFound:
+		apiErr = ErrNoSuchBucketOrigin
+	case BucketReplicationNotFound:
+		apiErr = ErrNoSuchBucketReplication
+	case BucketSnapshotNotFound:
+		apiErr = ErrNoSuchBucketSnapshot
+	case TooManyUploads:
+		apiErr = ErrTooManyUploads
+	case ObjectAlreadyExists:
+		apiErr = ErrObjectAlreadyExists
+	case ObjectLocked:
+		apiErr = ErrObjectLocked
+	case TenantQuotaExceeded:
+		apiErr = ErrTenantQuotaExceeded
+	case ObjectPreconditionFailed:
+		apiErr = ErrPreconditionFailed
+	case InvalidRange:
+		apiErr = ErrInvalidRange
+	case ComposeTiersMixed:
+		apiErr = ErrComposeTiersMixed
+	case HealingNotSupported:
+		apiErr = ErrNotImplemented
+	case TaggingNotSupported:
+		apiErr = ErrNotImplemented
+	case VersionNotFound:
+		apiErr = ErrNoSuchVersion
+	case VersioningNotSupported:
+		apiErr = ErrNotImplemented
+	case CSEMetadataNotSupported:
+		apiErr = ErrCSEMetadataNotSupported
 	default:
 		apiErr = ErrInternalError
 	}
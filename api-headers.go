@@ -20,6 +20,7 @@ import (
 	"bytes"
 	"crypto/rand"
 	"encoding/xml"
+	"io"
 	"net/http"
 	"runtime"
 	"strconv"
@@ -57,6 +58,15 @@ func encodeResponse(response interface{}) []byte {
 	return bytesBuffer.Bytes()
 }
 
+// Encodes the response into XML format while writing to the writer,
+// the complete document is never held in memory.
+func encodeResponseStream(w io.Writer, response interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	return xml.NewEncoder(w).Encode(response)
+}
+
 // Write object header
 func setObjectHeaders(w http.ResponseWriter, objInfo ObjectInfo, contentRange *httpRange) {
 	// set common headers
@@ -67,19 +77,35 @@ func setObjectHeaders(w http.ResponseWriter, objInfo ObjectInfo, contentRange *h
 	w.Header().Set("Last-Modified", lastModified)
 
 	w.Header().Set("Content-Type", objInfo.ContentType)
+	if objInfo.ContentEncoding != "" {
+		w.Header().Set("Content-Encoding", objInfo.ContentEncoding)
+	}
+	if objInfo.CacheControl != "" {
+		w.Header().Set("Cache-Control", objInfo.CacheControl)
+	}
 	if objInfo.MD5Sum != "" {
 		w.Header().Set("ETag", "\""+objInfo.MD5Sum+"\"")
 	}
+	if objInfo.VersionID != "" {
+		w.Header().Set(amzVersionIDHeader, objInfo.VersionID)
+	}
+
+	// Set user defined metadata.
+	for key, value := range objInfo.UserDefined {
+		w.Header().Set(key, value)
+	}
+	// Tags are read with the tagging subresource, only their number
+	// is sent.
+	if len(objInfo.Tags) > 0 {
+		w.Header().Set(amzTaggingCountHeader, strconv.Itoa(len(objInfo.Tags)))
+	}
 
 	w.Header().Set("Content-Length", strconv.FormatInt(objInfo.Size, 10))
 
 	// for providing ranged content
-	if contentRange != nil {
-		if contentRange.start > 0 || contentRange.length > 0 {
-			// override content-length
-			w.Header().Set("Content-Length", strconv.FormatInt(contentRange.length, 10))
-			w.Header().Set("Content-Range", contentRange.String())
-			w.WriteHeader(http.StatusPartialContent)
-		}
+	if contentRange.isPartial() {
+		// override content-length
+		w.Header().Set("Content-Length", strconv.FormatInt(contentRange.length, 10))
+		w.Header().Set("Content-Range", contentRange.String())
 	}
 }
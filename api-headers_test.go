@@ -17,6 +17,12 @@
 package main
 
 import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"sort"
 	"testing"
 )
 
@@ -38,3 +44,102 @@ func TestGenerateRequestID(t *testing.T) {
 		}
 	}
 }
+
+// Tests validate streamed XML encoding matches buffered encoding.
+func TestEncodeResponseStream(t *testing.T) {
+	resp := ListObjectsInfo{
+		IsTruncated: true,
+		NextMarker:  "object2",
+		Objects: []ObjectInfo{
+			{Name: "object1", Size: 10, MD5Sum: "abcd"},
+			{Name: "object2", Size: 20, MD5Sum: "efgh"},
+		},
+		Prefixes: []string{"prefix/"},
+	}
+	response := generateListObjectsResponse("bucket", "", "", "/", 2, resp, false)
+
+	var buffer bytes.Buffer
+	if err := encodeResponseStream(&buffer, response); err != nil {
+		t.Fatalf("Unable to stream response. %s", err)
+	}
+	if !bytes.Equal(buffer.Bytes(), encodeResponse(response)) {
+		t.Errorf("Expected %s, got %s", encodeResponse(response), buffer.Bytes())
+	}
+}
+
+// Wrapper for calling streamed listing tests for both XL multiple
+// disks and single node setup.
+func TestWriteListObjectsStream(t *testing.T) {
+	ExecObjectLayerTest(t, testWriteListObjectsStream)
+}
+
+// Tests validate listings of more than a page of the object layer are
+// streamed in full.
+func testWriteListObjectsStream(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "streamed-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	var objects []string
+	for i := 0; i < 1500; i++ {
+		object := fmt.Sprintf("dir%d/object%04d", i%2, i)
+		if _, err := obj.PutObject(bucket, object, 0, bytes.NewReader(nil), nil); err != nil {
+			t.Fatalf("%s : %s", instanceType, err.Error())
+		}
+		objects = append(objects, object)
+	}
+	sort.Strings(objects)
+
+	testCases := []struct {
+		marker          string
+		delimiter       string
+		maxKeys         int
+		expectedKeys    []string
+		expectedPrefix  []string
+		expectTruncated bool
+	}{
+		// Test case - 1.
+		// All objects are listed beyond a single page.
+		{"", "", 2000, objects, nil, false},
+		// Test case - 2.
+		// Listing is truncated at max-keys spanning pages.
+		{"", "", 1200, objects[:1200], nil, true},
+		// Test case - 3.
+		// Listing continues after the marker.
+		{objects[1199], "", 2000, objects[1200:], nil, false},
+		// Test case - 4.
+		// Common prefixes are listed once.
+		{"", "/", 2000, nil, []string{"dir0/", "dir1/"}, false},
+	}
+	for i, testCase := range testCases {
+		rec := httptest.NewRecorder()
+		if err := writeListObjectsStream(rec, obj, bucket, "", testCase.marker, testCase.delimiter, testCase.maxKeys, false); err != nil {
+			t.Fatalf("%s: Test %d: Unable to stream listing. %s", instanceType, i+1, err)
+		}
+		response := ListObjectsResponse{}
+		if err := xml.Unmarshal(rec.Body.Bytes(), &response); err != nil {
+			t.Fatalf("%s: Test %d: Unable to parse listing. %s", instanceType, i+1, err)
+		}
+		var keys, prefixes []string
+		for _, content := range response.Contents {
+			keys = append(keys, content.Key)
+		}
+		for _, commonPrefix := range response.CommonPrefixes {
+			prefixes = append(prefixes, commonPrefix.Prefix)
+		}
+		if !reflect.DeepEqual(keys, testCase.expectedKeys) {
+			t.Errorf("%s: Test %d: Expected %d keys, got %d", instanceType, i+1, len(testCase.expectedKeys), len(keys))
+		}
+		if !reflect.DeepEqual(prefixes, testCase.expectedPrefix) {
+			t.Errorf("%s: Test %d: Expected prefixes %v, got %v", instanceType, i+1, testCase.expectedPrefix, prefixes)
+		}
+		if response.IsTruncated != testCase.expectTruncated || response.MaxKeys != testCase.maxKeys || response.Name != bucket {
+			t.Errorf("%s: Test %d: Expected truncated %t, max-keys %d of %s, got %t, %d of %s", instanceType, i+1, testCase.expectTruncated, testCase.maxKeys, bucket, response.IsTruncated, response.MaxKeys, response.Name)
+		}
+	}
+
+	// Errors listing the first page are returned.
+	if err := writeListObjectsStream(httptest.NewRecorder(), obj, "missing-bucket", "", "", "", 1000, false); err == nil {
+		t.Errorf("%s: Expected listing a missing bucket to fail", instanceType)
+	}
+}
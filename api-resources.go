@@ -17,6 +17,7 @@
 package main
 
 import (
+	"encoding/base64"
 	"net/url"
 	"strconv"
 )
@@ -36,10 +37,11 @@ func getListObjectsV1Args(values url.Values) (prefix, marker, delimiter string,
 }
 
 // Parse bucket url queries for ListObjects V2.
-func getListObjectsV2Args(values url.Values) (prefix, token, startAfter, delimiter string, maxkeys int, encodingType string) {
+func getListObjectsV2Args(values url.Values) (prefix, token, startAfter, delimiter string, fetchOwner bool, maxkeys int, encodingType string) {
 	prefix = values.Get("prefix")
 	startAfter = values.Get("start-after")
 	delimiter = values.Get("delimiter")
+	fetchOwner = values.Get("fetch-owner") == "true"
 	if values.Get("max-keys") != "" {
 		maxkeys, _ = strconv.Atoi(values.Get("max-keys"))
 	} else {
@@ -50,6 +52,33 @@ func getListObjectsV2Args(values url.Values) (prefix, token, startAfter, delimit
 	return
 }
 
+// Parse bucket url queries for ListObjectVersions.
+func getListObjectVersionsArgs(values url.Values) (prefix, keyMarker, versionIDMarker, delimiter string, maxkeys int) {
+	prefix = values.Get("prefix")
+	keyMarker = values.Get("key-marker")
+	versionIDMarker = values.Get("version-id-marker")
+	delimiter = values.Get("delimiter")
+	if values.Get("max-keys") != "" {
+		maxkeys, _ = strconv.Atoi(values.Get("max-keys"))
+	} else {
+		maxkeys = maxObjectList
+	}
+	return
+}
+
+// Continuation tokens of ListObjects V2 are opaque to clients, they
+// encode the name of the last entry listed before them.
+func encodeContinuationToken(name string) string {
+	return base64.StdEncoding.EncodeToString([]byte(name))
+}
+
+// decodeContinuationToken - returns name encoded by the continuation
+// token, empty for an empty token.
+func decodeContinuationToken(token string) (string, error) {
+	name, err := base64.StdEncoding.DecodeString(token)
+	return string(name), err
+}
+
 // Parse bucket url queries
 func getBucketResources(values url.Values) (listType int, prefix, marker, delimiter string, maxkeys int, encodingType string) {
 	if values.Get("list-type") != "" {
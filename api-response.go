@@ -20,14 +20,17 @@ import (
 	"encoding/xml"
 	"net/http"
 	"path"
+	"sort"
 	"time"
 )
 
 const (
 	timeFormatAMZ  = "2006-01-02T15:04:05.000Z" // Reply date format
 	maxObjectList  = 1000                       // Limit number of objects in a listObjectsResponse.
+	maxStreamList  = 100000                     // Limit number of objects in a streamed listObjectsResponse.
 	maxUploadsList = 1000                       // Limit number of uploads in a listUploadsResponse.
 	maxPartsList   = 1000                       // Limit number of parts in a listPartsResponse.
+	bucketOwnerID  = "minio"                    // Owner ID of all buckets.
 )
 
 // LocationResponse - format for location response.
@@ -81,19 +84,19 @@ type ListObjectsV2Response struct {
 	// A flag that indicates whether or not ListObjects returned all of the results
 	// that satisfied the search criteria.
 	IsTruncated bool
-	StartAfter  string
+	StartAfter  string `xml:",omitempty"`
 	MaxKeys     int
 	Name        string
 
-	// When response is truncated (the IsTruncated element value in the response
-	// is true), you can use the key name in this field as marker in the subsequent
-	// request to get next set of objects. Server lists objects in alphabetical
-	// order Note: This element is returned only if you have delimiter request parameter
-	// specified. If response does not include the NextMaker and it is truncated,
-	// you can use the value of the last Key in the response as the marker in the
-	// subsequent request to get the next set of object keys.
-	ContinuationToken     string
-	NextContinuationToken string
+	// Number of objects and common prefixes in the response.
+	KeyCount int
+
+	// Continuation token of the request, if any. When response is
+	// truncated (the IsTruncated element value in the response is
+	// true), NextContinuationToken is sent as continuation-token of
+	// the subsequent request to get the next set of objects.
+	ContinuationToken     string `xml:",omitempty"`
+	NextContinuationToken string `xml:",omitempty"`
 	Prefix                string
 }
 
@@ -184,10 +187,31 @@ type Object struct {
 	LastModified string // time string of format "2006-01-02T15:04:05.000Z"
 	Size         int64
 
-	Owner Owner
+	// Owner of the object, not sent by ListObjects V2 unless
+	// fetch-owner is set.
+	Owner *Owner `xml:",omitempty"`
 
 	// The class of storage used to store the object.
 	StorageClass string
+
+	// Metadata of the object if requested, Minio extension.
+	UserMetadata *UserMetadata `xml:",omitempty"`
+
+	// Client side encryption version of the object, "v1" or "v2",
+	// empty if not encrypted by the client. Minio extension.
+	ClientSideEncryption string `xml:",omitempty"`
+}
+
+// UserMetadata container for metadata headers of a listed object, as
+// returned by HEAD Object. Minio extension.
+type UserMetadata struct {
+	Items []MetadataItem `xml:"Item"`
+}
+
+// MetadataItem container for a metadata header and its value.
+type MetadataItem struct {
+	Key   string
+	Value string
 }
 
 // CopyObjectResponse container returns ETag and LastModified of the
@@ -198,6 +222,40 @@ type CopyObjectResponse struct {
 	LastModified string // time string of format "2006-01-02T15:04:05.000Z"
 }
 
+// ComposeObjectResponse container returns ETag and LastModified of the
+// composed object.
+type ComposeObjectResponse struct {
+	XMLName      xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ComposeObjectResult" json:"-"`
+	ETag         string
+	LastModified string // time string of format "2006-01-02T15:04:05.000Z"
+}
+
+// ObjectProvenance container for deployment, node and time an object
+// was written at, Minio extension.
+type ObjectProvenance struct {
+	DeploymentID string `xml:"DeploymentId"`
+	Node         string
+	WriteTime    string // time string of format "2006-01-02T15:04:05.000Z"
+}
+
+// GetObjectAttributesResponse container returns requested attributes
+// of an object.
+type GetObjectAttributesResponse struct {
+	XMLName      xml.Name          `xml:"http://s3.amazonaws.com/doc/2006-03-01/ GetObjectAttributesResponse" json:"-"`
+	ETag         string            `xml:",omitempty"`
+	ObjectSize   *int64            `xml:",omitempty"`
+	StorageClass string            `xml:",omitempty"`
+	Provenance   *ObjectProvenance `xml:",omitempty"`
+}
+
+// CopyObjectPartResponse container returns ETag and LastModified of the
+// successfully copied object part
+type CopyObjectPartResponse struct {
+	XMLName      xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CopyPartResult" json:"-"`
+	LastModified string   // time string of format "2006-01-02T15:04:05.000Z"
+	ETag         string
+}
+
 // Initiator inherit from Owner struct, fields are same
 type Initiator Owner
 
@@ -275,7 +333,7 @@ func generateListBucketsResponse(buckets []BucketInfo) ListBucketsResponse {
 	var data = ListBucketsResponse{}
 	var owner = Owner{}
 
-	owner.ID = "minio"
+	owner.ID = bucketOwnerID
 	owner.DisplayName = "minio"
 
 	for _, bucket := range buckets {
@@ -291,14 +349,39 @@ func generateListBucketsResponse(buckets []BucketInfo) ListBucketsResponse {
 	return data
 }
 
+// generateUserMetadata - returns metadata headers of a listed object
+// sorted by name.
+func generateUserMetadata(objInfo ObjectInfo) *UserMetadata {
+	headers := map[string]string{
+		"Content-Type":     objInfo.ContentType,
+		"Content-Encoding": objInfo.ContentEncoding,
+		"Cache-Control":    objInfo.CacheControl,
+	}
+	for key, value := range objInfo.UserDefined {
+		headers[key] = value
+	}
+	var keys []string
+	for key, value := range headers {
+		if value != "" {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	metadata := &UserMetadata{}
+	for _, key := range keys {
+		metadata.Items = append(metadata.Items, MetadataItem{Key: key, Value: headers[key]})
+	}
+	return metadata
+}
+
 // generates an ListObjects response for the said bucket with other enumerated options.
-func generateListObjectsResponse(bucket, prefix, marker, delimiter string, maxKeys int, resp ListObjectsInfo) ListObjectsResponse {
+func generateListObjectsResponse(bucket, prefix, marker, delimiter string, maxKeys int, resp ListObjectsInfo, withMetadata bool) ListObjectsResponse {
 	var contents []Object
 	var prefixes []CommonPrefix
 	var owner = Owner{}
 	var data = ListObjectsResponse{}
 
-	owner.ID = "minio"
+	owner.ID = bucketOwnerID
 	owner.DisplayName = "minio"
 
 	for _, object := range resp.Objects {
@@ -313,7 +396,11 @@ func generateListObjectsResponse(bucket, prefix, marker, delimiter string, maxKe
 		}
 		content.Size = object.Size
 		content.StorageClass = "STANDARD"
-		content.Owner = owner
+		content.Owner = &owner
+		content.ClientSideEncryption = getCSEVersion(object.UserDefined)
+		if withMetadata {
+			content.UserMetadata = generateUserMetadata(object)
+		}
 		contents = append(contents, content)
 	}
 	// TODO - support EncodingType in xml decoding
@@ -336,14 +423,14 @@ func generateListObjectsResponse(bucket, prefix, marker, delimiter string, maxKe
 	return data
 }
 
-// generates an ListObjects response for the said bucket with other enumerated options.
-func generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter string, maxKeys int, resp ListObjectsInfo) ListObjectsV2Response {
+// generates an ListObjects V2 response for the said bucket with other enumerated options.
+func generateListObjectsV2Response(bucket, prefix, startAfter, delimiter string, maxKeys int, fetchOwner bool, resp ListObjectsV2Info, withMetadata bool) ListObjectsV2Response {
 	var contents []Object
 	var prefixes []CommonPrefix
 	var owner = Owner{}
 	var data = ListObjectsV2Response{}
 
-	owner.ID = "minio"
+	owner.ID = bucketOwnerID
 	owner.DisplayName = "minio"
 
 	for _, object := range resp.Objects {
@@ -358,7 +445,13 @@ func generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter
 		}
 		content.Size = object.Size
 		content.StorageClass = "STANDARD"
-		content.Owner = owner
+		if fetchOwner {
+			content.Owner = &owner
+		}
+		content.ClientSideEncryption = getCSEVersion(object.UserDefined)
+		if withMetadata {
+			content.UserMetadata = generateUserMetadata(object)
+		}
 		contents = append(contents, content)
 	}
 	// TODO - support EncodingType in xml decoding
@@ -369,8 +462,12 @@ func generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter
 	data.Delimiter = delimiter
 	data.Prefix = prefix
 	data.MaxKeys = maxKeys
-	data.ContinuationToken = token
-	data.NextContinuationToken = resp.NextMarker
+	if resp.ContinuationToken != "" {
+		data.ContinuationToken = encodeContinuationToken(resp.ContinuationToken)
+	}
+	if resp.NextContinuationToken != "" {
+		data.NextContinuationToken = encodeContinuationToken(resp.NextContinuationToken)
+	}
 	data.IsTruncated = resp.IsTruncated
 	for _, prefix := range resp.Prefixes {
 		var prefixItem = CommonPrefix{}
@@ -378,6 +475,7 @@ func generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter
 		prefixes = append(prefixes, prefixItem)
 	}
 	data.CommonPrefixes = prefixes
+	data.KeyCount = len(contents) + len(prefixes)
 	return data
 }
 
@@ -389,6 +487,51 @@ func generateCopyObjectResponse(etag string, lastModified time.Time) CopyObjectR
 	}
 }
 
+// generateComposeObjectResponse
+func generateComposeObjectResponse(etag string, lastModified time.Time) ComposeObjectResponse {
+	return ComposeObjectResponse{
+		ETag:         "\"" + etag + "\"",
+		LastModified: lastModified.UTC().Format(timeFormatAMZ),
+	}
+}
+
+// generateGetObjectAttributesResponse - returns the requested
+// attributes, all attributes if none are requested.
+func generateGetObjectAttributesResponse(objInfo ObjectInfo, attributes []string) GetObjectAttributesResponse {
+	requested := make(map[string]bool)
+	for _, attribute := range attributes {
+		requested[attribute] = true
+	}
+	all := len(requested) == 0
+	response := GetObjectAttributesResponse{}
+	if (all || requested["ETag"]) && objInfo.MD5Sum != "" {
+		response.ETag = objInfo.MD5Sum
+	}
+	if all || requested["ObjectSize"] {
+		size := objInfo.Size
+		response.ObjectSize = &size
+	}
+	if all || requested["StorageClass"] {
+		response.StorageClass = storageClassStandard
+	}
+	if (all || requested["Provenance"]) && objInfo.Provenance.DeploymentID != "" {
+		response.Provenance = &ObjectProvenance{
+			DeploymentID: objInfo.Provenance.DeploymentID,
+			Node:         objInfo.Provenance.Node,
+			WriteTime:    objInfo.Provenance.WriteTime.UTC().Format(timeFormatAMZ),
+		}
+	}
+	return response
+}
+
+// generateCopyObjectPartResponse
+func generateCopyObjectPartResponse(etag string, lastModified time.Time) CopyObjectPartResponse {
+	return CopyObjectPartResponse{
+		ETag:         "\"" + etag + "\"",
+		LastModified: lastModified.UTC().Format(timeFormatAMZ),
+	}
+}
+
 // generateInitiateMultipartUploadResponse
 func generateInitiateMultipartUploadResponse(bucket, key, uploadID string) InitiateMultipartUploadResponse {
 	return InitiateMultipartUploadResponse{
@@ -416,9 +559,9 @@ func generateListPartsResponse(partsInfo ListPartsInfo) ListPartsResponse {
 	listPartsResponse.Key = partsInfo.Object
 	listPartsResponse.UploadID = partsInfo.UploadID
 	listPartsResponse.StorageClass = "STANDARD"
-	listPartsResponse.Initiator.ID = "minio"
+	listPartsResponse.Initiator.ID = bucketOwnerID
 	listPartsResponse.Initiator.DisplayName = "minio"
-	listPartsResponse.Owner.ID = "minio"
+	listPartsResponse.Owner.ID = bucketOwnerID
 	listPartsResponse.Owner.DisplayName = "minio"
 
 	listPartsResponse.MaxParts = partsInfo.MaxParts
@@ -489,6 +632,110 @@ func writeSuccessResponse(w http.ResponseWriter, response []byte) {
 	w.(http.Flusher).Flush()
 }
 
+// writeSuccessResponseStream write success headers and stream XML
+// encoded response, since the length is not known in advance the
+// response is sent with chunked transfer encoding.
+func writeSuccessResponseStream(w http.ResponseWriter, response interface{}) {
+	setCommonHeaders(w)
+	if err := encodeResponseStream(w, response); err != nil {
+		errorIf(err, "Unable to stream response.")
+		return
+	}
+	w.(http.Flusher).Flush()
+}
+
+// writeListObjectsStream - writes a ListObjects response while listing
+// objects, pages of the object layer are encoded as soon as they are
+// listed and only one page is held in memory. Errors listing the first
+// page are returned with nothing written, later errors end the
+// response early.
+func writeListObjectsStream(w http.ResponseWriter, objAPI ObjectLayer, bucket, prefix, marker, delimiter string, maxKeys int, withMetadata bool) error {
+	pageKeys := maxKeys
+	if pageKeys > maxObjectList {
+		pageKeys = maxObjectList
+	}
+	result, err := objAPI.ListObjects(bucket, prefix, marker, delimiter, pageKeys)
+	if err != nil {
+		return err
+	}
+
+	setCommonHeaders(w)
+	if _, err = w.Write([]byte(xml.Header)); err != nil {
+		return nil
+	}
+	encoder := xml.NewEncoder(w)
+	root := xml.StartElement{Name: xml.Name{Space: "http://s3.amazonaws.com/doc/2006-03-01/", Local: "ListBucketResult"}}
+	if err = encoder.EncodeToken(root); err != nil {
+		return nil
+	}
+	remaining := maxKeys
+	for {
+		page := generateListObjectsResponse(bucket, prefix, marker, delimiter, maxKeys, result, withMetadata)
+		for _, commonPrefix := range page.CommonPrefixes {
+			if err = encoder.EncodeElement(commonPrefix, xml.StartElement{Name: xml.Name{Local: "CommonPrefixes"}}); err != nil {
+				return nil
+			}
+		}
+		for _, content := range page.Contents {
+			if err = encoder.EncodeElement(content, xml.StartElement{Name: xml.Name{Local: "Contents"}}); err != nil {
+				return nil
+			}
+		}
+		if err = encoder.Flush(); err != nil {
+			return nil
+		}
+		w.(http.Flusher).Flush()
+
+		listed := len(result.Objects) + len(result.Prefixes)
+		remaining -= listed
+		if !result.IsTruncated || remaining <= 0 || listed == 0 {
+			break
+		}
+		pageMarker := result.NextMarker
+		if len(result.Objects) > 0 && result.Objects[len(result.Objects)-1].Name > pageMarker {
+			pageMarker = result.Objects[len(result.Objects)-1].Name
+		}
+		if remaining < pageKeys {
+			pageKeys = remaining
+		}
+		nextResult, err := objAPI.ListObjects(bucket, prefix, pageMarker, delimiter, pageKeys)
+		if err != nil {
+			errorIf(err, "Unable to list objects.")
+			return nil
+		}
+		// The response carries the marker of the last page.
+		if nextResult.NextMarker == "" {
+			nextResult.NextMarker = result.NextMarker
+		}
+		result = nextResult
+	}
+
+	for _, element := range []struct {
+		name  string
+		value interface{}
+	}{
+		{"Delimiter", delimiter},
+		{"EncodingType", ""},
+		{"IsTruncated", result.IsTruncated},
+		{"Marker", marker},
+		{"MaxKeys", maxKeys},
+		{"Name", bucket},
+		{"NextMarker", result.NextMarker},
+		{"Prefix", prefix},
+	} {
+		if err = encoder.EncodeElement(element.value, xml.StartElement{Name: xml.Name{Local: element.name}}); err != nil {
+			return nil
+		}
+	}
+	if err = encoder.EncodeToken(root.End()); err != nil {
+		return nil
+	}
+	if err = encoder.Flush(); err == nil {
+		w.(http.Flusher).Flush()
+	}
+	return nil
+}
+
 // writeSuccessNoContent write success headers with http status 204
 func writeSuccessNoContent(w http.ResponseWriter) {
 	setCommonHeaders(w)
@@ -500,6 +747,12 @@ func writeErrorResponse(w http.ResponseWriter, req *http.Request, errorCode APIE
 	error := getAPIError(errorCode)
 	// set common headers
 	setCommonHeaders(w)
+	// Name the check denying the request, for debugging.
+	if globalDebugDenials {
+		if reason := getDenialReason(req, errorCode); reason != "" {
+			w.Header().Set(denialReasonHeader, reason)
+		}
+	}
 	// write Header
 	w.WriteHeader(error.HTTPStatusCode)
 	writeErrorResponseNoHeader(w, req, error, resource)
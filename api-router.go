@@ -33,24 +33,50 @@ func registerAPIRouter(mux *router.Router, api objectAPIHandlers) {
 
 	/// Object operations
 
+	// ResumableUploadOffset
+	bucket.Methods("HEAD").Path("/{object:.+}").HandlerFunc(api.ResumableUploadOffsetHandler).Queries("resumable", "{resumable:.+}")
 	// HeadObject
 	bucket.Methods("HEAD").Path("/{object:.+}").HandlerFunc(api.HeadObjectHandler)
+	// CopyObjectPart
+	bucket.Methods("PUT").Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".*?(\\/).*?").HandlerFunc(api.CopyObjectPartHandler).Queries("partNumber", "{partNumber:[0-9]+}", "uploadId", "{uploadId:.*}")
 	// PutObjectPart
 	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.PutObjectPartHandler).Queries("partNumber", "{partNumber:[0-9]+}", "uploadId", "{uploadId:.*}")
 	// ListObjectPxarts
 	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.ListObjectPartsHandler).Queries("uploadId", "{uploadId:.*}")
 	// CompleteMultipartUpload
 	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(api.CompleteMultipartUploadHandler).Queries("uploadId", "{uploadId:.*}")
+	// ComposeObject
+	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(api.ComposeObjectHandler).Queries("compose", "")
 	// NewMultipartUpload
 	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(api.NewMultipartUploadHandler).Queries("uploads", "")
 	// AbortMultipartUpload
 	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(api.AbortMultipartUploadHandler).Queries("uploadId", "{uploadId:.*}")
+	// GetObjectAttributes
+	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectAttributesHandler).Queries("attributes", "")
+	// GetObjectRetention
+	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectRetentionHandler).Queries("retention", "")
+	// GetObjectLegalHold
+	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectLegalHoldHandler).Queries("legal-hold", "")
+	// GetObjectTagging
+	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectTaggingHandler).Queries("tagging", "")
 	// GetObject
 	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(api.GetObjectHandler)
+	// ResumablePutObject
+	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.ResumablePutObjectHandler).Queries("resumable", "{resumable:.+}")
+	// PatchObject
+	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.PatchObjectHandler).Queries("patch", "{offset:.*}")
+	// PutObjectRetention
+	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.PutObjectRetentionHandler).Queries("retention", "")
+	// PutObjectLegalHold
+	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.PutObjectLegalHoldHandler).Queries("legal-hold", "")
+	// PutObjectTagging
+	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.PutObjectTaggingHandler).Queries("tagging", "")
 	// CopyObject
 	bucket.Methods("PUT").Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".*?(\\/).*?").HandlerFunc(api.CopyObjectHandler)
 	// PutObject
 	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(api.PutObjectHandler)
+	// DeleteObjectTagging
+	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(api.DeleteObjectTaggingHandler).Queries("tagging", "")
 	// DeleteObject
 	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(api.DeleteObjectHandler)
 
@@ -60,22 +86,82 @@ func registerAPIRouter(mux *router.Router, api objectAPIHandlers) {
 	bucket.Methods("GET").HandlerFunc(api.GetBucketLocationHandler).Queries("location", "")
 	// GetBucketPolicy
 	bucket.Methods("GET").HandlerFunc(api.GetBucketPolicyHandler).Queries("policy", "")
+	// GetBucketChecksum
+	bucket.Methods("GET").HandlerFunc(api.GetBucketChecksumHandler).Queries("checksum", "")
+	// GetBucketDefaults
+	bucket.Methods("GET").HandlerFunc(api.GetBucketDefaultsHandler).Queries("defaults", "")
+	// GetBucketOrigin
+	bucket.Methods("GET").HandlerFunc(api.GetBucketOriginHandler).Queries("origin", "")
+	// GetBucketObjectLockConfig
+	bucket.Methods("GET").HandlerFunc(api.GetBucketObjectLockConfigHandler).Queries("object-lock", "")
+	// GetBucketOverwrite
+	bucket.Methods("GET").HandlerFunc(api.GetBucketOverwriteHandler).Queries("overwrite", "")
+	// GetBucketReplication
+	bucket.Methods("GET").HandlerFunc(api.GetBucketReplicationHandler).Queries("replicate", "")
+	// GetBucketReplica
+	bucket.Methods("GET").HandlerFunc(api.GetBucketReplicaHandler).Queries("replica", "")
+	// GetBucketRewrite
+	bucket.Methods("GET").HandlerFunc(api.GetBucketRewriteHandler).Queries("rewrite", "")
+	// GetBucketVersioning
+	bucket.Methods("GET").HandlerFunc(api.GetBucketVersioningHandler).Queries("versioning", "")
+	// ListObjectVersions
+	bucket.Methods("GET").HandlerFunc(api.ListObjectVersionsHandler).Queries("versions", "")
+	// ListBucketSnapshots
+	bucket.Methods("GET").HandlerFunc(api.ListBucketSnapshotsHandler).Queries("snapshot", "")
 	// ListMultipartUploads
 	bucket.Methods("GET").HandlerFunc(api.ListMultipartUploadsHandler).Queries("uploads", "")
+	// ListObjectsV2
+	bucket.Methods("GET").HandlerFunc(api.ListObjectsV2Handler).Queries("list-type", "2")
 	// ListObjects
 	bucket.Methods("GET").HandlerFunc(api.ListObjectsHandler)
 	// PutBucketPolicy
 	bucket.Methods("PUT").HandlerFunc(api.PutBucketPolicyHandler).Queries("policy", "")
+	// PutBucketChecksum
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketChecksumHandler).Queries("checksum", "")
+	// PutBucketDefaults
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketDefaultsHandler).Queries("defaults", "")
+	// PutBucketOrigin
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketOriginHandler).Queries("origin", "")
+	// PutBucketObjectLockConfig
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketObjectLockConfigHandler).Queries("object-lock", "")
+	// PutBucketOverwrite
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketOverwriteHandler).Queries("overwrite", "")
+	// PutBucketReplication
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketReplicationHandler).Queries("replicate", "")
+	// PutBucketReplica
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketReplicaHandler).Queries("replica", "")
+	// PutBucketRewrite
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketRewriteHandler).Queries("rewrite", "")
+	// PutBucketVersioning
+	bucket.Methods("PUT").HandlerFunc(api.PutBucketVersioningHandler).Queries("versioning", "")
+	// CreateBucketSnapshot
+	bucket.Methods("PUT").HandlerFunc(api.CreateBucketSnapshotHandler).Queries("snapshot", "")
+	// CloneBucketSnapshot
+	bucket.Methods("PUT").HandlerFunc(api.CloneBucketSnapshotHandler).Queries("clone", "")
 	// PutBucket
 	bucket.Methods("PUT").HandlerFunc(api.PutBucketHandler)
 	// HeadBucket
 	bucket.Methods("HEAD").HandlerFunc(api.HeadBucketHandler)
+	// GetMultipleObjects
+	bucket.Methods("POST").HandlerFunc(api.GetMultipleObjectsHandler).Queries("getMultiple", "")
 	// PostPolicy
 	bucket.Methods("POST").HeadersRegexp("Content-Type", "multipart/form-data*").HandlerFunc(api.PostPolicyBucketHandler)
 	// DeleteMultipleObjects
-	bucket.Methods("POST").HandlerFunc(api.DeleteMultipleObjectsHandler)
+	bucket.Methods("POST").HandlerFunc(api.DeleteMultipleObjectsHandler).Queries("delete", "")
+	// DeleteBucketOrigin
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketOriginHandler).Queries("origin", "")
 	// DeleteBucketPolicy
 	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketPolicyHandler).Queries("policy", "")
+	// DeleteBucketDefaults
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketDefaultsHandler).Queries("defaults", "")
+	// DeleteBucketReplication
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketReplicationHandler).Queries("replicate", "")
+	// DeleteBucketReplica
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketReplicaHandler).Queries("replica", "")
+	// DeleteBucketRewrite
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketRewriteHandler).Queries("rewrite", "")
+	// DeleteBucketSnapshot
+	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketSnapshotHandler).Queries("snapshot", "{snapshotID:.+}")
 	// DeleteBucket
 	bucket.Methods("DELETE").HandlerFunc(api.DeleteBucketHandler)
 
@@ -0,0 +1,160 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Bucket config types recorded in the audit log.
+const (
+	auditConfigPolicy      = "policy"
+	auditConfigReplication = "replication"
+
+	// Bucket erases are recorded along with config changes.
+	auditConfigErase = "erase"
+)
+
+// Name of the append only audit log inside config directory.
+const auditLogFile = "audit.log"
+
+// auditEntry - a single change to a bucket config.
+type auditEntry struct {
+	Time       time.Time `json:"time"`
+	Bucket     string    `json:"bucket"`
+	ConfigType string    `json:"configType"`
+	AccessKey  string    `json:"accessKey"`
+	Before     string    `json:"before"`
+	After      string    `json:"after"`
+}
+
+// Serializes all appends to the audit log.
+var auditLogMutex = &sync.Mutex{}
+
+// getAuditLogPath - get audit log path.
+func getAuditLogPath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configPath, auditLogFile), nil
+}
+
+// appendAuditEntry - appends an entry to the audit log, log is only
+// ever appended to and never rewritten.
+func appendAuditEntry(entry auditEntry) error {
+	auditLogPath, err := getAuditLogPath()
+	if err != nil {
+		return err
+	}
+	entryBytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	auditLogMutex.Lock()
+	defer auditLogMutex.Unlock()
+
+	file, err := os.OpenFile(auditLogPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = file.Write(append(entryBytes, '\n'))
+	return err
+}
+
+// auditBucketConfigChange - records a bucket config change, failures
+// are logged but do not fail the change.
+func auditBucketConfigChange(bucket, configType, accessKey string, before, after []byte) {
+	err := appendAuditEntry(auditEntry{
+		Time:       time.Now().UTC(),
+		Bucket:     bucket,
+		ConfigType: configType,
+		AccessKey:  accessKey,
+		Before:     string(before),
+		After:      string(after),
+	})
+	errorIf(err, "Unable to record %s change of bucket %s in audit log.", configType, bucket)
+}
+
+// auditBucketErase - records an erase of a bucket, including failed
+// erases which may have removed part of the bucket.
+func auditBucketErase(bucket, accessKey string, overwrite bool, eraseErr error) {
+	after := "deleted"
+	if overwrite {
+		after = "overwritten"
+	}
+	if eraseErr != nil {
+		after = "failed: " + eraseErr.Error()
+	}
+	err := appendAuditEntry(auditEntry{
+		Time:       time.Now().UTC(),
+		Bucket:     bucket,
+		ConfigType: auditConfigErase,
+		AccessKey:  accessKey,
+		After:      after,
+	})
+	errorIf(err, "Unable to record erase of bucket %s in audit log.", bucket)
+}
+
+// readAuditEntries - returns all audit entries matching bucket and
+// configType changed at or after since, empty values match all.
+func readAuditEntries(bucket, configType string, since time.Time) ([]auditEntry, error) {
+	auditLogPath, err := getAuditLogPath()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(auditLogPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []auditEntry
+	scanner := bufio.NewScanner(file)
+	// Config documents can be large, allow up to 1MiB per entry.
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry auditEntry
+		if err = json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return nil, err
+		}
+		if bucket != "" && entry.Bucket != bucket {
+			continue
+		}
+		if configType != "" && entry.ConfigType != configType {
+			continue
+		}
+		if entry.Time.Before(since) {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
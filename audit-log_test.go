@@ -0,0 +1,88 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests validate audit log entries are appended and filtered.
+func TestAuditLog(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+
+	// Empty audit log returns no entries.
+	entries, err := readAuditEntries("", "", time.Time{})
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Expected no entries, got %v, %v", entries, err)
+	}
+
+	auditBucketConfigChange("bucket1", auditConfigPolicy, "accesskey1", nil, []byte("policy1"))
+	auditBucketConfigChange("bucket1", auditConfigPolicy, "accesskey1", []byte("policy1"), []byte("policy2"))
+	auditBucketConfigChange("bucket2", auditConfigReplication, "accesskey2", nil, []byte("replication1"))
+
+	testCases := []struct {
+		bucket        string
+		configType    string
+		since         time.Time
+		expectedCount int
+	}{
+		// Test case - 1.
+		// No filters return all entries.
+		{"", "", time.Time{}, 3},
+		// Test case - 2.
+		// Filter by bucket.
+		{"bucket1", "", time.Time{}, 2},
+		// Test case - 3.
+		// Filter by config type.
+		{"", auditConfigReplication, time.Time{}, 1},
+		// Test case - 4.
+		// Filter by bucket and config type.
+		{"bucket2", auditConfigPolicy, time.Time{}, 0},
+		// Test case - 5.
+		// Filter by time.
+		{"", "", time.Now().UTC().Add(time.Hour), 0},
+	}
+	for i, testCase := range testCases {
+		entries, err = readAuditEntries(testCase.bucket, testCase.configType, testCase.since)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to read audit log. %s", i+1, err)
+		}
+		if len(entries) != testCase.expectedCount {
+			t.Errorf("Test %d: Expected %d entries, got %d", i+1, testCase.expectedCount, len(entries))
+		}
+	}
+
+	// Entries are recorded in order with before and after content.
+	entries, _ = readAuditEntries("bucket1", "", time.Time{})
+	if entries[1].Before != "policy1" || entries[1].After != "policy2" || entries[1].AccessKey != "accesskey1" {
+		t.Errorf("Unexpected audit entry %v", entries[1])
+	}
+
+	// Erases are recorded along with failures.
+	auditBucketErase("bucket2", "accesskey2", true, nil)
+	auditBucketErase("bucket2", "accesskey2", false, errXLWriteQuorum)
+	entries, _ = readAuditEntries("", auditConfigErase, time.Time{})
+	if len(entries) != 2 || entries[0].After != "overwritten" || entries[1].After != "failed: "+errXLWriteQuorum.Error() {
+		t.Errorf("Unexpected erase audit entries %v", entries)
+	}
+}
@@ -76,6 +76,7 @@ const (
 	authTypePostPolicy
 	authTypeSigned
 	authTypeJWT
+	authTypeBasic
 )
 
 // Get request authentication type.
@@ -88,6 +89,8 @@ func getRequestAuthType(r *http.Request) authType {
 		return authTypeJWT
 	} else if isRequestPostPolicySignatureV4(r) {
 		return authTypePostPolicy
+	} else if isRequestBasicAuth(r) {
+		return authTypeBasic
 	} else if _, ok := r.Header["Authorization"]; !ok {
 		return authTypeAnonymous
 	}
@@ -134,6 +137,28 @@ func isReqAuthenticated(r *http.Request) (s3Error APIErrorCode) {
 	return ErrAccessDenied
 }
 
+// getRequestAccessKey - returns the access key of a signed request,
+// empty for all other requests.
+func getRequestAccessKey(r *http.Request) string {
+	switch getRequestAuthType(r) {
+	case authTypeSigned:
+		signV4Values, s3Error := parseSignV4(r.Header.Get("Authorization"))
+		if s3Error == ErrNone {
+			return signV4Values.Credential.accessKey
+		}
+	case authTypePresigned:
+		preSignV4Values, s3Error := parsePreSignV4(r.URL.Query())
+		if s3Error == ErrNone {
+			return preSignV4Values.Credential.accessKey
+		}
+	case authTypeBasic:
+		if accessKey, _, ok := r.BasicAuth(); ok {
+			return accessKey
+		}
+	}
+	return ""
+}
+
 // authHandler - handles all the incoming authorization headers and
 // validates them if possible.
 type authHandler struct {
@@ -160,6 +185,14 @@ func (a authHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		a.handler.ServeHTTP(w, r)
+	case authTypeBasic:
+		// Basic authentication is only accepted by the WebDAV
+		// frontend, which validates it.
+		if !isWebDAVRequest(r) {
+			writeErrorResponse(w, r, ErrSignatureVersionNotSupported, r.URL.Path)
+			return
+		}
+		a.handler.ServeHTTP(w, r)
 	default:
 		writeErrorResponse(w, r, ErrSignatureVersionNotSupported, r.URL.Path)
 		return
@@ -0,0 +1,121 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	mux "github.com/gorilla/mux"
+)
+
+// PutBucketChecksumHandler - PUT Bucket checksum
+// -----------------
+// This implementation of the PUT operation uses the checksum
+// subresource to require a checksum on all uploads to a bucket.
+func (api objectAPIHandlers) PutBucketChecksumHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	// If Content-Length is greater than maximum allowed size.
+	if r.ContentLength > maxBucketChecksumConfigSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+
+	configBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBucketChecksumConfigSize))
+	if err != nil {
+		errorIf(err, "Unable to read required checksum.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+
+	config, err := parseBucketChecksumConfig(configBuf)
+	if err != nil {
+		errorIf(err, "Unable to parse required checksum.")
+		writeErrorResponse(w, r, ErrMalformedChecksumConfig, r.URL.Path)
+		return
+	}
+
+	if err = writeBucketChecksumConfig(bucket, config); err != nil {
+		errorIf(err, "Unable to write required checksum.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// GetBucketChecksumHandler - GET Bucket checksum
+// -----------------
+// This operation uses the checksum subresource to return the checksum
+// required on uploads to a specified bucket.
+func (api objectAPIHandlers) GetBucketChecksumHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	config, err := readBucketChecksumConfig(bucket)
+	if err != nil {
+		errorIf(err, "Unable to read required checksum.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		errorIf(err, "Unable to marshal required checksum.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, configBuf)
+}
@@ -0,0 +1,125 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+const (
+	// Required checksum is saved alongside the bucket policy.
+	bucketChecksumConfigFile = "checksum.json"
+
+	// Maximum size of required checksum document.
+	maxBucketChecksumConfigSize = 1024 // 1KiB.
+
+	// Checksums uploads can be required to carry.
+	checksumMD5    = "MD5"
+	checksumSHA256 = "SHA256"
+)
+
+// bucketChecksumConfig - checksum all uploads to a bucket must carry,
+// none if empty.
+type bucketChecksumConfig struct {
+	Required string `json:"required"`
+}
+
+// isValidRequiredChecksum - returns true if uploads can be required
+// to carry the checksum.
+func isValidRequiredChecksum(checksum string) bool {
+	return checksum == "" || checksum == checksumMD5 || checksum == checksumSHA256
+}
+
+// parseBucketChecksumConfig - parses and validates required checksum.
+func parseBucketChecksumConfig(configBuf []byte) (config bucketChecksumConfig, err error) {
+	if err = json.Unmarshal(configBuf, &config); err != nil {
+		return bucketChecksumConfig{}, err
+	}
+	if !isValidRequiredChecksum(config.Required) {
+		return bucketChecksumConfig{}, errors.New("Required checksum should be one of MD5 or SHA256.")
+	}
+	return config, nil
+}
+
+// readBucketChecksumConfig - read required checksum, buckets without
+// a configuration require the checksum set via environment setting.
+func readBucketChecksumConfig(bucket string) (bucketChecksumConfig, error) {
+	configBuf, err := readBucketConfig(bucket, bucketChecksumConfigFile)
+	if err == errConfigNotFound {
+		return bucketChecksumConfig{Required: globalRequiredChecksum}, nil
+	}
+	if err != nil {
+		return bucketChecksumConfig{}, err
+	}
+	return parseBucketChecksumConfig(configBuf)
+}
+
+// writeBucketChecksumConfig - save required checksum.
+func writeBucketChecksumConfig(bucket string, config bucketChecksumConfig) error {
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return writeBucketConfig(bucket, bucketChecksumConfigFile, configBuf)
+}
+
+// removeBucketChecksumConfig - remove required checksum, if any.
+func removeBucketChecksumConfig(bucket string) error {
+	err := removeBucketConfig(bucket, bucketChecksumConfigFile)
+	if err == errConfigNotFound {
+		return nil
+	}
+	return err
+}
+
+// hasRequestChecksum - returns true if the upload carries a checksum
+// of the body which is verified while writing it. Content-Md5 is
+// verified by the object layer for some uploads only, the payload of
+// requests signed with signature V4 is part of the signature.
+func hasRequestChecksum(r *http.Request, checksum string, verifiesMD5 bool) bool {
+	switch checksum {
+	case checksumMD5:
+		return verifiesMD5 && r.Header.Get("Content-Md5") != ""
+	case checksumSHA256:
+		if isRequestSignatureV4(r) {
+			return true
+		}
+		// Presigned payloads are unsigned unless requested.
+		return isRequestPresignedSignatureV4(r) && r.URL.Query().Get("X-Amz-Content-Sha256") != ""
+	}
+	return false
+}
+
+// checkRequiredChecksum - verifies the upload carries the checksum
+// required by the bucket, uploads which cannot carry a verified
+// checksum pass a nil request.
+func checkRequiredChecksum(r *http.Request, bucket string, verifiesMD5 bool) APIErrorCode {
+	config, err := readBucketChecksumConfig(bucket)
+	if err != nil {
+		errorIf(err, "Unable to read required checksum of bucket %s.", bucket)
+		return ErrInternalError
+	}
+	if config.Required == "" {
+		return ErrNone
+	}
+	if r == nil || !hasRequestChecksum(r, config.Required, verifiesMD5) {
+		return ErrMissingRequiredChecksum
+	}
+	return ErrNone
+}
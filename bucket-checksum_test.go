@@ -0,0 +1,190 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// Tests validate parsing of required checksum.
+func TestParseBucketChecksumConfig(t *testing.T) {
+	testCases := []struct {
+		configBuf        string
+		expectedRequired string
+		shouldPass       bool
+	}{
+		// Test case - 1.
+		{`{"required":"SHA256"}`, checksumSHA256, true},
+		// Test case - 2.
+		{`{"required":"MD5"}`, checksumMD5, true},
+		// Test case - 3.
+		// No checksum required.
+		{`{"required":""}`, "", true},
+		// Test case - 4.
+		// Unsupported checksum.
+		{`{"required":"CRC32"}`, "", false},
+		// Test case - 5.
+		{`{"required":"sha256"}`, "", false},
+		// Test case - 6.
+		// Malformed document.
+		{`{"required":`, "", false},
+	}
+	for i, testCase := range testCases {
+		config, err := parseBucketChecksumConfig([]byte(testCase.configBuf))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+		if err == nil && config.Required != testCase.expectedRequired {
+			t.Errorf("Test %d: Expected required checksum %q, got %q", i+1, testCase.expectedRequired, config.Required)
+		}
+	}
+}
+
+// Tests checksums carried by uploads.
+func TestHasRequestChecksum(t *testing.T) {
+	newRequest := func(query string, headers map[string]string) *http.Request {
+		req, err := http.NewRequest("PUT", "http://localhost:9000/bucket/object?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return req
+	}
+	v4Auth := map[string]string{"Authorization": signV4Algorithm + " Credential=..."}
+	contentMD5 := map[string]string{"Content-Md5": "1B2M2Y8AsgTpgAmY7PhCfg=="}
+	presigned := "X-Amz-Credential=cred&X-Amz-Signature=sig"
+
+	testCases := []struct {
+		req         *http.Request
+		checksum    string
+		verifiesMD5 bool
+		expected    bool
+	}{
+		// Test case - 1.
+		{newRequest("", v4Auth), checksumSHA256, true, true},
+		// Test case - 2.
+		// Unsigned payload of a presigned request.
+		{newRequest(presigned, nil), checksumSHA256, true, false},
+		// Test case - 3.
+		{newRequest(presigned+"&X-Amz-Content-Sha256=abc", nil), checksumSHA256, true, true},
+		// Test case - 4.
+		// Anonymous request.
+		{newRequest("", nil), checksumSHA256, true, false},
+		// Test case - 5.
+		{newRequest("", contentMD5), checksumMD5, true, true},
+		// Test case - 6.
+		// Content-Md5 not verified by the upload.
+		{newRequest("", contentMD5), checksumMD5, false, false},
+		// Test case - 7.
+		// Signed requests without Content-Md5.
+		{newRequest("", v4Auth), checksumMD5, true, false},
+	}
+	for i, testCase := range testCases {
+		if result := hasRequestChecksum(testCase.req, testCase.checksum, testCase.verifiesMD5); result != testCase.expected {
+			t.Errorf("Test %d: Expected %t, got %t", i+1, testCase.expected, result)
+		}
+	}
+}
+
+// Tests uploads without the required checksum are rejected, for single
+// PUTs and parts of multipart uploads.
+func TestRequiredChecksum(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+	bucket := makeIntegrationBucket(t, client)
+
+	data := []byte("hello, world")
+	// Signed test requests carry Content-Md5 unless cleared.
+	noMD5 := map[string]string{"Content-Md5": ""}
+
+	putChecksum := func(bucket, config string) {
+		resp, respBody, err := client.do("PUT", bucket, "", url.Values{"checksum": {""}}, nil, []byte(config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "PutBucketChecksum", resp, respBody, http.StatusNoContent)
+	}
+	expectPut := func(testName string, headers map[string]string, status int) {
+		resp, respBody, err := client.do("PUT", bucket, "object", nil, headers, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, testName, resp, respBody, status)
+	}
+
+	// Unsupported checksums are rejected.
+	resp, respBody, err := client.do("PUT", bucket, "", url.Values{"checksum": {""}}, nil, []byte(`{"required":"CRC32"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutBucketChecksum", resp, respBody, http.StatusBadRequest)
+
+	// Payloads of requests signed with signature V4 are verified.
+	putChecksum(bucket, `{"required":"SHA256"}`)
+	expectPut("PutObject signed", nil, http.StatusOK)
+
+	// Content-Md5 is required on single PUTs and parts.
+	putChecksum(bucket, `{"required":"MD5"}`)
+	expectPut("PutObject without Content-Md5", noMD5, http.StatusBadRequest)
+	expectPut("PutObject with Content-Md5", nil, http.StatusOK)
+
+	resp, respBody, err = client.do("POST", bucket, "multipart", url.Values{"uploads": {""}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "NewMultipartUpload", resp, respBody, http.StatusOK)
+	initResp := InitiateMultipartUploadResponse{}
+	if err = xml.Unmarshal(respBody, &initResp); err != nil {
+		t.Fatal(err)
+	}
+	partQuery := url.Values{"uploadId": {initResp.UploadID}, "partNumber": {"1"}}
+	resp, respBody, err = client.do("PUT", bucket, "multipart", partQuery, noMD5, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObjectPart without Content-Md5", resp, respBody, http.StatusBadRequest)
+	resp, respBody, err = client.do("PUT", bucket, "multipart", partQuery, nil, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObjectPart with Content-Md5", resp, respBody, http.StatusOK)
+
+	// Buckets without a configuration require the global checksum.
+	globalRequiredChecksum = checksumMD5
+	defer func() { globalRequiredChecksum = "" }()
+	otherBucket := makeIntegrationBucket(t, client)
+	resp, respBody, err = client.do("PUT", otherBucket, "object", nil, noMD5, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject without global checksum", resp, respBody, http.StatusBadRequest)
+
+	// Buckets can opt out of the global checksum.
+	putChecksum(otherBucket, `{"required":""}`)
+	if s3Error := checkRequiredChecksum(nil, otherBucket, false); s3Error != ErrNone {
+		t.Fatalf("Expected no checksum required for opted out bucket, got %s", getAPIError(s3Error).Code)
+	}
+}
@@ -0,0 +1,177 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// List of all config files which may be saved alongside the bucket
+// policy, only these are accepted from peers.
+var bucketConfigFiles = []string{
+	bucketChecksumConfigFile,
+	bucketDefaultsConfigFile,
+	bucketObjectLockConfigFile,
+	bucketOriginConfigFile,
+	bucketOverwriteConfigFile,
+	bucketOwnerConfigFile,
+	bucketReplicaConfigFile,
+	bucketReplicationConfigFile,
+	bucketRewriteConfigFile,
+	bucketVersioningConfigFile,
+}
+
+// isBucketConfigFile - returns true if configFile is a known bucket
+// config file.
+func isBucketConfigFile(configFile string) bool {
+	for _, bucketConfigFile := range bucketConfigFiles {
+		if configFile == bucketConfigFile {
+			return true
+		}
+	}
+	return false
+}
+
+// readBucketConfig - read a named config file saved alongside the
+// bucket policy, returns errConfigNotFound if not present.
+func readBucketConfig(bucket, configFile string) ([]byte, error) {
+	// Verify bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return nil, BucketNameInvalid{Bucket: bucket}
+	}
+
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return nil, err
+	}
+
+	configBytes, err := ioutil.ReadFile(filepath.Join(bucketConfigPath, configFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errConfigNotFound
+		}
+		return nil, err
+	}
+	return configBytes, nil
+}
+
+// writeBucketConfig - save a named config file of a bucket and send
+// it to all peers, configs are saved on every node of the cluster.
+func writeBucketConfig(bucket, configFile string, configBytes []byte) error {
+	if err := writeLocalBucketConfig(bucket, configFile, configBytes); err != nil {
+		return err
+	}
+	broadcastBucketConfig(bucket, configFile, configBytes)
+	return nil
+}
+
+// writeLocalBucketConfig - save a named config file of a bucket on
+// this node only.
+func writeLocalBucketConfig(bucket, configFile string, configBytes []byte) error {
+	// Verify bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+
+	// Create bucket config path.
+	if err := createBucketConfigPath(bucket); err != nil {
+		return err
+	}
+
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(bucketConfigPath, configFile), configBytes, 0600)
+}
+
+// removeBucketConfig - remove a named config file of a bucket on this
+// node and all peers, returns errConfigNotFound if not present.
+func removeBucketConfig(bucket, configFile string) error {
+	if err := removeLocalBucketConfig(bucket, configFile); err != nil {
+		return err
+	}
+	broadcastBucketConfig(bucket, configFile, nil)
+	return nil
+}
+
+// removeLocalBucketConfig - remove a named config file of a bucket on
+// this node only, returns errConfigNotFound if not present.
+func removeLocalBucketConfig(bucket, configFile string) error {
+	// Verify bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+
+	bucketConfigPath, err := getBucketConfigPath(bucket)
+	if err != nil {
+		return err
+	}
+
+	if err = os.Remove(filepath.Join(bucketConfigPath, configFile)); err != nil {
+		if os.IsNotExist(err) {
+			return errConfigNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// listBucketConfigs - list all saved bucket configs along with their
+// last modified time.
+func listBucketConfigs() ([]BucketConfigInfo, error) {
+	bucketsConfigPath, err := getBucketsConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ioutil.ReadDir(bucketsConfigPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var configs []BucketConfigInfo
+	for _, entry := range entries {
+		if !entry.IsDir() || !IsValidBucketName(entry.Name()) {
+			continue
+		}
+		for _, configFile := range bucketConfigFiles {
+			bucketConfigFile := filepath.Join(bucketsConfigPath, entry.Name(), configFile)
+			st, err := os.Stat(bucketConfigFile)
+			if err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return nil, err
+			}
+			configBytes, err := ioutil.ReadFile(bucketConfigFile)
+			if err != nil {
+				return nil, err
+			}
+			configs = append(configs, BucketConfigInfo{
+				Bucket:     entry.Name(),
+				ConfigFile: configFile,
+				Config:     configBytes,
+				ModTime:    st.ModTime(),
+			})
+		}
+	}
+	return configs, nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	mux "github.com/gorilla/mux"
+)
+
+// PutBucketDefaultsHandler - PUT Bucket defaults
+// -----------------
+// This implementation of the PUT operation uses the defaults
+// subresource to set metadata applied to every object written to a
+// bucket, unless the client sets it.
+func (api objectAPIHandlers) PutBucketDefaultsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	// If Content-Length is greater than maximum allowed size.
+	if r.ContentLength > maxBucketDefaultsConfigSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+
+	configBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBucketDefaultsConfigSize))
+	if err != nil {
+		errorIf(err, "Unable to read default metadata.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+
+	config, err := parseBucketDefaultsConfig(configBuf)
+	if err != nil {
+		errorIf(err, "Unable to parse default metadata.")
+		writeErrorResponse(w, r, ErrMalformedDefaultsConfig, r.URL.Path)
+		return
+	}
+
+	if err = writeBucketDefaultsConfig(bucket, config); err != nil {
+		errorIf(err, "Unable to write default metadata.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// GetBucketDefaultsHandler - GET Bucket defaults
+// -----------------
+// This operation uses the defaults subresource to return the default
+// metadata of a specified bucket.
+func (api objectAPIHandlers) GetBucketDefaultsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	config, err := readBucketDefaultsConfig(bucket)
+	if err != nil {
+		errorIf(err, "Unable to read default metadata.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		errorIf(err, "Unable to marshal default metadata.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, configBuf)
+}
+
+// DeleteBucketDefaultsHandler - DELETE Bucket defaults
+// -----------------
+// This implementation of the DELETE operation uses the defaults
+// subresource to remove default metadata of a bucket.
+func (api objectAPIHandlers) DeleteBucketDefaultsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if err := removeBucketDefaultsConfig(bucket); err != nil {
+		errorIf(err, "Unable to remove default metadata.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
@@ -0,0 +1,103 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+const (
+	// Default metadata is saved alongside the bucket policy.
+	bucketDefaultsConfigFile = "defaults.json"
+
+	// Maximum size of default metadata document, same as the limit
+	// of user metadata of an object.
+	maxBucketDefaultsConfigSize = 2 * 1024 // 2KiB.
+)
+
+// bucketDefaultsConfig - metadata applied to every object written to
+// the bucket, unless the client sets it.
+type bucketDefaultsConfig struct {
+	// User defined metadata, for example "X-Amz-Meta-Cost-Center".
+	Metadata map[string]string `json:"metadata"`
+}
+
+// parseBucketDefaultsConfig - parses and validates default metadata,
+// metadata keys are canonicalized.
+func parseBucketDefaultsConfig(configBuf []byte) (config bucketDefaultsConfig, err error) {
+	if err = json.Unmarshal(configBuf, &config); err != nil {
+		return bucketDefaultsConfig{}, err
+	}
+	metadata := make(map[string]string)
+	for key, value := range config.Metadata {
+		cKey := http.CanonicalHeaderKey(key)
+		if !isUserMetadataKey(cKey) {
+			return bucketDefaultsConfig{}, errors.New("Default metadata must be user defined metadata, " + key + " is not.")
+		}
+		metadata[cKey] = value
+	}
+	config.Metadata = metadata
+	return config, nil
+}
+
+// readBucketDefaultsConfig - read default metadata, buckets have no
+// default metadata unless configured.
+func readBucketDefaultsConfig(bucket string) (bucketDefaultsConfig, error) {
+	configBuf, err := readBucketConfig(bucket, bucketDefaultsConfigFile)
+	if err == errConfigNotFound {
+		return bucketDefaultsConfig{Metadata: map[string]string{}}, nil
+	}
+	if err != nil {
+		return bucketDefaultsConfig{}, err
+	}
+	return parseBucketDefaultsConfig(configBuf)
+}
+
+// writeBucketDefaultsConfig - save default metadata.
+func writeBucketDefaultsConfig(bucket string, config bucketDefaultsConfig) error {
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return writeBucketConfig(bucket, bucketDefaultsConfigFile, configBuf)
+}
+
+// removeBucketDefaultsConfig - remove default metadata, if any.
+func removeBucketDefaultsConfig(bucket string) error {
+	err := removeBucketConfig(bucket, bucketDefaultsConfigFile)
+	if err == errConfigNotFound {
+		return nil
+	}
+	return err
+}
+
+// applyBucketDefaultMetadata - sets default metadata of the bucket
+// which is not already set in metadata.
+func applyBucketDefaultMetadata(bucket string, metadata map[string]string) {
+	config, err := readBucketDefaultsConfig(bucket)
+	if err != nil {
+		errorIf(err, "Unable to read default metadata of bucket %s.", bucket)
+		return
+	}
+	for key, value := range config.Metadata {
+		if _, ok := metadata[key]; !ok {
+			metadata[key] = value
+		}
+	}
+}
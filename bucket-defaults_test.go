@@ -0,0 +1,158 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// Tests validate parsing of default metadata.
+func TestParseBucketDefaultsConfig(t *testing.T) {
+	testCases := []struct {
+		configBuf        string
+		expectedMetadata map[string]string
+		shouldPass       bool
+	}{
+		// Test case - 1.
+		{`{"metadata":{}}`, map[string]string{}, true},
+		// Test case - 2.
+		// Keys are canonicalized.
+		{`{"metadata":{"x-amz-meta-cost-center":"eng"}}`, map[string]string{"X-Amz-Meta-Cost-Center": "eng"}, true},
+		// Test case - 3.
+		{`{"metadata":{"X-Minio-Meta-Team":"storage"}}`, map[string]string{"X-Minio-Meta-Team": "storage"}, true},
+		// Test case - 4.
+		// Only user defined metadata is allowed.
+		{`{"metadata":{"Content-Type":"text/plain"}}`, nil, false},
+		// Test case - 5.
+		// Malformed document.
+		{`{"metadata":`, nil, false},
+	}
+	for i, testCase := range testCases {
+		config, err := parseBucketDefaultsConfig([]byte(testCase.configBuf))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+		if err != nil {
+			continue
+		}
+		if len(config.Metadata) != len(testCase.expectedMetadata) {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expectedMetadata, config.Metadata)
+		}
+		for key, value := range testCase.expectedMetadata {
+			if config.Metadata[key] != value {
+				t.Errorf("Test %d: Expected %s to be %s, got %s", i+1, key, value, config.Metadata[key])
+			}
+		}
+	}
+}
+
+// Tests default metadata is applied to objects written to the bucket.
+func TestBucketDefaultMetadata(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+
+	bucket := makeIntegrationBucket(t, client)
+	config := []byte(`{"metadata":{"X-Amz-Meta-Cost-Center":"eng","X-Amz-Meta-Team":"storage"}}`)
+	resp, respBody, err := client.do("PUT", bucket, "", url.Values{"defaults": {""}}, nil, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutBucketDefaults", resp, respBody, http.StatusNoContent)
+
+	// Client metadata takes precedence over defaults.
+	headers := map[string]string{"X-Amz-Meta-Team": "ingest"}
+	resp, respBody, err = client.do("PUT", bucket, "object", nil, headers, []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+
+	// Defaults apply to multipart uploads too.
+	resp, respBody, err = client.do("POST", bucket, "multipart", url.Values{"uploads": {""}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "NewMultipartUpload", resp, respBody, http.StatusOK)
+	initResp := InitiateMultipartUploadResponse{}
+	if err = xml.Unmarshal(respBody, &initResp); err != nil {
+		t.Fatal(err)
+	}
+	queryValues := url.Values{"partNumber": {"1"}, "uploadId": {initResp.UploadID}}
+	resp, respBody, err = client.do("PUT", bucket, "multipart", queryValues, nil, []byte("part"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObjectPart", resp, respBody, http.StatusOK)
+	completeBuf, err := xml.Marshal(completeMultipartUpload{
+		Parts: []completePart{{PartNumber: 1, ETag: resp.Header.Get("ETag")}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, respBody, err = client.do("POST", bucket, "multipart", url.Values{"uploadId": {initResp.UploadID}}, nil, completeBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "CompleteMultipartUpload", resp, respBody, http.StatusOK)
+
+	testCases := []struct {
+		object           string
+		expectedMetadata map[string]string
+	}{
+		// Test case - 1.
+		{"object", map[string]string{"X-Amz-Meta-Cost-Center": "eng", "X-Amz-Meta-Team": "ingest"}},
+		// Test case - 2.
+		{"multipart", map[string]string{"X-Amz-Meta-Cost-Center": "eng", "X-Amz-Meta-Team": "storage"}},
+	}
+	for i, testCase := range testCases {
+		resp, respBody, err = client.do("HEAD", bucket, testCase.object, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "HeadObject", resp, respBody, http.StatusOK)
+		for key, value := range testCase.expectedMetadata {
+			if resp.Header.Get(key) != value {
+				t.Errorf("Test %d: Expected %s to be %s, got %s", i+1, key, value, resp.Header.Get(key))
+			}
+		}
+	}
+
+	resp, respBody, err = client.do("DELETE", bucket, "", url.Values{"defaults": {""}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "DeleteBucketDefaults", resp, respBody, http.StatusNoContent)
+	resp, respBody, err = client.do("PUT", bucket, "plain", nil, nil, []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+	resp, respBody, err = client.do("HEAD", bucket, "plain", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Header.Get("X-Amz-Meta-Cost-Center") != "" {
+		t.Errorf("Expected no default metadata after removal, got %s", resp.Header.Get("X-Amz-Meta-Cost-Center"))
+	}
+}
@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/xml"
@@ -84,7 +85,7 @@ func (api objectAPIHandlers) GetBucketLocationHandler(w http.ResponseWriter, r *
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
-		if s3Error := enforceBucketPolicy("s3:GetBucketLocation", bucket, r.URL); s3Error != ErrNone {
+		if s3Error := enforceBucketProbePolicy("s3:GetBucketLocation", bucket, r); s3Error != ErrNone {
 			writeErrorResponse(w, r, s3Error, r.URL.Path)
 			return
 		}
@@ -176,6 +177,14 @@ func (api objectAPIHandlers) ListMultipartUploadsHandler(w http.ResponseWriter,
 			writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
 			return
 		}
+		// Listings continue on the node which listed the previous
+		// page, reusing its tree walk.
+		continuation := listContinuation{bucket, prefix, delimiter, keyMarker, uploadIDMarker}
+		if peerAddr := findContinuationPeer(r, continuation); peerAddr != "" {
+			proxyToPeer(w, r, peerAddr)
+			return
+		}
+		globalContinuations.remove(continuation)
 	}
 
 	listMultipartsInfo, err := api.ObjectAPI.ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter, maxUploads)
@@ -184,6 +193,9 @@ func (api objectAPIHandlers) ListMultipartUploadsHandler(w http.ResponseWriter,
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
+	if listMultipartsInfo.IsTruncated && len(globalPeers) > 0 {
+		globalContinuations.add(listContinuation{bucket, prefix, delimiter, listMultipartsInfo.NextKeyMarker, listMultipartsInfo.NextUploadIDMarker})
+	}
 	// generate response
 	response := generateListMultipartUploadsResponse(bucket, listMultipartsInfo)
 	encodedSuccessResponse := encodeResponse(response)
@@ -193,16 +205,20 @@ func (api objectAPIHandlers) ListMultipartUploadsHandler(w http.ResponseWriter,
 	writeSuccessResponse(w, encodedSuccessResponse)
 }
 
-// ListObjectsHandler - GET Bucket (List Objects)
+// ListObjectsV2Handler - GET Bucket (List Objects) Version 2
 // -- -----------------------
 // This implementation of the GET operation returns some or all (up to 1000)
-// of the objects in a bucket. You can use the request parameters as selection
-// criteria to return a subset of the objects in a bucket.
+// of the objects in a bucket, the next objects are listed with the opaque
+// continuation token of the response. You can use the request parameters as
+// selection criteria to return a subset of the objects in a bucket.
 //
-func (api objectAPIHandlers) ListObjectsHandler(w http.ResponseWriter, r *http.Request) {
+func (api objectAPIHandlers) ListObjectsV2Handler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	bucket := vars["bucket"]
 
+	// Minio extension, list metadata of objects along with them.
+	withMetadata := r.Header.Get(listMetadataHeader) == "true"
+
 	switch getRequestAuthType(r) {
 	default:
 		// For all unknown auth types return error.
@@ -214,28 +230,104 @@ func (api objectAPIHandlers) ListObjectsHandler(w http.ResponseWriter, r *http.R
 			writeErrorResponse(w, r, s3Error, r.URL.Path)
 			return
 		}
+		// Policies allowing to list a bucket need not allow to read
+		// metadata of its objects.
+		if withMetadata {
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+			return
+		}
 	case authTypeSigned, authTypePresigned:
 		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
 			writeErrorResponse(w, r, s3Error, r.URL.Path)
 			return
 		}
 	}
-	var prefix, marker, token, delimiter, startAfter string
-	var maxkeys int
-	var listV2 bool
 	// TODO handle encoding type.
-	if r.URL.Query().Get("list-type") == "2" {
-		listV2 = true
-		prefix, token, startAfter, delimiter, maxkeys, _ = getListObjectsV2Args(r.URL.Query())
-		// For ListV2 "start-after" is considered only if "continuation-token" is empty.
-		if token == "" {
-			marker = startAfter
-		} else {
-			marker = token
+	prefix, token, startAfter, delimiter, fetchOwner, maxkeys, _ := getListObjectsV2Args(r.URL.Query())
+	if maxkeys < 0 {
+		writeErrorResponse(w, r, ErrInvalidMaxKeys, r.URL.Path)
+		return
+	}
+	// Verify if delimiter is anything other than '/', which we do not support.
+	if delimiter != "" && delimiter != "/" {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+	continuationToken, err := decodeContinuationToken(token)
+	if err != nil {
+		writeErrorResponse(w, r, ErrIncorrectContinuationToken, r.URL.Path)
+		return
+	}
+
+	// Minio extension, filter listed objects by metadata and tags.
+	filters, err := parseListFilters(r.URL.Query())
+	if err != nil {
+		writeErrorResponse(w, r, ErrInvalidMetadataFilter, r.URL.Path)
+		return
+	}
+	var listObjectsInfo ListObjectsV2Info
+	if len(filters) > 0 {
+		if !api.ObjectAPI.Capabilities().MetadataFilter {
+			writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+			return
 		}
+		listObjects := func(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+			return listObjectsFiltered(api.ObjectAPI, bucket, prefix, marker, delimiter, maxKeys, filters)
+		}
+		listObjectsInfo, err = listObjectsV2(listObjects, bucket, prefix, continuationToken, delimiter, maxkeys, startAfter)
 	} else {
-		prefix, marker, delimiter, maxkeys, _ = getListObjectsV1Args(r.URL.Query())
+		listObjectsInfo, err = api.ObjectAPI.ListObjectsV2(bucket, prefix, continuationToken, delimiter, maxkeys, startAfter)
+	}
+	if err != nil {
+		errorIf(err, "Unable to list objects.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
 	}
+	// generate response, large listings are streamed to the client
+	// instead of buffering the encoded document.
+	response := generateListObjectsV2Response(bucket, prefix, startAfter, delimiter, maxkeys, fetchOwner, listObjectsInfo, withMetadata)
+	writeSuccessResponseStream(w, response)
+}
+
+// ListObjectsHandler - GET Bucket (List Objects)
+// -- -----------------------
+// This implementation of the GET operation returns some or all (up to
+// maxStreamList, streamed a page at a time) of the objects in a bucket. You
+// can use the request parameters as selection criteria to return a subset of
+// the objects in a bucket.
+//
+func (api objectAPIHandlers) ListObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	// Minio extension, list metadata of objects along with them.
+	withMetadata := r.Header.Get(listMetadataHeader) == "true"
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypeAnonymous:
+		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
+		if s3Error := enforceBucketPolicy("s3:ListBucket", bucket, r.URL); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+		// Policies allowing to list a bucket need not allow to read
+		// metadata of its objects.
+		if withMetadata {
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+			return
+		}
+	case authTypeSigned, authTypePresigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+	// TODO handle encoding type.
+	prefix, marker, delimiter, maxkeys, _ := getListObjectsV1Args(r.URL.Query())
 	if maxkeys < 0 {
 		writeErrorResponse(w, r, ErrInvalidMaxKeys, r.URL.Path)
 		return
@@ -254,24 +346,34 @@ func (api objectAPIHandlers) ListObjectsHandler(w http.ResponseWriter, r *http.R
 		}
 	}
 
-	listObjectsInfo, err := api.ObjectAPI.ListObjects(bucket, prefix, marker, delimiter, maxkeys)
-
-	if err == nil {
-		var encodedSuccessResponse []byte
-		// generate response
-		if listV2 {
-			response := generateListObjectsV2Response(bucket, prefix, token, startAfter, delimiter, maxkeys, listObjectsInfo)
-			encodedSuccessResponse = encodeResponse(response)
-		} else {
-			response := generateListObjectsResponse(bucket, prefix, marker, delimiter, maxkeys, listObjectsInfo)
-			encodedSuccessResponse = encodeResponse(response)
-		}
-		// Write headers
-		setCommonHeaders(w)
-		// Write success response.
-		writeSuccessResponse(w, encodedSuccessResponse)
+	// Minio extension, filter listed objects by metadata and tags.
+	filters, err := parseListFilters(r.URL.Query())
+	if err != nil {
+		writeErrorResponse(w, r, ErrInvalidMetadataFilter, r.URL.Path)
 		return
 	}
+	if len(filters) > 0 {
+		if !api.ObjectAPI.Capabilities().MetadataFilter {
+			writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+			return
+		}
+		var listObjectsInfo ListObjectsInfo
+		listObjectsInfo, err = listObjectsFiltered(api.ObjectAPI, bucket, prefix, marker, delimiter, maxkeys, filters)
+		if err == nil {
+			response := generateListObjectsResponse(bucket, prefix, marker, delimiter, maxkeys, listObjectsInfo, withMetadata)
+			writeSuccessResponseStream(w, response)
+			return
+		}
+	} else {
+		// Minio extension, listings of up to maxStreamList objects are
+		// streamed to the client page by page as they are listed.
+		if maxkeys > maxStreamList {
+			maxkeys = maxStreamList
+		}
+		if err = writeListObjectsStream(w, api.ObjectAPI, bucket, prefix, marker, delimiter, maxkeys, withMetadata); err == nil {
+			return
+		}
+	}
 	errorIf(err, "Unable to list objects.")
 	writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 }
@@ -317,6 +419,8 @@ func (api objectAPIHandlers) ListBucketsHandler(w http.ResponseWriter, r *http.R
 
 	bucketsInfo, err := api.ObjectAPI.ListBuckets()
 	if err == nil {
+		// Tenants only see buckets of their namespace.
+		bucketsInfo = filterTenantBuckets(getRequestAccessKey(r), bucketsInfo)
 		// generate response
 		response := generateListBucketsResponse(bucketsInfo)
 		encodedSuccessResponse := encodeResponse(response)
@@ -330,7 +434,11 @@ func (api objectAPIHandlers) ListBucketsHandler(w http.ResponseWriter, r *http.R
 	writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 }
 
-// DeleteMultipleObjectsHandler - deletes multiple objects.
+// DeleteMultipleObjectsHandler - POST Bucket ?delete
+// ----------
+// This operation deletes up to 1000 objects of a bucket in a single
+// request, reporting the result of each key. In quiet mode only keys
+// which failed to delete are reported.
 func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	bucket := vars["bucket"]
@@ -367,6 +475,12 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 		return
 	}
 
+	// If Content-Length is greater than maximum allowed size.
+	if r.ContentLength > maxDeleteObjectsSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+
 	// Allocate incoming content length bytes.
 	deleteXMLBytes := make([]byte, r.ContentLength)
 
@@ -377,31 +491,60 @@ func (api objectAPIHandlers) DeleteMultipleObjectsHandler(w http.ResponseWriter,
 		return
 	}
 
+	// Content-Md5 must match the body.
+	md5Bytes, err := checkValidMD5(r.Header.Get("Content-Md5"))
+	if err != nil {
+		writeErrorResponse(w, r, ErrInvalidDigest, r.URL.Path)
+		return
+	}
+	if md5Sum := md5.Sum(deleteXMLBytes); !bytes.Equal(md5Sum[:], md5Bytes) {
+		writeErrorResponse(w, r, ErrBadDigest, r.URL.Path)
+		return
+	}
+
 	// Unmarshal list of keys to be deleted.
 	deleteObjects := &DeleteObjectsRequest{}
-	if err := xml.Unmarshal(deleteXMLBytes, deleteObjects); err != nil {
+	if err = xml.Unmarshal(deleteXMLBytes, deleteObjects); err != nil {
 		errorIf(err, "Unable to unmarshal delete objects request XML.")
 		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
 		return
 	}
+	if len(deleteObjects.Objects) == 0 || len(deleteObjects.Objects) > maxDeleteObjects {
+		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		return
+	}
+
+	objects := make([]string, len(deleteObjects.Objects))
+	for index, object := range deleteObjects.Objects {
+		objects[index] = object.ObjectName
+	}
+	// Delete all the objects in a single batch.
+	errs, err := api.ObjectAPI.DeleteObjects(bucket, objects)
+	if err != nil {
+		errorIf(err, "Unable to delete objects.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
 
 	var deleteErrors []DeleteError
 	var deletedObjects []ObjectIdentifier
-	// Loop through all the objects and delete them sequentially.
-	for _, object := range deleteObjects.Objects {
-		err := api.ObjectAPI.DeleteObject(bucket, object.ObjectName)
-		if err == nil {
+	for index, object := range objects {
+		// Keys which do not exist are reported as deleted, same as S3.
+		if _, ok := errs[index].(ObjectNotFound); ok || errs[index] == nil {
 			deletedObjects = append(deletedObjects, ObjectIdentifier{
-				ObjectName: object.ObjectName,
-			})
-		} else {
-			errorIf(err, "Unable to delete object.")
-			deleteErrors = append(deleteErrors, DeleteError{
-				Code:    errorCodeResponse[toAPIErrorCode(err)].Code,
-				Message: errorCodeResponse[toAPIErrorCode(err)].Description,
-				Key:     object.ObjectName,
+				ObjectName: object,
 			})
+			if errs[index] == nil {
+				replicateChange(api.ObjectAPI, replicationOp{bucket: bucket, object: object, delete: true})
+			}
+			continue
 		}
+		errorIf(errs[index], "Unable to delete object.")
+		deleteErrors = append(deleteErrors, DeleteError{
+			Code:    errorCodeResponse[toAPIErrorCode(errs[index])].Code,
+			Message: errorCodeResponse[toAPIErrorCode(errs[index])].Description,
+			Key:     object,
+		})
 	}
 	// Generate response
 	response := generateMultiDeleteResponse(deleteObjects.Quiet, deletedObjects, deleteErrors)
@@ -440,6 +583,12 @@ func (api objectAPIHandlers) PutBucketHandler(w http.ResponseWriter, r *http.Req
 		writeErrorResponse(w, r, errCode, r.URL.Path)
 		return
 	}
+	// Verify bucket name is not reserved and bucket count limits.
+	accessKey := getRequestAccessKey(r)
+	if s3Error := checkBucketLimits(api.ObjectAPI, bucket, accessKey); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
 	// Make bucket.
 	err := api.ObjectAPI.MakeBucket(bucket)
 	if err != nil {
@@ -447,6 +596,8 @@ func (api objectAPIHandlers) PutBucketHandler(w http.ResponseWriter, r *http.Req
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
+	// Record bucket owner for enforcing bucket count limits.
+	errorIf(writeBucketOwner(bucket, accessKey), "Unable to save bucket owner.")
 	// Make sure to add Location information here only for bucket
 	w.Header().Set("Location", getLocation(r))
 	writeSuccessResponse(w, nil)
@@ -508,10 +659,23 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 		writeErrorResponse(w, r, apiErr, r.URL.Path)
 		return
 	}
+	credHeader, _ := parseCredentialHeader("Credential=" + formValues["X-Amz-Credential"])
+	setRequestAuthenticated(r, credHeader.accessKey)
 	if apiErr = checkPostPolicy(formValues); apiErr != ErrNone {
 		writeErrorResponse(w, r, apiErr, r.URL.Path)
 		return
 	}
+	// Tenants can only upload to buckets of their namespace.
+	if apiErr = checkTenantBucket(credHeader.accessKey, bucket); apiErr != ErrNone {
+		writeErrorResponse(w, r, apiErr, r.URL.Path)
+		return
+	}
+
+	// Form uploads carry no verified checksum.
+	if apiErr = checkRequiredChecksum(nil, bucket, false); apiErr != ErrNone {
+		writeErrorResponse(w, r, apiErr, r.URL.Path)
+		return
+	}
 
 	// Save metadata.
 	metadata := make(map[string]string)
@@ -526,6 +690,7 @@ func (api objectAPIHandlers) PostPolicyBucketHandler(w http.ResponseWriter, r *h
 	if md5Sum != "" {
 		w.Header().Set("ETag", "\""+md5Sum+"\"")
 	}
+	replicateObjectWrite(w, api.ObjectAPI, bucket, object)
 	encodedSuccessResponse := encodeResponse(PostResponse{
 		Location: getObjectLocation(bucket, object), // TODO Full URL is preferred
 		Bucket:   bucket,
@@ -553,7 +718,7 @@ func (api objectAPIHandlers) HeadBucketHandler(w http.ResponseWriter, r *http.Re
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
-		if s3Error := enforceBucketPolicy("s3:ListBucket", bucket, r.URL); s3Error != ErrNone {
+		if s3Error := enforceBucketProbePolicy("s3:ListBucket", bucket, r); s3Error != ErrNone {
 			writeErrorResponse(w, r, s3Error, r.URL.Path)
 			return
 		}
@@ -572,6 +737,40 @@ func (api objectAPIHandlers) HeadBucketHandler(w http.ResponseWriter, r *http.Re
 	writeSuccessResponse(w, nil)
 }
 
+// removeBucketConfigs - removes all configs of a deleted bucket, errors
+// are ignored.
+func removeBucketConfigs(bucket, accessKey string) {
+	// Delete bucket access policy, if present - ignore any errors.
+	if prevBucketPolicyBuf, err := readBucketPolicy(bucket); err == nil {
+		removeBucketPolicy(bucket)
+		auditBucketConfigChange(bucket, auditConfigPolicy, accessKey, prevBucketPolicyBuf, nil)
+	}
+
+	// Delete bucket rewrite rules, if present - ignore any errors.
+	removeBucketRewriteConfig(bucket)
+
+	// Delete bucket owner, if present - ignore any errors.
+	removeBucketOwner(bucket)
+
+	// Delete replica config, if present - ignore any errors.
+	removeBucketReplicaConfig(bucket)
+
+	// Delete origin config, if present - ignore any errors.
+	removeBucketOriginConfig(bucket)
+
+	// Delete required checksum, if present - ignore any errors.
+	removeBucketChecksumConfig(bucket)
+
+	// Delete versioning, if present - ignore any errors.
+	removeBucketVersioningConfig(bucket)
+
+	// Delete object lock, if present - ignore any errors.
+	removeBucketObjectLockConfig(bucket)
+
+	// Propagate bucket policy removal to all peers.
+	broadcastBucketPolicy(bucket, nil)
+}
+
 // DeleteBucketHandler - Delete bucket
 func (api objectAPIHandlers) DeleteBucketHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -595,8 +794,8 @@ func (api objectAPIHandlers) DeleteBucketHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	// Delete bucket access policy, if present - ignore any errors.
-	removeBucketPolicy(bucket)
+	// Delete all bucket configs.
+	removeBucketConfigs(bucket, getRequestAccessKey(r))
 
 	// Write success response.
 	writeSuccessNoContent(w)
@@ -0,0 +1,197 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// Bucket limits are saved in the config directory.
+	bucketLimitsFile = "bucket-limits.json"
+
+	// Owner of a bucket is saved alongside the bucket policy.
+	bucketOwnerConfigFile = "owner"
+
+	// Maximum size of bucket limits document.
+	maxBucketLimitsSize = 1 * 1024 * 1024 // 1MiB.
+)
+
+// Bucket names which can never be created, in addition to the
+// configured reserved names.
+var builtinReservedBucketNames = []string{
+	path.Base(reservedBucket),
+	"probe",
+}
+
+// bucketLimits - limits enforced while creating buckets.
+type bucketLimits struct {
+	// Maximum number of buckets per access key, 0 is unlimited.
+	MaxBuckets int `json:"maxBuckets"`
+	// Overrides MaxBuckets for individual access keys.
+	UserMaxBuckets map[string]int `json:"userMaxBuckets,omitempty"`
+	// Bucket names which cannot be created, shell patterns as
+	// understood by path.Match.
+	ReservedNames []string `json:"reservedNames,omitempty"`
+}
+
+// getMaxBuckets - returns maximum number of buckets for the access key.
+func (limits bucketLimits) getMaxBuckets(accessKey string) int {
+	if maxBuckets, ok := limits.UserMaxBuckets[accessKey]; ok {
+		return maxBuckets
+	}
+	return limits.MaxBuckets
+}
+
+// isReservedBucketName - returns true if bucket name matches any
+// built-in or configured reserved name.
+func (limits bucketLimits) isReservedBucketName(bucket string) bool {
+	for _, pattern := range append(builtinReservedBucketNames, limits.ReservedNames...) {
+		if ok, _ := path.Match(pattern, bucket); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// parseBucketLimits - parses and validates bucket limits.
+func parseBucketLimits(limitsBuf []byte) (limits bucketLimits, err error) {
+	if err = json.Unmarshal(limitsBuf, &limits); err != nil {
+		return bucketLimits{}, err
+	}
+	if limits.MaxBuckets < 0 {
+		return bucketLimits{}, errors.New("Maximum number of buckets cannot be negative.")
+	}
+	for _, maxBuckets := range limits.UserMaxBuckets {
+		if maxBuckets < 0 {
+			return bucketLimits{}, errors.New("Maximum number of buckets cannot be negative.")
+		}
+	}
+	for _, pattern := range limits.ReservedNames {
+		if _, err = path.Match(pattern, ""); err != nil {
+			return bucketLimits{}, err
+		}
+	}
+	return limits, nil
+}
+
+// getBucketLimitsPath - get bucket limits path.
+func getBucketLimitsPath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configPath, bucketLimitsFile), nil
+}
+
+// readBucketLimits - read bucket limits, no limits are returned if
+// none were configured.
+func readBucketLimits() (bucketLimits, error) {
+	bucketLimitsPath, err := getBucketLimitsPath()
+	if err != nil {
+		return bucketLimits{}, err
+	}
+	limitsBuf, err := ioutil.ReadFile(bucketLimitsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return bucketLimits{}, nil
+		}
+		return bucketLimits{}, err
+	}
+	return parseBucketLimits(limitsBuf)
+}
+
+// writeBucketLimits - save bucket limits.
+func writeBucketLimits(limits bucketLimits) error {
+	limitsBuf, err := json.Marshal(limits)
+	if err != nil {
+		return err
+	}
+	bucketLimitsPath, err := getBucketLimitsPath()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(bucketLimitsPath, limitsBuf, 0600)
+}
+
+// readBucketOwner - returns access key which created the bucket,
+// buckets created before owners were recorded belong to the server
+// credential.
+func readBucketOwner(bucket string) string {
+	ownerBuf, err := readBucketConfig(bucket, bucketOwnerConfigFile)
+	if err != nil {
+		return serverConfig.GetCredential().AccessKeyID
+	}
+	return strings.TrimSpace(string(ownerBuf))
+}
+
+// writeBucketOwner - save access key which created the bucket.
+func writeBucketOwner(bucket, accessKey string) error {
+	return writeBucketConfig(bucket, bucketOwnerConfigFile, []byte(accessKey))
+}
+
+// removeBucketOwner - remove saved bucket owner, if any.
+func removeBucketOwner(bucket string) error {
+	err := removeBucketConfig(bucket, bucketOwnerConfigFile)
+	if err == errConfigNotFound {
+		return nil
+	}
+	return err
+}
+
+// checkBucketLimits - verifies bucket can be created by access key.
+func checkBucketLimits(objAPI ObjectLayer, bucket, accessKey string) APIErrorCode {
+	limits, err := readBucketLimits()
+	if err != nil {
+		errorIf(err, "Unable to read bucket limits.")
+		return ErrInternalError
+	}
+	if limits.isReservedBucketName(bucket) {
+		return ErrBucketNameReserved
+	}
+	// Bucket quota of tenants applies to all buckets of their
+	// namespace, whoever created them.
+	t, isTenant := globalTenants.GetByAccessKey(accessKey)
+	maxBuckets := limits.getMaxBuckets(accessKey)
+	if isTenant && t.MaxBuckets > 0 && (maxBuckets == 0 || t.MaxBuckets < maxBuckets) {
+		maxBuckets = t.MaxBuckets
+	}
+	if maxBuckets == 0 {
+		return ErrNone
+	}
+	bucketsInfo, err := objAPI.ListBuckets()
+	if err != nil {
+		errorIf(err, "Unable to list buckets.")
+		return toAPIErrorCode(err)
+	}
+	var ownedBuckets int
+	for _, bucketInfo := range bucketsInfo {
+		if (isTenant && t.ownsBucket(bucketInfo.Name)) || readBucketOwner(bucketInfo.Name) == accessKey {
+			ownedBuckets++
+		}
+	}
+	if ownedBuckets >= maxBuckets {
+		return ErrTooManyBuckets
+	}
+	return ErrNone
+}
@@ -0,0 +1,122 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "testing"
+
+// Tests validate parsing of bucket limits.
+func TestParseBucketLimits(t *testing.T) {
+	testCases := []struct {
+		limitsBuf  string
+		shouldPass bool
+	}{
+		// Test case - 1.
+		// Empty limits.
+		{`{}`, true},
+		// Test case - 2.
+		// Valid limits.
+		{`{"maxBuckets":10,"userMaxBuckets":{"tenant":2},"reservedNames":["tmp-*"]}`, true},
+		// Test case - 3.
+		// Negative maximum.
+		{`{"maxBuckets":-1}`, false},
+		// Test case - 4.
+		// Negative maximum for an access key.
+		{`{"userMaxBuckets":{"tenant":-1}}`, false},
+		// Test case - 5.
+		// Malformed reserved name pattern.
+		{`{"reservedNames":["["]}`, false},
+		// Test case - 6.
+		// Malformed document.
+		{`{"maxBuckets":`, false},
+	}
+	for i, testCase := range testCases {
+		_, err := parseBucketLimits([]byte(testCase.limitsBuf))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+	}
+}
+
+// Tests validate bucket limits enforced while creating buckets.
+func TestCheckBucketLimits(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	objAPI, disk, err := getSingleNodeObjectLayer()
+	if err != nil {
+		t.Fatalf("Unable to initialize object layer. %s", err)
+	}
+	defer removeAll(disk)
+
+	owner := serverConfig.GetCredential().AccessKeyID
+	if err = writeBucketLimits(bucketLimits{
+		MaxBuckets:     1,
+		UserMaxBuckets: map[string]int{"tenant": 2},
+		ReservedNames:  []string{"tmp-*"},
+	}); err != nil {
+		t.Fatalf("Unable to save bucket limits. %s", err)
+	}
+	// Bucket without a saved owner belongs to the server credential.
+	if err = objAPI.MakeBucket("bucket"); err != nil {
+		t.Fatalf("Unable to create bucket. %s", err)
+	}
+	if err = objAPI.MakeBucket("tenant-bucket"); err != nil {
+		t.Fatalf("Unable to create bucket. %s", err)
+	}
+	if err = writeBucketOwner("tenant-bucket", "tenant"); err != nil {
+		t.Fatalf("Unable to save bucket owner. %s", err)
+	}
+
+	testCases := []struct {
+		bucket      string
+		accessKey   string
+		expectedErr APIErrorCode
+	}{
+		// Test case - 1.
+		// Built-in reserved name.
+		{"minio", "tenant", ErrBucketNameReserved},
+		// Test case - 2.
+		// Built-in reserved name.
+		{"probe", "tenant", ErrBucketNameReserved},
+		// Test case - 3.
+		// Configured reserved name.
+		{"tmp-bucket", "tenant", ErrBucketNameReserved},
+		// Test case - 4.
+		// Server credential already owns a bucket.
+		{"new-bucket", owner, ErrTooManyBuckets},
+		// Test case - 5.
+		// Access key override allows a second bucket.
+		{"new-bucket", "tenant", ErrNone},
+		// Test case - 6.
+		// Default limit for other access keys.
+		{"new-bucket", "other", ErrNone},
+	}
+	for i, testCase := range testCases {
+		if s3Error := checkBucketLimits(objAPI, testCase.bucket, testCase.accessKey); s3Error != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error code %d, got %d", i+1, testCase.expectedErr, s3Error)
+		}
+	}
+}
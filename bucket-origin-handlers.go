@@ -0,0 +1,151 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	mux "github.com/gorilla/mux"
+)
+
+// PutBucketOriginHandler - PUT Bucket origin
+// -----------------
+// This implementation of the PUT operation uses the origin
+// subresource to set an upstream objects missing from a bucket are
+// pulled from upon GET and HEAD.
+func (api objectAPIHandlers) PutBucketOriginHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	// If Content-Length is greater than maximum allowed size.
+	if r.ContentLength > maxBucketOriginConfigSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+
+	configBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBucketOriginConfigSize))
+	if err != nil {
+		errorIf(err, "Unable to read origin config.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+
+	config, err := parseBucketOriginConfig(configBuf)
+	if err != nil {
+		errorIf(err, "Unable to parse origin config.")
+		writeErrorResponse(w, r, ErrMalformedOriginConfig, r.URL.Path)
+		return
+	}
+
+	if err = writeBucketOriginConfig(bucket, config); err != nil {
+		errorIf(err, "Unable to write origin config.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// GetBucketOriginHandler - GET Bucket origin
+// -----------------
+// This operation uses the origin subresource to return the origin
+// config of a specified bucket, without its secret key.
+func (api objectAPIHandlers) GetBucketOriginHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	config, err := readBucketOriginConfig(bucket)
+	if err != nil {
+		errorIf(err, "Unable to read origin config.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	config.SecretKey = ""
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		errorIf(err, "Unable to marshal origin config.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, configBuf)
+}
+
+// DeleteBucketOriginHandler - DELETE Bucket origin
+// -----------------
+// This implementation of the DELETE operation uses the origin
+// subresource to stop pulling missing objects of a bucket.
+func (api objectAPIHandlers) DeleteBucketOriginHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if err := removeBucketOriginConfig(bucket); err != nil {
+		errorIf(err, "Unable to remove origin config.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
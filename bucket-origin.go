@@ -0,0 +1,208 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// Origin config is saved alongside the bucket policy.
+	bucketOriginConfigFile = "origin.json"
+
+	// Maximum size of origin config document.
+	maxBucketOriginConfigSize = 1 * 1024 // 1KiB.
+
+	// Time allowed for the origin to respond with headers.
+	originResponseTimeout = 30 * time.Second
+)
+
+// ETags of objects uploaded in a single part are their md5sum.
+var isMD5ETag = regexp.MustCompile("^[0-9a-f]{32}$")
+
+// Client used to pull objects from bucket origins.
+var originClient = &http.Client{
+	Transport: &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: originResponseTimeout,
+	},
+}
+
+// bucketOriginConfig - upstream pulled from when a GET or HEAD finds
+// no object, pulled objects are saved to the bucket before serving.
+type bucketOriginConfig struct {
+	// Upstream objects are fetched from, for example
+	// "https://cdn.example.com/assets" or
+	// "https://s3.amazonaws.com/source-bucket".
+	URL string `json:"url"`
+	// Credentials signing requests to an S3 upstream, anonymous if
+	// not set.
+	AccessKey string `json:"accessKey,omitempty"`
+	SecretKey string `json:"secretKey,omitempty"`
+	// Region of an S3 upstream, defaults to "us-east-1".
+	Region string `json:"region,omitempty"`
+}
+
+// parseBucketOriginConfig - parses and validates origin config.
+func parseBucketOriginConfig(configBuf []byte) (config bucketOriginConfig, err error) {
+	if err = json.Unmarshal(configBuf, &config); err != nil {
+		return bucketOriginConfig{}, err
+	}
+	originURL, err := url.Parse(config.URL)
+	if err != nil {
+		return bucketOriginConfig{}, err
+	}
+	if originURL.Scheme != "http" && originURL.Scheme != "https" {
+		return bucketOriginConfig{}, errors.New("Origin must be a http or https URL.")
+	}
+	if originURL.Host == "" || originURL.RawQuery != "" {
+		return bucketOriginConfig{}, errors.New("Origin must be a URL with a host and no query.")
+	}
+	if (config.AccessKey == "") != (config.SecretKey == "") {
+		return bucketOriginConfig{}, errors.New("Origin credentials must have both access key and secret key.")
+	}
+	if config.AccessKey != "" && (!isValidAccessKey.MatchString(config.AccessKey) || !isValidSecretKey.MatchString(config.SecretKey)) {
+		return bucketOriginConfig{}, errors.New("Origin credentials are not valid.")
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	return config, nil
+}
+
+// readBucketOriginConfig - read origin config, returns
+// BucketOriginNotFound if the bucket has no origin.
+func readBucketOriginConfig(bucket string) (bucketOriginConfig, error) {
+	configBuf, err := readBucketConfig(bucket, bucketOriginConfigFile)
+	if err == errConfigNotFound {
+		return bucketOriginConfig{}, BucketOriginNotFound{Bucket: bucket}
+	}
+	if err != nil {
+		return bucketOriginConfig{}, err
+	}
+	return parseBucketOriginConfig(configBuf)
+}
+
+// writeBucketOriginConfig - save origin config.
+func writeBucketOriginConfig(bucket string, config bucketOriginConfig) error {
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return writeBucketConfig(bucket, bucketOriginConfigFile, configBuf)
+}
+
+// removeBucketOriginConfig - remove origin config.
+func removeBucketOriginConfig(bucket string) error {
+	err := removeBucketConfig(bucket, bucketOriginConfigFile)
+	if err == errConfigNotFound {
+		return BucketOriginNotFound{Bucket: bucket}
+	}
+	return err
+}
+
+// newOriginRequest - returns GET request of an object from the origin,
+// signed with signature v4 if the origin has credentials.
+func newOriginRequest(config bucketOriginConfig, object string) (*http.Request, error) {
+	originURL, err := url.Parse(config.URL)
+	if err != nil {
+		return nil, err
+	}
+	originURL.Path = strings.TrimSuffix(originURL.Path, "/") + "/" + object
+	req, err := http.NewRequest("GET", originURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if config.AccessKey == "" {
+		return req, nil
+	}
+
+	t := time.Now().UTC()
+	hashedPayload := hex.EncodeToString(sum256(nil))
+	signedHeaders := make(http.Header)
+	signedHeaders.Set("X-Amz-Date", t.Format(iso8601Format))
+	signedHeaders.Set("X-Amz-Content-Sha256", hashedPayload)
+	for k, v := range signedHeaders {
+		req.Header[k] = v
+	}
+	canonicalRequest := getCanonicalRequest(signedHeaders, hashedPayload, "", originURL.Path, "GET", originURL.Host)
+	stringToSign := getStringToSign(canonicalRequest, t, config.Region)
+	signature := getSignature(getSigningKey(config.SecretKey, t, config.Region), stringToSign)
+	req.Header.Set("Authorization", strings.Join([]string{
+		signV4Algorithm + " Credential=" + config.AccessKey + "/" + getScope(t, config.Region),
+		"SignedHeaders=" + getSignedHeaders(signedHeaders),
+		"Signature=" + signature,
+	}, ", "))
+	return req, nil
+}
+
+// pullObjectFromOrigin - fetches a missing object from the bucket
+// origin and saves it to the bucket, returns ObjectNotFound if the
+// bucket has no origin or the origin has no such object.
+func pullObjectFromOrigin(objAPI ObjectLayer, bucket, object string) (ObjectInfo, error) {
+	notFoundErr := ObjectNotFound{Bucket: bucket, Object: object}
+	config, err := readBucketOriginConfig(bucket)
+	if err != nil {
+		if _, ok := err.(BucketOriginNotFound); !ok {
+			errorIf(err, "Unable to read origin config of bucket %s.", bucket)
+		}
+		return ObjectInfo{}, notFoundErr
+	}
+	req, err := newOriginRequest(config, object)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	resp, err := originClient.Do(req)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return ObjectInfo{}, notFoundErr
+	case resp.StatusCode != http.StatusOK:
+		return ObjectInfo{}, fmt.Errorf("origin %s responded with %s", config.URL, resp.Status)
+	case resp.ContentLength < 0:
+		return ObjectInfo{}, fmt.Errorf("origin %s responded without Content-Length", config.URL)
+	case isMaxObjectSize(resp.ContentLength):
+		return ObjectInfo{}, fmt.Errorf("origin %s object %s is larger than the maximum object size", config.URL, object)
+	}
+
+	metadata := extractUserMetadata(resp.Header)
+	metadata["content-type"] = resp.Header.Get("Content-Type")
+	metadata["content-encoding"] = resp.Header.Get("Content-Encoding")
+	metadata["cache-control"] = resp.Header.Get("Cache-Control")
+	// Verify integrity of objects whose ETag is their md5sum.
+	if etag := strings.Trim(resp.Header.Get("ETag"), "\""); isMD5ETag.MatchString(etag) {
+		metadata["md5Sum"] = etag
+	}
+	_, err = objAPI.PutObject(bucket, object, resp.ContentLength, resp.Body, metadata)
+	// Concurrent pulls into an overwrite protected bucket fail with
+	// ObjectAlreadyExists, these are served the object saved first.
+	if _, ok := err.(ObjectAlreadyExists); err != nil && !ok {
+		return ObjectInfo{}, err
+	}
+	return objAPI.GetObjectInfo(bucket, object)
+}
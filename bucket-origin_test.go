@@ -0,0 +1,158 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Tests validate parsing of origin config.
+func TestParseBucketOriginConfig(t *testing.T) {
+	testCases := []struct {
+		configBuf      string
+		expectedRegion string
+		shouldPass     bool
+	}{
+		// Test case - 1.
+		{`{"url":"https://cdn.example.com/assets"}`, "us-east-1", true},
+		// Test case - 2.
+		{`{"url":"https://s3.amazonaws.com/source","accessKey":"ACCESSKEY","secretKey":"SECRETKEY","region":"eu-west-1"}`, "eu-west-1", true},
+		// Test case - 3.
+		// Secret key without access key.
+		{`{"url":"https://s3.amazonaws.com/source","secretKey":"SECRETKEY"}`, "", false},
+		// Test case - 4.
+		// URL with a query.
+		{`{"url":"https://cdn.example.com/assets?a=b"}`, "", false},
+		// Test case - 5.
+		// URL is not http.
+		{`{"url":"ftp://cdn.example.com/assets"}`, "", false},
+		// Test case - 6.
+		// Malformed document.
+		{`{"url":`, "", false},
+	}
+	for i, testCase := range testCases {
+		config, err := parseBucketOriginConfig([]byte(testCase.configBuf))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+		if err == nil && config.Region != testCase.expectedRegion {
+			t.Errorf("Test %d: Expected region %s, got %s", i+1, testCase.expectedRegion, config.Region)
+		}
+	}
+}
+
+// Wrapper for calling origin pull tests for both XL multiple disks
+// and single node setup.
+func TestPullObjectFromOrigin(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	ExecObjectLayerTest(t, testPullObjectFromOrigin)
+}
+
+// Tests validate missing objects are pulled from the origin and saved.
+func testPullObjectFromOrigin(obj ObjectLayer, instanceType string, t *testing.T) {
+	data := []byte("hello, origin")
+	md5Sum := md5.Sum(data)
+	var authorization string
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		switch r.URL.Path {
+		case "/assets/object":
+			w.Header().Set("ETag", "\""+hex.EncodeToString(md5Sum[:])+"\"")
+		case "/assets/corrupted":
+			w.Header().Set("ETag", "\"00000000000000000000000000000000\"")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write(data)
+	}))
+	defer origin.Close()
+
+	bucket := "origin-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	defer removeBucketOriginConfig(bucket)
+
+	// Buckets without origin only have local objects.
+	if _, err := pullObjectFromOrigin(obj, bucket, "object"); err == nil {
+		t.Fatalf("%s: Expected ObjectNotFound without origin", instanceType)
+	}
+
+	config := bucketOriginConfig{URL: origin.URL + "/assets/", AccessKey: "ACCESSKEY", SecretKey: "SECRETKEY", Region: "us-east-1"}
+	if err := writeBucketOriginConfig(bucket, config); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+
+	testCases := []struct {
+		object     string
+		shouldPass bool
+	}{
+		// Test case - 1.
+		{"object", true},
+		// Test case - 2.
+		// Not found on the origin either.
+		{"missing", false},
+		// Test case - 3.
+		// ETag of the origin does not match the data.
+		{"corrupted", false},
+	}
+	for i, testCase := range testCases {
+		objInfo, err := pullObjectFromOrigin(obj, bucket, testCase.object)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("%s: Test %d: Expected to pass, failed with %s", instanceType, i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("%s: Test %d: Expected to fail, passed instead", instanceType, i+1)
+		}
+		if !strings.HasPrefix(authorization, signV4Algorithm+" Credential=ACCESSKEY/") {
+			t.Errorf("%s: Test %d: Expected origin request to be signed, got %q", instanceType, i+1, authorization)
+		}
+		if err != nil || !testCase.shouldPass {
+			continue
+		}
+		if objInfo.Size != int64(len(data)) {
+			t.Errorf("%s: Test %d: Expected size %d, got %d", instanceType, i+1, len(data), objInfo.Size)
+		}
+		// Pulled object is saved to the bucket.
+		var buffer bytes.Buffer
+		if err = obj.GetObject(bucket, testCase.object, 0, objInfo.Size, &buffer); err != nil {
+			t.Errorf("%s: Test %d: Expected pulled object to be saved, failed with %s", instanceType, i+1, err)
+		}
+		if !bytes.Equal(buffer.Bytes(), data) {
+			t.Errorf("%s: Test %d: Expected saved object %q, got %q", instanceType, i+1, data, buffer.Bytes())
+		}
+	}
+}
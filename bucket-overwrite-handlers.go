@@ -0,0 +1,122 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	mux "github.com/gorilla/mux"
+)
+
+// PutBucketOverwriteHandler - PUT Bucket overwrite
+// -----------------
+// This implementation of the PUT operation uses the overwrite
+// subresource to protect existing objects of a bucket against being
+// replaced by uploads.
+func (api objectAPIHandlers) PutBucketOverwriteHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	// If Content-Length is greater than maximum allowed size.
+	if r.ContentLength > maxBucketOverwriteConfigSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+
+	configBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBucketOverwriteConfigSize))
+	if err != nil {
+		errorIf(err, "Unable to read overwrite protection.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+
+	config, err := parseBucketOverwriteConfig(configBuf)
+	if err != nil {
+		errorIf(err, "Unable to parse overwrite protection.")
+		writeErrorResponse(w, r, ErrMalformedOverwriteConfig, r.URL.Path)
+		return
+	}
+
+	if err = writeBucketOverwriteConfig(bucket, config); err != nil {
+		errorIf(err, "Unable to write overwrite protection.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// GetBucketOverwriteHandler - GET Bucket overwrite
+// -----------------
+// This operation uses the overwrite subresource to return the
+// overwrite protection of a specified bucket.
+func (api objectAPIHandlers) GetBucketOverwriteHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	config, err := readBucketOverwriteConfig(bucket)
+	if err != nil {
+		errorIf(err, "Unable to read overwrite protection.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		errorIf(err, "Unable to marshal overwrite protection.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, configBuf)
+}
@@ -0,0 +1,76 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "encoding/json"
+
+const (
+	// Overwrite protection is saved alongside the bucket policy.
+	bucketOverwriteConfigFile = "overwrite.json"
+
+	// Maximum size of overwrite protection document.
+	maxBucketOverwriteConfigSize = 1024 // 1KiB.
+)
+
+// bucketOverwriteConfig - overwrite protection of a bucket, objects of
+// a protected bucket can be deleted but never replaced.
+type bucketOverwriteConfig struct {
+	Protected bool `json:"protected"`
+}
+
+// parseBucketOverwriteConfig - parses overwrite protection.
+func parseBucketOverwriteConfig(configBuf []byte) (config bucketOverwriteConfig, err error) {
+	if err = json.Unmarshal(configBuf, &config); err != nil {
+		return bucketOverwriteConfig{}, err
+	}
+	return config, nil
+}
+
+// readBucketOverwriteConfig - read overwrite protection, buckets are
+// not protected unless configured.
+func readBucketOverwriteConfig(bucket string) (bucketOverwriteConfig, error) {
+	configBuf, err := readBucketConfig(bucket, bucketOverwriteConfigFile)
+	if err == errConfigNotFound {
+		return bucketOverwriteConfig{}, nil
+	}
+	if err != nil {
+		return bucketOverwriteConfig{}, err
+	}
+	return parseBucketOverwriteConfig(configBuf)
+}
+
+// writeBucketOverwriteConfig - save overwrite protection.
+func writeBucketOverwriteConfig(bucket string, config bucketOverwriteConfig) error {
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return writeBucketConfig(bucket, bucketOverwriteConfigFile, configBuf)
+}
+
+// isBucketOverwriteProtected - returns true if existing objects of the
+// bucket must not be replaced, callers must hold the namespace lock
+// of the object while checking for and writing it.
+func isBucketOverwriteProtected(bucket string) bool {
+	config, err := readBucketOverwriteConfig(bucket)
+	if err != nil {
+		// Protect objects if protection is unknown.
+		errorIf(err, "Unable to read overwrite protection of bucket %s.", bucket)
+		return true
+	}
+	return config.Protected
+}
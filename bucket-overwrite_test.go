@@ -0,0 +1,125 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Tests validate parsing of overwrite protection.
+func TestParseBucketOverwriteConfig(t *testing.T) {
+	testCases := []struct {
+		configBuf         string
+		expectedProtected bool
+		shouldPass        bool
+	}{
+		// Test case - 1.
+		{`{"protected":true}`, true, true},
+		// Test case - 2.
+		{`{"protected":false}`, false, true},
+		// Test case - 3.
+		{`{}`, false, true},
+		// Test case - 4.
+		// Malformed document.
+		{`{"protected":`, false, false},
+		// Test case - 5.
+		{`{"protected":"yes"}`, false, false},
+	}
+	for i, testCase := range testCases {
+		config, err := parseBucketOverwriteConfig([]byte(testCase.configBuf))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+		if err == nil && config.Protected != testCase.expectedProtected {
+			t.Errorf("Test %d: Expected protected to be %t, got %t", i+1, testCase.expectedProtected, config.Protected)
+		}
+	}
+}
+
+// Wrapper for calling overwrite protection tests for both XL multiple
+// disks and single node setup.
+func TestObjectOverwriteProtection(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	ExecObjectLayerTest(t, testObjectOverwriteProtection)
+}
+
+// Tests validate existing objects of protected buckets are not replaced.
+func testObjectOverwriteProtection(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "protected-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if err := writeBucketOverwriteConfig(bucket, bucketOverwriteConfig{Protected: true}); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	defer removeBucketConfig(bucket, bucketOverwriteConfigFile)
+
+	data := []byte("hello")
+	if _, err := obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if _, err := obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err == nil {
+		t.Fatalf("%s: Expected overwrite to fail.", instanceType)
+	} else if _, ok := err.(ObjectAlreadyExists); !ok {
+		t.Fatalf("%s: Expected ObjectAlreadyExists, got %s", instanceType, err)
+	}
+
+	// Multipart uploads cannot replace objects either.
+	uploadID, err := obj.NewMultipartUpload(bucket, "object", nil)
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	md5Hex, err := obj.PutObjectPart(bucket, "object", uploadID, 1, int64(len(data)), bytes.NewReader(data), "")
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if _, err = obj.CompleteMultipartUpload(bucket, "object", uploadID, []completePart{{PartNumber: 1, ETag: md5Hex}}); err == nil {
+		t.Fatalf("%s: Expected overwrite by multipart upload to fail.", instanceType)
+	} else if _, ok := err.(ObjectAlreadyExists); !ok {
+		t.Fatalf("%s: Expected ObjectAlreadyExists, got %s", instanceType, err)
+	}
+
+	// Deleted objects can be written again.
+	if err = obj.DeleteObject(bucket, "object"); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if _, err = obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+
+	// Objects of other buckets are not protected.
+	if err = obj.MakeBucket("unprotected-bucket"); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = obj.PutObject("unprotected-bucket", "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("%s : %s", instanceType, err.Error())
+		}
+	}
+}
@@ -209,6 +209,9 @@ func (api objectAPIHandlers) PutBucketPolicyHandler(w http.ResponseWriter, r *ht
 		return
 	}
 
+	// Read previous bucket policy for audit, if any.
+	prevBucketPolicyBuf, _ := readBucketPolicy(bucket)
+
 	// Save bucket policy.
 	if err := writeBucketPolicy(bucket, bucketPolicyBuf); err != nil {
 		errorIf(err, "Unable to write bucket policy.")
@@ -220,6 +223,13 @@ func (api objectAPIHandlers) PutBucketPolicyHandler(w http.ResponseWriter, r *ht
 		}
 		return
 	}
+
+	// Record bucket policy change.
+	auditBucketConfigChange(bucket, auditConfigPolicy, getRequestAccessKey(r), prevBucketPolicyBuf, bucketPolicyBuf)
+
+	// Propagate bucket policy to all peers.
+	broadcastBucketPolicy(bucket, bucketPolicyBuf)
+
 	writeSuccessNoContent(w)
 }
 
@@ -243,6 +253,9 @@ func (api objectAPIHandlers) DeleteBucketPolicyHandler(w http.ResponseWriter, r
 		}
 	}
 
+	// Read previous bucket policy for audit, if any.
+	prevBucketPolicyBuf, _ := readBucketPolicy(bucket)
+
 	// Delete bucket access policy.
 	if err := removeBucketPolicy(bucket); err != nil {
 		errorIf(err, "Unable to remove bucket policy.")
@@ -256,6 +269,13 @@ func (api objectAPIHandlers) DeleteBucketPolicyHandler(w http.ResponseWriter, r
 		}
 		return
 	}
+
+	// Record bucket policy removal.
+	auditBucketConfigChange(bucket, auditConfigPolicy, getRequestAccessKey(r), prevBucketPolicyBuf, nil)
+
+	// Propagate bucket policy removal to all peers.
+	broadcastBucketPolicy(bucket, nil)
+
 	writeSuccessNoContent(w)
 }
 
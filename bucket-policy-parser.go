@@ -42,6 +42,9 @@ var supportedActionMap = map[string]struct{}{
 	"s3:AbortMultipartUpload":       {},
 	"s3:ListBucketMultipartUploads": {},
 	"s3:ListMultipartUploadParts":   {},
+	"s3:GetObjectVersion":           {},
+	"s3:DeleteObjectVersion":        {},
+	"s3:ListBucketVersions":         {},
 }
 
 // supported Conditions type.
@@ -197,6 +200,7 @@ var invalidPrefixActions = map[string]struct{}{
 	"s3:GetBucketLocation":          {},
 	"s3:ListBucket":                 {},
 	"s3:ListBucketMultipartUploads": {},
+	"s3:ListBucketVersions":         {},
 	// Add actions which do not honor prefixes.
 }
 
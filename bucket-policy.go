@@ -126,3 +126,42 @@ func writeBucketPolicy(bucket string, accessPolicyBytes []byte) error {
 	// Write bucket policy.
 	return ioutil.WriteFile(bucketPolicyFile, accessPolicyBytes, 0600)
 }
+
+// listBucketPolicies - list all saved bucket policies along with their
+// last modified time.
+func listBucketPolicies() (map[string]BucketPolicyInfo, error) {
+	bucketsConfigPath, err := getBucketsConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	policies := make(map[string]BucketPolicyInfo)
+	entries, err := ioutil.ReadDir(bucketsConfigPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return policies, nil
+		}
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() || !IsValidBucketName(entry.Name()) {
+			continue
+		}
+		bucketPolicyFile := filepath.Join(bucketsConfigPath, entry.Name(), "access-policy.json")
+		st, err := os.Stat(bucketPolicyFile)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		policy, err := ioutil.ReadFile(bucketPolicyFile)
+		if err != nil {
+			return nil, err
+		}
+		policies[entry.Name()] = BucketPolicyInfo{
+			Policy:  policy,
+			ModTime: st.ModTime(),
+		}
+	}
+	return policies, nil
+}
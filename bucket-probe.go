@@ -0,0 +1,118 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// Failed anonymous bucket probes allowed per client within the
+	// window, further probes are throttled until the window ends.
+	bucketProbeMaxFailures = 20
+	bucketProbeWindow      = 1 * time.Minute
+	// Expired clients are pruned once this many are tracked.
+	bucketProbeMaxClients = 10000
+)
+
+// bucketProbeFailures - failed probes of a client in current window.
+type bucketProbeFailures struct {
+	count       int
+	windowStart time.Time
+}
+
+// bucketProbeThrottle - throttles clients repeatedly failing anonymous
+// bucket probes, making bucket name enumeration impractical.
+type bucketProbeThrottle struct {
+	mutex   *sync.Mutex
+	clients map[string]bucketProbeFailures
+}
+
+// Throttle of anonymous bucket probes.
+var globalBucketProbeThrottle = newBucketProbeThrottle()
+
+// newBucketProbeThrottle - initialize a bucket probe throttle.
+func newBucketProbeThrottle() *bucketProbeThrottle {
+	return &bucketProbeThrottle{
+		mutex:   &sync.Mutex{},
+		clients: make(map[string]bucketProbeFailures),
+	}
+}
+
+// isThrottled - returns true if client exceeded failed probes in the
+// current window.
+func (t *bucketProbeThrottle) isThrottled(client string, now time.Time) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	failures, ok := t.clients[client]
+	if !ok || now.Sub(failures.windowStart) > bucketProbeWindow {
+		return false
+	}
+	return failures.count >= bucketProbeMaxFailures
+}
+
+// addFailure - records a failed probe of the client.
+func (t *bucketProbeThrottle) addFailure(client string, now time.Time) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if len(t.clients) >= bucketProbeMaxClients {
+		for c, failures := range t.clients {
+			if now.Sub(failures.windowStart) > bucketProbeWindow {
+				delete(t.clients, c)
+			}
+		}
+	}
+	failures, ok := t.clients[client]
+	if !ok || now.Sub(failures.windowStart) > bucketProbeWindow {
+		failures = bucketProbeFailures{windowStart: now}
+	}
+	failures.count++
+	t.clients[client] = failures
+}
+
+// getRequestClientIP - returns IP address of the client.
+func getRequestClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// enforceBucketProbePolicy - enforces bucket policy for anonymous
+// requests probing a bucket. Missing buckets are reported same as
+// buckets denying access to not reveal which buckets exist, clients
+// repeatedly failing probes are throttled.
+func enforceBucketProbePolicy(action, bucket string, r *http.Request) APIErrorCode {
+	client := getRequestClientIP(r)
+	now := time.Now().UTC()
+	if globalBucketProbeThrottle.isThrottled(client, now) {
+		return ErrSlowDown
+	}
+	s3Error := enforceBucketPolicy(action, bucket, r.URL)
+	if s3Error == ErrNone {
+		return ErrNone
+	}
+	globalBucketProbeThrottle.addFailure(client, now)
+	if s3Error == ErrNoSuchBucket {
+		return ErrAccessDenied
+	}
+	return s3Error
+}
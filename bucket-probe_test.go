@@ -0,0 +1,122 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Tests throttling of clients failing bucket probes.
+func TestBucketProbeThrottle(t *testing.T) {
+	throttle := newBucketProbeThrottle()
+	now := time.Now().UTC()
+	for i := 0; i < bucketProbeMaxFailures; i++ {
+		if throttle.isThrottled("10.0.0.1", now) {
+			t.Fatalf("Client throttled after %d failures", i)
+		}
+		throttle.addFailure("10.0.0.1", now)
+	}
+	if !throttle.isThrottled("10.0.0.1", now) {
+		t.Fatal("Expected client to be throttled")
+	}
+	if throttle.isThrottled("10.0.0.2", now) {
+		t.Fatal("Expected other clients not to be throttled")
+	}
+	// Throttle is lifted once the window ends.
+	if throttle.isThrottled("10.0.0.1", now.Add(bucketProbeWindow+time.Second)) {
+		t.Fatal("Expected client not to be throttled after the window")
+	}
+}
+
+// Tests anonymous probes do not reveal if buckets exist.
+func TestAnonymousBucketProbe(t *testing.T) {
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+	globalBucketProbeThrottle = newBucketProbeThrottle()
+	defer func() {
+		globalBucketProbeThrottle = newBucketProbeThrottle()
+	}()
+
+	bucketName := getRandomBucketName()
+	req, err := newTestRequest("PUT", getMakeBucketURL(testServer.Server.URL, bucketName), 0, nil, testServer.AccessKey, testServer.SecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unable to create bucket, got status %d", resp.StatusCode)
+	}
+
+	testCases := []struct {
+		method         string
+		urlStr         string
+		expectedStatus int
+	}{
+		// Test case - 1.
+		// Existing bucket without policy.
+		{"HEAD", getHEADBucketURL(testServer.Server.URL, bucketName), http.StatusForbidden},
+		// Test case - 2.
+		// Missing bucket.
+		{"HEAD", getHEADBucketURL(testServer.Server.URL, getRandomBucketName()), http.StatusForbidden},
+		// Test case - 3.
+		{"GET", testServer.Server.URL + "/" + bucketName + "?location", http.StatusForbidden},
+		// Test case - 4.
+		{"GET", testServer.Server.URL + "/" + getRandomBucketName() + "?location", http.StatusForbidden},
+	}
+	for i, testCase := range testCases {
+		resp, err := http.DefaultClient.Do(mustNewRequest(t, testCase.method, testCase.urlStr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != testCase.expectedStatus {
+			t.Errorf("Test %d: Expected status %d, got %d", i+1, testCase.expectedStatus, resp.StatusCode)
+		}
+	}
+
+	// Repeated failures are throttled.
+	for i := len(testCases); i < bucketProbeMaxFailures; i++ {
+		resp, err := http.DefaultClient.Do(mustNewRequest(t, "HEAD", getHEADBucketURL(testServer.Server.URL, getRandomBucketName())))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	resp, err = http.DefaultClient.Do(mustNewRequest(t, "HEAD", getHEADBucketURL(testServer.Server.URL, bucketName)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d after repeated failures, got %d", http.StatusServiceUnavailable, resp.StatusCode)
+	}
+}
+
+// mustNewRequest - returns a new anonymous request.
+func mustNewRequest(t *testing.T, method, urlStr string) *http.Request {
+	req, err := http.NewRequest(method, urlStr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
@@ -0,0 +1,501 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Bucket rates are counted in slots of this duration.
+	bucketRatesSlot = 10 * time.Second
+	// Slots kept per bucket, covering the longest window.
+	bucketRatesSlots = 90
+
+	// Bucket rate hook is saved in the config directory.
+	bucketRateHookFile = "bucket-rate-hook.json"
+
+	// Maximum size of bucket rate hook document.
+	maxBucketRateHookSize = 64 * 1024 // 64KiB.
+
+	// States of a bucket against a threshold reported by hook events.
+	bucketRateAbove = "above"
+	bucketRateBelow = "below"
+)
+
+// Sliding windows bucket rates are reported over, the first one is
+// the default window of the hook.
+var bucketRateWindows = []time.Duration{1 * time.Minute, 5 * time.Minute, 15 * time.Minute}
+
+// bucketRateCounts - reads and writes of objects of a bucket.
+type bucketRateCounts struct {
+	Reads        int64
+	Writes       int64
+	BytesRead    int64
+	BytesWritten int64
+}
+
+// bucketRateHistory - counts of a bucket per slot, slots are reused
+// once older than the longest window.
+type bucketRateHistory struct {
+	counts [bucketRatesSlots]bucketRateCounts
+	// Number of the slot counted in each entry since the epoch.
+	numbers [bucketRatesSlots]int64
+}
+
+// add - adds counts to the slot.
+func (h *bucketRateHistory) add(number int64, counts bucketRateCounts) {
+	i := number % bucketRatesSlots
+	if h.numbers[i] != number {
+		h.numbers[i] = number
+		h.counts[i] = bucketRateCounts{}
+	}
+	h.counts[i].Reads += counts.Reads
+	h.counts[i].Writes += counts.Writes
+	h.counts[i].BytesRead += counts.BytesRead
+	h.counts[i].BytesWritten += counts.BytesWritten
+}
+
+// sum - returns counts of the slots completed before the slot, over
+// the given number of slots.
+func (h *bucketRateHistory) sum(number, slots int64) (sum bucketRateCounts) {
+	for n := number - slots; n < number; n++ {
+		i := n % bucketRatesSlots
+		if n < 0 || h.numbers[i] != n {
+			continue
+		}
+		sum.Reads += h.counts[i].Reads
+		sum.Writes += h.counts[i].Writes
+		sum.BytesRead += h.counts[i].BytesRead
+		sum.BytesWritten += h.counts[i].BytesWritten
+	}
+	return sum
+}
+
+// bucketRate - rates of a bucket over a window, per second.
+type bucketRate struct {
+	Window             string  `json:"window"`
+	ReadsPerSec        float64 `json:"readsPerSec"`
+	WritesPerSec       float64 `json:"writesPerSec"`
+	BytesReadPerSec    float64 `json:"bytesReadPerSec"`
+	BytesWrittenPerSec float64 `json:"bytesWrittenPerSec"`
+}
+
+// bucketRates - rates of a bucket over all windows.
+type bucketRates struct {
+	Bucket string       `json:"bucket"`
+	Rates  []bucketRate `json:"rates"`
+}
+
+// bucketRatesMonitor - counts reads and writes of objects per bucket
+// served by this node.
+type bucketRatesMonitor struct {
+	mutex   *sync.Mutex
+	buckets map[string]*bucketRateHistory
+}
+
+// Reads and writes of objects served by this node.
+var globalBucketRates = &bucketRatesMonitor{
+	mutex:   &sync.Mutex{},
+	buckets: make(map[string]*bucketRateHistory),
+}
+
+// getBucketRatesSlot - returns number of the slot a time falls in.
+func getBucketRatesSlot(t time.Time) int64 {
+	return t.UnixNano() / int64(bucketRatesSlot)
+}
+
+// record - accounts a read or write of an object of the bucket.
+func (m *bucketRatesMonitor) record(bucket string, counts bucketRateCounts, now time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	history, ok := m.buckets[bucket]
+	if !ok {
+		history = &bucketRateHistory{}
+		m.buckets[bucket] = history
+	}
+	history.add(getBucketRatesSlot(now), counts)
+}
+
+// getRate - returns rates of the bucket over the window, the window is
+// rounded up to whole slots.
+func (m *bucketRatesMonitor) getRate(bucket string, window time.Duration, now time.Time) bucketRate {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	slots := int64((window + bucketRatesSlot - 1) / bucketRatesSlot)
+	rate := bucketRate{Window: window.String()}
+	history, ok := m.buckets[bucket]
+	if !ok {
+		return rate
+	}
+	sum := history.sum(getBucketRatesSlot(now), slots)
+	seconds := float64(slots) * bucketRatesSlot.Seconds()
+	rate.ReadsPerSec = float64(sum.Reads) / seconds
+	rate.WritesPerSec = float64(sum.Writes) / seconds
+	rate.BytesReadPerSec = float64(sum.BytesRead) / seconds
+	rate.BytesWrittenPerSec = float64(sum.BytesWritten) / seconds
+	return rate
+}
+
+// getBuckets - returns all buckets counted, sorted.
+func (m *bucketRatesMonitor) getBuckets() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	buckets := make([]string, 0, len(m.buckets))
+	for bucket := range m.buckets {
+		buckets = append(buckets, bucket)
+	}
+	sort.Strings(buckets)
+	return buckets
+}
+
+// GetRates - returns rates of all buckets over all windows, sorted by
+// bucket.
+func (m *bucketRatesMonitor) GetRates(now time.Time) []bucketRates {
+	buckets := m.getBuckets()
+	allRates := make([]bucketRates, 0, len(buckets))
+	for _, bucket := range buckets {
+		rates := bucketRates{Bucket: bucket}
+		for _, window := range bucketRateWindows {
+			rates.Rates = append(rates.Rates, m.getRate(bucket, window, now))
+		}
+		allRates = append(allRates, rates)
+	}
+	return allRates
+}
+
+// prune - forgets buckets without reads or writes over the longest
+// window.
+func (m *bucketRatesMonitor) prune(now time.Time) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	number := getBucketRatesSlot(now)
+	for bucket, history := range m.buckets {
+		if history.sum(number+1, bucketRatesSlots) == (bucketRateCounts{}) {
+			delete(m.buckets, bucket)
+		}
+	}
+}
+
+// bucketRateThreshold - rates of a bucket firing the hook, zero rates
+// are not checked.
+type bucketRateThreshold struct {
+	// Bucket checked, all buckets if empty.
+	Bucket             string  `json:"bucket,omitempty"`
+	ReadsPerSec        float64 `json:"readsPerSec,omitempty"`
+	WritesPerSec       float64 `json:"writesPerSec,omitempty"`
+	BytesReadPerSec    float64 `json:"bytesReadPerSec,omitempty"`
+	BytesWrittenPerSec float64 `json:"bytesWrittenPerSec,omitempty"`
+}
+
+// isCrossedBy - returns true if any checked rate reaches the threshold.
+func (threshold bucketRateThreshold) isCrossedBy(rate bucketRate) bool {
+	return (threshold.ReadsPerSec > 0 && rate.ReadsPerSec >= threshold.ReadsPerSec) ||
+		(threshold.WritesPerSec > 0 && rate.WritesPerSec >= threshold.WritesPerSec) ||
+		(threshold.BytesReadPerSec > 0 && rate.BytesReadPerSec >= threshold.BytesReadPerSec) ||
+		(threshold.BytesWrittenPerSec > 0 && rate.BytesWrittenPerSec >= threshold.BytesWrittenPerSec)
+}
+
+// bucketRateHook - HTTP endpoint notified when rates of a bucket cross
+// a threshold, and again when they fall back below it.
+type bucketRateHook struct {
+	// Events are POSTed to this URL.
+	URL string `json:"url"`
+	// Window rates are checked over, one of the reported windows.
+	Window     string                `json:"window,omitempty"`
+	Thresholds []bucketRateThreshold `json:"thresholds"`
+}
+
+// getWindow - returns window of a validated hook.
+func (hook bucketRateHook) getWindow() time.Duration {
+	window, err := time.ParseDuration(hook.Window)
+	if err != nil {
+		return bucketRateWindows[0]
+	}
+	return window
+}
+
+// parseBucketRateHook - parses and validates bucket rate hook.
+func parseBucketRateHook(hookBuf []byte) (hook bucketRateHook, err error) {
+	if err = json.Unmarshal(hookBuf, &hook); err != nil {
+		return bucketRateHook{}, err
+	}
+	if hook.URL == "" {
+		if len(hook.Thresholds) > 0 {
+			return bucketRateHook{}, errors.New("Bucket rate hook URL is missing.")
+		}
+		return hook, nil
+	}
+	u, err := url.Parse(hook.URL)
+	if err != nil {
+		return bucketRateHook{}, err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return bucketRateHook{}, errors.New("Bucket rate hook URL must be an http or https URL.")
+	}
+	if hook.Window != "" {
+		window, err := time.ParseDuration(hook.Window)
+		if err != nil {
+			return bucketRateHook{}, err
+		}
+		isWindow := false
+		for _, w := range bucketRateWindows {
+			isWindow = isWindow || w == window
+		}
+		if !isWindow {
+			return bucketRateHook{}, fmt.Errorf("Bucket rate hook window must be one of %v.", bucketRateWindows)
+		}
+	}
+	for _, threshold := range hook.Thresholds {
+		if threshold.Bucket != "" && !IsValidBucketName(threshold.Bucket) {
+			return bucketRateHook{}, errors.New("Invalid bucket " + threshold.Bucket + " in bucket rate hook.")
+		}
+		if threshold.ReadsPerSec < 0 || threshold.WritesPerSec < 0 || threshold.BytesReadPerSec < 0 || threshold.BytesWrittenPerSec < 0 {
+			return bucketRateHook{}, errors.New("Bucket rate thresholds cannot be negative.")
+		}
+		if threshold == (bucketRateThreshold{Bucket: threshold.Bucket}) {
+			return bucketRateHook{}, errors.New("Bucket rate threshold checks no rate.")
+		}
+	}
+	return hook, nil
+}
+
+// getBucketRateHookPath - get bucket rate hook path.
+func getBucketRateHookPath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configPath, bucketRateHookFile), nil
+}
+
+// readBucketRateHook - read bucket rate hook, no events are sent
+// unless configured.
+func readBucketRateHook() (bucketRateHook, error) {
+	hookPath, err := getBucketRateHookPath()
+	if err != nil {
+		return bucketRateHook{}, err
+	}
+	hookBuf, err := ioutil.ReadFile(hookPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return bucketRateHook{}, nil
+		}
+		return bucketRateHook{}, err
+	}
+	return parseBucketRateHook(hookBuf)
+}
+
+// writeBucketRateHook - save bucket rate hook.
+func writeBucketRateHook(hook bucketRateHook) error {
+	hookBuf, err := json.Marshal(hook)
+	if err != nil {
+		return err
+	}
+	hookPath, err := getBucketRateHookPath()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(hookPath, hookBuf, 0600)
+}
+
+// bucketRateEvent - POSTed to the hook when rates of a bucket cross a
+// threshold, or fall back below it.
+type bucketRateEvent struct {
+	Time      time.Time           `json:"time"`
+	Node      string              `json:"node"`
+	Bucket    string              `json:"bucket"`
+	State     string              `json:"state"`
+	Threshold bucketRateThreshold `json:"threshold"`
+	Rate      bucketRate          `json:"rate"`
+}
+
+// bucketRateHookState - buckets currently above each threshold of the
+// hook, keyed by threshold index and bucket.
+type bucketRateHookState struct {
+	hook  bucketRateHook
+	above map[string]bool
+}
+
+// getBucketRateEvents - returns events of buckets whose state against
+// a threshold changed since the last events sent.
+func getBucketRateEvents(hookState *bucketRateHookState, monitor *bucketRatesMonitor, now time.Time) (events []bucketRateEvent, keys []string) {
+	window := hookState.hook.getWindow()
+	for index, threshold := range hookState.hook.Thresholds {
+		buckets := []string{threshold.Bucket}
+		if threshold.Bucket == "" {
+			buckets = monitor.getBuckets()
+		}
+		for _, bucket := range buckets {
+			rate := monitor.getRate(bucket, window, now)
+			above := threshold.isCrossedBy(rate)
+			key := fmt.Sprintf("%d/%s", index, bucket)
+			if above == hookState.above[key] {
+				continue
+			}
+			state := bucketRateBelow
+			if above {
+				state = bucketRateAbove
+			}
+			events = append(events, bucketRateEvent{
+				Time:      now,
+				Node:      globalNodeName,
+				Bucket:    bucket,
+				State:     state,
+				Threshold: threshold,
+				Rate:      rate,
+			})
+			keys = append(keys, key)
+		}
+	}
+	return events, keys
+}
+
+// sendBucketRateEvent - POSTs an event to the hook URL.
+func sendBucketRateEvent(hookURL string, event bucketRateEvent) error {
+	eventBuf, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	resp, err := http.Post(hookURL, "application/json", bytes.NewReader(eventBuf))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("bucket rate hook %s responded with %s", hookURL, resp.Status)
+	}
+	return nil
+}
+
+// checkBucketRateHook - sends events of buckets which crossed a
+// threshold of the hook, events which could not be sent are retried
+// with the next check. Changing the hook starts over with all buckets
+// below all thresholds.
+func checkBucketRateHook(hookState *bucketRateHookState, hook bucketRateHook, now time.Time) {
+	if !reflect.DeepEqual(hookState.hook, hook) {
+		hookState.hook = hook
+		hookState.above = make(map[string]bool)
+	}
+	if hook.URL == "" {
+		return
+	}
+	events, keys := getBucketRateEvents(hookState, globalBucketRates, now)
+	for i, event := range events {
+		if err := sendBucketRateEvent(hook.URL, event); err != nil {
+			errorIf(err, "Unable to send bucket rate event.")
+			continue
+		}
+		hookState.above[keys[i]] = event.State == bucketRateAbove
+	}
+}
+
+// bucketRatesJob - checks the hook once every slot and forgets idle
+// buckets.
+func bucketRatesJob() {
+	hookState := &bucketRateHookState{above: make(map[string]bool)}
+	for {
+		time.Sleep(bucketRatesSlot)
+		now := time.Now().UTC()
+		hook, err := readBucketRateHook()
+		if err != nil {
+			errorIf(err, "Unable to read bucket rate hook.")
+			continue
+		}
+		checkBucketRateHook(hookState, hook, now)
+		globalBucketRates.prune(now)
+	}
+}
+
+// bucketRatesResponseWriter - counts bytes written to a response and
+// keeps its status code.
+type bucketRatesResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	written    int64
+}
+
+func (w *bucketRatesResponseWriter) WriteHeader(statusCode int) {
+	w.statusCode = statusCode
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *bucketRatesResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// Flush - implements http.Flusher, if supported by the wrapped writer.
+func (w *bucketRatesResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// bucketRatesHandler - counts successful reads and writes of objects
+// per bucket.
+type bucketRatesHandler struct {
+	handler http.Handler
+}
+
+// setBucketRatesHandler to report rates of buckets.
+func setBucketRatesHandler(h http.Handler) http.Handler {
+	return bucketRatesHandler{h}
+}
+
+func (h bucketRatesHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, object := urlPath2BucketObjectName(r.URL)
+	if object == "" || strings.HasPrefix(r.URL.Path, reservedBucket) || !IsValidBucketName(bucket) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	var body *meteringReader
+	if r.Body != nil {
+		body = &meteringReader{ReadCloser: r.Body}
+		r.Body = body
+	}
+	rw := &bucketRatesResponseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+	h.handler.ServeHTTP(rw, r)
+	if rw.statusCode/100 != 2 {
+		return
+	}
+	var counts bucketRateCounts
+	switch r.Method {
+	case "GET", "HEAD":
+		counts = bucketRateCounts{Reads: 1, BytesRead: rw.written}
+	default:
+		counts = bucketRateCounts{Writes: 1}
+		if body != nil {
+			counts.BytesWritten = body.read
+		}
+	}
+	globalBucketRates.record(bucket, counts, time.Now().UTC())
+}
@@ -0,0 +1,216 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Tests validate parsing of bucket rate hook.
+func TestParseBucketRateHook(t *testing.T) {
+	testCases := []struct {
+		hookBuf    string
+		shouldPass bool
+	}{
+		// Test case - 1.
+		// No hook.
+		{`{}`, true},
+		// Test case - 2.
+		{`{"url":"https://scaler.example.com/minio","window":"5m","thresholds":[{"bucket":"photos","writesPerSec":100},{"bytesReadPerSec":1048576}]}`, true},
+		// Test case - 3.
+		// Thresholds without URL.
+		{`{"thresholds":[{"writesPerSec":100}]}`, false},
+		// Test case - 4.
+		// URL which is not http.
+		{`{"url":"ftp://scaler.example.com"}`, false},
+		// Test case - 5.
+		// Window which is not reported.
+		{`{"url":"http://scaler.example.com","window":"2m"}`, false},
+		// Test case - 6.
+		// Invalid bucket name.
+		{`{"url":"http://scaler.example.com","thresholds":[{"bucket":"ab","writesPerSec":1}]}`, false},
+		// Test case - 7.
+		// Negative rate.
+		{`{"url":"http://scaler.example.com","thresholds":[{"readsPerSec":-1}]}`, false},
+		// Test case - 8.
+		// Threshold checking no rate.
+		{`{"url":"http://scaler.example.com","thresholds":[{"bucket":"photos"}]}`, false},
+		// Test case - 9.
+		// Malformed document.
+		{`{"url":`, false},
+	}
+	for i, testCase := range testCases {
+		_, err := parseBucketRateHook([]byte(testCase.hookBuf))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+	}
+}
+
+// Tests rates over sliding windows.
+func TestBucketRatesMonitor(t *testing.T) {
+	monitor := &bucketRatesMonitor{
+		mutex:   &sync.Mutex{},
+		buckets: make(map[string]*bucketRateHistory),
+	}
+	start := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	// 10 writes of 1KiB and 20 reads of 2KiB every slot for a minute.
+	for i := 0; i < 6; i++ {
+		now := start.Add(time.Duration(i) * bucketRatesSlot)
+		monitor.record("photos", bucketRateCounts{Writes: 10, BytesWritten: 10 * 1024}, now)
+		monitor.record("photos", bucketRateCounts{Reads: 20, BytesRead: 20 * 2048}, now)
+	}
+
+	testCases := []struct {
+		now          time.Time
+		window       time.Duration
+		expectedRate bucketRate
+	}{
+		// Test case - 1.
+		{start.Add(time.Minute), time.Minute, bucketRate{"1m0s", 2, 1, 4096, 1024}},
+		// Test case - 2.
+		{start.Add(time.Minute), 5 * time.Minute, bucketRate{"5m0s", 0.4, 0.2, 819.2, 204.8}},
+		// Test case - 3.
+		// Slot in progress is not counted.
+		{start.Add(time.Minute - time.Second), time.Minute, bucketRate{"1m0s", 50.0 / 30, 50.0 / 60, 50 * 2048.0 / 30, 50 * 1024.0 / 60}},
+		// Test case - 4.
+		// Window slid past all requests.
+		{start.Add(2 * time.Minute), time.Minute, bucketRate{"1m0s", 0, 0, 0, 0}},
+	}
+	for i, testCase := range testCases {
+		if rate := monitor.getRate("photos", testCase.window, testCase.now); rate != testCase.expectedRate {
+			t.Errorf("Test %d: Expected rate %+v, got %+v", i+1, testCase.expectedRate, rate)
+		}
+	}
+
+	if rates := monitor.GetRates(start.Add(time.Minute)); len(rates) != 1 || len(rates[0].Rates) != len(bucketRateWindows) {
+		t.Errorf("Expected rates of 1 bucket over %d windows, got %+v", len(bucketRateWindows), rates)
+	}
+	monitor.prune(start.Add(14 * time.Minute))
+	if len(monitor.getBuckets()) != 1 {
+		t.Error("Expected bucket with requests in the longest window to be kept")
+	}
+	monitor.prune(start.Add(16 * time.Minute))
+	if len(monitor.getBuckets()) != 0 {
+		t.Error("Expected idle bucket to be forgotten")
+	}
+}
+
+// Tests hook events are sent when rates cross a threshold and fall
+// back below it.
+func TestBucketRateHook(t *testing.T) {
+	var received []bucketRateEvent
+	endpointStatus := http.StatusInternalServerError
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event bucketRateEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err == nil {
+			received = append(received, event)
+		}
+		w.WriteHeader(endpointStatus)
+	}))
+	defer endpoint.Close()
+
+	start := time.Date(2016, 8, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 6; i++ {
+		globalBucketRates.record("rate-hook", bucketRateCounts{Writes: 10}, start.Add(time.Duration(i)*bucketRatesSlot))
+	}
+	hook := bucketRateHook{
+		URL:        endpoint.URL,
+		Thresholds: []bucketRateThreshold{{Bucket: "rate-hook", WritesPerSec: 1}},
+	}
+	hookState := &bucketRateHookState{above: make(map[string]bool)}
+
+	testCases := []struct {
+		now            time.Time
+		endpointStatus int
+		expectedStates []string
+	}{
+		// Test case - 1.
+		// Below the threshold.
+		{start.Add(30 * time.Second), http.StatusOK, nil},
+		// Test case - 2.
+		// Failed event is retried.
+		{start.Add(time.Minute), http.StatusInternalServerError, []string{bucketRateAbove}},
+		// Test case - 3.
+		{start.Add(time.Minute), http.StatusOK, []string{bucketRateAbove}},
+		// Test case - 4.
+		// Still above the threshold.
+		{start.Add(time.Minute), http.StatusOK, nil},
+		// Test case - 5.
+		{start.Add(2 * time.Minute), http.StatusOK, []string{bucketRateBelow}},
+	}
+	for i, testCase := range testCases {
+		received = nil
+		endpointStatus = testCase.endpointStatus
+		checkBucketRateHook(hookState, hook, testCase.now)
+		if len(received) != len(testCase.expectedStates) {
+			t.Fatalf("Test %d: Expected %d events, got %d", i+1, len(testCase.expectedStates), len(received))
+		}
+		for j, event := range received {
+			if event.Bucket != "rate-hook" || event.State != testCase.expectedStates[j] {
+				t.Errorf("Test %d: Expected %s event of rate-hook, got %+v", i+1, testCase.expectedStates[j], event)
+			}
+		}
+	}
+}
+
+// Tests only successful reads and writes of objects are counted.
+func TestBucketRatesHandler(t *testing.T) {
+	handler := setBucketRatesHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, object := urlPath2BucketObjectName(r.URL); object == "missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		buf := new(bytes.Buffer)
+		buf.ReadFrom(r.Body)
+		w.Write([]byte("hello"))
+	}))
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"PUT", "/rates-handler/object", "hello, world"},
+		{"GET", "/rates-handler/object", ""},
+		{"GET", "/rates-handler/missing", ""},
+		{"GET", "/rates-handler", ""},
+	}
+	for _, request := range requests {
+		req, err := http.NewRequest(request.method, "http://localhost:9000"+request.path, bytes.NewReader([]byte(request.body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	// Rates of the completed slot.
+	rate := globalBucketRates.getRate("rates-handler", bucketRatesSlot, time.Now().UTC().Add(bucketRatesSlot))
+	seconds := bucketRatesSlot.Seconds()
+	expectedRate := bucketRate{bucketRatesSlot.String(), 1 / seconds, 1 / seconds, 5 / seconds, 12 / seconds}
+	if rate != expectedRate {
+		t.Errorf("Expected rate %+v, got %+v", expectedRate, rate)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+const (
+	// Replica config is saved alongside the bucket policy.
+	bucketReplicaConfigFile = "replica.json"
+
+	// Maximum size of replica config document.
+	maxBucketReplicaConfigSize = 1 * 1024 // 1KiB.
+)
+
+// bucketReplicaConfig - marks a bucket as a read-only replica of the
+// same bucket on another deployment. Reads are served locally, writes
+// are proxied to the primary.
+type bucketReplicaConfig struct {
+	// Endpoint of the primary deployment, for example
+	// "https://site-a.example.com:9000".
+	Primary string `json:"primary"`
+	// Access key used by replication to feed the replica, writes
+	// signed with it are applied locally.
+	ReplicationAccessKey string `json:"replicationAccessKey"`
+}
+
+// parseBucketReplicaConfig - parses and validates replica config.
+func parseBucketReplicaConfig(configBuf []byte) (config bucketReplicaConfig, err error) {
+	if err = json.Unmarshal(configBuf, &config); err != nil {
+		return bucketReplicaConfig{}, err
+	}
+	primaryURL, err := url.Parse(config.Primary)
+	if err != nil {
+		return bucketReplicaConfig{}, err
+	}
+	if primaryURL.Scheme != "http" && primaryURL.Scheme != "https" {
+		return bucketReplicaConfig{}, errors.New("Replica primary must be a http or https endpoint.")
+	}
+	if primaryURL.Host == "" || (primaryURL.Path != "" && primaryURL.Path != "/") {
+		return bucketReplicaConfig{}, errors.New("Replica primary must be an endpoint without a path.")
+	}
+	if !isValidAccessKey.MatchString(config.ReplicationAccessKey) {
+		return bucketReplicaConfig{}, errors.New("Replica must have a valid replication access key.")
+	}
+	return config, nil
+}
+
+// readBucketReplicaConfig - read replica config, returns
+// BucketReplicaNotFound if the bucket is not a replica.
+func readBucketReplicaConfig(bucket string) (bucketReplicaConfig, error) {
+	configBuf, err := readBucketConfig(bucket, bucketReplicaConfigFile)
+	if err == errConfigNotFound {
+		return bucketReplicaConfig{}, BucketReplicaNotFound{Bucket: bucket}
+	}
+	if err != nil {
+		return bucketReplicaConfig{}, err
+	}
+	return parseBucketReplicaConfig(configBuf)
+}
+
+// writeBucketReplicaConfig - save replica config.
+func writeBucketReplicaConfig(bucket string, config bucketReplicaConfig) error {
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return writeBucketConfig(bucket, bucketReplicaConfigFile, configBuf)
+}
+
+// removeBucketReplicaConfig - remove replica config.
+func removeBucketReplicaConfig(bucket string) error {
+	err := removeBucketConfig(bucket, bucketReplicaConfigFile)
+	if err == errConfigNotFound {
+		return BucketReplicaNotFound{Bucket: bucket}
+	}
+	return err
+}
+
+// isReplicaWrite - returns true for requests modifying objects of a
+// bucket. Bucket sub-resources are configured per deployment and are
+// never proxied.
+func isReplicaWrite(r *http.Request, object string) bool {
+	switch r.Method {
+	case "GET", "HEAD":
+		return false
+	case "POST":
+		// Multiple object delete and browser uploads.
+		return true
+	}
+	return object != ""
+}
+
+// bucketReplicaHandler - proxies writes to replica buckets to their
+// primary.
+type bucketReplicaHandler struct {
+	handler http.Handler
+}
+
+// setBucketReplicaHandler to serve reads of replica buckets locally
+// and proxy their writes to the primary.
+func setBucketReplicaHandler(h http.Handler) http.Handler {
+	return bucketReplicaHandler{h}
+}
+
+func (h bucketReplicaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, object := urlPath2BucketObjectName(r.URL)
+	if bucket == "" || isMinioMetaBucketName(bucket) || !isReplicaWrite(r, object) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	config, err := readBucketReplicaConfig(bucket)
+	if err != nil {
+		if _, ok := err.(BucketReplicaNotFound); !ok {
+			errorIf(err, "Unable to read replica config of bucket %s.", bucket)
+		}
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	// Replication feeds the replica locally.
+	if getRequestAccessKey(r) == config.ReplicationAccessKey {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	primaryURL, err := url.Parse(config.Primary)
+	if err != nil {
+		errorIf(err, "Unable to parse replica primary of bucket %s.", bucket)
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	// Host header is left untouched, signatures are verified against
+	// the host the client signed for, which requires the primary to
+	// share credentials with the replica.
+	proxy := httputil.NewSingleHostReverseProxy(primaryURL)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		errorIf(err, "Unable to proxy write of bucket %s to replica primary %s.", bucket, config.Primary)
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+	}
+	proxy.ServeHTTP(w, r)
+}
@@ -0,0 +1,138 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests validate parsing of replica config.
+func TestParseBucketReplicaConfig(t *testing.T) {
+	testCases := []struct {
+		configBuf  string
+		shouldPass bool
+	}{
+		// Test case - 1.
+		{`{"primary":"https://site-a:9000","replicationAccessKey":"REPLICATIONKEY"}`, true},
+		// Test case - 2.
+		{`{"primary":"http://site-a/","replicationAccessKey":"REPLICATIONKEY"}`, true},
+		// Test case - 3.
+		// Primary with a path.
+		{`{"primary":"http://site-a/bucket","replicationAccessKey":"REPLICATIONKEY"}`, false},
+		// Test case - 4.
+		// Primary is not a http endpoint.
+		{`{"primary":"site-a:9000","replicationAccessKey":"REPLICATIONKEY"}`, false},
+		// Test case - 5.
+		// Missing replication access key.
+		{`{"primary":"http://site-a"}`, false},
+		// Test case - 6.
+		// Malformed document.
+		{`{"primary":`, false},
+	}
+	for i, testCase := range testCases {
+		_, err := parseBucketReplicaConfig([]byte(testCase.configBuf))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+	}
+}
+
+// Tests validate reads of replica buckets are served locally and
+// writes are proxied to the primary.
+func TestBucketReplicaHandler(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+
+	var primaryHost string
+	primary := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		primaryHost = r.Host
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer primary.Close()
+
+	config := bucketReplicaConfig{
+		Primary:              primary.URL,
+		ReplicationAccessKey: "REPLICATIONKEY",
+	}
+	if err = writeBucketReplicaConfig("replica", config); err != nil {
+		t.Fatalf("Unable to write replica config. %s", err)
+	}
+
+	handler := setBucketReplicaHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	credential := serverConfig.GetCredential()
+	testCases := []struct {
+		method       string
+		urlPath      string
+		accessKey    string
+		secretKey    string
+		expectedCode int
+	}{
+		// Test case - 1.
+		// Reads are served locally.
+		{"GET", "/replica/object", credential.AccessKeyID, credential.SecretAccessKey, http.StatusOK},
+		// Test case - 2.
+		{"HEAD", "/replica/object", credential.AccessKeyID, credential.SecretAccessKey, http.StatusOK},
+		// Test case - 3.
+		// Object writes are proxied.
+		{"PUT", "/replica/object", credential.AccessKeyID, credential.SecretAccessKey, http.StatusAccepted},
+		// Test case - 4.
+		{"DELETE", "/replica/object", credential.AccessKeyID, credential.SecretAccessKey, http.StatusAccepted},
+		// Test case - 5.
+		// Multiple object delete is proxied.
+		{"POST", "/replica?delete", credential.AccessKeyID, credential.SecretAccessKey, http.StatusAccepted},
+		// Test case - 6.
+		// Bucket sub-resources are configured locally.
+		{"PUT", "/replica?policy", credential.AccessKeyID, credential.SecretAccessKey, http.StatusOK},
+		// Test case - 7.
+		// Replication feeds the replica locally.
+		{"PUT", "/replica/object", "REPLICATIONKEY", "REPLICATIONSECRET", http.StatusOK},
+		// Test case - 8.
+		// Buckets which are not replicas are served locally.
+		{"PUT", "/bucket/object", credential.AccessKeyID, credential.SecretAccessKey, http.StatusOK},
+	}
+	for i, testCase := range testCases {
+		primaryHost = ""
+		req, err := newTestRequest(testCase.method, "http://replica.example.com"+testCase.urlPath, 0, nil, testCase.accessKey, testCase.secretKey)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to create request. %s", i+1, err)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedCode {
+			t.Errorf("Test %d: Expected status %d, got %d", i+1, testCase.expectedCode, rec.Code)
+		}
+		// Proxied writes keep the host the client signed for.
+		if testCase.expectedCode == http.StatusAccepted && primaryHost != "replica.example.com" {
+			t.Errorf("Test %d: Expected primary to receive host replica.example.com, got %s", i+1, primaryHost)
+		}
+	}
+}
@@ -0,0 +1,180 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	mux "github.com/gorilla/mux"
+)
+
+// PutBucketReplicationHandler - PUT Bucket replicate
+// -----------------
+// This implementation of the PUT operation uses the replicate
+// subresource to set a target deployment writes to a bucket are
+// replicated to, synchronously or asynchronously.
+func (api objectAPIHandlers) PutBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	// If Content-Length is greater than maximum allowed size.
+	if r.ContentLength > maxBucketReplicationConfigSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+
+	configBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBucketReplicationConfigSize))
+	if err != nil {
+		errorIf(err, "Unable to read replication config.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+
+	config, err := parseBucketReplicationConfig(configBuf)
+	if err != nil {
+		errorIf(err, "Unable to parse replication config.")
+		writeErrorResponse(w, r, ErrMalformedReplicationConfig, r.URL.Path)
+		return
+	}
+
+	// Read previous replication config for audit, if any.
+	prevConfigBuf := getAuditReplicationConfig(bucket)
+
+	if err = writeBucketReplicationConfig(bucket, config); err != nil {
+		errorIf(err, "Unable to write replication config.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	// Record replication config change.
+	auditBucketConfigChange(bucket, auditConfigReplication, getRequestAccessKey(r), prevConfigBuf, getAuditReplicationConfig(bucket))
+	writeSuccessNoContent(w)
+}
+
+// GetBucketReplicationHandler - GET Bucket replicate
+// -----------------
+// This operation uses the replicate subresource to return the
+// replication config of a specified bucket, without its secret key.
+func (api objectAPIHandlers) GetBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	config, err := readBucketReplicationConfig(bucket)
+	if err != nil {
+		errorIf(err, "Unable to read replication config.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	config.SecretKey = ""
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		errorIf(err, "Unable to marshal replication config.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, configBuf)
+}
+
+// DeleteBucketReplicationHandler - DELETE Bucket replicate
+// -----------------
+// This implementation of the DELETE operation uses the replicate
+// subresource to stop replicating writes to a bucket, changes already
+// queued are dropped.
+func (api objectAPIHandlers) DeleteBucketReplicationHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	// Read previous replication config for audit, if any.
+	prevConfigBuf := getAuditReplicationConfig(bucket)
+
+	if err := removeBucketReplicationConfig(bucket); err != nil {
+		errorIf(err, "Unable to remove replication config.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	// Record replication config change.
+	auditBucketConfigChange(bucket, auditConfigReplication, getRequestAccessKey(r), prevConfigBuf, nil)
+	writeSuccessNoContent(w)
+}
+
+// getAuditReplicationConfig - returns the replication config of bucket
+// recorded in the audit log without its secret key, nil if the bucket
+// is not replicated.
+func getAuditReplicationConfig(bucket string) []byte {
+	config, err := readBucketReplicationConfig(bucket)
+	if err != nil {
+		return nil
+	}
+	config.SecretKey = ""
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		return nil
+	}
+	return configBuf
+}
@@ -0,0 +1,389 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+const (
+	// Replication config is saved alongside the bucket policy.
+	bucketReplicationConfigFile = "replication.json"
+
+	// Maximum size of replication config document.
+	maxBucketReplicationConfigSize = 1 * 1024 // 1KiB.
+
+	// Time synchronous writes wait for the target by default, and at
+	// most.
+	defaultReplicationTimeout = 10 * time.Second
+	maxReplicationTimeout     = 5 * time.Minute
+
+	// Time allowed for the target to respond with headers to
+	// asynchronous replication.
+	replicationResponseTimeout = 30 * time.Second
+
+	// Changes waiting for asynchronous replication, changes beyond
+	// are dropped.
+	replicationQueueSize = 10000
+
+	// Attempts of asynchronous replication of a change.
+	replicationAttempts = 3
+
+	// Response header set to the replication status of a write.
+	replicationStatusHeader = "X-Amz-Replication-Status"
+)
+
+// Replication status of a write.
+const (
+	replicationStatusCompleted = "COMPLETED"
+	replicationStatusPending   = "PENDING"
+)
+
+var errReplicationQueueFull = errors.New("Replication queue is full")
+
+// Transport shared by synchronous and asynchronous replication.
+var replicationTransport = &http.Transport{
+	Proxy:                 http.ProxyFromEnvironment,
+	ResponseHeaderTimeout: replicationResponseTimeout,
+}
+
+// Client used by asynchronous replication.
+var replicationClient = &http.Client{Transport: replicationTransport}
+
+// bucketReplicationConfig - target deployment writes to a bucket are
+// replicated to, into the same bucket. The target bucket is usually a
+// replica whose replication access key signs the replicated writes.
+type bucketReplicationConfig struct {
+	// Endpoint of the target deployment, for example
+	// "https://site-b.example.com:9000".
+	Target string `json:"target"`
+	// Credentials signing writes to the target.
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey,omitempty"`
+	// Region of the target, defaults to "us-east-1".
+	Region string `json:"region,omitempty"`
+	// Writes succeed only once the target acknowledged them, writes
+	// not acknowledged within the timeout are replicated
+	// asynchronously instead.
+	Synchronous bool `json:"synchronous,omitempty"`
+	// Seconds synchronous writes wait for the target, defaults to 10.
+	Timeout int `json:"timeout,omitempty"`
+}
+
+// getTimeout - returns time synchronous writes wait for the target.
+func (config bucketReplicationConfig) getTimeout() time.Duration {
+	if config.Timeout == 0 {
+		return defaultReplicationTimeout
+	}
+	return time.Duration(config.Timeout) * time.Second
+}
+
+// parseBucketReplicationConfig - parses and validates replication
+// config.
+func parseBucketReplicationConfig(configBuf []byte) (config bucketReplicationConfig, err error) {
+	if err = json.Unmarshal(configBuf, &config); err != nil {
+		return bucketReplicationConfig{}, err
+	}
+	targetURL, err := url.Parse(config.Target)
+	if err != nil {
+		return bucketReplicationConfig{}, err
+	}
+	if targetURL.Scheme != "http" && targetURL.Scheme != "https" {
+		return bucketReplicationConfig{}, errors.New("Replication target must be a http or https endpoint.")
+	}
+	if targetURL.Host == "" || (targetURL.Path != "" && targetURL.Path != "/") {
+		return bucketReplicationConfig{}, errors.New("Replication target must be an endpoint without a path.")
+	}
+	if !isValidAccessKey.MatchString(config.AccessKey) || !isValidSecretKey.MatchString(config.SecretKey) {
+		return bucketReplicationConfig{}, errors.New("Replication credentials are not valid.")
+	}
+	if config.Timeout < 0 || time.Duration(config.Timeout)*time.Second > maxReplicationTimeout {
+		return bucketReplicationConfig{}, fmt.Errorf("Replication timeout must be between 0 and %d seconds.", int(maxReplicationTimeout/time.Second))
+	}
+	if config.Region == "" {
+		config.Region = "us-east-1"
+	}
+	return config, nil
+}
+
+// readBucketReplicationConfig - read replication config, returns
+// BucketReplicationNotFound if the bucket is not replicated.
+func readBucketReplicationConfig(bucket string) (bucketReplicationConfig, error) {
+	configBuf, err := readBucketConfig(bucket, bucketReplicationConfigFile)
+	if err == errConfigNotFound {
+		return bucketReplicationConfig{}, BucketReplicationNotFound{Bucket: bucket}
+	}
+	if err != nil {
+		return bucketReplicationConfig{}, err
+	}
+	return parseBucketReplicationConfig(configBuf)
+}
+
+// writeBucketReplicationConfig - save replication config.
+func writeBucketReplicationConfig(bucket string, config bucketReplicationConfig) error {
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return writeBucketConfig(bucket, bucketReplicationConfigFile, configBuf)
+}
+
+// removeBucketReplicationConfig - remove replication config.
+func removeBucketReplicationConfig(bucket string) error {
+	err := removeBucketConfig(bucket, bucketReplicationConfigFile)
+	if err == errConfigNotFound {
+		return BucketReplicationNotFound{Bucket: bucket}
+	}
+	return err
+}
+
+// replicationOp - a change of an object to be replicated.
+type replicationOp struct {
+	bucket string
+	object string
+	delete bool
+}
+
+// newReplicationRequest - returns a request to the target signed with
+// signature v4, all headers are signed.
+func newReplicationRequest(config bucketReplicationConfig, method, bucket, object string, body io.Reader, size int64, hashedPayload string, headers http.Header) (*http.Request, error) {
+	targetURL, err := url.Parse(config.Target)
+	if err != nil {
+		return nil, err
+	}
+	targetURL.Path = "/" + bucket + "/" + object
+	req, err := http.NewRequest(method, targetURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+
+	t := time.Now().UTC()
+	signedHeaders := make(http.Header)
+	for k, v := range headers {
+		signedHeaders[k] = v
+	}
+	signedHeaders.Set("X-Amz-Date", t.Format(iso8601Format))
+	signedHeaders.Set("X-Amz-Content-Sha256", hashedPayload)
+	for k, v := range signedHeaders {
+		req.Header[k] = v
+	}
+	canonicalRequest := getCanonicalRequest(signedHeaders, hashedPayload, "", targetURL.Path, method, targetURL.Host)
+	stringToSign := getStringToSign(canonicalRequest, t, config.Region)
+	signature := getSignature(getSigningKey(config.SecretKey, t, config.Region), stringToSign)
+	req.Header.Set("Authorization", strings.Join([]string{
+		signV4Algorithm + " Credential=" + config.AccessKey + "/" + getScope(t, config.Region),
+		"SignedHeaders=" + getSignedHeaders(signedHeaders),
+		"Signature=" + signature,
+	}, ", "))
+	return req, nil
+}
+
+// sendReplication - applies a change of an object to the target,
+// returns nil once the target acknowledged it. Objects deleted since
+// the change are skipped, their delete is replicated on its own.
+func sendReplication(client *http.Client, objAPI ObjectLayer, config bucketReplicationConfig, op replicationOp) error {
+	var req *http.Request
+	var err error
+	if op.delete {
+		req, err = newReplicationRequest(config, "DELETE", op.bucket, op.object, nil, 0, hex.EncodeToString(sum256(nil)), nil)
+		if err != nil {
+			return err
+		}
+	} else {
+		var objInfo ObjectInfo
+		objInfo, err = objAPI.GetObjectInfo(op.bucket, op.object)
+		if err != nil {
+			if _, ok := err.(ObjectNotFound); ok {
+				return nil
+			}
+			return err
+		}
+		// Payload of replicated writes is signed, the object is read
+		// once into a staging file hashing it, and sent from there.
+		var stagingFile *os.File
+		stagingFile, err = ioutil.TempFile("", "minio-replication-")
+		if err != nil {
+			return err
+		}
+		defer func() {
+			stagingFile.Close()
+			os.Remove(stagingFile.Name())
+		}()
+		shaWriter := sha256.New()
+		err = objAPI.GetObject(op.bucket, op.object, 0, objInfo.Size, io.MultiWriter(stagingFile, shaWriter))
+		// Objects overwritten or deleted while staged are skipped,
+		// their change is replicated on its own.
+		if changed, changeErr := isObjectChanged(objAPI, objInfo); changeErr != nil || changed {
+			return changeErr
+		}
+		if err != nil {
+			return err
+		}
+		if _, err = stagingFile.Seek(0, 0); err != nil {
+			return err
+		}
+		headers := make(http.Header)
+		for key, value := range objInfo.UserDefined {
+			headers.Set(key, value)
+		}
+		if len(objInfo.Tags) > 0 {
+			headers.Set(amzTaggingHeader, encodeObjectTags(objInfo.Tags))
+		}
+		if objInfo.ContentType != "" {
+			headers.Set("Content-Type", objInfo.ContentType)
+		}
+		if objInfo.ContentEncoding != "" {
+			headers.Set("Content-Encoding", objInfo.ContentEncoding)
+		}
+		if objInfo.CacheControl != "" {
+			headers.Set("Cache-Control", objInfo.CacheControl)
+		}
+		req, err = newReplicationRequest(config, "PUT", op.bucket, op.object, stagingFile, objInfo.Size, hex.EncodeToString(shaWriter.Sum(nil)), headers)
+		if err != nil {
+			return err
+		}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent:
+		return nil
+	}
+	return fmt.Errorf("replication target %s responded with %s", config.Target, resp.Status)
+}
+
+// isObjectChanged - returns true if the object was overwritten or
+// deleted since objInfo was read.
+func isObjectChanged(objAPI ObjectLayer, objInfo ObjectInfo) (bool, error) {
+	newObjInfo, err := objAPI.GetObjectInfo(objInfo.Bucket, objInfo.Name)
+	if err != nil {
+		if _, ok := err.(ObjectNotFound); ok {
+			return true, nil
+		}
+		return false, err
+	}
+	return newObjInfo.MD5Sum != objInfo.MD5Sum || !newObjInfo.ModTime.Equal(objInfo.ModTime), nil
+}
+
+// Changes waiting for asynchronous replication.
+var globalReplicationQueue = make(chan replicationOp, replicationQueueSize)
+
+// queueReplication - queues a change for asynchronous replication,
+// changes are dropped and logged while the queue is full.
+func queueReplication(op replicationOp) {
+	select {
+	case globalReplicationQueue <- op:
+	default:
+		errorIf(errReplicationQueueFull, "Unable to queue replication of %s/%s.", op.bucket, op.object)
+	}
+}
+
+// replicateChange - replicates a change of an object to the target of
+// its bucket. Synchronous buckets wait for the target to acknowledge
+// the change, changes the target fails to acknowledge within the
+// timeout are logged as a warning and replicated asynchronously.
+// Returns the replication status, empty if the bucket is not
+// replicated.
+func replicateChange(objAPI ObjectLayer, op replicationOp) string {
+	config, err := readBucketReplicationConfig(op.bucket)
+	if err != nil {
+		if _, ok := err.(BucketReplicationNotFound); !ok {
+			errorIf(err, "Unable to read replication config of bucket %s.", op.bucket)
+		}
+		return ""
+	}
+	if !config.Synchronous {
+		queueReplication(op)
+		return replicationStatusPending
+	}
+	client := &http.Client{Transport: replicationTransport, Timeout: config.getTimeout()}
+	if err = sendReplication(client, objAPI, config, op); err == nil {
+		return replicationStatusCompleted
+	}
+	log.WithFields(logrus.Fields{
+		"bucket":  op.bucket,
+		"object":  op.object,
+		"target":  config.Target,
+		"timeout": config.getTimeout().String(),
+		"cause":   err.Error(),
+	}).Warn("Synchronous replication failed, falling back to asynchronous replication.")
+	queueReplication(op)
+	return replicationStatusPending
+}
+
+// replicateObjectWrite - replicates a write of an object, setting the
+// replication status header of the response.
+func replicateObjectWrite(w http.ResponseWriter, objAPI ObjectLayer, bucket, object string) {
+	if status := replicateChange(objAPI, replicationOp{bucket: bucket, object: object}); status != "" {
+		w.Header().Set(replicationStatusHeader, status)
+	}
+}
+
+// replicateCurrentVersion - replicates the current version of an
+// object once one of its versions was removed, a delete if no version
+// is left.
+func replicateCurrentVersion(objAPI ObjectLayer, bucket, object string) string {
+	op := replicationOp{bucket: bucket, object: object}
+	if _, err := objAPI.GetObjectInfo(bucket, object); err != nil {
+		if _, ok := err.(ObjectNotFound); ok {
+			op.delete = true
+		}
+	}
+	return replicateChange(objAPI, op)
+}
+
+// replicationJob - replicates queued changes, runs forever. Changes
+// are retried with a growing delay, and dropped once all attempts
+// failed or the bucket is no longer replicated.
+func replicationJob(objAPI ObjectLayer) {
+	for op := range globalReplicationQueue {
+		for attempt := 1; attempt <= replicationAttempts; attempt++ {
+			config, err := readBucketReplicationConfig(op.bucket)
+			if err != nil {
+				if _, ok := err.(BucketReplicationNotFound); !ok {
+					errorIf(err, "Unable to read replication config of bucket %s.", op.bucket)
+				}
+				break
+			}
+			if err = sendReplication(replicationClient, objAPI, config, op); err == nil {
+				break
+			}
+			if attempt == replicationAttempts {
+				errorIf(err, "Unable to replicate %s/%s after %d attempts.", op.bucket, op.object, replicationAttempts)
+				break
+			}
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+	}
+}
@@ -0,0 +1,349 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Tests validate parsing of replication config.
+func TestParseBucketReplicationConfig(t *testing.T) {
+	testCases := []struct {
+		configBuf       string
+		expectedTimeout time.Duration
+		shouldPass      bool
+	}{
+		// Test case - 1.
+		{`{"target":"https://site-b.example.com:9000","accessKey":"ACCESSKEY","secretKey":"SECRETKEY"}`, defaultReplicationTimeout, true},
+		// Test case - 2.
+		{`{"target":"https://site-b.example.com:9000","accessKey":"ACCESSKEY","secretKey":"SECRETKEY","synchronous":true,"timeout":3}`, 3 * time.Second, true},
+		// Test case - 3.
+		// Target with a path.
+		{`{"target":"https://site-b.example.com:9000/bucket","accessKey":"ACCESSKEY","secretKey":"SECRETKEY"}`, 0, false},
+		// Test case - 4.
+		// Target is not http.
+		{`{"target":"ftp://site-b.example.com","accessKey":"ACCESSKEY","secretKey":"SECRETKEY"}`, 0, false},
+		// Test case - 5.
+		// Missing secret key.
+		{`{"target":"https://site-b.example.com:9000","accessKey":"ACCESSKEY"}`, 0, false},
+		// Test case - 6.
+		// Negative timeout.
+		{`{"target":"https://site-b.example.com:9000","accessKey":"ACCESSKEY","secretKey":"SECRETKEY","timeout":-1}`, 0, false},
+		// Test case - 7.
+		// Timeout above the maximum.
+		{`{"target":"https://site-b.example.com:9000","accessKey":"ACCESSKEY","secretKey":"SECRETKEY","timeout":3600}`, 0, false},
+		// Test case - 8.
+		// Malformed document.
+		{`{"target":`, 0, false},
+	}
+	for i, testCase := range testCases {
+		config, err := parseBucketReplicationConfig([]byte(testCase.configBuf))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+		if err == nil && config.getTimeout() != testCase.expectedTimeout {
+			t.Errorf("Test %d: Expected timeout %s, got %s", i+1, testCase.expectedTimeout, config.getTimeout())
+		}
+	}
+}
+
+// Wrapper for calling replication tests for both XL multiple disks
+// and single node setup.
+func TestReplicateChange(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	ExecObjectLayerTest(t, testReplicateChange)
+}
+
+// Tests validate synchronous replication waits for the target and
+// falls back to asynchronous replication once the target fails.
+func testReplicateChange(obj ObjectLayer, instanceType string, t *testing.T) {
+	data := []byte("hello, replication")
+	var mutex sync.Mutex
+	var method, path, authorization string
+	var body []byte
+	var status int
+	var delay time.Duration
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		method, path = r.Method, r.URL.Path
+		authorization = r.Header.Get("Authorization")
+		body, _ = ioutil.ReadAll(r.Body)
+		respStatus, respDelay := status, delay
+		mutex.Unlock()
+		time.Sleep(respDelay)
+		w.WriteHeader(respStatus)
+	}))
+	defer target.Close()
+
+	bucket := "replicated-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	defer removeBucketReplicationConfig(bucket)
+	if _, err := obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+
+	// Buckets without replication config are not replicated.
+	if replicationStatus := replicateChange(obj, replicationOp{bucket: bucket, object: "object"}); replicationStatus != "" {
+		t.Fatalf("%s: Expected no replication status, got %s", instanceType, replicationStatus)
+	}
+
+	config := bucketReplicationConfig{Target: target.URL, AccessKey: "ACCESSKEY", SecretKey: "SECRETKEY", Region: "us-east-1", Synchronous: true, Timeout: 1}
+	if err := writeBucketReplicationConfig(bucket, config); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+
+	// Capture changes falling back to asynchronous replication.
+	defer func(queue chan replicationOp) { globalReplicationQueue = queue }(globalReplicationQueue)
+	globalReplicationQueue = make(chan replicationOp, 1)
+
+	testCases := []struct {
+		op             replicationOp
+		status         int
+		delay          time.Duration
+		expectedStatus string
+		expectedMethod string
+	}{
+		// Test case - 1.
+		{replicationOp{bucket: bucket, object: "object"}, http.StatusOK, 0, replicationStatusCompleted, "PUT"},
+		// Test case - 2.
+		{replicationOp{bucket: bucket, object: "object", delete: true}, http.StatusNoContent, 0, replicationStatusCompleted, "DELETE"},
+		// Test case - 3.
+		// Target fails the write.
+		{replicationOp{bucket: bucket, object: "object"}, http.StatusInternalServerError, 0, replicationStatusPending, "PUT"},
+		// Test case - 4.
+		// Target does not acknowledge within the timeout.
+		{replicationOp{bucket: bucket, object: "object"}, http.StatusOK, 2 * time.Second, replicationStatusPending, "PUT"},
+	}
+	for i, testCase := range testCases {
+		mutex.Lock()
+		status, delay = testCase.status, testCase.delay
+		mutex.Unlock()
+		replicationStatus := replicateChange(obj, testCase.op)
+		if replicationStatus != testCase.expectedStatus {
+			t.Errorf("%s: Test %d: Expected replication status %s, got %s", instanceType, i+1, testCase.expectedStatus, replicationStatus)
+		}
+		mutex.Lock()
+		if method != testCase.expectedMethod || path != "/"+bucket+"/object" {
+			t.Errorf("%s: Test %d: Expected %s /%s/object, got %s %s", instanceType, i+1, testCase.expectedMethod, bucket, method, path)
+		}
+		if !strings.HasPrefix(authorization, signV4Algorithm+" Credential=ACCESSKEY/") {
+			t.Errorf("%s: Test %d: Expected replicated write to be signed, got %q", instanceType, i+1, authorization)
+		}
+		if method == "PUT" && !bytes.Equal(body, data) {
+			t.Errorf("%s: Test %d: Expected replicated object %q, got %q", instanceType, i+1, data, body)
+		}
+		mutex.Unlock()
+		select {
+		case op := <-globalReplicationQueue:
+			if testCase.expectedStatus != replicationStatusPending {
+				t.Errorf("%s: Test %d: Expected no asynchronous replication, got %v", instanceType, i+1, op)
+			} else if op != testCase.op {
+				t.Errorf("%s: Test %d: Expected %v to be queued, got %v", instanceType, i+1, testCase.op, op)
+			}
+		default:
+			if testCase.expectedStatus == replicationStatusPending {
+				t.Errorf("%s: Test %d: Expected %v to be queued", instanceType, i+1, testCase.op)
+			}
+		}
+	}
+
+	// Objects overwritten or deleted since their info was read are
+	// skipped.
+	objInfo, err := obj.GetObjectInfo(bucket, "object")
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if changed, err := isObjectChanged(obj, objInfo); err != nil || changed {
+		t.Errorf("%s: Expected object unchanged, got %t, %v", instanceType, changed, err)
+	}
+	if _, err = obj.PutObject(bucket, "object", 5, bytes.NewReader([]byte("hello")), nil); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if changed, err := isObjectChanged(obj, objInfo); err != nil || !changed {
+		t.Errorf("%s: Expected overwritten object changed, got %t, %v", instanceType, changed, err)
+	}
+	if err = obj.DeleteObject(bucket, "object"); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if changed, err := isObjectChanged(obj, objInfo); err != nil || !changed {
+		t.Errorf("%s: Expected deleted object changed, got %t, %v", instanceType, changed, err)
+	}
+}
+
+// Tests validate replication configs are recorded without their
+// secret key.
+func TestGetAuditReplicationConfig(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+
+	bucket := "audited-bucket"
+	if configBuf := getAuditReplicationConfig(bucket); configBuf != nil {
+		t.Fatalf("Expected no config, got %s", string(configBuf))
+	}
+	config := bucketReplicationConfig{Target: "https://site-b.example.com", AccessKey: "ACCESSKEY", SecretKey: "SECRETKEY", Region: "us-east-1"}
+	if err = writeBucketReplicationConfig(bucket, config); err != nil {
+		t.Fatalf("Unable to write replication config. %s", err)
+	}
+	configBuf := getAuditReplicationConfig(bucket)
+	if !bytes.Contains(configBuf, []byte("ACCESSKEY")) || bytes.Contains(configBuf, []byte("SECRETKEY")) {
+		t.Fatalf("Expected config without secret key, got %s", string(configBuf))
+	}
+}
+
+// Tests validate writes of every API are replicated.
+func TestReplicatedWritePaths(t *testing.T) {
+	var mutex sync.Mutex
+	var changes []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		changes = append(changes, r.Method+" "+r.URL.Path)
+		mutex.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer target.Close()
+
+	globalWebDAVEnabled = true
+	defer func() { globalWebDAVEnabled = false }()
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+	bucket := makeIntegrationBucket(t, client)
+	config := bucketReplicationConfig{Target: target.URL, AccessKey: "ACCESSKEY", SecretKey: "SECRETKEY", Region: "us-east-1", Synchronous: true, Timeout: 5}
+	if err := writeBucketReplicationConfig(bucket, config); err != nil {
+		t.Fatalf("Unable to write replication config. %s", err)
+	}
+	bucketURL := testServer.Server.URL + webDAVPrefix + "/" + bucket
+	composeBuf := []byte(`<ComposeObject><Source><Key>object</Key></Source><Source><Key>object</Key></Source></ComposeObject>`)
+	versioning := []byte(`<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>`)
+
+	testCases := []struct {
+		name            string
+		send            func() (*http.Response, error)
+		expectedStatus  int
+		expectedChanges []string
+	}{
+		// Test case - 1.
+		{"WebDAV PUT", func() (*http.Response, error) {
+			return sendWebDAVRequest(testServer, "PUT", bucketURL+"/object", nil, "hello")
+		}, http.StatusCreated, []string{"PUT /" + bucket + "/object"}},
+		// Test case - 2.
+		{"WebDAV COPY", func() (*http.Response, error) {
+			return sendWebDAVRequest(testServer, "COPY", bucketURL+"/object", map[string]string{"Destination": bucketURL + "/copy"}, "")
+		}, http.StatusCreated, []string{"PUT /" + bucket + "/copy"}},
+		// Test case - 3.
+		{"WebDAV MOVE", func() (*http.Response, error) {
+			return sendWebDAVRequest(testServer, "MOVE", bucketURL+"/copy", map[string]string{"Destination": bucketURL + "/moved"}, "")
+		}, http.StatusCreated, []string{"PUT /" + bucket + "/moved", "DELETE /" + bucket + "/copy"}},
+		// Test case - 4.
+		{"WebDAV DELETE", func() (*http.Response, error) {
+			return sendWebDAVRequest(testServer, "DELETE", bucketURL+"/moved", nil, "")
+		}, http.StatusNoContent, []string{"DELETE /" + bucket + "/moved"}},
+		// Test case - 5.
+		{"ComposeObject", func() (*http.Response, error) {
+			resp, _, err := client.do("POST", bucket, "composed", url.Values{"compose": {""}}, nil, composeBuf)
+			return resp, err
+		}, http.StatusOK, []string{"PUT /" + bucket + "/composed"}},
+		// Test case - 6.
+		{"PutBucketVersioning", func() (*http.Response, error) {
+			resp, _, err := client.do("PUT", bucket, "", url.Values{"versioning": {""}}, nil, versioning)
+			return resp, err
+		}, http.StatusOK, nil},
+		// Test case - 7.
+		{"PutObject", func() (*http.Response, error) {
+			resp, _, err := client.do("PUT", bucket, "versioned", nil, nil, []byte("hello"))
+			return resp, err
+		}, http.StatusOK, []string{"PUT /" + bucket + "/versioned"}},
+	}
+	for i, testCase := range testCases {
+		mutex.Lock()
+		changes = nil
+		mutex.Unlock()
+		resp, err := testCase.send()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != testCase.expectedStatus {
+			t.Fatalf("Test %d: %s: Expected status %d, got %d", i+1, testCase.name, testCase.expectedStatus, resp.StatusCode)
+		}
+		mutex.Lock()
+		if !reflect.DeepEqual(changes, testCase.expectedChanges) {
+			t.Errorf("Test %d: %s: Expected changes %v, got %v", i+1, testCase.name, testCase.expectedChanges, changes)
+		}
+		mutex.Unlock()
+	}
+
+	// Removing the only version replicates a delete.
+	mutex.Lock()
+	changes = nil
+	mutex.Unlock()
+	resp, _, err := client.do("HEAD", bucket, "versioned", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	versionID := resp.Header.Get(amzVersionIDHeader)
+	resp, respBody, err := client.do("DELETE", bucket, "versioned", url.Values{"versionId": {versionID}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "DeleteObjectVersion", resp, respBody, http.StatusNoContent)
+	mutex.Lock()
+	defer mutex.Unlock()
+	if expectedChanges := []string{"DELETE /" + bucket + "/versioned"}; !reflect.DeepEqual(changes, expectedChanges) {
+		t.Errorf("DeleteObjectVersion: Expected changes %v, got %v", expectedChanges, changes)
+	}
+}
+
+// sendWebDAVRequest - sends a WebDAV request authenticated with the
+// credentials of the test server.
+func sendWebDAVRequest(testServer TestServer, method, urlStr string, headers map[string]string, body string) (*http.Response, error) {
+	req, err := http.NewRequest(method, urlStr, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	req.SetBasicAuth(testServer.AccessKey, testServer.SecretKey)
+	return http.DefaultClient.Do(req)
+}
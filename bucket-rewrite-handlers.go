@@ -0,0 +1,139 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	mux "github.com/gorilla/mux"
+)
+
+// PutBucketRewriteHandler - PUT Bucket rewrite
+// -----------------
+// This implementation of the PUT operation uses the rewrite
+// subresource to add rewrite rules to a bucket. Objects matching a
+// rule are redirected to the rule target upon GET and HEAD.
+func (api objectAPIHandlers) PutBucketRewriteHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	// If Content-Length is greater than maximum allowed size.
+	if r.ContentLength > maxBucketRewriteConfigSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+
+	configBuf, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBucketRewriteConfigSize))
+	if err != nil {
+		errorIf(err, "Unable to read rewrite rules.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+
+	// Parse and validate rewrite rules.
+	if _, err = parseBucketRewriteConfig(configBuf); err != nil {
+		errorIf(err, "Unable to parse rewrite rules.")
+		writeErrorResponse(w, r, ErrMalformedRewriteConfig, r.URL.Path)
+		return
+	}
+
+	// Save rewrite rules.
+	if err = writeBucketRewriteConfig(bucket, configBuf); err != nil {
+		errorIf(err, "Unable to write rewrite rules.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// GetBucketRewriteHandler - GET Bucket rewrite
+// -----------------
+// This operation uses the rewrite subresource to return the rewrite
+// rules of a specified bucket.
+func (api objectAPIHandlers) GetBucketRewriteHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	configBuf, err := readBucketRewriteConfig(bucket)
+	if err != nil {
+		errorIf(err, "Unable to read rewrite rules.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	io.Copy(w, bytes.NewReader(configBuf))
+}
+
+// DeleteBucketRewriteHandler - DELETE Bucket rewrite
+// -----------------
+// This implementation of the DELETE operation uses the rewrite
+// subresource to remove rewrite rules of a bucket.
+func (api objectAPIHandlers) DeleteBucketRewriteHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if err := removeBucketRewriteConfig(bucket); err != nil {
+		errorIf(err, "Unable to remove rewrite rules.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
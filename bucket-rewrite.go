@@ -0,0 +1,141 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	// Rewrite rules are saved alongside the bucket policy.
+	bucketRewriteConfigFile = "rewrite.json"
+
+	// Maximum size of rewrite rules document.
+	maxBucketRewriteConfigSize = 20 * 1024 // 20KiB.
+)
+
+// rewriteRule - redirects objects matching Pattern to Target. Pattern
+// may end with a '*' wildcard, in which case '*' in Target is replaced
+// with the matched suffix. Target is either an object name in the same
+// bucket or an absolute URL.
+type rewriteRule struct {
+	Pattern    string `json:"pattern"`
+	Target     string `json:"target"`
+	StatusCode int    `json:"statusCode"`
+}
+
+// bucketRewriteConfig - list of rewrite rules, first matching rule wins.
+type bucketRewriteConfig struct {
+	Rules []rewriteRule `json:"rules"`
+}
+
+// parseBucketRewriteConfig - parses and validates rewrite rules.
+func parseBucketRewriteConfig(configBuf []byte) (config bucketRewriteConfig, err error) {
+	if err = json.Unmarshal(configBuf, &config); err != nil {
+		return bucketRewriteConfig{}, err
+	}
+	if len(config.Rules) == 0 {
+		return bucketRewriteConfig{}, errors.New("Rewrite config must have at least one rule.")
+	}
+	for _, rule := range config.Rules {
+		if rule.Pattern == "" || rule.Target == "" {
+			return bucketRewriteConfig{}, errors.New("Rewrite rule must have a pattern and a target.")
+		}
+		if strings.Contains(strings.TrimSuffix(rule.Pattern, "*"), "*") {
+			return bucketRewriteConfig{}, errors.New("Rewrite rule pattern may only have a trailing wildcard.")
+		}
+		if !strings.HasSuffix(rule.Pattern, "*") && strings.Contains(rule.Target, "*") {
+			return bucketRewriteConfig{}, errors.New("Rewrite rule target wildcard requires a pattern wildcard.")
+		}
+		switch rule.StatusCode {
+		case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect:
+		default:
+			return bucketRewriteConfig{}, errors.New("Rewrite rule status code must be one of 301, 302 or 307.")
+		}
+	}
+	return config, nil
+}
+
+// match - returns target and status code of the first rule matching
+// the object.
+func (config bucketRewriteConfig) match(object string) (target string, statusCode int, ok bool) {
+	for _, rule := range config.Rules {
+		if strings.HasSuffix(rule.Pattern, "*") {
+			prefix := strings.TrimSuffix(rule.Pattern, "*")
+			if strings.HasPrefix(object, prefix) {
+				target = strings.Replace(rule.Target, "*", strings.TrimPrefix(object, prefix), 1)
+				return target, rule.StatusCode, true
+			}
+			continue
+		}
+		if object == rule.Pattern {
+			return rule.Target, rule.StatusCode, true
+		}
+	}
+	return "", 0, false
+}
+
+// readBucketRewriteConfig - read bucket rewrite rules.
+func readBucketRewriteConfig(bucket string) ([]byte, error) {
+	configBuf, err := readBucketConfig(bucket, bucketRewriteConfigFile)
+	if err == errConfigNotFound {
+		return nil, BucketRewriteNotFound{Bucket: bucket}
+	}
+	return configBuf, err
+}
+
+// removeBucketRewriteConfig - remove bucket rewrite rules.
+func removeBucketRewriteConfig(bucket string) error {
+	err := removeBucketConfig(bucket, bucketRewriteConfigFile)
+	if err == errConfigNotFound {
+		return BucketRewriteNotFound{Bucket: bucket}
+	}
+	return err
+}
+
+// writeBucketRewriteConfig - save bucket rewrite rules.
+func writeBucketRewriteConfig(bucket string, configBuf []byte) error {
+	return writeBucketConfig(bucket, bucketRewriteConfigFile, configBuf)
+}
+
+// getObjectRewrite - returns redirect location and status code if the
+// object matches any rewrite rule of the bucket.
+func getObjectRewrite(bucket, object string) (location string, statusCode int, ok bool) {
+	configBuf, err := readBucketRewriteConfig(bucket)
+	if err != nil {
+		return "", 0, false
+	}
+	config, err := parseBucketRewriteConfig(configBuf)
+	if err != nil {
+		errorIf(err, "Unable to parse rewrite rules of bucket %s.", bucket)
+		return "", 0, false
+	}
+	target, statusCode, ok := config.match(object)
+	if !ok {
+		return "", 0, false
+	}
+	// Absolute URLs are redirected to as is.
+	if strings.Contains(target, "://") {
+		return target, statusCode, true
+	}
+	location = (&url.URL{Path: "/" + bucket + "/" + strings.TrimPrefix(target, "/")}).String()
+	return location, statusCode, true
+}
@@ -0,0 +1,99 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "testing"
+
+// Tests validate parsing of bucket rewrite rules.
+func TestParseBucketRewriteConfig(t *testing.T) {
+	testCases := []struct {
+		config     string
+		shouldPass bool
+	}{
+		// Test case - 1.
+		// Valid prefix rule.
+		{`{"rules":[{"pattern":"old/*","target":"new/*","statusCode":301}]}`, true},
+		// Test case - 2.
+		// Valid exact rule to an external URL.
+		{`{"rules":[{"pattern":"index.html","target":"https://example.com/","statusCode":307}]}`, true},
+		// Test case - 3.
+		// Malformed JSON.
+		{`{"rules":[`, false},
+		// Test case - 4.
+		// No rules.
+		{`{"rules":[]}`, false},
+		// Test case - 5.
+		// Unsupported status code.
+		{`{"rules":[{"pattern":"a","target":"b","statusCode":200}]}`, false},
+		// Test case - 6.
+		// Wildcard in the middle of pattern.
+		{`{"rules":[{"pattern":"a*b","target":"b","statusCode":302}]}`, false},
+		// Test case - 7.
+		// Target wildcard without pattern wildcard.
+		{`{"rules":[{"pattern":"a","target":"b/*","statusCode":302}]}`, false},
+		// Test case - 8.
+		// Empty target.
+		{`{"rules":[{"pattern":"a","target":"","statusCode":302}]}`, false},
+	}
+	for i, testCase := range testCases {
+		_, err := parseBucketRewriteConfig([]byte(testCase.config))
+		if testCase.shouldPass && err != nil {
+			t.Errorf("Test %d: Expected to pass, but failed with %s", i+1, err)
+		}
+		if !testCase.shouldPass && err == nil {
+			t.Errorf("Test %d: Expected to fail, but passed", i+1)
+		}
+	}
+}
+
+// Tests validate matching of objects against bucket rewrite rules.
+func TestBucketRewriteConfigMatch(t *testing.T) {
+	config := bucketRewriteConfig{
+		Rules: []rewriteRule{
+			{Pattern: "docs/v1/*", Target: "docs/v2/*", StatusCode: 301},
+			{Pattern: "docs/*", Target: "https://example.com/docs", StatusCode: 302},
+			{Pattern: "index.htm", Target: "index.html", StatusCode: 307},
+		},
+	}
+	testCases := []struct {
+		object             string
+		expectedTarget     string
+		expectedStatusCode int
+		expectedMatch      bool
+	}{
+		// Test case - 1.
+		// Prefix match replaces wildcard with suffix.
+		{"docs/v1/intro.html", "docs/v2/intro.html", 301, true},
+		// Test case - 2.
+		// First matching rule wins.
+		{"docs/v3/intro.html", "https://example.com/docs", 302, true},
+		// Test case - 3.
+		// Exact match.
+		{"index.htm", "index.html", 307, true},
+		// Test case - 4.
+		// Exact pattern doesn't match as prefix.
+		{"index.html", "", 0, false},
+	}
+	for i, testCase := range testCases {
+		target, statusCode, ok := config.match(testCase.object)
+		if ok != testCase.expectedMatch || target != testCase.expectedTarget || statusCode != testCase.expectedStatusCode {
+			t.Errorf("Test %d: Expected (%s, %d, %t), got (%s, %d, %t)", i+1,
+				testCase.expectedTarget, testCase.expectedStatusCode, testCase.expectedMatch,
+				target, statusCode, ok)
+		}
+	}
+}
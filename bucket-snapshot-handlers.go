@@ -0,0 +1,215 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+
+	mux "github.com/gorilla/mux"
+)
+
+// Header carrying the source "bucket/snapshot" of a clone request.
+const cloneSourceHeader = "X-Minio-Clone-Source"
+
+// Snapshot container for a bucket snapshot.
+type Snapshot struct {
+	SnapshotID   string `xml:"SnapshotId"`
+	CreationDate string // time string of format "2006-01-02T15:04:05.000Z"
+}
+
+// CreateBucketSnapshotResponse container for create snapshot response.
+type CreateBucketSnapshotResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CreateBucketSnapshotResult" json:"-"`
+	Bucket  string
+	Snapshot
+}
+
+// ListBucketSnapshotsResponse container for list snapshots response.
+type ListBucketSnapshotsResponse struct {
+	XMLName   xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketSnapshotsResult" json:"-"`
+	Bucket    string
+	Snapshots []Snapshot `xml:"Snapshot"`
+}
+
+// generates Snapshot response container.
+func generateSnapshot(snapshotInfo BucketSnapshotInfo) Snapshot {
+	return Snapshot{
+		SnapshotID:   snapshotInfo.ID,
+		CreationDate: snapshotInfo.Created.Format(timeFormatAMZ),
+	}
+}
+
+// CreateBucketSnapshotHandler - PUT Bucket snapshot
+// -----------------
+// This implementation of the PUT operation uses the snapshot
+// subresource to create a point in time snapshot of a bucket. Object
+// data is shared with the bucket, no data is copied.
+func (api objectAPIHandlers) CreateBucketSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	// Snapshots are not supported by all backends.
+	if !api.ObjectAPI.Capabilities().Snapshots {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+
+	snapshotInfo, err := api.ObjectAPI.SnapshotBucket(bucket, getUUID())
+	if err != nil {
+		errorIf(err, "Unable to snapshot bucket.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	response := CreateBucketSnapshotResponse{
+		Bucket:   bucket,
+		Snapshot: generateSnapshot(snapshotInfo),
+	}
+	writeSuccessResponse(w, encodeResponse(response))
+}
+
+// ListBucketSnapshotsHandler - GET Bucket snapshot
+// -----------------
+// This operation uses the snapshot subresource to list all snapshots
+// of a bucket, oldest first.
+func (api objectAPIHandlers) ListBucketSnapshotsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	// Snapshots are not supported by all backends.
+	if !api.ObjectAPI.Capabilities().Snapshots {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+
+	snapshots, err := api.ObjectAPI.ListBucketSnapshots(bucket)
+	if err != nil {
+		errorIf(err, "Unable to list bucket snapshots.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	response := ListBucketSnapshotsResponse{Bucket: bucket}
+	for _, snapshotInfo := range snapshots {
+		response.Snapshots = append(response.Snapshots, generateSnapshot(snapshotInfo))
+	}
+	writeSuccessResponse(w, encodeResponse(response))
+}
+
+// DeleteBucketSnapshotHandler - DELETE Bucket snapshot
+// -----------------
+// This implementation of the DELETE operation uses the snapshot
+// subresource to remove a snapshot of a bucket.
+func (api objectAPIHandlers) DeleteBucketSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	snapshotID := vars["snapshotID"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	// Snapshots are not supported by all backends.
+	if !api.ObjectAPI.Capabilities().Snapshots {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+
+	if err := api.ObjectAPI.DeleteBucketSnapshot(bucket, snapshotID); err != nil {
+		errorIf(err, "Unable to delete bucket snapshot.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessNoContent(w)
+}
+
+// CloneBucketSnapshotHandler - PUT Bucket clone
+// -----------------
+// This implementation of the PUT operation uses the clone subresource
+// to create a new bucket from the snapshot given in the
+// X-Minio-Clone-Source header as "bucket/snapshot".
+func (api objectAPIHandlers) CloneBucketSnapshotHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	// Snapshots are not supported by all backends.
+	if !api.ObjectAPI.Capabilities().Snapshots {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+
+	cloneSource := strings.TrimPrefix(r.Header.Get(cloneSourceHeader), "/")
+	tokens := strings.SplitN(cloneSource, "/", 2)
+	if len(tokens) != 2 || !IsValidBucketName(tokens[0]) || !isValidSnapshotID(tokens[1]) {
+		writeErrorResponse(w, r, ErrInvalidCloneSource, r.URL.Path)
+		return
+	}
+	sourceBucket, snapshotID := tokens[0], tokens[1]
+
+	if err := api.ObjectAPI.CloneBucketSnapshot(sourceBucket, snapshotID, bucket); err != nil {
+		errorIf(err, "Unable to clone bucket snapshot.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	// Make sure to add Location information here only for bucket
+	w.Header().Set("Location", getLocation(r))
+	writeSuccessResponse(w, nil)
+}
@@ -0,0 +1,265 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"strings"
+
+	mux "github.com/gorilla/mux"
+)
+
+// ObjectVersion container for a version of an object.
+type ObjectVersion struct {
+	XMLName      xml.Name `xml:"Version" json:"-"`
+	Key          string
+	VersionID    string `xml:"VersionId"`
+	IsLatest     bool
+	LastModified string // time string of format "2006-01-02T15:04:05.000Z"
+	ETag         string
+	Size         int64
+	Owner        Owner
+	StorageClass string
+}
+
+// DeleteMarkerVersion container for a delete marker of an object.
+type DeleteMarkerVersion struct {
+	XMLName      xml.Name `xml:"DeleteMarker" json:"-"`
+	Key          string
+	VersionID    string `xml:"VersionId"`
+	IsLatest     bool
+	LastModified string // time string of format "2006-01-02T15:04:05.000Z"
+	Owner        Owner
+}
+
+// ListVersionsResponse container for list object versions response.
+type ListVersionsResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListVersionsResult" json:"-"`
+
+	Name            string
+	Prefix          string
+	KeyMarker       string
+	VersionIDMarker string `xml:"VersionIdMarker"`
+	MaxKeys         int
+	Delimiter       string `xml:",omitempty"`
+	IsTruncated     bool
+
+	// When response is truncated, the key-marker and version-id-marker
+	// of the subsequent request.
+	NextKeyMarker       string `xml:",omitempty"`
+	NextVersionIDMarker string `xml:"NextVersionIdMarker,omitempty"`
+
+	// Versions and delete markers, in the order they are listed.
+	Versions       []interface{}
+	CommonPrefixes []CommonPrefix
+}
+
+// generates a ListObjectVersions response for the bucket.
+func generateListVersionsResponse(bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int, resp ListObjectVersionsInfo) ListVersionsResponse {
+	owner := Owner{ID: bucketOwnerID, DisplayName: "minio"}
+	data := ListVersionsResponse{
+		Name:                bucket,
+		Prefix:              prefix,
+		KeyMarker:           keyMarker,
+		VersionIDMarker:     versionIDMarker,
+		MaxKeys:             maxKeys,
+		Delimiter:           delimiter,
+		IsTruncated:         resp.IsTruncated,
+		NextKeyMarker:       resp.NextKeyMarker,
+		NextVersionIDMarker: resp.NextVersionIDMarker,
+	}
+	for _, object := range resp.Objects {
+		if object.DeleteMarker {
+			data.Versions = append(data.Versions, DeleteMarkerVersion{
+				Key:          object.Name,
+				VersionID:    getVersionID(object.VersionID),
+				IsLatest:     object.IsLatest,
+				LastModified: object.ModTime.UTC().Format(timeFormatAMZ),
+				Owner:        owner,
+			})
+			continue
+		}
+		version := ObjectVersion{
+			Key:          object.Name,
+			VersionID:    getVersionID(object.VersionID),
+			IsLatest:     object.IsLatest,
+			LastModified: object.ModTime.UTC().Format(timeFormatAMZ),
+			Size:         object.Size,
+			Owner:        owner,
+			StorageClass: "STANDARD",
+		}
+		if object.MD5Sum != "" {
+			version.ETag = "\"" + object.MD5Sum + "\""
+		}
+		data.Versions = append(data.Versions, version)
+	}
+	for _, prefix := range resp.Prefixes {
+		data.CommonPrefixes = append(data.CommonPrefixes, CommonPrefix{Prefix: prefix})
+	}
+	return data
+}
+
+// PutBucketVersioningHandler - PUT Bucket versioning
+// -----------------
+// This implementation of the PUT operation uses the versioning
+// subresource to enable or suspend versioning of a bucket. Versioning
+// cannot be turned off once configured.
+func (api objectAPIHandlers) PutBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	// Versioning is not supported by all backends.
+	if !api.ObjectAPI.Capabilities().Versioning {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	// If Content-Length is greater than maximum allowed size.
+	if r.ContentLength > maxBucketVersioningConfigSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+
+	config, err := parseVersioningConfiguration(r.Body)
+	if err != nil {
+		errorIf(err, "Unable to parse versioning.")
+		if err == errInvalidVersioningStatus {
+			writeErrorResponse(w, r, ErrIllegalVersioningConfig, r.URL.Path)
+		} else {
+			writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		}
+		return
+	}
+
+	if err = writeBucketVersioningConfig(bucket, config); err != nil {
+		errorIf(err, "Unable to write versioning.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// GetBucketVersioningHandler - GET Bucket versioning
+// -----------------
+// This operation uses the versioning subresource to return the
+// versioning status of a bucket, buckets never configured have none.
+func (api objectAPIHandlers) GetBucketVersioningHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	config, err := readBucketVersioningConfig(bucket)
+	if err != nil {
+		errorIf(err, "Unable to read versioning.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, encodeResponse(config))
+}
+
+// ListObjectVersionsHandler - GET Bucket versions
+// -----------------
+// This operation uses the versions subresource to list versions and
+// delete markers of objects in a bucket, newest first for each key.
+func (api objectAPIHandlers) ListObjectVersionsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypeAnonymous:
+		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
+		if s3Error := enforceBucketPolicy("s3:ListBucketVersions", bucket, r.URL); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	case authTypeSigned, authTypePresigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	prefix, keyMarker, versionIDMarker, delimiter, maxkeys := getListObjectVersionsArgs(r.URL.Query())
+	if maxkeys < 0 {
+		writeErrorResponse(w, r, ErrInvalidMaxKeys, r.URL.Path)
+		return
+	}
+	// Versions are not streamed like objects of ListObjects, listings
+	// are built in memory and keep the limit of maxObjectList.
+	if maxkeys > maxObjectList {
+		maxkeys = maxObjectList
+	}
+	// Verify if delimiter is anything other than '/', which we do not support.
+	if delimiter != "" && delimiter != "/" {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+	// Marker not common with prefix is not implemented.
+	if keyMarker != "" && !strings.HasPrefix(keyMarker, prefix) {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+
+	listVersionsInfo, err := api.ObjectAPI.ListObjectVersions(bucket, prefix, keyMarker, versionIDMarker, delimiter, maxkeys)
+	if err != nil {
+		errorIf(err, "Unable to list object versions.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	response := generateListVersionsResponse(bucket, prefix, keyMarker, versionIDMarker, delimiter, maxkeys, listVersionsInfo)
+	writeSuccessResponse(w, encodeResponse(response))
+}
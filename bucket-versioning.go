@@ -0,0 +1,191 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+)
+
+const (
+	// Versioning is saved alongside the bucket policy.
+	bucketVersioningConfigFile = "versioning.json"
+
+	// Maximum size of a PUT versioning request body.
+	maxBucketVersioningConfigSize = 1024 // 1KiB.
+
+	// Status of versioning, buckets never configured have none.
+	versioningEnabled   = "Enabled"
+	versioningSuspended = "Suspended"
+
+	// Internal metadata of object versions, never accepted from
+	// clients. Versions written without versioning enabled have no
+	// version id, they are the "null" version.
+	objectVersionIDKey    = "X-Minio-Internal-Version-Id"
+	objectDeleteMarkerKey = "X-Minio-Internal-Delete-Marker"
+
+	// Version id of versions written without versioning enabled.
+	nullVersionID = "null"
+
+	// Headers reporting the version of an object.
+	amzVersionIDHeader    = "X-Amz-Version-Id"
+	amzDeleteMarkerHeader = "X-Amz-Delete-Marker"
+)
+
+// errInvalidVersioningStatus - versioning can only be enabled or
+// suspended, never turned off once configured.
+var errInvalidVersioningStatus = errors.New("Invalid versioning status")
+
+// versioningConfiguration - versioning of a bucket, as sent and
+// returned by the versioning subresource.
+type versioningConfiguration struct {
+	XMLName xml.Name `xml:"VersioningConfiguration" json:"-"`
+	Status  string   `xml:"Status,omitempty" json:"status"`
+}
+
+// parseVersioningConfiguration - parses versioning of a PUT versioning
+// request.
+func parseVersioningConfiguration(reader io.Reader) (config versioningConfiguration, err error) {
+	if err = xml.NewDecoder(io.LimitReader(reader, maxBucketVersioningConfigSize)).Decode(&config); err != nil {
+		return versioningConfiguration{}, err
+	}
+	if config.Status != versioningEnabled && config.Status != versioningSuspended {
+		return versioningConfiguration{}, errInvalidVersioningStatus
+	}
+	return config, nil
+}
+
+// readBucketVersioningConfig - read versioning, buckets are not
+// versioned unless configured.
+func readBucketVersioningConfig(bucket string) (versioningConfiguration, error) {
+	configBuf, err := readBucketConfig(bucket, bucketVersioningConfigFile)
+	if err == errConfigNotFound {
+		return versioningConfiguration{}, nil
+	}
+	if err != nil {
+		return versioningConfiguration{}, err
+	}
+	var config versioningConfiguration
+	if err = json.Unmarshal(configBuf, &config); err != nil {
+		return versioningConfiguration{}, err
+	}
+	return config, nil
+}
+
+// writeBucketVersioningConfig - save versioning.
+func writeBucketVersioningConfig(bucket string, config versioningConfiguration) error {
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return writeBucketConfig(bucket, bucketVersioningConfigFile, configBuf)
+}
+
+// removeBucketVersioningConfig - remove versioning of a deleted
+// bucket.
+func removeBucketVersioningConfig(bucket string) error {
+	return removeBucketConfig(bucket, bucketVersioningConfigFile)
+}
+
+// getBucketVersioning - returns the versioning status of a bucket,
+// empty if never configured.
+func getBucketVersioning(bucket string) string {
+	config, err := readBucketVersioningConfig(bucket)
+	if err != nil {
+		// Keep versions if versioning is unknown.
+		errorIf(err, "Unable to read versioning of bucket %s.", bucket)
+		return versioningEnabled
+	}
+	return config.Status
+}
+
+// getVersionID - returns the version id of an object as reported to
+// clients.
+func getVersionID(versionID string) string {
+	if versionID == "" {
+		return nullVersionID
+	}
+	return versionID
+}
+
+// isValidVersionID - returns true for the null version id and version
+// ids generated by getUUID.
+func isValidVersionID(versionID string) bool {
+	if versionID == nullVersionID {
+		return true
+	}
+	if len(versionID) != 36 {
+		return false
+	}
+	for index, char := range versionID {
+		switch index {
+		case 8, 13, 18, 23:
+			if char != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdef", char) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// stampObjectVersion - records a new version id in metadata of objects
+// written to buckets with versioning enabled, other objects are the
+// null version.
+func stampObjectVersion(bucket string, metadata map[string]string) {
+	delete(metadata, objectDeleteMarkerKey)
+	if getBucketVersioning(bucket) == versioningEnabled {
+		metadata[objectVersionIDKey] = getUUID()
+		return
+	}
+	delete(metadata, objectVersionIDKey)
+}
+
+// objectVersionLayer - reads a version of objects instead of their
+// latest version, all other operations are passed on.
+type objectVersionLayer struct {
+	ObjectLayer
+	versionID string
+}
+
+// GetObject - reads the version of the object.
+func (l objectVersionLayer) GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer) error {
+	return l.ObjectLayer.GetObjectVersion(bucket, object, l.versionID, startOffset, length, writer)
+}
+
+// GetObjectInfo - returns info of the version of the object.
+func (l objectVersionLayer) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
+	return l.ObjectLayer.GetObjectVersionInfo(bucket, object, l.versionID)
+}
+
+// setVersionIDHeader - sets the version id of an object written to a
+// versioned bucket, null versions have none.
+func setVersionIDHeader(w http.ResponseWriter, objAPI ObjectLayer, bucket, object string) {
+	if getBucketVersioning(bucket) == "" {
+		return
+	}
+	if objInfo, err := objAPI.GetObjectInfo(bucket, object); err == nil && objInfo.VersionID != "" {
+		w.Header().Set(amzVersionIDHeader, objInfo.VersionID)
+	}
+}
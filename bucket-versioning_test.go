@@ -0,0 +1,231 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+// Tests validate parsing of versioning configurations.
+func TestParseVersioningConfiguration(t *testing.T) {
+	testCases := []struct {
+		configBuf      string
+		expectedStatus string
+		expectedErr    error
+	}{
+		// Test case - 1.
+		{`<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>`, versioningEnabled, nil},
+		// Test case - 2.
+		{`<VersioningConfiguration><Status>Suspended</Status></VersioningConfiguration>`, versioningSuspended, nil},
+		// Test case - 3.
+		// Versioning cannot be turned off.
+		{`<VersioningConfiguration></VersioningConfiguration>`, "", errInvalidVersioningStatus},
+		// Test case - 4.
+		{`<VersioningConfiguration><Status>enabled</Status></VersioningConfiguration>`, "", errInvalidVersioningStatus},
+	}
+	for i, testCase := range testCases {
+		config, err := parseVersioningConfiguration(strings.NewReader(testCase.configBuf))
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		if err == nil && config.Status != testCase.expectedStatus {
+			t.Errorf("Test %d: Expected status %s, got %s", i+1, testCase.expectedStatus, config.Status)
+		}
+	}
+
+	// Malformed document.
+	if _, err := parseVersioningConfiguration(strings.NewReader(`<VersioningConfiguration>`)); err == nil {
+		t.Errorf("Expected malformed document to fail.")
+	}
+}
+
+// Tests validate version ids accepted in requests.
+func TestIsValidVersionID(t *testing.T) {
+	testCases := []struct {
+		versionID string
+		isValid   bool
+	}{
+		// Test case - 1.
+		{nullVersionID, true},
+		// Test case - 2.
+		{getUUID(), true},
+		// Test case - 3.
+		{"", false},
+		// Test case - 4.
+		{"../../object", false},
+		// Test case - 5.
+		{strings.ToUpper(getUUID()), false},
+	}
+	for i, testCase := range testCases {
+		if isValid := isValidVersionID(testCase.versionID); isValid != testCase.isValid {
+			t.Errorf("Test %d: Expected %s to be valid %t, got %t", i+1, testCase.versionID, testCase.isValid, isValid)
+		}
+	}
+}
+
+// Wrapper for calling object versioning tests for both XL multiple
+// disks and single node setup.
+func TestObjectVersioning(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	ExecObjectLayerTest(t, testObjectVersioning)
+}
+
+// Tests versions kept by overwrites and deletes of objects in
+// versioned buckets.
+func testObjectVersioning(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "versioned-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if !obj.Capabilities().Versioning {
+		if _, err := obj.ListObjectVersions(bucket, "", "", "", "", 1000); err == nil {
+			t.Fatalf("%s: Expected listing versions to fail.", instanceType)
+		} else if _, ok := err.(VersioningNotSupported); !ok {
+			t.Fatalf("%s: Expected VersioningNotSupported, got %s", instanceType, err)
+		}
+		return
+	}
+
+	// Objects written before versioning is enabled are null versions.
+	data := []byte("version-0")
+	if _, err := obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if err := writeBucketVersioningConfig(bucket, versioningConfiguration{Status: versioningEnabled}); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	defer removeBucketVersioningConfig(bucket)
+
+	var versionIDs []string
+	for _, data := range [][]byte{[]byte("version-1"), []byte("version-2")} {
+		if _, err := obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("%s : %s", instanceType, err.Error())
+		}
+		objInfo, err := obj.GetObjectInfo(bucket, "object")
+		if err != nil {
+			t.Fatalf("%s : %s", instanceType, err.Error())
+		}
+		if !isValidVersionID(objInfo.VersionID) || objInfo.VersionID == nullVersionID {
+			t.Fatalf("%s: Expected a version id, got %q", instanceType, objInfo.VersionID)
+		}
+		versionIDs = append(versionIDs, objInfo.VersionID)
+	}
+
+	// Previous versions stay readable.
+	testCases := []struct {
+		versionID    string
+		expectedData string
+	}{
+		// Test case - 1.
+		{nullVersionID, "version-0"},
+		// Test case - 2.
+		{versionIDs[0], "version-1"},
+		// Test case - 3.
+		{versionIDs[1], "version-2"},
+	}
+	for i, testCase := range testCases {
+		var buffer bytes.Buffer
+		if err := obj.GetObjectVersion(bucket, "object", testCase.versionID, 0, int64(len(testCase.expectedData)), &buffer); err != nil {
+			t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+		}
+		if buffer.String() != testCase.expectedData {
+			t.Errorf("%s: Test %d: Expected %s, got %s", instanceType, i+1, testCase.expectedData, buffer.String())
+		}
+	}
+	if _, err := obj.GetObjectVersionInfo(bucket, "object", getUUID()); err == nil {
+		t.Fatalf("%s: Expected unknown version to fail.", instanceType)
+	} else if _, ok := err.(VersionNotFound); !ok {
+		t.Fatalf("%s: Expected VersionNotFound, got %s", instanceType, err)
+	}
+
+	// Deletes leave a delete marker as the latest version.
+	if err := obj.DeleteObject(bucket, "object"); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if _, err := obj.GetObjectInfo(bucket, "object"); err == nil {
+		t.Fatalf("%s: Expected deleted object to be missing.", instanceType)
+	}
+	result, err := obj.ListObjectVersions(bucket, "", "", "", "", 1000)
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if len(result.Objects) != 4 {
+		t.Fatalf("%s: Expected 4 versions, got %d", instanceType, len(result.Objects))
+	}
+	marker := result.Objects[0]
+	if !marker.DeleteMarker || !marker.IsLatest {
+		t.Errorf("%s: Expected the latest version to be a delete marker, got %v", instanceType, marker)
+	}
+	for i, versionID := range []string{versionIDs[1], versionIDs[0], ""} {
+		if result.Objects[i+1].VersionID != versionID || result.Objects[i+1].DeleteMarker {
+			t.Errorf("%s: Expected version %d to be %q, got %q", instanceType, i+2, versionID, result.Objects[i+1].VersionID)
+		}
+	}
+
+	// Listings are paged by key and version id.
+	result, err = obj.ListObjectVersions(bucket, "", "", "", "", 2)
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if !result.IsTruncated || result.NextKeyMarker != "object" || result.NextVersionIDMarker != versionIDs[1] {
+		t.Fatalf("%s: Unexpected first page %v", instanceType, result)
+	}
+	result, err = obj.ListObjectVersions(bucket, "", result.NextKeyMarker, result.NextVersionIDMarker, "", 2)
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if result.IsTruncated || len(result.Objects) != 2 || result.Objects[0].VersionID != versionIDs[0] {
+		t.Fatalf("%s: Unexpected second page %v", instanceType, result)
+	}
+
+	// Deleting the delete marker restores the previous version.
+	if err = obj.DeleteObjectVersion(bucket, "object", marker.VersionID); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	objInfo, err := obj.GetObjectInfo(bucket, "object")
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if objInfo.VersionID != versionIDs[1] {
+		t.Errorf("%s: Expected version %s restored, got %s", instanceType, versionIDs[1], objInfo.VersionID)
+	}
+
+	// Buckets holding versions are not empty.
+	for _, versionID := range []string{versionIDs[1], versionIDs[0], nullVersionID} {
+		if err = obj.DeleteObjectVersion(bucket, "object", versionID); err != nil {
+			t.Fatalf("%s : %s", instanceType, err.Error())
+		}
+		if versionID != nullVersionID {
+			if err = obj.DeleteBucket(bucket); err == nil {
+				t.Fatalf("%s: Expected deleting bucket with versions to fail.", instanceType)
+			}
+		}
+	}
+	if err = obj.DeleteBucket(bucket); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+}
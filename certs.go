@@ -17,8 +17,10 @@
 package main
 
 import (
+	"crypto/tls"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/minio/go-homedir"
 )
@@ -88,3 +90,37 @@ func isSSL() bool {
 	}
 	return false
 }
+
+// certStore - TLS certificate served to clients, replaced without
+// dropping connections when certificates are reloaded.
+type certStore struct {
+	mutex *sync.RWMutex
+	cert  *tls.Certificate
+}
+
+// TLS certificate of the server, loaded only if SSL is enabled.
+var globalCerts = &certStore{mutex: &sync.RWMutex{}}
+
+// loadCertificate - loads and parses cert and key file.
+func loadCertificate() (*tls.Certificate, error) {
+	cert, err := tls.LoadX509KeyPair(mustGetCertFile(), mustGetKeyFile())
+	if err != nil {
+		return nil, err
+	}
+	return &cert, nil
+}
+
+// Set - replaces the served certificate.
+func (s *certStore) Set(cert *tls.Certificate) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.cert = cert
+}
+
+// GetCertificate - returns the served certificate, satisfies
+// tls.Config.GetCertificate.
+func (s *certStore) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.cert, nil
+}
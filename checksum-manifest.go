@@ -0,0 +1,241 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+const (
+	// Version of the checksum manifest format.
+	checksumManifestVersion = "1"
+
+	// Maximum size of an imported checksum manifest.
+	maxChecksumManifestSize = 512 * 1024 * 1024 // 512MiB.
+
+	// Objects listed at once while exporting a manifest.
+	checksumManifestListKeys = 1000
+)
+
+// Reasons of objects failing verification against a manifest.
+const (
+	// Object was deleted since the manifest was exported.
+	checksumMismatchMissing = "missing"
+	// Size, md5sum or parts of the object changed.
+	checksumMismatchModified = "modified"
+	// Shard checksums changed or shards are missing.
+	checksumMismatchShards = "shards"
+	// Shards do not match their checksums or are missing on disks.
+	checksumMismatchDamaged = "damaged"
+)
+
+// checksumManifest - checksums of all objects of a bucket, signed with
+// the server credential so that alterations are detected on import.
+type checksumManifest struct {
+	Version   string               `json:"version"`
+	Bucket    string               `json:"bucket"`
+	Created   time.Time            `json:"created"`
+	Objects   []ObjectChecksumInfo `json:"objects"`
+	Signature string               `json:"signature,omitempty"`
+}
+
+// checksumMismatch - object of a manifest failing verification.
+type checksumMismatch struct {
+	Object         string   `json:"object"`
+	Reason         string   `json:"reason,omitempty"`
+	MissingDisks   []string `json:"missingDisks,omitempty"`
+	CorruptedDisks []string `json:"corruptedDisks,omitempty"`
+	// Verification failed, object could not be compared.
+	Error string `json:"error,omitempty"`
+}
+
+// checksumVerifyReport - result of verifying live data against a
+// manifest.
+type checksumVerifyReport struct {
+	Bucket     string             `json:"bucket"`
+	Created    time.Time          `json:"created"`
+	Verified   int                `json:"verified"`
+	Mismatches []checksumMismatch `json:"mismatches"`
+}
+
+// getSignature - returns hex encoded HMAC-SHA256 of the manifest
+// without its signature.
+func (m checksumManifest) getSignature(secretKey string) (string, error) {
+	m.Signature = ""
+	manifestBuf, err := json.Marshal(m)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, []byte(secretKey))
+	mac.Write(manifestBuf)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// isValidSignature - returns true if the manifest was signed with the
+// secret key and not altered since.
+func (m checksumManifest) isValidSignature(secretKey string) bool {
+	signature, err := m.getSignature(secretKey)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(m.Signature))
+}
+
+// parseChecksumManifest - parses and validates a manifest, its signature
+// is not verified.
+func parseChecksumManifest(manifestBuf []byte) (manifest checksumManifest, err error) {
+	if err = json.Unmarshal(manifestBuf, &manifest); err != nil {
+		return checksumManifest{}, err
+	}
+	if manifest.Version != checksumManifestVersion {
+		return checksumManifest{}, errors.New("Unsupported checksum manifest version.")
+	}
+	if !IsValidBucketName(manifest.Bucket) {
+		return checksumManifest{}, BucketNameInvalid{Bucket: manifest.Bucket}
+	}
+	return manifest, nil
+}
+
+// exportChecksumManifest - returns signed manifest of the checksums of
+// all objects of the bucket, objects deleted while exporting are left
+// out.
+func exportChecksumManifest(objAPI ObjectLayer, bucket string) (checksumManifest, error) {
+	if _, err := objAPI.GetBucketInfo(bucket); err != nil {
+		return checksumManifest{}, err
+	}
+	manifest := checksumManifest{
+		Version: checksumManifestVersion,
+		Bucket:  bucket,
+		Created: time.Now().UTC(),
+		Objects: []ObjectChecksumInfo{},
+	}
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(bucket, "", marker, "", checksumManifestListKeys)
+		if err != nil {
+			return checksumManifest{}, err
+		}
+		for _, objInfo := range result.Objects {
+			if objInfo.IsDir {
+				continue
+			}
+			info, err := objAPI.GetObjectChecksums(bucket, objInfo.Name)
+			if err != nil {
+				if _, ok := err.(ObjectNotFound); ok {
+					continue
+				}
+				return checksumManifest{}, err
+			}
+			manifest.Objects = append(manifest.Objects, info)
+		}
+		if !result.IsTruncated || len(result.Objects) == 0 {
+			break
+		}
+		// FS sets next marker only when listing with a delimiter.
+		marker = result.Objects[len(result.Objects)-1].Name
+	}
+	signature, err := manifest.getSignature(serverConfig.GetCredential().SecretAccessKey)
+	if err != nil {
+		return checksumManifest{}, err
+	}
+	manifest.Signature = signature
+	return manifest, nil
+}
+
+// compareObjectChecksums - returns reason live checksums of an object
+// differ from the expected ones, empty if they match. Shards found live
+// but not expected are ignored, they were healed onto disks missing
+// them at export.
+func compareObjectChecksums(expected, live ObjectChecksumInfo) string {
+	if expected.Size != live.Size || expected.MD5Sum != live.MD5Sum || len(expected.Parts) != len(live.Parts) {
+		return checksumMismatchModified
+	}
+	for i, part := range expected.Parts {
+		livePart := live.Parts[i]
+		if part.Number != livePart.Number || part.Size != livePart.Size {
+			return checksumMismatchModified
+		}
+		liveShards := make(map[int]ShardChecksumInfo)
+		for _, shard := range livePart.Shards {
+			liveShards[shard.Index] = shard
+		}
+		for _, shard := range part.Shards {
+			if liveShard, ok := liveShards[shard.Index]; !ok || liveShard != shard {
+				return checksumMismatchShards
+			}
+		}
+	}
+	return ""
+}
+
+// verifyObjectChecksums - verifies live data of an object against its
+// checksums in a manifest, returns nil if it is intact.
+func verifyObjectChecksums(objAPI ObjectLayer, bucket string, expected ObjectChecksumInfo) *checksumMismatch {
+	mismatch := &checksumMismatch{Object: expected.Object}
+	live, err := objAPI.GetObjectChecksums(bucket, expected.Object)
+	if err != nil {
+		if _, ok := err.(ObjectNotFound); ok {
+			mismatch.Reason = checksumMismatchMissing
+		} else {
+			mismatch.Error = err.Error()
+		}
+		return mismatch
+	}
+	if mismatch.Reason = compareObjectChecksums(expected, live); mismatch.Reason != "" {
+		return mismatch
+	}
+	// Checksums are recorded, verify the shards still match them. FS
+	// checksums are computed from the data.
+	healInfo, err := objAPI.HealObject(bucket, expected.Object, true)
+	if err != nil {
+		if _, ok := err.(HealingNotSupported); ok {
+			return nil
+		}
+		mismatch.Error = err.Error()
+		return mismatch
+	}
+	if len(healInfo.MissingDisks)+len(healInfo.CorruptedDisks) == 0 {
+		return nil
+	}
+	mismatch.Reason = checksumMismatchDamaged
+	mismatch.MissingDisks = healInfo.MissingDisks
+	mismatch.CorruptedDisks = healInfo.CorruptedDisks
+	return mismatch
+}
+
+// verifyChecksumManifest - verifies live data of all objects of a
+// manifest, objects created since the export are not verified.
+func verifyChecksumManifest(objAPI ObjectLayer, manifest checksumManifest) checksumVerifyReport {
+	report := checksumVerifyReport{
+		Bucket:     manifest.Bucket,
+		Created:    manifest.Created,
+		Mismatches: []checksumMismatch{},
+	}
+	for _, expected := range manifest.Objects {
+		if mismatch := verifyObjectChecksums(objAPI, manifest.Bucket, expected); mismatch != nil {
+			report.Mismatches = append(report.Mismatches, *mismatch)
+			continue
+		}
+		report.Verified++
+	}
+	return report
+}
@@ -0,0 +1,164 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Tests exported manifests verify against live data, and detect
+// altered manifests and changed, deleted and damaged objects.
+func TestChecksumManifest(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	ExecObjectLayerTest(t, testChecksumManifest)
+}
+
+func testChecksumManifest(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	objects := []string{"a", "b", "c", "dir/d"}
+	for _, object := range objects {
+		data := bytes.Repeat([]byte(object), 1024)
+		if _, err := obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+	}
+
+	manifest, err := exportChecksumManifest(obj, bucket)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(manifest.Objects) != len(objects) {
+		t.Fatalf("%s: Expected %d objects, got %d", instanceType, len(objects), len(manifest.Objects))
+	}
+	for i, info := range manifest.Objects {
+		if info.Object != objects[i] || info.Size != int64(1024*len(objects[i])) || len(info.Parts) != 1 || len(info.Parts[0].Shards) == 0 {
+			t.Errorf("%s: Unexpected checksums %+v", instanceType, info)
+		}
+	}
+	if _, err = exportChecksumManifest(obj, "missing"); err != (BucketNotFound{Bucket: "missing"}) {
+		t.Errorf("%s: Expected %v, got %v", instanceType, BucketNotFound{Bucket: "missing"}, err)
+	}
+
+	// Manifests survive a round trip through their JSON document.
+	manifestBuf, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	imported, err := parseChecksumManifest(manifestBuf)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	secretKey := serverConfig.GetCredential().SecretAccessKey
+	if !imported.isValidSignature(secretKey) {
+		t.Errorf("%s: Expected valid signature of imported manifest", instanceType)
+	}
+	if imported.isValidSignature("othersecretkey") {
+		t.Errorf("%s: Expected invalid signature with other secret key", instanceType)
+	}
+	altered := imported
+	altered.Objects = append([]ObjectChecksumInfo{}, imported.Objects...)
+	altered.Objects[0].Size++
+	if altered.isValidSignature(secretKey) {
+		t.Errorf("%s: Expected invalid signature of altered manifest", instanceType)
+	}
+
+	report := verifyChecksumManifest(obj, imported)
+	if report.Verified != len(objects) || len(report.Mismatches) != 0 {
+		t.Errorf("%s: Expected all objects verified, got %+v", instanceType, report)
+	}
+
+	// Overwrite, delete and damage objects.
+	data := []byte("overwritten")
+	if _, err = obj.PutObject(bucket, "a", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if err = obj.DeleteObject(bucket, "b"); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	expected := []checksumMismatch{
+		{Object: "a", Reason: checksumMismatchModified},
+		{Object: "b", Reason: checksumMismatchMissing},
+	}
+	if xl, ok := obj.(xlObjects); ok {
+		xlMeta, err := xl.readXLMetadata(bucket, "c")
+		if err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		diskPath := getDiskName(xl.storageDisks[5], 5)
+		partPath := filepath.Join(diskPath, bucket, "c", xlMeta.Parts[0].Name)
+		if err = os.WriteFile(partPath, []byte("corrupted"), 0600); err != nil {
+			t.Fatalf("%s: %s", instanceType, err)
+		}
+		expected = append(expected, checksumMismatch{
+			Object:         "c",
+			Reason:         checksumMismatchDamaged,
+			CorruptedDisks: []string{diskPath},
+		})
+	}
+	report = verifyChecksumManifest(obj, imported)
+	if report.Verified != len(objects)-len(expected) {
+		t.Errorf("%s: Expected %d objects verified, got %d", instanceType, len(objects)-len(expected), report.Verified)
+	}
+	if !reflect.DeepEqual(report.Mismatches, expected) {
+		t.Errorf("%s: Expected mismatches %+v, got %+v", instanceType, expected, report.Mismatches)
+	}
+}
+
+// Tests validate parsing of checksum manifests.
+func TestParseChecksumManifest(t *testing.T) {
+	testCases := []struct {
+		manifestBuf string
+		shouldPass  bool
+	}{
+		// Test case - 1.
+		{`{"version":"1","bucket":"bucket","objects":[]}`, true},
+		// Test case - 2.
+		// Unsupported version.
+		{`{"version":"2","bucket":"bucket","objects":[]}`, false},
+		// Test case - 3.
+		// Invalid bucket name.
+		{`{"version":"1","bucket":"b","objects":[]}`, false},
+		// Test case - 4.
+		// Malformed document.
+		{`{"version":`, false},
+	}
+	for i, testCase := range testCases {
+		_, err := parseChecksumManifest([]byte(testCase.manifestBuf))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail", i+1)
+		}
+	}
+}
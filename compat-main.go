@@ -0,0 +1,160 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/minio/cli"
+	"github.com/minio/mc/pkg/console"
+)
+
+// Run S3 compatibility suite against an endpoint.
+var compatCmd = cli.Command{
+	Name:   "compat",
+	Usage:  "Run S3 compatibility checks against a server.",
+	Action: mainCompat,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "region",
+			Value: "us-east-1",
+			Usage: "Region of the server.",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print report in JSON format.",
+		},
+	},
+	CustomHelpTemplate: `NAME:
+  minio {{.Name}} - {{.Usage}}
+
+USAGE:
+  minio {{.Name}} [OPTIONS] [ENDPOINT]
+
+OPTIONS:
+  {{range .Flags}}{{.}}
+  {{end}}
+ENVIRONMENT VARIABLES:
+  MINIO_ACCESS_KEY: Access key of the server.
+  MINIO_SECRET_KEY: Secret key of the server.
+
+EXAMPLES:
+  1. Run compatibility checks against a local server.
+      $ minio {{.Name}}
+
+  2. Run compatibility checks against a remote server, printing a JSON report.
+      $ minio {{.Name}} --json https://play.minio.io:9000
+`,
+}
+
+// Endpoint checked if none is given, usually a server started on this
+// node.
+const compatDefaultEndpoint = "http://localhost:9000"
+
+// compatAPIReport - compatibility report of an S3 API.
+type compatAPIReport struct {
+	API     string         `json:"api"`
+	Passed  bool           `json:"passed"`
+	Results []compatResult `json:"results"`
+}
+
+// compatReport - compatibility report of an endpoint.
+type compatReport struct {
+	Endpoint string            `json:"endpoint"`
+	APIs     []compatAPIReport `json:"apis"`
+}
+
+// newCompatReport - groups results of checks by API, in the order the
+// APIs were first checked.
+func newCompatReport(endpoint string, results []compatResult) compatReport {
+	report := compatReport{Endpoint: endpoint}
+	apiIndex := make(map[string]int)
+	for _, result := range results {
+		i, ok := apiIndex[result.API]
+		if !ok {
+			i = len(report.APIs)
+			apiIndex[result.API] = i
+			report.APIs = append(report.APIs, compatAPIReport{API: result.API, Passed: true})
+		}
+		report.APIs[i].Results = append(report.APIs[i].Results, result)
+		report.APIs[i].Passed = report.APIs[i].Passed && result.Passed
+	}
+	return report
+}
+
+// passed - returns number of APIs which passed all checks.
+func (report compatReport) passed() (passed int) {
+	for _, api := range report.APIs {
+		if api.Passed {
+			passed++
+		}
+	}
+	return passed
+}
+
+// String - colorized per API report.
+func (report compatReport) String() string {
+	colorRed := color.New(color.FgRed, color.Bold).SprintfFunc()
+	msg := "Endpoint: " + report.Endpoint + "\n"
+	for _, api := range report.APIs {
+		status := colorGreen("PASS")
+		if !api.Passed {
+			status = colorRed("FAIL")
+		}
+		msg += fmt.Sprintf("%s %s\n", status, api.API)
+		for _, result := range api.Results {
+			if !result.Passed {
+				msg += fmt.Sprintf("     %s: %s\n", result.Check, result.Error)
+			}
+		}
+	}
+	msg += fmt.Sprintf("%d/%d APIs passed.", report.passed(), len(report.APIs))
+	return msg
+}
+
+func mainCompat(ctx *cli.Context) {
+	if len(ctx.Args()) > 1 {
+		cli.ShowCommandHelpAndExit(ctx, "compat", 1)
+	}
+	endpoint := compatDefaultEndpoint
+	if ctx.Args().Present() {
+		endpoint = ctx.Args().First()
+	}
+	accessKey := os.Getenv("MINIO_ACCESS_KEY")
+	secretKey := os.Getenv("MINIO_SECRET_KEY")
+	if accessKey == "" || secretKey == "" {
+		fatalIf(errors.New("Missing credentials"), "MINIO_ACCESS_KEY and MINIO_SECRET_KEY environment variables must be set.")
+	}
+	client, err := newCompatClient(endpoint, accessKey, secretKey, ctx.String("region"))
+	fatalIf(err, "Unable to initialize client for %s.", endpoint)
+
+	report := newCompatReport(endpoint, runCompatSuite(client))
+	if ctx.Bool("json") {
+		reportBuf, err := json.Marshal(report)
+		fatalIf(err, "Unable to marshal into JSON.")
+		console.Println(string(reportBuf))
+	} else {
+		console.Println(report)
+	}
+	if report.passed() != len(report.APIs) {
+		os.Exit(1)
+	}
+}
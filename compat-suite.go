@@ -0,0 +1,505 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// compatClient - minimal S3 client signing requests with signature v4,
+// used to verify S3 compatibility of an endpoint.
+type compatClient struct {
+	endpoint  *url.URL
+	accessKey string
+	secretKey string
+	region    string
+	client    *http.Client
+}
+
+// compatResponse - response of a compatibility request.
+type compatResponse struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+// newCompatClient - returns a client for the endpoint.
+func newCompatClient(endpoint, accessKey, secretKey, region string) (*compatClient, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("Endpoint %s is not a valid http or https URL.", endpoint)
+	}
+	return &compatClient{
+		endpoint:  u,
+		accessKey: accessKey,
+		secretKey: secretKey,
+		region:    region,
+		client:    &http.Client{Timeout: 5 * time.Minute},
+	}, nil
+}
+
+// do - sends a request signed with signature v4, all headers are signed.
+func (c *compatClient) do(method, bucket, object string, queryValues url.Values, headers map[string]string, body []byte) (*compatResponse, error) {
+	urlPath := "/"
+	if bucket != "" {
+		urlPath += bucket
+		if object != "" {
+			urlPath += "/" + object
+		}
+	}
+	u := *c.endpoint
+	u.Path = urlPath
+	u.RawQuery = strings.Replace(queryValues.Encode(), "+", "%20", -1)
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+
+	t := time.Now().UTC()
+	payloadSum := sha256.Sum256(body)
+	hashedPayload := hex.EncodeToString(payloadSum[:])
+	signedHeaders := make(http.Header)
+	signedHeaders.Set("X-Amz-Date", t.Format(iso8601Format))
+	signedHeaders.Set("X-Amz-Content-Sha256", hashedPayload)
+	if len(body) > 0 {
+		md5Sum := md5.Sum(body)
+		signedHeaders.Set("Content-Md5", base64.StdEncoding.EncodeToString(md5Sum[:]))
+	}
+	for k, v := range headers {
+		signedHeaders.Set(k, v)
+	}
+	for k, v := range signedHeaders {
+		req.Header[k] = v
+	}
+
+	canonicalRequest := getCanonicalRequest(signedHeaders, hashedPayload, queryValues.Encode(), urlPath, method, u.Host)
+	stringToSign := getStringToSign(canonicalRequest, t, c.region)
+	signature := getSignature(getSigningKey(c.secretKey, t, c.region), stringToSign)
+	req.Header.Set("Authorization", strings.Join([]string{
+		signV4Algorithm + " Credential=" + c.accessKey + "/" + getScope(t, c.region),
+		"SignedHeaders=" + getSignedHeaders(signedHeaders),
+		"Signature=" + signature,
+	}, ", "))
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &compatResponse{resp.StatusCode, resp.Header, respBody}, nil
+}
+
+// expectStatus - returns an error unless response has the status.
+func (resp *compatResponse) expectStatus(status int) error {
+	if resp.StatusCode != status {
+		return fmt.Errorf("expected status %d, got %d", status, resp.StatusCode)
+	}
+	return nil
+}
+
+// expectErrorCode - returns an error unless response is an S3 error
+// with the status and code.
+func (resp *compatResponse) expectErrorCode(status int, code string) error {
+	if err := resp.expectStatus(status); err != nil {
+		return err
+	}
+	errResp := APIErrorResponse{}
+	if err := xml.Unmarshal(resp.Body, &errResp); err != nil {
+		return fmt.Errorf("unable to parse error response: %s", err)
+	}
+	if errResp.Code != code {
+		return fmt.Errorf("expected error code %s, got %s", code, errResp.Code)
+	}
+	return nil
+}
+
+// compatCheck - a single compatibility check of an S3 API.
+type compatCheck struct {
+	API  string
+	Name string
+	run  func(c *compatClient, state *compatState) error
+}
+
+// compatState - state shared by checks of a suite run.
+type compatState struct {
+	bucket   string
+	uploadID string
+	etags    []string
+}
+
+// compatResult - result of a compatibility check.
+type compatResult struct {
+	API    string `json:"api"`
+	Check  string `json:"check"`
+	Passed bool   `json:"passed"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Data of objects written by compatibility checks.
+var compatObjectData = []byte("The quick brown fox jumps over the lazy dog.")
+
+// compatChecks - S3 compatibility suite, checks run in order and may
+// depend on earlier checks.
+var compatChecks = []compatCheck{
+	{"PutBucket", "create bucket", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("PUT", state.bucket, "", nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		return resp.expectStatus(http.StatusOK)
+	}},
+	{"PutBucket", "create existing bucket", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("PUT", state.bucket, "", nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		return resp.expectErrorCode(http.StatusConflict, "BucketAlreadyOwnedByYou")
+	}},
+	{"HeadBucket", "existing bucket", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("HEAD", state.bucket, "", nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		return resp.expectStatus(http.StatusOK)
+	}},
+	{"ListBuckets", "bucket is listed", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("GET", "", "", nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		if err = resp.expectStatus(http.StatusOK); err != nil {
+			return err
+		}
+		listResp := ListBucketsResponse{}
+		if err = xml.Unmarshal(resp.Body, &listResp); err != nil {
+			return err
+		}
+		for _, bucket := range listResp.Buckets.Buckets {
+			if bucket.Name == state.bucket {
+				return nil
+			}
+		}
+		return fmt.Errorf("bucket %s not listed", state.bucket)
+	}},
+	{"GetBucketLocation", "location", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("GET", state.bucket, "", url.Values{"location": {""}}, nil, nil)
+		if err != nil {
+			return err
+		}
+		return resp.expectStatus(http.StatusOK)
+	}},
+	{"PutObject", "simple object", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("PUT", state.bucket, "compat/object", nil, nil, compatObjectData)
+		if err != nil {
+			return err
+		}
+		if err = resp.expectStatus(http.StatusOK); err != nil {
+			return err
+		}
+		md5Sum := md5.Sum(compatObjectData)
+		if etag := resp.Header.Get("ETag"); etag != "\""+hex.EncodeToString(md5Sum[:])+"\"" {
+			return fmt.Errorf("unexpected ETag %s", etag)
+		}
+		return nil
+	}},
+	{"PutObject", "invalid Content-Md5", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("PUT", state.bucket, "compat/bad-md5", nil, map[string]string{"Content-Md5": "invalid"}, compatObjectData)
+		if err != nil {
+			return err
+		}
+		return resp.expectErrorCode(http.StatusBadRequest, "InvalidDigest")
+	}},
+	{"GetObject", "full object", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("GET", state.bucket, "compat/object", nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		if err = resp.expectStatus(http.StatusOK); err != nil {
+			return err
+		}
+		if !bytes.Equal(resp.Body, compatObjectData) {
+			return fmt.Errorf("object content mismatch")
+		}
+		return nil
+	}},
+	{"GetObject", "byte range", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("GET", state.bucket, "compat/object", nil, map[string]string{"Range": "bytes=4-8"}, nil)
+		if err != nil {
+			return err
+		}
+		if err = resp.expectStatus(http.StatusPartialContent); err != nil {
+			return err
+		}
+		if !bytes.Equal(resp.Body, compatObjectData[4:9]) {
+			return fmt.Errorf("expected %q, got %q", compatObjectData[4:9], resp.Body)
+		}
+		return nil
+	}},
+	{"GetObject", "If-Unmodified-Since", func(c *compatClient, state *compatState) error {
+		past := time.Now().UTC().Add(-24 * time.Hour).Format(http.TimeFormat)
+		resp, err := c.do("GET", state.bucket, "compat/object", nil, map[string]string{"If-Unmodified-Since": past}, nil)
+		if err != nil {
+			return err
+		}
+		return resp.expectStatus(http.StatusPreconditionFailed)
+	}},
+	{"GetObject", "missing object", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("GET", state.bucket, "compat/missing", nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		return resp.expectErrorCode(http.StatusNotFound, "NoSuchKey")
+	}},
+	{"HeadObject", "existing object", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("HEAD", state.bucket, "compat/object", nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		if err = resp.expectStatus(http.StatusOK); err != nil {
+			return err
+		}
+		if size := resp.Header.Get("Content-Length"); size != strconv.Itoa(len(compatObjectData)) {
+			return fmt.Errorf("unexpected Content-Length %s", size)
+		}
+		return nil
+	}},
+	{"CopyObject", "copy object", func(c *compatClient, state *compatState) error {
+		headers := map[string]string{"X-Amz-Copy-Source": "/" + state.bucket + "/compat/object"}
+		resp, err := c.do("PUT", state.bucket, "compat/copy", nil, headers, nil)
+		if err != nil {
+			return err
+		}
+		if err = resp.expectStatus(http.StatusOK); err != nil {
+			return err
+		}
+		resp, err = c.do("GET", state.bucket, "compat/copy", nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(resp.Body, compatObjectData) {
+			return fmt.Errorf("copied object content mismatch")
+		}
+		return nil
+	}},
+	{"ListObjects", "prefix and delimiter", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("GET", state.bucket, "", url.Values{"prefix": {"compat/"}, "delimiter": {"/"}}, nil, nil)
+		if err != nil {
+			return err
+		}
+		if err = resp.expectStatus(http.StatusOK); err != nil {
+			return err
+		}
+		listResp := ListObjectsResponse{}
+		if err = xml.Unmarshal(resp.Body, &listResp); err != nil {
+			return err
+		}
+		if len(listResp.Contents) != 2 {
+			return fmt.Errorf("expected 2 objects, got %d", len(listResp.Contents))
+		}
+		return nil
+	}},
+	{"NewMultipartUpload", "initiate upload", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("POST", state.bucket, "compat/multipart", url.Values{"uploads": {""}}, nil, nil)
+		if err != nil {
+			return err
+		}
+		if err = resp.expectStatus(http.StatusOK); err != nil {
+			return err
+		}
+		initResp := InitiateMultipartUploadResponse{}
+		if err = xml.Unmarshal(resp.Body, &initResp); err != nil {
+			return err
+		}
+		state.uploadID = initResp.UploadID
+		return nil
+	}},
+	{"PutObjectPart", "upload parts", func(c *compatClient, state *compatState) error {
+		parts := [][]byte{bytes.Repeat([]byte("a"), minPartSize), compatObjectData}
+		for i, part := range parts {
+			queryValues := url.Values{"partNumber": {strconv.Itoa(i + 1)}, "uploadId": {state.uploadID}}
+			resp, err := c.do("PUT", state.bucket, "compat/multipart", queryValues, nil, part)
+			if err != nil {
+				return err
+			}
+			if err = resp.expectStatus(http.StatusOK); err != nil {
+				return err
+			}
+			state.etags = append(state.etags, resp.Header.Get("ETag"))
+		}
+		return nil
+	}},
+	{"ListObjectParts", "uploaded parts", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("GET", state.bucket, "compat/multipart", url.Values{"uploadId": {state.uploadID}}, nil, nil)
+		if err != nil {
+			return err
+		}
+		if err = resp.expectStatus(http.StatusOK); err != nil {
+			return err
+		}
+		partsResp := ListPartsResponse{}
+		if err = xml.Unmarshal(resp.Body, &partsResp); err != nil {
+			return err
+		}
+		if len(partsResp.Parts) != len(state.etags) {
+			return fmt.Errorf("expected %d parts, got %d", len(state.etags), len(partsResp.Parts))
+		}
+		return nil
+	}},
+	{"ListMultipartUploads", "upload in progress", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("GET", state.bucket, "", url.Values{"uploads": {""}}, nil, nil)
+		if err != nil {
+			return err
+		}
+		if err = resp.expectStatus(http.StatusOK); err != nil {
+			return err
+		}
+		uploadsResp := ListMultipartUploadsResponse{}
+		if err = xml.Unmarshal(resp.Body, &uploadsResp); err != nil {
+			return err
+		}
+		for _, upload := range uploadsResp.Uploads {
+			if upload.UploadID == state.uploadID {
+				return nil
+			}
+		}
+		return fmt.Errorf("upload %s not listed", state.uploadID)
+	}},
+	{"CompleteMultipartUpload", "complete upload", func(c *compatClient, state *compatState) error {
+		complete := completeMultipartUpload{}
+		for i, etag := range state.etags {
+			complete.Parts = append(complete.Parts, completePart{PartNumber: i + 1, ETag: etag})
+		}
+		completeBuf, err := xml.Marshal(complete)
+		if err != nil {
+			return err
+		}
+		resp, err := c.do("POST", state.bucket, "compat/multipart", url.Values{"uploadId": {state.uploadID}}, nil, completeBuf)
+		if err != nil {
+			return err
+		}
+		if err = resp.expectStatus(http.StatusOK); err != nil {
+			return err
+		}
+		completeResp := CompleteMultipartUploadResponse{}
+		if err = xml.Unmarshal(resp.Body, &completeResp); err != nil {
+			return err
+		}
+		if !strings.HasSuffix(strings.Trim(completeResp.ETag, "\""), "-"+strconv.Itoa(len(state.etags))) {
+			return fmt.Errorf("unexpected multipart ETag %s", completeResp.ETag)
+		}
+		return nil
+	}},
+	{"AbortMultipartUpload", "abort upload", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("POST", state.bucket, "compat/aborted", url.Values{"uploads": {""}}, nil, nil)
+		if err != nil {
+			return err
+		}
+		initResp := InitiateMultipartUploadResponse{}
+		if err = xml.Unmarshal(resp.Body, &initResp); err != nil {
+			return err
+		}
+		resp, err = c.do("DELETE", state.bucket, "compat/aborted", url.Values{"uploadId": {initResp.UploadID}}, nil, nil)
+		if err != nil {
+			return err
+		}
+		return resp.expectStatus(http.StatusNoContent)
+	}},
+	{"PutBucketPolicy", "read-only policy", func(c *compatClient, state *compatState) error {
+		policy := `{"Version":"2012-10-17","Statement":[{"Action":["s3:GetObject"],"Effect":"Allow","Principal":{"AWS":["*"]},"Resource":["arn:aws:s3:::` + state.bucket + `/*"],"Sid":""}]}`
+		resp, err := c.do("PUT", state.bucket, "", url.Values{"policy": {""}}, nil, []byte(policy))
+		if err != nil {
+			return err
+		}
+		return resp.expectStatus(http.StatusNoContent)
+	}},
+	{"GetBucketPolicy", "read-only policy", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("GET", state.bucket, "", url.Values{"policy": {""}}, nil, nil)
+		if err != nil {
+			return err
+		}
+		return resp.expectStatus(http.StatusOK)
+	}},
+	{"DeleteBucketPolicy", "read-only policy", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("DELETE", state.bucket, "", url.Values{"policy": {""}}, nil, nil)
+		if err != nil {
+			return err
+		}
+		return resp.expectStatus(http.StatusNoContent)
+	}},
+	{"DeleteObject", "remove objects", func(c *compatClient, state *compatState) error {
+		for _, object := range []string{"compat/object", "compat/copy", "compat/multipart"} {
+			resp, err := c.do("DELETE", state.bucket, object, nil, nil, nil)
+			if err != nil {
+				return err
+			}
+			if err = resp.expectStatus(http.StatusNoContent); err != nil {
+				return err
+			}
+		}
+		return nil
+	}},
+	{"DeleteBucket", "remove bucket", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("DELETE", state.bucket, "", nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		return resp.expectStatus(http.StatusNoContent)
+	}},
+	{"HeadBucket", "missing bucket", func(c *compatClient, state *compatState) error {
+		resp, err := c.do("HEAD", state.bucket, "", nil, nil, nil)
+		if err != nil {
+			return err
+		}
+		return resp.expectStatus(http.StatusNotFound)
+	}},
+}
+
+// runCompatSuite - runs all compatibility checks against the endpoint
+// in a new bucket.
+func runCompatSuite(c *compatClient) []compatResult {
+	state := &compatState{bucket: "minio-compat-" + getUUID()}
+	results := make([]compatResult, 0, len(compatChecks))
+	for _, check := range compatChecks {
+		result := compatResult{API: check.API, Check: check.Name, Passed: true}
+		if err := check.run(c, state); err != nil {
+			result.Passed = false
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
@@ -0,0 +1,61 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "testing"
+
+// Tests compatibility suite passes against FS and XL backends.
+func TestCompatSuite(t *testing.T) {
+	for _, instanceType := range []string{"FS", "XL"} {
+		testServer := StartTestServer(t, instanceType)
+		client, err := newCompatClient(testServer.Server.URL, testServer.AccessKey, testServer.SecretKey, "us-east-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		report := newCompatReport(testServer.Server.URL, runCompatSuite(client))
+		for _, api := range report.APIs {
+			for _, result := range api.Results {
+				if !result.Passed {
+					t.Errorf("%s: %s %s failed: %s", instanceType, result.API, result.Check, result.Error)
+				}
+			}
+		}
+		testServer.Stop()
+	}
+}
+
+// Tests grouping of check results by API.
+func TestNewCompatReport(t *testing.T) {
+	results := []compatResult{
+		{API: "PutObject", Check: "a", Passed: true},
+		{API: "GetObject", Check: "a", Passed: true},
+		{API: "PutObject", Check: "b", Passed: false, Error: "failed"},
+	}
+	report := newCompatReport("http://localhost:9000", results)
+	if len(report.APIs) != 2 {
+		t.Fatalf("Expected 2 APIs, got %d", len(report.APIs))
+	}
+	if report.APIs[0].API != "PutObject" || report.APIs[0].Passed || len(report.APIs[0].Results) != 2 {
+		t.Errorf("Expected PutObject to fail with 2 results, got %+v", report.APIs[0])
+	}
+	if report.APIs[1].API != "GetObject" || !report.APIs[1].Passed {
+		t.Errorf("Expected GetObject to pass, got %+v", report.APIs[1])
+	}
+	if report.passed() != 1 {
+		t.Errorf("Expected 1 API passed, got %d", report.passed())
+	}
+}
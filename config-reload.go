@@ -0,0 +1,84 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/tls"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/minio/mc/pkg/console"
+)
+
+// reloadConfig - reloads logger settings, region, bitrot algorithm,
+// block size classes, inline threshold and disabled APIs of the server
+// config, tenants, heal throttle and the TLS certificate. Everything is
+// validated before anything is applied, a broken config keeps the
+// running one. Credential, deployment ID and quorum require a restart.
+func reloadConfig() error {
+	srvCfg, err := loadServerConfig()
+	if err != nil {
+		return err
+	}
+	if err = validateLoggers(srvCfg.Logger); err != nil {
+		return err
+	}
+	tenants, err := readTenantsConfig()
+	if err != nil {
+		return err
+	}
+	healThrottle, err := readHealThrottle()
+	if err != nil {
+		return err
+	}
+	var cert *tls.Certificate
+	if isSSL() {
+		if cert, err = loadCertificate(); err != nil {
+			return err
+		}
+	}
+
+	serverConfig.SetRegion(srvCfg.Region)
+	serverConfig.SetConsoleLogger(srvCfg.Logger.Console)
+	serverConfig.SetFileLogger(srvCfg.Logger.File)
+	serverConfig.SetSyslogLogger(srvCfg.Logger.Syslog)
+	serverConfig.SetBitrotAlgorithm(srvCfg.BitrotAlgorithm)
+	serverConfig.SetBlockSizeClasses(srvCfg.BlockSizeClasses)
+	serverConfig.SetInlineThreshold(srvCfg.InlineThreshold)
+	serverConfig.SetDisabledAPIs(srvCfg.DisabledAPIs)
+	reloadLoggers()
+	globalTenants.Set(tenants.Tenants)
+	globalHealThrottle.Set(healThrottle)
+	if cert != nil {
+		globalCerts.Set(cert)
+	}
+	return nil
+}
+
+// reloadConfigOnSIGHUP - reloads config on every SIGHUP.
+func reloadConfigOnSIGHUP() {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	for range sigCh {
+		if err := reloadConfig(); err != nil {
+			errorIf(err, "Unable to reload config, keeping the running config.")
+			continue
+		}
+		console.Println("Config reloaded.")
+	}
+}
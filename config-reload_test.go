@@ -0,0 +1,149 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Tests valid configs are applied on reload and broken ones keep the
+// running config.
+func TestReloadConfig(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	defer func(prevLog *logrus.Logger) {
+		log = prevLog
+	}(log)
+	configFile, err := getConfigFile()
+	if err != nil {
+		t.Fatal(err)
+	}
+	configBuf, err := ioutil.ReadFile(configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred := serverConfig.GetCredential()
+
+	// writeConfig - saves the config with changes made by fn.
+	writeConfig := func(fn func(*serverConfigV4)) {
+		var srvCfg serverConfigV4
+		if wErr := json.Unmarshal(configBuf, &srvCfg); wErr != nil {
+			t.Fatal(wErr)
+		}
+		fn(&srvCfg)
+		buf, wErr := json.Marshal(srvCfg)
+		if wErr != nil {
+			t.Fatal(wErr)
+		}
+		if wErr = ioutil.WriteFile(configFile, buf, 0600); wErr != nil {
+			t.Fatal(wErr)
+		}
+	}
+
+	testCases := []struct {
+		fn             func(*serverConfigV4)
+		shouldPass     bool
+		expectedRegion string
+		expectedAlgo   string
+	}{
+		// Test case - 1.
+		// Region, log level and bitrot algorithm are applied,
+		// credential and quorum require a restart.
+		{func(srvCfg *serverConfigV4) {
+			srvCfg.Region = "eu-west-1"
+			srvCfg.Logger.Console.Level = "error"
+			srvCfg.BitrotAlgorithm = bitrotAlgorithmSHA256
+			srvCfg.ReadQuorum = 9
+			srvCfg.Credential = credential{AccessKeyID: "NEWACCESSKEY", SecretAccessKey: "newsecretaccesskey"}
+		}, true, "eu-west-1", bitrotAlgorithmSHA256},
+		// Test case - 2.
+		// Unsupported bitrot algorithm.
+		{func(srvCfg *serverConfigV4) {
+			srvCfg.Region = "us-west-1"
+			srvCfg.BitrotAlgorithm = "md5"
+		}, false, "eu-west-1", bitrotAlgorithmSHA256},
+		// Test case - 3.
+		// Unknown log level.
+		{func(srvCfg *serverConfigV4) {
+			srvCfg.Region = "us-west-1"
+			srvCfg.Logger.Console.Level = "loud"
+		}, false, "eu-west-1", bitrotAlgorithmSHA256},
+		// Test case - 4.
+		// Negative quorum.
+		{func(srvCfg *serverConfigV4) {
+			srvCfg.Region = "us-west-1"
+			srvCfg.WriteQuorum = -1
+		}, false, "eu-west-1", bitrotAlgorithmSHA256},
+		// Test case - 5.
+		// Block size classes out of order.
+		{func(srvCfg *serverConfigV4) {
+			srvCfg.Region = "us-west-1"
+			srvCfg.BlockSizeClasses = []blockSizeClass{{4 * 1024 * 1024, 1024 * 1024}, {1024 * 1024, minBlockSize}}
+		}, false, "eu-west-1", bitrotAlgorithmSHA256},
+		// Test case - 6.
+		// Inline threshold too large.
+		{func(srvCfg *serverConfigV4) {
+			srvCfg.Region = "us-west-1"
+			srvCfg.InlineThreshold = maxInlineThreshold + 1
+		}, false, "eu-west-1", bitrotAlgorithmSHA256},
+		// Test case - 7.
+		// Unknown API group disabled.
+		{func(srvCfg *serverConfigV4) {
+			srvCfg.Region = "us-west-1"
+			srvCfg.DisabledAPIs = []string{"DeleteBucket", "ListEverything"}
+		}, false, "eu-west-1", bitrotAlgorithmSHA256},
+		// Test case - 8.
+		// Defaults are restored.
+		{func(srvCfg *serverConfigV4) {}, true, "us-east-1", bitrotAlgorithmBlake2b},
+	}
+	for i, testCase := range testCases {
+		writeConfig(testCase.fn)
+		err = reloadConfig()
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+		if region := serverConfig.GetRegion(); region != testCase.expectedRegion {
+			t.Errorf("Test %d: Expected region %s, got %s", i+1, testCase.expectedRegion, region)
+		}
+		if algo := serverConfig.GetBitrotAlgorithm(); algo != testCase.expectedAlgo {
+			t.Errorf("Test %d: Expected bitrot algorithm %s, got %s", i+1, testCase.expectedAlgo, algo)
+		}
+		if serverConfig.GetCredential() != cred {
+			t.Errorf("Test %d: Expected credential to be kept", i+1)
+		}
+		if readQuorum, writeQuorum := serverConfig.GetQuorum(); readQuorum != 0 || writeQuorum != 0 {
+			t.Errorf("Test %d: Expected quorum to be kept", i+1)
+		}
+	}
+	if log.Level != logrus.FatalLevel {
+		t.Errorf("Expected log level %s, got %s", logrus.FatalLevel, log.Level)
+	}
+}
@@ -17,6 +17,8 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"sync"
 
@@ -34,6 +36,29 @@ type serverConfigV4 struct {
 	// Additional error logging configuration.
 	Logger logger `json:"logger"`
 
+	// Unique ID of the deployment, objects are stamped with it.
+	DeploymentID string `json:"deploymentID"`
+
+	// Bitrot hash algorithm of new writes, blake2b if not set.
+	BitrotAlgorithm string `json:"bitrotAlgorithm,omitempty"`
+
+	// Read and write quorum of XL, calculated from the number of disks
+	// if not set.
+	ReadQuorum  int `json:"readQuorum,omitempty"`
+	WriteQuorum int `json:"writeQuorum,omitempty"`
+
+	// Erasure block size of new objects by object size, 10MiB for
+	// objects larger than all classes.
+	BlockSizeClasses []blockSizeClass `json:"blockSizeClasses,omitempty"`
+
+	// New objects up to this size are kept in `xl.json` instead of
+	// erasure coded, objects are never inlined if not set.
+	InlineThreshold int64 `json:"inlineThreshold,omitempty"`
+
+	// API groups whose requests are rejected, all APIs are enabled if
+	// not set.
+	DisabledAPIs []string `json:"disabledAPIs,omitempty"`
+
 	// Read Write mutex.
 	rwMutex *sync.RWMutex
 }
@@ -45,6 +70,7 @@ func initConfig() error {
 		srvCfg.Version = globalMinioConfigVersion
 		srvCfg.Region = "us-east-1"
 		srvCfg.Credential = mustGenAccessKeys()
+		srvCfg.DeploymentID = getUUID()
 		// Enable console logger by default on a fresh run.
 		srvCfg.Logger.Console = consoleLogger{
 			Enable: true,
@@ -69,28 +95,60 @@ func initConfig() error {
 		// Save config into file.
 		return serverConfig.Save()
 	}
-	configFile, err := getConfigFile()
+	srvCfg, err := loadServerConfig()
 	if err != nil {
 		return err
 	}
+	// Save the loaded config globally.
+	serverConfig = srvCfg
+	// Configs saved before deployment IDs were introduced get one.
+	if serverConfig.DeploymentID == "" {
+		serverConfig.DeploymentID = getUUID()
+		return serverConfig.Save()
+	}
+	return nil
+}
+
+// loadServerConfig - reads and validates the config file without
+// applying it.
+func loadServerConfig() (*serverConfigV4, error) {
+	configFile, err := getConfigFile()
+	if err != nil {
+		return nil, err
+	}
 	if _, err = os.Stat(configFile); err != nil {
-		return err
+		return nil, err
 	}
 	srvCfg := &serverConfigV4{}
 	srvCfg.Version = globalMinioConfigVersion
 	srvCfg.rwMutex = &sync.RWMutex{}
 	qc, err := quick.New(srvCfg)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if err := qc.Load(configFile); err != nil {
-		return err
+	if err = qc.Load(configFile); err != nil {
+		return nil, err
+	}
+	if srvCfg.BitrotAlgorithm != "" && !isValidBitrotAlgorithm(srvCfg.BitrotAlgorithm) {
+		return nil, fmt.Errorf("Unsupported bitrot algorithm %s.", srvCfg.BitrotAlgorithm)
+	}
+	// Quorum is validated against the number of disks while
+	// initializing XL.
+	if srvCfg.ReadQuorum < 0 || srvCfg.WriteQuorum < 0 {
+		return nil, errors.New("Read and write quorum cannot be negative.")
+	}
+	if err = validateBlockSizeClasses(srvCfg.BlockSizeClasses); err != nil {
+		return nil, err
+	}
+	if err = validateInlineThreshold(srvCfg.InlineThreshold); err != nil {
+		return nil, err
+	}
+	if err = validateDisabledAPIs(srvCfg.DisabledAPIs); err != nil {
+		return nil, err
 	}
-	// Save the loaded config globally.
-	serverConfig = srvCfg
 	// Set the version properly after the unmarshalled json is loaded.
-	serverConfig.Version = globalMinioConfigVersion
-	return nil
+	srvCfg.Version = globalMinioConfigVersion
+	return srvCfg, nil
 }
 
 // serverConfig server config.
@@ -175,6 +233,95 @@ func (s serverConfigV4) GetCredential() credential {
 	return s.Credential
 }
 
+// SetDeploymentID set deployment ID.
+func (s *serverConfigV4) SetDeploymentID(deploymentID string) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.DeploymentID = deploymentID
+}
+
+// GetDeploymentID get deployment ID.
+func (s serverConfigV4) GetDeploymentID() string {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.DeploymentID
+}
+
+// SetBitrotAlgorithm set bitrot hash algorithm of new writes.
+func (s *serverConfigV4) SetBitrotAlgorithm(algo string) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.BitrotAlgorithm = algo
+}
+
+// GetBitrotAlgorithm get bitrot hash algorithm of new writes.
+func (s serverConfigV4) GetBitrotAlgorithm() string {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	if s.BitrotAlgorithm == "" {
+		return defaultBitrotAlgorithm
+	}
+	return s.BitrotAlgorithm
+}
+
+// SetQuorum set read and write quorum of XL.
+func (s *serverConfigV4) SetQuorum(readQuorum, writeQuorum int) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.ReadQuorum = readQuorum
+	s.WriteQuorum = writeQuorum
+}
+
+// GetQuorum get read and write quorum of XL, 0 if not set.
+func (s serverConfigV4) GetQuorum() (readQuorum, writeQuorum int) {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.ReadQuorum, s.WriteQuorum
+}
+
+// SetBlockSizeClasses set erasure block size classes of new objects.
+func (s *serverConfigV4) SetBlockSizeClasses(classes []blockSizeClass) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.BlockSizeClasses = classes
+}
+
+// GetBlockSizeClasses get erasure block size classes of new objects.
+func (s serverConfigV4) GetBlockSizeClasses() []blockSizeClass {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.BlockSizeClasses
+}
+
+// SetInlineThreshold set size up to which new objects are inlined.
+func (s *serverConfigV4) SetInlineThreshold(threshold int64) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.InlineThreshold = threshold
+}
+
+// GetInlineThreshold get size up to which new objects are inlined, 0
+// if not set.
+func (s serverConfigV4) GetInlineThreshold() int64 {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.InlineThreshold
+}
+
+// SetDisabledAPIs set API groups whose requests are rejected.
+func (s *serverConfigV4) SetDisabledAPIs(disabledAPIs []string) {
+	s.rwMutex.Lock()
+	defer s.rwMutex.Unlock()
+	s.DisabledAPIs = disabledAPIs
+}
+
+// GetDisabledAPIs get API groups whose requests are rejected.
+func (s serverConfigV4) GetDisabledAPIs() []string {
+	s.rwMutex.RLock()
+	defer s.rwMutex.RUnlock()
+	return s.DisabledAPIs
+}
+
 // Save config.
 func (s serverConfigV4) Save() error {
 	s.rwMutex.RLock()
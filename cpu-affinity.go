@@ -0,0 +1,120 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// Maximum number of CPUs supported in an affinity list, same as the
+// default size of cpu_set_t on Linux.
+const maxAffinityCPUs = 1024
+
+// errAffinityUnsupported - CPU affinity is not supported on this platform.
+var errAffinityUnsupported = errors.New("CPU affinity is not supported on this platform")
+
+// cpuSet - bit mask of CPUs a thread is allowed to run on.
+type cpuSet [maxAffinityCPUs / 64]uint64
+
+// set - adds cpu to the set.
+func (s *cpuSet) set(cpu int) {
+	s[cpu/64] |= 1 << uint(cpu%64)
+}
+
+// isSet - returns true if cpu is in the set.
+func (s cpuSet) isSet(cpu int) bool {
+	return s[cpu/64]&(1<<uint(cpu%64)) != 0
+}
+
+// parseCPUList - parses a CPU list of the form "0-3,8,10-11" as
+// understood by taskset(1).
+func parseCPUList(cpuList string) (*cpuSet, error) {
+	cpus := &cpuSet{}
+	for _, cpuRange := range strings.Split(cpuList, ",") {
+		bounds := strings.SplitN(strings.TrimSpace(cpuRange), "-", 2)
+		start, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.Atoi(bounds[1]); err != nil {
+				return nil, err
+			}
+		}
+		if start < 0 || end < start || end >= maxAffinityCPUs {
+			return nil, errors.New("Invalid CPU range " + cpuRange)
+		}
+		for cpu := start; cpu <= end; cpu++ {
+			cpus.set(cpu)
+		}
+	}
+	return cpus, nil
+}
+
+// pinThread - locks the calling goroutine to its OS thread and pins
+// the thread to cpus, returned function restores previous affinity
+// and unlocks the thread. Nil cpus is a no-op.
+func pinThread(cpus *cpuSet) (unpin func(), err error) {
+	if cpus == nil {
+		return func() {}, nil
+	}
+	runtime.LockOSThread()
+	prevCPUs, err := getThreadAffinity()
+	if err != nil {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	if err = setThreadAffinity(cpus); err != nil {
+		runtime.UnlockOSThread()
+		return nil, err
+	}
+	return func() {
+		setThreadAffinity(prevCPUs)
+		runtime.UnlockOSThread()
+	}, nil
+}
+
+// networkAffinityHandler - pins request goroutines to network CPUs.
+type networkAffinityHandler struct {
+	handler http.Handler
+	cpus    *cpuSet
+}
+
+// setNetworkAffinityHandler to pin goroutines serving requests to the
+// CPUs set via MINIO_NETWORK_CPUS, experimental.
+func setNetworkAffinityHandler(h http.Handler) http.Handler {
+	if globalNetworkCPUs == nil {
+		return h
+	}
+	return networkAffinityHandler{h, globalNetworkCPUs}
+}
+
+func (h networkAffinityHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	unpin, err := pinThread(h.cpus)
+	if err != nil {
+		errorIf(err, "Unable to pin request to network CPUs.")
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	defer unpin()
+	h.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,88 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "testing"
+
+// Tests parsing of CPU lists.
+func TestParseCPUList(t *testing.T) {
+	testCases := []struct {
+		cpuList      string
+		expectedCPUs []int
+		shouldPass   bool
+	}{
+		// Test case - 1.
+		{"0", []int{0}, true},
+		// Test case - 2.
+		{"0-3,8", []int{0, 1, 2, 3, 8}, true},
+		// Test case - 3.
+		{" 2 , 64-65", []int{2, 64, 65}, true},
+		// Test case - 4.
+		// Reversed range.
+		{"3-1", nil, false},
+		// Test case - 5.
+		// Out of range.
+		{"0-1024", nil, false},
+		// Test case - 6.
+		{"a", nil, false},
+		// Test case - 7.
+		{"", nil, false},
+		// Test case - 8.
+		{"-1", nil, false},
+	}
+	for i, testCase := range testCases {
+		cpus, err := parseCPUList(testCase.cpuList)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed", i+1)
+		}
+		if err != nil {
+			continue
+		}
+		expected := &cpuSet{}
+		for _, cpu := range testCase.expectedCPUs {
+			expected.set(cpu)
+		}
+		if *cpus != *expected {
+			t.Errorf("Test %d: Expected CPUs %v, got %v", i+1, testCase.expectedCPUs, cpus)
+		}
+	}
+}
+
+// Tests pinning to the current CPUs and restoring them.
+func TestPinThread(t *testing.T) {
+	unpin, err := pinThread(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpin()
+
+	cpus, err := getThreadAffinity()
+	if err == errAffinityUnsupported {
+		return
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpin, err = pinThread(cpus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpin()
+}
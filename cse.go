@@ -0,0 +1,133 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+)
+
+// Metadata of objects encrypted client side by the S3 encryption
+// clients, the envelope of the data key. These are saved and copied
+// like any user metadata, the server never decrypts the data.
+const (
+	cseKeyMetaKey                   = "X-Amz-Meta-X-Amz-Key"
+	cseKeyV2MetaKey                 = "X-Amz-Meta-X-Amz-Key-V2"
+	cseIVMetaKey                    = "X-Amz-Meta-X-Amz-Iv"
+	cseMatDescMetaKey               = "X-Amz-Meta-X-Amz-Matdesc"
+	cseCEKAlgMetaKey                = "X-Amz-Meta-X-Amz-Cek-Alg"
+	cseWrapAlgMetaKey               = "X-Amz-Meta-X-Amz-Wrap-Alg"
+	cseTagLenMetaKey                = "X-Amz-Meta-X-Amz-Tag-Len"
+	cseUnencryptedLengthMetaKey     = "X-Amz-Meta-X-Amz-Unencrypted-Content-Length"
+	cseUnencryptedContentMD5MetaKey = "X-Amz-Meta-X-Amz-Unencrypted-Content-Md5"
+)
+
+// Metadata field of listings and metadata queries naming the envelope
+// version of client side encrypted objects.
+const cseMetadataField = "Client-Side-Encryption"
+
+// Envelope versions of client side encrypted objects.
+const (
+	cseVersionV1 = "v1"
+	cseVersionV2 = "v2"
+)
+
+// All metadata keys of client side encryption.
+var cseMetadataKeys = []string{
+	cseKeyMetaKey,
+	cseKeyV2MetaKey,
+	cseIVMetaKey,
+	cseMatDescMetaKey,
+	cseCEKAlgMetaKey,
+	cseWrapAlgMetaKey,
+	cseTagLenMetaKey,
+	cseUnencryptedLengthMetaKey,
+	cseUnencryptedContentMD5MetaKey,
+}
+
+// getCSEVersion - returns envelope version of a client side encrypted
+// object, empty if the object is not encrypted client side.
+func getCSEVersion(metadata map[string]string) string {
+	if _, ok := metadata[cseKeyV2MetaKey]; ok {
+		return cseVersionV2
+	}
+	if _, ok := metadata[cseKeyMetaKey]; ok {
+		return cseVersionV1
+	}
+	return ""
+}
+
+// hasCSEMetadata - returns true if any client side encryption metadata
+// is set.
+func hasCSEMetadata(metadata map[string]string) bool {
+	for _, key := range cseMetadataKeys {
+		if _, ok := metadata[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidBase64 - returns true for non-empty standard base64.
+func isValidBase64(value string) bool {
+	buf, err := base64.StdEncoding.DecodeString(value)
+	return err == nil && len(buf) > 0
+}
+
+// isValidCSEInt - returns true for non-negative integers.
+func isValidCSEInt(value string) bool {
+	i, err := strconv.ParseInt(value, 10, 64)
+	return err == nil && i >= 0
+}
+
+// checkCSEMetadata - verifies client side encryption metadata of an
+// upload is a complete envelope, objects whose envelope is lost or
+// damaged cannot be decrypted by any client.
+func checkCSEMetadata(metadata map[string]string) APIErrorCode {
+	if !hasCSEMetadata(metadata) {
+		return ErrNone
+	}
+	key, isV1 := metadata[cseKeyMetaKey]
+	keyV2, isV2 := metadata[cseKeyV2MetaKey]
+	switch {
+	case isV1 == isV2:
+		// Either no envelope key or both.
+		return ErrInvalidCSEMetadata
+	case isV1 && !isValidBase64(key):
+		return ErrInvalidCSEMetadata
+	case isV2 && !isValidBase64(keyV2):
+		return ErrInvalidCSEMetadata
+	case isV2 && (metadata[cseCEKAlgMetaKey] == "" || metadata[cseWrapAlgMetaKey] == ""):
+		return ErrInvalidCSEMetadata
+	}
+	if !isValidBase64(metadata[cseIVMetaKey]) {
+		return ErrInvalidCSEMetadata
+	}
+	if matDesc, ok := metadata[cseMatDescMetaKey]; ok {
+		var desc map[string]interface{}
+		if err := json.Unmarshal([]byte(matDesc), &desc); err != nil {
+			return ErrInvalidCSEMetadata
+		}
+	}
+	for _, key := range []string{cseTagLenMetaKey, cseUnencryptedLengthMetaKey} {
+		if value, ok := metadata[key]; ok && !isValidCSEInt(value) {
+			return ErrInvalidCSEMetadata
+		}
+	}
+	return ErrNone
+}
@@ -0,0 +1,137 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// Tests validating client side encryption metadata of uploads.
+func TestCheckCSEMetadata(t *testing.T) {
+	testCases := []struct {
+		metadata        map[string]string
+		expectedVersion string
+		expectedErr     APIErrorCode
+	}{
+		// Test case - 1.
+		// Not encrypted client side.
+		{map[string]string{"X-Amz-Meta-Foo": "bar"}, "", ErrNone},
+		// Test case - 2.
+		{map[string]string{cseKeyMetaKey: "a2V5", cseIVMetaKey: "aXY=", cseMatDescMetaKey: "{}"}, cseVersionV1, ErrNone},
+		// Test case - 3.
+		{map[string]string{cseKeyV2MetaKey: "a2V5", cseIVMetaKey: "aXY=", cseCEKAlgMetaKey: "AES/GCM/NoPadding", cseWrapAlgMetaKey: "kms", cseTagLenMetaKey: "128"}, cseVersionV2, ErrNone},
+		// Test case - 4.
+		// Envelope without a key.
+		{map[string]string{cseIVMetaKey: "aXY=", cseMatDescMetaKey: "{}"}, "", ErrInvalidCSEMetadata},
+		// Test case - 5.
+		// Both envelope keys.
+		{map[string]string{cseKeyMetaKey: "a2V5", cseKeyV2MetaKey: "a2V5", cseIVMetaKey: "aXY="}, cseVersionV2, ErrInvalidCSEMetadata},
+		// Test case - 6.
+		// Key is not base64.
+		{map[string]string{cseKeyMetaKey: "not base64", cseIVMetaKey: "aXY="}, cseVersionV1, ErrInvalidCSEMetadata},
+		// Test case - 7.
+		// Missing IV.
+		{map[string]string{cseKeyMetaKey: "a2V5"}, cseVersionV1, ErrInvalidCSEMetadata},
+		// Test case - 8.
+		// V2 envelope without algorithms.
+		{map[string]string{cseKeyV2MetaKey: "a2V5", cseIVMetaKey: "aXY="}, cseVersionV2, ErrInvalidCSEMetadata},
+		// Test case - 9.
+		// Material description is not a JSON object.
+		{map[string]string{cseKeyMetaKey: "a2V5", cseIVMetaKey: "aXY=", cseMatDescMetaKey: "[1]"}, cseVersionV1, ErrInvalidCSEMetadata},
+		// Test case - 10.
+		// Negative unencrypted length.
+		{map[string]string{cseKeyMetaKey: "a2V5", cseIVMetaKey: "aXY=", cseUnencryptedLengthMetaKey: "-1"}, cseVersionV1, ErrInvalidCSEMetadata},
+	}
+	for i, testCase := range testCases {
+		if version := getCSEVersion(testCase.metadata); version != testCase.expectedVersion {
+			t.Errorf("Test %d: Expected version %q, got %q", i+1, testCase.expectedVersion, version)
+		}
+		if err := checkCSEMetadata(testCase.metadata); err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %d, got %d", i+1, testCase.expectedErr, err)
+		}
+	}
+}
+
+// Tests client side encrypted objects keep their envelope through
+// copy, are flagged in listings and cannot be modified in place.
+func TestClientSideEncryptedObjects(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+
+	bucket := makeIntegrationBucket(t, client)
+	envelope := map[string]string{
+		"x-amz-meta-x-amz-key":     "a2V5",
+		"x-amz-meta-x-amz-iv":      "aXY=",
+		"x-amz-meta-x-amz-matdesc": "{}",
+	}
+	resp, respBody, err := client.do("PUT", bucket, "encrypted", nil, envelope, []byte("ciphertext"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+
+	resp, respBody, err = client.do("PUT", bucket, "invalid", nil, map[string]string{"x-amz-meta-x-amz-key": "a2V5"}, []byte("ciphertext"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "PutObject with incomplete envelope", resp, respBody, ErrInvalidCSEMetadata)
+
+	resp, respBody, err = client.do("PUT", bucket, "copy", nil, map[string]string{"X-Amz-Copy-Source": "/" + bucket + "/encrypted"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "CopyObject", resp, respBody, http.StatusOK)
+	resp, respBody, err = client.do("HEAD", bucket, "copy", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "HeadObject", resp, respBody, http.StatusOK)
+	for key, value := range envelope {
+		if resp.Header.Get(key) != value {
+			t.Errorf("Expected %s of copy to be %q, got %q", key, value, resp.Header.Get(key))
+		}
+	}
+
+	resp, respBody, err = client.do("PUT", bucket, "encrypted", url.Values{"patch": {"0"}}, nil, []byte("xx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "PatchObject", resp, respBody, ErrCSEObjectNotModifiable)
+
+	resp, respBody, err = client.do("GET", bucket, "", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "ListObjects", resp, respBody, http.StatusOK)
+	listResp := ListObjectsResponse{}
+	if err = xml.Unmarshal(respBody, &listResp); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"copy": cseVersionV1, "encrypted": cseVersionV1}
+	if len(listResp.Contents) != len(expected) {
+		t.Fatalf("Expected %d objects, got %d", len(expected), len(listResp.Contents))
+	}
+	for _, object := range listResp.Contents {
+		if object.ClientSideEncryption != expected[object.Key] {
+			t.Errorf("Expected %s to be listed with %q, got %q", object.Key, expected[object.Key], object.ClientSideEncryption)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Response header naming the check which denied a request, only set
+// if the server was started with MINIO_DEBUG_DENIALS=1.
+const denialReasonHeader = "X-Minio-Denial-Reason"
+
+// denialChecks - checks which deny requests with the error code,
+// ErrAccessDenied is returned by many checks and is resolved per
+// request by getAccessDeniedReason.
+var denialChecks = map[APIErrorCode]string{
+	ErrSignatureDoesNotMatch:     "signature",
+	ErrInvalidAccessKeyID:        "signature",
+	ErrRequestTimeTooSkewed:      "signature",
+	ErrExpiredPresignRequest:     "signature",
+	ErrMissingDateHeader:         "signature",
+	ErrPolicyAlreadyExpired:      "signature",
+	ErrPresignConstraintMismatch: "presign-constraints",
+	ErrTenantQuotaExceeded:       "quota",
+	ErrTooManyBuckets:            "quota",
+	ErrStorageFull:               "quota",
+	ErrObjectAlreadyExists:       "retention",
+	ErrObjectLocked:              "retention",
+	ErrMissingRequiredChecksum:   "checksum",
+}
+
+// getRequestPolicyAction - returns bucket policy action authorizing
+// an anonymous request, empty if anonymous requests are never allowed.
+func getRequestPolicyAction(r *http.Request, object string) string {
+	query := r.URL.Query()
+	_, isUpload := query["uploadId"]
+	if object == "" {
+		if r.Method != "GET" && r.Method != "HEAD" {
+			return ""
+		}
+		if _, ok := query["location"]; ok {
+			return "s3:GetBucketLocation"
+		}
+		if _, ok := query["uploads"]; ok {
+			return "s3:ListBucketMultipartUploads"
+		}
+		if _, ok := query["versions"]; ok {
+			return "s3:ListBucketVersions"
+		}
+		return "s3:ListBucket"
+	}
+	_, isVersion := query["versionId"]
+	switch r.Method {
+	case "GET", "HEAD":
+		if isUpload {
+			return "s3:ListMultipartUploadParts"
+		}
+		if isVersion {
+			return "s3:GetObjectVersion"
+		}
+		return "s3:GetObject"
+	case "PUT", "POST":
+		return "s3:PutObject"
+	case "DELETE":
+		if isUpload {
+			return "s3:AbortMultipartUpload"
+		}
+		if isVersion {
+			return "s3:DeleteObjectVersion"
+		}
+		return "s3:DeleteObject"
+	}
+	return ""
+}
+
+// getAccessDeniedReason - evaluates the checks returning ErrAccessDenied
+// in the order requests pass them.
+func getAccessDeniedReason(r *http.Request) string {
+	for _, header := range []string{"X-Amz-Expected-Bucket-Owner", "X-Amz-Source-Expected-Bucket-Owner"} {
+		if _, ok := r.Header[header]; ok && r.Header.Get(header) != bucketOwnerID {
+			return "bucket-owner: " + header + " does not match the owner of the bucket."
+		}
+	}
+	bucket, object := urlPath2BucketObjectName(r.URL)
+	switch getRequestAuthType(r) {
+	case authTypeAnonymous:
+		action := getRequestPolicyAction(r, object)
+		if action == "" || strings.HasPrefix(r.URL.Path, reservedBucket) {
+			return "policy: Anonymous requests are never allowed for this operation."
+		}
+		conditions := make(map[string]string)
+		for queryParam := range r.URL.Query() {
+			conditions[queryParam] = r.URL.Query().Get(queryParam)
+		}
+		result, err := simulateBucketPolicy(policySimulation{
+			Action:     action,
+			Bucket:     bucket,
+			Object:     object,
+			Conditions: conditions,
+		})
+		if err != nil {
+			return "policy: Unable to evaluate the bucket policy."
+		}
+		if result.Statement != nil && result.Statement.Sid != "" {
+			return "policy: " + result.Reason + " (Sid " + result.Statement.Sid + ")"
+		}
+		return "policy: " + result.Reason
+	case authTypeSigned, authTypePresigned, authTypeBasic:
+		accessKey := getRequestAccessKey(r)
+		if accessKey == serverConfig.GetCredential().AccessKeyID {
+			return "credential: Operation is not allowed for the server credential."
+		}
+		t, ok := globalTenants.GetByAccessKey(accessKey)
+		if !ok {
+			return "credential: Access key is unknown."
+		}
+		if strings.HasPrefix(r.URL.Path, reservedBucket) {
+			return "tenant: Admin, web and RPC APIs require the server credential."
+		}
+		if bucket != "" && !t.ownsBucket(bucket) {
+			return "tenant: Bucket is outside the namespace of tenant " + t.Name + "."
+		}
+		if copySource := r.Header.Get("X-Amz-Copy-Source"); copySource != "" {
+			if sourceBucket, _ := getCopySource(copySource); !t.ownsBucket(sourceBucket) {
+				return "tenant: Copy source bucket is outside the namespace of tenant " + t.Name + "."
+			}
+		}
+		return "credential: Operation is not allowed for tenant " + t.Name + "."
+	}
+	return "auth: Authentication type of the request is not supported."
+}
+
+// getDenialReason - returns the check which denied the request with
+// the error code, empty if the error code is not a denial.
+func getDenialReason(r *http.Request, errorCode APIErrorCode) string {
+	if errorCode == ErrAccessDenied {
+		return getAccessDeniedReason(r)
+	}
+	check, ok := denialChecks[errorCode]
+	if !ok {
+		return ""
+	}
+	return check + ": " + getAPIError(errorCode).Description
+}
@@ -0,0 +1,139 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Tests bucket policy actions of anonymous requests.
+func TestGetRequestPolicyAction(t *testing.T) {
+	testCases := []struct {
+		method         string
+		urlStr         string
+		expectedAction string
+	}{
+		// Test case - 1.
+		{"GET", "/bucket/object", "s3:GetObject"},
+		// Test case - 2.
+		{"HEAD", "/bucket/object", "s3:GetObject"},
+		// Test case - 3.
+		{"PUT", "/bucket/object", "s3:PutObject"},
+		// Test case - 4.
+		{"POST", "/bucket/object?uploads", "s3:PutObject"},
+		// Test case - 5.
+		{"DELETE", "/bucket/object", "s3:DeleteObject"},
+		// Test case - 6.
+		{"DELETE", "/bucket/object?uploadId=id", "s3:AbortMultipartUpload"},
+		// Test case - 7.
+		{"GET", "/bucket/object?uploadId=id", "s3:ListMultipartUploadParts"},
+		// Test case - 8.
+		{"GET", "/bucket", "s3:ListBucket"},
+		// Test case - 9.
+		{"GET", "/bucket?location", "s3:GetBucketLocation"},
+		// Test case - 10.
+		{"GET", "/bucket?uploads", "s3:ListBucketMultipartUploads"},
+		// Test case - 11.
+		// Bucket operations other than reads are never anonymous.
+		{"PUT", "/bucket", ""},
+		// Test case - 12.
+		{"GET", "/bucket?versions", "s3:ListBucketVersions"},
+		// Test case - 13.
+		{"GET", "/bucket/object?versionId=null", "s3:GetObjectVersion"},
+		// Test case - 14.
+		{"DELETE", "/bucket/object?versionId=null", "s3:DeleteObjectVersion"},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(testCase.method, testCase.urlStr, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, object := urlPath2BucketObjectName(req.URL)
+		if action := getRequestPolicyAction(req, object); action != testCase.expectedAction {
+			t.Errorf("Test %d: Expected action %q, got %q", i+1, testCase.expectedAction, action)
+		}
+	}
+}
+
+// Tests denied requests name the denying check in debug mode only.
+func TestDenialReasonHeader(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+	bucket := makeIntegrationBucket(t, client)
+	noPolicyBucket := makeIntegrationBucket(t, client)
+
+	policy := `{"Version":"2012-10-17","Statement":[{"Sid":"DenyGet","Action":["s3:GetObject"],"Effect":"Deny","Principal":{"AWS":["*"]},"Resource":["arn:aws:s3:::` + bucket + `/*"]}]}`
+	resp, respBody, err := client.do("PUT", bucket, "", url.Values{"policy": {""}}, nil, []byte(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutBucketPolicy", resp, respBody, http.StatusNoContent)
+
+	signedRequest := func(bucket, secretKey string) *http.Request {
+		req, rErr := newTestRequest("GET", makeTestTargetURL(testServer.Server.URL, bucket, "object", nil), 0, nil, testServer.AccessKey, secretKey)
+		if rErr != nil {
+			t.Fatal(rErr)
+		}
+		return req
+	}
+	anonymousRequest := func(bucket string) *http.Request {
+		req, rErr := http.NewRequest("GET", makeTestTargetURL(testServer.Server.URL, bucket, "object", nil), nil)
+		if rErr != nil {
+			t.Fatal(rErr)
+		}
+		return req
+	}
+
+	testCases := []struct {
+		req            *http.Request
+		debug          bool
+		expectedReason string
+	}{
+		// Test case - 1.
+		{anonymousRequest(bucket), true, "policy: Statement 1 matches with effect Deny. (Sid DenyGet)"},
+		// Test case - 2.
+		{anonymousRequest(noPolicyBucket), true, "policy: Bucket has no policy"},
+		// Test case - 3.
+		{signedRequest(bucket, "wrong-secret-key"), true, "signature: "},
+		// Test case - 4.
+		// Not denied.
+		{signedRequest(bucket, testServer.SecretKey), true, ""},
+		// Test case - 5.
+		// Debug mode is off.
+		{anonymousRequest(bucket), false, ""},
+	}
+	for i, testCase := range testCases {
+		globalDebugDenials = testCase.debug
+		resp, err = http.DefaultClient.Do(testCase.req)
+		globalDebugDenials = false
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		reason := resp.Header.Get(denialReasonHeader)
+		if testCase.expectedReason == "" && reason != "" {
+			t.Errorf("Test %d: Expected no denial reason, got %q", i+1, reason)
+		}
+		if !strings.HasPrefix(reason, testCase.expectedReason) {
+			t.Errorf("Test %d: Expected denial reason %q, got %q", i+1, testCase.expectedReason, reason)
+		}
+	}
+}
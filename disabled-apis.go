@@ -0,0 +1,103 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// API groups which can be disabled in the server config, each returns
+// true for requests of the group. Requests of the browser, admin and
+// RPC paths are never part of a group.
+var apiGroups = map[string]func(r *http.Request, bucket, object string) bool{
+	// Deleting buckets, erasing them with the admin API is not
+	// affected.
+	"DeleteBucket": func(r *http.Request, bucket, object string) bool {
+		return getRequestAPI(r, bucket, object) == "DELETE bucket"
+	},
+	// Deleting objects, one at a time and in batches.
+	"DeleteObject": func(r *http.Request, bucket, object string) bool {
+		api := getRequestAPI(r, bucket, object)
+		return api == "DELETE object" || api == "POST bucket?delete"
+	},
+	// Modifying objects in place.
+	"PatchObject": func(r *http.Request, bucket, object string) bool {
+		return getRequestAPI(r, bucket, object) == "PUT object?patch"
+	},
+	// All anonymous requests, whatever the bucket policies allow.
+	"Anonymous": func(r *http.Request, bucket, object string) bool {
+		return getRequestAuthType(r) == authTypeAnonymous
+	},
+	// Anonymous reads of objects served publicly.
+	"Website": func(r *http.Request, bucket, object string) bool {
+		return object != "" && isWebsiteRequest(r)
+	},
+}
+
+// validateDisabledAPIs - verifies all disabled API groups are known.
+func validateDisabledAPIs(disabledAPIs []string) error {
+	for _, name := range disabledAPIs {
+		if _, ok := apiGroups[name]; !ok {
+			return fmt.Errorf("Unknown API group %s.", name)
+		}
+	}
+	return nil
+}
+
+// getDisabledAPIs - returns API groups disabled in the server config.
+func getDisabledAPIs() []string {
+	if serverConfig == nil {
+		return nil
+	}
+	return serverConfig.GetDisabledAPIs()
+}
+
+// isAPIDisabled - returns true if the request is part of a disabled
+// API group.
+func isAPIDisabled(r *http.Request) bool {
+	if strings.HasPrefix(r.URL.Path, reservedBucket) {
+		return false
+	}
+	bucket, object := urlPath2BucketObjectName(r.URL)
+	for _, name := range getDisabledAPIs() {
+		if isAPIGroup, ok := apiGroups[name]; ok && isAPIGroup(r, bucket, object) {
+			return true
+		}
+	}
+	return false
+}
+
+// disabledAPIsHandler - rejects requests of disabled API groups.
+type disabledAPIsHandler struct {
+	handler http.Handler
+}
+
+// setDisabledAPIsHandler to reject requests of API groups disabled in
+// the server config.
+func setDisabledAPIsHandler(h http.Handler) http.Handler {
+	return disabledAPIsHandler{h}
+}
+
+func (h disabledAPIsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isAPIDisabled(r) {
+		writeErrorResponse(w, r, ErrMethodNotAllowed, r.URL.Path)
+		return
+	}
+	h.handler.ServeHTTP(w, r)
+}
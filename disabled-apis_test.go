@@ -0,0 +1,122 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests requests of disabled API groups are rejected.
+func TestDisabledAPIsHandler(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	handler := setDisabledAPIsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	signed := "AWS4-HMAC-SHA256 Credential=ACCESSKEY/20160820/us-east-1/s3/aws4_request"
+	testCases := []struct {
+		disabledAPIs   []string
+		method         string
+		url            string
+		authorization  string
+		expectedStatus int
+	}{
+		// Test case - 1.
+		// Nothing is disabled by default.
+		{nil, "DELETE", "/bucket", signed, http.StatusOK},
+		// Test case - 2.
+		{[]string{"DeleteBucket"}, "DELETE", "/bucket", signed, http.StatusMethodNotAllowed},
+		// Test case - 3.
+		// Subresources of the bucket are not part of the group.
+		{[]string{"DeleteBucket"}, "DELETE", "/bucket?replicate", signed, http.StatusOK},
+		// Test case - 4.
+		{[]string{"DeleteBucket"}, "DELETE", "/bucket/object", signed, http.StatusOK},
+		// Test case - 5.
+		{[]string{"DeleteObject"}, "DELETE", "/bucket/object", signed, http.StatusMethodNotAllowed},
+		// Test case - 6.
+		// Deleting objects in a batch.
+		{[]string{"DeleteObject"}, "POST", "/bucket?delete", signed, http.StatusMethodNotAllowed},
+		// Test case - 7.
+		{[]string{"PatchObject"}, "PUT", "/bucket/object?patch=0", signed, http.StatusMethodNotAllowed},
+		// Test case - 8.
+		{[]string{"PatchObject"}, "PUT", "/bucket/object", signed, http.StatusOK},
+		// Test case - 9.
+		{[]string{"Anonymous"}, "PUT", "/bucket/object", "", http.StatusMethodNotAllowed},
+		// Test case - 10.
+		{[]string{"Anonymous"}, "GET", "/bucket/object", signed, http.StatusOK},
+		// Test case - 11.
+		// Browser paths are never disabled.
+		{[]string{"Anonymous"}, "POST", "/minio/webrpc", "", http.StatusOK},
+		// Test case - 12.
+		{[]string{"Website"}, "GET", "/bucket/index.html", "", http.StatusMethodNotAllowed},
+		// Test case - 13.
+		// Anonymous writes and listings are not part of the website.
+		{[]string{"Website"}, "PUT", "/bucket/index.html", "", http.StatusOK},
+		// Test case - 14.
+		{[]string{"Website"}, "GET", "/bucket", "", http.StatusOK},
+	}
+	for i, testCase := range testCases {
+		serverConfig.SetDisabledAPIs(testCase.disabledAPIs)
+		req, err := http.NewRequest(testCase.method, "http://localhost:9000"+testCase.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if testCase.authorization != "" {
+			req.Header.Set("Authorization", testCase.authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedStatus {
+			t.Errorf("Test %d: Expected status %d, got %d", i+1, testCase.expectedStatus, rec.Code)
+		}
+	}
+}
+
+// Tests validate disabled API groups of the server config.
+func TestValidateDisabledAPIs(t *testing.T) {
+	testCases := []struct {
+		disabledAPIs []string
+		shouldPass   bool
+	}{
+		// Test case - 1.
+		{nil, true},
+		// Test case - 2.
+		{[]string{"DeleteBucket", "DeleteObject", "PatchObject", "Anonymous", "Website"}, true},
+		// Test case - 3.
+		// Names are case sensitive.
+		{[]string{"deletebucket"}, false},
+	}
+	for i, testCase := range testCases {
+		err := validateDisabledAPIs(testCase.disabledAPIs)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail", i+1)
+		}
+	}
+}
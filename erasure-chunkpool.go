@@ -0,0 +1,60 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+// Idle chunk buffers kept for reuse by erasure reads.
+const maxIdleErasureChunks = 64
+
+// chunkPool - reuses chunk buffers across blocks and reads, keeping at
+// most a bounded number of idle buffers so memory is returned after
+// bursts of reads.
+type chunkPool struct {
+	idle chan []byte
+}
+
+// newChunkPool - initialize a pool keeping up to maxIdle buffers.
+func newChunkPool(maxIdle int) *chunkPool {
+	return &chunkPool{idle: make(chan []byte, maxIdle)}
+}
+
+// get - returns a buffer of length size, an idle buffer is reused if
+// large enough.
+func (p *chunkPool) get(size int64) []byte {
+	select {
+	case buf := <-p.idle:
+		if int64(cap(buf)) >= size {
+			return buf[:size]
+		}
+	default:
+	}
+	return make([]byte, size)
+}
+
+// put - returns a buffer to the pool, dropped if enough buffers are
+// idle already.
+func (p *chunkPool) put(buf []byte) {
+	if buf == nil {
+		return
+	}
+	select {
+	case p.idle <- buf:
+	default:
+	}
+}
+
+// Chunk buffers of erasure reads across all requests of the server.
+var globalErasureChunks = newChunkPool(maxIdleErasureChunks)
@@ -0,0 +1,104 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// Tests chunk buffers are reused and idle buffers are bounded.
+func TestChunkPool(t *testing.T) {
+	pool := newChunkPool(1)
+	buf := pool.get(1024)
+	if len(buf) != 1024 {
+		t.Fatalf("Expected buffer of 1024 bytes, got %d", len(buf))
+	}
+	pool.put(buf)
+	// Idle buffer is reused for smaller chunks.
+	if reused := pool.get(512); len(reused) != 512 || &reused[0] != &buf[0] {
+		t.Error("Expected idle buffer to be reused")
+	}
+
+	// Idle buffers beyond the bound are dropped.
+	first, second := pool.get(1024), pool.get(1024)
+	pool.put(first)
+	pool.put(second)
+	if reused := pool.get(1024); &reused[0] != &first[0] {
+		t.Error("Expected first idle buffer to be reused")
+	}
+	if reused := pool.get(1024); &reused[0] == &second[0] {
+		t.Error("Expected buffer beyond the bound to be dropped")
+	}
+
+	// Idle buffer too small for the chunk is not reused.
+	pool.put(make([]byte, 10))
+	if reused := pool.get(1024); len(reused) != 1024 {
+		t.Errorf("Expected buffer of 1024 bytes, got %d", len(reused))
+	}
+}
+
+// Tests chunks are read in full from shards.
+func TestReadChunk(t *testing.T) {
+	root, err := ioutil.TempDir("", "minio-")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir. %s", err)
+	}
+	defer removeAll(root)
+	disk, err := newPosix(root)
+	if err != nil {
+		t.Fatalf("Unable to initialize posix. %s", err)
+	}
+	if err = disk.MakeVol("bucket"); err != nil {
+		t.Fatalf("Unable to create volume. %s", err)
+	}
+	shard := bytes.Repeat([]byte("a"), 3*readSizeV1+10)
+	if err = disk.AppendFile("bucket", "shard", shard); err != nil {
+		t.Fatalf("Unable to create shard. %s", err)
+	}
+
+	testCases := []struct {
+		offset      int64
+		length      int64
+		expectedErr error
+	}{
+		// Test case - 1.
+		// Chunk larger than a single read.
+		{0, 2*readSizeV1 + 5, nil},
+		// Test case - 2.
+		// Chunk ending with the shard.
+		{readSizeV1, 2*readSizeV1 + 10, nil},
+		// Test case - 3.
+		// Shard shorter than the chunk.
+		{readSizeV1, 3 * readSizeV1, io.ErrUnexpectedEOF},
+		// Test case - 4.
+		// Chunk past the end of the shard.
+		{int64(len(shard)), 1, io.ErrUnexpectedEOF},
+	}
+	for i, testCase := range testCases {
+		buf := make([]byte, testCase.length)
+		err := readChunk(disk, "bucket", "shard", testCase.offset, buf)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		if err == nil && !bytes.Equal(buf, shard[testCase.offset:testCase.offset+testCase.length]) {
+			t.Errorf("Test %d: Chunk does not match the shard", i+1)
+		}
+	}
+}
@@ -27,14 +27,35 @@ import (
 
 // erasureCreateFile - writes an entire stream by erasure coding to
 // all the disks, writes also calculate individual block's checksum
-// for future bit-rot protection.
+// for future bit-rot protection. Disks failing a write are skipped for
+// the rest of the stream as long as write quorum disks remain, only
+// erasure infos of the disks holding all blocks are returned valid.
 func erasureCreateFile(disks []StorageAPI, volume string, path string, partName string, data io.Reader, eInfos []erasureInfo, writeQuorum int) (newEInfos []erasureInfo, size int64, err error) {
 	// Just pick one eInfo.
 	eInfo := pickValidErasureInfo(eInfos)
+	if err = checkErasureLayout(eInfo, len(disks)); err != nil {
+		return nil, 0, err
+	}
+	// Failed disks are removed from a copy, disks of the caller are
+	// left untouched. Disks without valid erasure info failed writes
+	// of previous parts and are not written.
+	disks = append([]StorageAPI(nil), disks...)
+	for index, eInfo := range eInfos {
+		if !eInfo.IsValid() {
+			disks[index] = nil
+		}
+	}
 
 	// Allocated blockSized buffer for reading.
 	buf := make([]byte, eInfo.BlockSize)
-	hashWriters := newHashWriters(len(disks))
+	// Bitrot hash algorithm is recorded with the checksums, parts
+	// written before a change of algorithm remain verifiable.
+	algo := getBitrotAlgorithm()
+	hashWriters := newHashWriters(len(disks), algo)
+	// Checksums of each block of every shard, verified while reading.
+	blockHashes := make([][]string, len(disks))
+	// Shard written to each disk.
+	shards := shardIndexes(eInfos)
 
 	// Read until io.EOF, erasure codes data and writes to all disks.
 	for {
@@ -47,7 +68,7 @@ func erasureCreateFile(disks []StorageAPI, volume string, path string, partName
 			// data. Will create a 0byte file instead.
 			if size == 0 {
 				blocks = make([][]byte, len(disks))
-				err = appendFile(disks, volume, path, blocks, eInfo.Distribution, hashWriters, writeQuorum)
+				err = appendFile(disks, volume, path, blocks, shards, hashWriters, writeQuorum)
 				if err != nil {
 					return nil, 0, err
 				}
@@ -65,32 +86,28 @@ func erasureCreateFile(disks []StorageAPI, volume string, path string, partName
 		if enErr != nil {
 			return nil, 0, enErr
 		}
+		for shard := range blocks {
+			blockHashes[shard] = append(blockHashes[shard], blockHash(algo, blocks[shard]))
+		}
 
 		// Write to all disks.
-		err = appendFile(disks, volume, path, blocks, eInfo.Distribution, hashWriters, writeQuorum)
+		err = appendFile(disks, volume, path, blocks, shards, hashWriters, writeQuorum)
 		if err != nil {
 			return nil, 0, err
 		}
 	}
 
-	// Save the checksums.
-	checkSums := make([]checkSumInfo, len(disks))
-	for index := range disks {
-		blockIndex := eInfo.Distribution[index] - 1
-		checkSums[blockIndex] = checkSumInfo{
-			Name:      partName,
-			Algorithm: "blake2b",
-			Hash:      hex.EncodeToString(hashWriters[blockIndex].Sum(nil)),
-		}
-	}
-
-	// Erasure info update for checksum for each disks.
+	// Erasure info update for checksum of the shard on each disk.
 	newEInfos = make([]erasureInfo, len(disks))
 	for index, eInfo := range eInfos {
-		if eInfo.IsValid() {
-			blockIndex := eInfo.Distribution[index] - 1
+		if eInfo.IsValid() && disks[index] != nil {
 			newEInfos[index] = eInfo
-			newEInfos[index].Checksum = append(newEInfos[index].Checksum, checkSums[blockIndex])
+			newEInfos[index].Checksum = append(newEInfos[index].Checksum, checkSumInfo{
+				Name:      partName,
+				Algorithm: algo,
+				Hash:      hex.EncodeToString(hashWriters[shards[index]].Sum(nil)),
+				Blocks:    blockHashes[shards[index]],
+			})
 		}
 	}
 
@@ -101,6 +118,9 @@ func erasureCreateFile(disks []StorageAPI, volume string, path string, partName
 // encodeData - encodes incoming data buffer into
 // dataBlocks+parityBlocks returns a 2 dimensional byte array.
 func encodeData(dataBuffer []byte, dataBlocks, parityBlocks int) ([][]byte, error) {
+	release := globalErasureWorkers.acquireCodec()
+	defer release()
+
 	rs, err := reedsolomon.New(dataBlocks, parityBlocks)
 	if err != nil {
 		return nil, err
@@ -122,10 +142,13 @@ func encodeData(dataBuffer []byte, dataBlocks, parityBlocks int) ([][]byte, erro
 	return blocks, nil
 }
 
-// appendFile - append data buffer at path.
-func appendFile(disks []StorageAPI, volume, path string, enBlocks [][]byte, distribution []int, hashWriters []hash.Hash, writeQuorum int) (err error) {
+// appendFile - append data buffer at path, shards holds the index of
+// the block written to each disk. Disks failing the write are removed
+// from disks, offline disks do not count towards write quorum.
+func appendFile(disks []StorageAPI, volume, path string, enBlocks [][]byte, shards []int, hashWriters []hash.Hash, writeQuorum int) (err error) {
 	var wg = &sync.WaitGroup{}
 	var wErrs = make([]error, len(disks))
+	workers := globalErasureWorkers
 	// Write encoded data to quorum disks in parallel.
 	for index, disk := range disks {
 		if disk == nil {
@@ -135,8 +158,10 @@ func appendFile(disks []StorageAPI, volume, path string, enBlocks [][]byte, dist
 		// Write encoded data in routine.
 		go func(index int, disk StorageAPI) {
 			defer wg.Done()
-			// Pick the block from the distribution.
-			blockIndex := distribution[index] - 1
+			workers.acquireIO()
+			defer workers.releaseIO()
+			// Pick the block of the disk.
+			blockIndex := shards[index]
 			wErr := disk.AppendFile(volume, path, enBlocks[blockIndex])
 			if wErr != nil {
 				wErrs[index] = wErr
@@ -154,8 +179,15 @@ func appendFile(disks []StorageAPI, volume, path string, enBlocks [][]byte, dist
 	// Wait for all the appends to finish.
 	wg.Wait()
 
+	// Remove failed disks, they are written again by healing.
+	for index, wErr := range wErrs {
+		if wErr != nil {
+			disks[index] = nil
+		}
+	}
+
 	// Do we have write quorum?.
-	if !isQuorum(wErrs, writeQuorum) {
+	if diskCount(disks) < writeQuorum {
 		return toObjectErr(errXLWriteQuorum, volume, path)
 	}
 	return nil
@@ -0,0 +1,58 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "github.com/klauspost/cpuid"
+
+// cpuFeatures - CPU instructions accelerating bitrot hashes.
+type cpuFeatures struct {
+	SSE42 bool // crc32 instruction.
+	CLMUL bool // Carry-less multiplication, folds crc32c of large buffers.
+	SHA   bool // sha256 instructions.
+	AVX2  bool // Vectorized sha256 message schedule.
+}
+
+// detectCPUFeatures - detects CPU features of the running CPU, all are
+// false on other architectures than x86.
+func detectCPUFeatures() cpuFeatures {
+	return cpuFeatures{
+		SSE42: cpuid.CPU.SSE42(),
+		CLMUL: cpuid.CPU.Clmul(),
+		SHA:   cpuid.CPU.SHA(),
+		AVX2:  cpuid.CPU.AVX2(),
+	}
+}
+
+// fastestBitrotAlgorithm - returns the bitrot algorithm hashed fastest
+// with the CPU features. Hashes of crc32c and sha256 switch to the
+// vectorized implementation by themselves, blake2b is never
+// accelerated and is the fallback.
+func fastestBitrotAlgorithm(cpu cpuFeatures) string {
+	switch {
+	case cpu.SSE42 && cpu.CLMUL:
+		return bitrotAlgorithmCRC32C
+	case cpu.SHA || cpu.AVX2:
+		return bitrotAlgorithmSHA256
+	default:
+		return bitrotAlgorithmBlake2b
+	}
+}
+
+// Bitrot algorithm of new writes configured as "auto", detected at
+// startup. Objects record the detected algorithm, so they verify on
+// any CPU.
+var autoBitrotAlgorithm = fastestBitrotAlgorithm(detectCPUFeatures())
@@ -0,0 +1,115 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/minio/minio/pkg/crypto/sha256"
+)
+
+// Tests the fastest bitrot algorithm is selected for CPU features.
+func TestFastestBitrotAlgorithm(t *testing.T) {
+	testCases := []struct {
+		cpu          cpuFeatures
+		expectedAlgo string
+	}{
+		// Test case - 1.
+		{cpuFeatures{SSE42: true, CLMUL: true, SHA: true, AVX2: true}, bitrotAlgorithmCRC32C},
+		// Test case - 2.
+		// crc32c of large buffers is slow without carry-less multiplication.
+		{cpuFeatures{SSE42: true, AVX2: true}, bitrotAlgorithmSHA256},
+		// Test case - 3.
+		{cpuFeatures{SHA: true}, bitrotAlgorithmSHA256},
+		// Test case - 4.
+		// Fallback without acceleration.
+		{cpuFeatures{}, bitrotAlgorithmBlake2b},
+	}
+	for i, testCase := range testCases {
+		if algo := fastestBitrotAlgorithm(testCase.cpu); algo != testCase.expectedAlgo {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.expectedAlgo, algo)
+		}
+	}
+	if !isValidBitrotAlgorithm(autoBitrotAlgorithm) {
+		t.Errorf("Expected detected algorithm %s to be registered", autoBitrotAlgorithm)
+	}
+}
+
+// crc32cGeneric - bitwise crc32c, reference for the accelerated one.
+func crc32cGeneric(data []byte) []byte {
+	crc := ^uint32(0)
+	for _, b := range data {
+		crc ^= uint32(b)
+		for i := 0; i < 8; i++ {
+			if crc&1 == 1 {
+				crc = crc>>1 ^ 0x82f63b78
+			} else {
+				crc >>= 1
+			}
+		}
+	}
+	crc = ^crc
+	return []byte{byte(crc >> 24), byte(crc >> 16), byte(crc >> 8), byte(crc)}
+}
+
+// Tests accelerated bitrot hashes match implementations without
+// acceleration for all buffer sizes.
+func TestBitrotHashParity(t *testing.T) {
+	for i, size := range []int{0, 1, 63, 64, 65, 4095, 4096, readSizeV1 + 7} {
+		data := make([]byte, size)
+		for j := range data {
+			data[j] = byte(j * 31)
+		}
+		crc := newHash(bitrotAlgorithmCRC32C)
+		crc.Write(data)
+		if sum := crc.Sum(nil); !bytes.Equal(sum, crc32cGeneric(data)) {
+			t.Errorf("Test %d: Expected crc32c %x, got %x", i+1, crc32cGeneric(data), sum)
+		}
+		expectedSum := sha256.Sum256(data)
+		if sum := blockHash(bitrotAlgorithmSHA256, data); sum != hex.EncodeToString(expectedSum[:]) {
+			t.Errorf("Test %d: Expected sha256 %x, got %s", i+1, expectedSum, sum)
+		}
+	}
+}
+
+// benchmarkBitrotHash - benchmarks hashing a block of an erasure shard.
+func benchmarkBitrotHash(b *testing.B, algo string) {
+	block := make([]byte, getEncodedBlockLen(blockSizeV1, 8))
+	b.SetBytes(int64(len(block)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		blockHash(algo, block)
+	}
+}
+
+func BenchmarkBitrotHashBlake2b(b *testing.B) {
+	benchmarkBitrotHash(b, bitrotAlgorithmBlake2b)
+}
+
+func BenchmarkBitrotHashSHA256(b *testing.B) {
+	benchmarkBitrotHash(b, bitrotAlgorithmSHA256)
+}
+
+func BenchmarkBitrotHashCRC32C(b *testing.B) {
+	benchmarkBitrotHash(b, bitrotAlgorithmCRC32C)
+}
+
+func BenchmarkBitrotHashAuto(b *testing.B) {
+	benchmarkBitrotHash(b, autoBitrotAlgorithm)
+}
@@ -17,7 +17,6 @@
 package main
 
 import (
-	"bytes"
 	"encoding/hex"
 	"errors"
 	"io"
@@ -65,16 +64,20 @@ func isSuccessDataBlocks(enBlocks [][]byte, dataBlocks int) bool {
 	return successDataBlocksCount >= dataBlocks
 }
 
-// getOrderedDisks - get ordered disks from erasure distribution.
-// returns ordered slice of disks from their actual distribution.
-func getOrderedDisks(distribution []int, disks []StorageAPI, blockCheckSums []checkSumInfo) (orderedDisks []StorageAPI, orderedBlockCheckSums []checkSumInfo) {
+// getOrderedDisks - get ordered disks from erasure info of each disk.
+// returns ordered slice of disks from the shards they hold, disks
+// without valid erasure info hold no shard.
+func getOrderedDisks(eInfos []erasureInfo, disks []StorageAPI, blockCheckSums []checkSumInfo) (orderedDisks []StorageAPI, orderedBlockCheckSums []checkSumInfo) {
 	orderedDisks = make([]StorageAPI, len(disks))
 	orderedBlockCheckSums = make([]checkSumInfo, len(disks))
 	// From disks gets ordered disks.
 	for index := range disks {
-		blockIndex := distribution[index]
-		orderedDisks[blockIndex-1] = disks[index]
-		orderedBlockCheckSums[blockIndex-1] = blockCheckSums[index]
+		if !eInfos[index].IsValid() {
+			continue
+		}
+		blockIndex := eInfos[index].shardIndex(index)
+		orderedDisks[blockIndex] = disks[index]
+		orderedBlockCheckSums[blockIndex] = blockCheckSums[index]
 	}
 	return orderedDisks, orderedBlockCheckSums
 }
@@ -133,35 +136,59 @@ func getReadDisks(orderedDisks []StorageAPI, index int, dataBlocks int) (readDis
 func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path string, partName string, eInfos []erasureInfo, offset int64, length int64, totalLength int64) (int64, error) {
 	// Pick one erasure info.
 	eInfo := pickValidErasureInfo(eInfos)
+	if err := checkErasureLayout(eInfo, len(disks)); err != nil {
+		return 0, err
+	}
 
 	// Gather previously calculated block checksums.
 	blockCheckSums := metaPartBlockChecksums(disks, eInfos, partName)
 
 	// []orderedDisks will have first eInfo.DataBlocks disks as data
 	// disks and rest will be parity.
-	orderedDisks, orderedBlockCheckSums := getOrderedDisks(eInfo.Distribution, disks, blockCheckSums)
+	orderedDisks, orderedBlockCheckSums := getOrderedDisks(eInfos, disks, blockCheckSums)
+
+	// Blocks are read concurrently, disks failing a read are skipped
+	// by all the blocks read after.
+	disksMutex := &sync.Mutex{}
+	getBlockDisks := func() []StorageAPI {
+		disksMutex.Lock()
+		defer disksMutex.Unlock()
+		return append([]StorageAPI(nil), orderedDisks...)
+	}
+	removeDisk := func(diskIndex int) {
+		disksMutex.Lock()
+		orderedDisks[diskIndex] = nil
+		disksMutex.Unlock()
+	}
 
 	// bitRotVerify verifies if the file on a particular disk doesn't have bitrot
 	// by verifying the hash of the contents of the file.
-	bitRotVerify := func() func(diskIndex int) bool {
+	bitRotVerify := func() func(disk StorageAPI, diskIndex int) bool {
 		verified := make([]bool, len(orderedDisks))
+		valid := make([]bool, len(orderedDisks))
+		verifyMutexes := make([]sync.Mutex, len(orderedDisks))
 		// Return closure so that we have reference to []verified and
 		// not recalculate the hash on it every time the function is
 		// called for the same disk.
-		return func(diskIndex int) bool {
+		return func(disk StorageAPI, diskIndex int) bool {
+			verifyMutexes[diskIndex].Lock()
+			defer verifyMutexes[diskIndex].Unlock()
 			if verified[diskIndex] {
 				// Already validated.
-				return true
+				return valid[diskIndex]
 			}
 			// Is this a valid block?
-			isValid := isValidBlock(orderedDisks[diskIndex], volume, path, orderedBlockCheckSums[diskIndex])
-			verified[diskIndex] = isValid
-			return isValid
+			valid[diskIndex] = isValidBlock(disk, volume, path, orderedBlockCheckSums[diskIndex])
+			verified[diskIndex] = true
+			return valid[diskIndex]
 		}
 	}()
 
 	// Total bytes written to writer
 	bytesWritten := int64(0)
+	if length <= 0 {
+		return bytesWritten, nil
+	}
 
 	// chunkSize is roughly BlockSize/DataBlocks.
 	// chunkSize is calculated such that chunkSize*DataBlocks accommodates BlockSize bytes.
@@ -172,17 +199,25 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 	// Get start and end block, also bytes to be skipped based on the input offset.
 	startBlock, endBlock, bytesToSkip := getBlockInfo(offset, totalLength, eInfo.BlockSize)
 
-	// For each block, read chunk from each disk. If we are able to read all the data disks then we don't
+	// Last block holding requested bytes.
+	lastBlock := (offset + length - 1) / eInfo.BlockSize
+
+	// Only chunks of the blocks being read are held, their buffers
+	// are returned to the pool once the block is written.
+	chunks := globalErasureChunks
+	releaseChunks := func(enBlocks [][]byte) {
+		for _, chunk := range enBlocks {
+			chunks.put(chunk)
+		}
+	}
+
+	// readBlock - read chunk from each disk. If we are able to read all the data disks then we don't
 	// need to read parity disks. If one of the data disk is missing we need to read DataBlocks+1 number
-	// of disks. Once read, we Reconstruct() missing data if needed and write it to the given writer.
-	for block := startBlock; bytesWritten < length; block++ {
+	// of disks. Once read, we Reconstruct() missing data if needed.
+	readBlock := func(block int64) ([][]byte, error) {
 		// Each element of enBlocks holds curChunkSize'd amount of data read from its corresponding disk.
 		enBlocks := make([][]byte, len(orderedDisks))
 
-		// enBlocks data can have 0-padding hence we need to figure the exact number
-		// of bytes we want to read from enBlocks.
-		blockSize := eInfo.BlockSize
-
 		// curChunkSize is chunkSize until end block.
 		curChunkSize := chunkSize
 
@@ -190,9 +225,6 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 		if block == endBlock && (totalLength%eInfo.BlockSize != 0) {
 			// If this is the last block and size of the block is < BlockSize.
 			curChunkSize = getEncodedBlockLen(totalLength%eInfo.BlockSize, eInfo.DataBlocks)
-
-			// For the last block, the block size can be less than BlockSize.
-			blockSize = totalLength % eInfo.BlockSize
 		}
 
 		// Block offset.
@@ -201,6 +233,9 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 		// then it can result in wrong offset for the last block.
 		blockOffset := block * chunkSize
 
+		// Disks of this block, failed disks are removed from it.
+		blockDisks := getBlockDisks()
+
 		// nextIndex - index from which next set of parallel reads
 		// should happen.
 		nextIndex := 0
@@ -214,7 +249,7 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 			if isSuccessDecodeBlocks(enBlocks, eInfo.DataBlocks) {
 				return nil
 			}
-			if nextIndex == len(orderedDisks) {
+			if nextIndex == len(blockDisks) {
 				// No more disks to read from.
 				return errXLReadQuorum
 			}
@@ -222,13 +257,20 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 			// readDisks - disks from which we need to read in parallel.
 			var readDisks []StorageAPI
 			var err error
-			readDisks, nextIndex, err = getReadDisks(orderedDisks, nextIndex, eInfo.DataBlocks)
+			readDisks, nextIndex, err = getReadDisks(blockDisks, nextIndex, eInfo.DataBlocks)
 			if err != nil {
 				return err
 			}
 
 			// WaitGroup to synchronise the read go-routines.
 			wg := &sync.WaitGroup{}
+			workers := globalErasureWorkers
+
+			// So that we don't read from this disk again.
+			failDisk := func(index int) {
+				blockDisks[index] = nil
+				removeDisk(index)
+			}
 
 			// Read disks in parallel.
 			for index := range readDisks {
@@ -239,32 +281,39 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 				// Reads chunk from readDisk[index] in routine.
 				go func(index int) {
 					defer wg.Done()
-
-					// Verify bit rot for the file on this disk.
-					if !bitRotVerify(index) {
-						// So that we don't read from this disk for the next block.
-						orderedDisks[index] = nil
+					workers.acquireIO()
+					defer workers.releaseIO()
+
+					// Verify bit rot for the file on this disk, shards
+					// with block checksums are verified block by block
+					// instead.
+					blockCheckSum := orderedBlockCheckSums[index]
+					if len(blockCheckSum.Blocks) == 0 && !bitRotVerify(readDisks[index], index) {
+						failDisk(index)
 						return
 					}
 
-					// Chunk writer.
-					chunkWriter := bytes.NewBuffer(make([]byte, 0, curChunkSize))
-
-					// CopyN - copies until current chunk size.
-					err := copyN(chunkWriter, readDisks[index], volume, path, blockOffset, curChunkSize)
+					// Chunks of all blocks fit buffers of chunkSize,
+					// which are reused across blocks.
+					chunk := chunks.get(chunkSize)[:curChunkSize]
+					err := readChunk(readDisks[index], volume, path, blockOffset, chunk)
 					if err != nil {
-						// So that we don't read from this disk for the next block.
-						orderedDisks[index] = nil
+						chunks.put(chunk)
+						failDisk(index)
 						return
 					}
 
-					// Copy the read blocks.
-					enBlocks[index] = chunkWriter.Bytes()
-
-					// Reset the buffer.
-					chunkWriter.Reset()
+					// Corrupted chunks are reconstructed from the other
+					// disks.
+					if len(blockCheckSum.Blocks) > 0 && !isValidChunk(chunk, blockCheckSum, block) {
+						chunks.put(chunk)
+						errorIf(errBitrotDetected, "Block %d of %s/%s failed verification.", block, volume, path)
+						failDisk(index)
+						return
+					}
 
 					// Successfully read.
+					enBlocks[index] = chunk
 				}(index)
 			}
 
@@ -276,18 +325,79 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 		}
 
 		// Start reading all blocks in parallel.
-		err := parallelRead()
-		if err != nil {
-			return bytesWritten, err
+		if err := parallelRead(); err != nil {
+			releaseChunks(enBlocks)
+			return nil, err
 		}
 
-		// If we have all the data blocks no need to decode, continue to write.
+		// If we have all the data blocks no need to decode.
 		if !isSuccessDataBlocks(enBlocks, eInfo.DataBlocks) {
 			// Reconstruct the missing data blocks.
-			if err = decodeData(enBlocks, eInfo.DataBlocks, eInfo.ParityBlocks); err != nil {
-				return bytesWritten, err
+			if err := decodeData(enBlocks, eInfo.DataBlocks, eInfo.ParityBlocks); err != nil {
+				releaseChunks(enBlocks)
+				return nil, err
 			}
 		}
+		return enBlocks, nil
+	}
+
+	// Up to concurrency blocks are read ahead while earlier blocks are
+	// written, a slow disk delays its block but not the reads of later
+	// blocks.
+	concurrency := globalErasureReadConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	type blockResult struct {
+		enBlocks [][]byte
+		err      error
+	}
+	resultsCh := make(chan chan blockResult, concurrency)
+	readingCh := make(chan struct{}, concurrency)
+	doneCh := make(chan struct{})
+	go func() {
+		defer close(resultsCh)
+		for block := startBlock; block <= lastBlock; block++ {
+			select {
+			case readingCh <- struct{}{}:
+			case <-doneCh:
+				return
+			}
+			resultCh := make(chan blockResult, 1)
+			resultsCh <- resultCh
+			go func(block int64) {
+				enBlocks, err := readBlock(block)
+				resultCh <- blockResult{enBlocks, err}
+			}(block)
+		}
+	}()
+	defer func() {
+		close(doneCh)
+		// Return chunks of blocks read ahead but never written.
+		go func() {
+			for resultCh := range resultsCh {
+				releaseChunks((<-resultCh).enBlocks)
+			}
+		}()
+	}()
+
+	// Write blocks in order as they are read.
+	for block := startBlock; block <= lastBlock; block++ {
+		resultCh := <-resultsCh
+		result := <-resultCh
+		<-readingCh
+		if result.err != nil {
+			return bytesWritten, result.err
+		}
+
+		// enBlocks data can have 0-padding hence we need to figure the exact number
+		// of bytes we want to read from enBlocks.
+		blockSize := eInfo.BlockSize
+
+		// For the last block, the block size can be less than BlockSize.
+		if block == endBlock && (totalLength%eInfo.BlockSize != 0) {
+			blockSize = totalLength % eInfo.BlockSize
+		}
 
 		var outSize, outOffset int64
 		// If this is start block, skip unwanted bytes.
@@ -303,7 +413,8 @@ func erasureReadFile(writer io.Writer, disks []StorageAPI, volume string, path s
 		}
 
 		// Write data blocks.
-		n, err := writeDataBlocks(writer, enBlocks, eInfo.DataBlocks, outOffset, outSize)
+		n, err := writeDataBlocks(writer, result.enBlocks, eInfo.DataBlocks, outOffset, outSize)
+		releaseChunks(result.enBlocks)
 		if err != nil {
 			return bytesWritten, err
 		}
@@ -356,8 +467,20 @@ func isValidBlock(disk StorageAPI, volume, path string, blockCheckSum checkSumIn
 	return hex.EncodeToString(hashBytes) == blockCheckSum.Hash
 }
 
+// isValidChunk - validates checksum of a chunk read from the block of
+// a shard.
+func isValidChunk(chunk []byte, blockCheckSum checkSumInfo, block int64) bool {
+	if block >= int64(len(blockCheckSum.Blocks)) {
+		return false
+	}
+	return blockHash(blockCheckSum.Algorithm, chunk) == blockCheckSum.Blocks[block]
+}
+
 // decodeData - decode encoded blocks.
 func decodeData(enBlocks [][]byte, dataBlocks, parityBlocks int) error {
+	release := globalErasureWorkers.acquireCodec()
+	defer release()
+
 	// Initialized reedsolomon.
 	rs, err := reedsolomon.New(dataBlocks, parityBlocks)
 	if err != nil {
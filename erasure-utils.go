@@ -18,33 +18,105 @@ package main
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"hash"
+	"hash/crc32"
 	"io"
 
 	"github.com/dchest/blake2b"
 	"github.com/klauspost/reedsolomon"
 )
 
+// errErasureLayout - returned for erasure info with a layout which
+// does not match the disks.
+var errErasureLayout = errors.New("Erasure layout does not match the number of disks")
+
+// checkErasureLayout - verifies data and parity blocks of erasure info
+// add up to the number of disks, each disk holding one block.
+func checkErasureLayout(eInfo erasureInfo, diskCount int) error {
+	if eInfo.DataBlocks < 1 || eInfo.ParityBlocks < 1 {
+		return errErasureLayout
+	}
+	if eInfo.DataBlocks+eInfo.ParityBlocks != diskCount || len(eInfo.Distribution) != diskCount {
+		return errErasureLayout
+	}
+	return nil
+}
+
+// Bitrot hash algorithms.
+const (
+	bitrotAlgorithmBlake2b = "blake2b"
+	bitrotAlgorithmSHA256  = "sha256"
+	bitrotAlgorithmCRC32C  = "crc32c"
+
+	// Selects the fastest algorithm for the CPU.
+	bitrotAlgorithmAuto = "auto"
+
+	// Algorithm of new writes unless configured otherwise.
+	defaultBitrotAlgorithm = bitrotAlgorithmBlake2b
+)
+
+// Table of crc32c, computed by SSE4.2 instructions where available.
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// bitrotAlgorithms - registry of bitrot hash algorithms by name, the
+// name is saved with every checksum in `xl.json`.
+var bitrotAlgorithms = map[string]func() hash.Hash{
+	bitrotAlgorithmBlake2b: blake2b.New512,
+	bitrotAlgorithmSHA256:  sha256.New,
+	bitrotAlgorithmCRC32C:  func() hash.Hash { return crc32.New(crc32cTable) },
+	// Add new hashes here.
+}
+
+// isValidBitrotAlgorithm - returns true if the algorithm is registered
+// or selected by the CPU.
+func isValidBitrotAlgorithm(algo string) bool {
+	if algo == bitrotAlgorithmAuto {
+		return true
+	}
+	_, ok := bitrotAlgorithms[algo]
+	return ok
+}
+
+// getBitrotAlgorithm - returns bitrot hash algorithm of new writes.
+func getBitrotAlgorithm() string {
+	if serverConfig == nil {
+		return defaultBitrotAlgorithm
+	}
+	algo := serverConfig.GetBitrotAlgorithm()
+	if algo == bitrotAlgorithmAuto {
+		return autoBitrotAlgorithm
+	}
+	return algo
+}
+
 // newHashWriters - inititialize a slice of hashes for the disk count.
-func newHashWriters(diskCount int) []hash.Hash {
+func newHashWriters(diskCount int, algo string) []hash.Hash {
 	hashWriters := make([]hash.Hash, diskCount)
 	for index := range hashWriters {
-		hashWriters[index] = newHash("blake2b")
+		hashWriters[index] = newHash(algo)
 	}
 	return hashWriters
 }
 
 // newHash - gives you a newly allocated hash depending on the input algorithm.
 func newHash(algo string) hash.Hash {
-	switch algo {
-	case "blake2b":
-		return blake2b.New512()
-	// Add new hashes here.
-	default:
+	newFn, ok := bitrotAlgorithms[algo]
+	if !ok {
 		// Default to blake2b.
-		return blake2b.New512()
+		newFn = blake2b.New512
 	}
+	return newFn()
+}
+
+// blockHash - returns hex encoded checksum of a block.
+func blockHash(algo string, block []byte) string {
+	hashWriter := newHash(algo)
+	hashWriter.Write(block)
+	return hex.EncodeToString(hashWriter.Sum(nil))
 }
 
 // hashSum calculates the hash of the entire path and returns.
@@ -147,50 +219,66 @@ func getEncodedBlockLen(inputLen int64, dataBlocks int) (curEncBlockSize int64)
 	return curEncBlockSize
 }
 
-// copyN - copies from disk, volume, path to input writer until length
-// is reached at volume, path or an error occurs. A success copyN returns
-// err == nil, not err == EOF. Additionally offset can be provided to start
-// the read at. copyN returns io.EOF if there aren't enough data to be read.
-func copyN(writer io.Writer, disk StorageAPI, volume string, path string, offset int64, length int64) (err error) {
-	// Use 128KiB staging buffer to read up to length.
-	buf := make([]byte, readSizeV1)
+// Smallest erasure block size, every block adds a checksum per disk to
+// `xl.json`.
+const minBlockSize = 64 * 1024 // 64KiB.
 
-	// Read into writer until length.
-	for length > 0 {
-		curLength := int64(readSizeV1)
-		if length < readSizeV1 {
-			curLength = length
-		}
-		nr, er := disk.ReadFile(volume, path, offset, buf[:curLength])
-		if nr > 0 {
-			nw, ew := writer.Write(buf[0:nr])
-			if nw > 0 {
-				// Decrement the length.
-				length -= int64(nw)
-
-				// Progress the offset.
-				offset += int64(nw)
-			}
-			if ew != nil {
-				err = ew
-				break
-			}
-			if nr != int64(nw) {
-				err = io.ErrShortWrite
-				break
-			}
+// blockSizeClass - erasure block size of objects up to MaxObjectSize
+// bytes.
+type blockSizeClass struct {
+	MaxObjectSize int64 `json:"maxObjectSize"`
+	BlockSize     int64 `json:"blockSize"`
+}
+
+// validateBlockSizeClasses - verifies block sizes are between 64KiB
+// and 10MiB and classes are ordered by increasing object size.
+func validateBlockSizeClasses(classes []blockSizeClass) error {
+	for index, class := range classes {
+		if class.BlockSize < minBlockSize || class.BlockSize > blockSizeV1 {
+			return fmt.Errorf("Block size %d has to be between %d and %d.", class.BlockSize, minBlockSize, blockSizeV1)
 		}
-		if er == io.EOF || er == io.ErrUnexpectedEOF {
-			break
+		if class.MaxObjectSize <= 0 || (index > 0 && class.MaxObjectSize <= classes[index-1].MaxObjectSize) {
+			return errors.New("Block size classes have to be ordered by increasing maximum object size.")
 		}
-		if er != nil {
-			err = er
-			break
+	}
+	return nil
+}
+
+// getObjectBlockSize - returns erasure block size of new objects of
+// size bytes, objects of unknown size and objects larger than all
+// configured classes use 10MiB blocks.
+func getObjectBlockSize(size int64) int64 {
+	if serverConfig == nil || size < 0 {
+		return blockSizeV1
+	}
+	for _, class := range serverConfig.GetBlockSizeClasses() {
+		if size <= class.MaxObjectSize {
+			return class.BlockSize
 		}
 	}
+	return blockSizeV1
+}
 
-	// Success.
-	return err
+// readChunk - reads a chunk of a shard from disk at offset into buf,
+// up to 128KiB at a time. Shards shorter than the chunk fail with
+// io.ErrUnexpectedEOF.
+func readChunk(disk StorageAPI, volume string, path string, offset int64, buf []byte) error {
+	for len(buf) > 0 {
+		curLength := int64(readSizeV1)
+		if int64(len(buf)) < curLength {
+			curLength = int64(len(buf))
+		}
+		n, err := disk.ReadFile(volume, path, offset, buf[:curLength])
+		if err == io.EOF || (err == nil && n == 0) {
+			err = io.ErrUnexpectedEOF
+		}
+		if err != nil {
+			return err
+		}
+		buf = buf[n:]
+		offset += n
+	}
+	return nil
 }
 
 // copyBuffer - copies from disk, volume, path to input writer until either EOF
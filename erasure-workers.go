@@ -0,0 +1,153 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Concurrent shard reads and writes allowed per disk.
+const ioWorkersPerDisk = 4
+
+// workerPool - limits concurrent operations, keeping utilization
+// statistics.
+type workerPool struct {
+	ch       chan struct{}
+	busy     int64 // Workers currently in use.
+	acquired int64 // Total number of times a worker was acquired.
+	waitTime int64 // Total nanoseconds spent waiting for a worker.
+}
+
+// workerPoolStats - utilization statistics of a worker pool.
+type workerPoolStats struct {
+	Workers  int           `json:"workers"`
+	Busy     int64         `json:"busy"`
+	Acquired int64         `json:"acquired"`
+	WaitTime time.Duration `json:"waitTime"`
+}
+
+// newWorkerPool - initialize a pool of workers.
+func newWorkerPool(workers int) *workerPool {
+	return &workerPool{ch: make(chan struct{}, workers)}
+}
+
+// acquire - blocks until a worker is available.
+func (p *workerPool) acquire() {
+	start := time.Now()
+	p.ch <- struct{}{}
+	atomic.AddInt64(&p.waitTime, int64(time.Since(start)))
+	atomic.AddInt64(&p.acquired, 1)
+	atomic.AddInt64(&p.busy, 1)
+}
+
+// release - releases a worker.
+func (p *workerPool) release() {
+	atomic.AddInt64(&p.busy, -1)
+	<-p.ch
+}
+
+// stats - returns utilization statistics.
+func (p *workerPool) stats() workerPoolStats {
+	return workerPoolStats{
+		Workers:  cap(p.ch),
+		Busy:     atomic.LoadInt64(&p.busy),
+		Acquired: atomic.LoadInt64(&p.acquired),
+		WaitTime: time.Duration(atomic.LoadInt64(&p.waitTime)),
+	}
+}
+
+// erasureWorkers - limits concurrent erasure encode/decode and shard
+// reads/writes across all requests of the server.
+type erasureWorkers struct {
+	codec *workerPool
+	io    *workerPool
+	// CPUs encode/decode workers are pinned to, nil if not pinned.
+	codecCPUs *cpuSet
+}
+
+// erasureWorkersStats - utilization statistics of erasure workers.
+type erasureWorkersStats struct {
+	Codec workerPoolStats `json:"codec"`
+	IO    workerPoolStats `json:"io"`
+}
+
+// newErasureWorkers - sizes codec workers from available CPUs and
+// shard I/O workers from available CPUs and disk count, non-zero
+// overrides take precedence.
+func newErasureWorkers(diskCount, codecOverride, ioOverride int) *erasureWorkers {
+	codecWorkers := runtime.GOMAXPROCS(0)
+	if codecOverride > 0 {
+		codecWorkers = codecOverride
+	}
+	ioWorkers := diskCount * ioWorkersPerDisk
+	if ioWorkers < codecWorkers {
+		ioWorkers = codecWorkers
+	}
+	if ioOverride > 0 {
+		ioWorkers = ioOverride
+	}
+	return &erasureWorkers{
+		codec: newWorkerPool(codecWorkers),
+		io:    newWorkerPool(ioWorkers),
+	}
+}
+
+// Erasure workers, sized for the disks of the XL object layer.
+var globalErasureWorkers = newErasureWorkers(0, 0, 0)
+
+// initErasureWorkers - sizes erasure workers for the disk count.
+func initErasureWorkers(diskCount int) {
+	workers := newErasureWorkers(diskCount, globalErasureCodecWorkers, globalErasureIOWorkers)
+	workers.codecCPUs = globalErasureCPUs
+	globalErasureWorkers = workers
+}
+
+// acquireCodec - blocks until an encode/decode worker is available,
+// pinning the calling goroutine to the configured CPUs. Returned
+// function releases the worker.
+func (w *erasureWorkers) acquireCodec() (release func()) {
+	w.codec.acquire()
+	unpin, err := pinThread(w.codecCPUs)
+	if err != nil {
+		errorIf(err, "Unable to pin erasure worker to CPUs.")
+		unpin = func() {}
+	}
+	return func() {
+		unpin()
+		w.codec.release()
+	}
+}
+
+// acquireIO - blocks until a shard read/write worker is available.
+func (w *erasureWorkers) acquireIO() {
+	w.io.acquire()
+}
+
+// releaseIO - releases a shard read/write worker.
+func (w *erasureWorkers) releaseIO() {
+	w.io.release()
+}
+
+// stats - returns utilization statistics of all erasure workers.
+func (w *erasureWorkers) stats() erasureWorkersStats {
+	return erasureWorkersStats{
+		Codec: w.codec.stats(),
+		IO:    w.io.stats(),
+	}
+}
@@ -0,0 +1,70 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"runtime"
+	"testing"
+)
+
+// Tests sizing of erasure workers.
+func TestNewErasureWorkers(t *testing.T) {
+	cpus := runtime.GOMAXPROCS(0)
+	testCases := []struct {
+		diskCount     int
+		codecOverride int
+		ioOverride    int
+		expectedCodec int
+		expectedIO    int
+	}{
+		// Test case - 1.
+		// No disks, I/O workers fall back to CPU count.
+		{0, 0, 0, cpus, cpus},
+		// Test case - 2.
+		// Sized from disk count.
+		{1024, 0, 0, cpus, 1024 * ioWorkersPerDisk},
+		// Test case - 3.
+		// Overrides take precedence.
+		{16, 3, 5, 3, 5},
+	}
+	for i, testCase := range testCases {
+		workers := newErasureWorkers(testCase.diskCount, testCase.codecOverride, testCase.ioOverride)
+		if workers.codec.stats().Workers != testCase.expectedCodec {
+			t.Errorf("Test %d: Expected %d codec workers, got %d", i+1, testCase.expectedCodec, workers.codec.stats().Workers)
+		}
+		if workers.io.stats().Workers != testCase.expectedIO {
+			t.Errorf("Test %d: Expected %d I/O workers, got %d", i+1, testCase.expectedIO, workers.io.stats().Workers)
+		}
+	}
+}
+
+// Tests utilization statistics of worker pools.
+func TestWorkerPoolStats(t *testing.T) {
+	pool := newWorkerPool(2)
+	pool.acquire()
+	pool.acquire()
+	if stats := pool.stats(); stats.Busy != 2 || stats.Acquired != 2 {
+		t.Fatalf("Expected 2 busy and 2 acquired workers, got %d busy and %d acquired", stats.Busy, stats.Acquired)
+	}
+	pool.release()
+	pool.release()
+	pool.acquire()
+	if stats := pool.stats(); stats.Busy != 1 || stats.Acquired != 3 {
+		t.Fatalf("Expected 1 busy and 3 acquired workers, got %d busy and %d acquired", stats.Busy, stats.Acquired)
+	}
+	pool.release()
+}
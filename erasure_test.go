@@ -182,3 +182,84 @@ func TestErasureDecode(t *testing.T) {
 		}
 	}
 }
+
+// Tests erasure layouts are validated against the number of disks.
+func TestCheckErasureLayout(t *testing.T) {
+	testCases := []struct {
+		eInfo       erasureInfo
+		diskCount   int
+		expectedErr error
+	}{
+		// Test case - 1.
+		{erasureInfo{DataBlocks: 6, ParityBlocks: 2, Distribution: []int{1, 2, 3, 4, 5, 6, 7, 8}}, 8, nil},
+		// Test case - 2.
+		// Layout of fewer disks.
+		{erasureInfo{DataBlocks: 6, ParityBlocks: 2, Distribution: []int{1, 2, 3, 4, 5, 6, 7, 8}}, 16, errErasureLayout},
+		// Test case - 3.
+		// Data and parity blocks do not match distribution.
+		{erasureInfo{DataBlocks: 4, ParityBlocks: 2, Distribution: []int{1, 2, 3, 4, 5, 6, 7, 8}}, 8, errErasureLayout},
+		// Test case - 4.
+		{erasureInfo{DataBlocks: 8, ParityBlocks: 0, Distribution: []int{1, 2, 3, 4, 5, 6, 7, 8}}, 8, errErasureLayout},
+	}
+	for i, testCase := range testCases {
+		if err := checkErasureLayout(testCase.eInfo, testCase.diskCount); err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+	}
+}
+
+// Tests block size classes are validated.
+func TestValidateBlockSizeClasses(t *testing.T) {
+	testCases := []struct {
+		classes    []blockSizeClass
+		shouldPass bool
+	}{
+		// Test case - 1.
+		{nil, true},
+		// Test case - 2.
+		{[]blockSizeClass{{1024 * 1024, minBlockSize}, {16 * 1024 * 1024, blockSizeV1}}, true},
+		// Test case - 3.
+		// Block size smaller than 64KiB.
+		{[]blockSizeClass{{1024 * 1024, 4096}}, false},
+		// Test case - 4.
+		// Block size larger than 10MiB.
+		{[]blockSizeClass{{1024 * 1024 * 1024, 2 * blockSizeV1}}, false},
+		// Test case - 5.
+		// Classes of the same object size.
+		{[]blockSizeClass{{1024 * 1024, minBlockSize}, {1024 * 1024, blockSizeV1}}, false},
+		// Test case - 6.
+		{[]blockSizeClass{{0, minBlockSize}}, false},
+	}
+	for i, testCase := range testCases {
+		err := validateBlockSizeClasses(testCase.classes)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+	}
+}
+
+// Tests chunks are verified with the algorithm of their checksums.
+func TestIsValidChunk(t *testing.T) {
+	chunk := []byte("chunk")
+	for i, algo := range []string{bitrotAlgorithmBlake2b, bitrotAlgorithmSHA256, bitrotAlgorithmCRC32C} {
+		if !isValidBitrotAlgorithm(algo) {
+			t.Fatalf("Test %d: Expected algorithm %s to be registered", i+1, algo)
+		}
+		checkSum := checkSumInfo{Algorithm: algo, Blocks: []string{blockHash(algo, chunk)}}
+		if !isValidChunk(chunk, checkSum, 0) {
+			t.Errorf("Test %d: Expected chunk to be valid", i+1)
+		}
+		if isValidChunk([]byte("chunK"), checkSum, 0) {
+			t.Errorf("Test %d: Expected corrupted chunk to be invalid", i+1)
+		}
+		if isValidChunk(chunk, checkSum, 1) {
+			t.Errorf("Test %d: Expected chunk without checksum to be invalid", i+1)
+		}
+	}
+	if isValidBitrotAlgorithm("md5") {
+		t.Error("Expected md5 to be unsupported")
+	}
+}
@@ -0,0 +1,178 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Set on responses of requests proxied to a peer, with the
+	// address of the peer.
+	failoverHeader = "X-Minio-Failover"
+
+	// Interval at which health of peers is polled.
+	failoverInterval = 10 * time.Second
+)
+
+// quorumStatus - whether reachable disks have read and write quorum.
+type quorumStatus struct {
+	read  bool
+	write bool
+}
+
+// getQuorumStatus - returns quorum status of the disks of the object
+// layer as last seen by their health pings. Layers without quorum,
+// like FS, always have quorum.
+func getQuorumStatus(objAPI ObjectLayer) quorumStatus {
+	switch l := objAPI.(type) {
+	case xlObjects:
+		var onlineDisks int
+		for _, state := range l.diskMonitor.states() {
+			if state.Online {
+				onlineDisks++
+			}
+		}
+		return quorumStatus{
+			read:  onlineDisks >= l.readQuorum,
+			write: onlineDisks >= l.writeQuorum,
+		}
+	case setsObjects:
+		status := quorumStatus{true, true}
+		for _, set := range l.sets {
+			setStatus := getQuorumStatus(set)
+			status.read = status.read && setStatus.read
+			status.write = status.write && setStatus.write
+		}
+		return status
+	case tierObjects:
+		hot, cold := getQuorumStatus(l.hot), getQuorumStatus(l.cold)
+		return quorumStatus{hot.read && cold.read, hot.write && cold.write}
+	case indexedObjects:
+		return getQuorumStatus(l.ObjectLayer)
+	case retainedObjects:
+		return getQuorumStatus(l.ObjectLayer)
+	}
+	return quorumStatus{true, true}
+}
+
+// failoverMonitor - keeps last polled health of peers, picking a
+// healthy peer while disks of this node lack quorum.
+type failoverMonitor struct {
+	objAPI ObjectLayer
+	mutex  *sync.RWMutex
+	peers  map[string]quorumStatus
+}
+
+// Failover of this node, nil unless enabled in distributed setups.
+var globalFailover *failoverMonitor
+
+// newFailoverMonitor - initialize failover of the object layer, peers
+// are taken for unhealthy until polled.
+func newFailoverMonitor(objAPI ObjectLayer) *failoverMonitor {
+	return &failoverMonitor{
+		objAPI: objAPI,
+		mutex:  &sync.RWMutex{},
+		peers:  make(map[string]quorumStatus),
+	}
+}
+
+// update - polls health of all peers once, peers which cannot be
+// reached are unhealthy.
+func (f *failoverMonitor) update() {
+	token, err := newPeerToken(serverConfig.GetCredential())
+	if err != nil {
+		errorIf(err, "Unable to generate peer token.")
+		return
+	}
+	peers := make(map[string]quorumStatus)
+	for _, peer := range globalPeers {
+		reply := HealthPeerReply{}
+		if err = peer.Call("Peer.HealthHandler", &PeerAuthArgs{Token: token}, &reply); err != nil {
+			peers[peer.addr] = quorumStatus{}
+			continue
+		}
+		peers[peer.addr] = quorumStatus{reply.ReadQuorum, reply.WriteQuorum}
+	}
+	f.mutex.Lock()
+	f.peers = peers
+	f.mutex.Unlock()
+}
+
+// run - polls health of peers periodically.
+func (f *failoverMonitor) run(interval time.Duration) {
+	for {
+		f.update()
+		time.Sleep(interval)
+	}
+}
+
+// pickPeer - returns address of the peer to proxy the request to, empty
+// if this node has quorum for it or no peer has.
+func (f *failoverMonitor) pickPeer(isWrite bool) string {
+	hasQuorum := func(status quorumStatus) bool {
+		if isWrite {
+			return status.write
+		}
+		return status.read
+	}
+	if hasQuorum(getQuorumStatus(f.objAPI)) {
+		return ""
+	}
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+	// Peers are tried in the order of the disks, all nodes fail
+	// over to the same peer.
+	for _, peer := range globalPeers {
+		if hasQuorum(f.peers[peer.addr]) {
+			return peer.addr
+		}
+	}
+	return ""
+}
+
+// failoverHandler - proxies requests to a healthy peer while disks of
+// this node lack quorum.
+type failoverHandler struct {
+	handler http.Handler
+}
+
+// setFailoverHandler to serve requests on a healthy peer while disks
+// of this node lack quorum, only if failover is enabled.
+func setFailoverHandler(h http.Handler) http.Handler {
+	return failoverHandler{h}
+}
+
+func (h failoverHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Rpc, admin and browser requests are always served by the node
+	// they were sent to.
+	if globalFailover == nil || isProxiedRequest(r) || strings.HasPrefix(r.URL.Path, reservedBucket+"/") {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	isWrite := r.Method != "GET" && r.Method != "HEAD"
+	peerAddr := globalFailover.pickPeer(isWrite)
+	if peerAddr == "" {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	w.Header().Set(failoverHeader, peerAddr)
+	proxyToPeer(w, r, peerAddr)
+}
@@ -0,0 +1,109 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests requests are proxied to a healthy peer only while disks of
+// this node lack quorum for them.
+func TestFailoverHandler(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("peer " + r.Header.Get(peerProxyHeader)))
+	}))
+	defer peer.Close()
+	peerAddr := peer.Listener.Addr().String()
+
+	defer func(peers []*peerClient) { globalPeers = peers }(globalPeers)
+	globalPeers = []*peerClient{newPeerClient(peerAddr)}
+	defer func() { globalFailover = nil }()
+	globalFailover = newFailoverMonitor(obj)
+	defer func(nodeName string) { globalNodeName = nodeName }(globalNodeName)
+	globalNodeName = "node1"
+	handler := setFailoverHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("local"))
+	}))
+
+	// Disks offline beyond write quorum, within read quorum.
+	for index := 0; index < len(xl.storageDisks)-xl.writeQuorum+1; index++ {
+		d := xl.diskMonitor.disks[index]
+		d.setOffline(d.getDisk())
+	}
+	if status := getQuorumStatus(obj); !status.read || status.write {
+		t.Fatalf("Expected read quorum only, got %+v", status)
+	}
+
+	testCases := []struct {
+		method       string
+		path         string
+		header       string
+		peerHealthy  bool
+		expectedBody string
+	}{
+		// Test case - 1.
+		// Reads have quorum.
+		{"GET", "/bucket/object", "", true, "local"},
+		// Test case - 2.
+		{"PUT", "/bucket/object", "", true, "peer node1"},
+		// Test case - 3.
+		// No healthy peer.
+		{"PUT", "/bucket/object", "", false, "local"},
+		// Test case - 4.
+		// Proxied by a peer already.
+		{"PUT", "/bucket/object", "node2", true, "local"},
+		// Test case - 5.
+		// Admin requests are never proxied.
+		{"PUT", "/minio/admin/heal-throttle", "", true, "local"},
+	}
+	for i, testCase := range testCases {
+		globalFailover.peers[peerAddr] = quorumStatus{testCase.peerHealthy, testCase.peerHealthy}
+		req, err := http.NewRequest(testCase.method, "http://localhost"+testCase.path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if testCase.header != "" {
+			req.Header.Set(peerProxyHeader, testCase.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		body, _ := ioutil.ReadAll(rec.Body)
+		if string(body) != testCase.expectedBody {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expectedBody, body)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// faultRule - injects an error and/or latency into requests matching
+// Method, Bucket and Object. Bucket and Object are shell patterns as
+// understood by path.Match, empty values match everything.
+type faultRule struct {
+	Method string `json:"method"`
+	Bucket string `json:"bucket"`
+	Object string `json:"object"`
+
+	// S3 error code to respond with, empty injects latency only.
+	ErrorCode string `json:"errorCode"`
+	// HTTP status code, required if ErrorCode is not a known error.
+	StatusCode int `json:"statusCode"`
+	// Latency in milliseconds added before responding.
+	LatencyMs int `json:"latencyMs"`
+	// Number of requests to inject the fault into, 0 is unlimited.
+	Count int `json:"count"`
+}
+
+// faultInjector - list of active fault rules, first matching rule wins.
+type faultInjector struct {
+	mutex *sync.Mutex
+	rules []faultRule
+}
+
+// Fault injector is only consulted if server was started with the
+// hidden '--debug-faults' flag.
+var globalFaultInjector = &faultInjector{mutex: &sync.Mutex{}}
+
+// getAPIErrorByCode - returns known API error for the S3 error code.
+func getAPIErrorByCode(code string) (APIError, bool) {
+	for _, apiErr := range errorCodeResponse {
+		if apiErr.Code == code {
+			return apiErr, true
+		}
+	}
+	return APIError{}, false
+}
+
+// validateFaultRule - validates pattern syntax and error code.
+func validateFaultRule(rule faultRule) error {
+	for _, pattern := range []string{rule.Bucket, rule.Object} {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return err
+		}
+	}
+	if rule.LatencyMs < 0 || rule.Count < 0 {
+		return errors.New("Fault rule latency and count cannot be negative.")
+	}
+	if rule.ErrorCode == "" {
+		if rule.LatencyMs == 0 {
+			return errors.New("Fault rule must inject an error or latency.")
+		}
+		return nil
+	}
+	if _, ok := getAPIErrorByCode(rule.ErrorCode); !ok && rule.StatusCode < 400 {
+		return errors.New("Fault rule with unknown error code requires an error status code.")
+	}
+	return nil
+}
+
+// SetRules - replaces all active fault rules.
+func (f *faultInjector) SetRules(rules []faultRule) error {
+	for _, rule := range rules {
+		if err := validateFaultRule(rule); err != nil {
+			return err
+		}
+	}
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.rules = rules
+	return nil
+}
+
+// GetRules - returns all active fault rules.
+func (f *faultInjector) GetRules() []faultRule {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	return append([]faultRule(nil), f.rules...)
+}
+
+// match - returns first rule matching the request, rules with a
+// count are consumed and removed once exhausted.
+func (f *faultInjector) match(method, bucket, object string) (faultRule, bool) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	for i, rule := range f.rules {
+		if rule.Method != "" && rule.Method != method {
+			continue
+		}
+		if ok, _ := path.Match(rule.Bucket, bucket); rule.Bucket != "" && !ok {
+			continue
+		}
+		if ok, _ := path.Match(rule.Object, object); rule.Object != "" && !ok {
+			continue
+		}
+		if rule.Count > 0 {
+			f.rules[i].Count--
+			if f.rules[i].Count == 0 {
+				f.rules = append(f.rules[:i], f.rules[i+1:]...)
+			}
+		}
+		return rule, true
+	}
+	return faultRule{}, false
+}
+
+// faultInjectionHandler - injects configured faults into API requests.
+type faultInjectionHandler struct {
+	handler http.Handler
+}
+
+// setFaultInjectionHandler to inject faults configured via admin API.
+func setFaultInjectionHandler(h http.Handler) http.Handler {
+	return faultInjectionHandler{h}
+}
+
+func (h faultInjectionHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Faults are never injected into internal APIs.
+	if !globalDebugFaults || strings.HasPrefix(r.URL.Path, reservedBucket+"/") {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	bucket, object := urlPath2BucketObjectName(r.URL)
+	rule, ok := globalFaultInjector.match(r.Method, bucket, object)
+	if !ok {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	if rule.LatencyMs > 0 {
+		time.Sleep(time.Duration(rule.LatencyMs) * time.Millisecond)
+	}
+	if rule.ErrorCode == "" {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	apiErr, ok := getAPIErrorByCode(rule.ErrorCode)
+	if !ok {
+		apiErr = APIError{
+			Code:        rule.ErrorCode,
+			Description: "Injected fault.",
+		}
+	}
+	if rule.StatusCode != 0 {
+		apiErr.HTTPStatusCode = rule.StatusCode
+	}
+	setCommonHeaders(w)
+	w.WriteHeader(apiErr.HTTPStatusCode)
+	writeErrorResponseNoHeader(w, r, apiErr, r.URL.Path)
+}
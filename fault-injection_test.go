@@ -0,0 +1,104 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"sync"
+	"testing"
+)
+
+// Tests validate fault rule validation.
+func TestValidateFaultRule(t *testing.T) {
+	testCases := []struct {
+		rule       faultRule
+		shouldPass bool
+	}{
+		// Test case - 1.
+		// Known error code.
+		{faultRule{Bucket: "bucket", ErrorCode: "InternalError"}, true},
+		// Test case - 2.
+		// Unknown error code with status code.
+		{faultRule{ErrorCode: "ServiceUnavailable", StatusCode: 503}, true},
+		// Test case - 3.
+		// Unknown error code without status code.
+		{faultRule{ErrorCode: "ServiceUnavailable"}, false},
+		// Test case - 4.
+		// Latency only.
+		{faultRule{Object: "*.txt", LatencyMs: 100}, true},
+		// Test case - 5.
+		// Neither error nor latency.
+		{faultRule{Bucket: "bucket"}, false},
+		// Test case - 6.
+		// Malformed pattern.
+		{faultRule{Bucket: "[", ErrorCode: "InternalError"}, false},
+		// Test case - 7.
+		// Negative count.
+		{faultRule{ErrorCode: "InternalError", Count: -1}, false},
+	}
+	for i, testCase := range testCases {
+		err := validateFaultRule(testCase.rule)
+		if testCase.shouldPass && err != nil {
+			t.Errorf("Test %d: Expected to pass, but failed with %s", i+1, err)
+		}
+		if !testCase.shouldPass && err == nil {
+			t.Errorf("Test %d: Expected to fail, but passed", i+1)
+		}
+	}
+}
+
+// Tests validate matching and consumption of fault rules.
+func TestFaultInjectorMatch(t *testing.T) {
+	injector := &faultInjector{mutex: &sync.Mutex{}}
+	err := injector.SetRules([]faultRule{
+		{Method: "PUT", Bucket: "bucket", Object: "*.txt", ErrorCode: "InternalError", Count: 2},
+		{Bucket: "bucket*", ErrorCode: "SlowDown", StatusCode: 503},
+	})
+	if err != nil {
+		t.Fatalf("Unable to set fault rules. %s", err)
+	}
+	testCases := []struct {
+		method       string
+		bucket       string
+		object       string
+		expectedCode string
+	}{
+		// Test case - 1.
+		// First rule matches.
+		{"PUT", "bucket", "a.txt", "InternalError"},
+		// Test case - 2.
+		// Method mismatch falls through to second rule.
+		{"GET", "bucket", "a.txt", "SlowDown"},
+		// Test case - 3.
+		// First rule matches for the last time.
+		{"PUT", "bucket", "b.txt", "InternalError"},
+		// Test case - 4.
+		// First rule is exhausted.
+		{"PUT", "bucket", "c.txt", "SlowDown"},
+		// Test case - 5.
+		// No rule matches.
+		{"GET", "other", "a.txt", ""},
+	}
+	for i, testCase := range testCases {
+		rule, ok := injector.match(testCase.method, testCase.bucket, testCase.object)
+		if ok != (testCase.expectedCode != "") || rule.ErrorCode != testCase.expectedCode {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expectedCode, rule.ErrorCode)
+		}
+	}
+	if len(injector.GetRules()) != 1 {
+		t.Errorf("Expected exhausted rule to be removed, got %v", injector.GetRules())
+	}
+}
@@ -36,6 +36,12 @@ type xlFormat struct {
 	// JBOD field carries the input disk order generated the first
 	// time when fresh disks were supplied.
 	JBOD []string `json:"jbod"`
+	// Set field carries the uuid of the erasure set of this disk,
+	// XL format version '2' only.
+	Set string `json:"set,omitempty"`
+	// Sets field carries the uuids of all erasure sets of the
+	// deployment in their order, XL format version '2' only.
+	Sets []string `json:"sets,omitempty"`
 }
 
 // formatConfigV1 - structure holds format config version '1'.
@@ -471,6 +477,8 @@ func healFormatXL(storageDisks []StorageAPI) error {
 					Version: referenceConfig.XL.Version,
 					Disk:    newJBOD[index],
 					JBOD:    newJBOD,
+					Set:     referenceConfig.XL.Set,
+					Sets:    referenceConfig.XL.Sets,
 				},
 			}
 			newFormatConfigs[index] = config
@@ -542,7 +550,7 @@ func checkFormatXL(formatConfigs []*formatConfigV1) error {
 		if formatXL.Format != "xl" {
 			return fmt.Errorf("Unsupported backend format [%s] found.", formatXL.Format)
 		}
-		if formatXL.XL.Version != "1" {
+		if formatXL.XL.Version != "1" && formatXL.XL.Version != xlFormatVersionSets {
 			return fmt.Errorf("Unsupported XL backend format found [%s]", formatXL.XL.Version)
 		}
 		if len(formatConfigs) != len(formatXL.XL.JBOD) {
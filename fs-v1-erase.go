@@ -0,0 +1,35 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+/// Bucket erase operations
+
+// EraseBucket - erase a bucket along with all of its objects, multipart
+// uploads and snapshots.
+func (fs fsObjects) EraseBucket(bucket string, overwrite bool) error {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if !isBucketExist(fs.storage, bucket) {
+		return BucketNotFound{Bucket: bucket}
+	}
+	if err := eraseBucketContents(fs.storage, bucket, overwrite); err != nil {
+		return toObjectErr(err, bucket)
+	}
+	return fs.DeleteBucket(bucket)
+}
@@ -9,8 +9,20 @@ import (
 const (
 	fsMetaJSONFile   = "fs.json"
 	fsFormatJSONFile = "format.json"
+
+	// Metadata of objects is saved in `fs.json` under
+	// '.minio.sys/buckets/bucket/object/'.
+	fsObjectMetaPrefix = "buckets"
 )
 
+// Internal metadata keys FS saves alongside objects, other metadata
+// is not saved.
+var fsObjectMetaKeys = []string{
+	objectRetainUntilKey,
+	objectLegalHoldKey,
+	objectExpiresKey,
+}
+
 // A fsMetaV1 represents a metadata header mapping keys to sets of values.
 type fsMetaV1 struct {
 	Version string `json:"version"`
@@ -18,7 +30,8 @@ type fsMetaV1 struct {
 	Minio   struct {
 		Release string `json:"release"`
 	} `json:"minio"`
-	Parts []objectPartInfo `json:"parts,omitempty"`
+	Parts []objectPartInfo  `json:"parts,omitempty"`
+	Meta  map[string]string `json:"meta,omitempty"`
 }
 
 // ObjectPartIndex - returns the index of matching object part number.
@@ -82,6 +95,76 @@ func newFSMetaV1() (fsMeta fsMetaV1) {
 	return fsMeta
 }
 
+// getFSObjectMeta - returns the keys of metadata saved by FS, nil if
+// there are none.
+func getFSObjectMeta(metadata map[string]string) map[string]string {
+	var meta map[string]string
+	for _, key := range fsObjectMetaKeys {
+		if value, ok := metadata[key]; ok {
+			if meta == nil {
+				meta = make(map[string]string)
+			}
+			meta[key] = value
+		}
+	}
+	return meta
+}
+
+// getFSObjectMetaPath - returns path of the metadata of an object in
+// minioMetaBucket.
+func getFSObjectMetaPath(bucket, object string) string {
+	return path.Join(fsObjectMetaPrefix, bucket, object, fsMetaJSONFile)
+}
+
+// readObjectMeta - returns saved metadata of an object, nil if none is
+// saved.
+func (fs fsObjects) readObjectMeta(bucket, object string) (map[string]string, error) {
+	buf, err := fs.storage.ReadAll(minioMetaBucket, getFSObjectMetaPath(bucket, object))
+	if err != nil {
+		if err == errFileNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var fsMeta fsMetaV1
+	if err = json.Unmarshal(buf, &fsMeta); err != nil {
+		return nil, err
+	}
+	return fsMeta.Meta, nil
+}
+
+// writeObjectMeta - replaces saved metadata of an object with the keys
+// of metadata saved by FS, removes it if there are none. Callers hold
+// the write lock of the object.
+func (fs fsObjects) writeObjectMeta(bucket, object string, metadata map[string]string) error {
+	meta := getFSObjectMeta(metadata)
+	if meta == nil {
+		return fs.deleteObjectMeta(bucket, object)
+	}
+	fsMeta := newFSMetaV1()
+	fsMeta.Meta = meta
+	tempPath := path.Join(tmpMetaPrefix, getUUID())
+	if err := fs.writeFSMetadata(minioMetaBucket, tempPath, fsMeta); err != nil {
+		return err
+	}
+	// Rename replaces the previous `fs.json` at once.
+	err := fs.storage.RenameFile(minioMetaBucket, path.Join(tempPath, fsMetaJSONFile), minioMetaBucket, getFSObjectMetaPath(bucket, object))
+	if err != nil {
+		fs.storage.DeleteFile(minioMetaBucket, path.Join(tempPath, fsMetaJSONFile))
+		return err
+	}
+	return nil
+}
+
+// deleteObjectMeta - removes saved metadata of an object, if any.
+func (fs fsObjects) deleteObjectMeta(bucket, object string) error {
+	err := fs.storage.DeleteFile(minioMetaBucket, getFSObjectMetaPath(bucket, object))
+	if err != nil && err != errFileNotFound {
+		return err
+	}
+	return nil
+}
+
 // newFSFormatV1 - initializes new formatConfigV1 with FS format info.
 func newFSFormatV1() (format formatConfigV1) {
 	return formatConfigV1{
@@ -55,6 +55,18 @@ func (fs fsObjects) isBucketExist(bucket string) bool {
 	return true
 }
 
+// isObject - returns true if the object exists.
+func (fs fsObjects) isObject(bucket, object string) bool {
+	_, err := fs.storage.StatFile(bucket, object)
+	if err != nil {
+		if err != errFileNotFound {
+			errorIf(err, "Stat failed on object %s/%s.", bucket, object)
+		}
+		return false
+	}
+	return true
+}
+
 // isUploadIDExists - verify if a given uploadID exists and is valid.
 func (fs fsObjects) isUploadIDExists(bucket, object, uploadID string) bool {
 	uploadIDPath := path.Join(mpartMetaPrefix, bucket, object, uploadID)
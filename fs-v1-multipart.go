@@ -203,7 +203,7 @@ func (fs fsObjects) ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMark
 // request, returns back a unique upload id.
 //
 // Internally this function creates 'uploads.json' associated for the
-// incoming object at '.minio/multipart/bucket/object/uploads.json' on
+// incoming object at '.minio.sys/multipart/bucket/object/uploads.json' on
 // all the disks. `uploads.json` carries metadata regarding on going
 // multipart operation on the object.
 func (fs fsObjects) newMultipartUpload(bucket string, object string, meta map[string]string) (uploadID string, err error) {
@@ -214,8 +214,9 @@ func (fs fsObjects) newMultipartUpload(bucket string, object string, meta map[st
 
 	// Initialize `fs.json` values.
 	fsMeta := newFSMetaV1()
+	fsMeta.Meta = getFSObjectMeta(meta)
 
-	// This lock needs to be held for any changes to the directory contents of ".minio/multipart/object/"
+	// This lock needs to be held for any changes to the directory contents of ".minio.sys/multipart/object/"
 	nsMutex.Lock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, object))
 	defer nsMutex.Unlock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, object))
 
@@ -247,7 +248,16 @@ func (fs fsObjects) newMultipartUpload(bucket string, object string, meta map[st
 //
 // Implements S3 compatible initiate multipart API.
 func (fs fsObjects) NewMultipartUpload(bucket, object string, meta map[string]string) (string, error) {
-	meta = make(map[string]string) // Reset the meta value, we are not going to save headers for fs.
+	// User metadata is not saved, client side encrypted objects
+	// would lose their envelope.
+	if hasCSEMetadata(meta) {
+		return "", CSEMetadataNotSupported{}
+	}
+	// Tags would be lost the same way.
+	if hasObjectTags(meta) {
+		return "", TaggingNotSupported{}
+	}
+	meta = getFSObjectMeta(meta) // Reset the meta value, we are not going to save headers for fs.
 	// Verify if bucket name is valid.
 	if !IsValidBucketName(bucket) {
 		return "", BucketNameInvalid{Bucket: bucket}
@@ -265,8 +275,8 @@ func (fs fsObjects) NewMultipartUpload(bucket, object string, meta map[string]st
 
 // PutObjectPart - reads incoming data until EOF for the part file on
 // an ongoing multipart transaction. Internally incoming data is
-// written to '.minio/tmp' location and safely renamed to
-// '.minio/multipart' for reach parts.
+// written to '.minio.sys/tmp' location and safely renamed to
+// '.minio.sys/multipart' for reach parts.
 func (fs fsObjects) PutObjectPart(bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (string, error) {
 	// Verify if bucket is valid.
 	if !IsValidBucketName(bucket) {
@@ -362,9 +372,15 @@ func (fs fsObjects) PutObjectPart(bucket, object, uploadID string, partID int, s
 	return newMD5Hex, nil
 }
 
+// CopyObjectPart - uploads a part from a range of the source object,
+// the range is copied.
+func (fs fsObjects) CopyObjectPart(srcBucket, srcObject, bucket, object, uploadID string, partID int, startOffset, length int64) (string, error) {
+	return copyObjectPartData(fs, srcBucket, srcObject, fs, bucket, object, uploadID, partID, startOffset, length)
+}
+
 // listObjectParts - wrapper scanning through
-// '.minio/multipart/bucket/object/UPLOADID'. Lists all the parts
-// saved inside '.minio/multipart/bucket/object/UPLOADID'.
+// '.minio.sys/multipart/bucket/object/UPLOADID'. Lists all the parts
+// saved inside '.minio.sys/multipart/bucket/object/UPLOADID'.
 func (fs fsObjects) listObjectParts(bucket, object, uploadID string, partNumberMarker, maxParts int) (ListPartsInfo, error) {
 	result := ListPartsInfo{}
 
@@ -499,8 +515,9 @@ func (fs fsObjects) CompleteMultipartUpload(bucket string, object string, upload
 		if partIdx == -1 {
 			return "", InvalidPart{}
 		}
+		// Part ETag should match the ETag generated upon upload.
 		if fsMeta.Parts[partIdx].ETag != part.ETag {
-			return "", BadDigest{}
+			return "", InvalidPart{}
 		}
 		// All parts except the last part has to be atleast 5MB.
 		if (i < len(parts)-1) && !isMinAllowedPartSize(fsMeta.Parts[partIdx].Size) {
@@ -511,6 +528,8 @@ func (fs fsObjects) CompleteMultipartUpload(bucket string, object string, upload
 		multipartPartFile := path.Join(mpartMetaPrefix, bucket, object, uploadID, partSuffix)
 		offset := int64(0)
 		totalLeft := fsMeta.Parts[partIdx].Size
+		// Verify part content against its ETag while stitching.
+		md5Writer := md5.New()
 		for totalLeft > 0 {
 			curLeft := int64(readSizeV1)
 			if totalLeft < readSizeV1 {
@@ -519,6 +538,7 @@ func (fs fsObjects) CompleteMultipartUpload(bucket string, object string, upload
 			var n int64
 			n, err = fs.storage.ReadFile(minioMetaBucket, multipartPartFile, offset, buf[:curLeft])
 			if n > 0 {
+				md5Writer.Write(buf[:n])
 				if err = fs.storage.AppendFile(minioMetaBucket, tempObj, buf[:n]); err != nil {
 					return "", toObjectErr(err, minioMetaBucket, tempObj)
 				}
@@ -535,6 +555,27 @@ func (fs fsObjects) CompleteMultipartUpload(bucket string, object string, upload
 			offset += n
 			totalLeft -= n
 		}
+		// Part was corrupted after upload, do not stitch it.
+		if hex.EncodeToString(md5Writer.Sum(nil)) != part.ETag {
+			fs.storage.DeleteFile(minioMetaBucket, tempObj)
+			return "", InvalidPart{}
+		}
+	}
+
+	// Hold write lock on the destination before rename.
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	// Existing objects of protected buckets are never replaced.
+	if isBucketOverwriteProtected(bucket) && fs.isObject(bucket, object) {
+		fs.storage.DeleteFile(minioMetaBucket, tempObj)
+		return "", ObjectAlreadyExists{Bucket: bucket, Object: object}
+	}
+
+	// Save the metadata of the upload for the object.
+	if err = fs.writeObjectMeta(bucket, object, fsMeta.Meta); err != nil {
+		fs.storage.DeleteFile(minioMetaBucket, tempObj)
+		return "", toObjectErr(err, bucket, object)
 	}
 
 	// Rename the file back to original location, if not delete the temporary object.
@@ -586,7 +627,7 @@ func (fs fsObjects) CompleteMultipartUpload(bucket string, object string, upload
 
 // abortMultipartUpload - wrapper for purging an ongoing multipart
 // transaction, deletes uploadID entry from `uploads.json` and purges
-// the directory at '.minio/multipart/bucket/object/uploadID' holding
+// the directory at '.minio.sys/multipart/bucket/object/uploadID' holding
 // all the upload parts.
 func (fs fsObjects) abortMultipartUpload(bucket, object, uploadID string) error {
 	// Cleanup all uploaded parts.
@@ -612,7 +653,7 @@ func (fs fsObjects) abortMultipartUpload(bucket, object, uploadID string) error
 			return nil
 		}
 	} // No more pending uploads for the object, we purge the entire
-	// entry at '.minio/multipart/bucket/object'.
+	// entry at '.minio.sys/multipart/bucket/object'.
 	if err = fs.storage.DeleteFile(minioMetaBucket, path.Join(mpartMetaPrefix, bucket, object, uploadsJSONFile)); err != nil {
 		return toObjectErr(err, minioMetaBucket, path.Join(mpartMetaPrefix, bucket, object))
 	}
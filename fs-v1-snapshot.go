@@ -0,0 +1,101 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "time"
+
+/// Bucket snapshot operations
+
+// SnapshotBucket - snapshot all objects of a bucket, every object is a
+// single file which is hard linked into the snapshot.
+func (fs fsObjects) SnapshotBucket(bucket, snapshotID string) (BucketSnapshotInfo, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketSnapshotInfo{}, BucketNameInvalid{Bucket: bucket}
+	}
+	if !isBucketExist(fs.storage, bucket) {
+		return BucketSnapshotInfo{}, BucketNotFound{Bucket: bucket}
+	}
+	if !isValidSnapshotID(snapshotID) {
+		return BucketSnapshotInfo{}, BucketSnapshotNotFound{Bucket: bucket, SnapshotID: snapshotID}
+	}
+	snapshotPrefix := getSnapshotPrefix(bucket, snapshotID)
+	if err := linkDir(fs.storage, bucket, "", minioMetaBucket, getSnapshotDataPrefix(bucket, snapshotID)); err != nil {
+		cleanupDir(fs.storage, minioMetaBucket, snapshotPrefix)
+		return BucketSnapshotInfo{}, toObjectErr(err, bucket)
+	}
+	snapshotInfo := BucketSnapshotInfo{
+		Bucket:  bucket,
+		ID:      snapshotID,
+		Created: time.Now().UTC(),
+	}
+	if err := writeSnapshotMetadata(fs.storage, snapshotInfo); err != nil {
+		cleanupDir(fs.storage, minioMetaBucket, snapshotPrefix)
+		return BucketSnapshotInfo{}, toObjectErr(err, bucket)
+	}
+	return snapshotInfo, nil
+}
+
+// ListBucketSnapshots - list all snapshots of a bucket.
+func (fs fsObjects) ListBucketSnapshots(bucket string) ([]BucketSnapshotInfo, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return nil, BucketNameInvalid{Bucket: bucket}
+	}
+	snapshots, err := listSnapshots(fs.storage, bucket)
+	if err != nil {
+		return nil, toObjectErr(err, bucket)
+	}
+	return snapshots, nil
+}
+
+// CloneBucketSnapshot - create cloneBucket with the contents of the snapshot.
+func (fs fsObjects) CloneBucketSnapshot(bucket, snapshotID, cloneBucket string) error {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if _, err := readSnapshotMetadata(fs.storage, bucket, snapshotID); err != nil {
+		return toObjectErr(err, bucket)
+	}
+	if err := fs.MakeBucket(cloneBucket); err != nil {
+		return err
+	}
+	if err := linkDir(fs.storage, minioMetaBucket, getSnapshotDataPrefix(bucket, snapshotID), cloneBucket, ""); err != nil {
+		// Undo the partially cloned bucket.
+		cleanupDir(fs.storage, cloneBucket, "")
+		fs.storage.DeleteVol(cloneBucket)
+		return toObjectErr(err, cloneBucket)
+	}
+	return nil
+}
+
+// DeleteBucketSnapshot - delete a snapshot, data is freed once it is no
+// longer referenced by the bucket or any other snapshot.
+func (fs fsObjects) DeleteBucketSnapshot(bucket, snapshotID string) error {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if _, err := readSnapshotMetadata(fs.storage, bucket, snapshotID); err != nil {
+		return toObjectErr(err, bucket)
+	}
+	if err := cleanupDir(fs.storage, minioMetaBucket, getSnapshotPrefix(bucket, snapshotID)); err != nil {
+		return toObjectErr(err, bucket)
+	}
+	return nil
+}
@@ -18,6 +18,7 @@ package main
 
 import (
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
 	"io"
@@ -66,7 +67,12 @@ func loadFormatFS(storageDisk StorageAPI) (format formatConfigV1, err error) {
 func shutdownFS(storage StorageAPI) {
 	_, err := storage.ListDir(minioMetaBucket, mpartMetaPrefix)
 	if err != errFileNotFound {
-		// Multipart directory is not empty hence do not remove .minio volume.
+		// Multipart directory is not empty hence do not remove .minio.sys volume.
+		os.Exit(0)
+	}
+	_, err = storage.ListDir(minioMetaBucket, fsObjectMetaPrefix)
+	if err != errFileNotFound {
+		// Metadata of objects is saved, do not remove .minio.sys volume.
 		os.Exit(0)
 	}
 	prefix := ""
@@ -86,7 +92,9 @@ func newFSObjects(disk string) (ObjectLayer, error) {
 	}
 
 	// Runs house keeping code, like creating minioMetaBucket, cleaning up tmp files etc.
-	fsHouseKeeping(storage)
+	if err = fsHouseKeeping(storage); err != nil {
+		return nil, err
+	}
 
 	// loading format.json from minioMetaBucket.
 	// Note: The format.json content is ignored, reserved for future use.
@@ -126,6 +134,16 @@ func (fs fsObjects) StorageInfo() StorageInfo {
 	}
 }
 
+// Capabilities - returns optional features supported by FS.
+func (fs fsObjects) Capabilities() BackendCapabilities {
+	return BackendCapabilities{
+		Backend:    "FS",
+		Multipart:  true,
+		Snapshots:  true,
+		ObjectLock: true,
+	}
+}
+
 /// Bucket operations
 
 // MakeBucket - make a bucket.
@@ -187,6 +205,10 @@ func (fs fsObjects) DeleteBucket(bucket string) error {
 	if err := fs.storage.DeleteVol(bucket); err != nil {
 		return toObjectErr(err, bucket)
 	}
+	// Remove the metadata saved for objects of the bucket.
+	if err := cleanupDir(fs.storage, minioMetaBucket, path.Join(fsObjectMetaPrefix, bucket)); err != nil {
+		return toObjectErr(err, bucket)
+	}
 	return nil
 }
 
@@ -257,6 +279,10 @@ func (fs fsObjects) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
 	if err != nil {
 		return ObjectInfo{}, toObjectErr(err, bucket, object)
 	}
+	meta, err := fs.readObjectMeta(bucket, object)
+	if err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
 
 	// Guess content-type from the extension if possible.
 	contentType := ""
@@ -274,6 +300,8 @@ func (fs fsObjects) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
 		IsDir:       fi.Mode.IsDir(),
 		ContentType: contentType,
 		MD5Sum:      "", // Read from metadata.
+		Retention:   getObjectRetention(meta),
+		Expires:     getObjectExpiry(meta),
 	}, nil
 }
 
@@ -289,6 +317,16 @@ func (fs fsObjects) PutObject(bucket string, object string, size int64, data io.
 			Object: object,
 		}
 	}
+	// User metadata is not saved, client side encrypted objects
+	// would lose their envelope.
+	if hasCSEMetadata(metadata) {
+		return "", CSEMetadataNotSupported{}
+	}
+	// Tags would be lost the same way.
+	if hasObjectTags(metadata) {
+		return "", TaggingNotSupported{}
+	}
+	createOnly := takeCreateOnly(metadata)
 
 	uniqueID := getUUID()
 
@@ -342,6 +380,29 @@ func (fs fsObjects) PutObject(bucket string, object string, size int64, data io.
 		}
 	}
 
+	// Hold write lock on the destination before rename.
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	// Existing objects of protected buckets are never replaced.
+	if isBucketOverwriteProtected(bucket) && fs.isObject(bucket, object) {
+		fs.storage.DeleteFile(minioMetaBucket, tempObj)
+		return "", ObjectAlreadyExists{Bucket: bucket, Object: object}
+	}
+	// Create-only uploads fail if the object exists, checked under
+	// the lock so that concurrent creates succeed only once.
+	if createOnly && fs.isObject(bucket, object) {
+		fs.storage.DeleteFile(minioMetaBucket, tempObj)
+		return "", ObjectPreconditionFailed{Bucket: bucket, Object: object}
+	}
+
+	// Metadata is saved before the object is in place, objects are
+	// never visible without it.
+	if err := fs.writeObjectMeta(bucket, object, metadata); err != nil {
+		fs.storage.DeleteFile(minioMetaBucket, tempObj)
+		return "", toObjectErr(err, bucket, object)
+	}
+
 	// Entire object was written to the temp location, now it's safe to rename it
 	// to the actual location.
 	err := fs.storage.RenameFile(minioMetaBucket, tempObj, bucket, object)
@@ -353,6 +414,170 @@ func (fs fsObjects) PutObject(bucket string, object string, size int64, data io.
 	return newMD5Hex, nil
 }
 
+// fsAppendWriter - appends all writes to a file.
+type fsAppendWriter struct {
+	storage StorageAPI
+	volume  string
+	path    string
+}
+
+func (w fsAppendWriter) Write(p []byte) (int, error) {
+	if err := w.storage.AppendFile(w.volume, w.path, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// PatchObject - replaces size bytes of an object at offset with data,
+// the object grows if the patch extends past its end.
+func (fs fsObjects) PatchObject(bucket, object string, offset, size int64, data io.Reader) (string, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return "", BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) {
+		return "", ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	// Existing objects of protected buckets are never modified.
+	if isBucketOverwriteProtected(bucket) && fs.isObject(bucket, object) {
+		return "", ObjectAlreadyExists{Bucket: bucket, Object: object}
+	}
+	fi, err := fs.storage.StatFile(bucket, object)
+	if err != nil {
+		return "", toObjectErr(err, bucket, object)
+	}
+	if offset < 0 || offset > fi.Size {
+		return "", InvalidRange{}
+	}
+
+	// Patched object is written to the temporary location and renamed
+	// over the object.
+	tempObj := path.Join(tmpMetaPrefix, getUUID())
+	md5Writer := md5.New()
+	writer := io.MultiWriter(md5Writer, fsAppendWriter{fs.storage, minioMetaBucket, tempObj})
+
+	// Empty file is created first, the patched object may be empty.
+	err = fs.storage.AppendFile(minioMetaBucket, tempObj, []byte(""))
+	if err == nil {
+		err = fs.GetObject(bucket, object, 0, offset, writer)
+	}
+	if err == nil {
+		if _, err = io.CopyN(writer, data, size); err == io.EOF {
+			err = IncompleteBody{}
+		}
+	}
+	if err == nil {
+		err = checkPatchEOF(data)
+	}
+	if tailOffset := offset + size; err == nil && tailOffset < fi.Size {
+		err = fs.GetObject(bucket, object, tailOffset, fi.Size-tailOffset, writer)
+	}
+	if err == nil {
+		err = fs.storage.RenameFile(minioMetaBucket, tempObj, bucket, object)
+	}
+	if err != nil {
+		fs.storage.DeleteFile(minioMetaBucket, tempObj)
+		return "", toObjectErr(err, bucket, object)
+	}
+	return hex.EncodeToString(md5Writer.Sum(nil)), nil
+}
+
+// CopyObject - copies the object, FS has no shared data so the object
+// data is copied. Copies onto the source only replace the metadata
+// saved by FS.
+func (fs fsObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string, metadata map[string]string) (string, error) {
+	if srcBucket == dstBucket && srcObject == dstObject {
+		// User metadata is not saved, client side encrypted objects
+		// would lose their envelope.
+		if hasCSEMetadata(metadata) {
+			return "", CSEMetadataNotSupported{}
+		}
+		if hasObjectTags(metadata) {
+			return "", TaggingNotSupported{}
+		}
+		objInfo, err := fs.GetObjectInfo(srcBucket, srcObject)
+		if err != nil {
+			return "", err
+		}
+		// Existing objects of protected buckets are never replaced.
+		if isBucketOverwriteProtected(dstBucket) {
+			return "", ObjectAlreadyExists{Bucket: dstBucket, Object: dstObject}
+		}
+		nsMutex.Lock(dstBucket, dstObject)
+		err = fs.writeObjectMeta(dstBucket, dstObject, metadata)
+		nsMutex.Unlock(dstBucket, dstObject)
+		if err != nil {
+			return "", toObjectErr(err, dstBucket, dstObject)
+		}
+		md5Writer := md5.New()
+		if err = fs.GetObject(srcBucket, srcObject, 0, objInfo.Size, md5Writer); err != nil {
+			return "", err
+		}
+		return hex.EncodeToString(md5Writer.Sum(nil)), nil
+	}
+	return copyObjectData(fs, srcBucket, srcObject, fs, dstBucket, dstObject, metadata)
+}
+
+// ComposeObject - creates an object from the concatenation of source
+// objects of the bucket, data of all sources is copied.
+func (fs fsObjects) ComposeObject(bucket, object string, sources []string, metadata map[string]string) (string, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return "", BucketNameInvalid{Bucket: bucket}
+	}
+	for _, name := range append([]string{object}, sources...) {
+		if !IsValidObjectName(name) {
+			return "", ObjectNameInvalid{Bucket: bucket, Object: name}
+		}
+	}
+	if len(sources) == 0 {
+		return "", errInvalidArgument
+	}
+
+	tempObj := path.Join(tmpMetaPrefix, getUUID())
+	md5Writer := md5.New()
+	writer := io.MultiWriter(md5Writer, fsAppendWriter{fs.storage, minioMetaBucket, tempObj})
+
+	// Empty file is created first, all sources may be empty.
+	err := fs.storage.AppendFile(minioMetaBucket, tempObj, []byte(""))
+	for _, source := range sources {
+		if err != nil {
+			break
+		}
+		var fi FileInfo
+		if fi, err = fs.storage.StatFile(bucket, source); err == nil {
+			err = fs.GetObject(bucket, source, 0, fi.Size, writer)
+		}
+		err = toObjectErr(err, bucket, source)
+	}
+	if err != nil {
+		fs.storage.DeleteFile(minioMetaBucket, tempObj)
+		return "", err
+	}
+
+	// Hold write lock on the destination before rename.
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	// Existing objects of protected buckets are never replaced.
+	if isBucketOverwriteProtected(bucket) && fs.isObject(bucket, object) {
+		fs.storage.DeleteFile(minioMetaBucket, tempObj)
+		return "", ObjectAlreadyExists{Bucket: bucket, Object: object}
+	}
+	if err = fs.writeObjectMeta(bucket, object, metadata); err != nil {
+		fs.storage.DeleteFile(minioMetaBucket, tempObj)
+		return "", toObjectErr(err, bucket, object)
+	}
+	if err = fs.storage.RenameFile(minioMetaBucket, tempObj, bucket, object); err != nil {
+		fs.storage.DeleteFile(minioMetaBucket, tempObj)
+		return "", toObjectErr(err, bucket, object)
+	}
+	return hex.EncodeToString(md5Writer.Sum(nil)), nil
+}
+
 func (fs fsObjects) DeleteObject(bucket, object string) error {
 	// Verify if bucket is valid.
 	if !IsValidBucketName(bucket) {
@@ -364,9 +589,82 @@ func (fs fsObjects) DeleteObject(bucket, object string) error {
 	if err := fs.storage.DeleteFile(bucket, object); err != nil {
 		return toObjectErr(err, bucket, object)
 	}
+	if err := fs.deleteObjectMeta(bucket, object); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
 	return nil
 }
 
+// DeleteObjects - deletes a batch of objects concurrently, returns the
+// error of each object in the order of the batch.
+func (fs fsObjects) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return nil, BucketNameInvalid{Bucket: bucket}
+	}
+	if !isBucketExist(fs.storage, bucket) {
+		return nil, BucketNotFound{Bucket: bucket}
+	}
+	return deleteObjects(fs.DeleteObject, bucket, objects), nil
+}
+
+// PutObjectTags - FS saves no object metadata, tags are not supported.
+func (fs fsObjects) PutObjectTags(bucket, object string, tags map[string]string) error {
+	return TaggingNotSupported{}
+}
+
+// PutObjectRetention - replaces retention and legal hold of an object,
+// saved in its `fs.json`.
+func (fs fsObjects) PutObjectRetention(bucket, object string, retention objectRetention) error {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) {
+		return ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	if !fs.isObject(bucket, object) {
+		return ObjectNotFound{Bucket: bucket, Object: object}
+	}
+	meta, err := fs.readObjectMeta(bucket, object)
+	if err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	if meta == nil {
+		meta = make(map[string]string)
+	}
+	setObjectRetention(meta, retention)
+	if err = fs.writeObjectMeta(bucket, object, meta); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	return nil
+}
+
+/// Object version operations, FS keeps a single version of objects.
+
+// GetObjectVersion - versioning is not supported.
+func (fs fsObjects) GetObjectVersion(bucket, object, versionID string, startOffset int64, length int64, writer io.Writer) error {
+	return VersioningNotSupported{}
+}
+
+// GetObjectVersionInfo - versioning is not supported.
+func (fs fsObjects) GetObjectVersionInfo(bucket, object, versionID string) (ObjectInfo, error) {
+	return ObjectInfo{}, VersioningNotSupported{}
+}
+
+// DeleteObjectVersion - versioning is not supported.
+func (fs fsObjects) DeleteObjectVersion(bucket, object, versionID string) error {
+	return VersioningNotSupported{}
+}
+
+// ListObjectVersions - versioning is not supported.
+func (fs fsObjects) ListObjectVersions(bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int) (ListObjectVersionsInfo, error) {
+	return ListObjectVersionsInfo{}, VersioningNotSupported{}
+}
+
 // Checks whether bucket exists.
 func isBucketExist(storage StorageAPI, bucketName string) bool {
 	// Check whether bucket exists.
@@ -502,11 +800,17 @@ func (fs fsObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 				continue
 			}
 		}
+		meta, err := fs.readObjectMeta(bucket, fileInfo.Name)
+		if err != nil {
+			return ListObjectsInfo{}, toObjectErr(err, bucket, fileInfo.Name)
+		}
 		result.Objects = append(result.Objects, ObjectInfo{
-			Name:    fileInfo.Name,
-			ModTime: fileInfo.ModTime,
-			Size:    fileInfo.Size,
-			IsDir:   false,
+			Name:      fileInfo.Name,
+			ModTime:   fileInfo.ModTime,
+			Size:      fileInfo.Size,
+			IsDir:     false,
+			Retention: getObjectRetention(meta),
+			Expires:   getObjectExpiry(meta),
 		})
 	}
 	return result, nil
@@ -516,3 +820,51 @@ func (fs fsObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 func (fs fsObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
 	return fs.listObjects(bucket, prefix, marker, delimiter, maxKeys)
 }
+
+// ListObjectsV2 - lists objects after the continuation token, or
+// after startAfter for the first page.
+func (fs fsObjects) ListObjectsV2(bucket, prefix, continuationToken, delimiter string, maxKeys int, startAfter string) (ListObjectsV2Info, error) {
+	return listObjectsV2(fs.ListObjects, bucket, prefix, continuationToken, delimiter, maxKeys, startAfter)
+}
+
+/// Healing operations
+
+// HealBucket - not supported, FS keeps a single copy of objects.
+func (fs fsObjects) HealBucket(bucket string, dryRun bool) (BucketHealInfo, error) {
+	return BucketHealInfo{}, HealingNotSupported{}
+}
+
+// HealObject - not supported, FS keeps a single copy of objects.
+func (fs fsObjects) HealObject(bucket, object string, dryRun bool) (ObjectHealInfo, error) {
+	return ObjectHealInfo{}, HealingNotSupported{}
+}
+
+/// Auditing operations
+
+// GetObjectChecksums - FS records no checksums, reports the sha256 of
+// the object data as a single shard.
+func (fs fsObjects) GetObjectChecksums(bucket, object string) (ObjectChecksumInfo, error) {
+	nsMutex.RLock(bucket, object)
+	defer nsMutex.RUnlock(bucket, object)
+	objInfo, err := fs.GetObjectInfo(bucket, object)
+	if err != nil {
+		return ObjectChecksumInfo{}, err
+	}
+	sha256Writer := sha256.New()
+	if err = fs.GetObject(bucket, object, 0, objInfo.Size, sha256Writer); err != nil {
+		return ObjectChecksumInfo{}, err
+	}
+	return ObjectChecksumInfo{
+		Object: object,
+		Size:   objInfo.Size,
+		MD5Sum: objInfo.MD5Sum,
+		Parts: []PartChecksumInfo{{
+			Number: 1,
+			Size:   objInfo.Size,
+			Shards: []ShardChecksumInfo{{
+				Algorithm: "sha256",
+				Hash:      hex.EncodeToString(sha256Writer.Sum(nil)),
+			}},
+		}},
+	}, nil
+}
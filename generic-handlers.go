@@ -116,6 +116,11 @@ func (h minioPrivateBucketHandler) ServeHTTP(w http.ResponseWriter, r *http.Requ
 		writeErrorResponse(w, r, ErrAllAccessDisabled, r.URL.Path)
 		return
 	}
+	// Internal state is never accessible through the API.
+	if bucket, _ := urlPath2BucketObjectName(r.URL); isMinioMetaBucketName(bucket) {
+		writeErrorResponse(w, r, ErrAllAccessDisabled, r.URL.Path)
+		return
+	}
 	h.handler.ServeHTTP(w, r)
 }
 
@@ -168,8 +173,9 @@ func setTimeValidityHandler(h http.Handler) http.Handler {
 }
 
 func (h timeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Verify if date headers are set, if not reject the request
-	if _, ok := r.Header["Authorization"]; ok {
+	// Verify if date headers are set, if not reject the request,
+	// basic authentication has no date.
+	if _, ok := r.Header["Authorization"]; ok && !isRequestBasicAuth(r) {
 		amzDate, apiErr := parseAmzDateHeader(r)
 		if apiErr != ErrNone {
 			// All our internal APIs are sensitive towards Date
@@ -178,9 +184,9 @@ func (h timeHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			writeErrorResponse(w, r, apiErr, r.URL.Path)
 			return
 		}
-		// Verify if the request date header is more than 5minutes
-		// late, reject such clients.
-		if time.Now().UTC().Sub(amzDate)/time.Minute > time.Duration(5)*time.Minute {
+		// Verify if the request date header is more than
+		// globalMaxSkewTime late, reject such clients.
+		if time.Now().UTC().Sub(amzDate) > globalMaxSkewTime {
 			writeErrorResponse(w, r, ErrRequestTimeTooSkewed, r.URL.Path)
 			return
 		}
@@ -196,11 +202,20 @@ type resourceHandler struct {
 func setCorsHandler(h http.Handler) http.Handler {
 	c := cors.New(cors.Options{
 		AllowedOrigins: []string{"*"},
-		AllowedMethods: []string{"GET", "HEAD", "POST", "PUT"},
+		AllowedMethods: []string{"GET", "HEAD", "POST", "PUT", "DELETE"},
 		AllowedHeaders: []string{"*"},
 		ExposedHeaders: []string{"ETag"},
 	})
-	return c.Handler(h)
+	corsHandler := c.Handler(h)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// WebDAV clients send OPTIONS to discover DAV support,
+		// which must not be answered as CORS preflight.
+		if isWebDAVRequest(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		corsHandler.ServeHTTP(w, r)
+	})
 }
 
 // setIgnoreResourcesHandler -
@@ -213,18 +228,8 @@ func setIgnoreResourcesHandler(h http.Handler) http.Handler {
 
 // Resource handler ServeHTTP() wrapper
 func (h resourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	// Skip the first element which is usually '/' and split the rest.
-	splits := strings.SplitN(r.URL.Path[1:], "/", 2)
-
 	// Save bucketName and objectName extracted from url Path.
-	var bucketName, objectName string
-	if len(splits) == 1 {
-		bucketName = splits[0]
-	}
-	if len(splits) == 2 {
-		bucketName = splits[0]
-		objectName = splits[1]
-	}
+	bucketName, objectName := urlPath2BucketObjectName(r.URL)
 	// If bucketName is present and not objectName check for bucket
 	// level resource queries.
 	if bucketName != "" && objectName == "" {
@@ -279,9 +284,7 @@ var notimplementedBucketResourceNames = map[string]bool{
 	"notification":   true,
 	"replication":    true,
 	"tagging":        true,
-	"versions":       true,
 	"requestPayment": true,
-	"versioning":     true,
 	"website":        true,
 }
 
@@ -291,3 +294,25 @@ var notimplementedObjectResourceNames = map[string]bool{
 	"acl":     true,
 	"policy":  true,
 }
+
+// expectedBucketOwnerHandler - rejects requests whose expected bucket
+// owner doesn't match the owner of the bucket.
+type expectedBucketOwnerHandler struct {
+	handler http.Handler
+}
+
+// setExpectedBucketOwnerHandler to validate X-Amz-Expected-Bucket-Owner
+// and X-Amz-Source-Expected-Bucket-Owner headers.
+func setExpectedBucketOwnerHandler(h http.Handler) http.Handler {
+	return expectedBucketOwnerHandler{h}
+}
+
+func (h expectedBucketOwnerHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	for _, header := range []string{"X-Amz-Expected-Bucket-Owner", "X-Amz-Source-Expected-Bucket-Owner"} {
+		if _, ok := r.Header[header]; ok && r.Header.Get(header) != bucketOwnerID {
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+			return
+		}
+	}
+	h.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,64 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Tests validate expected bucket owner headers.
+func TestExpectedBucketOwnerHandler(t *testing.T) {
+	handler := setExpectedBucketOwnerHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	testCases := []struct {
+		headers            map[string]string
+		expectedStatusCode int
+	}{
+		// Test case - 1.
+		// No expected owner is allowed.
+		{map[string]string{}, http.StatusOK},
+		// Test case - 2.
+		// Matching expected owner is allowed.
+		{map[string]string{"X-Amz-Expected-Bucket-Owner": bucketOwnerID}, http.StatusOK},
+		// Test case - 3.
+		// Mismatching expected owner is denied.
+		{map[string]string{"X-Amz-Expected-Bucket-Owner": "111122223333"}, http.StatusForbidden},
+		// Test case - 4.
+		// Empty expected owner is denied.
+		{map[string]string{"X-Amz-Expected-Bucket-Owner": ""}, http.StatusForbidden},
+		// Test case - 5.
+		// Mismatching source expected owner is denied.
+		{map[string]string{"X-Amz-Source-Expected-Bucket-Owner": "111122223333"}, http.StatusForbidden},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("GET", "http://localhost:9000/bucket/object", nil)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to create request. %s", i+1, err)
+		}
+		for k, v := range testCase.headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedStatusCode {
+			t.Errorf("Test %d: Expected status %d, got %d", i+1, testCase.expectedStatusCode, rec.Code)
+		}
+	}
+}
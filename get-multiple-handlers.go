@@ -0,0 +1,194 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+
+	mux "github.com/gorilla/mux"
+)
+
+const (
+	// Headers of every part of a GetMultipleObjects response.
+	getMultipleKeyHeader    = "X-Minio-Key"
+	getMultipleStatusHeader = "X-Minio-Status"
+
+	// Maximum size of GetMultipleObjects request, 1000 keys of
+	// at most 1024 bytes each with ranges.
+	maxGetMultipleObjectsSize = 2 * 1024 * 1024 // 2MiB.
+)
+
+// GetMultipleObjectsHandler - POST Bucket ?getMultiple
+// ----------
+// This implementation of the POST operation is a Minio extension which
+// reads a list of objects, or byte ranges of them, in one
+// multipart/mixed response. Failures of individual objects are sent as
+// error parts and do not fail the request.
+func (api objectAPIHandlers) GetMultipleObjectsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	reqAuthType := getRequestAuthType(r)
+	switch reqAuthType {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypeAnonymous:
+		// Objects are verified below, once they are known.
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	// If Content-Length is greater than maximum allowed size.
+	if r.ContentLength > maxGetMultipleObjectsSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+	getMultipleBytes, err := ioutil.ReadAll(io.LimitReader(r.Body, maxGetMultipleObjectsSize))
+	if err != nil {
+		errorIf(err, "Unable to read get multiple objects request.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	getMultiple := &getMultipleObjects{}
+	if err = xml.Unmarshal(getMultipleBytes, getMultiple); err != nil {
+		errorIf(err, "Unable to parse get multiple objects XML.")
+		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		return
+	}
+	if len(getMultiple.Objects) == 0 {
+		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		return
+	}
+	if len(getMultiple.Objects) > maxGetMultipleObjects {
+		writeErrorResponse(w, r, ErrTooManyGetMultipleObjects, r.URL.Path)
+		return
+	}
+
+	mw := multipart.NewWriter(w)
+	setCommonHeaders(w)
+	w.Header().Set("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	w.WriteHeader(http.StatusOK)
+	for _, entry := range getMultiple.Objects {
+		if err = api.writeGetMultiplePart(mw, reqAuthType, bucket, entry); err != nil {
+			// Parts already sent cannot be taken back, the response
+			// ends without its closing boundary instead.
+			errorIf(err, "Writing to client failed.")
+			return
+		}
+	}
+	mw.Close()
+}
+
+// writeGetMultiplePart - writes the object, or an error part if it
+// cannot be read. Returned error means the response is broken.
+func (api objectAPIHandlers) writeGetMultiplePart(mw *multipart.Writer, reqAuthType authType, bucket string, entry getMultipleObjectsEntry) error {
+	object := entry.Key
+	if reqAuthType == authTypeAnonymous {
+		objectURL := &url.URL{Path: "/" + bucket + "/" + object}
+		if s3Error := enforceBucketPolicy("s3:GetObject", bucket, objectURL); s3Error != ErrNone {
+			return writeGetMultipleErrorPart(mw, bucket, object, s3Error)
+		}
+	}
+	objInfo, err := api.ObjectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		errorIf(err, "Unable to fetch object info.")
+		return writeGetMultipleErrorPart(mw, bucket, object, toAPIErrorCode(err))
+	}
+	hrange, err := getRequestedRange(entry.Range, objInfo.Size)
+	if err != nil {
+		return writeGetMultipleErrorPart(mw, bucket, object, ErrInvalidRange)
+	}
+
+	statusCode := http.StatusOK
+	if hrange.isPartial() {
+		statusCode = http.StatusPartialContent
+	}
+	header := getMultipleObjectHeader(objInfo, hrange)
+	header.Set(getMultipleKeyHeader, object)
+	header.Set(getMultipleStatusHeader, strconv.Itoa(statusCode))
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	startOffset := hrange.start
+	length := hrange.length
+	if length == 0 {
+		length = objInfo.Size - startOffset
+	}
+	return api.ObjectAPI.GetObject(bucket, object, startOffset, length, part)
+}
+
+// getMultipleObjectHeader - returns the object headers of a GET object
+// response, for a part of GetMultipleObjects.
+func getMultipleObjectHeader(objInfo ObjectInfo, contentRange *httpRange) textproto.MIMEHeader {
+	header := make(textproto.MIMEHeader)
+	header.Set("Last-Modified", objInfo.ModTime.UTC().Format(http.TimeFormat))
+	header.Set("Content-Type", objInfo.ContentType)
+	if objInfo.ContentEncoding != "" {
+		header.Set("Content-Encoding", objInfo.ContentEncoding)
+	}
+	if objInfo.CacheControl != "" {
+		header.Set("Cache-Control", objInfo.CacheControl)
+	}
+	if objInfo.MD5Sum != "" {
+		header.Set("ETag", "\""+objInfo.MD5Sum+"\"")
+	}
+	// Set user defined metadata.
+	for key, value := range objInfo.UserDefined {
+		header.Set(key, value)
+	}
+	header.Set("Content-Length", strconv.FormatInt(objInfo.Size, 10))
+	if contentRange.isPartial() {
+		header.Set("Content-Length", strconv.FormatInt(contentRange.length, 10))
+		header.Set("Content-Range", contentRange.String())
+	}
+	return header
+}
+
+// writeGetMultipleErrorPart - writes an error part carrying the error
+// response a GET of the object would have returned.
+func writeGetMultipleErrorPart(mw *multipart.Writer, bucket, object string, errorCode APIErrorCode) error {
+	apiErr := getAPIError(errorCode)
+	header := make(textproto.MIMEHeader)
+	header.Set(getMultipleKeyHeader, object)
+	header.Set(getMultipleStatusHeader, strconv.Itoa(apiErr.HTTPStatusCode))
+	header.Set("Content-Type", "application/xml")
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(encodeResponse(getAPIErrorResponse(apiErr, "/"+bucket+"/"+object)))
+	return err
+}
@@ -0,0 +1,121 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Tests reading multiple objects with POST ?getMultiple.
+func TestGetMultipleObjectsHandler(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+
+	bucket := makeIntegrationBucket(t, client)
+	for _, object := range []string{"a", "b"} {
+		resp, respBody, err := client.do("PUT", bucket, object, nil, nil, []byte("hello "+object))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+	}
+
+	body := "<GetMultipleObjects>" +
+		"<Object><Key>a</Key></Object>" +
+		"<Object><Key>b</Key><Range>bytes=0-3</Range></Object>" +
+		"<Object><Key>missing</Key></Object>" +
+		"<Object><Key>a</Key><Range>bytes=100-200</Range></Object>" +
+		"</GetMultipleObjects>"
+	resp, respBody, err := client.do("POST", bucket, "", url.Values{"getMultiple": {""}}, nil, []byte(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "GetMultipleObjects", resp, respBody, http.StatusOK)
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/mixed" {
+		t.Fatalf("Expected multipart/mixed response, got %q", resp.Header.Get("Content-Type"))
+	}
+
+	testCases := []struct {
+		key            string
+		expectedStatus string
+		expectedData   string
+		expectedRange  string
+	}{
+		// Test case - 1.
+		{"a", "200", "hello a", ""},
+		// Test case - 2.
+		{"b", "206", "hell", "bytes 0-3/7"},
+		// Test case - 3.
+		{"missing", "404", "", ""},
+		// Test case - 4.
+		{"a", "416", "", ""},
+	}
+	mr := multipart.NewReader(bytes.NewReader(respBody), params["boundary"])
+	for i, testCase := range testCases {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("Test %d: Unable to read part. %v", i+1, err)
+		}
+		partData, err := ioutil.ReadAll(part)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to read part. %v", i+1, err)
+		}
+		if key := part.Header.Get(getMultipleKeyHeader); key != testCase.key {
+			t.Errorf("Test %d: Expected key %q, got %q", i+1, testCase.key, key)
+		}
+		if status := part.Header.Get(getMultipleStatusHeader); status != testCase.expectedStatus {
+			t.Errorf("Test %d: Expected status %s, got %s", i+1, testCase.expectedStatus, status)
+		}
+		if testCase.expectedData != "" && string(partData) != testCase.expectedData {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expectedData, partData)
+		}
+		if contentRange := part.Header.Get("Content-Range"); contentRange != testCase.expectedRange {
+			t.Errorf("Test %d: Expected range %q, got %q", i+1, testCase.expectedRange, contentRange)
+		}
+	}
+	if _, err = mr.NextPart(); err == nil {
+		t.Errorf("Expected %d parts only", len(testCases))
+	}
+
+	// Test malformed requests.
+	badCases := []string{
+		// Test case - 1.
+		"<GetMultipleObjects></GetMultipleObjects>",
+		// Test case - 2.
+		"<GetMultipleObjects>" + strings.Repeat("<Object><Key>a</Key></Object>", maxGetMultipleObjects+1) + "</GetMultipleObjects>",
+		// Test case - 3.
+		"not xml",
+	}
+	for i, badCase := range badCases {
+		resp, respBody, err = client.do("POST", bucket, "", url.Values{"getMultiple": {""}}, nil, []byte(badCase))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("Test %d: Expected status %d, got %d: %s", i+1, http.StatusBadRequest, resp.StatusCode, respBody)
+		}
+	}
+}
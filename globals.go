@@ -16,7 +16,11 @@
 
 package main
 
-import "github.com/fatih/color"
+import (
+	"time"
+
+	"github.com/fatih/color"
+)
 
 // Global constants for Minio.
 const (
@@ -32,17 +36,115 @@ const (
 	globalMinioKeyFile       = "private.key"
 	globalMinioConfigFile    = "config.json"
 	globalMinioProfilePath   = "profile"
+	// Maximum clock skew tolerated for signed requests.
+	globalMaxSkewTime = 5 * time.Minute
 	// Add new global values here.
 )
 
 var (
 	globalQuiet = false // Quiet flag set via command line
 	globalTrace = false // Trace flag set via environment setting.
+	// Fault injection flag set via hidden command line flag.
+	globalDebugFaults = false
 	// Add new global flags here.
 
 	// Maximum connections handled per
 	// server, defaults to 0 (unlimited).
 	globalMaxConn = 0
+
+	// Time at which this server process was started.
+	globalBootTime = time.Now().UTC()
+
+	// Disks of erasure sets added to the deployment, the export
+	// paths are its first set.
+	globalErasureSets [][]string
+
+	// Export paths of the cold storage tier, tiering is
+	// disabled if empty.
+	globalTierColdPaths []string
+	// Age after which objects are demoted to the cold storage
+	// tier, defaults to 0 (never demoted).
+	globalTierDemoteAfter time.Duration
+
+	// Interval between scrubs of all XL objects, defaults to 0
+	// (never scrubbed), set via environment setting.
+	globalScrubInterval time.Duration
+
+	// Percentage of XL objects verified per day by sampling, defaults
+	// to 0 (never sampled), and percentage of damaged objects of a
+	// bucket in a sample escalating to healing of the bucket, set via
+	// environment setting.
+	globalVerifySamplePercent  float64
+	globalVerifyErrorThreshold = 1.0
+
+	// Single PUTs larger than this are stored as parts of this size,
+	// stored as one part if 0, set via environment setting.
+	globalPutPartSize int64
+
+	// Content addressed dedup of identical objects, set via
+	// environment setting.
+	globalDedup = false
+
+	// WebDAV frontend with basic authentication, set via
+	// environment setting.
+	globalWebDAVEnabled = false
+
+	// In-memory index of object metadata for metadata queries,
+	// set via environment setting.
+	globalMetadataIndexEnabled = false
+
+	// Name the check denying a request in a response header, set
+	// via environment setting.
+	globalDebugDenials = false
+
+	// Checksum required on uploads to buckets without a checksum
+	// configuration, none if empty, set via environment setting.
+	globalRequiredChecksum = ""
+
+	// Refuse to start on failed preflight checks, set via
+	// environment setting.
+	globalPreflightStrict = false
+
+	// Proxy requests to a healthy peer while disks of this node
+	// lack quorum, set via environment setting.
+	globalFailoverEnabled = false
+
+	// Time to wait at startup for disks not found, set via
+	// environment setting.
+	globalDisksWaitTimeout = 2 * time.Minute
+
+	// Name space locks held longer than this are logged as stuck,
+	// never if 0, set via environment setting.
+	globalStuckLockTimeout = 10 * time.Minute
+
+	// Maximum tree walks kept alive for continuing listings,
+	// set via environment setting.
+	globalMaxTreeWalks = 1000
+	// Maximum multipart uploads in progress per object, set via
+	// environment setting.
+	globalMaxUploadsPerObject = 10000
+
+	// Erasure encode/decode and shard I/O workers, sized
+	// automatically if 0, set via environment setting.
+	globalErasureCodecWorkers = 0
+	globalErasureIOWorkers    = 0
+
+	// Blocks of an object read concurrently by a GET, set via
+	// environment setting.
+	globalErasureReadConcurrency = 2
+
+	// Parity blocks of new XL objects, half of the disks if 0, set
+	// via environment setting.
+	globalErasureParity = 0
+
+	// CPUs erasure encode/decode workers and request goroutines are
+	// pinned to, not pinned if nil, set via environment setting.
+	// Experimental.
+	globalErasureCPUs *cpuSet
+	globalNetworkCPUs *cpuSet
+
+	// Name of this node, objects are stamped with it.
+	globalNodeName = ""
 	// Add new variable global values here.
 )
 
@@ -51,4 +153,5 @@ var (
 	colorMagenta = color.New(color.FgMagenta, color.Bold).SprintfFunc()
 	colorWhite   = color.New(color.FgWhite, color.Bold).SprintfFunc()
 	colorGreen   = color.New(color.FgGreen, color.Bold).SprintfFunc()
+	colorYellow  = color.New(color.FgYellow, color.Bold).SprintfFunc()
 )
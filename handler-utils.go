@@ -18,6 +18,9 @@ package main
 
 import (
 	"io"
+	"net/http"
+	"net/url"
+	"strings"
 )
 
 // validates location constraint from the request body.
@@ -52,3 +55,54 @@ func isValidLocationContraint(reqBody io.Reader, serverRegion string) APIErrorCo
 	}
 	return errCode
 }
+
+// urlPath2BucketObjectName - returns bucket and object name from the
+// request URL path.
+func urlPath2BucketObjectName(u *url.URL) (bucketName, objectName string) {
+	// Skip the first element which is usually '/' and split the rest.
+	splits := strings.SplitN(strings.TrimPrefix(u.Path, "/"), "/", 2)
+	bucketName = splits[0]
+	if len(splits) == 2 {
+		objectName = splits[1]
+	}
+	return bucketName, objectName
+}
+
+// User defined metadata header prefixes.
+var userMetadataPrefixes = []string{"X-Amz-Meta-", "X-Minio-Meta-"}
+
+// isUserMetadataKey - returns true if canonical header name is user
+// defined metadata.
+func isUserMetadataKey(key string) bool {
+	for _, prefix := range userMetadataPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// extractUserMetadata - returns user defined metadata of the headers,
+// keyed by canonical header name.
+func extractUserMetadata(header http.Header) map[string]string {
+	metadata := make(map[string]string)
+	for key := range header {
+		cKey := http.CanonicalHeaderKey(key)
+		if isUserMetadataKey(cKey) {
+			metadata[cKey] = header.Get(cKey)
+		}
+	}
+	return metadata
+}
+
+// getUserMetadata - returns user defined metadata of saved object
+// metadata.
+func getUserMetadata(meta map[string]string) map[string]string {
+	metadata := make(map[string]string)
+	for key, value := range meta {
+		if isUserMetadataKey(key) {
+			metadata[key] = value
+		}
+	}
+	return metadata
+}
@@ -0,0 +1,325 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// Heal throttle is saved in the config directory.
+	healThrottleFile = "heal-throttle.json"
+
+	// Maximum size of heal throttle document.
+	maxHealThrottleSize = 1 * 1024 * 1024 // 1MiB.
+
+	// Interval at which paused healing checks client latency again.
+	healPauseInterval = 1 * time.Second
+
+	// Client latency is reported as zero once no request responded
+	// during the window, healing resumes without client traffic.
+	clientLatencyWindow = 10 * time.Second
+)
+
+// healThrottleConfig - limits on healing in favour of client traffic.
+type healThrottleConfig struct {
+	// Objects healed concurrently by bucket heals and scrubbing, 1 if
+	// not set.
+	Concurrency int `json:"concurrency,omitempty"`
+	// Bytes read from and written to each disk per second by healing,
+	// 0 is unlimited.
+	DiskBandwidth int64 `json:"diskBandwidth,omitempty"`
+	// Healing pauses while the latency of client requests exceeds
+	// MaxClientLatency.
+	ClientTrafficFirst bool   `json:"clientTrafficFirst,omitempty"`
+	MaxClientLatency   string `json:"maxClientLatency,omitempty"`
+}
+
+// getConcurrency - returns objects healed concurrently.
+func (config healThrottleConfig) getConcurrency() int {
+	if config.Concurrency == 0 {
+		return 1
+	}
+	return config.Concurrency
+}
+
+// getMaxClientLatency - returns client latency pausing healing of a
+// validated config, 0 never pauses.
+func (config healThrottleConfig) getMaxClientLatency() time.Duration {
+	if !config.ClientTrafficFirst {
+		return 0
+	}
+	maxLatency, err := time.ParseDuration(config.MaxClientLatency)
+	if err != nil {
+		return 0
+	}
+	return maxLatency
+}
+
+// parseHealThrottle - parses and validates heal throttle.
+func parseHealThrottle(configBuf []byte) (config healThrottleConfig, err error) {
+	if err = json.Unmarshal(configBuf, &config); err != nil {
+		return healThrottleConfig{}, err
+	}
+	if config.Concurrency < 0 || config.DiskBandwidth < 0 {
+		return healThrottleConfig{}, errors.New("Heal concurrency and disk bandwidth cannot be negative.")
+	}
+	if config.ClientTrafficFirst {
+		maxLatency, err := time.ParseDuration(config.MaxClientLatency)
+		if err != nil {
+			return healThrottleConfig{}, err
+		}
+		if maxLatency <= 0 {
+			return healThrottleConfig{}, errors.New("Maximum client latency has to be positive.")
+		}
+	}
+	return config, nil
+}
+
+// getHealThrottlePath - get heal throttle path.
+func getHealThrottlePath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configPath, healThrottleFile), nil
+}
+
+// readHealThrottle - read heal throttle, healing is not throttled
+// unless configured.
+func readHealThrottle() (healThrottleConfig, error) {
+	configPath, err := getHealThrottlePath()
+	if err != nil {
+		return healThrottleConfig{}, err
+	}
+	configBuf, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return healThrottleConfig{}, nil
+		}
+		return healThrottleConfig{}, err
+	}
+	return parseHealThrottle(configBuf)
+}
+
+// writeHealThrottle - save heal throttle.
+func writeHealThrottle(config healThrottleConfig) error {
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	configPath, err := getHealThrottlePath()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(configPath, configBuf, 0600)
+}
+
+// clientLatencyMonitor - moving average of time to first byte of
+// client requests.
+type clientLatencyMonitor struct {
+	mutex   *sync.Mutex
+	average time.Duration
+	updated time.Time
+}
+
+// Latency of S3 requests served by this node.
+var globalClientLatency = &clientLatencyMonitor{mutex: &sync.Mutex{}}
+
+// record - adds latency of a request to the average.
+func (m *clientLatencyMonitor) record(latency time.Duration) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if time.Since(m.updated) > clientLatencyWindow {
+		m.average = latency
+	} else {
+		m.average += (latency - m.average) / 8
+	}
+	m.updated = time.Now()
+}
+
+// get - returns average latency, zero if no request responded during
+// the window.
+func (m *clientLatencyMonitor) get() time.Duration {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	if time.Since(m.updated) > clientLatencyWindow {
+		return 0
+	}
+	return m.average
+}
+
+// healThrottle - limits objects healed concurrently and disk I/O of
+// healing, adjustable while healing.
+type healThrottle struct {
+	mutex  *sync.Mutex
+	cond   *sync.Cond
+	config healThrottleConfig
+	// Objects currently healed.
+	healing int
+	// Time each disk is free of previous heal I/O at its bandwidth.
+	diskFree map[string]time.Time
+}
+
+// healThrottleStatus - heal throttle along with its effect.
+type healThrottleStatus struct {
+	healThrottleConfig
+	Healing       int           `json:"healing"`
+	Paused        bool          `json:"paused"`
+	ClientLatency time.Duration `json:"clientLatency"`
+}
+
+// newHealThrottle - initialize heal throttle without limits.
+func newHealThrottle() *healThrottle {
+	mutex := &sync.Mutex{}
+	return &healThrottle{
+		mutex:    mutex,
+		cond:     sync.NewCond(mutex),
+		diskFree: make(map[string]time.Time),
+	}
+}
+
+// Throttle of healing by admin requests and scrubbing.
+var globalHealThrottle = newHealThrottle()
+
+// initHealThrottle - loads heal throttle from the config directory.
+func initHealThrottle() error {
+	config, err := readHealThrottle()
+	if err != nil {
+		return err
+	}
+	globalHealThrottle.Set(config)
+	return nil
+}
+
+// Set - replaces the heal throttle, objects currently healed continue
+// with the new limits.
+func (t *healThrottle) Set(config healThrottleConfig) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.config = config
+	t.diskFree = make(map[string]time.Time)
+	t.cond.Broadcast()
+}
+
+// GetStatus - returns the heal throttle and objects currently healed.
+func (t *healThrottle) GetStatus() healThrottleStatus {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return healThrottleStatus{
+		healThrottleConfig: t.config,
+		Healing:            t.healing,
+		Paused:             t.isPaused(),
+		ClientLatency:      globalClientLatency.get(),
+	}
+}
+
+// isPaused - returns true if healing waits for client traffic, called
+// with the mutex held.
+func (t *healThrottle) isPaused() bool {
+	maxLatency := t.config.getMaxClientLatency()
+	return maxLatency > 0 && globalClientLatency.get() > maxLatency
+}
+
+// waitWhilePaused - blocks while healing waits for client traffic,
+// called with the mutex held.
+func (t *healThrottle) waitWhilePaused() {
+	for t.isPaused() {
+		t.mutex.Unlock()
+		time.Sleep(healPauseInterval)
+		t.mutex.Lock()
+	}
+}
+
+// acquire - blocks until an object may be healed. Healing pauses only
+// between objects, objects are locked while healed.
+func (t *healThrottle) acquire() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	for {
+		t.waitWhilePaused()
+		if t.healing < t.config.getConcurrency() {
+			break
+		}
+		t.cond.Wait()
+	}
+	t.healing++
+}
+
+// release - releases an object healed.
+func (t *healThrottle) release() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.healing--
+	t.cond.Signal()
+}
+
+// waitDisk - blocks until size bytes may be read from or written to
+// the disk by healing.
+func (t *healThrottle) waitDisk(disk string, size int64) {
+	t.mutex.Lock()
+	bandwidth := t.config.DiskBandwidth
+	if bandwidth == 0 {
+		t.mutex.Unlock()
+		return
+	}
+	now := time.Now()
+	free := t.diskFree[disk]
+	if free.Before(now) {
+		free = now
+	}
+	t.diskFree[disk] = free.Add(time.Duration(size) * time.Second / time.Duration(bandwidth))
+	t.mutex.Unlock()
+	time.Sleep(free.Sub(now))
+}
+
+// healDisk - disk whose reads and writes by healing are paced to the
+// heal throttle.
+type healDisk struct {
+	StorageAPI
+	name string
+}
+
+// ReadFile - reads from the disk once the heal throttle allows.
+func (d healDisk) ReadFile(volume string, path string, offset int64, buf []byte) (int64, error) {
+	globalHealThrottle.waitDisk(d.name, int64(len(buf)))
+	return d.StorageAPI.ReadFile(volume, path, offset, buf)
+}
+
+// AppendFile - writes to the disk once the heal throttle allows.
+func (d healDisk) AppendFile(volume string, path string, buf []byte) error {
+	globalHealThrottle.waitDisk(d.name, int64(len(buf)))
+	return d.StorageAPI.AppendFile(volume, path, buf)
+}
+
+// getHealDisks - returns disks paced to the heal throttle, offline
+// disks remain nil.
+func getHealDisks(disks []StorageAPI) []StorageAPI {
+	healDisks := make([]StorageAPI, len(disks))
+	for index, disk := range disks {
+		if disk != nil {
+			healDisks[index] = healDisk{StorageAPI: disk, name: getDiskName(disk, index)}
+		}
+	}
+	return healDisks
+}
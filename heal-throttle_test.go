@@ -0,0 +1,159 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests validate parsing of heal throttle.
+func TestParseHealThrottle(t *testing.T) {
+	testCases := []struct {
+		configBuf           string
+		shouldPass          bool
+		expectedConcurrency int
+		expectedMaxLatency  time.Duration
+	}{
+		// Test case - 1.
+		// Not throttled.
+		{`{}`, true, 1, 0},
+		// Test case - 2.
+		{`{"concurrency":4,"diskBandwidth":1048576,"clientTrafficFirst":true,"maxClientLatency":"200ms"}`, true, 4, 200 * time.Millisecond},
+		// Test case - 3.
+		// Client latency is ignored unless client traffic goes first.
+		{`{"maxClientLatency":"200ms"}`, true, 1, 0},
+		// Test case - 4.
+		// Negative concurrency.
+		{`{"concurrency":-1}`, false, 0, 0},
+		// Test case - 5.
+		// Negative bandwidth.
+		{`{"diskBandwidth":-1}`, false, 0, 0},
+		// Test case - 6.
+		// Client traffic first without latency.
+		{`{"clientTrafficFirst":true}`, false, 0, 0},
+		// Test case - 7.
+		// Zero latency.
+		{`{"clientTrafficFirst":true,"maxClientLatency":"0s"}`, false, 0, 0},
+		// Test case - 8.
+		// Malformed document.
+		{`{"concurrency":`, false, 0, 0},
+	}
+	for i, testCase := range testCases {
+		config, err := parseHealThrottle([]byte(testCase.configBuf))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+		if err != nil {
+			continue
+		}
+		if config.getConcurrency() != testCase.expectedConcurrency {
+			t.Errorf("Test %d: Expected concurrency %d, got %d", i+1, testCase.expectedConcurrency, config.getConcurrency())
+		}
+		if config.getMaxClientLatency() != testCase.expectedMaxLatency {
+			t.Errorf("Test %d: Expected max client latency %s, got %s", i+1, testCase.expectedMaxLatency, config.getMaxClientLatency())
+		}
+	}
+}
+
+// Tests objects healed concurrently are limited, raising the limit
+// releases waiting heals.
+func TestHealThrottleConcurrency(t *testing.T) {
+	throttle := newHealThrottle()
+	throttle.Set(healThrottleConfig{Concurrency: 2})
+	throttle.acquire()
+	throttle.acquire()
+
+	acquired := make(chan struct{})
+	go func() {
+		throttle.acquire()
+		close(acquired)
+	}()
+	select {
+	case <-acquired:
+		t.Fatal("Expected heal beyond concurrency to wait")
+	case <-time.After(50 * time.Millisecond):
+	}
+	throttle.Set(healThrottleConfig{Concurrency: 3})
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("Expected heal to proceed once concurrency was raised")
+	}
+	if status := throttle.GetStatus(); status.Healing != 3 {
+		t.Errorf("Expected 3 objects healed, got %d", status.Healing)
+	}
+	throttle.release()
+	if status := throttle.GetStatus(); status.Healing != 2 {
+		t.Errorf("Expected 2 objects healed, got %d", status.Healing)
+	}
+}
+
+// Tests heal I/O of each disk is paced to the bandwidth.
+func TestHealThrottleDiskBandwidth(t *testing.T) {
+	throttle := newHealThrottle()
+	throttle.Set(healThrottleConfig{DiskBandwidth: 1024 * 1024})
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		throttle.waitDisk("disk1", 100*1024)
+	}
+	// Other disks are paced on their own.
+	throttle.waitDisk("disk2", 100*1024)
+	// Third I/O waits for the first two, 200KiB at 1MiB/s.
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Expected heal I/O to take about 200ms, took %s", elapsed)
+	}
+
+	// Unlimited bandwidth never waits.
+	throttle.Set(healThrottleConfig{})
+	start = time.Now()
+	throttle.waitDisk("disk1", 100*1024*1024)
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Expected unlimited heal I/O not to wait, took %s", elapsed)
+	}
+}
+
+// Tests healing pauses while client requests are slow, and resumes
+// once client traffic is gone.
+func TestHealThrottlePause(t *testing.T) {
+	defer func(prevLatency *clientLatencyMonitor) {
+		globalClientLatency = prevLatency
+	}(globalClientLatency)
+	globalClientLatency = &clientLatencyMonitor{mutex: globalClientLatency.mutex}
+
+	throttle := newHealThrottle()
+	throttle.Set(healThrottleConfig{ClientTrafficFirst: true, MaxClientLatency: "100ms"})
+	globalClientLatency.record(50 * time.Millisecond)
+	if throttle.GetStatus().Paused {
+		t.Error("Expected healing not to pause with fast client requests")
+	}
+	for i := 0; i < 20; i++ {
+		globalClientLatency.record(time.Second)
+	}
+	if !throttle.GetStatus().Paused {
+		t.Errorf("Expected healing to pause with client latency %s", globalClientLatency.get())
+	}
+
+	// Without responses during the window, latency is reported as zero.
+	globalClientLatency.updated = time.Now().Add(-2 * clientLatencyWindow)
+	if status := throttle.GetStatus(); status.Paused || status.ClientLatency != 0 {
+		t.Errorf("Expected healing to resume without client traffic, latency %s", status.ClientLatency)
+	}
+}
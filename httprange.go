@@ -19,12 +19,19 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"sort"
 	"strconv"
 	"strings"
 )
 
 const (
 	b = "bytes="
+
+	// Maximum number of ranges of a GET object request.
+	maxRequestedRanges = 100
 )
 
 // InvalidRange - invalid range
@@ -44,6 +51,11 @@ func (r *httpRange) String() string {
 	return fmt.Sprintf("bytes %d-%d/%d", r.start, r.start+r.length-1, r.size)
 }
 
+// isPartial - returns true if the range is a part of the content.
+func (r *httpRange) isPartial() bool {
+	return r != nil && (r.start > 0 || r.length > 0)
+}
+
 // Grab new range from request header
 func getRequestedRange(hrange string, size int64) (*httpRange, error) {
 	r := &httpRange{
@@ -61,6 +73,140 @@ func getRequestedRange(hrange string, size int64) (*httpRange, error) {
 	return r, nil
 }
 
+// byRangeStart - sorts ranges by their start.
+type byRangeStart []*httpRange
+
+func (r byRangeStart) Len() int           { return len(r) }
+func (r byRangeStart) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r byRangeStart) Less(i, j int) bool { return r[i].start < r[j].start }
+
+// getRequestedRanges - grab all ranges from request header. Ranges
+// are sorted, overlapping and adjacent ranges are coalesced and
+// ranges starting at the end of the content are dropped, as allowed
+// by RFC 7233.
+func getRequestedRanges(hrange string, size int64) ([]*httpRange, error) {
+	if !strings.Contains(hrange, ",") {
+		r, err := getRequestedRange(hrange, size)
+		if err != nil {
+			return nil, err
+		}
+		return []*httpRange{r}, nil
+	}
+	if !strings.HasPrefix(hrange, b) {
+		return nil, InvalidRange{}
+	}
+	ras := strings.Split(hrange[len(b):], ",")
+	if len(ras) > maxRequestedRanges {
+		return nil, InvalidRange{}
+	}
+	var ranges []*httpRange
+	for _, ra := range ras {
+		ra = strings.TrimSpace(ra)
+		if ra == "" {
+			continue
+		}
+		r := &httpRange{size: size}
+		if err := r.parse(ra); err != nil {
+			return nil, err
+		}
+		if r.length > 0 {
+			ranges = append(ranges, r)
+		}
+	}
+	if len(ranges) == 0 {
+		return nil, InvalidRange{}
+	}
+	sort.Sort(byRangeStart(ranges))
+	coalesced := []*httpRange{ranges[0]}
+	for _, r := range ranges[1:] {
+		last := coalesced[len(coalesced)-1]
+		if r.start > last.start+last.length {
+			coalesced = append(coalesced, r)
+			continue
+		}
+		if end := r.start + r.length; end > last.start+last.length {
+			last.length = end - last.start
+		}
+	}
+	return coalesced, nil
+}
+
+// planRangeReads - groups sorted ranges sharing a block of blockSize
+// into read spans, each span is read once and every block is read by
+// one span only.
+func planRangeReads(ranges []*httpRange, blockSize int64) [][]*httpRange {
+	var spans [][]*httpRange
+	for _, r := range ranges {
+		if len(spans) > 0 {
+			span := spans[len(spans)-1]
+			last := span[len(span)-1]
+			if (last.start+last.length-1)/blockSize == r.start/blockSize {
+				spans[len(spans)-1] = append(span, r)
+				continue
+			}
+		}
+		spans = append(spans, []*httpRange{r})
+	}
+	return spans
+}
+
+// rangesWriter - writes the bytes of a read span belonging to its
+// ranges as parts of a multipart/byteranges response, bytes between
+// the ranges are skipped.
+type rangesWriter struct {
+	mw          *multipart.Writer
+	contentType string
+	ranges      []*httpRange
+	offset      int64     // Offset in the content of the next byte.
+	part        io.Writer // Part of the current range, nil if not yet created.
+}
+
+// newRangesWriter - initialize a writer of the span starting at the
+// start of its first range.
+func newRangesWriter(mw *multipart.Writer, contentType string, span []*httpRange) *rangesWriter {
+	return &rangesWriter{mw: mw, contentType: contentType, ranges: span, offset: span[0].start}
+}
+
+func (w *rangesWriter) Write(p []byte) (int, error) {
+	written := len(p)
+	for len(p) > 0 && len(w.ranges) > 0 {
+		r := w.ranges[0]
+		if w.offset < r.start {
+			skip := r.start - w.offset
+			if skip > int64(len(p)) {
+				skip = int64(len(p))
+			}
+			p = p[skip:]
+			w.offset += skip
+			continue
+		}
+		if w.part == nil {
+			header := make(textproto.MIMEHeader)
+			header.Set("Content-Type", w.contentType)
+			header.Set("Content-Range", r.String())
+			part, err := w.mw.CreatePart(header)
+			if err != nil {
+				return 0, err
+			}
+			w.part = part
+		}
+		n := r.start + r.length - w.offset
+		if n > int64(len(p)) {
+			n = int64(len(p))
+		}
+		if _, err := w.part.Write(p[:n]); err != nil {
+			return 0, err
+		}
+		p = p[n:]
+		w.offset += n
+		if w.offset == r.start+r.length {
+			w.ranges = w.ranges[1:]
+			w.part = nil
+		}
+	}
+	return written, nil
+}
+
 func (r *httpRange) parse(ra string) error {
 	i := strings.Index(ra, "-")
 	if i < 0 {
@@ -0,0 +1,146 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// Tests parsing and coalescing of multiple ranges.
+func TestGetRequestedRanges(t *testing.T) {
+	testCases := []struct {
+		hrange         string
+		expectedRanges string
+		expectedErr    bool
+	}{
+		// Test case - 1.
+		{"bytes=0-9", "bytes 0-9/100", false},
+		// Test case - 2.
+		{"bytes=0-9,20-29", "bytes 0-9/100,bytes 20-29/100", false},
+		// Test case - 3.
+		{"bytes=20-29,0-9", "bytes 0-9/100,bytes 20-29/100", false},
+		// Test case - 4.
+		{"bytes=0-9,5-14,15-19", "bytes 0-19/100", false},
+		// Test case - 5.
+		{"bytes=90-, -5, 0-0", "bytes 0-0/100,bytes 90-99/100", false},
+		// Test case - 6.
+		{"bytes=100-,0-9", "bytes 0-9/100", false},
+		// Test case - 7.
+		{"bytes=100-,-0", "", true},
+		// Test case - 8.
+		{"bytes=0-9,x", "", true},
+		// Test case - 9.
+		{"bytes=0-9,101-", "", true},
+		// Test case - 10.
+		{"bytes=" + strings.Repeat("0-0,", maxRequestedRanges) + "0-0", "", true},
+	}
+	for i, testCase := range testCases {
+		ranges, err := getRequestedRanges(testCase.hrange, 100)
+		if (err != nil) != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %t, got %v", i+1, testCase.expectedErr, err)
+			continue
+		}
+		var rangeStrs []string
+		for _, r := range ranges {
+			rangeStrs = append(rangeStrs, r.String())
+		}
+		if strings.Join(rangeStrs, ",") != testCase.expectedRanges {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.expectedRanges, strings.Join(rangeStrs, ","))
+		}
+	}
+}
+
+// Tests ranges sharing a block are read together.
+func TestPlanRangeReads(t *testing.T) {
+	ranges := []*httpRange{
+		{start: 0, length: 2, size: 100},
+		{start: 5, length: 2, size: 100},
+		{start: 9, length: 3, size: 100},
+		{start: 30, length: 1, size: 100},
+	}
+	spans := planRangeReads(ranges, 10)
+	expectedSpans := []int{3, 1}
+	if len(spans) != len(expectedSpans) {
+		t.Fatalf("Expected %d spans, got %d", len(expectedSpans), len(spans))
+	}
+	for i, span := range spans {
+		if len(span) != expectedSpans[i] {
+			t.Errorf("Test %d: Expected %d ranges, got %d", i+1, expectedSpans[i], len(span))
+		}
+	}
+}
+
+// Tests GET of multiple ranges returns multipart/byteranges.
+func TestGetObjectMultipleRanges(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+
+	bucket := makeIntegrationBucket(t, client)
+	data := []byte("0123456789abcdefghij")
+	resp, respBody, err := client.do("PUT", bucket, "object", nil, nil, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+
+	resp, respBody, err = client.do("GET", bucket, "object", nil, map[string]string{"Range": "bytes=15-,0-2,1-3"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "GetObject", resp, respBody, http.StatusPartialContent)
+	mediaType, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/byteranges" {
+		t.Fatalf("Expected multipart/byteranges response, got %q", resp.Header.Get("Content-Type"))
+	}
+
+	testCases := []struct {
+		expectedRange string
+		expectedData  string
+	}{
+		// Test case - 1.
+		{"bytes 0-3/20", "0123"},
+		// Test case - 2.
+		{"bytes 15-19/20", "fghij"},
+	}
+	mr := multipart.NewReader(bytes.NewReader(respBody), params["boundary"])
+	for i, testCase := range testCases {
+		part, err := mr.NextPart()
+		if err != nil {
+			t.Fatalf("Test %d: Unable to read part. %v", i+1, err)
+		}
+		partData, err := ioutil.ReadAll(part)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to read part. %v", i+1, err)
+		}
+		if contentRange := part.Header.Get("Content-Range"); contentRange != testCase.expectedRange {
+			t.Errorf("Test %d: Expected range %q, got %q", i+1, testCase.expectedRange, contentRange)
+		}
+		if string(partData) != testCase.expectedData {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expectedData, partData)
+		}
+	}
+	if _, err = mr.NextPart(); err == nil {
+		t.Errorf("Expected %d parts only", len(testCases))
+	}
+}
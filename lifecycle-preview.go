@@ -0,0 +1,323 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// Maximum size of a lifecycle configuration document.
+	maxLifecycleConfigSize = 1 * 1024 * 1024 // 1MiB.
+
+	// Maximum number of rules of a lifecycle configuration.
+	maxLifecycleRules = 1000
+
+	// Number of keys of objects affected by an action listed in
+	// previews.
+	maxLifecyclePreviewSamples = 10
+)
+
+// Status of lifecycle rules.
+const (
+	lifecycleRuleEnabled  = "Enabled"
+	lifecycleRuleDisabled = "Disabled"
+)
+
+// errInvalidLifecycleConfig - lifecycle configuration is malformed.
+var errInvalidLifecycleConfig = errors.New("Invalid lifecycle configuration")
+
+// lifecycleTag - a tag objects must have for a rule to apply.
+type lifecycleTag struct {
+	Key   string
+	Value string
+}
+
+// lifecycleAnd - prefix and tags objects must all match.
+type lifecycleAnd struct {
+	Prefix string
+	Tags   []lifecycleTag `xml:"Tag"`
+}
+
+// lifecycleFilter - either a prefix, a tag, or both combined with And.
+type lifecycleFilter struct {
+	Prefix *string
+	Tag    *lifecycleTag
+	And    *lifecycleAnd
+}
+
+// lifecycleAction - an expiration or transition, applying a number of
+// days after objects were modified or on a date.
+type lifecycleAction struct {
+	Days         int
+	Date         string
+	StorageClass string
+
+	// Date parsed, set by validate.
+	date time.Time
+}
+
+// lifecycleRule - actions applying to the objects matching a filter,
+// Prefix outside of Filter is the legacy form of the filter.
+type lifecycleRule struct {
+	ID          string
+	Status      string
+	Prefix      *string
+	Filter      *lifecycleFilter
+	Expiration  *lifecycleAction
+	Transitions []lifecycleAction `xml:"Transition"`
+
+	// Filter flattened, set by validate.
+	prefix string
+	tags   map[string]string
+}
+
+// lifecycleConfiguration - lifecycle rules of a bucket, in the format
+// of S3.
+type lifecycleConfiguration struct {
+	XMLName xml.Name        `xml:"LifecycleConfiguration"`
+	Rules   []lifecycleRule `xml:"Rule"`
+}
+
+// validate - verifies an action, days are positive and dates are at
+// midnight UTC.
+func (a *lifecycleAction) validate() error {
+	if (a.Days == 0) == (a.Date == "") || a.Days < 0 {
+		return errInvalidLifecycleConfig
+	}
+	if a.Date != "" {
+		date, err := time.Parse(time.RFC3339, a.Date)
+		if err != nil || !date.Equal(date.UTC().Truncate(24*time.Hour)) {
+			return errInvalidLifecycleConfig
+		}
+		a.date = date.UTC()
+	}
+	return nil
+}
+
+// getTime - returns the time the action applies to an object modified
+// at modTime, days are rounded up to the next midnight UTC.
+func (a lifecycleAction) getTime(modTime time.Time) time.Time {
+	if !a.date.IsZero() {
+		return a.date
+	}
+	actionTime := modTime.UTC().Add(time.Duration(a.Days) * 24 * time.Hour)
+	midnight := actionTime.Truncate(24 * time.Hour)
+	if midnight.Before(actionTime) {
+		midnight = midnight.Add(24 * time.Hour)
+	}
+	return midnight
+}
+
+// validate - verifies a rule has a status and at least one action,
+// transitions are to storage classes of the cold tier.
+func (rule *lifecycleRule) validate() error {
+	if len(rule.ID) > 255 {
+		return errInvalidLifecycleConfig
+	}
+	if rule.Status != lifecycleRuleEnabled && rule.Status != lifecycleRuleDisabled {
+		return errInvalidLifecycleConfig
+	}
+	if rule.Expiration == nil && len(rule.Transitions) == 0 {
+		return errInvalidLifecycleConfig
+	}
+	if rule.Expiration != nil {
+		if rule.Expiration.StorageClass != "" {
+			return errInvalidLifecycleConfig
+		}
+		if err := rule.Expiration.validate(); err != nil {
+			return err
+		}
+	}
+	for i := range rule.Transitions {
+		transition := &rule.Transitions[i]
+		if !isColdStorageClass(transition.StorageClass) {
+			return errInvalidLifecycleConfig
+		}
+		if err := transition.validate(); err != nil {
+			return err
+		}
+	}
+
+	// Flatten the filter, only one of its elements may be set.
+	rule.tags = make(map[string]string)
+	switch {
+	case rule.Filter == nil:
+		if rule.Prefix != nil {
+			rule.prefix = *rule.Prefix
+		}
+	case rule.Prefix != nil:
+		return errInvalidLifecycleConfig
+	case rule.Filter.And != nil:
+		if rule.Filter.Prefix != nil || rule.Filter.Tag != nil {
+			return errInvalidLifecycleConfig
+		}
+		rule.prefix = rule.Filter.And.Prefix
+		for _, tag := range rule.Filter.And.Tags {
+			if _, ok := rule.tags[tag.Key]; ok || tag.Key == "" {
+				return errInvalidLifecycleConfig
+			}
+			rule.tags[tag.Key] = tag.Value
+		}
+	case rule.Filter.Tag != nil:
+		if rule.Filter.Prefix != nil || rule.Filter.Tag.Key == "" {
+			return errInvalidLifecycleConfig
+		}
+		rule.tags[rule.Filter.Tag.Key] = rule.Filter.Tag.Value
+	case rule.Filter.Prefix != nil:
+		rule.prefix = *rule.Filter.Prefix
+	}
+	return nil
+}
+
+// match - returns true if the rule applies to the object.
+func (rule lifecycleRule) match(objInfo ObjectInfo) bool {
+	if !strings.HasPrefix(objInfo.Name, rule.prefix) {
+		return false
+	}
+	for key, value := range rule.tags {
+		if tagValue, ok := objInfo.Tags[key]; !ok || tagValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// parseLifecycleConfiguration - parses and validates a lifecycle
+// configuration.
+func parseLifecycleConfiguration(configBuf []byte) (config lifecycleConfiguration, err error) {
+	if err = xml.Unmarshal(configBuf, &config); err != nil {
+		return lifecycleConfiguration{}, errInvalidLifecycleConfig
+	}
+	if len(config.Rules) == 0 || len(config.Rules) > maxLifecycleRules {
+		return lifecycleConfiguration{}, errInvalidLifecycleConfig
+	}
+	ids := make(map[string]bool)
+	for i := range config.Rules {
+		rule := &config.Rules[i]
+		if rule.ID != "" && ids[rule.ID] {
+			return lifecycleConfiguration{}, errInvalidLifecycleConfig
+		}
+		ids[rule.ID] = true
+		if err = rule.validate(); err != nil {
+			return lifecycleConfiguration{}, err
+		}
+	}
+	return config, nil
+}
+
+// lifecycleActionPreview - objects an action applies to, with the
+// first keys of them in lexical order.
+type lifecycleActionPreview struct {
+	Objects    int64    `json:"objects"`
+	Bytes      int64    `json:"bytes"`
+	SampleKeys []string `json:"sampleKeys"`
+}
+
+// add - counts an object, keeping the first keys as samples.
+func (p *lifecycleActionPreview) add(objInfo ObjectInfo) {
+	p.Objects++
+	p.Bytes += objInfo.Size
+	index := sort.SearchStrings(p.SampleKeys, objInfo.Name)
+	if index == maxLifecyclePreviewSamples {
+		return
+	}
+	p.SampleKeys = append(p.SampleKeys, "")
+	copy(p.SampleKeys[index+1:], p.SampleKeys[index:])
+	p.SampleKeys[index] = objInfo.Name
+	if len(p.SampleKeys) > maxLifecyclePreviewSamples {
+		p.SampleKeys = p.SampleKeys[:maxLifecyclePreviewSamples]
+	}
+}
+
+// lifecycleRulePreview - objects a rule would expire and transition,
+// transitions by target storage class.
+type lifecycleRulePreview struct {
+	ID         string                             `json:"id"`
+	Status     string                             `json:"status"`
+	Expire     *lifecycleActionPreview            `json:"expire"`
+	Transition map[string]*lifecycleActionPreview `json:"transition"`
+}
+
+// lifecyclePreview - objects of a bucket each rule of a lifecycle
+// configuration would act on at a time, Building is set while the
+// metadata index is being built and may be missing objects written
+// before the server started.
+type lifecyclePreview struct {
+	Bucket         string                 `json:"bucket"`
+	At             time.Time              `json:"at"`
+	ObjectsScanned int64                  `json:"objectsScanned"`
+	Rules          []lifecycleRulePreview `json:"rules"`
+	Building       bool                   `json:"building"`
+}
+
+// previewLifecycle - evaluates all rules, enabled or not, against the
+// indexed objects of a bucket. An object due for expiration is not
+// transitioned, of transitions due the one applying last is counted
+// unless the object is in its storage class already.
+func (idx *metadataIndex) previewLifecycle(bucket string, config lifecycleConfiguration, at time.Time) lifecyclePreview {
+	preview := lifecyclePreview{
+		Bucket: bucket,
+		At:     at,
+		Rules:  make([]lifecycleRulePreview, len(config.Rules)),
+	}
+	for i, rule := range config.Rules {
+		preview.Rules[i] = lifecycleRulePreview{
+			ID:         rule.ID,
+			Status:     rule.Status,
+			Expire:     &lifecycleActionPreview{SampleKeys: []string{}},
+			Transition: make(map[string]*lifecycleActionPreview),
+		}
+		for _, transition := range rule.Transitions {
+			preview.Rules[i].Transition[transition.StorageClass] = &lifecycleActionPreview{SampleKeys: []string{}}
+		}
+	}
+
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	preview.Building = idx.building
+	for _, objInfo := range idx.buckets[bucket] {
+		preview.ObjectsScanned++
+		for i, rule := range config.Rules {
+			if !rule.match(objInfo) {
+				continue
+			}
+			if rule.Expiration != nil && !rule.Expiration.getTime(objInfo.ModTime).After(at) {
+				preview.Rules[i].Expire.add(objInfo)
+				continue
+			}
+			var due *lifecycleAction
+			for j, transition := range rule.Transitions {
+				transitionTime := transition.getTime(objInfo.ModTime)
+				if transitionTime.After(at) {
+					continue
+				}
+				if due == nil || transitionTime.After(due.getTime(objInfo.ModTime)) {
+					due = &rule.Transitions[j]
+				}
+			}
+			if due != nil && objInfo.UserDefined[storageClassMetaKey] != due.StorageClass {
+				preview.Rules[i].Transition[due.StorageClass].add(objInfo)
+			}
+		}
+	}
+	return preview
+}
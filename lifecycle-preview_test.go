@@ -0,0 +1,160 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Tests parsing and validating lifecycle configurations.
+func TestParseLifecycleConfiguration(t *testing.T) {
+	testCases := []struct {
+		configBuf   string
+		expectedErr error
+	}{
+		// Test case - 1.
+		{`<LifecycleConfiguration><Rule><ID>logs</ID><Prefix>logs/</Prefix><Status>Enabled</Status><Expiration><Days>30</Days></Expiration></Rule></LifecycleConfiguration>`, nil},
+		// Test case - 2.
+		{`<LifecycleConfiguration><Rule><Filter><And><Prefix>tmp/</Prefix><Tag><Key>env</Key><Value>dev</Value></Tag></And></Filter><Status>Disabled</Status><Transition><Days>10</Days><StorageClass>STANDARD_IA</StorageClass></Transition><Transition><Days>20</Days><StorageClass>GLACIER</StorageClass></Transition></Rule></LifecycleConfiguration>`, nil},
+		// Test case - 3.
+		{`<LifecycleConfiguration><Rule><Filter><Tag><Key>env</Key><Value>dev</Value></Tag></Filter><Status>Enabled</Status><Expiration><Date>2016-09-01T00:00:00Z</Date></Expiration></Rule></LifecycleConfiguration>`, nil},
+		// Test case - 4.
+		// No rules.
+		{`<LifecycleConfiguration></LifecycleConfiguration>`, errInvalidLifecycleConfig},
+		// Test case - 5.
+		{`<LifecycleConfiguration><Rule>`, errInvalidLifecycleConfig},
+		// Test case - 6.
+		// Invalid status.
+		{`<LifecycleConfiguration><Rule><Status>On</Status><Expiration><Days>1</Days></Expiration></Rule></LifecycleConfiguration>`, errInvalidLifecycleConfig},
+		// Test case - 7.
+		// No action.
+		{`<LifecycleConfiguration><Rule><Status>Enabled</Status></Rule></LifecycleConfiguration>`, errInvalidLifecycleConfig},
+		// Test case - 8.
+		// Both days and date.
+		{`<LifecycleConfiguration><Rule><Status>Enabled</Status><Expiration><Days>1</Days><Date>2016-09-01T00:00:00Z</Date></Expiration></Rule></LifecycleConfiguration>`, errInvalidLifecycleConfig},
+		// Test case - 9.
+		// Date not at midnight.
+		{`<LifecycleConfiguration><Rule><Status>Enabled</Status><Expiration><Date>2016-09-01T10:00:00Z</Date></Expiration></Rule></LifecycleConfiguration>`, errInvalidLifecycleConfig},
+		// Test case - 10.
+		{`<LifecycleConfiguration><Rule><Status>Enabled</Status><Expiration><Days>-1</Days></Expiration></Rule></LifecycleConfiguration>`, errInvalidLifecycleConfig},
+		// Test case - 11.
+		// Transition to the standard storage class.
+		{`<LifecycleConfiguration><Rule><Status>Enabled</Status><Transition><Days>1</Days><StorageClass>STANDARD</StorageClass></Transition></Rule></LifecycleConfiguration>`, errInvalidLifecycleConfig},
+		// Test case - 12.
+		// Duplicate rule ids.
+		{`<LifecycleConfiguration><Rule><ID>a</ID><Status>Enabled</Status><Expiration><Days>1</Days></Expiration></Rule><Rule><ID>a</ID><Status>Enabled</Status><Expiration><Days>2</Days></Expiration></Rule></LifecycleConfiguration>`, errInvalidLifecycleConfig},
+		// Test case - 13.
+		// Both prefix and filter.
+		{`<LifecycleConfiguration><Rule><Prefix>a</Prefix><Filter><Prefix>b</Prefix></Filter><Status>Enabled</Status><Expiration><Days>1</Days></Expiration></Rule></LifecycleConfiguration>`, errInvalidLifecycleConfig},
+		// Test case - 14.
+		// Filter with both tag and prefix outside of And.
+		{`<LifecycleConfiguration><Rule><Filter><Prefix>b</Prefix><Tag><Key>env</Key><Value>dev</Value></Tag></Filter><Status>Enabled</Status><Expiration><Days>1</Days></Expiration></Rule></LifecycleConfiguration>`, errInvalidLifecycleConfig},
+	}
+	for i, testCase := range testCases {
+		_, err := parseLifecycleConfiguration([]byte(testCase.configBuf))
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+	}
+}
+
+// Tests times actions apply at are rounded up to midnight UTC.
+func TestLifecycleActionTime(t *testing.T) {
+	action := lifecycleAction{Days: 1}
+	modTime := time.Date(2016, time.August, 1, 10, 0, 0, 0, time.UTC)
+	if actionTime := action.getTime(modTime); !actionTime.Equal(time.Date(2016, time.August, 3, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected action at 2016-08-03, got %s", actionTime)
+	}
+	modTime = time.Date(2016, time.August, 1, 0, 0, 0, 0, time.UTC)
+	if actionTime := action.getTime(modTime); !actionTime.Equal(time.Date(2016, time.August, 2, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected action at 2016-08-02, got %s", actionTime)
+	}
+}
+
+// Tests previewing lifecycle rules against the metadata index.
+func TestMetadataIndexPreviewLifecycle(t *testing.T) {
+	modTime := time.Date(2016, time.August, 1, 0, 0, 0, 0, time.UTC)
+	idx := newMetadataIndex()
+	for _, objInfo := range []ObjectInfo{
+		{Bucket: "bucket", Name: "logs/a", Size: 1, ModTime: modTime},
+		{Bucket: "bucket", Name: "logs/b", Size: 2, ModTime: modTime.Add(20 * 24 * time.Hour)},
+		{Bucket: "bucket", Name: "logs/c", Size: 4, ModTime: modTime.Add(20 * 24 * time.Hour), UserDefined: map[string]string{storageClassMetaKey: storageClassGlacier}},
+		{Bucket: "bucket", Name: "logs/d", Size: 8, ModTime: modTime.Add(25 * 24 * time.Hour)},
+		{Bucket: "bucket", Name: "tmp/a", Size: 16, ModTime: modTime, Tags: map[string]string{"env": "dev"}},
+		{Bucket: "bucket", Name: "tmp/b", Size: 32, ModTime: modTime, Tags: map[string]string{"env": "prod"}},
+		{Bucket: "other", Name: "logs/a", Size: 64, ModTime: modTime},
+	} {
+		idx.set(objInfo, false)
+	}
+
+	config, err := parseLifecycleConfiguration([]byte(`<LifecycleConfiguration>` +
+		`<Rule><ID>logs</ID><Prefix>logs/</Prefix><Status>Enabled</Status>` +
+		`<Transition><Days>5</Days><StorageClass>STANDARD_IA</StorageClass></Transition>` +
+		`<Transition><Days>10</Days><StorageClass>GLACIER</StorageClass></Transition>` +
+		`<Expiration><Days>30</Days></Expiration></Rule>` +
+		`<Rule><ID>dev</ID><Filter><And><Prefix>tmp/</Prefix><Tag><Key>env</Key><Value>dev</Value></Tag></And></Filter><Status>Disabled</Status>` +
+		`<Expiration><Date>2016-08-15T00:00:00Z</Date></Expiration></Rule>` +
+		`</LifecycleConfiguration>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// logs/a expires, logs/b moves to GLACIER, logs/c is in GLACIER
+	// already and logs/d moves to STANDARD_IA.
+	at := modTime.Add(32 * 24 * time.Hour)
+	preview := idx.previewLifecycle("bucket", config, at)
+	expectedPreview := lifecyclePreview{
+		Bucket:         "bucket",
+		At:             at,
+		ObjectsScanned: 6,
+		Rules: []lifecycleRulePreview{
+			{
+				ID:     "logs",
+				Status: lifecycleRuleEnabled,
+				Expire: &lifecycleActionPreview{Objects: 1, Bytes: 1, SampleKeys: []string{"logs/a"}},
+				Transition: map[string]*lifecycleActionPreview{
+					storageClassColdIA:  {Objects: 1, Bytes: 8, SampleKeys: []string{"logs/d"}},
+					storageClassGlacier: {Objects: 1, Bytes: 2, SampleKeys: []string{"logs/b"}},
+				},
+			},
+			{
+				ID:         "dev",
+				Status:     lifecycleRuleDisabled,
+				Expire:     &lifecycleActionPreview{Objects: 1, Bytes: 16, SampleKeys: []string{"tmp/a"}},
+				Transition: map[string]*lifecycleActionPreview{},
+			},
+		},
+	}
+	if !reflect.DeepEqual(preview, expectedPreview) {
+		t.Errorf("Expected preview %+v, got %+v", expectedPreview, preview)
+	}
+}
+
+// Tests sample keys are the first keys in lexical order.
+func TestLifecycleActionPreviewSamples(t *testing.T) {
+	preview := &lifecycleActionPreview{}
+	for i := maxLifecyclePreviewSamples + 5; i > 0; i-- {
+		preview.add(ObjectInfo{Name: string('a' + rune(i)), Size: 1})
+	}
+	if preview.Objects != maxLifecyclePreviewSamples+5 || preview.Bytes != maxLifecyclePreviewSamples+5 {
+		t.Errorf("Expected %d objects, got %d objects of %d bytes", maxLifecyclePreviewSamples+5, preview.Objects, preview.Bytes)
+	}
+	if len(preview.SampleKeys) != maxLifecyclePreviewSamples || preview.SampleKeys[0] != "b" {
+		t.Errorf("Expected %d samples from b, got %v", maxLifecyclePreviewSamples, preview.SampleKeys)
+	}
+}
@@ -0,0 +1,242 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	// Extension query parameter of ListObjects, each value restricts
+	// the listing to objects with a matching metadata field.
+	listMetadataFilterParam = "metadata"
+
+	// Extension header of ListObjects, listed objects carry their
+	// metadata if set to "true".
+	listMetadataHeader = "X-Minio-List-Metadata"
+
+	// Maximum number of entries scanned by a filtered listing, beyond
+	// which a truncated listing is returned to be continued from
+	// NextMarker.
+	maxListFilterScan = 100000
+)
+
+// Object metadata fields which can be filtered on, besides user
+// defined metadata.
+var filterableMetadataFields = []string{"Content-Type", "Content-Encoding", "Cache-Control", cseMetadataField}
+
+// errInvalidMetadataFilter - metadata filter is empty or names an
+// unknown field.
+var errInvalidMetadataFilter = errors.New("Invalid metadata filter")
+
+// metadataFilter - matches objects on a metadata field, either having
+// the field at all or having the field set to a value. Tag filters
+// match on a tag of the object instead.
+type metadataFilter struct {
+	Key    string
+	Value  string
+	Exists bool
+	Tag    bool
+}
+
+// parseListFilters - parses metadata and tag filters of a listing.
+func parseListFilters(query url.Values) ([]metadataFilter, error) {
+	filters, err := parseMetadataFilters(query[listMetadataFilterParam])
+	if err != nil {
+		return nil, err
+	}
+	tagFilters, err := parseTagFilters(query[listTagFilterParam])
+	if err != nil {
+		return nil, err
+	}
+	return append(filters, tagFilters...), nil
+}
+
+// parseMetadataFilters - parses values of the form "key" or
+// "key=value".
+func parseMetadataFilters(values []string) ([]metadataFilter, error) {
+	var filters []metadataFilter
+	for _, value := range values {
+		var filter metadataFilter
+		i := strings.Index(value, "=")
+		if i == -1 {
+			filter.Key = value
+			filter.Exists = true
+		} else {
+			filter.Key = value[:i]
+			filter.Value = value[i+1:]
+		}
+		var ok bool
+		if filter.Key, ok = canonicalMetadataKey(filter.Key); !ok {
+			return nil, errInvalidMetadataFilter
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// parseTagFilters - parses values of the form "key" or "key=value",
+// tag keys are matched verbatim.
+func parseTagFilters(values []string) ([]metadataFilter, error) {
+	var filters []metadataFilter
+	for _, value := range values {
+		filter := metadataFilter{Tag: true}
+		i := strings.Index(value, "=")
+		if i == -1 {
+			filter.Key = value
+			filter.Exists = true
+		} else {
+			filter.Key = value[:i]
+			filter.Value = value[i+1:]
+		}
+		if filter.Key == "" {
+			return nil, errInvalidMetadataFilter
+		}
+		filters = append(filters, filter)
+	}
+	return filters, nil
+}
+
+// canonicalMetadataKey - returns canonical header name of a metadata
+// field, keys without a user metadata prefix which are not metadata
+// fields are user metadata "X-Amz-Meta-<key>".
+func canonicalMetadataKey(key string) (string, bool) {
+	key = http.CanonicalHeaderKey(strings.TrimSpace(key))
+	if key == "" || strings.ContainsAny(key, " \t") {
+		return "", false
+	}
+	if !isUserMetadataKey(key) && !isFilterableMetadataField(key) {
+		key = userMetadataPrefixes[0] + key
+	}
+	return key, true
+}
+
+// isFilterableMetadataField - returns true if canonical header name is
+// an object metadata field.
+func isFilterableMetadataField(key string) bool {
+	for _, field := range filterableMetadataFields {
+		if key == field {
+			return true
+		}
+	}
+	return false
+}
+
+// getMetadataField - returns value of a metadata field of the object.
+func getMetadataField(objInfo ObjectInfo, key string) (value string, ok bool) {
+	switch key {
+	case "Content-Type":
+		value = objInfo.ContentType
+	case "Content-Encoding":
+		value = objInfo.ContentEncoding
+	case "Cache-Control":
+		value = objInfo.CacheControl
+	case cseMetadataField:
+		value = getCSEVersion(objInfo.UserDefined)
+	default:
+		value, ok = objInfo.UserDefined[key]
+		return value, ok
+	}
+	return value, value != ""
+}
+
+// matchMetadataFilters - returns true if the object matches all filters.
+func matchMetadataFilters(filters []metadataFilter, objInfo ObjectInfo) bool {
+	for _, filter := range filters {
+		var value string
+		var ok bool
+		if filter.Tag {
+			value, ok = objInfo.Tags[filter.Key]
+		} else {
+			value, ok = getMetadataField(objInfo, filter.Key)
+		}
+		if !ok || (!filter.Exists && value != filter.Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// listEntries - returns objects and prefixes of a listing in lexical
+// order, prefixes are represented as directories.
+func listEntries(result ListObjectsInfo) []ObjectInfo {
+	entries := make([]ObjectInfo, 0, len(result.Objects)+len(result.Prefixes))
+	entries = append(entries, result.Objects...)
+	for _, prefix := range result.Prefixes {
+		entries = append(entries, ObjectInfo{Name: prefix, IsDir: true})
+	}
+	sort.Sort(byObjectInfoName(entries))
+	return entries
+}
+
+// byObjectInfoName is a collection satisfying sort.Interface.
+type byObjectInfoName []ObjectInfo
+
+func (s byObjectInfoName) Len() int           { return len(s) }
+func (s byObjectInfoName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byObjectInfoName) Less(i, j int) bool { return s[i].Name < s[j].Name }
+
+// listObjectsFiltered - lists up to maxKeys objects matching all
+// filters, pages of the object layer listing are filtered until
+// enough objects matched or maxListFilterScan entries were scanned.
+// Common prefixes are not filtered.
+func listObjectsFiltered(objAPI ObjectLayer, bucket, prefix, marker, delimiter string, maxKeys int, filters []metadataFilter) (ListObjectsInfo, error) {
+	if maxKeys == 0 {
+		return objAPI.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	}
+	if maxKeys > maxObjectList {
+		maxKeys = maxObjectList
+	}
+	result := ListObjectsInfo{}
+	var scanned int
+	for {
+		page, err := objAPI.ListObjects(bucket, prefix, marker, delimiter, maxObjectList)
+		if err != nil {
+			return ListObjectsInfo{}, err
+		}
+		entries := listEntries(page)
+		for _, entry := range entries {
+			if entry.IsDir || matchMetadataFilters(filters, entry) {
+				if len(result.Objects)+len(result.Prefixes) == maxKeys {
+					result.IsTruncated = true
+					return result, nil
+				}
+				if entry.IsDir {
+					result.Prefixes = append(result.Prefixes, entry.Name)
+				} else {
+					result.Objects = append(result.Objects, entry)
+				}
+				result.NextMarker = entry.Name
+			}
+			marker = entry.Name
+			scanned++
+		}
+		if !page.IsTruncated || len(entries) == 0 {
+			result.NextMarker = ""
+			return result, nil
+		}
+		if scanned >= maxListFilterScan {
+			result.IsTruncated = true
+			result.NextMarker = marker
+			return result, nil
+		}
+	}
+}
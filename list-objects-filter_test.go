@@ -0,0 +1,293 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+)
+
+// Tests parsing metadata filters of listings.
+func TestParseMetadataFilters(t *testing.T) {
+	testCases := []struct {
+		values          []string
+		expectedFilters []metadataFilter
+		expectedErr     error
+	}{
+		// Test case - 1.
+		{[]string{"env=prod"}, []metadataFilter{{Key: "X-Amz-Meta-Env", Value: "prod"}}, nil},
+		// Test case - 2.
+		// Key only, matches any value.
+		{[]string{"x-amz-meta-owner"}, []metadataFilter{{Key: "X-Amz-Meta-Owner", Exists: true}}, nil},
+		// Test case - 3.
+		// Metadata fields and empty values.
+		{[]string{"content-type=image/png", "x-minio-meta-team="}, []metadataFilter{
+			{Key: "Content-Type", Value: "image/png"},
+			{Key: "X-Minio-Meta-Team", Value: ""},
+		}, nil},
+		// Test case - 4.
+		// Values may contain '='.
+		{[]string{"query=a=b"}, []metadataFilter{{Key: "X-Amz-Meta-Query", Value: "a=b"}}, nil},
+		// Test case - 5.
+		{[]string{"=prod"}, nil, errInvalidMetadataFilter},
+		// Test case - 6.
+		{[]string{"my env=prod"}, nil, errInvalidMetadataFilter},
+		// Test case - 7.
+		{nil, nil, nil},
+	}
+	for i, testCase := range testCases {
+		filters, err := parseMetadataFilters(testCase.values)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+			continue
+		}
+		if !reflect.DeepEqual(filters, testCase.expectedFilters) {
+			t.Errorf("Test %d: Expected filters %v, got %v", i+1, testCase.expectedFilters, filters)
+		}
+	}
+}
+
+// Tests matching objects against metadata filters.
+func TestMatchMetadataFilters(t *testing.T) {
+	objInfo := ObjectInfo{
+		Name:        "object",
+		ContentType: "image/png",
+		UserDefined: map[string]string{
+			"X-Amz-Meta-Env":   "prod",
+			"X-Amz-Meta-Empty": "",
+		},
+	}
+	testCases := []struct {
+		values        []string
+		expectedMatch bool
+	}{
+		// Test case - 1.
+		{[]string{"env=prod"}, true},
+		// Test case - 2.
+		{[]string{"env=dev"}, false},
+		// Test case - 3.
+		// All filters have to match.
+		{[]string{"env=prod", "content-type=image/png"}, true},
+		// Test case - 4.
+		{[]string{"env=prod", "content-type=text/plain"}, false},
+		// Test case - 5.
+		{[]string{"empty"}, true},
+		// Test case - 6.
+		{[]string{"owner"}, false},
+		// Test case - 7.
+		// Unset metadata fields do not exist.
+		{[]string{"cache-control"}, false},
+	}
+	for i, testCase := range testCases {
+		filters, err := parseMetadataFilters(testCase.values)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if match := matchMetadataFilters(filters, objInfo); match != testCase.expectedMatch {
+			t.Errorf("Test %d: Expected match %v, got %v", i+1, testCase.expectedMatch, match)
+		}
+	}
+}
+
+// Tests matching objects against tag filters.
+func TestMatchTagFilters(t *testing.T) {
+	objInfo := ObjectInfo{
+		Name:        "object",
+		UserDefined: map[string]string{"X-Amz-Meta-Env": "dev"},
+		Tags:        map[string]string{"env": "prod", "Team": ""},
+	}
+	testCases := []struct {
+		query         url.Values
+		expectedMatch bool
+	}{
+		// Test case - 1.
+		{url.Values{"tag": {"env=prod"}}, true},
+		// Test case - 2.
+		// Tags are not metadata.
+		{url.Values{"tag": {"env=dev"}}, false},
+		// Test case - 3.
+		{url.Values{"tag": {"Team"}}, true},
+		// Test case - 4.
+		// Tag keys are matched verbatim.
+		{url.Values{"tag": {"team"}}, false},
+		// Test case - 5.
+		// Tag and metadata filters all have to match.
+		{url.Values{"tag": {"env=prod"}, "metadata": {"env=dev"}}, true},
+		// Test case - 6.
+		{url.Values{"tag": {"env=prod"}, "metadata": {"env=prod"}}, false},
+	}
+	for i, testCase := range testCases {
+		filters, err := parseListFilters(testCase.query)
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if match := matchMetadataFilters(filters, objInfo); match != testCase.expectedMatch {
+			t.Errorf("Test %d: Expected match %v, got %v", i+1, testCase.expectedMatch, match)
+		}
+	}
+	if _, err := parseListFilters(url.Values{"tag": {"=prod"}}); err != errInvalidMetadataFilter {
+		t.Errorf("Expected errInvalidMetadataFilter for an empty tag key, got %v", err)
+	}
+}
+
+// Tests listing objects filtered by metadata.
+func TestListObjectsFiltered(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+	bucket := makeIntegrationBucket(t, client)
+
+	objects := []struct {
+		name string
+		env  string
+	}{
+		{"a", "prod"},
+		{"b", "dev"},
+		{"dir/c", "prod"},
+		{"d", "prod"},
+		{"e", ""},
+	}
+	for _, object := range objects {
+		headers := map[string]string{}
+		if object.env != "" {
+			headers["X-Amz-Meta-Env"] = object.env
+		}
+		resp, respBody, err := client.do("PUT", bucket, object.name, nil, headers, []byte("data"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+	}
+
+	testCases := []struct {
+		queryValues       url.Values
+		expectedKeys      []string
+		expectedPrefixes  []string
+		expectedTruncated bool
+	}{
+		// Test case - 1.
+		{url.Values{"metadata": {"env=prod"}}, []string{"a", "d", "dir/c"}, nil, false},
+		// Test case - 2.
+		// Pages are filled with matching objects only.
+		{url.Values{"metadata": {"env=prod"}, "max-keys": {"2"}}, []string{"a", "d"}, nil, true},
+		// Test case - 3.
+		{url.Values{"metadata": {"env=prod"}, "marker": {"d"}}, []string{"dir/c"}, nil, false},
+		// Test case - 4.
+		// Common prefixes are not filtered.
+		{url.Values{"metadata": {"env=dev"}, "delimiter": {"/"}}, []string{"b"}, []string{"dir/"}, false},
+		// Test case - 5.
+		{url.Values{"metadata": {"env"}, "list-type": {"2"}}, []string{"a", "b", "d", "dir/c"}, nil, false},
+		// Test case - 6.
+		{url.Values{"metadata": {"env=staging"}}, nil, nil, false},
+	}
+	for i, testCase := range testCases {
+		resp, respBody, err := client.do("GET", bucket, "", testCase.queryValues, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Test %d: Expected status %d, got %d: %s", i+1, http.StatusOK, resp.StatusCode, respBody)
+		}
+		var result ListObjectsResponse
+		if err = xmlDecoder(bytes.NewReader(respBody), &result); err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		var keys, prefixes []string
+		for _, content := range result.Contents {
+			keys = append(keys, content.Key)
+		}
+		for _, prefix := range result.CommonPrefixes {
+			prefixes = append(prefixes, prefix.Prefix)
+		}
+		if !reflect.DeepEqual(keys, testCase.expectedKeys) {
+			t.Errorf("Test %d: Expected keys %v, got %v", i+1, testCase.expectedKeys, keys)
+		}
+		if !reflect.DeepEqual(prefixes, testCase.expectedPrefixes) {
+			t.Errorf("Test %d: Expected prefixes %v, got %v", i+1, testCase.expectedPrefixes, prefixes)
+		}
+		if result.IsTruncated != testCase.expectedTruncated {
+			t.Errorf("Test %d: Expected truncated %v, got %v", i+1, testCase.expectedTruncated, result.IsTruncated)
+		}
+	}
+
+	// Invalid filters are rejected.
+	resp, respBody, err := client.do("GET", bucket, "", url.Values{"metadata": {"=prod"}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "ListObjects", resp, respBody, http.StatusBadRequest)
+}
+
+// Tests listing objects along with their metadata.
+func TestListObjectsMetadata(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+	bucket := makeIntegrationBucket(t, client)
+
+	headers := map[string]string{
+		"Content-Type":   "text/plain",
+		"X-Amz-Meta-Env": "prod",
+	}
+	resp, respBody, err := client.do("PUT", bucket, "object", nil, headers, []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+
+	expected := &UserMetadata{Items: []MetadataItem{
+		{Key: "Content-Type", Value: "text/plain"},
+		{Key: "X-Amz-Meta-Env", Value: "prod"},
+	}}
+	testCases := []struct {
+		queryValues url.Values
+		headers     map[string]string
+		expected    *UserMetadata
+	}{
+		// Test case - 1.
+		// Metadata is not listed by default.
+		{nil, nil, nil},
+		// Test case - 2.
+		{nil, map[string]string{listMetadataHeader: "true"}, expected},
+		// Test case - 3.
+		{url.Values{"list-type": {"2"}}, map[string]string{listMetadataHeader: "true"}, expected},
+		// Test case - 4.
+		{url.Values{"metadata": {"env=prod"}}, map[string]string{listMetadataHeader: "true"}, expected},
+	}
+	for i, testCase := range testCases {
+		resp, respBody, err = client.do("GET", bucket, "", testCase.queryValues, testCase.headers, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Test %d: Expected status %d, got %d: %s", i+1, http.StatusOK, resp.StatusCode, respBody)
+		}
+		var result ListObjectsResponse
+		if err = xmlDecoder(bytes.NewReader(respBody), &result); err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		if len(result.Contents) != 1 {
+			t.Fatalf("Test %d: Expected 1 object, got %d", i+1, len(result.Contents))
+		}
+		if !reflect.DeepEqual(result.Contents[0].UserMetadata, testCase.expected) {
+			t.Errorf("Test %d: Expected metadata %+v, got %+v", i+1, testCase.expected, result.Contents[0].UserMetadata)
+		}
+	}
+}
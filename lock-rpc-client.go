@@ -0,0 +1,227 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// Leases are renewed well before they expire, a lost renewal is
+	// retried before the lease runs out.
+	lockRefreshInterval = lockLeaseDuration / 3
+
+	// Bounds of the random wait before locking is attempted again,
+	// randomness keeps contending nodes from retrying in lockstep.
+	lockRetryMinInterval = 10 * time.Millisecond
+	lockRetryMaxInterval = 1 * time.Second
+)
+
+// lockNode - lock server of a node, lockServer of this node or an rpc
+// client of a peer.
+type lockNode interface {
+	lock(args *LockArgs) (bool, error)
+	unlock(args *LockArgs) error
+}
+
+// lockRPCClient - rpc client of the lock server of a peer.
+type lockRPCClient struct {
+	rpcClient *rpcClientPool
+}
+
+// newLockRPCClient - initialize a lock rpc client of the peer at addr.
+func newLockRPCClient(addr string) *lockRPCClient {
+	return &lockRPCClient{rpcClient: newRPCClientPool(addr, lockRPCPath)}
+}
+
+// lock - requests the lock from the peer.
+func (c *lockRPCClient) lock(args *LockArgs) (bool, error) {
+	reply := LockReply{}
+	if err := c.rpcClient.Call("Lock.LockHandler", args, &reply); err != nil {
+		return false, err
+	}
+	return reply.Granted, nil
+}
+
+// unlock - releases the lock on the peer.
+func (c *lockRPCClient) unlock(args *LockArgs) error {
+	return c.rpcClient.Call("Lock.UnlockHandler", args, &LockReply{})
+}
+
+// distLocker - locks name space resources on a majority of nodes, no
+// two nodes can hold conflicting locks at the same time.
+type distLocker struct {
+	nodes  []lockNode
+	quorum int
+	// Longest wait for a lock, locks held longer are stuck. Zero
+	// waits forever.
+	timeout time.Duration
+}
+
+// newDistLocker - initialize a locker of the nodes, locks need more
+// than half of them.
+func newDistLocker(nodes []lockNode) *distLocker {
+	return &distLocker{
+		nodes:   nodes,
+		quorum:  len(nodes)/2 + 1,
+		timeout: globalStuckLockTimeout,
+	}
+}
+
+// distLock - a lock held on a majority of nodes, its leases are
+// renewed until unlocked.
+type distLock struct {
+	locker *distLocker
+	args   LockArgs
+	doneCh chan struct{}
+	wg     *sync.WaitGroup
+}
+
+// tryLock - requests the lock from all nodes, returning the nodes
+// which granted it.
+func (d *distLocker) tryLock(args *LockArgs) []bool {
+	granted := make([]bool, len(d.nodes))
+	var wg = &sync.WaitGroup{}
+	for index, node := range d.nodes {
+		wg.Add(1)
+		go func(index int, node lockNode) {
+			defer wg.Done()
+			ok, err := node.lock(args)
+			granted[index] = err == nil && ok
+		}(index, node)
+	}
+	wg.Wait()
+	return granted
+}
+
+// release - releases the lock on the nodes, all nodes if nil. Nodes
+// which cannot be reached release it once the lease expires.
+func (d *distLocker) release(args *LockArgs, nodes []bool) {
+	var wg = &sync.WaitGroup{}
+	for index, node := range d.nodes {
+		if nodes != nil && !nodes[index] {
+			continue
+		}
+		wg.Add(1)
+		go func(node lockNode) {
+			defer wg.Done()
+			node.unlock(args)
+		}(node)
+	}
+	wg.Wait()
+}
+
+// countGranted - returns number of nodes which granted the lock.
+func countGranted(granted []bool) (count int) {
+	for _, ok := range granted {
+		if ok {
+			count++
+		}
+	}
+	return count
+}
+
+// lock - blocks until the lock is held on a majority of nodes, fails
+// with errLockTimeout if it is not held within the timeout.
+func (d *distLocker) lock(volume, path string, readLock bool) (*distLock, error) {
+	token, err := newPeerToken(serverConfig.GetCredential())
+	errorIf(err, "Unable to generate peer token.")
+	args := LockArgs{
+		Token:    token,
+		Volume:   volume,
+		Path:     path,
+		UID:      getUUID(),
+		ReadLock: readLock,
+	}
+	deadline := time.Now().Add(d.timeout)
+	for {
+		granted := d.tryLock(&args)
+		if countGranted(granted) >= d.quorum {
+			break
+		}
+		// Partial grants are given up, holding them could deadlock
+		// against another node holding the rest.
+		d.release(&args, granted)
+		wait := lockRetryMinInterval + time.Duration(rand.Int63n(int64(lockRetryMaxInterval-lockRetryMinInterval)))
+		if d.timeout > 0 {
+			remaining := deadline.Sub(time.Now())
+			if remaining <= 0 {
+				return nil, errLockTimeout
+			}
+			if wait > remaining {
+				wait = remaining
+			}
+		}
+		time.Sleep(wait)
+	}
+	dl := &distLock{
+		locker: d,
+		args:   args,
+		doneCh: make(chan struct{}),
+		wg:     &sync.WaitGroup{},
+	}
+	dl.wg.Add(1)
+	go dl.refresh()
+	return dl, nil
+}
+
+// refresh - renews the leases until unlocked. Nodes which lost the
+// lease, for example after a restart, are granted it again.
+func (dl *distLock) refresh() {
+	defer dl.wg.Done()
+	ticker := time.NewTicker(lockRefreshInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-dl.doneCh:
+			return
+		case <-ticker.C:
+			granted := dl.locker.tryLock(&dl.args)
+			if countGranted(granted) < dl.locker.quorum {
+				errorIf(errLockNotRenewed, "Unable to renew lock of %s/%s on a majority of nodes.", dl.args.Volume, dl.args.Path)
+			}
+		}
+	}
+}
+
+// unlock - stops renewing the leases and releases the lock on all
+// nodes.
+func (dl *distLock) unlock() {
+	close(dl.doneCh)
+	dl.wg.Wait()
+	dl.locker.release(&dl.args, nil)
+}
+
+// getLockNodes - returns lock servers of this node and all its peers.
+func getLockNodes() []lockNode {
+	nodes := []lockNode{globalLockServer}
+	for _, peer := range globalPeers {
+		nodes = append(nodes, newLockRPCClient(peer.addr))
+	}
+	return nodes
+}
+
+// initDistNSLock - locks the name space on this node and all its
+// peers, name space locks stay process local without peers.
+func initDistNSLock() {
+	if len(globalPeers) == 0 {
+		return
+	}
+	nsMutex.setDistLocker(newDistLocker(getLockNodes()))
+}
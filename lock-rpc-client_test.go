@@ -0,0 +1,159 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net"
+	"net/http/httptest"
+	"net/rpc"
+	"testing"
+	"time"
+
+	router "github.com/gorilla/mux"
+)
+
+// Tests locks are held on a majority of nodes, local and over rpc,
+// and conflicting locks wait until released.
+func TestDistLocker(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+
+	// Lock server of a peer.
+	peerServer := newLockServer()
+	lockRPCServer := rpc.NewServer()
+	lockRPCServer.RegisterName("Lock", peerServer)
+	mux := router.NewRouter()
+	mux.Path(lockRPCPath).Handler(lockRPCServer)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	// Address of a peer which is down.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	offlineAddr := listener.Addr().String()
+	listener.Close()
+
+	localServer := newLockServer()
+	nodes := []lockNode{
+		localServer,
+		newLockRPCClient(server.Listener.Addr().String()),
+		newLockRPCClient(offlineAddr),
+	}
+	locker := newDistLocker(nodes)
+	otherLocker := newDistLocker(nodes)
+
+	dl, err := locker.lock("bucket", "object", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(localServer.locks) != 1 || len(peerServer.locks) != 1 {
+		t.Fatalf("Expected lock to be held on both reachable nodes.")
+	}
+
+	// Waiting for a held lock is bounded by the timeout.
+	timeoutLocker := newDistLocker(nodes)
+	timeoutLocker.timeout = 100 * time.Millisecond
+	start := time.Now()
+	if _, err = timeoutLocker.lock("bucket", "object", true); err != errLockTimeout {
+		t.Fatalf("Expected %s, got %v", errLockTimeout, err)
+	}
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Errorf("Expected lock to time out after %s, waited %s", timeoutLocker.timeout, waited)
+	}
+	if len(localServer.locks) != 1 || len(peerServer.locks) != 1 {
+		t.Fatalf("Expected timed out lock to leave no leases behind.")
+	}
+
+	lockedCh := make(chan *distLock)
+	go func() {
+		otherDL, _ := otherLocker.lock("bucket", "object", true)
+		lockedCh <- otherDL
+	}()
+	select {
+	case <-lockedCh:
+		t.Fatalf("Expected read lock to wait for the write lock.")
+	case <-time.After(100 * time.Millisecond):
+	}
+	dl.unlock()
+	var otherDL *distLock
+	select {
+	case otherDL = <-lockedCh:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Expected read lock once the write lock was released.")
+	}
+	// Read locks are shared.
+	readDL, err := locker.lock("bucket", "object", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	readDL.unlock()
+	otherDL.unlock()
+	if len(localServer.locks) != 0 || len(peerServer.locks) != 0 {
+		t.Errorf("Expected all locks to be released.")
+	}
+
+	// A single reachable node is not a majority.
+	minority := newDistLocker([]lockNode{localServer, newLockRPCClient(offlineAddr), newLockRPCClient(offlineAddr)})
+	granted := minority.tryLock(&LockArgs{Volume: "bucket", Path: "object", UID: "a"})
+	if count := countGranted(granted); count >= minority.quorum {
+		t.Errorf("Expected %d grants to be short of quorum %d", count, minority.quorum)
+	}
+}
+
+// Tests name space locks are held on the nodes of the locker until
+// unlocked.
+func TestNamespaceDistLock(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+
+	initNSLock()
+	defer initNSLock()
+	server := newLockServer()
+	nsMutex.setDistLocker(newDistLocker([]lockNode{server}))
+
+	nsMutex.RLock("bucket", "object")
+	nsMutex.RLock("bucket", "object")
+	if leases := server.locks[nsParam{"bucket", "object"}]; len(leases) != 2 {
+		t.Fatalf("Expected 2 read leases, got %d", len(leases))
+	}
+	nsMutex.RUnlock("bucket", "object")
+	nsMutex.RUnlock("bucket", "object")
+	nsMutex.Lock("bucket", "object")
+	if leases := server.locks[nsParam{"bucket", "object"}]; len(leases) != 1 {
+		t.Fatalf("Expected 1 write lease, got %d", len(leases))
+	}
+	nsMutex.Unlock("bucket", "object")
+	if len(server.locks) != 0 || len(nsMutex.lockMap) != 0 {
+		t.Errorf("Expected all locks to be released.")
+	}
+}
@@ -0,0 +1,40 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+// LockArgs represents lock RPC arguments.
+type LockArgs struct {
+	// Authentication token.
+	Token string
+
+	// Name space resource to be locked.
+	Volume string
+	Path   string
+
+	// Unique ID of the lock holder, locking again with the same ID
+	// renews the lease.
+	UID string
+
+	// Read locks are shared, write locks are exclusive.
+	ReadLock bool
+}
+
+// LockReply represents lock RPC reply.
+type LockReply struct {
+	// True if the lock was granted or its lease renewed.
+	Granted bool
+}
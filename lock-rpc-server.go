@@ -0,0 +1,150 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/rpc"
+	"sync"
+	"time"
+
+	router "github.com/gorilla/mux"
+)
+
+const (
+	lockRPCPath = reservedBucket + "/lock"
+
+	// Locks not renewed within their lease are released, holders
+	// which crashed or lost the network do not block others for long.
+	lockLeaseDuration = 30 * time.Second
+)
+
+// lockLease - a lock held by a holder until it expires.
+type lockLease struct {
+	readLock bool
+	expiry   time.Time
+}
+
+// lockServer - grants name space locks with a lease to this node and
+// its peers. Leases are measured with the clock of this node only, so
+// clock skew between nodes does not matter.
+type lockServer struct {
+	mutex *sync.Mutex
+	// Leases of every locked resource by holder ID.
+	locks     map[nsParam]map[string]lockLease
+	lastSweep time.Time
+}
+
+// Lock server of this node.
+var globalLockServer = newLockServer()
+
+// newLockServer - initialize a lock server without any locks.
+func newLockServer() *lockServer {
+	return &lockServer{
+		mutex: &sync.Mutex{},
+		locks: make(map[nsParam]map[string]lockLease),
+	}
+}
+
+// expireLeases - removes expired leases of the resource.
+func (l *lockServer) expireLeases(param nsParam, now time.Time) {
+	for uid, lease := range l.locks[param] {
+		if now.After(lease.expiry) {
+			delete(l.locks[param], uid)
+		}
+	}
+	if len(l.locks[param]) == 0 {
+		delete(l.locks, param)
+	}
+}
+
+// lock - grants the lock if no other holder has a conflicting lease,
+// renews the lease if already held.
+func (l *lockServer) lock(args *LockArgs) (bool, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	// Resources which are never locked again are swept once per
+	// lease.
+	if now.Sub(l.lastSweep) > lockLeaseDuration {
+		for param := range l.locks {
+			l.expireLeases(param, now)
+		}
+		l.lastSweep = now
+	}
+	param := nsParam{args.Volume, args.Path}
+	l.expireLeases(param, now)
+	for uid, lease := range l.locks[param] {
+		if uid == args.UID {
+			continue
+		}
+		if !args.ReadLock || !lease.readLock {
+			return false, nil
+		}
+	}
+	if l.locks[param] == nil {
+		l.locks[param] = make(map[string]lockLease)
+	}
+	l.locks[param][args.UID] = lockLease{
+		readLock: args.ReadLock,
+		expiry:   now.Add(lockLeaseDuration),
+	}
+	return true, nil
+}
+
+// unlock - releases the lock of the holder, if any.
+func (l *lockServer) unlock(args *LockArgs) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	param := nsParam{args.Volume, args.Path}
+	delete(l.locks[param], args.UID)
+	if len(l.locks[param]) == 0 {
+		delete(l.locks, param)
+	}
+	return nil
+}
+
+// LockHandler - lock handler is rpc wrapper to grant or renew a lock.
+func (l *lockServer) LockHandler(args *LockArgs, reply *LockReply) error {
+	if !isPeerTokenValid(args.Token) {
+		return errInvalidToken
+	}
+	granted, err := l.lock(args)
+	if err != nil {
+		return err
+	}
+	reply.Granted = granted
+	return nil
+}
+
+// UnlockHandler - unlock handler is rpc wrapper to release a lock.
+func (l *lockServer) UnlockHandler(args *LockArgs, reply *LockReply) error {
+	if !isPeerTokenValid(args.Token) {
+		return errInvalidToken
+	}
+	return l.unlock(args)
+}
+
+// registerLockRPCRouter - register lock rpc router.
+func registerLockRPCRouter(mux *router.Router) {
+	lockRPCServer := rpc.NewServer()
+	lockRPCServer.RegisterName("Lock", globalLockServer)
+	lockRouter := mux.NewRoute().PathPrefix(reservedBucket).Subrouter()
+	// Add minio lock routes.
+	lockRouter.Path("/lock").Handler(lockRPCServer)
+}
@@ -0,0 +1,131 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests locks are granted unless another holder has a conflicting
+// lease, and leases expire.
+func TestLockServer(t *testing.T) {
+	server := newLockServer()
+	testCases := []struct {
+		uid      string
+		path     string
+		readLock bool
+		unlock   bool
+		granted  bool
+	}{
+		// Test case - 1.
+		{"a", "x", false, false, true},
+		// Test case - 2.
+		// Read lock of a write locked resource.
+		{"b", "x", true, false, false},
+		// Test case - 3.
+		// Lease is renewed by the holder.
+		{"a", "x", false, false, true},
+		// Test case - 4.
+		{"b", "y", false, false, true},
+		// Test case - 5.
+		{"a", "x", false, true, true},
+		// Test case - 6.
+		{"b", "x", true, false, true},
+		// Test case - 7.
+		// Read locks are shared.
+		{"c", "x", true, false, true},
+		// Test case - 8.
+		// Write lock of a read locked resource.
+		{"d", "x", false, false, false},
+	}
+	for i, testCase := range testCases {
+		args := &LockArgs{Volume: "bucket", Path: testCase.path, UID: testCase.uid, ReadLock: testCase.readLock}
+		if testCase.unlock {
+			if err := server.unlock(args); err != nil {
+				t.Errorf("Test %d: Unable to unlock. %v", i+1, err)
+			}
+			continue
+		}
+		granted, err := server.lock(args)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to lock. %v", i+1, err)
+		}
+		if granted != testCase.granted {
+			t.Errorf("Test %d: Expected granted to be %t, got %t", i+1, testCase.granted, granted)
+		}
+	}
+
+	// Expire all leases, a lease later.
+	server.lastSweep = time.Time{}
+	for _, leases := range server.locks {
+		for uid, lease := range leases {
+			lease.expiry = time.Now().Add(-time.Second)
+			leases[uid] = lease
+		}
+	}
+	granted, err := server.lock(&LockArgs{Volume: "bucket", Path: "x", UID: "d"})
+	if err != nil || !granted {
+		t.Fatalf("Expected lock to be granted once leases expired. %v", err)
+	}
+	if len(server.locks) != 1 {
+		t.Errorf("Expected expired leases to be removed, got %d locked resources", len(server.locks))
+	}
+}
+
+// Tests lock rpc handlers refuse invalid tokens.
+func TestLockServerHandlers(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+
+	cred := serverConfig.GetCredential()
+	validToken, err := newPeerToken(cred)
+	if err != nil {
+		t.Fatalf("Unable to generate peer token. %s", err)
+	}
+	invalidToken, err := newPeerToken(credential{cred.AccessKeyID, "invalid-secret-key"})
+	if err != nil {
+		t.Fatalf("Unable to generate peer token. %s", err)
+	}
+
+	server := newLockServer()
+	args := &LockArgs{Token: invalidToken, Volume: "bucket", Path: "object", UID: "a"}
+	if err = server.LockHandler(args, &LockReply{}); err != errInvalidToken {
+		t.Errorf("Expected %s, got %v", errInvalidToken, err)
+	}
+	if err = server.UnlockHandler(args, &LockReply{}); err != errInvalidToken {
+		t.Errorf("Expected %s, got %v", errInvalidToken, err)
+	}
+	args.Token = validToken
+	reply := &LockReply{}
+	if err = server.LockHandler(args, reply); err != nil || !reply.Granted {
+		t.Errorf("Expected lock to be granted. %v", err)
+	}
+	if err = server.UnlockHandler(args, &LockReply{}); err != nil {
+		t.Errorf("Unable to unlock. %v", err)
+	}
+	if len(server.locks) != 0 {
+		t.Errorf("Expected no locked resources, got %d", len(server.locks))
+	}
+}
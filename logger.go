@@ -19,6 +19,7 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"fmt"
 	"os"
 	"reflect"
 	"runtime"
@@ -48,6 +49,44 @@ type logger struct {
 	// Add new loggers here.
 }
 
+// validateLoggers - verifies levels of enabled loggers parse and the
+// log file can be opened.
+func validateLoggers(l logger) error {
+	if l.Console.Enable {
+		if _, err := logrus.ParseLevel(l.Console.Level); err != nil {
+			return fmt.Errorf("Unknown console log level %s.", l.Console.Level)
+		}
+	}
+	if l.File.Enable && l.File.Filename != "" {
+		if _, err := logrus.ParseLevel(l.File.Level); err != nil {
+			return fmt.Errorf("Unknown file log level %s.", l.File.Level)
+		}
+		file, err := os.OpenFile(l.File.Filename, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0666)
+		if err != nil {
+			return fmt.Errorf("Unable to open log file %s (%s).", l.File.Filename, err)
+		}
+		file.Close()
+	}
+	return nil
+}
+
+// reloadLoggers - replaces the logger with one configured from the
+// server config, log files of the previous logger are closed.
+func reloadLoggers() {
+	prevLog := log
+	log = logrus.New()
+	enableLoggers()
+	for _, hooks := range prevLog.Hooks {
+		for _, hook := range hooks {
+			if file, ok := hook.(*localFile); ok {
+				// Hooks are registered for each of their levels,
+				// closing again is harmless.
+				file.Close()
+			}
+		}
+	}
+}
+
 // sysInfo returns useful system statistics.
 func sysInfo() map[string]string {
 	host, err := os.Hostname()
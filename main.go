@@ -107,6 +107,7 @@ func registerApp() *cli.App {
 	registerCommand(serverCmd)
 	registerCommand(versionCmd)
 	registerCommand(updateCmd)
+	registerCommand(compatCmd)
 
 	// Set up app.
 	app := cli.NewApp()
@@ -0,0 +1,605 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dustin/go-humanize"
+)
+
+const (
+	// Maximum size of a metadata query document.
+	maxMetadataQuerySize = 1 * 1024 * 1024 // 1MiB.
+
+	// Maximum number of objects returned per metadata query.
+	maxMetadataQueryResults = 1000
+)
+
+// Fields of indexed objects besides metadata fields.
+const (
+	metadataQueryFieldKey     = "key"
+	metadataQueryFieldSize    = "size"
+	metadataQueryFieldModTime = "modtime"
+)
+
+// Comparison operators of metadata query predicates.
+const (
+	metadataQueryOpEq     = "eq"
+	metadataQueryOpNe     = "ne"
+	metadataQueryOpLt     = "lt"
+	metadataQueryOpLe     = "le"
+	metadataQueryOpGt     = "gt"
+	metadataQueryOpGe     = "ge"
+	metadataQueryOpPrefix = "prefix"
+	metadataQueryOpExists = "exists"
+)
+
+// errInvalidMetadataQuery - metadata query is malformed.
+var errInvalidMetadataQuery = errors.New("Invalid metadata query")
+
+// metadataPredicate - either all of And, any of Or, or comparison of
+// an object field with Value.
+type metadataPredicate struct {
+	And   []*metadataPredicate `json:"and,omitempty"`
+	Or    []*metadataPredicate `json:"or,omitempty"`
+	Field string               `json:"field,omitempty"`
+	Op    string               `json:"op,omitempty"`
+	Value string               `json:"value,omitempty"`
+
+	// Value parsed for the field, set by validate.
+	size    int64
+	modTime time.Time
+}
+
+// validate - verifies the predicate, canonicalizing metadata fields
+// and parsing values of size and modtime comparisons. Sizes may have
+// units, such as "1GB".
+func (p *metadataPredicate) validate() error {
+	if p == nil {
+		return errInvalidMetadataQuery
+	}
+	if len(p.And) > 0 || len(p.Or) > 0 {
+		if p.Field != "" || p.Op != "" || (len(p.And) > 0 && len(p.Or) > 0) {
+			return errInvalidMetadataQuery
+		}
+		for _, child := range append(p.And, p.Or...) {
+			if err := child.validate(); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	switch p.Op {
+	case metadataQueryOpEq, metadataQueryOpNe, metadataQueryOpLt, metadataQueryOpLe, metadataQueryOpGt, metadataQueryOpGe:
+	case metadataQueryOpPrefix, metadataQueryOpExists:
+		if p.Field == metadataQueryFieldSize || p.Field == metadataQueryFieldModTime {
+			return errInvalidMetadataQuery
+		}
+	default:
+		return errInvalidMetadataQuery
+	}
+	switch p.Field {
+	case metadataQueryFieldKey:
+	case metadataQueryFieldSize:
+		size, err := humanize.ParseBytes(p.Value)
+		if err != nil {
+			return errInvalidMetadataQuery
+		}
+		p.size = int64(size)
+	case metadataQueryFieldModTime:
+		modTime, err := time.Parse(time.RFC3339, p.Value)
+		if err != nil {
+			return errInvalidMetadataQuery
+		}
+		p.modTime = modTime
+	default:
+		key, ok := canonicalMetadataKey(p.Field)
+		if !ok {
+			return errInvalidMetadataQuery
+		}
+		p.Field = key
+	}
+	return nil
+}
+
+// compareOp - returns true if the comparison result, as returned by
+// strings.Compare, satisfies the operator.
+func compareOp(op string, cmp int) bool {
+	switch op {
+	case metadataQueryOpEq:
+		return cmp == 0
+	case metadataQueryOpNe:
+		return cmp != 0
+	case metadataQueryOpLt:
+		return cmp < 0
+	case metadataQueryOpLe:
+		return cmp <= 0
+	case metadataQueryOpGt:
+		return cmp > 0
+	case metadataQueryOpGe:
+		return cmp >= 0
+	}
+	return false
+}
+
+// compareInt64 - returns -1, 0 or 1 as a is less than, equal to or
+// greater than b.
+func compareInt64(a, b int64) int {
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// match - returns true if the object satisfies a validated predicate.
+func (p *metadataPredicate) match(objInfo ObjectInfo) bool {
+	if len(p.And) > 0 {
+		for _, child := range p.And {
+			if !child.match(objInfo) {
+				return false
+			}
+		}
+		return true
+	}
+	if len(p.Or) > 0 {
+		for _, child := range p.Or {
+			if child.match(objInfo) {
+				return true
+			}
+		}
+		return false
+	}
+	switch p.Field {
+	case metadataQueryFieldSize:
+		return compareOp(p.Op, compareInt64(objInfo.Size, p.size))
+	case metadataQueryFieldModTime:
+		return compareOp(p.Op, compareInt64(objInfo.ModTime.UnixNano(), p.modTime.UnixNano()))
+	}
+	value, ok := objInfo.Name, true
+	if p.Field != metadataQueryFieldKey {
+		value, ok = getMetadataField(objInfo, p.Field)
+	}
+	switch p.Op {
+	case metadataQueryOpExists:
+		return ok
+	case metadataQueryOpPrefix:
+		return ok && strings.HasPrefix(value, p.Value)
+	}
+	return ok && compareOp(p.Op, strings.Compare(value, p.Value))
+}
+
+// metadataQuery - selects indexed objects of a bucket, or of all
+// buckets if Bucket is empty, matching the predicate.
+type metadataQuery struct {
+	Bucket string             `json:"bucket,omitempty"`
+	Prefix string             `json:"prefix,omitempty"`
+	Where  *metadataPredicate `json:"where,omitempty"`
+	// Sort by key, size or modtime, in asc or desc order, ties are
+	// sorted by bucket and key.
+	SortBy string `json:"sortBy,omitempty"`
+	Order  string `json:"order,omitempty"`
+	// Pagination, marker is the NextMarker of the previous page.
+	MaxResults int    `json:"maxResults,omitempty"`
+	Marker     string `json:"marker,omitempty"`
+
+	// Offset of the page, parsed from marker.
+	offset int
+}
+
+// parseMetadataQuery - parses and validates a metadata query.
+func parseMetadataQuery(queryBuf []byte) (query metadataQuery, err error) {
+	if err = json.Unmarshal(queryBuf, &query); err != nil {
+		return metadataQuery{}, err
+	}
+	if query.Where != nil {
+		if err = query.Where.validate(); err != nil {
+			return metadataQuery{}, err
+		}
+	}
+	switch query.SortBy {
+	case "":
+		query.SortBy = metadataQueryFieldKey
+	case metadataQueryFieldKey, metadataQueryFieldSize, metadataQueryFieldModTime:
+	default:
+		return metadataQuery{}, errInvalidMetadataQuery
+	}
+	switch query.Order {
+	case "":
+		query.Order = "asc"
+	case "asc", "desc":
+	default:
+		return metadataQuery{}, errInvalidMetadataQuery
+	}
+	if query.MaxResults < 0 {
+		return metadataQuery{}, errInvalidMetadataQuery
+	}
+	if query.MaxResults == 0 || query.MaxResults > maxMetadataQueryResults {
+		query.MaxResults = maxMetadataQueryResults
+	}
+	if query.Marker != "" {
+		if query.offset, err = strconv.Atoi(query.Marker); err != nil || query.offset < 0 {
+			return metadataQuery{}, errInvalidMetadataQuery
+		}
+	}
+	return query, nil
+}
+
+// metadataQueryObject - an object returned by a metadata query.
+type metadataQueryObject struct {
+	Bucket          string            `json:"bucket"`
+	Key             string            `json:"key"`
+	Size            int64             `json:"size"`
+	ModTime         time.Time         `json:"modTime"`
+	ETag            string            `json:"etag,omitempty"`
+	ContentType     string            `json:"contentType,omitempty"`
+	ContentEncoding string            `json:"contentEncoding,omitempty"`
+	CacheControl    string            `json:"cacheControl,omitempty"`
+	Metadata        map[string]string `json:"metadata,omitempty"`
+	// Client side encryption version, "v1" or "v2", if encrypted by
+	// the client.
+	ClientSideEncryption string `json:"clientSideEncryption,omitempty"`
+}
+
+// metadataQueryResult - a page of objects matching a metadata query,
+// Building is set while the index is being built and may be missing
+// objects written before the server started.
+type metadataQueryResult struct {
+	Objects     []metadataQueryObject `json:"objects"`
+	IsTruncated bool                  `json:"isTruncated"`
+	NextMarker  string                `json:"nextMarker,omitempty"`
+	Building    bool                  `json:"building"`
+}
+
+// metadataIndex - in-memory index of object metadata of all buckets,
+// kept up to date by indexedObjects.
+type metadataIndex struct {
+	mutex    *sync.RWMutex
+	buckets  map[string]map[string]ObjectInfo
+	building bool
+}
+
+// Metadata index, only populated if enabled via environment setting.
+var globalMetadataIndex = newMetadataIndex()
+
+// newMetadataIndex - initialize an empty metadata index.
+func newMetadataIndex() *metadataIndex {
+	return &metadataIndex{
+		mutex:   &sync.RWMutex{},
+		buckets: make(map[string]map[string]ObjectInfo),
+	}
+}
+
+// set - indexes an object, replacing the previous entry unless
+// onlyIfMissing is set.
+func (idx *metadataIndex) set(objInfo ObjectInfo, onlyIfMissing bool) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	objects, ok := idx.buckets[objInfo.Bucket]
+	if !ok {
+		objects = make(map[string]ObjectInfo)
+		idx.buckets[objInfo.Bucket] = objects
+	}
+	if _, ok = objects[objInfo.Name]; ok && onlyIfMissing {
+		return
+	}
+	objects[objInfo.Name] = objInfo
+}
+
+// remove - removes an object from the index.
+func (idx *metadataIndex) remove(bucket, object string) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	delete(idx.buckets[bucket], object)
+}
+
+// removeBucket - removes all objects of a bucket from the index.
+func (idx *metadataIndex) removeBucket(bucket string) {
+	idx.mutex.Lock()
+	defer idx.mutex.Unlock()
+	delete(idx.buckets, bucket)
+}
+
+// isBuilding - returns true while the index is being built.
+func (idx *metadataIndex) isBuilding() bool {
+	idx.mutex.RLock()
+	defer idx.mutex.RUnlock()
+	return idx.building
+}
+
+// indexBucket - indexes all objects of a bucket, objects already
+// indexed were written meanwhile and are kept.
+func (idx *metadataIndex) indexBucket(objAPI ObjectLayer, bucket string) error {
+	var marker string
+	for {
+		result, err := objAPI.ListObjects(bucket, "", marker, "", maxObjectList)
+		if err != nil {
+			return err
+		}
+		for _, objInfo := range result.Objects {
+			objInfo.Bucket = bucket
+			idx.set(objInfo, true)
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// build - indexes all objects of all buckets, writes are indexed
+// while building.
+func (idx *metadataIndex) build(objAPI ObjectLayer) error {
+	defer func() {
+		idx.mutex.Lock()
+		idx.building = false
+		idx.mutex.Unlock()
+	}()
+
+	bucketsInfo, err := objAPI.ListBuckets()
+	if err != nil {
+		return err
+	}
+	for _, bucketInfo := range bucketsInfo {
+		if err = idx.indexBucket(objAPI, bucketInfo.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// metadataQuerySorter - sorts objects by the field of a query, ties
+// are sorted by bucket and key.
+type metadataQuerySorter struct {
+	objects []ObjectInfo
+	sortBy  string
+}
+
+func (s metadataQuerySorter) Len() int      { return len(s.objects) }
+func (s metadataQuerySorter) Swap(i, j int) { s.objects[i], s.objects[j] = s.objects[j], s.objects[i] }
+func (s metadataQuerySorter) Less(i, j int) bool {
+	a, b := s.objects[i], s.objects[j]
+	var cmp int
+	switch s.sortBy {
+	case metadataQueryFieldSize:
+		cmp = compareInt64(a.Size, b.Size)
+	case metadataQueryFieldModTime:
+		cmp = compareInt64(a.ModTime.UnixNano(), b.ModTime.UnixNano())
+	}
+	if cmp == 0 {
+		cmp = strings.Compare(a.Bucket, b.Bucket)
+	}
+	if cmp == 0 {
+		cmp = strings.Compare(a.Name, b.Name)
+	}
+	return cmp < 0
+}
+
+// query - returns a page of indexed objects matching the query.
+func (idx *metadataIndex) query(query metadataQuery) metadataQueryResult {
+	var matches []ObjectInfo
+	idx.mutex.RLock()
+	building := idx.building
+	for bucket, objects := range idx.buckets {
+		if query.Bucket != "" && bucket != query.Bucket {
+			continue
+		}
+		for _, objInfo := range objects {
+			if !strings.HasPrefix(objInfo.Name, query.Prefix) {
+				continue
+			}
+			if query.Where == nil || query.Where.match(objInfo) {
+				matches = append(matches, objInfo)
+			}
+		}
+	}
+	idx.mutex.RUnlock()
+
+	var sorter sort.Interface = metadataQuerySorter{matches, query.SortBy}
+	if query.Order == "desc" {
+		sorter = sort.Reverse(sorter)
+	}
+	sort.Sort(sorter)
+
+	result := metadataQueryResult{Objects: []metadataQueryObject{}, Building: building}
+	for i := query.offset; i < len(matches); i++ {
+		if len(result.Objects) == query.MaxResults {
+			result.IsTruncated = true
+			result.NextMarker = strconv.Itoa(i)
+			break
+		}
+		objInfo := matches[i]
+		result.Objects = append(result.Objects, metadataQueryObject{
+			Bucket:               objInfo.Bucket,
+			Key:                  objInfo.Name,
+			Size:                 objInfo.Size,
+			ModTime:              objInfo.ModTime,
+			ETag:                 objInfo.MD5Sum,
+			ContentType:          objInfo.ContentType,
+			ContentEncoding:      objInfo.ContentEncoding,
+			CacheControl:         objInfo.CacheControl,
+			Metadata:             objInfo.UserDefined,
+			ClientSideEncryption: getCSEVersion(objInfo.UserDefined),
+		})
+	}
+	return result
+}
+
+// indexedObjects - object layer keeping the metadata index up to date
+// with all writes and deletes.
+type indexedObjects struct {
+	ObjectLayer
+	index *metadataIndex
+}
+
+// newIndexedObjects - wraps an object layer to index its objects.
+func newIndexedObjects(objAPI ObjectLayer, index *metadataIndex) ObjectLayer {
+	return indexedObjects{objAPI, index}
+}
+
+// indexObject - indexes an object after it was written.
+func (o indexedObjects) indexObject(bucket, object string) {
+	objInfo, err := o.ObjectLayer.GetObjectInfo(bucket, object)
+	if err != nil {
+		errorIf(err, "Unable to index object %s/%s.", bucket, object)
+		return
+	}
+	objInfo.Bucket = bucket
+	o.index.set(objInfo, false)
+}
+
+// PutObject - writes an object and indexes it.
+func (o indexedObjects) PutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string) (string, error) {
+	md5, err := o.ObjectLayer.PutObject(bucket, object, size, data, metadata)
+	if err == nil {
+		o.indexObject(bucket, object)
+	}
+	return md5, err
+}
+
+// PatchObject - patches an object and indexes it again.
+func (o indexedObjects) PatchObject(bucket, object string, offset, size int64, data io.Reader) (string, error) {
+	md5, err := o.ObjectLayer.PatchObject(bucket, object, offset, size, data)
+	if err == nil {
+		o.indexObject(bucket, object)
+	}
+	return md5, err
+}
+
+// ComposeObject - composes an object and indexes it.
+func (o indexedObjects) ComposeObject(bucket, object string, sources []string, metadata map[string]string) (string, error) {
+	md5, err := o.ObjectLayer.ComposeObject(bucket, object, sources, metadata)
+	if err == nil {
+		o.indexObject(bucket, object)
+	}
+	return md5, err
+}
+
+// CopyObject - copies an object and indexes the copy.
+func (o indexedObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string, metadata map[string]string) (string, error) {
+	md5, err := o.ObjectLayer.CopyObject(srcBucket, srcObject, dstBucket, dstObject, metadata)
+	if err == nil {
+		o.indexObject(dstBucket, dstObject)
+	}
+	return md5, err
+}
+
+// CompleteMultipartUpload - completes an upload and indexes the object.
+func (o indexedObjects) CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []completePart) (string, error) {
+	md5, err := o.ObjectLayer.CompleteMultipartUpload(bucket, object, uploadID, uploadedParts)
+	if err == nil {
+		o.indexObject(bucket, object)
+	}
+	return md5, err
+}
+
+// PutObjectTags - sets tags of an object and indexes it again.
+func (o indexedObjects) PutObjectTags(bucket, object string, tags map[string]string) error {
+	err := o.ObjectLayer.PutObjectTags(bucket, object, tags)
+	if err == nil {
+		o.indexObject(bucket, object)
+	}
+	return err
+}
+
+// DeleteObjectVersion - deletes a version of an object and indexes
+// the latest version, if any.
+func (o indexedObjects) DeleteObjectVersion(bucket, object, versionID string) error {
+	err := o.ObjectLayer.DeleteObjectVersion(bucket, object, versionID)
+	if err != nil {
+		return err
+	}
+	if _, err = o.ObjectLayer.GetObjectInfo(bucket, object); err != nil {
+		o.index.remove(bucket, object)
+		return nil
+	}
+	o.indexObject(bucket, object)
+	return nil
+}
+
+// DeleteObject - deletes an object and removes it from the index.
+func (o indexedObjects) DeleteObject(bucket, object string) error {
+	err := o.ObjectLayer.DeleteObject(bucket, object)
+	if _, ok := err.(ObjectNotFound); ok || err == nil {
+		o.index.remove(bucket, object)
+	}
+	return err
+}
+
+// DeleteObjects - deletes a batch of objects and removes them from the
+// index.
+func (o indexedObjects) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	errs, err := o.ObjectLayer.DeleteObjects(bucket, objects)
+	if err != nil {
+		return nil, err
+	}
+	for index, object := range objects {
+		if _, ok := errs[index].(ObjectNotFound); ok || errs[index] == nil {
+			o.index.remove(bucket, object)
+		}
+	}
+	return errs, nil
+}
+
+// DeleteBucket - deletes a bucket and removes its objects from the index.
+func (o indexedObjects) DeleteBucket(bucket string) error {
+	err := o.ObjectLayer.DeleteBucket(bucket)
+	if err == nil {
+		o.index.removeBucket(bucket)
+	}
+	return err
+}
+
+// EraseBucket - erases a bucket and removes its objects from the index.
+func (o indexedObjects) EraseBucket(bucket string, overwrite bool) error {
+	err := o.ObjectLayer.EraseBucket(bucket, overwrite)
+	if err == nil {
+		o.index.removeBucket(bucket)
+	}
+	return err
+}
+
+// CloneBucketSnapshot - clones a snapshot and indexes the new bucket.
+func (o indexedObjects) CloneBucketSnapshot(bucket, snapshotID, cloneBucket string) error {
+	if err := o.ObjectLayer.CloneBucketSnapshot(bucket, snapshotID, cloneBucket); err != nil {
+		return err
+	}
+	if err := o.index.indexBucket(o.ObjectLayer, cloneBucket); err != nil {
+		errorIf(err, "Unable to index bucket %s.", cloneBucket)
+	}
+	return nil
+}
+
+// initMetadataIndex - builds the metadata index in the background.
+func initMetadataIndex(objAPI ObjectLayer) {
+	globalMetadataIndex.mutex.Lock()
+	globalMetadataIndex.building = true
+	globalMetadataIndex.mutex.Unlock()
+	go func() {
+		errorIf(globalMetadataIndex.build(objAPI), "Unable to build metadata index.")
+	}()
+}
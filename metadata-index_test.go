@@ -0,0 +1,176 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Tests parsing and validating metadata queries.
+func TestParseMetadataQuery(t *testing.T) {
+	testCases := []struct {
+		queryBuf    string
+		expectedErr error
+	}{
+		// Test case - 1.
+		{`{}`, nil},
+		// Test case - 2.
+		{`{"where":{"and":[{"field":"size","op":"gt","value":"1GB"},{"field":"env","op":"eq","value":"prod"}]}}`, nil},
+		// Test case - 3.
+		{`{"where":{"or":[{"field":"key","op":"prefix","value":"logs/"},{"field":"content-type","op":"exists"}]},"sortBy":"modtime","order":"desc"}`, nil},
+		// Test case - 4.
+		{`{"where":{"field":"modtime","op":"lt","value":"2016-08-01T00:00:00Z"}}`, nil},
+		// Test case - 5.
+		// Predicate with both and, or.
+		{`{"where":{"and":[{"field":"key","op":"eq","value":"a"}],"or":[{"field":"key","op":"eq","value":"b"}]}}`, errInvalidMetadataQuery},
+		// Test case - 6.
+		// Predicate with both and, field.
+		{`{"where":{"and":[{"field":"key","op":"eq","value":"a"}],"field":"key","op":"eq"}}`, errInvalidMetadataQuery},
+		// Test case - 7.
+		{`{"where":{"field":"size","op":"gt","value":"large"}}`, errInvalidMetadataQuery},
+		// Test case - 8.
+		{`{"where":{"field":"size","op":"prefix","value":"1"}}`, errInvalidMetadataQuery},
+		// Test case - 9.
+		{`{"where":{"field":"modtime","op":"gt","value":"yesterday"}}`, errInvalidMetadataQuery},
+		// Test case - 10.
+		{`{"where":{"field":"env","op":"like","value":"prod"}}`, errInvalidMetadataQuery},
+		// Test case - 11.
+		{`{"where":{"field":"","op":"eq","value":"prod"}}`, errInvalidMetadataQuery},
+		// Test case - 12.
+		{`{"where":{"and":[null]}}`, errInvalidMetadataQuery},
+		// Test case - 13.
+		{`{"sortBy":"etag"}`, errInvalidMetadataQuery},
+		// Test case - 14.
+		{`{"order":"random"}`, errInvalidMetadataQuery},
+		// Test case - 15.
+		{`{"marker":"-1"}`, errInvalidMetadataQuery},
+		// Test case - 16.
+		{`{"maxResults":-1}`, errInvalidMetadataQuery},
+	}
+	for i, testCase := range testCases {
+		_, err := parseMetadataQuery([]byte(testCase.queryBuf))
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+	}
+}
+
+// Tests querying the metadata index.
+func TestMetadataIndexQuery(t *testing.T) {
+	modTime := time.Date(2016, time.August, 1, 0, 0, 0, 0, time.UTC)
+	idx := newMetadataIndex()
+	for _, objInfo := range []ObjectInfo{
+		{Bucket: "bucket", Name: "logs/a", Size: 2 << 30, ModTime: modTime, UserDefined: map[string]string{"X-Amz-Meta-Env": "prod"}},
+		{Bucket: "bucket", Name: "logs/b", Size: 512 << 20, ModTime: modTime.Add(time.Hour), UserDefined: map[string]string{"X-Amz-Meta-Env": "prod"}},
+		{Bucket: "bucket", Name: "images/c.png", Size: 3 << 30, ModTime: modTime.Add(2 * time.Hour), ContentType: "image/png", UserDefined: map[string]string{"X-Amz-Meta-Env": "dev"}},
+		{Bucket: "other", Name: "d", Size: 4 << 30, ModTime: modTime.Add(3 * time.Hour), UserDefined: map[string]string{"X-Amz-Meta-Env": "prod"}},
+	} {
+		idx.set(objInfo, false)
+	}
+
+	testCases := []struct {
+		queryBuf           string
+		expectedKeys       []string
+		expectedNextMarker string
+	}{
+		// Test case - 1.
+		// All objects > 1GB tagged env=prod.
+		{`{"where":{"and":[{"field":"size","op":"gt","value":"1GiB"},{"field":"env","op":"eq","value":"prod"}]}}`, []string{"logs/a", "d"}, ""},
+		// Test case - 2.
+		{`{"bucket":"bucket","where":{"or":[{"field":"content-type","op":"eq","value":"image/png"},{"field":"size","op":"lt","value":"1GiB"}]}}`, []string{"images/c.png", "logs/b"}, ""},
+		// Test case - 3.
+		{`{"bucket":"bucket","prefix":"logs/"}`, []string{"logs/a", "logs/b"}, ""},
+		// Test case - 4.
+		{`{"sortBy":"size","order":"desc","maxResults":2}`, []string{"d", "images/c.png"}, "2"},
+		// Test case - 5.
+		{`{"sortBy":"size","order":"desc","maxResults":2,"marker":"2"}`, []string{"logs/a", "logs/b"}, ""},
+		// Test case - 6.
+		{`{"where":{"field":"modtime","op":"ge","value":"2016-08-01T02:00:00Z"},"sortBy":"modtime"}`, []string{"images/c.png", "d"}, ""},
+		// Test case - 7.
+		{`{"where":{"field":"env","op":"ne","value":"prod"}}`, []string{"images/c.png"}, ""},
+		// Test case - 8.
+		{`{"where":{"field":"owner","op":"exists"}}`, []string{}, ""},
+	}
+	for i, testCase := range testCases {
+		query, err := parseMetadataQuery([]byte(testCase.queryBuf))
+		if err != nil {
+			t.Fatalf("Test %d: %v", i+1, err)
+		}
+		result := idx.query(query)
+		keys := []string{}
+		for _, object := range result.Objects {
+			keys = append(keys, object.Key)
+		}
+		if !reflect.DeepEqual(keys, testCase.expectedKeys) {
+			t.Errorf("Test %d: Expected keys %v, got %v", i+1, testCase.expectedKeys, keys)
+		}
+		if result.NextMarker != testCase.expectedNextMarker {
+			t.Errorf("Test %d: Expected next marker %q, got %q", i+1, testCase.expectedNextMarker, result.NextMarker)
+		}
+		if result.IsTruncated != (testCase.expectedNextMarker != "") {
+			t.Errorf("Test %d: Expected truncated %v, got %v", i+1, testCase.expectedNextMarker != "", result.IsTruncated)
+		}
+	}
+}
+
+// Tests the metadata index is built from existing objects and kept up
+// to date with writes.
+func TestIndexedObjects(t *testing.T) {
+	objLayer, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatalf("Initialization of object layer failed for XL setup: %s", err)
+	}
+	defer removeRoots(fsDirs)
+
+	if err = objLayer.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	prodMetadata := map[string]string{"X-Amz-Meta-Env": "prod"}
+	if _, err = objLayer.PutObject("bucket", "existing", 4, bytes.NewReader([]byte("data")), prodMetadata); err != nil {
+		t.Fatal(err)
+	}
+
+	idx := newMetadataIndex()
+	objAPI := newIndexedObjects(objLayer, idx)
+	if err = idx.build(objAPI); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = objAPI.PutObject("bucket", "new", 4, bytes.NewReader([]byte("data")), prodMetadata); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = objAPI.PutObject("bucket", "deleted", 4, bytes.NewReader([]byte("data")), prodMetadata); err != nil {
+		t.Fatal(err)
+	}
+	if err = objAPI.DeleteObject("bucket", "deleted"); err != nil {
+		t.Fatal(err)
+	}
+
+	query, err := parseMetadataQuery([]byte(`{"where":{"field":"env","op":"eq","value":"prod"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, object := range idx.query(query).Objects {
+		keys = append(keys, object.Key)
+	}
+	if expectedKeys := []string{"existing", "new"}; !reflect.DeepEqual(keys, expectedKeys) {
+		t.Errorf("Expected keys %v, got %v", expectedKeys, keys)
+	}
+}
@@ -0,0 +1,538 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Metering config is saved in the config directory.
+	meteringConfigFile = "metering.json"
+
+	// Maximum size of metering config document.
+	maxMeteringConfigSize = 64 * 1024 // 64KiB.
+
+	// Metering records are exported hourly unless configured.
+	defaultMeteringInterval = 1 * time.Hour
+
+	// Shortest interval metering records can be exported at.
+	minMeteringInterval = 1 * time.Minute
+
+	// Supported export formats.
+	meteringFormatCSV  = "csv"
+	meteringFormatJSON = "json"
+
+	// Requests which are anonymous or failed authentication are
+	// counted for this key, it is not a valid access key.
+	meteringAnonymousKey = "(anonymous)"
+)
+
+// Columns of exported CSV records, in order.
+var meteringCSVHeader = []string{"start", "end", "node", "accessKey", "tenant", "requests", "bytesIn", "bytesOut", "bytesStored"}
+
+// meteringConfig - where and how often metering records are exported,
+// to a bucket, an HTTP endpoint or both.
+type meteringConfig struct {
+	// Export interval, for example "15m", defaults to an hour.
+	Interval string `json:"interval,omitempty"`
+	// Format of exported records, "csv" (default) or "json".
+	Format string `json:"format,omitempty"`
+	// Records are saved as objects of this bucket, named with the
+	// prefix.
+	Bucket string `json:"bucket,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+	// Records are POSTed to this URL.
+	URL string `json:"url,omitempty"`
+}
+
+// getInterval - returns export interval of a validated config.
+func (config meteringConfig) getInterval() time.Duration {
+	interval, err := time.ParseDuration(config.Interval)
+	if err != nil {
+		return defaultMeteringInterval
+	}
+	return interval
+}
+
+// parseMeteringConfig - parses and validates metering config.
+func parseMeteringConfig(configBuf []byte) (config meteringConfig, err error) {
+	if err = json.Unmarshal(configBuf, &config); err != nil {
+		return meteringConfig{}, err
+	}
+	if config.Interval != "" {
+		interval, err := time.ParseDuration(config.Interval)
+		if err != nil {
+			return meteringConfig{}, err
+		}
+		if interval < minMeteringInterval {
+			return meteringConfig{}, fmt.Errorf("Metering interval cannot be shorter than %s.", minMeteringInterval)
+		}
+	}
+	switch config.Format {
+	case "":
+		config.Format = meteringFormatCSV
+	case meteringFormatCSV, meteringFormatJSON:
+	default:
+		return meteringConfig{}, errors.New("Unsupported metering format " + config.Format + ".")
+	}
+	if config.Bucket != "" && !IsValidBucketName(config.Bucket) {
+		return meteringConfig{}, errors.New("Invalid metering bucket " + config.Bucket + ".")
+	}
+	if config.URL != "" {
+		u, err := url.Parse(config.URL)
+		if err != nil {
+			return meteringConfig{}, err
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return meteringConfig{}, errors.New("Metering URL must be an http or https URL.")
+		}
+	}
+	return config, nil
+}
+
+// getMeteringConfigPath - get metering config path.
+func getMeteringConfigPath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configPath, meteringConfigFile), nil
+}
+
+// readMeteringConfig - read metering config, records are not exported
+// unless configured.
+func readMeteringConfig() (meteringConfig, error) {
+	configPath, err := getMeteringConfigPath()
+	if err != nil {
+		return meteringConfig{}, err
+	}
+	return readMeteringConfigFile(configPath)
+}
+
+// readMeteringConfigFile - read metering config at configPath.
+func readMeteringConfigFile(configPath string) (meteringConfig, error) {
+	configBuf, err := ioutil.ReadFile(configPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return meteringConfig{Format: meteringFormatCSV}, nil
+		}
+		return meteringConfig{}, err
+	}
+	return parseMeteringConfig(configBuf)
+}
+
+// writeMeteringConfig - save metering config.
+func writeMeteringConfig(config meteringConfig) error {
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	configPath, err := getMeteringConfigPath()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(configPath, configBuf, 0600)
+}
+
+// meteringRecord - usage of an access key on this node during a
+// metering period. Requests and bytes transferred are counted by each
+// node, bytes stored is measured at the end of the period and is the
+// same on all nodes.
+type meteringRecord struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Node        string    `json:"node"`
+	AccessKey   string    `json:"accessKey"`
+	Tenant      string    `json:"tenant"`
+	Requests    int64     `json:"requests"`
+	BytesIn     int64     `json:"bytesIn"`
+	BytesOut    int64     `json:"bytesOut"`
+	BytesStored int64     `json:"bytesStored"`
+}
+
+// meteringCounters - requests and bytes transferred by an access key.
+type meteringCounters struct {
+	Requests int64
+	BytesIn  int64
+	BytesOut int64
+}
+
+// meter - accumulates requests and bytes transferred per access key
+// during the current metering period.
+type meter struct {
+	mutex    *sync.Mutex
+	start    time.Time
+	counters map[string]meteringCounters
+}
+
+// newMeter - starts a metering period.
+func newMeter() *meter {
+	return &meter{
+		mutex:    &sync.Mutex{},
+		start:    time.Now().UTC(),
+		counters: make(map[string]meteringCounters),
+	}
+}
+
+// Meters requests served by this node.
+var globalMeter = newMeter()
+
+// record - accounts a request of the access key.
+func (m *meter) record(accessKey string, bytesIn, bytesOut int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	counters := m.counters[accessKey]
+	counters.Requests++
+	counters.BytesIn += bytesIn
+	counters.BytesOut += bytesOut
+	m.counters[accessKey] = counters
+}
+
+// current - returns start of the current period and its counters.
+func (m *meter) current() (time.Time, map[string]meteringCounters) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	counters := make(map[string]meteringCounters, len(m.counters))
+	for accessKey, c := range m.counters {
+		counters[accessKey] = c
+	}
+	return m.start, counters
+}
+
+// rotate - ends the current period at end and starts the next one,
+// returns start and counters of the ended period.
+func (m *meter) rotate(end time.Time) (time.Time, map[string]meteringCounters) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	start, counters := m.start, m.counters
+	m.start = end
+	m.counters = make(map[string]meteringCounters)
+	return start, counters
+}
+
+// restore - adds back counters of a period which could not be exported,
+// they are exported with the next period.
+func (m *meter) restore(start time.Time, counters map[string]meteringCounters) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.start = start
+	for accessKey, c := range counters {
+		current := m.counters[accessKey]
+		current.Requests += c.Requests
+		current.BytesIn += c.BytesIn
+		current.BytesOut += c.BytesOut
+		m.counters[accessKey] = current
+	}
+}
+
+// meteringRequest - access key a request authenticated with, empty
+// until its authentication succeeded.
+type meteringRequest struct {
+	mutex     *sync.Mutex
+	accessKey string
+}
+
+// Key of the meteringRequest in request contexts.
+type meteringContextKey struct{}
+
+// setRequestAuthenticated - meters the request for the access key its
+// signature or credentials were verified for.
+func setRequestAuthenticated(r *http.Request, accessKey string) {
+	mr, ok := r.Context().Value(meteringContextKey{}).(*meteringRequest)
+	if !ok {
+		return
+	}
+	mr.mutex.Lock()
+	mr.accessKey = accessKey
+	mr.mutex.Unlock()
+}
+
+// getAccessKey - returns access key the request authenticated with,
+// empty if it did not.
+func (mr *meteringRequest) getAccessKey() string {
+	mr.mutex.Lock()
+	defer mr.mutex.Unlock()
+	return mr.accessKey
+}
+
+// getBucketOwnerAccessKey - returns access key the bucket is metered
+// for, buckets of a tenant namespace belong to the tenant.
+func getBucketOwnerAccessKey(bucket string) string {
+	for _, t := range globalTenants.List() {
+		if t.ownsBucket(bucket) {
+			return t.Credential.AccessKeyID
+		}
+	}
+	return readBucketOwner(bucket)
+}
+
+// meteringReader - counts bytes read from a request body.
+type meteringReader struct {
+	io.ReadCloser
+	read int64
+}
+
+func (r *meteringReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.read += int64(n)
+	return n, err
+}
+
+// meteringResponseWriter - counts bytes written to a response.
+type meteringResponseWriter struct {
+	http.ResponseWriter
+	written int64
+}
+
+func (w *meteringResponseWriter) Write(p []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(p)
+	w.written += int64(n)
+	return n, err
+}
+
+// Flush - implements http.Flusher, if supported by the wrapped writer.
+func (w *meteringResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// meteringHandler - meters requests and bytes transferred per access
+// key.
+type meteringHandler struct {
+	handler http.Handler
+}
+
+// setMeteringHandler to meter usage for billing.
+func setMeteringHandler(h http.Handler) http.Handler {
+	return meteringHandler{h}
+}
+
+func (h meteringHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Requests are metered for their access key once served, claimed
+	// access keys are not trusted before authentication.
+	mr := &meteringRequest{mutex: &sync.Mutex{}}
+	r = r.WithContext(context.WithValue(r.Context(), meteringContextKey{}, mr))
+	var body *meteringReader
+	if r.Body != nil {
+		body = &meteringReader{ReadCloser: r.Body}
+		r.Body = body
+	}
+	mw := &meteringResponseWriter{ResponseWriter: w}
+	h.handler.ServeHTTP(mw, r)
+	var bytesIn int64
+	if body != nil {
+		bytesIn = body.read
+	}
+	accessKey := mr.getAccessKey()
+	if accessKey == "" {
+		// Calls between nodes are not metered.
+		if strings.HasPrefix(r.URL.Path, reservedBucket+"/") {
+			return
+		}
+		accessKey = meteringAnonymousKey
+	}
+	globalMeter.record(accessKey, bytesIn, mw.written)
+}
+
+// getStoredBytes - returns bytes stored per access key, buckets are
+// accounted to their owner.
+func getStoredBytes(objAPI ObjectLayer) (map[string]int64, error) {
+	bucketsInfo, err := objAPI.ListBuckets()
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]int64)
+	for _, bucketInfo := range bucketsInfo {
+		_, size, err := getBucketUsage(objAPI, bucketInfo.Name)
+		if err != nil {
+			return nil, err
+		}
+		stored[getBucketOwnerAccessKey(bucketInfo.Name)] += size
+	}
+	return stored, nil
+}
+
+// getMeteringRecords - returns records of the period sorted by access
+// key, access keys storing bytes are included even without requests.
+func getMeteringRecords(start, end time.Time, counters map[string]meteringCounters, stored map[string]int64) []meteringRecord {
+	accessKeys := make(map[string]bool)
+	for accessKey := range counters {
+		accessKeys[accessKey] = true
+	}
+	for accessKey := range stored {
+		accessKeys[accessKey] = true
+	}
+	records := make([]meteringRecord, 0, len(accessKeys))
+	for accessKey := range accessKeys {
+		c := counters[accessKey]
+		record := meteringRecord{
+			Start:       start,
+			End:         end,
+			Node:        globalNodeName,
+			AccessKey:   accessKey,
+			Requests:    c.Requests,
+			BytesIn:     c.BytesIn,
+			BytesOut:    c.BytesOut,
+			BytesStored: stored[accessKey],
+		}
+		if t, ok := globalTenants.GetByAccessKey(accessKey); ok {
+			record.Tenant = t.Name
+		}
+		records = append(records, record)
+	}
+	sort.Sort(meteringRecordsByAccessKey(records))
+	return records
+}
+
+// meteringRecordsByAccessKey - sorts records by access key.
+type meteringRecordsByAccessKey []meteringRecord
+
+func (r meteringRecordsByAccessKey) Len() int           { return len(r) }
+func (r meteringRecordsByAccessKey) Swap(i, j int)      { r[i], r[j] = r[j], r[i] }
+func (r meteringRecordsByAccessKey) Less(i, j int) bool { return r[i].AccessKey < r[j].AccessKey }
+
+// encodeMeteringRecords - encodes records as CSV with a header row, or
+// as JSON lines.
+func encodeMeteringRecords(records []meteringRecord, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	if format == meteringFormatJSON {
+		encoder := json.NewEncoder(&buf)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return nil, err
+			}
+		}
+		return buf.Bytes(), nil
+	}
+	writer := csv.NewWriter(&buf)
+	writer.Write(meteringCSVHeader)
+	for _, record := range records {
+		writer.Write([]string{
+			record.Start.Format(time.RFC3339),
+			record.End.Format(time.RFC3339),
+			record.Node,
+			record.AccessKey,
+			record.Tenant,
+			strconv.FormatInt(record.Requests, 10),
+			strconv.FormatInt(record.BytesIn, 10),
+			strconv.FormatInt(record.BytesOut, 10),
+			strconv.FormatInt(record.BytesStored, 10),
+		})
+	}
+	writer.Flush()
+	return buf.Bytes(), writer.Error()
+}
+
+// getMeteringContentType - returns content type of encoded records.
+func getMeteringContentType(format string) string {
+	if format == meteringFormatJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// getMeteringObjectName - returns name of the object records of a
+// period are saved as, unique per node.
+func getMeteringObjectName(config meteringConfig, start time.Time) string {
+	name := config.Prefix + start.UTC().Format("20060102T150405Z")
+	if globalNodeName != "" {
+		name += "-" + globalNodeName
+	}
+	return name + "." + config.Format
+}
+
+// exportMeteringRecords - saves encoded records to the bucket and POSTs
+// them to the URL of the config.
+func exportMeteringRecords(objAPI ObjectLayer, config meteringConfig, start time.Time, records []meteringRecord) error {
+	recordsBuf, err := encodeMeteringRecords(records, config.Format)
+	if err != nil {
+		return err
+	}
+	contentType := getMeteringContentType(config.Format)
+	if config.Bucket != "" {
+		metadata := map[string]string{"content-type": contentType}
+		object := getMeteringObjectName(config, start)
+		if _, err = objAPI.PutObject(config.Bucket, object, int64(len(recordsBuf)), bytes.NewReader(recordsBuf), metadata); err != nil {
+			return err
+		}
+	}
+	if config.URL != "" {
+		resp, err := http.Post(config.URL, contentType, bytes.NewReader(recordsBuf))
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("metering endpoint %s responded with %s", config.URL, resp.Status)
+		}
+	}
+	return nil
+}
+
+// exportMetering - ends the current metering period and exports its
+// records, records which could not be exported are retried with the
+// next period. Records are dropped if no export is configured.
+func exportMetering(objAPI ObjectLayer, config meteringConfig) error {
+	end := time.Now().UTC()
+	start, counters := globalMeter.rotate(end)
+	if config.Bucket == "" && config.URL == "" {
+		return nil
+	}
+	stored, err := getStoredBytes(objAPI)
+	if err == nil {
+		err = exportMeteringRecords(objAPI, config, start, getMeteringRecords(start, end, counters, stored))
+	}
+	if err != nil {
+		globalMeter.restore(start, counters)
+	}
+	return err
+}
+
+// meteringJob - exports metering records periodically, the config is
+// read from configPath resolved when the job was started.
+func meteringJob(objAPI ObjectLayer, configPath string) {
+	for {
+		config, err := readMeteringConfigFile(configPath)
+		if err != nil {
+			errorIf(err, "Unable to read metering config.")
+		}
+		time.Sleep(config.getInterval())
+		if config, err = readMeteringConfigFile(configPath); err != nil {
+			errorIf(err, "Unable to read metering config.")
+			continue
+		}
+		errorIf(exportMetering(objAPI, config), "Unable to export metering records.")
+	}
+}
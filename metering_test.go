@@ -0,0 +1,211 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Tests validate parsing of metering config.
+func TestParseMeteringConfig(t *testing.T) {
+	testCases := []struct {
+		configBuf      string
+		shouldPass     bool
+		expectedFormat string
+	}{
+		// Test case - 1.
+		// Format defaults to csv.
+		{`{}`, true, meteringFormatCSV},
+		// Test case - 2.
+		{`{"interval":"15m","format":"json","bucket":"billing","prefix":"metering/","url":"https://billing.example.com/minio"}`, true, meteringFormatJSON},
+		// Test case - 3.
+		// Interval shorter than the minimum.
+		{`{"interval":"10s"}`, false, ""},
+		// Test case - 4.
+		// Malformed interval.
+		{`{"interval":"hourly"}`, false, ""},
+		// Test case - 5.
+		// Unsupported format.
+		{`{"format":"xml"}`, false, ""},
+		// Test case - 6.
+		// Invalid bucket name.
+		{`{"bucket":"ab"}`, false, ""},
+		// Test case - 7.
+		// URL which is not http.
+		{`{"url":"ftp://billing.example.com"}`, false, ""},
+		// Test case - 8.
+		// Malformed document.
+		{`{"interval":`, false, ""},
+	}
+	for i, testCase := range testCases {
+		config, err := parseMeteringConfig([]byte(testCase.configBuf))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+		if err == nil && config.Format != testCase.expectedFormat {
+			t.Errorf("Test %d: Expected format %s, got %s", i+1, testCase.expectedFormat, config.Format)
+		}
+	}
+}
+
+// Tests encoding of metering records as CSV and JSON lines.
+func TestEncodeMeteringRecords(t *testing.T) {
+	start := time.Date(2016, time.July, 1, 10, 0, 0, 0, time.UTC)
+	records := []meteringRecord{{
+		Start:       start,
+		End:         start.Add(time.Hour),
+		Node:        "10.0.0.1:9000",
+		AccessKey:   "ACMEACCESSKEY",
+		Tenant:      "acme",
+		Requests:    3,
+		BytesIn:     100,
+		BytesOut:    200,
+		BytesStored: 300,
+	}}
+
+	testCases := []struct {
+		format   string
+		expected string
+	}{
+		// Test case - 1.
+		{meteringFormatCSV, "start,end,node,accessKey,tenant,requests,bytesIn,bytesOut,bytesStored\n" +
+			"2016-07-01T10:00:00Z,2016-07-01T11:00:00Z,10.0.0.1:9000,ACMEACCESSKEY,acme,3,100,200,300\n"},
+		// Test case - 2.
+		{meteringFormatJSON, `{"start":"2016-07-01T10:00:00Z","end":"2016-07-01T11:00:00Z","node":"10.0.0.1:9000","accessKey":"ACMEACCESSKEY","tenant":"acme","requests":3,"bytesIn":100,"bytesOut":200,"bytesStored":300}` + "\n"},
+	}
+	for i, testCase := range testCases {
+		recordsBuf, err := encodeMeteringRecords(records, testCase.format)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to encode records. %s", i+1, err)
+		}
+		if string(recordsBuf) != testCase.expected {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expected, string(recordsBuf))
+		}
+	}
+}
+
+// Tests requests are metered and exported to a bucket and to an HTTP
+// endpoint, failed exports are retried with the next period.
+func TestMeteringExport(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+	objAPI, err := newObjectLayer(testServer.Disks)
+	if err != nil {
+		t.Fatalf("Unable to initialize object layer. %s", err)
+	}
+
+	// Start a new period for this test.
+	globalMeter.rotate(time.Now().UTC())
+	bucket := makeIntegrationBucket(t, client)
+	data := []byte("metered object")
+	resp, respBody, err := client.do("PUT", bucket, "object", nil, nil, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+	resp, respBody, err = client.do("GET", bucket, "object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "GetObject", resp, respBody, http.StatusOK)
+
+	// Requests failing authentication are not counted for the access
+	// key they claim, they are counted with anonymous requests.
+	invalidClient := client
+	invalidClient.secretKey = "invalidsecretkey"
+	resp, respBody, err = invalidClient.do("PUT", bucket, "invalid", nil, nil, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject invalid signature", resp, respBody, http.StatusForbidden)
+	resp, err = http.Get(makeTestTargetURL(client.endpoint, bucket, "object", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("Expected anonymous GetObject to be denied, got %d", resp.StatusCode)
+	}
+
+	_, counters := globalMeter.current()
+	c := counters[testServer.AccessKey]
+	if c.Requests != 3 || c.BytesIn != int64(len(data)) || c.BytesOut < int64(len(data)) {
+		t.Errorf("Unexpected counters %v", c)
+	}
+	if anonymous := counters[meteringAnonymousKey]; anonymous.Requests != 2 {
+		t.Errorf("Expected 2 anonymous requests, got %v", anonymous)
+	}
+
+	var received []meteringRecord
+	endpointStatus := http.StatusInternalServerError
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+			var record meteringRecord
+			if err := json.Unmarshal([]byte(line), &record); err == nil {
+				received = append(received, record)
+			}
+		}
+		w.WriteHeader(endpointStatus)
+	}))
+	defer endpoint.Close()
+
+	config := meteringConfig{Format: meteringFormatJSON, Bucket: bucket, Prefix: "metering/", URL: endpoint.URL}
+	// Failed export keeps the counters.
+	if err = exportMetering(objAPI, config); err == nil {
+		t.Fatalf("Expected export to fail")
+	}
+	if _, counters = globalMeter.current(); counters[testServer.AccessKey].Requests < c.Requests {
+		t.Errorf("Expected counters to be kept after failed export, got %v", counters[testServer.AccessKey])
+	}
+
+	endpointStatus = http.StatusOK
+	received = nil
+	if err = exportMetering(objAPI, config); err != nil {
+		t.Fatalf("Unable to export metering records. %s", err)
+	}
+	var record meteringRecord
+	for _, r := range received {
+		if r.AccessKey == testServer.AccessKey {
+			record = r
+		}
+	}
+	if record.Requests < c.Requests || record.BytesStored < int64(len(data)) {
+		t.Errorf("Unexpected exported record %v", record)
+	}
+	result, err := objAPI.ListObjects(bucket, "metering/", "", "", 10)
+	if err != nil {
+		t.Fatalf("Unable to list exported records. %s", err)
+	}
+	// Retried export replaces records saved by the failed export.
+	if len(result.Objects) != 1 {
+		t.Errorf("Expected records of the period to be saved once in the bucket, got %d objects", len(result.Objects))
+	}
+	if _, counters = globalMeter.current(); len(counters) != 0 {
+		t.Errorf("Expected a new period after export, got %v", counters)
+	}
+}
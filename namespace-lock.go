@@ -18,7 +18,11 @@ package main
 
 import (
 	"errors"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 )
 
 // nsParam - carries name space resource.
@@ -27,10 +31,52 @@ type nsParam struct {
 	path   string
 }
 
+// nsLockHolder - a holder of a name space lock, named after the
+// function which locked it.
+type nsLockHolder struct {
+	caller   string
+	readLock bool
+	since    time.Time
+	// Distributed lock of the holder, nil if process local.
+	distLock *distLock
+	// Set once force released, the lock is no longer held but the
+	// holder is yet to unlock.
+	forced bool
+	// Set once reported as held past the stuck lock timeout.
+	reported bool
+}
+
 // nsLock - provides primitives for locking critical namespace regions.
 type nsLock struct {
 	*sync.RWMutex
 	ref uint
+	// Holders of the lock, either readers or a single writer, along
+	// with holders force released which are yet to unlock.
+	holders []*nsLockHolder
+}
+
+// nsLockStats - contention statistics of name space locks.
+type nsLockStats struct {
+	Acquired      int64         `json:"acquired"`
+	WaitTime      time.Duration `json:"waitTime"`
+	MaxWaitTime   time.Duration `json:"maxWaitTime"`
+	Released      int64         `json:"released"`
+	HeldTime      time.Duration `json:"heldTime"`
+	MaxHeldTime   time.Duration `json:"maxHeldTime"`
+	ForceReleased int64         `json:"forceReleased"`
+}
+
+// nsLockInfo - a held name space lock.
+type nsLockInfo struct {
+	Volume        string        `json:"volume"`
+	Path          string        `json:"path"`
+	ReadLock      bool          `json:"readLock"`
+	Holder        string        `json:"holder"`
+	Since         time.Time     `json:"since"`
+	Duration      time.Duration `json:"duration"`
+	ForceReleased bool          `json:"forceReleased,omitempty"`
+	// Lockers waiting for the resource.
+	Waiting int `json:"waiting"`
 }
 
 // nsLockMap - namespace lock map, provides primitives to Lock,
@@ -38,21 +84,53 @@ type nsLock struct {
 type nsLockMap struct {
 	lockMap map[nsParam]*nsLock
 	mutex   *sync.Mutex
+	// Locks resources on peers as well, nil if process local.
+	distLocker *distLocker
+	stats      nsLockStats
 }
 
 // Global name space lock.
 var nsMutex *nsLockMap
 
-// initNSLock - initialize name space lock map.
-func initNSLock() {
-	nsMutex = &nsLockMap{
+// newNSLockMap - initialize a name space lock map.
+func newNSLockMap() *nsLockMap {
+	return &nsLockMap{
 		lockMap: make(map[nsParam]*nsLock),
 		mutex:   &sync.Mutex{},
 	}
 }
 
+// initNSLock - initialize name space lock map.
+func initNSLock() {
+	nsMutex = newNSLockMap()
+}
+
+// setDistLocker - locks resources on the nodes of the locker as well.
+func (n *nsLockMap) setDistLocker(locker *distLocker) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.distLocker = locker
+}
+
+// getLockCaller - returns name of the function calling Lock, Unlock,
+// RLock or RUnlock, without its package.
+func getLockCaller() string {
+	pc, _, _, ok := runtime.Caller(2)
+	if !ok {
+		return ""
+	}
+	fn := runtime.FuncForPC(pc)
+	if fn == nil {
+		return ""
+	}
+	name := fn.Name()
+	name = name[strings.LastIndex(name, "/")+1:]
+	return name[strings.Index(name, ".")+1:]
+}
+
 // Lock the namespace resource.
-func (n *nsLockMap) lock(volume, path string, readLock bool) {
+func (n *nsLockMap) lock(volume, path string, readLock bool, caller string) {
+	start := time.Now()
 	n.mutex.Lock()
 
 	param := nsParam{volume, path}
@@ -65,6 +143,7 @@ func (n *nsLockMap) lock(volume, path string, readLock bool) {
 		n.lockMap[param] = nsLk
 	}
 	nsLk.ref++ // Update ref count here to avoid multiple races.
+	distLocker := n.distLocker
 	// Unlock map before Locking NS which might block.
 	n.mutex.Unlock()
 
@@ -74,20 +153,91 @@ func (n *nsLockMap) lock(volume, path string, readLock bool) {
 	} else {
 		nsLk.Lock()
 	}
+	holder := &nsLockHolder{caller: caller, readLock: readLock}
+	if distLocker != nil {
+		// Local holders contend on peers only once they hold the
+		// local lock. Peers holding the lock past the stuck lock
+		// timeout leave it held on this node only.
+		var err error
+		holder.distLock, err = distLocker.lock(volume, path, readLock)
+		errorIf(err, "Unable to lock %s/%s on a majority of nodes.", volume, path)
+	}
+	holder.since = time.Now()
+
+	n.mutex.Lock()
+	nsLk.holders = append(nsLk.holders, holder)
+	wait := holder.since.Sub(start)
+	n.stats.Acquired++
+	n.stats.WaitTime += wait
+	if wait > n.stats.MaxWaitTime {
+		n.stats.MaxWaitTime = wait
+	}
+	n.mutex.Unlock()
+}
+
+// removeHolder - removes the oldest holder of the lock, preferring
+// holders locked by the caller over others and holders not force
+// released over those force released. Returns nil if none.
+func (nsLk *nsLock) removeHolder(caller string, readLock bool) *nsLockHolder {
+	index, bestRank := -1, 4
+	for i, holder := range nsLk.holders {
+		if holder.readLock != readLock {
+			continue
+		}
+		rank := 0
+		if holder.caller != caller {
+			rank += 2
+		}
+		if holder.forced {
+			rank++
+		}
+		if rank < bestRank {
+			index, bestRank = i, rank
+		}
+	}
+	if index == -1 {
+		return nil
+	}
+	holder := nsLk.holders[index]
+	nsLk.holders = append(nsLk.holders[:index], nsLk.holders[index+1:]...)
+	return holder
 }
 
 // Unlock the namespace resource.
-func (n *nsLockMap) unlock(volume, path string, readLock bool) {
+func (n *nsLockMap) unlock(volume, path string, readLock bool, caller string) {
+	param := nsParam{volume, path}
+	n.mutex.Lock()
+	var holder *nsLockHolder
+	if nsLk, found := n.lockMap[param]; found {
+		holder = nsLk.removeHolder(caller, readLock)
+	}
+	n.mutex.Unlock()
+
+	// Releasing on peers is done without holding the map.
+	if holder != nil && holder.distLock != nil {
+		holder.distLock.unlock()
+	}
+
 	// nsLk.Unlock() will not block, hence locking the map for the entire function is fine.
 	n.mutex.Lock()
 	defer n.mutex.Unlock()
 
-	param := nsParam{volume, path}
 	if nsLk, found := n.lockMap[param]; found {
-		if readLock {
-			nsLk.RUnlock()
-		} else {
-			nsLk.Unlock()
+		// Locks force released are no longer held.
+		if holder == nil || !holder.forced {
+			if readLock {
+				nsLk.RUnlock()
+			} else {
+				nsLk.Unlock()
+			}
+		}
+		if holder != nil && !holder.forced {
+			held := time.Since(holder.since)
+			n.stats.Released++
+			n.stats.HeldTime += held
+			if held > n.stats.MaxHeldTime {
+				n.stats.MaxHeldTime = held
+			}
 		}
 		if nsLk.ref == 0 {
 			errorIf(errors.New("Namespace reference count cannot be 0."), "Invalid reference count detected.")
@@ -106,23 +256,135 @@ func (n *nsLockMap) unlock(volume, path string, readLock bool) {
 // allocated name space lock or initializing a new one.
 func (n *nsLockMap) Lock(volume, path string) {
 	readLock := false
-	n.lock(volume, path, readLock)
+	n.lock(volume, path, readLock, getLockCaller())
 }
 
 // Unlock - unlocks any previously acquired write locks.
 func (n *nsLockMap) Unlock(volume, path string) {
 	readLock := false
-	n.unlock(volume, path, readLock)
+	n.unlock(volume, path, readLock, getLockCaller())
 }
 
 // RLock - locks any previously acquired read locks.
 func (n *nsLockMap) RLock(volume, path string) {
 	readLock := true
-	n.lock(volume, path, readLock)
+	n.lock(volume, path, readLock, getLockCaller())
 }
 
 // RUnlock - unlocks any previously acquired read locks.
 func (n *nsLockMap) RUnlock(volume, path string) {
 	readLock := true
-	n.unlock(volume, path, readLock)
+	n.unlock(volume, path, readLock, getLockCaller())
+}
+
+// byLockSince - sorts locks held longest first.
+type byLockSince []nsLockInfo
+
+func (l byLockSince) Len() int           { return len(l) }
+func (l byLockSince) Swap(i, j int)      { l[i], l[j] = l[j], l[i] }
+func (l byLockSince) Less(i, j int) bool { return l[i].Since.Before(l[j].Since) }
+
+// newNSLockInfo - returns lock info of a holder of the resource.
+func newNSLockInfo(param nsParam, nsLk *nsLock, holder *nsLockHolder, now time.Time) nsLockInfo {
+	return nsLockInfo{
+		Volume:        param.volume,
+		Path:          param.path,
+		ReadLock:      holder.readLock,
+		Holder:        holder.caller,
+		Since:         holder.since.UTC(),
+		Duration:      now.Sub(holder.since),
+		ForceReleased: holder.forced,
+		Waiting:       int(nsLk.ref) - len(nsLk.holders),
+	}
+}
+
+// getStats - returns contention statistics of all locks so far.
+func (n *nsLockMap) getStats() nsLockStats {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	return n.stats
+}
+
+// getLocks - returns all held locks and locks force released whose
+// holders are yet to unlock, held longest first.
+func (n *nsLockMap) getLocks() []nsLockInfo {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	now := time.Now()
+	locks := []nsLockInfo{}
+	for param, nsLk := range n.lockMap {
+		for _, holder := range nsLk.holders {
+			locks = append(locks, newNSLockInfo(param, nsLk, holder, now))
+		}
+	}
+	sort.Sort(byLockSince(locks))
+	return locks
+}
+
+// getStuckLocks - returns locks held longer than timeout which were not
+// returned before.
+func (n *nsLockMap) getStuckLocks(timeout time.Duration) []nsLockInfo {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	now := time.Now()
+	var locks []nsLockInfo
+	for param, nsLk := range n.lockMap {
+		for _, holder := range nsLk.holders {
+			if holder.forced || holder.reported || now.Sub(holder.since) < timeout {
+				continue
+			}
+			holder.reported = true
+			locks = append(locks, newNSLockInfo(param, nsLk, holder, now))
+		}
+	}
+	sort.Sort(byLockSince(locks))
+	return locks
+}
+
+// forceRelease - releases locks held longer than olderThan on behalf of
+// their holders, whose unlock is then ignored. Returns the locks
+// released, held longest first.
+func (n *nsLockMap) forceRelease(olderThan time.Duration) []nsLockInfo {
+	n.mutex.Lock()
+	now := time.Now()
+	locks := []nsLockInfo{}
+	var distLocks []*distLock
+	for param, nsLk := range n.lockMap {
+		for _, holder := range nsLk.holders {
+			if holder.forced || now.Sub(holder.since) < olderThan {
+				continue
+			}
+			holder.forced = true
+			if holder.readLock {
+				nsLk.RUnlock()
+			} else {
+				nsLk.Unlock()
+			}
+			if holder.distLock != nil {
+				distLocks = append(distLocks, holder.distLock)
+				holder.distLock = nil
+			}
+			n.stats.ForceReleased++
+			locks = append(locks, newNSLockInfo(param, nsLk, holder, now))
+		}
+	}
+	n.mutex.Unlock()
+
+	// Releasing on peers is done without holding the map.
+	for _, dl := range distLocks {
+		dl.unlock()
+	}
+	sort.Sort(byLockSince(locks))
+	return locks
+}
+
+// stuckLockJob - logs locks held longer than timeout periodically, each
+// lock is logged once.
+func stuckLockJob(timeout time.Duration) {
+	for {
+		time.Sleep(timeout / 10)
+		for _, lock := range nsMutex.getStuckLocks(timeout) {
+			errorIf(errLockStuck, "Lock of %s/%s held by %s for %s.", lock.Volume, lock.Path, lock.Holder, lock.Duration)
+		}
+	}
 }
@@ -16,7 +16,10 @@
 
 package main
 
-import "testing"
+import (
+	"testing"
+	"time"
+)
 
 // Tests functionality provided by namespace lock.
 func TestNamespaceLockTest(t *testing.T) {
@@ -124,3 +127,55 @@ func TestNamespaceLockTest(t *testing.T) {
 		t.Errorf("Lock map not found.")
 	}
 }
+
+// Tests held locks are listed with their holder, and locks force
+// released can be locked again while their holder unlocks later.
+func TestNamespaceLockForceRelease(t *testing.T) {
+	initNSLock()
+
+	nsMutex.Lock("a", "b")
+	nsMutex.RLock("a", "c")
+	locks := nsMutex.getLocks()
+	if len(locks) != 2 {
+		t.Fatalf("Expected 2 locks, got %d", len(locks))
+	}
+	for _, lock := range locks {
+		if lock.Holder != "TestNamespaceLockForceRelease" {
+			t.Errorf("Expected lock held by TestNamespaceLockForceRelease, got %q", lock.Holder)
+		}
+	}
+	if stuck := nsMutex.getStuckLocks(time.Hour); len(stuck) != 0 {
+		t.Errorf("Expected no stuck locks, got %d", len(stuck))
+	}
+	if stuck := nsMutex.getStuckLocks(0); len(stuck) != 2 {
+		t.Errorf("Expected 2 stuck locks, got %d", len(stuck))
+	}
+	// Stuck locks are reported once.
+	if stuck := nsMutex.getStuckLocks(0); len(stuck) != 0 {
+		t.Errorf("Expected stuck locks to be reported once, got %d", len(stuck))
+	}
+
+	if released := nsMutex.forceRelease(time.Hour); len(released) != 0 {
+		t.Fatalf("Expected no locks to be released, got %d", len(released))
+	}
+	if released := nsMutex.forceRelease(0); len(released) != 2 {
+		t.Fatalf("Expected 2 locks to be released, got %d", len(released))
+	}
+
+	// Would block unless released.
+	nsMutex.Lock("a", "b")
+	nsMutex.Lock("a", "c")
+	nsMutex.Unlock("a", "b")
+	nsMutex.Unlock("a", "c")
+	// Late unlocks of the holders force released.
+	nsMutex.Unlock("a", "b")
+	nsMutex.RUnlock("a", "c")
+	if len(nsMutex.lockMap) != 0 {
+		t.Errorf("Expected no locks, got %d", len(nsMutex.lockMap))
+	}
+
+	stats := nsMutex.getStats()
+	if stats.Acquired != 4 || stats.Released != 2 || stats.ForceReleased != 2 {
+		t.Errorf("Expected 4 acquired, 2 released and 2 force released locks, got %+v", stats)
+	}
+}
@@ -0,0 +1,235 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Wrapper for calling ComposeObject tests for both XL multiple disks and single node setup.
+func TestObjectAPIComposeObject(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectAPIComposeObject)
+}
+
+// Tests validate composing objects from source objects.
+func testObjectAPIComposeObject(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "compose-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Unable to make bucket. %s", instanceType, err)
+	}
+	objects := map[string]string{"a": "aaaa", "b": "bb", "empty": "", "dir/c": "cccccc"}
+	for object, data := range objects {
+		if _, err := obj.PutObject(bucket, object, int64(len(data)), strings.NewReader(data), nil); err != nil {
+			t.Fatalf("%s: Unable to put object. %s", instanceType, err)
+		}
+	}
+
+	testCases := []struct {
+		object       string
+		sources      []string
+		expectedData string
+		shouldPass   bool
+	}{
+		// Test case - 1.
+		{"composed", []string{"a", "b", "dir/c"}, "aaaabbcccccc", true},
+		// Test case - 2.
+		// Sources can repeat.
+		{"composed", []string{"b", "a", "b"}, "bbaaaabb", true},
+		// Test case - 3.
+		// Empty sources.
+		{"composed", []string{"empty", "b", "empty"}, "bb", true},
+		// Test case - 4.
+		// Object composed into one of its sources.
+		{"a", []string{"a", "b"}, "aaaabb", true},
+		// Test case - 5.
+		// Missing source.
+		{"composed", []string{"a", "missing"}, "", false},
+		// Test case - 6.
+		// No sources.
+		{"composed", nil, "", false},
+	}
+	for i, testCase := range testCases {
+		md5Hex, err := obj.ComposeObject(bucket, testCase.object, testCase.sources, nil)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("%s: Test %d: Expected to pass, but failed with: %s", instanceType, i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("%s: Test %d: Expected to fail, but passed", instanceType, i+1)
+		}
+		if err != nil {
+			continue
+		}
+		if md5Hex == "" {
+			t.Errorf("%s: Test %d: Expected md5 of the composed object", instanceType, i+1)
+		}
+		var buffer bytes.Buffer
+		if err = obj.GetObject(bucket, testCase.object, 0, int64(len(testCase.expectedData)), &buffer); err != nil {
+			t.Fatalf("%s: Test %d: Unable to get object. %s", instanceType, i+1, err)
+		}
+		if buffer.String() != testCase.expectedData {
+			t.Errorf("%s: Test %d: Expected %q, got %q", instanceType, i+1, testCase.expectedData, buffer.String())
+		}
+		objInfo, err := obj.GetObjectInfo(bucket, testCase.object)
+		if err != nil {
+			t.Fatalf("%s: Test %d: Unable to get object info. %s", instanceType, i+1, err)
+		}
+		if objInfo.Size != int64(len(testCase.expectedData)) {
+			t.Errorf("%s: Test %d: Expected size %d, got %d", instanceType, i+1, len(testCase.expectedData), objInfo.Size)
+		}
+	}
+}
+
+// Tests composed objects link shard files of sources with the same
+// layout and erasure code others again.
+func TestXLComposeObjectLinks(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatalf("Unable to initialize XL object layer. %s", err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "compose-bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("Unable to make bucket. %s", err)
+	}
+	data := map[string][]byte{
+		"src":   bytes.Repeat([]byte("a"), 1024*1024),
+		"other": bytes.Repeat([]byte("b"), 1024),
+	}
+	for object, objData := range data {
+		if _, err = obj.PutObject(bucket, object, int64(len(objData)), bytes.NewReader(objData), nil); err != nil {
+			t.Fatalf("Unable to put object. %s", err)
+		}
+	}
+
+	md5Hex, err := obj.ComposeObject(bucket, "composed", []string{"src", "other", "src"}, nil)
+	if err != nil {
+		t.Fatalf("Unable to compose object. %s", err)
+	}
+	if !strings.HasSuffix(md5Hex, "-3") {
+		t.Errorf("Expected multipart md5 of 3 parts, got %s", md5Hex)
+	}
+	xlMeta, err := xl.readXLMetadata(bucket, "composed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srcMD5 := md5.Sum(data["src"])
+	for i, name := range []string{"object1", "object2", "object3"} {
+		if xlMeta.Parts[i].Name != name {
+			t.Errorf("Expected part %s, got %s", name, xlMeta.Parts[i].Name)
+		}
+	}
+	if xlMeta.Parts[2].ETag != hex.EncodeToString(srcMD5[:]) {
+		t.Errorf("Expected part ETag of the source, got %s", xlMeta.Parts[2].ETag)
+	}
+
+	// Parts of the source laying out the object are links.
+	srcStat, err := os.Stat(filepath.Join(fsDirs[0], bucket, "src", "object1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, partName := range []string{"object1", "object3"} {
+		partStat, sErr := os.Stat(filepath.Join(fsDirs[0], bucket, "composed", partName))
+		if sErr != nil {
+			t.Fatal(sErr)
+		}
+		if !os.SameFile(srcStat, partStat) {
+			t.Errorf("Expected part %s to be linked to the source", partName)
+		}
+	}
+
+	expectedData := string(data["src"]) + string(data["other"]) + string(data["src"])
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, "composed", 0, int64(len(expectedData)), &buffer); err != nil {
+		t.Fatalf("Unable to get object. %s", err)
+	}
+	if buffer.String() != expectedData {
+		t.Errorf("Composed object content mismatch.")
+	}
+
+	// Composed object is readable once sources are gone.
+	for object := range data {
+		if err = obj.DeleteObject(bucket, object); err != nil {
+			t.Fatal(err)
+		}
+	}
+	buffer.Reset()
+	if err = obj.GetObject(bucket, "composed", 0, int64(len(expectedData)), &buffer); err != nil || buffer.String() != expectedData {
+		t.Errorf("Expected composed object to be readable. %v", err)
+	}
+}
+
+// Tests composing objects with POST ?compose.
+func TestComposeObjectHandler(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+
+	bucket := makeIntegrationBucket(t, client)
+	for _, object := range []string{"a", "b"} {
+		resp, respBody, err := client.do("PUT", bucket, object, nil, nil, []byte(object+object))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+	}
+
+	testCases := []struct {
+		body           string
+		expectedStatus int
+		expectedData   string
+	}{
+		// Test case - 1.
+		{"<ComposeObject><Source><Key>a</Key></Source><Source><Key>b</Key></Source></ComposeObject>", http.StatusOK, "aabb"},
+		// Test case - 2.
+		{"<ComposeObject><Source><Key>a</Key></Source><Source><Key>missing</Key></Source></ComposeObject>", http.StatusNotFound, ""},
+		// Test case - 3.
+		{"<ComposeObject></ComposeObject>", http.StatusBadRequest, ""},
+		// Test case - 4.
+		{"<ComposeObject>" + strings.Repeat("<Source><Key>a</Key></Source>", maxComposeSources+1) + "</ComposeObject>", http.StatusBadRequest, ""},
+		// Test case - 5.
+		{"not xml", http.StatusBadRequest, ""},
+	}
+	for i, testCase := range testCases {
+		resp, respBody, err := client.do("POST", bucket, "composed", url.Values{"compose": {""}}, nil, []byte(testCase.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != testCase.expectedStatus {
+			t.Errorf("Test %d: Expected status %d, got %d: %s", i+1, testCase.expectedStatus, resp.StatusCode, respBody)
+		}
+		if testCase.expectedData == "" {
+			continue
+		}
+		resp, respBody, err = client.do("GET", bucket, "composed", nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(respBody) != testCase.expectedData {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expectedData, respBody)
+		}
+	}
+}
@@ -0,0 +1,338 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Wrapper for calling CopyObject tests for both XL multiple disks and single node setup.
+func TestObjectAPICopyObject(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectAPICopyObject)
+}
+
+// Tests validate copying objects within and across buckets.
+func testObjectAPICopyObject(obj ObjectLayer, instanceType string, t *testing.T) {
+	for _, bucket := range []string{"copy-bucket", "copy-dest"} {
+		if err := obj.MakeBucket(bucket); err != nil {
+			t.Fatalf("%s: Unable to make bucket. %s", instanceType, err)
+		}
+	}
+	objects := map[string]string{"a": "aaaa", "empty": "", "dir/c": "cccccc"}
+	md5Sums := make(map[string]string)
+	for object, data := range objects {
+		md5Sum, err := obj.PutObject("copy-bucket", object, int64(len(data)), strings.NewReader(data), nil)
+		if err != nil {
+			t.Fatalf("%s: Unable to put object. %s", instanceType, err)
+		}
+		md5Sums[object] = md5Sum
+	}
+
+	testCases := []struct {
+		srcObject  string
+		dstBucket  string
+		dstObject  string
+		shouldPass bool
+	}{
+		// Test case - 1.
+		{"a", "copy-bucket", "a-copy", true},
+		// Test case - 2.
+		// Copy to another bucket.
+		{"dir/c", "copy-dest", "c", true},
+		// Test case - 3.
+		// Empty source.
+		{"empty", "copy-dest", "empty", true},
+		// Test case - 4.
+		// Copy over an existing object.
+		{"dir/c", "copy-bucket", "a-copy", true},
+		// Test case - 5.
+		// Missing source.
+		{"missing", "copy-bucket", "a-copy", false},
+		// Test case - 6.
+		// Missing destination bucket.
+		{"a", "missing-bucket", "a", false},
+		// Test case - 7.
+		// Copy onto the source replaces its metadata.
+		{"a", "copy-bucket", "a", true},
+		// Test case - 8.
+		// Missing source copied onto itself.
+		{"missing", "copy-bucket", "missing", false},
+	}
+	for i, testCase := range testCases {
+		metadata := map[string]string{"content-type": "application/test"}
+		md5Sum, err := obj.CopyObject("copy-bucket", testCase.srcObject, testCase.dstBucket, testCase.dstObject, metadata)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("%s: Test %d: Expected to pass, but failed with: %s", instanceType, i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("%s: Test %d: Expected to fail, but passed", instanceType, i+1)
+		}
+		if err != nil {
+			continue
+		}
+		if md5Sum != md5Sums[testCase.srcObject] {
+			t.Errorf("%s: Test %d: Expected md5 %s of the source, got %s", instanceType, i+1, md5Sums[testCase.srcObject], md5Sum)
+		}
+		expectedData := objects[testCase.srcObject]
+		var buffer bytes.Buffer
+		if err = obj.GetObject(testCase.dstBucket, testCase.dstObject, 0, int64(len(expectedData)), &buffer); err != nil {
+			t.Fatalf("%s: Test %d: Unable to get object. %s", instanceType, i+1, err)
+		}
+		if buffer.String() != expectedData {
+			t.Errorf("%s: Test %d: Expected %q, got %q", instanceType, i+1, expectedData, buffer.String())
+		}
+		objInfo, err := obj.GetObjectInfo(testCase.dstBucket, testCase.dstObject)
+		if err != nil {
+			t.Fatalf("%s: Test %d: Unable to get object info. %s", instanceType, i+1, err)
+		}
+		if objInfo.Size != int64(len(expectedData)) {
+			t.Errorf("%s: Test %d: Expected size %d, got %d", instanceType, i+1, len(expectedData), objInfo.Size)
+		}
+		if instanceType == "XL" && objInfo.ContentType != "application/test" {
+			t.Errorf("%s: Test %d: Expected content type of the metadata, got %s", instanceType, i+1, objInfo.ContentType)
+		}
+	}
+}
+
+// Tests object copies link shard files of the source.
+func TestXLCopyObjectLinks(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatalf("Unable to initialize XL object layer. %s", err)
+	}
+	defer removeRoots(fsDirs)
+
+	for _, bucket := range []string{"copy-bucket", "copy-dest"} {
+		if err = obj.MakeBucket(bucket); err != nil {
+			t.Fatalf("Unable to make bucket. %s", err)
+		}
+	}
+	data := bytes.Repeat([]byte("a"), 1024*1024)
+	if _, err = obj.PutObject("copy-bucket", "src", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("Unable to put object. %s", err)
+	}
+	if _, err = obj.CopyObject("copy-bucket", "src", "copy-dest", "copy", nil); err != nil {
+		t.Fatalf("Unable to copy object. %s", err)
+	}
+
+	for _, fsDir := range fsDirs {
+		srcStat, sErr := os.Stat(filepath.Join(fsDir, "copy-bucket", "src", "object1"))
+		if sErr != nil {
+			t.Fatal(sErr)
+		}
+		copyStat, sErr := os.Stat(filepath.Join(fsDir, "copy-dest", "copy", "object1"))
+		if sErr != nil {
+			t.Fatal(sErr)
+		}
+		if !os.SameFile(srcStat, copyStat) {
+			t.Errorf("Expected shard on %s to be linked to the source", fsDir)
+		}
+	}
+
+	// Copy is readable once the source is gone.
+	if err = obj.DeleteObject("copy-bucket", "src"); err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject("copy-dest", "copy", 0, int64(len(data)), &buffer); err != nil || !bytes.Equal(buffer.Bytes(), data) {
+		t.Errorf("Expected object copy to be readable. %v", err)
+	}
+}
+
+// Tests object copies onto the source rewrite only `xl.json`.
+func TestXLCopyObjectMetadataOnly(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatalf("Unable to initialize XL object layer. %s", err)
+	}
+	defer removeRoots(fsDirs)
+
+	if err = obj.MakeBucket("copy-bucket"); err != nil {
+		t.Fatalf("Unable to make bucket. %s", err)
+	}
+	data := bytes.Repeat([]byte("a"), 1024*1024)
+	metadata := map[string]string{"content-type": "text/plain", "X-Amz-Meta-A": "1"}
+	md5Sum, err := obj.PutObject("copy-bucket", "object", int64(len(data)), bytes.NewReader(data), metadata)
+	if err != nil {
+		t.Fatalf("Unable to put object. %s", err)
+	}
+	shardStats := make([]os.FileInfo, len(fsDirs))
+	for i, fsDir := range fsDirs {
+		if shardStats[i], err = os.Stat(filepath.Join(fsDir, "copy-bucket", "object", "object1")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	metadata = map[string]string{"content-type": "application/json", "X-Amz-Meta-B": "2"}
+	copyMD5Sum, err := obj.CopyObject("copy-bucket", "object", "copy-bucket", "object", metadata)
+	if err != nil {
+		t.Fatalf("Unable to copy object. %s", err)
+	}
+	if copyMD5Sum != md5Sum {
+		t.Errorf("Expected md5 %s of the object, got %s", md5Sum, copyMD5Sum)
+	}
+	for i, fsDir := range fsDirs {
+		shardStat, sErr := os.Stat(filepath.Join(fsDir, "copy-bucket", "object", "object1"))
+		if sErr != nil {
+			t.Fatal(sErr)
+		}
+		if !os.SameFile(shardStats[i], shardStat) || !shardStat.ModTime().Equal(shardStats[i].ModTime()) {
+			t.Errorf("Expected shard on %s to be left untouched", fsDir)
+		}
+	}
+
+	objInfo, err := obj.GetObjectInfo("copy-bucket", "object")
+	if err != nil {
+		t.Fatalf("Unable to get object info. %s", err)
+	}
+	if objInfo.ContentType != "application/json" || objInfo.MD5Sum != md5Sum {
+		t.Errorf("Expected replaced content type and md5 of the object, got %+v", objInfo)
+	}
+	if expected := map[string]string{"X-Amz-Meta-B": "2"}; !reflect.DeepEqual(objInfo.UserDefined, expected) {
+		t.Errorf("Expected user metadata %v, got %v", expected, objInfo.UserDefined)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject("copy-bucket", "object", 0, int64(len(data)), &buffer); err != nil || !bytes.Equal(buffer.Bytes(), data) {
+		t.Errorf("Expected object to be readable. %v", err)
+	}
+}
+
+// Wrapper for calling CopyObjectPart tests for both XL multiple disks and single node setup.
+func TestObjectAPICopyObjectPart(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectAPICopyObjectPart)
+}
+
+// Tests validate uploading parts from ranges of objects.
+func testObjectAPICopyObjectPart(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "copy-part-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Unable to make bucket. %s", instanceType, err)
+	}
+	data := "hello world, copy me"
+	if _, err := obj.PutObject(bucket, "src", int64(len(data)), strings.NewReader(data), nil); err != nil {
+		t.Fatalf("%s: Unable to put object. %s", instanceType, err)
+	}
+
+	testCases := []struct {
+		srcObject    string
+		startOffset  int64
+		length       int64
+		expectedData string
+		shouldPass   bool
+	}{
+		// Test case - 1.
+		// Whole object.
+		{"src", 0, int64(len(data)), data, true},
+		// Test case - 2.
+		{"src", 6, 5, "world", true},
+		// Test case - 3.
+		// Range at the end of the object.
+		{"src", 13, 7, "copy me", true},
+		// Test case - 4.
+		// Missing source.
+		{"missing", 0, 1, "", false},
+	}
+	for i, testCase := range testCases {
+		uploadID, err := obj.NewMultipartUpload(bucket, "object", nil)
+		if err != nil {
+			t.Fatalf("%s: Test %d: Unable to initiate upload. %s", instanceType, i+1, err)
+		}
+		md5Hex, err := obj.CopyObjectPart(bucket, testCase.srcObject, bucket, "object", uploadID, 1, testCase.startOffset, testCase.length)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("%s: Test %d: Expected to pass, but failed with: %s", instanceType, i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("%s: Test %d: Expected to fail, but passed", instanceType, i+1)
+		}
+		if err != nil {
+			obj.AbortMultipartUpload(bucket, "object", uploadID)
+			continue
+		}
+		if _, err = obj.CompleteMultipartUpload(bucket, "object", uploadID, []completePart{{PartNumber: 1, ETag: md5Hex}}); err != nil {
+			t.Fatalf("%s: Test %d: Unable to complete upload. %s", instanceType, i+1, err)
+		}
+		var buffer bytes.Buffer
+		if err = obj.GetObject(bucket, "object", 0, int64(len(testCase.expectedData)), &buffer); err != nil {
+			t.Fatalf("%s: Test %d: Unable to get object. %s", instanceType, i+1, err)
+		}
+		if buffer.String() != testCase.expectedData {
+			t.Errorf("%s: Test %d: Expected %q, got %q", instanceType, i+1, testCase.expectedData, buffer.String())
+		}
+	}
+}
+
+// Tests parts copied from a whole part of the source link its shard
+// files.
+func TestXLCopyObjectPartLinks(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatalf("Unable to initialize XL object layer. %s", err)
+	}
+	defer removeRoots(fsDirs)
+
+	bucket := "copy-part-bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("Unable to make bucket. %s", err)
+	}
+	data := bytes.Repeat([]byte("a"), 1024*1024)
+	if _, err = obj.PutObject(bucket, "src", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("Unable to put object. %s", err)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, "object", nil)
+	if err != nil {
+		t.Fatalf("Unable to initiate upload. %s", err)
+	}
+	// Upload of the part is replaced by its copy.
+	if _, err = obj.PutObjectPart(bucket, "object", uploadID, 1, 3, strings.NewReader("abc"), ""); err != nil {
+		t.Fatalf("Unable to upload part. %s", err)
+	}
+	md5Hex, err := obj.CopyObjectPart(bucket, "src", bucket, "object", uploadID, 1, 0, int64(len(data)))
+	if err != nil {
+		t.Fatalf("Unable to copy part. %s", err)
+	}
+
+	for _, fsDir := range fsDirs {
+		srcStat, sErr := os.Stat(filepath.Join(fsDir, bucket, "src", "object1"))
+		if sErr != nil {
+			t.Fatal(sErr)
+		}
+		partStat, sErr := os.Stat(filepath.Join(fsDir, minioMetaBucket, mpartMetaPrefix, bucket, "object", uploadID, "object1"))
+		if sErr != nil {
+			t.Fatal(sErr)
+		}
+		if !os.SameFile(srcStat, partStat) {
+			t.Errorf("Expected part on %s to be linked to the source", fsDir)
+		}
+	}
+
+	if _, err = obj.CompleteMultipartUpload(bucket, "object", uploadID, []completePart{{PartNumber: 1, ETag: md5Hex}}); err != nil {
+		t.Fatalf("Unable to complete upload. %s", err)
+	}
+	// Object is readable once the source is gone.
+	if err = obj.DeleteObject(bucket, "src"); err != nil {
+		t.Fatal(err)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, "object", 0, int64(len(data)), &buffer); err != nil || !bytes.Equal(buffer.Bytes(), data) {
+		t.Errorf("Expected object of the copied part to be readable. %v", err)
+	}
+}
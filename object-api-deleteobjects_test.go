@@ -0,0 +1,78 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// Wrapper for calling DeleteObjects tests for both XL multiple disks and single node setup.
+func TestObjectAPIDeleteObjects(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectAPIDeleteObjects)
+}
+
+// Tests validate deleting a batch of objects reports the error of
+// each object in order.
+func testObjectAPIDeleteObjects(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "delete-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Unable to make bucket. %s", instanceType, err)
+	}
+	// More objects than deleted concurrently.
+	var objects []string
+	for i := 0; i < 2*deleteObjectsConcurrency; i++ {
+		object := "dir/object" + strconv.Itoa(i)
+		if _, err := obj.PutObject(bucket, object, 4, strings.NewReader("data"), nil); err != nil {
+			t.Fatalf("%s: Unable to put object. %s", instanceType, err)
+		}
+		objects = append(objects, object)
+	}
+	objects = append(objects, "missing", "")
+
+	if _, err := obj.DeleteObjects("missing-bucket", objects); err == nil {
+		t.Fatalf("%s: Expected DeleteObjects of a missing bucket to fail", instanceType)
+	}
+
+	errs, err := obj.DeleteObjects(bucket, objects)
+	if err != nil {
+		t.Fatalf("%s: Unable to delete objects. %s", instanceType, err)
+	}
+	if len(errs) != len(objects) {
+		t.Fatalf("%s: Expected %d errors, got %d", instanceType, len(objects), len(errs))
+	}
+	for i, object := range objects {
+		switch object {
+		case "missing":
+			if _, ok := errs[i].(ObjectNotFound); !ok {
+				t.Errorf("%s: Expected ObjectNotFound for %q, got %v", instanceType, object, errs[i])
+			}
+		case "":
+			if _, ok := errs[i].(ObjectNameInvalid); !ok {
+				t.Errorf("%s: Expected ObjectNameInvalid for %q, got %v", instanceType, object, errs[i])
+			}
+		default:
+			if errs[i] != nil {
+				t.Errorf("%s: Expected %q to be deleted, failed with %s", instanceType, object, errs[i])
+			}
+			if _, err = obj.GetObjectInfo(bucket, object); err == nil {
+				t.Errorf("%s: Expected %q to be deleted, still exists", instanceType, object)
+			}
+		}
+	}
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
@@ -571,6 +572,84 @@ func testListObjects(obj ObjectLayer, instanceType string, t *testing.T) {
 
 }
 
+// Wrapper for calling ListObjectsV2 tests for both XL multiple disks and single node setup.
+func TestListObjectsV2(t *testing.T) {
+	ExecObjectLayerTest(t, testListObjectsV2)
+}
+
+// Tests validate paging through objects with continuation tokens and
+// starting after a key.
+func testListObjectsV2(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "list-v2-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Unable to make bucket. %s", instanceType, err)
+	}
+	objects := []string{"a", "b", "dir/c", "dir/d", "e"}
+	for _, object := range objects {
+		if _, err := obj.PutObject(bucket, object, 4, strings.NewReader("data"), nil); err != nil {
+			t.Fatalf("%s: Unable to put object. %s", instanceType, err)
+		}
+	}
+
+	testCases := []struct {
+		prefix     string
+		delimiter  string
+		startAfter string
+		maxKeys    int
+		expected   []string
+	}{
+		// Test case - 1.
+		// Pages of two objects.
+		{"", "", "", 2, objects},
+		// Test case - 2.
+		// Pages of prefixes and objects.
+		{"", "/", "", 2, []string{"a", "b", "dir/", "e"}},
+		// Test case - 3.
+		{"", "", "b", 2, []string{"dir/c", "dir/d", "e"}},
+		// Test case - 4.
+		// Start after a key before the prefix lists all of it.
+		{"dir/", "", "b", 1, []string{"dir/c", "dir/d"}},
+		// Test case - 5.
+		// Start after a key after the prefix lists nothing.
+		{"dir/", "", "e", 1, nil},
+	}
+	for i, testCase := range testCases {
+		var listed []string
+		var token string
+		for page := 0; page <= len(objects); page++ {
+			result, err := obj.ListObjectsV2(bucket, testCase.prefix, token, testCase.delimiter, testCase.maxKeys, testCase.startAfter)
+			if err != nil {
+				t.Fatalf("%s: Test %d: Unable to list objects. %s", instanceType, i+1, err)
+			}
+			if result.ContinuationToken != token {
+				t.Errorf("%s: Test %d: Expected continuation token %q, got %q", instanceType, i+1, token, result.ContinuationToken)
+			}
+			for _, objInfo := range result.Objects {
+				listed = append(listed, objInfo.Name)
+			}
+			listed = append(listed, result.Prefixes...)
+			if !result.IsTruncated {
+				break
+			}
+			if result.NextContinuationToken == "" {
+				t.Fatalf("%s: Test %d: Expected a next continuation token for a truncated listing", instanceType, i+1)
+			}
+			token = result.NextContinuationToken
+		}
+		sort.Strings(listed)
+		if strings.Join(listed, ",") != strings.Join(testCase.expected, ",") {
+			t.Errorf("%s: Test %d: Expected %v, got %v", instanceType, i+1, testCase.expected, listed)
+		}
+	}
+
+	if _, err := obj.ListObjectsV2("missing-bucket", "", "", "", 10, ""); err == nil {
+		t.Errorf("%s: Expected listing a missing bucket to fail", instanceType)
+	}
+	if _, err := obj.ListObjectsV2("missing-bucket", "dir/", "", "", 10, "e"); err == nil {
+		t.Errorf("%s: Expected listing a missing bucket after its prefix to fail", instanceType)
+	}
+}
+
 func BenchmarkListObjects(b *testing.B) {
 	// Make a temporary directory to use as the obj.
 	directory, err := ioutil.TempDir("", "minio-list-benchmark")
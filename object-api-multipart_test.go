@@ -21,6 +21,9 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -69,6 +72,48 @@ func testObjectNewMultipartUpload(obj ObjectLayer, instanceType string, t *testi
 	}
 }
 
+// Wrapper for calling NewMultipartUpload limit tests for both XL multiple disks and single node setup.
+func TestObjectNewMultipartUploadLimit(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectNewMultipartUploadLimit)
+}
+
+// Tests validate uploads in progress per object are capped.
+func testObjectNewMultipartUploadLimit(obj ObjectLayer, instanceType string, t *testing.T) {
+	prevMaxUploads := globalMaxUploadsPerObject
+	globalMaxUploadsPerObject = 2
+	defer func() { globalMaxUploadsPerObject = prevMaxUploads }()
+
+	bucket := "minio-bucket"
+	object := "minio-object"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	var uploadIDs []string
+	for i := 0; i < globalMaxUploadsPerObject; i++ {
+		uploadID, err := obj.NewMultipartUpload(bucket, object, nil)
+		if err != nil {
+			t.Fatalf("%s : %s", instanceType, err.Error())
+		}
+		uploadIDs = append(uploadIDs, uploadID)
+	}
+	if _, err := obj.NewMultipartUpload(bucket, object, nil); err == nil {
+		t.Fatalf("%s: Expected to fail with too many uploads.", instanceType)
+	} else if _, ok := err.(TooManyUploads); !ok {
+		t.Fatalf("%s: Expected TooManyUploads, got %s", instanceType, err)
+	}
+	// Uploads of other objects are not affected.
+	if _, err := obj.NewMultipartUpload(bucket, "other-object", nil); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	// Aborting an upload allows a new one.
+	if err := obj.AbortMultipartUpload(bucket, object, uploadIDs[0]); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if _, err := obj.NewMultipartUpload(bucket, object, nil); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+}
+
 // Wrapper for calling isUploadIDExists tests for both XL multiple disks and single node setup.
 func TestObjectAPIIsUploadIDExists(t *testing.T) {
 	ExecObjectLayerTest(t, testObjectAPIIsUploadIDExists)
@@ -1507,7 +1552,7 @@ func testObjectCompleteMultipartUpload(obj ObjectLayer, instanceType string, t *
 		// Part number 0 doesn't exist, expecting InvalidPart error (Test number 12).
 		{bucketNames[0], objectNames[0], uploadIDs[0], []completePart{{ETag: "abcd", PartNumber: 0}}, "", InvalidPart{}, false},
 		// // Upload and PartNumber exists, But a deliberate ETag mismatch is introduced (Test number 13).
-		{bucketNames[0], objectNames[0], uploadIDs[0], inputParts[0].parts, "", BadDigest{}, false},
+		{bucketNames[0], objectNames[0], uploadIDs[0], inputParts[0].parts, "", InvalidPart{}, false},
 		// Test case with non existent object name (Test number 14).
 		{bucketNames[0], "my-object", uploadIDs[0], []completePart{{ETag: "abcd", PartNumber: 1}}, "", InvalidUploadID{UploadID: uploadIDs[0]}, false},
 		// Testing for Part being too small (Test number 15).
@@ -1547,3 +1592,55 @@ func testObjectCompleteMultipartUpload(obj ObjectLayer, instanceType string, t *
 		}
 	}
 }
+
+// Tests CompleteMultipartUpload to fail with InvalidPart for parts
+// corrupted after upload, for both XL and single node setup.
+func TestObjectCompleteMultipartUploadCorruptPart(t *testing.T) {
+	objLayer, fsDir, err := getSingleNodeObjectLayer()
+	if err != nil {
+		t.Fatalf("Initialization of object layer failed for single node setup: %s", err)
+	}
+	defer removeAll(fsDir)
+	testObjectCompleteMultipartUploadCorruptPart(objLayer, singleNodeTestStr, []string{fsDir}, t)
+
+	objLayer, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatalf("Initialization of object layer failed for XL setup: %s", err)
+	}
+	defer removeRoots(fsDirs)
+	testObjectCompleteMultipartUploadCorruptPart(objLayer, xLTestStr, fsDirs, t)
+}
+
+func testObjectCompleteMultipartUploadCorruptPart(obj ObjectLayer, instanceType string, dirs []string, t *testing.T) {
+	bucket, object := "minio-bucket", "minio-object"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Unable to make bucket. %s", instanceType, err)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, object, nil)
+	if err != nil {
+		t.Fatalf("%s: Unable to initiate multipart upload. %s", instanceType, err)
+	}
+	data := bytes.Repeat([]byte("a"), 1024)
+	md5Sum := md5.Sum(data)
+	partETag := hex.EncodeToString(md5Sum[:])
+	if _, err = obj.PutObjectPart(bucket, object, uploadID, 1, int64(len(data)), bytes.NewReader(data), partETag); err != nil {
+		t.Fatalf("%s: Unable to upload part. %s", instanceType, err)
+	}
+
+	// Corrupt the part on all disks.
+	for _, dir := range dirs {
+		partFile := filepath.Join(dir, minioMetaBucket, mpartMetaPrefix, bucket, object, uploadID, "object1")
+		st, err := os.Stat(partFile)
+		if err != nil {
+			t.Fatalf("%s: Unable to stat part. %s", instanceType, err)
+		}
+		if err = ioutil.WriteFile(partFile, bytes.Repeat([]byte("b"), int(st.Size())), 0644); err != nil {
+			t.Fatalf("%s: Unable to corrupt part. %s", instanceType, err)
+		}
+	}
+
+	_, err = obj.CompleteMultipartUpload(bucket, object, uploadID, []completePart{{PartNumber: 1, ETag: partETag}})
+	if _, ok := err.(InvalidPart); !ok {
+		t.Errorf("%s: Expected InvalidPart, got %v", instanceType, err)
+	}
+}
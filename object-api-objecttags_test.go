@@ -0,0 +1,109 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Wrapper for calling PutObjectTags tests for both XL multiple disks and single node setup.
+func TestObjectAPIPutObjectTags(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectAPIPutObjectTags)
+}
+
+// Tests validate setting and removing tags of an object.
+func testObjectAPIPutObjectTags(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "tags-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Unable to make bucket. %s", instanceType, err)
+	}
+	metadata := map[string]string{"content-type": "text/plain", "X-Amz-Meta-A": "1"}
+	md5Hex, err := obj.PutObject(bucket, "object", 5, strings.NewReader("hello"), metadata)
+	if err != nil {
+		t.Fatalf("%s: Unable to put object. %s", instanceType, err)
+	}
+	// FS saves no object metadata.
+	if !obj.Capabilities().Tagging {
+		if err = obj.PutObjectTags(bucket, "object", map[string]string{"env": "prod"}); err != (TaggingNotSupported{}) {
+			t.Fatalf("%s: Expected TaggingNotSupported, got %v", instanceType, err)
+		}
+		return
+	}
+	objInfo, err := obj.GetObjectInfo(bucket, "object")
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+
+	testCases := []struct {
+		object       string
+		tags         map[string]string
+		expectedTags map[string]string
+		shouldPass   bool
+	}{
+		// Test case - 1.
+		{"object", map[string]string{"env": "prod", "team": "a b&c"}, map[string]string{"env": "prod", "team": "a b&c"}, true},
+		// Test case - 2.
+		// Tags are replaced.
+		{"object", map[string]string{"env": "dev"}, map[string]string{"env": "dev"}, true},
+		// Test case - 3.
+		// No tags remove them.
+		{"object", nil, nil, true},
+		// Test case - 4.
+		// Missing objects.
+		{"missing", map[string]string{"env": "prod"}, nil, false},
+		// Test case - 5.
+		// Empty tag keys.
+		{"object", map[string]string{"": "prod"}, nil, false},
+	}
+	for i, testCase := range testCases {
+		err = obj.PutObjectTags(bucket, testCase.object, testCase.tags)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("%s: Test %d: Expected to pass, but failed with: %s", instanceType, i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("%s: Test %d: Expected to fail, but passed", instanceType, i+1)
+		}
+		if err != nil {
+			continue
+		}
+		newInfo, err := obj.GetObjectInfo(bucket, testCase.object)
+		if err != nil {
+			t.Fatalf("%s: Test %d: %s", instanceType, i+1, err)
+		}
+		if !reflect.DeepEqual(newInfo.Tags, testCase.expectedTags) {
+			t.Errorf("%s: Test %d: Expected tags %v, got %v", instanceType, i+1, testCase.expectedTags, newInfo.Tags)
+		}
+		// Tags leave data and metadata of the object untouched.
+		if newInfo.MD5Sum != md5Hex || !newInfo.ModTime.Equal(objInfo.ModTime) || !reflect.DeepEqual(newInfo.UserDefined, objInfo.UserDefined) {
+			t.Errorf("%s: Test %d: Expected object to be unchanged, got %v", instanceType, i+1, newInfo)
+		}
+	}
+
+	// Tags are listed along with objects.
+	if err = obj.PutObjectTags(bucket, "object", map[string]string{"env": "prod"}); err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	result, err := obj.ListObjects(bucket, "", "", "", 10)
+	if err != nil {
+		t.Fatalf("%s: %s", instanceType, err)
+	}
+	if len(result.Objects) != 1 || result.Objects[0].Tags["env"] != "prod" {
+		t.Errorf("%s: Expected listed object to carry its tags, got %v", instanceType, result.Objects)
+	}
+}
@@ -0,0 +1,222 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Wrapper for calling PatchObject tests for both XL multiple disks and single node setup.
+func TestObjectAPIPatchObject(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectAPIPatchObject)
+}
+
+// Tests validate patching byte ranges of an object.
+func testObjectAPIPatchObject(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "patch-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Unable to make bucket. %s", instanceType, err)
+	}
+
+	testCases := []struct {
+		data         string
+		offset       int64
+		patch        string
+		expectedData string
+		shouldPass   bool
+	}{
+		// Test case - 1.
+		// Patch in the middle.
+		{"abcdefgh", 2, "XY", "abXYefgh", true},
+		// Test case - 2.
+		// Patch at the start.
+		{"abcdefgh", 0, "XY", "XYcdefgh", true},
+		// Test case - 3.
+		// Patch extending the object.
+		{"abcdefgh", 6, "XYZ", "abcdefXYZ", true},
+		// Test case - 4.
+		// Patch appending to the object.
+		{"abcdefgh", 8, "XYZ", "abcdefghXYZ", true},
+		// Test case - 5.
+		// Patch of an empty object.
+		{"", 0, "XYZ", "XYZ", true},
+		// Test case - 6.
+		// Offset beyond the end of the object.
+		{"abcdefgh", 9, "XYZ", "abcdefgh", false},
+	}
+	for i, testCase := range testCases {
+		if _, err := obj.PutObject(bucket, "object", int64(len(testCase.data)), strings.NewReader(testCase.data), nil); err != nil {
+			t.Fatalf("%s: Test %d: Unable to put object. %s", instanceType, i+1, err)
+		}
+		md5Hex, err := obj.PatchObject(bucket, "object", testCase.offset, int64(len(testCase.patch)), strings.NewReader(testCase.patch))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("%s: Test %d: Expected to pass, but failed with: %s", instanceType, i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("%s: Test %d: Expected to fail, but passed", instanceType, i+1)
+		}
+		if err == nil {
+			md5Sum := md5.Sum([]byte(testCase.expectedData))
+			if expectedMD5Hex := hex.EncodeToString(md5Sum[:]); md5Hex != expectedMD5Hex {
+				t.Errorf("%s: Test %d: Expected md5 %s, got %s", instanceType, i+1, expectedMD5Hex, md5Hex)
+			}
+		}
+		var buffer bytes.Buffer
+		if err = obj.GetObject(bucket, "object", 0, int64(len(testCase.expectedData)), &buffer); err != nil {
+			t.Fatalf("%s: Test %d: Unable to get object. %s", instanceType, i+1, err)
+		}
+		if buffer.String() != testCase.expectedData {
+			t.Errorf("%s: Test %d: Expected %q, got %q", instanceType, i+1, testCase.expectedData, buffer.String())
+		}
+	}
+
+	// Short data is an incomplete body, the object is left untouched.
+	if _, err := obj.PatchObject(bucket, "object", 0, 4, strings.NewReader("XY")); err == nil {
+		t.Errorf("%s: Expected patch with short data to fail", instanceType)
+	}
+	// Patching a missing object fails.
+	if _, err := obj.PatchObject(bucket, "missing", 0, 2, strings.NewReader("XY")); err == nil {
+		t.Errorf("%s: Expected patch of missing object to fail", instanceType)
+	}
+}
+
+// Tests only parts overlapping the patch are rewritten.
+func TestXLPatchObjectParts(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatalf("Unable to initialize XL object layer. %s", err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "patch-bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("Unable to make bucket. %s", err)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, "object", nil)
+	if err != nil {
+		t.Fatalf("Unable to initiate upload. %s", err)
+	}
+	parts := [][]byte{bytes.Repeat([]byte("a"), minPartSize), []byte("bbbbbbbb")}
+	var completeParts []completePart
+	for i, part := range parts {
+		md5Hex, pErr := obj.PutObjectPart(bucket, "object", uploadID, i+1, int64(len(part)), bytes.NewReader(part), "")
+		if pErr != nil {
+			t.Fatalf("Unable to put part. %s", pErr)
+		}
+		completeParts = append(completeParts, completePart{PartNumber: i + 1, ETag: md5Hex})
+	}
+	if _, err = obj.CompleteMultipartUpload(bucket, "object", uploadID, completeParts); err != nil {
+		t.Fatalf("Unable to complete upload. %s", err)
+	}
+	before, err := xl.readXLMetadata(bucket, "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Patch second part only.
+	offset := int64(minPartSize + 2)
+	md5Hex, err := obj.PatchObject(bucket, "object", offset, 2, strings.NewReader("XY"))
+	if err != nil {
+		t.Fatalf("Unable to patch object. %s", err)
+	}
+	after, err := xl.readXLMetadata(bucket, "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if after.Parts[0].ETag != before.Parts[0].ETag {
+		t.Errorf("Expected first part to be kept, got ETag %s", after.Parts[0].ETag)
+	}
+	md5Sum := md5.Sum([]byte("bbXYbbbb"))
+	if after.Parts[1].ETag != hex.EncodeToString(md5Sum[:]) {
+		t.Errorf("Expected second part to be rewritten, got ETag %s", after.Parts[1].ETag)
+	}
+	if !strings.HasSuffix(md5Hex, "-2") || after.Meta["md5Sum"] != md5Hex {
+		t.Errorf("Expected multipart md5 of 2 parts, got %s", md5Hex)
+	}
+	if after.Stat.Size != before.Stat.Size {
+		t.Errorf("Expected size %d, got %d", before.Stat.Size, after.Stat.Size)
+	}
+
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, "object", minPartSize-2, 10, &buffer); err != nil {
+		t.Fatalf("Unable to get object. %s", err)
+	}
+	if buffer.String() != "aabbXYbbbb" {
+		t.Errorf("Expected %q, got %q", "aabbXYbbbb", buffer.String())
+	}
+}
+
+// Tests patching objects with PUT ?patch=<offset>.
+func TestPatchObjectHandler(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+
+	bucket := makeIntegrationBucket(t, client)
+	resp, respBody, err := client.do("PUT", bucket, "object", nil, nil, []byte("abcdefgh"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+
+	testCases := []struct {
+		object         string
+		offset         string
+		patch          string
+		expectedStatus int
+		expectedData   string
+	}{
+		// Test case - 1.
+		{"object", "2", "XY", http.StatusOK, "abXYefgh"},
+		// Test case - 2.
+		{"object", "8", "ZZ", http.StatusOK, "abXYefghZZ"},
+		// Test case - 3.
+		// Offset beyond the end of the object.
+		{"object", "11", "ZZ", http.StatusRequestedRangeNotSatisfiable, "abXYefghZZ"},
+		// Test case - 4.
+		// Invalid offset.
+		{"object", "-1", "ZZ", http.StatusRequestedRangeNotSatisfiable, "abXYefghZZ"},
+		// Test case - 5.
+		{"missing", "0", "ZZ", http.StatusNotFound, ""},
+	}
+	for i, testCase := range testCases {
+		resp, respBody, err = client.do("PUT", bucket, testCase.object, url.Values{"patch": {testCase.offset}}, nil, []byte(testCase.patch))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != testCase.expectedStatus {
+			t.Errorf("Test %d: Expected status %d, got %d: %s", i+1, testCase.expectedStatus, resp.StatusCode, respBody)
+		}
+		if testCase.expectedData == "" {
+			continue
+		}
+		resp, respBody, err = client.do("GET", bucket, testCase.object, nil, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(respBody) != testCase.expectedData {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expectedData, respBody)
+		}
+	}
+}
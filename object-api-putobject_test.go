@@ -0,0 +1,92 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Wrapper for calling create-only PutObject tests for both XL multiple
+// disks and single node setup.
+func TestObjectAPIPutObjectCreateOnly(t *testing.T) {
+	ExecObjectLayerTest(t, testObjectAPIPutObjectCreateOnly)
+}
+
+// Tests validate concurrent create-only uploads of an object succeed
+// only once.
+func testObjectAPIPutObjectCreateOnly(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "create-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Unable to make bucket. %s", instanceType, err)
+	}
+
+	const uploads = 8
+	errs := make([]error, uploads)
+	var wg = &sync.WaitGroup{}
+	for i := 0; i < uploads; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data := "data" + strconv.Itoa(i)
+			metadata := map[string]string{objectCreateOnlyKey: "true"}
+			_, errs[i] = obj.PutObject(bucket, "object", int64(len(data)), strings.NewReader(data), metadata)
+		}(i)
+	}
+	wg.Wait()
+
+	created := -1
+	for i, err := range errs {
+		switch err.(type) {
+		case nil:
+			if created != -1 {
+				t.Fatalf("%s: Expected a single upload to create the object, %d and %d did", instanceType, created, i)
+			}
+			created = i
+		case ObjectPreconditionFailed:
+		default:
+			t.Fatalf("%s: Expected ObjectPreconditionFailed, got %s", instanceType, err)
+		}
+	}
+	if created == -1 {
+		t.Fatalf("%s: Expected an upload to create the object", instanceType)
+	}
+
+	// The object is the one created, without the create-only flag.
+	objInfo, err := obj.GetObjectInfo(bucket, "object")
+	if err != nil {
+		t.Fatalf("%s: Unable to get object info. %s", instanceType, err)
+	}
+	if _, ok := objInfo.UserDefined[objectCreateOnlyKey]; ok {
+		t.Errorf("%s: Expected create-only flag not to be saved", instanceType)
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject(bucket, "object", 0, objInfo.Size, &buffer); err != nil {
+		t.Fatalf("%s: Unable to get object. %s", instanceType, err)
+	}
+	if buffer.String() != "data"+strconv.Itoa(created) {
+		t.Errorf("%s: Expected data of upload %d, got %q", instanceType, created, buffer.String())
+	}
+
+	// Uploads without the flag still replace the object.
+	if _, err = obj.PutObject(bucket, "object", 4, strings.NewReader("data"), nil); err != nil {
+		t.Fatalf("%s: Unable to put object. %s", instanceType, err)
+	}
+}
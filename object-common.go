@@ -17,6 +17,7 @@
 package main
 
 import (
+	"io"
 	"os"
 	"path/filepath"
 	"strings"
@@ -30,8 +31,24 @@ const (
 
 	// Staging buffer read size for all internal operations version 1.
 	readSizeV1 = 128 * 1024 // 128KiB.
+
+	// Objects of a batch delete deleted concurrently.
+	deleteObjectsConcurrency = 16
+
+	// Internal metadata of uploads which must not replace an existing
+	// object, never saved with the object.
+	objectCreateOnlyKey = "X-Minio-Internal-Create-Only"
 )
 
+// takeCreateOnly - returns true if the upload must not replace an
+// existing object, removing the flag from object metadata so it is
+// not saved.
+func takeCreateOnly(metadata map[string]string) bool {
+	createOnly := metadata[objectCreateOnlyKey] == "true"
+	delete(metadata, objectCreateOnlyKey)
+	return createOnly
+}
+
 // Register callback functions that needs to be called when process shutsdown.
 // For now, SIGINT triggers the callbacks, in future controller can trigger
 // shutdown callbacks.
@@ -45,13 +62,17 @@ func registerShutdown(callback func()) {
 
 // House keeping code needed for FS.
 func fsHouseKeeping(storageDisk StorageAPI) error {
-	// Attempt to create `.minio`.
+	// Attempt to create `.minio.sys`.
 	err := storageDisk.MakeVol(minioMetaBucket)
 	if err != nil {
 		if err != errVolumeExists && err != errDiskNotFound && err != errFaultyDisk {
 			return err
 		}
 	}
+	// Migrate internal state saved by older releases.
+	if err = migrateMinioMetaBucket(storageDisk); err != nil {
+		return err
+	}
 	// Cleanup all temp entries upon start.
 	err = cleanupDir(storageDisk, minioMetaBucket, tmpMetaPrefix)
 	if err != nil {
@@ -60,14 +81,58 @@ func fsHouseKeeping(storageDisk StorageAPI) error {
 	return nil
 }
 
+// Internal state saved by older releases in oldMinioMetaBucket which
+// is migrated even if the export path is also the config directory.
+var oldMinioMetaEntries = []string{
+	formatConfigFile,
+	mpartMetaPrefix + slashSeparator,
+	tmpMetaPrefix + slashSeparator,
+	snapshotMetaPrefix + slashSeparator,
+	dedupMetaPrefix + slashSeparator,
+	versionsMetaPrefix + slashSeparator,
+}
+
+// migrateMinioMetaBucket - moves internal state from oldMinioMetaBucket
+// into minioMetaBucket. All entries are moved and oldMinioMetaBucket
+// is removed, unless it holds the server config and thus is the
+// config directory, then the config is left in place.
+func migrateMinioMetaBucket(disk StorageAPI) error {
+	entries, err := disk.ListDir(oldMinioMetaBucket, "")
+	if err != nil {
+		if err == errVolumeNotFound || err == errDiskNotFound || err == errFaultyDisk {
+			return nil
+		}
+		return err
+	}
+	isConfigDir := contains(entries, globalMinioConfigFile)
+	for _, entry := range entries {
+		if isConfigDir && !contains(oldMinioMetaEntries, entry) {
+			continue
+		}
+		if err = disk.RenameFile(oldMinioMetaBucket, entry, minioMetaBucket, entry); err != nil {
+			return err
+		}
+	}
+	if isConfigDir {
+		return nil
+	}
+	return disk.DeleteVol(oldMinioMetaBucket)
+}
+
 // Depending on the disk type network or local, initialize storage API.
+// I/O of the disk is counted in globalStorageStats.
 func newStorageAPI(disk string) (storage StorageAPI, err error) {
 	if !strings.ContainsRune(disk, ':') || filepath.VolumeName(disk) != "" {
 		// Initialize filesystem storage API.
-		return newPosix(disk)
+		storage, err = newPosix(disk)
+	} else {
+		// Initialize rpc client storage API.
+		storage, err = newRPCClient(disk)
+	}
+	if storage == nil {
+		return nil, err
 	}
-	// Initialize rpc client storage API.
-	return newRPCClient(disk)
+	return newStatsStorage(disk, storage), err
 }
 
 // House keeping code needed for XL.
@@ -91,12 +156,18 @@ func xlHouseKeeping(storageDisks []StorageAPI) error {
 			// Indicate this wait group is done.
 			defer wg.Done()
 
-			// Attempt to create `.minio`.
+			// Attempt to create `.minio.sys`.
 			err := disk.MakeVol(minioMetaBucket)
 			if err != nil && err != errVolumeExists && err != errDiskNotFound && err != errFaultyDisk {
 				errs[index] = err
 				return
 			}
+			// Migrate internal state saved by older releases.
+			if err = migrateMinioMetaBucket(disk); err != nil {
+				errorIf(err, "Unable to migrate %s.", oldMinioMetaBucket)
+				errs[index] = err
+				return
+			}
 			// Cleanup all temp entries upon start.
 			err = cleanupDir(disk, minioMetaBucket, tmpMetaPrefix)
 			if err != nil {
@@ -124,15 +195,26 @@ func xlHouseKeeping(storageDisks []StorageAPI) error {
 
 // Cleanup a directory recursively.
 func cleanupDir(storage StorageAPI, volume, dirPath string) error {
+	return removeDir(volume, dirPath, storage.ListDir, storage.DeleteFile)
+}
+
+// Shred a directory recursively, files are overwritten before they
+// are deleted.
+func shredDir(storage StorageAPI, volume, dirPath string) error {
+	return removeDir(volume, dirPath, storage.ListDir, storage.ShredFile)
+}
+
+// Remove a directory recursively, files are removed by removeFile.
+func removeDir(volume, dirPath string, listDir func(volume, dirPath string) ([]string, error), removeFile func(volume, path string) error) error {
 	var delFunc func(string) error
 	// Function to delete entries recursively.
 	delFunc = func(entryPath string) error {
 		if !strings.HasSuffix(entryPath, slashSeparator) {
 			// No trailing "/" means that this is a file which can be deleted.
-			return storage.DeleteFile(volume, entryPath)
+			return removeFile(volume, entryPath)
 		}
 		// If it's a directory, list and call delFunc() for each entry.
-		entries, err := storage.ListDir(volume, entryPath)
+		entries, err := listDir(volume, entryPath)
 		if err != nil {
 			if err == errFileNotFound {
 				// if dirPath prefix never existed.
@@ -152,3 +234,129 @@ func cleanupDir(storage StorageAPI, volume, dirPath string) error {
 	err := delFunc(retainSlash(pathJoin(dirPath)))
 	return err
 }
+
+// Link all files of a directory recursively into another directory.
+func linkDir(storage StorageAPI, srcVolume, srcDir, dstVolume, dstDir string) error {
+	var linkFunc func(string) error
+	// Function to link entries recursively, entryPath is relative to srcDir.
+	linkFunc = func(entryPath string) error {
+		if entryPath != "" && !strings.HasSuffix(entryPath, slashSeparator) {
+			// No trailing "/" means that this is a file which can be linked.
+			return storage.LinkFile(srcVolume, pathJoin(srcDir, entryPath), dstVolume, pathJoin(dstDir, entryPath))
+		}
+		// If it's a directory, list and call linkFunc() for each entry.
+		entries, err := storage.ListDir(srcVolume, pathJoin(srcDir, entryPath))
+		if err != nil {
+			if err == errFileNotFound {
+				// if srcDir prefix never existed.
+				return nil
+			}
+			return err
+		}
+		for _, entry := range entries {
+			if err = linkFunc(pathJoin(entryPath, entry)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return linkFunc("")
+}
+
+// copyObjectData - copies an object by streaming it from the source
+// object layer to the destination object layer, used when the object
+// cannot be copied within a backend.
+func copyObjectData(src ObjectLayer, srcBucket, srcObject string, dst ObjectLayer, dstBucket, dstObject string, metadata map[string]string) (string, error) {
+	objInfo, err := src.GetObjectInfo(srcBucket, srcObject)
+	if err != nil {
+		return "", err
+	}
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(src.GetObject(srcBucket, srcObject, 0, objInfo.Size, pipeWriter))
+	}()
+	md5Sum, err := dst.PutObject(dstBucket, dstObject, objInfo.Size, pipeReader, metadata)
+	// Explicitly close the reader, unblocks the source on failures.
+	pipeReader.Close()
+	return md5Sum, err
+}
+
+// copyObjectPartData - uploads a part by streaming a range of the
+// source object from the source object layer to the upload of the
+// destination object layer.
+func copyObjectPartData(src ObjectLayer, srcBucket, srcObject string, dst ObjectLayer, bucket, object, uploadID string, partID int, startOffset, length int64) (string, error) {
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(src.GetObject(srcBucket, srcObject, startOffset, length, pipeWriter))
+	}()
+	md5Sum, err := dst.PutObjectPart(bucket, object, uploadID, partID, length, pipeReader, "")
+	// Explicitly close the reader, unblocks the source on failures.
+	pipeReader.Close()
+	return md5Sum, err
+}
+
+// deleteObjects - deletes objects of a batch concurrently with
+// deleteObject, returns the error of each object in the order of the
+// batch.
+func deleteObjects(deleteObject func(bucket, object string) error, bucket string, objects []string) []error {
+	errs := make([]error, len(objects))
+	indexCh := make(chan int)
+	var wg = &sync.WaitGroup{}
+	for worker := 0; worker < deleteObjectsConcurrency && worker < len(objects); worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range indexCh {
+				errs[index] = deleteObject(bucket, objects[index])
+			}
+		}()
+	}
+	for index := range objects {
+		indexCh <- index
+	}
+	close(indexCh)
+	wg.Wait()
+	return errs
+}
+
+// listObjectsV2 - lists a page of objects with listObjects after the
+// continuation token, or after startAfter for the first page. Keys
+// after startAfter outside of prefix are either all keys of prefix or
+// none of them.
+func listObjectsV2(listObjects func(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error), bucket, prefix, continuationToken, delimiter string, maxKeys int, startAfter string) (ListObjectsV2Info, error) {
+	marker := continuationToken
+	if marker == "" {
+		marker = startAfter
+	}
+	if marker != "" && !strings.HasPrefix(marker, prefix) {
+		if marker > prefix {
+			// All keys of prefix sort before marker, the bucket is
+			// still validated.
+			maxKeys = 0
+		}
+		marker = ""
+	}
+	result, err := listObjects(bucket, prefix, marker, delimiter, maxKeys)
+	if err != nil {
+		return ListObjectsV2Info{}, err
+	}
+	resultV2 := ListObjectsV2Info{
+		IsTruncated:       result.IsTruncated,
+		ContinuationToken: continuationToken,
+		Objects:           result.Objects,
+		Prefixes:          result.Prefixes,
+	}
+	if !result.IsTruncated {
+		return resultV2, nil
+	}
+	// NextMarker is not set by all backends without delimiter, the
+	// next page starts after the last entry listed.
+	resultV2.NextContinuationToken = result.NextMarker
+	if len(result.Objects) > 0 && result.Objects[len(result.Objects)-1].Name > resultV2.NextContinuationToken {
+		resultV2.NextContinuationToken = result.Objects[len(result.Objects)-1].Name
+	}
+	if len(result.Prefixes) > 0 && result.Prefixes[len(result.Prefixes)-1] > resultV2.NextContinuationToken {
+		resultV2.NextContinuationToken = result.Prefixes[len(result.Prefixes)-1]
+	}
+	return resultV2, nil
+}
@@ -0,0 +1,111 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+// Tests migration of internal state saved by older releases.
+func TestMigrateMinioMetaBucket(t *testing.T) {
+	root, err := ioutil.TempDir("", "minio-")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir. %s", err)
+	}
+	defer removeAll(root)
+	disk, err := newPosix(root)
+	if err != nil {
+		t.Fatalf("Unable to initialize posix. %s", err)
+	}
+
+	// Layout of older releases, config.json belongs to the config
+	// directory sharing the same path and must not be migrated.
+	if err = disk.MakeVol(oldMinioMetaBucket); err != nil {
+		t.Fatalf("Unable to create volume. %s", err)
+	}
+	oldFiles := []string{formatConfigFile, "multipart/bucket/object/uploads.json", "config.json"}
+	for _, file := range oldFiles {
+		if err = disk.AppendFile(oldMinioMetaBucket, file, []byte("{}")); err != nil {
+			t.Fatalf("Unable to create %s. %s", file, err)
+		}
+	}
+
+	if err = fsHouseKeeping(disk); err != nil {
+		t.Fatalf("House keeping failed with %s", err)
+	}
+
+	testCases := []struct {
+		volume      string
+		path        string
+		shouldExist bool
+	}{
+		// Test case - 1.
+		{minioMetaBucket, formatConfigFile, true},
+		// Test case - 2.
+		{minioMetaBucket, "multipart/bucket/object/uploads.json", true},
+		// Test case - 3.
+		{minioMetaBucket, "config.json", false},
+		// Test case - 4.
+		{oldMinioMetaBucket, "config.json", true},
+		// Test case - 5.
+		{oldMinioMetaBucket, formatConfigFile, false},
+	}
+	for i, testCase := range testCases {
+		_, err = disk.StatFile(testCase.volume, testCase.path)
+		if testCase.shouldExist && err != nil {
+			t.Errorf("Test %d: Expected %s/%s to exist, failed with %s", i+1, testCase.volume, testCase.path, err)
+		}
+		if !testCase.shouldExist && err == nil {
+			t.Errorf("Test %d: Expected %s/%s to not exist", i+1, testCase.volume, testCase.path)
+		}
+	}
+
+	// Old meta bucket is removed once fully migrated.
+	if err = disk.DeleteFile(oldMinioMetaBucket, "config.json"); err != nil {
+		t.Fatalf("Unable to delete config.json. %s", err)
+	}
+	if err = migrateMinioMetaBucket(disk); err != nil {
+		t.Fatalf("Migration failed with %s", err)
+	}
+	if _, err = disk.StatVol(oldMinioMetaBucket); err != errVolumeNotFound {
+		t.Errorf("Expected %s to be removed, got %v", oldMinioMetaBucket, err)
+	}
+
+	// Without the server config every entry is internal state, bucket
+	// metadata included.
+	if err = disk.MakeVol(oldMinioMetaBucket); err != nil {
+		t.Fatalf("Unable to create volume. %s", err)
+	}
+	oldFiles = []string{"buckets/bucket/access-policy.json", "notification/bucket.json"}
+	for _, file := range oldFiles {
+		if err = disk.AppendFile(oldMinioMetaBucket, file, []byte("{}")); err != nil {
+			t.Fatalf("Unable to create %s. %s", file, err)
+		}
+	}
+	if err = migrateMinioMetaBucket(disk); err != nil {
+		t.Fatalf("Migration failed with %s", err)
+	}
+	for _, file := range oldFiles {
+		if _, err = disk.StatFile(minioMetaBucket, file); err != nil {
+			t.Errorf("Expected %s/%s to exist, failed with %s", minioMetaBucket, file, err)
+		}
+	}
+	if _, err = disk.StatVol(oldMinioMetaBucket); err != errVolumeNotFound {
+		t.Errorf("Expected %s to be removed, got %v", oldMinioMetaBucket, err)
+	}
+}
@@ -26,6 +26,77 @@ type StorageInfo struct {
 	Free int64
 }
 
+// BackendCapabilities - represents optional features supported by
+// an object layer.
+type BackendCapabilities struct {
+	// Backend type, either "FS" or "XL".
+	Backend string `json:"backend"`
+
+	// Multipart uploads.
+	Multipart bool `json:"multipart"`
+	// Bucket snapshots and clones.
+	Snapshots bool `json:"snapshots"`
+	// Content addressed dedup of identical objects.
+	Dedup bool `json:"dedup"`
+	// Storage classes other than STANDARD.
+	StorageClasses bool `json:"storageClasses"`
+	// Bucket versioning.
+	Versioning bool `json:"versioning"`
+	// Listing objects filtered by metadata.
+	MetadataFilter bool `json:"metadataFilter"`
+	// Healing objects and buckets on demand.
+	Healing bool `json:"healing"`
+	// Object tags.
+	Tagging bool `json:"tagging"`
+	// Retention and legal holds of objects.
+	ObjectLock bool `json:"objectLock"`
+}
+
+// ObjectHealInfo - represents disks missing or holding corrupted
+// shards of an object.
+type ObjectHealInfo struct {
+	Bucket         string   `json:"bucket"`
+	Object         string   `json:"object"`
+	MissingDisks   []string `json:"missingDisks,omitempty"`
+	CorruptedDisks []string `json:"corruptedDisks,omitempty"`
+	// Shards were rebuilt, never in dry-run.
+	Healed bool `json:"healed"`
+	// Healing failed, only reported for objects of a bucket.
+	Error string `json:"error,omitempty"`
+}
+
+// BucketHealInfo - represents disks missing a bucket and objects of
+// the bucket with missing or corrupted shards.
+type BucketHealInfo struct {
+	Bucket       string           `json:"bucket"`
+	MissingDisks []string         `json:"missingDisks,omitempty"`
+	Objects      []ObjectHealInfo `json:"objects"`
+}
+
+// ObjectChecksumInfo - represents checksums of the shards of each part
+// of an object, as recorded by the backend.
+type ObjectChecksumInfo struct {
+	Object string             `json:"object"`
+	Size   int64              `json:"size"`
+	MD5Sum string             `json:"md5Sum,omitempty"`
+	Parts  []PartChecksumInfo `json:"parts"`
+}
+
+// PartChecksumInfo - represents checksums of the shards of a part.
+type PartChecksumInfo struct {
+	Number int                 `json:"number"`
+	Size   int64               `json:"size"`
+	Shards []ShardChecksumInfo `json:"shards"`
+}
+
+// ShardChecksumInfo - represents checksum of a shard, by its 0-based
+// index in the erasure layout.
+type ShardChecksumInfo struct {
+	Index     int    `json:"index"`
+	Algorithm string `json:"algorithm"`
+	Hash      string `json:"hash"`
+}
+
 // BucketInfo - represents bucket metadata.
 type BucketInfo struct {
 	// Name of the bucket.
@@ -35,6 +106,18 @@ type BucketInfo struct {
 	Created time.Time
 }
 
+// BucketSnapshotInfo - represents a point in time snapshot of a bucket.
+type BucketSnapshotInfo struct {
+	// Name of the bucket.
+	Bucket string
+
+	// Unique id of the snapshot.
+	ID string
+
+	// Date and time when the snapshot was created.
+	Created time.Time
+}
+
 // ObjectInfo - represents object metadata.
 type ObjectInfo struct {
 	// Name of the bucket.
@@ -62,6 +145,34 @@ type ObjectInfo struct {
 	// what decoding mechanisms must be applied to obtain the object referenced
 	// by the Content-Type header field.
 	ContentEncoding string
+
+	// Caching directives of the object, sent as Cache-Control.
+	CacheControl string
+
+	// User defined metadata, keyed by canonical header name.
+	UserDefined map[string]string
+
+	// Tags of the object, keyed by tag key.
+	Tags map[string]string
+
+	// Deployment, node and time the object was written at.
+	Provenance objectProvenance
+
+	// Retention and legal hold of the object.
+	Retention objectRetention
+
+	// Time after which the object is deleted, zero without a TTL.
+	Expires time.Time
+
+	// Version id of the object, empty for the null version.
+	VersionID string
+
+	// IsLatest is set for the current version of an object, only
+	// reported by version listings.
+	IsLatest bool
+
+	// DeleteMarker is set for versions recording a delete.
+	DeleteMarker bool
 }
 
 // ListPartsInfo - represents list of all parts.
@@ -168,6 +279,42 @@ type ListObjectsInfo struct {
 	Prefixes []string
 }
 
+// ListObjectsV2Info - container for list objects version 2.
+type ListObjectsV2Info struct {
+	// Indicates whether the returned list objects response is truncated.
+	IsTruncated bool
+
+	// Continuation token of this request, and of the request listing
+	// the next page when truncated. Tokens are the name of the last
+	// entry listed before them.
+	ContinuationToken     string
+	NextContinuationToken string
+
+	// List of objects info for this request.
+	Objects []ObjectInfo
+
+	// List of prefixes for this request.
+	Prefixes []string
+}
+
+// ListObjectVersionsInfo - container for list object versions.
+type ListObjectVersionsInfo struct {
+	// Indicates whether the returned list of versions is truncated.
+	IsTruncated bool
+
+	// When the list is truncated, the key and version id to use as
+	// key-marker and version-id-marker of the next request.
+	NextKeyMarker       string
+	NextVersionIDMarker string
+
+	// List of versions and delete markers for this request, versions
+	// of a key are ordered newest first.
+	Objects []ObjectInfo
+
+	// List of prefixes for this request.
+	Prefixes []string
+}
+
 // partInfo - represents individual part metadata.
 type partInfo struct {
 	// Part number that identifies the part. This is a positive integer between
@@ -219,3 +366,26 @@ func (a completedParts) Less(i, j int) bool { return a[i].PartNumber < a[j].Part
 type completeMultipartUpload struct {
 	Parts []completePart `xml:"Part"`
 }
+
+// composeObjectSource - represents a source object of ComposeObject.
+type composeObjectSource struct {
+	Key string
+}
+
+// composeObject - represents input fields for composing an object.
+type composeObject struct {
+	Sources []composeObjectSource `xml:"Source"`
+}
+
+// getMultipleObjectsEntry - represents an object, optionally a byte
+// range of it, of GetMultipleObjects.
+type getMultipleObjectsEntry struct {
+	Key   string
+	Range string
+}
+
+// getMultipleObjects - represents input fields for reading multiple
+// objects.
+type getMultipleObjects struct {
+	Objects []getMultipleObjectsEntry `xml:"Object"`
+}
@@ -0,0 +1,51 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "path"
+
+// Erasing a bucket removes all of its objects, versions, incomplete
+// multipart uploads and snapshots along with the bucket. With overwrite, files
+// are overwritten with zeros before they are deleted so that data
+// cannot be recovered from the disks. Files which are hard linked
+// elsewhere, shards of deduped objects still used by other objects,
+// are only deleted.
+
+// eraseDir - removes a directory recursively, files are overwritten
+// first with overwrite.
+func eraseDir(storage StorageAPI, volume, dirPath string, overwrite bool) error {
+	if overwrite {
+		return shredDir(storage, volume, dirPath)
+	}
+	return cleanupDir(storage, volume, dirPath)
+}
+
+// eraseBucketContents - erases snapshots, multipart uploads, versions
+// and all objects of a bucket on a disk. Snapshots are erased first so that
+// their links do not keep the data of the objects.
+func eraseBucketContents(storage StorageAPI, bucket string, overwrite bool) error {
+	if err := eraseDir(storage, minioMetaBucket, path.Join(snapshotMetaPrefix, bucket), overwrite); err != nil {
+		return err
+	}
+	if err := eraseDir(storage, minioMetaBucket, path.Join(mpartMetaPrefix, bucket), overwrite); err != nil {
+		return err
+	}
+	if err := eraseDir(storage, minioMetaBucket, path.Join(versionsMetaPrefix, bucket), overwrite); err != nil {
+		return err
+	}
+	return eraseDir(storage, bucket, "", overwrite)
+}
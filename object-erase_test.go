@@ -0,0 +1,114 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Wrapper for calling erase bucket tests for both XL and FS.
+func TestEraseBucket(t *testing.T) {
+	globalDedup = true
+	defer func() {
+		globalDedup = false
+	}()
+	ExecObjectLayerTest(t, testEraseBucket)
+}
+
+// Tests erase of a bucket with objects, uploads and snapshots.
+func testEraseBucket(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket, otherBucket := "erase-bucket", "other-bucket"
+	for _, b := range []string{bucket, otherBucket} {
+		if err := obj.MakeBucket(b); err != nil {
+			t.Fatalf("%s: Unable to make bucket. %s", instanceType, err)
+		}
+	}
+	// Objects of the other bucket share data with the erased bucket
+	// when deduped.
+	data := bytes.Repeat([]byte("a"), 1024*1024)
+	objects := []struct {
+		bucket string
+		object string
+	}{
+		{bucket, "object"},
+		{bucket, "dir/object"},
+		{otherBucket, "object"},
+	}
+	for _, o := range objects {
+		if _, err := obj.PutObject(o.bucket, o.object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("%s: Unable to put object. %s", instanceType, err)
+		}
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, "upload", nil)
+	if err != nil {
+		t.Fatalf("%s: Unable to start upload. %s", instanceType, err)
+	}
+	if _, err = obj.PutObjectPart(bucket, "upload", uploadID, 1, int64(len(data)), bytes.NewReader(data), ""); err != nil {
+		t.Fatalf("%s: Unable to put part. %s", instanceType, err)
+	}
+	if _, err = obj.SnapshotBucket(bucket, getUUID()); err != nil {
+		t.Fatalf("%s: Unable to snapshot bucket. %s", instanceType, err)
+	}
+
+	testCases := []struct {
+		bucket      string
+		overwrite   bool
+		expectedErr error
+	}{
+		// Test case - 1.
+		{bucket, true, nil},
+		// Test case - 2.
+		{bucket, true, BucketNotFound{Bucket: bucket}},
+		// Test case - 3.
+		{"a", false, BucketNameInvalid{Bucket: "a"}},
+	}
+	for i, testCase := range testCases {
+		if err = obj.EraseBucket(testCase.bucket, testCase.overwrite); err != testCase.expectedErr {
+			t.Errorf("%s: Test %d: Expected err %v, got %v", instanceType, i+1, testCase.expectedErr, err)
+		}
+	}
+
+	// Nothing of the erased bucket is left behind.
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Unable to make bucket again. %s", instanceType, err)
+	}
+	result, err := obj.ListObjects(bucket, "", "", "", 1000)
+	if err != nil || len(result.Objects) != 0 || len(result.Prefixes) != 0 {
+		t.Errorf("%s: Expected no objects, got %v, %v", instanceType, result.Objects, err)
+	}
+	uploads, err := obj.ListMultipartUploads(bucket, "", "", "", "", 1000)
+	if err != nil || len(uploads.Uploads) != 0 {
+		t.Errorf("%s: Expected no uploads, got %v, %v", instanceType, uploads.Uploads, err)
+	}
+	snapshots, err := obj.ListBucketSnapshots(bucket)
+	if err != nil || len(snapshots) != 0 {
+		t.Errorf("%s: Expected no snapshots, got %v, %v", instanceType, snapshots, err)
+	}
+
+	// Objects sharing data with the erased bucket remain intact.
+	var buf bytes.Buffer
+	if err = obj.GetObject(otherBucket, "object", 0, int64(len(data)), &buf); err != nil {
+		t.Fatalf("%s: Unable to read object. %s", instanceType, err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("%s: Object sharing data with the erased bucket was overwritten", instanceType)
+	}
+	if err = obj.EraseBucket(otherBucket, false); err != nil {
+		t.Errorf("%s: Unable to erase bucket. %s", instanceType, err)
+	}
+}
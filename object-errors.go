@@ -119,6 +119,17 @@ func (e ObjectNotFound) Error() string {
 	return "Object not found: " + e.Bucket + "#" + e.Object
 }
 
+// VersionNotFound version of an object does not exist.
+type VersionNotFound struct {
+	Bucket    string
+	Object    string
+	VersionID string
+}
+
+func (e VersionNotFound) Error() string {
+	return "Version not found: " + e.Bucket + "#" + e.Object + "#" + e.VersionID
+}
+
 // ObjectExistsAsDirectory object already exists as a directory.
 type ObjectExistsAsDirectory GenericError
 
@@ -126,6 +137,40 @@ func (e ObjectExistsAsDirectory) Error() string {
 	return "Object exists on : " + e.Bucket + " as directory " + e.Object
 }
 
+// ObjectAlreadyExists - object exists in a bucket protected against
+// overwrites.
+type ObjectAlreadyExists GenericError
+
+func (e ObjectAlreadyExists) Error() string {
+	return "Object already exists, bucket is protected against overwrites: " + e.Bucket + "#" + e.Object
+}
+
+// ObjectLocked - object is retained or under legal hold, and cannot
+// be replaced or deleted.
+type ObjectLocked GenericError
+
+func (e ObjectLocked) Error() string {
+	return "Object is protected by object lock: " + e.Bucket + "#" + e.Object
+}
+
+// TenantQuotaExceeded - write would store more bytes in buckets of a
+// tenant than its storage quota.
+type TenantQuotaExceeded struct {
+	Tenant string
+}
+
+func (e TenantQuotaExceeded) Error() string {
+	return "Storage quota of tenant " + e.Tenant + " is exceeded"
+}
+
+// ObjectPreconditionFailed - object exists and a create-only upload
+// must not replace it.
+type ObjectPreconditionFailed GenericError
+
+func (e ObjectPreconditionFailed) Error() string {
+	return "Object already exists: " + e.Bucket + "#" + e.Object
+}
+
 // BucketExists bucket exists.
 type BucketExists GenericError
 
@@ -177,6 +222,44 @@ func (e BucketPolicyNotFound) Error() string {
 	return "No bucket policy found for bucket: " + e.Bucket
 }
 
+// BucketRewriteNotFound - no bucket rewrite rules found.
+type BucketRewriteNotFound GenericError
+
+func (e BucketRewriteNotFound) Error() string {
+	return "No bucket rewrite rules found for bucket: " + e.Bucket
+}
+
+// BucketReplicaNotFound - bucket is not a replica.
+type BucketReplicaNotFound GenericError
+
+func (e BucketReplicaNotFound) Error() string {
+	return "No replica config found for bucket: " + e.Bucket
+}
+
+// BucketOriginNotFound - bucket has no origin.
+type BucketOriginNotFound GenericError
+
+func (e BucketOriginNotFound) Error() string {
+	return "No origin config found for bucket: " + e.Bucket
+}
+
+// BucketReplicationNotFound - bucket is not replicated.
+type BucketReplicationNotFound GenericError
+
+func (e BucketReplicationNotFound) Error() string {
+	return "No replication config found for bucket: " + e.Bucket
+}
+
+// BucketSnapshotNotFound - no such bucket snapshot.
+type BucketSnapshotNotFound struct {
+	Bucket     string
+	SnapshotID string
+}
+
+func (e BucketSnapshotNotFound) Error() string {
+	return "No snapshot " + e.SnapshotID + " found for bucket: " + e.Bucket
+}
+
 /// Bucket related errors.
 
 // BucketNameInvalid - bucketname provided is invalid.
@@ -256,3 +339,55 @@ type PartTooSmall struct{}
 func (e PartTooSmall) Error() string {
 	return "Part size should be atleast 5MB"
 }
+
+// TooManyUploads - error if an object has reached the maximum number
+// of multipart uploads in progress.
+type TooManyUploads struct {
+	Bucket string
+	Object string
+}
+
+func (e TooManyUploads) Error() string {
+	return "Too many multipart uploads in progress for " + e.Bucket + "/" + e.Object
+}
+
+// ComposeTiersMixed - error if source objects of a compose live on
+// different storage tiers.
+type ComposeTiersMixed GenericError
+
+func (e ComposeTiersMixed) Error() string {
+	return "Source objects of " + e.Bucket + "/" + e.Object + " live on different storage tiers"
+}
+
+// HealingNotSupported - error if the backend keeps a single copy of
+// objects, which cannot be healed.
+type HealingNotSupported struct{}
+
+func (e HealingNotSupported) Error() string {
+	return "Healing is not supported by this backend"
+}
+
+// TaggingNotSupported - error if the backend does not save object
+// metadata, which would lose the tags.
+type TaggingNotSupported struct{}
+
+func (e TaggingNotSupported) Error() string {
+	return "Object tagging is not supported by this backend"
+}
+
+// VersioningNotSupported - error if the backend does not keep
+// versions of objects.
+type VersioningNotSupported struct{}
+
+func (e VersioningNotSupported) Error() string {
+	return "Versioning is not supported by this backend"
+}
+
+// CSEMetadataNotSupported - error if the backend does not save user
+// metadata, which would lose the envelope of client side encrypted
+// objects.
+type CSEMetadataNotSupported struct{}
+
+func (e CSEMetadataNotSupported) Error() string {
+	return "Client side encryption metadata is not supported by this backend"
+}
@@ -0,0 +1,172 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	// Header setting the number of seconds after which an object is
+	// deleted.
+	expiresAfterHeader = "X-Amz-Expires-After"
+
+	// Longest TTL accepted, 100 years.
+	maxObjectExpiresAfter = 100 * 365 * 24 * time.Hour
+
+	// Internal metadata key of the time after which an object is
+	// deleted, saved with the object so that overwrites drop it and
+	// every server sees it.
+	objectExpiresKey = "X-Minio-Internal-Expires"
+
+	// Interval at which expired objects are deleted.
+	objectExpiryInterval = 1 * time.Minute
+)
+
+// parseExpiresAfter - parses TTL header of a request, returns zero if
+// not set.
+func parseExpiresAfter(header http.Header) (time.Duration, APIErrorCode) {
+	value := header.Get(expiresAfterHeader)
+	if value == "" {
+		return 0, ErrNone
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds <= 0 || seconds > int64(maxObjectExpiresAfter/time.Second) {
+		return 0, ErrInvalidExpiresAfter
+	}
+	return time.Duration(seconds) * time.Second, ErrNone
+}
+
+// getObjectExpiry - returns the time after which an object is deleted
+// saved in object metadata, zero if the object has no TTL.
+func getObjectExpiry(meta map[string]string) time.Time {
+	expires, _ := time.Parse(time.RFC3339Nano, meta[objectExpiresKey])
+	return expires
+}
+
+// hasObjectExpiry - returns true if object metadata carries a TTL.
+func hasObjectExpiry(meta map[string]string) bool {
+	_, ok := meta[objectExpiresKey]
+	return ok
+}
+
+// setObjectExpiry - saves the time after which an object is deleted
+// into object metadata, zero removes it.
+func setObjectExpiry(meta map[string]string, expires time.Time) {
+	if expires.IsZero() {
+		delete(meta, objectExpiresKey)
+		return
+	}
+	meta[objectExpiresKey] = expires.UTC().Format(time.RFC3339Nano)
+}
+
+// setObjectExpiryFromHeader - saves the TTL of the X-Amz-Expires-After
+// header, if set, into object metadata as the time after which the
+// object is deleted.
+func setObjectExpiryFromHeader(header http.Header, metadata map[string]string) APIErrorCode {
+	expiresAfter, s3Error := parseExpiresAfter(header)
+	if s3Error != ErrNone || expiresAfter == 0 {
+		return s3Error
+	}
+	setObjectExpiry(metadata, time.Now().UTC().Add(expiresAfter))
+	return ErrNone
+}
+
+// expireObjects - deletes all objects expired at now, returns plan
+// entries of expired objects. Nothing is deleted in dry-run.
+func expireObjects(objAPI ObjectLayer, now time.Time, dryRun bool) []planEntry {
+	buckets, err := objAPI.ListBuckets()
+	if err != nil {
+		errorIf(err, "Unable to list buckets for expiry.")
+		return nil
+	}
+	var entries []planEntry
+	for _, bucket := range buckets {
+		entries = append(entries, expireBucketObjects(objAPI, bucket.Name, now, dryRun)...)
+	}
+	return entries
+}
+
+// expireBucketObjects - deletes all objects of a bucket expired at now,
+// returns plan entries of expired objects. Expired objects are found
+// by listing the bucket, retained objects are expired by a later
+// listing once their retention ended.
+func expireBucketObjects(objAPI ObjectLayer, bucket string, now time.Time, dryRun bool) []planEntry {
+	var entries []planEntry
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(bucket, "", marker, "", maxObjectList)
+		if err != nil {
+			errorIf(err, "Unable to list objects of %s for expiry.", bucket)
+			break
+		}
+		for _, objInfo := range result.Objects {
+			if objInfo.Expires.IsZero() || now.Before(objInfo.Expires) {
+				continue
+			}
+			ok, err := expireObject(objAPI, bucket, objInfo, dryRun)
+			if ok || err != nil {
+				entries = append(entries, newPlanEntry(planActionDelete, bucket, objInfo, err))
+			}
+			if _, locked := err.(ObjectLocked); !locked {
+				errorIf(err, "Unable to expire %s/%s.", bucket, objInfo.Name)
+			}
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+		if marker == "" && len(result.Objects) > 0 {
+			marker = result.Objects[len(result.Objects)-1].Name
+		}
+	}
+	return entries
+}
+
+// expireObject - deletes a listed expired object unless it was
+// overwritten after listing, returns true if the object was (or in
+// dry-run would be) deleted.
+func expireObject(objAPI ObjectLayer, bucket string, objInfo ObjectInfo, dryRun bool) (bool, error) {
+	curInfo, err := objAPI.GetObjectInfo(bucket, objInfo.Name)
+	if err != nil {
+		if _, ok := err.(ObjectNotFound); ok {
+			return false, nil
+		}
+		return false, err
+	}
+	if !curInfo.ModTime.Equal(objInfo.ModTime) || !curInfo.Expires.Equal(objInfo.Expires) {
+		return false, nil
+	}
+	if dryRun {
+		return true, nil
+	}
+	err = objAPI.DeleteObject(bucket, objInfo.Name)
+	if _, ok := err.(ObjectNotFound); ok {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// objectExpiryJob - periodically deletes expired objects, runs forever.
+func objectExpiryJob(objAPI ObjectLayer) {
+	for {
+		time.Sleep(objectExpiryInterval)
+		expireObjects(objAPI, time.Now().UTC(), false)
+	}
+}
@@ -0,0 +1,159 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// Tests validate parsing of the TTL header.
+func TestParseExpiresAfter(t *testing.T) {
+	testCases := []struct {
+		value                string
+		expectedExpiresAfter time.Duration
+		expectedErr          APIErrorCode
+	}{
+		// Test case - 1.
+		// TTL not set.
+		{"", 0, ErrNone},
+		// Test case - 2.
+		{"60", 60 * time.Second, ErrNone},
+		// Test case - 3.
+		{"0", 0, ErrInvalidExpiresAfter},
+		// Test case - 4.
+		{"-1", 0, ErrInvalidExpiresAfter},
+		// Test case - 5.
+		{"1h", 0, ErrInvalidExpiresAfter},
+		// Test case - 6.
+		// Longer than 100 years.
+		{"3153600001", 0, ErrInvalidExpiresAfter},
+	}
+	for i, testCase := range testCases {
+		header := http.Header{}
+		if testCase.value != "" {
+			header.Set(expiresAfterHeader, testCase.value)
+		}
+		expiresAfter, s3Error := parseExpiresAfter(header)
+		if s3Error != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %d, got %d", i+1, testCase.expectedErr, s3Error)
+		}
+		if expiresAfter != testCase.expectedExpiresAfter {
+			t.Errorf("Test %d: Expected TTL %s, got %s", i+1, testCase.expectedExpiresAfter, expiresAfter)
+		}
+	}
+}
+
+// Wrapper for calling object expiry tests for both XL multiple disks
+// and single node setup.
+func TestExpireObjects(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	ExecObjectLayerTest(t, testExpireObjects)
+}
+
+// Tests validate only expired objects which were not overwritten are
+// deleted.
+func testExpireObjects(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "expiry-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+
+	putObject := func(object, expiresAfter string) error {
+		header := http.Header{}
+		if expiresAfter != "" {
+			header.Set(expiresAfterHeader, expiresAfter)
+		}
+		metadata := make(map[string]string)
+		if s3Error := setObjectExpiryFromHeader(header, metadata); s3Error != ErrNone {
+			t.Fatalf("%s: Unable to set TTL %s, failed with %d", instanceType, expiresAfter, s3Error)
+		}
+		data := []byte("hello")
+		_, err := obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), metadata)
+		return err
+	}
+
+	// Object with a short TTL.
+	if err := putObject("short", "60"); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	// Object with a long TTL.
+	if err := putObject("long", "3600"); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	// Object without a TTL.
+	if err := putObject("plain", ""); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	// Object whose TTL was dropped by a later upload.
+	for _, expiresAfter := range []string{"60", ""} {
+		if err := putObject("overwritten", expiresAfter); err != nil {
+			t.Fatalf("%s : %s", instanceType, err.Error())
+		}
+	}
+
+	// Dry-run reports the expired object without deleting it.
+	entries := expireObjects(obj, time.Now().UTC().Add(2*time.Minute), true)
+	if len(entries) != 1 || entries[0].Object != "short" || entries[0].Action != planActionDelete {
+		t.Errorf("%s: Expected plan to delete short, got %v", instanceType, entries)
+	}
+	if _, err := obj.GetObjectInfo(bucket, "short"); err != nil {
+		t.Errorf("%s: Expected short to exist after dry-run, failed with %s", instanceType, err)
+	}
+
+	entries = expireObjects(obj, time.Now().UTC().Add(2*time.Minute), false)
+	if len(entries) != 1 || entries[0].Object != "short" || entries[0].Error != "" {
+		t.Errorf("%s: Expected short to be deleted, got %v", instanceType, entries)
+	}
+
+	testCases := []struct {
+		object       string
+		shouldExist  bool
+		shouldExpire bool
+	}{
+		// Test case - 1.
+		{"short", false, false},
+		// Test case - 2.
+		{"long", true, true},
+		// Test case - 3.
+		{"plain", true, false},
+		// Test case - 4.
+		{"overwritten", true, false},
+	}
+	for i, testCase := range testCases {
+		objInfo, err := obj.GetObjectInfo(bucket, testCase.object)
+		if testCase.shouldExist && err != nil {
+			t.Errorf("%s: Test %d: Expected %s to exist, failed with %s", instanceType, i+1, testCase.object, err)
+		}
+		if !testCase.shouldExist && err == nil {
+			t.Errorf("%s: Test %d: Expected %s to be deleted", instanceType, i+1, testCase.object)
+		}
+		if err == nil && objInfo.Expires.IsZero() == testCase.shouldExpire {
+			t.Errorf("%s: Test %d: Expected %s to have expiry %t, got %s", instanceType, i+1, testCase.object, testCase.shouldExpire, objInfo.Expires)
+		}
+	}
+}
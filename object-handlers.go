@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/url"
 	"sort"
@@ -34,6 +35,12 @@ import (
 	mux "github.com/gorilla/mux"
 )
 
+// Values of X-Amz-Metadata-Directive of copy requests.
+const (
+	metadataDirectiveCopy    = "COPY"
+	metadataDirectiveReplace = "REPLACE"
+)
+
 // supportedGetReqParams - supported request parameters for GET presigned request.
 var supportedGetReqParams = map[string]string{
 	"response-expires":             "Expires",
@@ -78,6 +85,14 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 	bucket = vars["bucket"]
 	object = vars["object"]
 
+	// Reading a version other than the current one needs its own
+	// permission.
+	versionID := r.URL.Query().Get("versionId")
+	action := "s3:GetObject"
+	if versionID != "" {
+		action = "s3:GetObjectVersion"
+	}
+
 	switch getRequestAuthType(r) {
 	default:
 		// For all unknown auth types return error.
@@ -85,7 +100,7 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
-		if s3Error := enforceBucketPolicy("s3:GetObject", bucket, r.URL); s3Error != ErrNone {
+		if s3Error := enforceBucketPolicy(action, bucket, r.URL); s3Error != ErrNone {
 			writeErrorResponse(w, r, s3Error, r.URL.Path)
 			return
 		}
@@ -95,8 +110,26 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 			return
 		}
 	}
+
+	if versionID != "" {
+		if !isValidVersionID(versionID) {
+			writeErrorResponse(w, r, ErrNoSuchVersion, r.URL.Path)
+			return
+		}
+		// Serve the requested version in place of the object.
+		api.ObjectAPI = objectVersionLayer{api.ObjectAPI, versionID}
+	} else if location, statusCode, ok := getObjectRewrite(bucket, object); ok {
+		// Redirect if object matches any bucket rewrite rule.
+		http.Redirect(w, r, location, statusCode)
+		return
+	}
+
 	// Fetch object stat info.
 	objInfo, err := api.ObjectAPI.GetObjectInfo(bucket, object)
+	if _, ok := err.(ObjectNotFound); ok {
+		// Pull missing object from the bucket origin, if any.
+		objInfo, err = pullObjectFromOrigin(api.ObjectAPI, bucket, object)
+	}
 	if err != nil {
 		errorIf(err, "Unable to fetch object info.")
 		apiErr := toAPIErrorCode(err)
@@ -106,29 +139,58 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 		writeErrorResponse(w, r, apiErr, r.URL.Path)
 		return
 	}
+	// Delete markers have no content.
+	if objInfo.DeleteMarker {
+		w.Header().Set(amzDeleteMarkerHeader, "true")
+		w.Header().Set(amzVersionIDHeader, getVersionID(objInfo.VersionID))
+		writeErrorResponse(w, r, ErrMethodNotAllowed, r.URL.Path)
+		return
+	}
+
+	// Website clients not accepting gzip get gzip encoded objects
+	// decoded, ranges are served only of the stored representation.
+	decoded := isDecodedRepresentation(r, objInfo)
+	rangeHeader := r.Header.Get("Range")
+	if decoded || !checkIfRange(r, objInfo) {
+		rangeHeader = ""
+	}
 
-	var hrange *httpRange
-	hrange, err = getRequestedRange(r.Header.Get("Range"), objInfo.Size)
+	hranges, err := getRequestedRanges(rangeHeader, objInfo.Size)
 	if err != nil {
 		writeErrorResponse(w, r, ErrInvalidRange, r.URL.Path)
 		return
 	}
+	if len(hranges) > 1 {
+		api.getObjectRanges(w, r, bucket, object, objInfo, hranges)
+		return
+	}
+	hrange := hranges[0]
 
 	// Set standard object headers.
 	setObjectHeaders(w, objInfo, hrange)
+	if isWebsiteRequest(r) {
+		setWebsiteHeaders(w, objInfo, decoded)
+	}
 
 	// Set any additional requested response headers.
 	setGetRespHeaders(w, r.URL.Query())
 
-	// Verify 'If-Modified-Since' and 'If-Unmodified-Since'.
-	lastModified := objInfo.ModTime
-	if checkLastModified(w, r, lastModified) {
+	// Verify 'If-Match', 'If-Unmodified-Since', 'If-None-Match' and
+	// 'If-Modified-Since'.
+	if checkPreconditions(w, r, objInfo.ModTime) {
 		return
 	}
-	// Verify 'If-Match' and 'If-None-Match'.
-	if checkETag(w, r) {
+
+	if decoded {
+		if err = getDecodedObject(api.ObjectAPI, bucket, object, objInfo.Size, w); err != nil {
+			errorIf(err, "Writing decoded object to client failed.")
+		}
 		return
 	}
+	statusCode := http.StatusOK
+	if hrange.isPartial() {
+		statusCode = http.StatusPartialContent
+	}
 
 	// Get the object.
 	startOffset := hrange.start
@@ -136,59 +198,114 @@ func (api objectAPIHandlers) GetObjectHandler(w http.ResponseWriter, r *http.Req
 	if length == 0 {
 		length = objInfo.Size - startOffset
 	}
-	if err := api.ObjectAPI.GetObject(bucket, object, startOffset, length, w); err != nil {
+	objWriter := &objectResponseWriter{ResponseWriter: w, statusCode: statusCode}
+	if err := api.ObjectAPI.GetObject(bucket, object, startOffset, length, objWriter); err != nil {
 		errorIf(err, "Writing to client failed.")
-		// Do not send error response here, client would have already died.
+		// Errors before any data was written, like lost read quorum,
+		// are sent to the client. Otherwise do not send error response
+		// here, client would have already died.
+		if !objWriter.written {
+			for _, header := range objectResponseHeaders {
+				w.Header().Del(header)
+			}
+			writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		}
 		return
 	}
+	// Empty objects have nothing written.
+	if !objWriter.written {
+		w.WriteHeader(statusCode)
+	}
 }
 
-var unixEpochTime = time.Unix(0, 0)
+// getObjectRanges - writes multiple ranges of the object as a
+// multipart/byteranges response. Ranges sharing an erasure block are
+// read together, so that every block is read once.
+func (api objectAPIHandlers) getObjectRanges(w http.ResponseWriter, r *http.Request, bucket, object string, objInfo ObjectInfo, hranges []*httpRange) {
+	objWriter := &objectResponseWriter{ResponseWriter: w, statusCode: http.StatusPartialContent}
+	mw := multipart.NewWriter(objWriter)
 
-// checkLastModified implements If-Modified-Since and
-// If-Unmodified-Since checks.
-//
-// modtime is the modification time of the resource to be served, or
-// IsZero(). return value is whether this request is now complete.
-func checkLastModified(w http.ResponseWriter, r *http.Request, modtime time.Time) bool {
-	if modtime.IsZero() || modtime.Equal(unixEpochTime) {
-		// If the object doesn't have a modtime (IsZero), or the modtime
-		// is obviously garbage (Unix time == 0), then ignore modtimes
-		// and don't process the If-Modified-Since header.
-		return false
+	// Set standard object headers, the length of the response is not
+	// known upfront.
+	setObjectHeaders(w, objInfo, nil)
+	w.Header().Del("Content-Length")
+
+	// Set any additional requested response headers.
+	setGetRespHeaders(w, r.URL.Query())
+	w.Header().Set("Content-Type", "multipart/byteranges; boundary="+mw.Boundary())
+
+	// Verify 'If-Match', 'If-Unmodified-Since', 'If-None-Match' and
+	// 'If-Modified-Since'.
+	if checkPreconditions(w, r, objInfo.ModTime) {
+		return
 	}
 
-	// The Date-Modified header truncates sub-second precision, so
-	// use mtime < t+1s instead of mtime <= t to check for unmodified.
-	if _, ok := r.Header["If-Modified-Since"]; ok {
-		// Return the object only if it has been modified since the
-		// specified time, otherwise return a 304 (not modified).
-		t, err := time.Parse(http.TimeFormat, r.Header.Get("If-Modified-Since"))
-		if err == nil && modtime.Before(t.Add(1*time.Second)) {
-			h := w.Header()
-			// Remove following headers if already set.
-			delete(h, "Content-Type")
-			delete(h, "Content-Length")
-			delete(h, "Content-Range")
-			w.WriteHeader(http.StatusNotModified)
-			return true
-		}
-	} else if _, ok := r.Header["If-Unmodified-Since"]; ok {
-		// Return the object only if it has not been modified since
-		// the specified time, otherwise return a 412 (precondition failed).
-		t, err := time.Parse(http.TimeFormat, r.Header.Get("If-Unmodified-Since"))
-		if err == nil && modtime.After(t.Add(1*time.Second)) {
-			h := w.Header()
-			// Remove following headers if already set.
-			delete(h, "Content-Type")
-			delete(h, "Content-Length")
-			delete(h, "Content-Range")
-			w.WriteHeader(http.StatusPreconditionFailed)
-			return true
+	for _, span := range planRangeReads(hranges, blockSizeV1) {
+		last := span[len(span)-1]
+		length := last.start + last.length - span[0].start
+		if err := api.ObjectAPI.GetObject(bucket, object, span[0].start, length, newRangesWriter(mw, objInfo.ContentType, span)); err != nil {
+			errorIf(err, "Writing to client failed.")
+			// Errors before any data was written are sent to the
+			// client, otherwise the response ends without its closing
+			// boundary.
+			if !objWriter.written {
+				for _, header := range objectResponseHeaders {
+					w.Header().Del(header)
+				}
+				writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+			}
+			return
 		}
 	}
-	w.Header().Set("Last-Modified", modtime.UTC().Format(http.TimeFormat))
-	return false
+	mw.Close()
+}
+
+// Headers describing the object, removed from error responses.
+var objectResponseHeaders = []string{
+	"Last-Modified",
+	"Content-Type",
+	"Content-Encoding",
+	"Cache-Control",
+	"ETag",
+	"Content-Length",
+	"Content-Range",
+}
+
+// objectResponseWriter - delays the status of an object response until
+// the first write, so that errors before any data is read can still
+// be sent to the client.
+type objectResponseWriter struct {
+	http.ResponseWriter
+	statusCode int
+	written    bool
+}
+
+// Write - writes the status before the first data.
+func (w *objectResponseWriter) Write(b []byte) (int, error) {
+	if !w.written {
+		w.written = true
+		w.ResponseWriter.WriteHeader(w.statusCode)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+var unixEpochTime = time.Unix(0, 0)
+
+// isModifiedSince - returns true if modtime is after the HTTP date,
+// ok is false if the date cannot be parsed. HTTP dates truncate
+// sub-second precision, so mtime < t+1s is unmodified.
+func isModifiedSince(date string, modtime time.Time) (modified bool, ok bool) {
+	t, err := time.Parse(http.TimeFormat, date)
+	if err != nil {
+		return false, false
+	}
+	return !modtime.Before(t.Add(1 * time.Second)), true
+}
+
+// isValidModTime - returns false if the object doesn't have a modtime
+// (IsZero), or the modtime is obviously garbage (Unix time == 0).
+func isValidModTime(modtime time.Time) bool {
+	return !modtime.IsZero() && !modtime.Equal(unixEpochTime)
 }
 
 // canonicalizeETag returns ETag with leading and trailing double-quotes removed,
@@ -204,51 +321,104 @@ func isETagEqual(left, right string) bool {
 	return canonicalizeETag(left) == canonicalizeETag(right)
 }
 
-// checkETag implements If-None-Match and If-Match checks.
+// isETagListMatch - returns true if any ETag of the comma separated list
+// matches etag, or the list is "*". Weak comparison ignores the W/
+// prefix, strong comparison never matches weak ETags.
+func isETagListMatch(etagList, etag string, weak bool) bool {
+	if strings.TrimSpace(etagList) == "*" {
+		return true
+	}
+	if weak {
+		etag = strings.TrimPrefix(etag, "W/")
+	}
+	for _, candidate := range strings.Split(etagList, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = strings.TrimPrefix(candidate, "W/")
+		}
+		if candidate != "" && isETagEqual(candidate, etag) {
+			return true
+		}
+	}
+	return false
+}
+
+// writePreconditionStatus - writes status of a request completed by its
+// preconditions, content headers are removed if already set.
+func writePreconditionStatus(w http.ResponseWriter, statusCode int) {
+	h := w.Header()
+	delete(h, "Content-Type")
+	delete(h, "Content-Length")
+	delete(h, "Content-Range")
+	w.WriteHeader(statusCode)
+}
+
+// checkPreconditions implements If-Match, If-Unmodified-Since,
+// If-None-Match and If-Modified-Since checks, evaluated in the order of
+// RFC 7232 section 6.
 //
 // The ETag must have been previously set in the ResponseWriter's
-// headers. The return value is whether this request is now considered
-// done.
-func checkETag(w http.ResponseWriter, r *http.Request) bool {
-	// writer always has quoted string
-	// transform reader's etag to
+// headers, modtime is the modification time of the resource to be
+// served. The return value is whether this request is now complete.
+func checkPreconditions(w http.ResponseWriter, r *http.Request, modtime time.Time) bool {
 	if r.Method != "GET" && r.Method != "HEAD" {
 		return false
 	}
 	etag := w.Header().Get("ETag")
-	// Must know ETag.
-	if etag == "" {
-		return false
+
+	// Return the object only if its entity tag (ETag) is the same as
+	// one of the specified, otherwise return a 412 (precondition
+	// failed). If-Unmodified-Since is evaluated only without If-Match.
+	if im := r.Header.Get("If-Match"); im != "" {
+		if etag != "" && !isETagListMatch(im, etag, false) {
+			writePreconditionStatus(w, http.StatusPreconditionFailed)
+			return true
+		}
+	} else if ius := r.Header.Get("If-Unmodified-Since"); ius != "" && isValidModTime(modtime) {
+		if modified, ok := isModifiedSince(ius, modtime); ok && modified {
+			writePreconditionStatus(w, http.StatusPreconditionFailed)
+			return true
+		}
 	}
-	if inm := r.Header.Get("If-None-Match"); !isETagEqual(inm, "") {
-		// Return the object only if its entity tag (ETag) is
-		// different from the one specified; otherwise, return a 304
-		// (not modified).
-		if isETagEqual(inm, etag) || isETagEqual(inm, "*") {
-			h := w.Header()
-			// Remove following headers if already set.
-			delete(h, "Content-Type")
-			delete(h, "Content-Length")
-			delete(h, "Content-Range")
-			w.WriteHeader(http.StatusNotModified)
+
+	// Return the object only if its entity tag (ETag) is different from
+	// all of the specified, otherwise return a 304 (not modified).
+	// If-Modified-Since is evaluated only without If-None-Match.
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if etag != "" && isETagListMatch(inm, etag, true) {
+			writePreconditionStatus(w, http.StatusNotModified)
 			return true
 		}
-	} else if im := r.Header.Get("If-Match"); !isETagEqual(im, "") {
-		// Return the object only if its entity tag (ETag) is the same
-		// as the one specified; otherwise, return a 412 (precondition failed).
-		if !isETagEqual(im, etag) {
-			h := w.Header()
-			// Remove following headers if already set.
-			delete(h, "Content-Type")
-			delete(h, "Content-Length")
-			delete(h, "Content-Range")
-			w.WriteHeader(http.StatusPreconditionFailed)
+	} else if ims := r.Header.Get("If-Modified-Since"); ims != "" && isValidModTime(modtime) {
+		if modified, ok := isModifiedSince(ims, modtime); ok && !modified {
+			writePreconditionStatus(w, http.StatusNotModified)
 			return true
 		}
 	}
 	return false
 }
 
+// checkIfRange implements If-Range, returns true if the requested range
+// is to be served. Ranges are served only if the ETag strongly matches,
+// or the date exactly matches the modification time.
+func checkIfRange(r *http.Request, objInfo ObjectInfo) bool {
+	ifRange := strings.TrimSpace(r.Header.Get("If-Range"))
+	if ifRange == "" {
+		return true
+	}
+	if strings.HasPrefix(ifRange, "\"") || strings.HasPrefix(ifRange, "W/") {
+		return objInfo.MD5Sum != "" && isETagListMatch(ifRange, "\""+objInfo.MD5Sum+"\"", false)
+	}
+	t, err := time.Parse(http.TimeFormat, ifRange)
+	if err != nil || !isValidModTime(objInfo.ModTime) {
+		return false
+	}
+	return objInfo.ModTime.UTC().Truncate(time.Second).Equal(t)
+}
+
 // HeadObjectHandler - HEAD Object
 // -----------
 // The HEAD operation retrieves metadata from an object without returning the object itself.
@@ -258,6 +428,14 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 	bucket = vars["bucket"]
 	object = vars["object"]
 
+	// Reading a version other than the current one needs its own
+	// permission.
+	versionID := r.URL.Query().Get("versionId")
+	action := "s3:GetObject"
+	if versionID != "" {
+		action = "s3:GetObjectVersion"
+	}
+
 	switch getRequestAuthType(r) {
 	default:
 		// For all unknown auth types return error.
@@ -265,7 +443,7 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
-		if s3Error := enforceBucketPolicy("s3:GetObject", bucket, r.URL); s3Error != ErrNone {
+		if s3Error := enforceBucketPolicy(action, bucket, r.URL); s3Error != ErrNone {
 			writeErrorResponse(w, r, s3Error, r.URL.Path)
 			return
 		}
@@ -276,7 +454,24 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 		}
 	}
 
+	if versionID != "" {
+		if !isValidVersionID(versionID) {
+			writeErrorResponse(w, r, ErrNoSuchVersion, r.URL.Path)
+			return
+		}
+		// Serve the requested version in place of the object.
+		api.ObjectAPI = objectVersionLayer{api.ObjectAPI, versionID}
+	} else if location, statusCode, ok := getObjectRewrite(bucket, object); ok {
+		// Redirect if object matches any bucket rewrite rule.
+		http.Redirect(w, r, location, statusCode)
+		return
+	}
+
 	objInfo, err := api.ObjectAPI.GetObjectInfo(bucket, object)
+	if _, ok := err.(ObjectNotFound); ok {
+		// Pull missing object from the bucket origin, if any.
+		objInfo, err = pullObjectFromOrigin(api.ObjectAPI, bucket, object)
+	}
 	if err != nil {
 		errorIf(err, "Unable to fetch object info.")
 		apiErr := toAPIErrorCode(err)
@@ -286,18 +481,23 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 		writeErrorResponse(w, r, apiErr, r.URL.Path)
 		return
 	}
+	// Delete markers have no content.
+	if objInfo.DeleteMarker {
+		w.Header().Set(amzDeleteMarkerHeader, "true")
+		w.Header().Set(amzVersionIDHeader, getVersionID(objInfo.VersionID))
+		writeErrorResponse(w, r, ErrMethodNotAllowed, r.URL.Path)
+		return
+	}
 
 	// Set standard object headers.
 	setObjectHeaders(w, objInfo, nil)
-
-	// Verify 'If-Modified-Since' and 'If-Unmodified-Since'.
-	lastModified := objInfo.ModTime
-	if checkLastModified(w, r, lastModified) {
-		return
+	if isWebsiteRequest(r) {
+		setWebsiteHeaders(w, objInfo, isDecodedRepresentation(r, objInfo))
 	}
 
-	// Verify 'If-Match' and 'If-None-Match'.
-	if checkETag(w, r) {
+	// Verify 'If-Match', 'If-Unmodified-Since', 'If-None-Match' and
+	// 'If-Modified-Since'.
+	if checkPreconditions(w, r, objInfo.ModTime) {
 		return
 	}
 
@@ -305,6 +505,61 @@ func (api objectAPIHandlers) HeadObjectHandler(w http.ResponseWriter, r *http.Re
 	w.WriteHeader(http.StatusOK)
 }
 
+// GetObjectAttributesHandler - GET Object attributes
+// ----------
+// This implementation of the GET operation returns attributes listed
+// in X-Amz-Object-Attributes without the object data. Provenance is a
+// Minio extension reporting the deployment, node and time the object
+// was written at.
+func (api objectAPIHandlers) GetObjectAttributesHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypeAnonymous:
+		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
+		if s3Error := enforceBucketPolicy("s3:GetObject", bucket, r.URL); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	objInfo, err := api.ObjectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		errorIf(err, "Unable to fetch object info.")
+		apiErr := toAPIErrorCode(err)
+		if apiErr == ErrNoSuchKey {
+			apiErr = errAllowableObjectNotFound(bucket, r)
+		}
+		writeErrorResponse(w, r, apiErr, r.URL.Path)
+		return
+	}
+
+	var attributes []string
+	for _, attribute := range strings.Split(r.Header.Get("X-Amz-Object-Attributes"), ",") {
+		if attribute = strings.TrimSpace(attribute); attribute != "" {
+			attributes = append(attributes, attribute)
+		}
+	}
+	response := generateGetObjectAttributesResponse(objInfo, attributes)
+	encodedSuccessResponse := encodeResponse(response)
+	// write headers
+	setCommonHeaders(w)
+	w.Header().Set("Last-Modified", objInfo.ModTime.UTC().Format(http.TimeFormat))
+	// write success response.
+	writeSuccessResponse(w, encodedSuccessResponse)
+}
+
 // CopyObjectHandler - Copy Object
 // ----------
 // This implementation of the PUT operation adds an object to a bucket
@@ -337,27 +592,42 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 
 	// objectSource
 	objectSource := r.Header.Get("X-Amz-Copy-Source")
+	sourceBucket, sourceObject := getCopySource(objectSource)
 
-	// Skip the first element if it is '/', split the rest.
-	if strings.HasPrefix(objectSource, "/") {
-		objectSource = objectSource[1:]
-	}
-	splits := strings.SplitN(objectSource, "/", 2)
-
-	// Save sourceBucket and sourceObject extracted from url Path.
-	var sourceBucket, sourceObject string
-	if len(splits) == 2 {
-		sourceBucket = splits[0]
-		sourceObject = splits[1]
-	}
 	// If source object is empty, reply back error.
 	if sourceObject == "" {
 		writeErrorResponse(w, r, ErrInvalidCopySource, r.URL.Path)
 		return
 	}
 
-	// Source and destination objects cannot be same, reply back error.
-	if sourceObject == object && sourceBucket == bucket {
+	// Anonymous requests also need read access on the copy source.
+	if s3Error := enforceCopySourcePolicy(r, sourceBucket, sourceObject); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, objectSource)
+		return
+	}
+
+	// Metadata is copied from the source unless replaced.
+	metadataDirective := r.Header.Get("X-Amz-Metadata-Directive")
+	switch metadataDirective {
+	case "", metadataDirectiveCopy, metadataDirectiveReplace:
+	default:
+		writeErrorResponse(w, r, ErrInvalidMetadataDirective, r.URL.Path)
+		return
+	}
+	replaceMetadata := metadataDirective == metadataDirectiveReplace
+
+	// Tags are copied from the source unless replaced as well.
+	taggingDirective := r.Header.Get(amzTaggingDirectiveHeader)
+	switch taggingDirective {
+	case "", metadataDirectiveCopy, metadataDirectiveReplace:
+	default:
+		writeErrorResponse(w, r, ErrInvalidTaggingDirective, r.URL.Path)
+		return
+	}
+
+	// Source and destination objects cannot be same unless the
+	// metadata is replaced, reply back error.
+	if sourceObject == object && sourceBucket == bucket && !replaceMetadata {
 		writeErrorResponse(w, r, ErrInvalidCopyDest, r.URL.Path)
 		return
 	}
@@ -368,18 +638,9 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		writeErrorResponse(w, r, toAPIErrorCode(err), objectSource)
 		return
 	}
-	// Verify before writing.
-
-	// Verify x-amz-copy-source-if-modified-since and
-	// x-amz-copy-source-if-unmodified-since.
-	lastModified := objInfo.ModTime
-	if checkCopySourceLastModified(w, r, lastModified) {
-		return
-	}
-
-	// Verify x-amz-copy-source-if-match and
-	// x-amz-copy-source-if-none-match.
-	if checkCopySourceETag(w, r) {
+	// Verify x-amz-copy-source-if-* conditions before writing.
+	if s3Error := checkCopySourceConditions(r, objInfo); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, objectSource)
 		return
 	}
 
@@ -389,34 +650,61 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	pipeReader, pipeWriter := io.Pipe()
-	go func() {
-		startOffset := int64(0) // Read the whole file.
-		// Get the object.
-		gErr := api.ObjectAPI.GetObject(sourceBucket, sourceObject, startOffset, objInfo.Size, pipeWriter)
-		if gErr != nil {
-			errorIf(gErr, "Unable to read an object.")
-			pipeWriter.CloseWithError(gErr)
+	// Storage classes are not supported by all backends.
+	if storageClass := r.Header.Get(storageClassMetaKey); storageClass != "" && storageClass != storageClassStandard {
+		if !api.ObjectAPI.Capabilities().StorageClasses {
+			writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
 			return
 		}
-		pipeWriter.Close() // Close.
-	}()
-
-	// Size of object.
-	size := objInfo.Size
+	}
 
 	// Save metadata.
-	metadata := make(map[string]string)
-	// Save other metadata if available.
-	metadata["content-type"] = objInfo.ContentType
-	metadata["content-encoding"] = objInfo.ContentEncoding
-	// Do not set `md5sum` as CopyObject will not keep the
-	// same md5sum as the source.
+	var metadata map[string]string
+	if replaceMetadata {
+		metadata = getReplacedCopyMetadata(r.Header, objInfo)
+	} else {
+		metadata = getCopiedMetadata(objInfo)
+	}
+	if taggingDirective == metadataDirectiveReplace {
+		if s3Error := setObjectTagsFromHeader(r.Header, metadata); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	} else {
+		setObjectTags(metadata, objInfo.Tags)
+	}
+	// Storage class decides the storage tier of the object copy.
+	if storageClass := r.Header.Get(storageClassMetaKey); storageClass != "" {
+		metadata[storageClassMetaKey] = storageClass
+	}
+	// TTL of the object copy, the source TTL is not copied.
+	if s3Error := setObjectExpiryFromHeader(r.Header, metadata); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	// Apply default metadata of the bucket not set on the source.
+	applyBucketDefaultMetadata(bucket, metadata)
+	// Client side encryption envelope, if any, must be complete.
+	if s3Error := checkCSEMetadata(metadata); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	// Presigned URL constraints apply to the object copy.
+	if s3Error := checkPresignSize(r, objInfo.Size); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, objectSource)
+		return
+	}
+	if s3Error := checkPresignContentType(r, metadata["content-type"]); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
 
-	// Create the object.
-	md5Sum, err := api.ObjectAPI.PutObject(bucket, object, size, pipeReader, metadata)
+	// Copy the object, backends copy without reading it through
+	// the server where possible. Copies onto the source only replace
+	// its metadata.
+	md5Sum, err := api.ObjectAPI.CopyObject(sourceBucket, sourceObject, bucket, object, metadata)
 	if err != nil {
-		errorIf(err, "Unable to create an object.")
+		errorIf(err, "Unable to copy an object.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
@@ -428,155 +716,343 @@ func (api objectAPIHandlers) CopyObjectHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	replicateObjectWrite(w, api.ObjectAPI, bucket, object)
+	if objInfo.VersionID != "" {
+		w.Header().Set(amzVersionIDHeader, objInfo.VersionID)
+	}
+
 	response := generateCopyObjectResponse(md5Sum, objInfo.ModTime)
 	encodedSuccessResponse := encodeResponse(response)
 	// write headers
 	setCommonHeaders(w)
 	// write success response.
 	writeSuccessResponse(w, encodedSuccessResponse)
-	// Explicitly close the reader, to avoid fd leaks.
-	pipeReader.Close()
 }
 
-// checkCopySource implements x-amz-copy-source-if-modified-since and
-// x-amz-copy-source-if-unmodified-since checks.
-//
-// modtime is the modification time of the resource to be served, or
-// IsZero(). return value is whether this request is now complete.
-func checkCopySourceLastModified(w http.ResponseWriter, r *http.Request, modtime time.Time) bool {
-	if modtime.IsZero() || modtime.Equal(unixEpochTime) {
-		// If the object doesn't have a modtime (IsZero), or the modtime
-		// is obviously garbage (Unix time == 0), then ignore modtimes
-		// and don't process the If-Modified-Since header.
-		return false
+// getCopiedMetadata - returns metadata of an object copy with the
+// metadata of the source.
+func getCopiedMetadata(objInfo ObjectInfo) map[string]string {
+	metadata := make(map[string]string)
+	metadata["content-type"] = objInfo.ContentType
+	metadata["content-encoding"] = objInfo.ContentEncoding
+	metadata["cache-control"] = objInfo.CacheControl
+	// User metadata is copied from the source.
+	for key, value := range objInfo.UserDefined {
+		metadata[key] = value
 	}
+	return metadata
+}
+
+// getReplacedCopyMetadata - returns metadata of an object copy with
+// the metadata of the request. Client side encryption envelope of the
+// source describes the copied data, it is always kept.
+func getReplacedCopyMetadata(header http.Header, objInfo ObjectInfo) map[string]string {
+	metadata := make(map[string]string)
+	metadata["content-type"] = header.Get("Content-Type")
+	metadata["content-encoding"] = header.Get("Content-Encoding")
+	metadata["cache-control"] = header.Get("Cache-Control")
+	for key, value := range extractUserMetadata(header) {
+		metadata[key] = value
+	}
+	if hasCSEMetadata(objInfo.UserDefined) {
+		for _, key := range cseMetadataKeys {
+			delete(metadata, key)
+			if value, ok := objInfo.UserDefined[key]; ok {
+				metadata[key] = value
+			}
+		}
+	}
+	return metadata
+}
+
+// getCopySource - returns source bucket and object of the
+// X-Amz-Copy-Source header, object is empty for invalid sources.
+func getCopySource(objectSource string) (sourceBucket, sourceObject string) {
+	// Skip the first element if it is '/', split the rest.
+	if strings.HasPrefix(objectSource, "/") {
+		objectSource = objectSource[1:]
+	}
+	splits := strings.SplitN(objectSource, "/", 2)
+
+	// Save sourceBucket and sourceObject extracted from url Path.
+	if len(splits) == 2 {
+		sourceBucket = splits[0]
+		sourceObject = splits[1]
+	}
+	return sourceBucket, sourceObject
+}
+
+// enforceCopySourcePolicy - verifies that anonymous requests are allowed
+// to read the copy source, authenticated requests are always allowed.
+func enforceCopySourcePolicy(r *http.Request, sourceBucket, sourceObject string) APIErrorCode {
+	if getRequestAuthType(r) != authTypeAnonymous {
+		return ErrNone
+	}
+	// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
+	sourceURL := &url.URL{Path: "/" + sourceBucket + "/" + sourceObject}
+	return enforceBucketPolicy("s3:GetObject", sourceBucket, sourceURL)
+}
+
+// checkCopySourceConditions implements x-amz-copy-source-if-match,
+// x-amz-copy-source-if-none-match, x-amz-copy-source-if-modified-since
+// and x-amz-copy-source-if-unmodified-since checks against the source
+// object. Returns ErrPreconditionFailed if any of the conditions do
+// not hold, ErrNone otherwise.
+func checkCopySourceConditions(r *http.Request, objInfo ObjectInfo) APIErrorCode {
+	etag := objInfo.MD5Sum
+	modtime := objInfo.ModTime
+	// Ignore the modtimes if the object doesn't have a modtime (IsZero),
+	// or the modtime is obviously garbage (Unix time == 0).
+	hasModTime := !modtime.IsZero() && !modtime.Equal(unixEpochTime)
+
+	// Copy the object only if its entity tag (ETag) is the same as
+	// the one specified.
+	ifMatch := r.Header.Get("X-Amz-Copy-Source-If-Match")
+	if ifMatch != "" && !isETagEqual(ifMatch, etag) && !isETagEqual(ifMatch, "*") {
+		return ErrPreconditionFailed
+	}
+
+	// Copy the object only if its entity tag (ETag) is different from
+	// the one specified.
+	ifNoneMatch := r.Header.Get("X-Amz-Copy-Source-If-None-Match")
+	if ifNoneMatch != "" && (isETagEqual(ifNoneMatch, etag) || isETagEqual(ifNoneMatch, "*")) {
+		return ErrPreconditionFailed
+	}
+
+	if !hasModTime {
+		return ErrNone
+	}
+
 	// The Date-Modified header truncates sub-second precision, so
 	// use mtime < t+1s instead of mtime <= t to check for unmodified.
-	if _, ok := r.Header["x-amz-copy-source-if-modified-since"]; ok {
-		// Return the object only if it has been modified since the
-		// specified time, otherwise return a 304 error (not modified).
-		t, err := time.Parse(http.TimeFormat, r.Header.Get("x-amz-copy-source-if-modified-since"))
-		if err == nil && modtime.Before(t.Add(1*time.Second)) {
-			h := w.Header()
-			// Remove Content headers if set
-			delete(h, "Content-Type")
-			delete(h, "Content-Length")
-			delete(h, "Content-Range")
-			w.WriteHeader(http.StatusNotModified)
-			return true
+
+	// Copy the object only if it has not been modified since the
+	// specified time. As with S3, a matching if-match condition
+	// takes precedence over this one.
+	if ifMatch == "" {
+		if t, err := time.Parse(http.TimeFormat, r.Header.Get("X-Amz-Copy-Source-If-Unmodified-Since")); err == nil {
+			if modtime.After(t.Add(1 * time.Second)) {
+				return ErrPreconditionFailed
+			}
 		}
-	} else if _, ok := r.Header["x-amz-copy-source-if-unmodified-since"]; ok {
-		// Return the object only if it has not been modified since the
-		// specified time, otherwise return a 412 error (precondition failed).
-		t, err := time.Parse(http.TimeFormat, r.Header.Get("x-amz-copy-source-if-unmodified-since"))
-		if err == nil && modtime.After(t.Add(1*time.Second)) {
-			h := w.Header()
-			// Remove Content headers if set
-			delete(h, "Content-Type")
-			delete(h, "Content-Length")
-			delete(h, "Content-Range")
-			w.WriteHeader(http.StatusPreconditionFailed)
-			return true
+	}
+
+	// Copy the object only if it has been modified since the
+	// specified time.
+	if t, err := time.Parse(http.TimeFormat, r.Header.Get("X-Amz-Copy-Source-If-Modified-Since")); err == nil {
+		if modtime.Before(t.Add(1 * time.Second)) {
+			return ErrPreconditionFailed
 		}
 	}
-	w.Header().Set("Last-Modified", modtime.UTC().Format(http.TimeFormat))
-	return false
+	return ErrNone
 }
 
-// checkCopySourceETag implements x-amz-copy-source-if-match and
-// x-amz-copy-source-if-none-match checks.
-//
-// The ETag must have been previously set in the ResponseWriter's
-// headers. The return value is whether this request is now considered
-// complete.
-func checkCopySourceETag(w http.ResponseWriter, r *http.Request) bool {
-	etag := w.Header().Get("ETag")
-	// Tag must be provided...
-	if etag == "" {
-		return false
+// PutObjectHandler - PUT Object
+// ----------
+// This implementation of the PUT operation adds an object to a bucket.
+func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Request) {
+	// If the matching failed, it means that the X-Amz-Copy-Source was
+	// wrong, fail right here.
+	if _, ok := r.Header["X-Amz-Copy-Source"]; ok {
+		writeErrorResponse(w, r, ErrInvalidCopySource, r.URL.Path)
+		return
+	}
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	// Get Content-Md5 sent by client and verify if valid
+	md5Bytes, err := checkValidMD5(r.Header.Get("Content-Md5"))
+	if err != nil {
+		errorIf(err, "Unable to validate content-md5 format.")
+		writeErrorResponse(w, r, ErrInvalidDigest, r.URL.Path)
+		return
+	}
+	/// if Content-Length is unknown/missing, deny the request
+	size := r.ContentLength
+	if size == -1 && !contains(r.TransferEncoding, "chunked") {
+		writeErrorResponse(w, r, ErrMissingContentLength, r.URL.Path)
+		return
+	}
+	/// maximum Upload size for objects in a single operation
+	if isMaxObjectSize(size) {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+
+	// Save metadata.
+	metadata := make(map[string]string)
+	// Make sure we hex encode md5sum here.
+	metadata["md5Sum"] = hex.EncodeToString(md5Bytes)
+	// Save other metadata if available.
+	metadata["content-type"] = r.Header.Get("Content-Type")
+	metadata["content-encoding"] = r.Header.Get("Content-Encoding")
+	metadata["cache-control"] = r.Header.Get("Cache-Control")
+	// Storage class decides the storage tier of the object.
+	if storageClass := r.Header.Get(storageClassMetaKey); storageClass != "" {
+		// Storage classes are not supported by all backends.
+		if storageClass != storageClassStandard && !api.ObjectAPI.Capabilities().StorageClasses {
+			writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+			return
+		}
+		metadata[storageClassMetaKey] = storageClass
+	}
+	for key, value := range extractUserMetadata(r.Header) {
+		metadata[key] = value
+	}
+	// Tags of the object, if set.
+	if s3Error := setObjectTagsFromHeader(r.Header, metadata); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	// Apply default metadata of the bucket not set by the client.
+	applyBucketDefaultMetadata(bucket, metadata)
+	// Client side encryption envelope, if any, must be complete.
+	if s3Error := checkCSEMetadata(metadata); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	// TTL of the object, if set.
+	if s3Error := setObjectExpiryFromHeader(r.Header, metadata); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	// With 'If-None-Match: *' the upload only creates the object, it
+	// fails if the object exists. Other entity tags are not supported.
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		if inm != "*" {
+			writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+			return
+		}
+		metadata[objectCreateOnlyKey] = "true"
+	}
+
+	// Uploads to the bucket may be required to carry a checksum.
+	if s3Error := checkRequiredChecksum(r, bucket, true); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	var md5Sum string
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypeAnonymous:
+		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
+		if s3Error := enforceBucketPolicy("s3:PutObject", bucket, r.URL); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+		// Create anonymous object.
+		md5Sum, err = api.ObjectAPI.PutObject(bucket, object, size, r.Body, metadata)
+	case authTypePresigned, authTypeSigned:
+		// Reject uploads violating presigned URL constraints before
+		// reading the body.
+		if isRequestPresignedSignatureV4(r) {
+			if _, s3Error := checkPresignConstraints(r); s3Error != ErrNone {
+				writeErrorResponse(w, r, s3Error, r.URL.Path)
+				return
+			}
+			limitPresignedBody(r)
+		}
+		// Initialize a pipe for data pipe line.
+		reader, writer := io.Pipe()
+		var wg = &sync.WaitGroup{}
+		// Start writing in a routine.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shaWriter := sha256.New()
+			multiWriter := io.MultiWriter(shaWriter, writer)
+			if _, wErr := io.CopyN(multiWriter, r.Body, size); wErr != nil {
+				// Pipe closed.
+				if wErr == io.ErrClosedPipe {
+					return
+				}
+				errorIf(wErr, "Unable to read from HTTP body.")
+				writer.CloseWithError(wErr)
+				return
+			}
+			shaPayload := shaWriter.Sum(nil)
+			validateRegion := true // Validate region.
+			var s3Error APIErrorCode
+			if isRequestSignatureV4(r) {
+				s3Error = doesSignatureMatch(hex.EncodeToString(shaPayload), r, validateRegion)
+			} else if isRequestPresignedSignatureV4(r) {
+				s3Error = doesPresignedSignatureMatch(hex.EncodeToString(shaPayload), r, validateRegion)
+			}
+			var sErr error
+			if s3Error != ErrNone {
+				if s3Error == ErrSignatureDoesNotMatch {
+					sErr = errSignatureMismatch
+				} else {
+					sErr = fmt.Errorf("%v", getAPIError(s3Error))
+				}
+				writer.CloseWithError(sErr)
+				return
+			}
+			writer.Close()
+		}()
+
+		// Create object.
+		md5Sum, err = api.ObjectAPI.PutObject(bucket, object, size, reader, metadata)
+		// Close the pipe.
+		reader.Close()
+		// Wait for all the routines to finish.
+		wg.Wait()
 	}
-	if inm := r.Header.Get("x-amz-copy-source-if-none-match"); inm != "" {
-		// Return the object only if its entity tag (ETag) is different
-		// from the one specified; otherwise, return a 304 (not modified).
-		if r.Method != "PUT" {
-			return false
-		}
-		if inm == etag || inm == "*" {
-			h := w.Header()
-			// Remove Content headers if set
-			delete(h, "Content-Type")
-			delete(h, "Content-Length")
-			delete(h, "Content-Range")
-			w.WriteHeader(http.StatusNotModified)
-			return true
-		}
-	} else if inm := r.Header.Get("x-amz-copy-source-if-match"); inm != "" {
-		// Return the object only if its entity tag (ETag) is the same
-		// as the one specified; otherwise, return a 412 (precondition failed).
-		if r.Method != "PUT" {
-			return false
-		}
-		if inm != etag {
-			h := w.Header()
-			// Remove Content headers if set
-			delete(h, "Content-Type")
-			delete(h, "Content-Length")
-			delete(h, "Content-Range")
-			w.WriteHeader(http.StatusPreconditionFailed)
-			return true
-		}
+	if err != nil {
+		errorIf(err, "Unable to create an object.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
 	}
-	return false
-
+	replicateObjectWrite(w, api.ObjectAPI, bucket, object)
+	setVersionIDHeader(w, api.ObjectAPI, bucket, object)
+	if md5Sum != "" {
+		w.Header().Set("ETag", "\""+md5Sum+"\"")
+	}
+	writeSuccessResponse(w, nil)
 }
 
-// PutObjectHandler - PUT Object
+// PatchObjectHandler - PUT Object ?patch=<offset>
 // ----------
-// This implementation of the PUT operation adds an object to a bucket.
-func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Request) {
-	// If the matching failed, it means that the X-Amz-Copy-Source was
-	// wrong, fail right here.
-	if _, ok := r.Header["X-Amz-Copy-Source"]; ok {
-		writeErrorResponse(w, r, ErrInvalidCopySource, r.URL.Path)
-		return
-	}
+// This implementation of the PUT operation is a Minio extension which
+// replaces a byte range of an existing object with the request body,
+// the object grows if the body extends past its end.
+func (api objectAPIHandlers) PatchObjectHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	bucket := vars["bucket"]
 	object := vars["object"]
 
-	// Get Content-Md5 sent by client and verify if valid
-	md5Bytes, err := checkValidMD5(r.Header.Get("Content-Md5"))
-	if err != nil {
-		errorIf(err, "Unable to validate content-md5 format.")
-		writeErrorResponse(w, r, ErrInvalidDigest, r.URL.Path)
+	offset, err := strconv.ParseInt(vars["offset"], 10, 64)
+	if err != nil || offset < 0 {
+		writeErrorResponse(w, r, ErrInvalidRange, r.URL.Path)
 		return
 	}
 	/// if Content-Length is unknown/missing, deny the request
 	size := r.ContentLength
-	if size == -1 && !contains(r.TransferEncoding, "chunked") {
+	if size == -1 {
 		writeErrorResponse(w, r, ErrMissingContentLength, r.URL.Path)
 		return
 	}
 	/// maximum Upload size for objects in a single operation
-	if isMaxObjectSize(size) {
+	if isMaxObjectSize(offset + size) {
 		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
 		return
 	}
-
-	// Save metadata.
-	metadata := make(map[string]string)
-	// Make sure we hex encode md5sum here.
-	metadata["md5Sum"] = hex.EncodeToString(md5Bytes)
-	// Save other metadata if available.
-	metadata["content-type"] = r.Header.Get("Content-Type")
-	metadata["content-encoding"] = r.Header.Get("Content-Encoding")
-	for key := range r.Header {
-		cKey := http.CanonicalHeaderKey(key)
-		if strings.HasPrefix(cKey, "x-amz-meta-") {
-			metadata[cKey] = r.Header.Get(cKey)
-		} else if strings.HasPrefix(key, "x-minio-meta-") {
-			metadata[cKey] = r.Header.Get(cKey)
-		}
+	// Content-Md5 of the patch is not verified, only signed payloads
+	// satisfy a checksum required by the bucket.
+	if s3Error := checkRequiredChecksum(r, bucket, false); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	// Patching ciphertext in place would corrupt client side
+	// encrypted objects.
+	if objInfo, oErr := api.ObjectAPI.GetObjectInfo(bucket, object); oErr == nil && getCSEVersion(objInfo.UserDefined) != "" {
+		writeErrorResponse(w, r, ErrCSEObjectNotModifiable, r.URL.Path)
+		return
 	}
 
 	var md5Sum string
@@ -586,13 +1062,12 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
 		return
 	case authTypeAnonymous:
-		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
+		// Patching is writing the object.
 		if s3Error := enforceBucketPolicy("s3:PutObject", bucket, r.URL); s3Error != ErrNone {
 			writeErrorResponse(w, r, s3Error, r.URL.Path)
 			return
 		}
-		// Create anonymous object.
-		md5Sum, err = api.ObjectAPI.PutObject(bucket, object, size, r.Body, metadata)
+		md5Sum, err = api.ObjectAPI.PatchObject(bucket, object, offset, size, r.Body)
 	case authTypePresigned, authTypeSigned:
 		// Initialize a pipe for data pipe line.
 		reader, writer := io.Pipe()
@@ -633,24 +1108,143 @@ func (api objectAPIHandlers) PutObjectHandler(w http.ResponseWriter, r *http.Req
 			writer.Close()
 		}()
 
-		// Create object.
-		md5Sum, err = api.ObjectAPI.PutObject(bucket, object, size, reader, metadata)
+		// Patch object.
+		md5Sum, err = api.ObjectAPI.PatchObject(bucket, object, offset, size, reader)
 		// Close the pipe.
 		reader.Close()
 		// Wait for all the routines to finish.
 		wg.Wait()
 	}
 	if err != nil {
-		errorIf(err, "Unable to create an object.")
+		errorIf(err, "Unable to patch an object.")
 		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
 		return
 	}
+	replicateObjectWrite(w, api.ObjectAPI, bucket, object)
+	setVersionIDHeader(w, api.ObjectAPI, bucket, object)
 	if md5Sum != "" {
 		w.Header().Set("ETag", "\""+md5Sum+"\"")
 	}
 	writeSuccessResponse(w, nil)
 }
 
+// ComposeObjectHandler - POST Object ?compose
+// ----------
+// This implementation of the POST operation is a Minio extension which
+// creates an object from the concatenation of source objects of the
+// bucket, in the order listed.
+func (api objectAPIHandlers) ComposeObjectHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypeAnonymous:
+		// Sources are verified below, once they are known.
+		if s3Error := enforceBucketPolicy("s3:PutObject", bucket, r.URL); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+	composeBytes, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		errorIf(err, "Unable to compose object.")
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+		return
+	}
+	compose := &composeObject{}
+	if err = xml.Unmarshal(composeBytes, compose); err != nil {
+		errorIf(err, "Unable to parse compose object XML.")
+		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		return
+	}
+	if len(compose.Sources) == 0 {
+		writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		return
+	}
+	if len(compose.Sources) > maxComposeSources {
+		writeErrorResponse(w, r, ErrTooManyComposeSources, r.URL.Path)
+		return
+	}
+	var sources []string
+	var size int64
+	for _, source := range compose.Sources {
+		if getRequestAuthType(r) == authTypeAnonymous {
+			sourceURL := &url.URL{Path: "/" + bucket + "/" + source.Key}
+			if s3Error := enforceBucketPolicy("s3:GetObject", bucket, sourceURL); s3Error != ErrNone {
+				writeErrorResponse(w, r, s3Error, r.URL.Path)
+				return
+			}
+		}
+		sourceInfo, err := api.ObjectAPI.GetObjectInfo(bucket, source.Key)
+		if err != nil {
+			errorIf(err, "Unable to fetch object info.")
+			writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+			return
+		}
+		if getCSEVersion(sourceInfo.UserDefined) != "" {
+			writeErrorResponse(w, r, ErrCSEObjectNotModifiable, r.URL.Path)
+			return
+		}
+		sources = append(sources, source.Key)
+		size += sourceInfo.Size
+	}
+	// Presigned URL constraints apply to the composed object.
+	if s3Error := checkPresignSize(r, size); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
+	// Save metadata.
+	metadata := make(map[string]string)
+	// Save other metadata if available.
+	metadata["content-type"] = r.Header.Get("Content-Type")
+	metadata["content-encoding"] = r.Header.Get("Content-Encoding")
+	metadata["cache-control"] = r.Header.Get("Cache-Control")
+	for key, value := range extractUserMetadata(r.Header) {
+		metadata[key] = value
+	}
+	// Apply default metadata of the bucket not set by the client.
+	applyBucketDefaultMetadata(bucket, metadata)
+	// Concatenated ciphertexts cannot be decrypted with any envelope.
+	if hasCSEMetadata(metadata) {
+		writeErrorResponse(w, r, ErrCSEObjectNotModifiable, r.URL.Path)
+		return
+	}
+
+	md5Sum, err := api.ObjectAPI.ComposeObject(bucket, object, sources, metadata)
+	if err != nil {
+		errorIf(err, "Unable to compose an object.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	objInfo, err := api.ObjectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		errorIf(err, "Unable to fetch object info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	if objInfo.VersionID != "" {
+		w.Header().Set(amzVersionIDHeader, objInfo.VersionID)
+	}
+	replicateObjectWrite(w, api.ObjectAPI, bucket, object)
+	response := generateComposeObjectResponse(md5Sum, objInfo.ModTime)
+	encodedSuccessResponse := encodeResponse(response)
+	// write headers
+	setCommonHeaders(w)
+	// write success response.
+	writeSuccessResponse(w, encodedSuccessResponse)
+}
+
 /// Multipart objectAPIHandlers
 
 // NewMultipartUploadHandler - New multipart upload
@@ -683,13 +1277,21 @@ func (api objectAPIHandlers) NewMultipartUploadHandler(w http.ResponseWriter, r
 	// Save other metadata if available.
 	metadata["content-type"] = r.Header.Get("Content-Type")
 	metadata["content-encoding"] = r.Header.Get("Content-Encoding")
-	for key := range r.Header {
-		cKey := http.CanonicalHeaderKey(key)
-		if strings.HasPrefix(cKey, "x-amz-meta-") {
-			metadata[cKey] = r.Header.Get(cKey)
-		} else if strings.HasPrefix(key, "x-minio-meta-") {
-			metadata[cKey] = r.Header.Get(cKey)
-		}
+	metadata["cache-control"] = r.Header.Get("Cache-Control")
+	for key, value := range extractUserMetadata(r.Header) {
+		metadata[key] = value
+	}
+	// Tags of the object, if set.
+	if s3Error := setObjectTagsFromHeader(r.Header, metadata); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	// Apply default metadata of the bucket not set by the client.
+	applyBucketDefaultMetadata(bucket, metadata)
+	// Client side encryption envelope, if any, must be complete.
+	if s3Error := checkCSEMetadata(metadata); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
 	}
 
 	uploadID, err := api.ObjectAPI.NewMultipartUpload(bucket, object, metadata)
@@ -748,6 +1350,13 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		return
 	}
 
+	// Parts of uploads to the bucket may be required to carry a
+	// checksum.
+	if s3Error := checkRequiredChecksum(r, bucket, true); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+
 	var partMD5 string
 	switch getRequestAuthType(r) {
 	default:
@@ -765,6 +1374,15 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 		hexMD5 := hex.EncodeToString(md5Bytes)
 		partMD5, err = api.ObjectAPI.PutObjectPart(bucket, object, uploadID, partID, size, r.Body, hexMD5)
 	case authTypePresigned, authTypeSigned:
+		// Reject uploads violating presigned URL constraints before
+		// reading the body.
+		if isRequestPresignedSignatureV4(r) {
+			if _, s3Error := checkPresignConstraints(r); s3Error != ErrNone {
+				writeErrorResponse(w, r, s3Error, r.URL.Path)
+				return
+			}
+			limitPresignedBody(r)
+		}
 		// Initialize a pipe for data pipe line.
 		reader, writer := io.Pipe()
 		var wg = &sync.WaitGroup{}
@@ -822,6 +1440,115 @@ func (api objectAPIHandlers) PutObjectPartHandler(w http.ResponseWriter, r *http
 	writeSuccessResponse(w, nil)
 }
 
+// CopyObjectPartHandler - uploads a part by copying data from an
+// existing object, optionally restricted to the byte range given in
+// x-amz-copy-source-range.
+func (api objectAPIHandlers) CopyObjectPartHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypeAnonymous:
+		// http://docs.aws.amazon.com/AmazonS3/latest/dev/mpuAndPermissions.html
+		if s3Error := enforceBucketPolicy("s3:PutObject", bucket, r.URL); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	uploadID := r.URL.Query().Get("uploadId")
+	partIDString := r.URL.Query().Get("partNumber")
+
+	partID, err := strconv.Atoi(partIDString)
+	if err != nil {
+		writeErrorResponse(w, r, ErrInvalidPart, r.URL.Path)
+		return
+	}
+
+	// check partID with maximum part ID for multipart objects
+	if isMaxPartID(partID) {
+		writeErrorResponse(w, r, ErrInvalidMaxParts, r.URL.Path)
+		return
+	}
+
+	// objectSource
+	objectSource := r.Header.Get("X-Amz-Copy-Source")
+	sourceBucket, sourceObject := getCopySource(objectSource)
+
+	// If source object is empty, reply back error.
+	if sourceObject == "" {
+		writeErrorResponse(w, r, ErrInvalidCopySource, r.URL.Path)
+		return
+	}
+
+	// Anonymous requests also need read access on the copy source.
+	if s3Error := enforceCopySourcePolicy(r, sourceBucket, sourceObject); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, objectSource)
+		return
+	}
+
+	objInfo, err := api.ObjectAPI.GetObjectInfo(sourceBucket, sourceObject)
+	if err != nil {
+		errorIf(err, "Unable to fetch object info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), objectSource)
+		return
+	}
+
+	// Verify x-amz-copy-source-if-* conditions before writing.
+	if s3Error := checkCopySourceConditions(r, objInfo); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, objectSource)
+		return
+	}
+
+	// Get the requested range of the source, whole object by default.
+	copyRange, err := getRequestedRange(r.Header.Get("X-Amz-Copy-Source-Range"), objInfo.Size)
+	if err != nil {
+		errorIf(err, "Unable to parse copy source range.")
+		writeErrorResponse(w, r, ErrInvalidRange, objectSource)
+		return
+	}
+	length := objInfo.Size
+	if copyRange.length > 0 {
+		length = copyRange.length
+	}
+
+	/// maximum Upload size for a single part.
+	if isMaxObjectSize(length) {
+		writeErrorResponse(w, r, ErrEntityTooLarge, objectSource)
+		return
+	}
+	// Presigned URL constraints apply to the copied range.
+	if s3Error := checkPresignSize(r, length); s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, objectSource)
+		return
+	}
+
+	// Copy the range, backends link the data where possible.
+	partMD5, err := api.ObjectAPI.CopyObjectPart(sourceBucket, sourceObject, bucket, object, uploadID, partID, copyRange.start, length)
+	if err != nil {
+		errorIf(err, "Unable to create object part.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	response := generateCopyObjectPartResponse(partMD5, time.Now().UTC())
+	encodedSuccessResponse := encodeResponse(response)
+	// write headers
+	setCommonHeaders(w)
+	// write success response.
+	writeSuccessResponse(w, encodedSuccessResponse)
+}
+
 // AbortMultipartUploadHandler - Abort multipart upload
 func (api objectAPIHandlers) AbortMultipartUploadHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -979,6 +1706,11 @@ func (api objectAPIHandlers) CompleteMultipartUploadHandler(w http.ResponseWrite
 	// Signal that completeMultipartUpload is over via doneCh
 	go func(doneCh chan<- struct{}) {
 		md5Sum, err = api.ObjectAPI.CompleteMultipartUpload(bucket, object, uploadID, completeParts)
+		// Status is already sent, synchronous replication only
+		// delays the response.
+		if err == nil {
+			replicateChange(api.ObjectAPI, replicationOp{bucket: bucket, object: object})
+		}
 		doneCh <- struct{}{}
 	}(doneCh)
 
@@ -1008,6 +1740,14 @@ func (api objectAPIHandlers) DeleteObjectHandler(w http.ResponseWriter, r *http.
 	bucket := vars["bucket"]
 	object := vars["object"]
 
+	// Deleting a version removes it for good, it needs its own
+	// permission.
+	versionID := r.URL.Query().Get("versionId")
+	action := "s3:DeleteObject"
+	if versionID != "" {
+		action = "s3:DeleteObjectVersion"
+	}
+
 	switch getRequestAuthType(r) {
 	default:
 		// For all unknown auth types return error.
@@ -1015,7 +1755,7 @@ func (api objectAPIHandlers) DeleteObjectHandler(w http.ResponseWriter, r *http.
 		return
 	case authTypeAnonymous:
 		// http://docs.aws.amazon.com/AmazonS3/latest/dev/using-with-s3-actions.html
-		if s3Error := enforceBucketPolicy("s3:DeleteObject", bucket, r.URL); s3Error != ErrNone {
+		if s3Error := enforceBucketPolicy(action, bucket, r.URL); s3Error != ErrNone {
 			writeErrorResponse(w, r, s3Error, r.URL.Path)
 			return
 		}
@@ -1025,9 +1765,53 @@ func (api objectAPIHandlers) DeleteObjectHandler(w http.ResponseWriter, r *http.
 			return
 		}
 	}
+	if versionID != "" {
+		api.deleteObjectVersion(w, r, bucket, object, versionID)
+		return
+	}
 	/// http://docs.aws.amazon.com/AmazonS3/latest/API/RESTObjectDELETE.html
 	/// Ignore delete object errors, since we are suppposed to reply
-	/// only 204.
-	api.ObjectAPI.DeleteObject(bucket, object)
+	/// only 204. Retained objects are refused like on S3.
+	err := api.ObjectAPI.DeleteObject(bucket, object)
+	if _, ok := err.(ObjectLocked); ok {
+		writeErrorResponse(w, r, ErrObjectLocked, r.URL.Path)
+		return
+	}
+	if err == nil {
+		replicateChange(api.ObjectAPI, replicationOp{bucket: bucket, object: object, delete: true})
+		// Deletes in versioned buckets leave a delete marker.
+		if getBucketVersioning(bucket) != "" {
+			w.Header().Set(amzDeleteMarkerHeader, "true")
+		}
+	}
+	writeSuccessNoContent(w)
+}
+
+// deleteObjectVersion - removes a version of an object for good, the
+// previous version becomes the current one. Missing versions are
+// reported with 204 like missing objects.
+func (api objectAPIHandlers) deleteObjectVersion(w http.ResponseWriter, r *http.Request, bucket, object, versionID string) {
+	if !isValidVersionID(versionID) {
+		writeErrorResponse(w, r, ErrNoSuchVersion, r.URL.Path)
+		return
+	}
+	objInfo, err := api.ObjectAPI.GetObjectVersionInfo(bucket, object, versionID)
+	if err == nil {
+		err = api.ObjectAPI.DeleteObjectVersion(bucket, object, versionID)
+	}
+	switch err.(type) {
+	case nil:
+		if objInfo.DeleteMarker {
+			w.Header().Set(amzDeleteMarkerHeader, "true")
+		}
+		// The previous version became the current one.
+		replicateCurrentVersion(api.ObjectAPI, bucket, object)
+	case VersionNotFound:
+	default:
+		errorIf(err, "Unable to delete an object version.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	w.Header().Set(amzVersionIDHeader, versionID)
 	writeSuccessNoContent(w)
 }
@@ -0,0 +1,206 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Tests validate x-amz-copy-source-if-* conditions.
+func TestCheckCopySourceConditions(t *testing.T) {
+	modTime := time.Date(2016, time.July, 1, 10, 0, 0, 0, time.UTC)
+	objInfo := ObjectInfo{
+		MD5Sum:  "d41d8cd98f00b204e9800998ecf8427e",
+		ModTime: modTime,
+	}
+	before := modTime.Add(-time.Hour).Format(http.TimeFormat)
+	after := modTime.Add(time.Hour).Format(http.TimeFormat)
+
+	testCases := []struct {
+		headers     map[string]string
+		expectedErr APIErrorCode
+	}{
+		// Test case - 1.
+		// No conditions.
+		{map[string]string{}, ErrNone},
+		// Test case - 2.
+		// Matching quoted ETag.
+		{map[string]string{"X-Amz-Copy-Source-If-Match": "\"d41d8cd98f00b204e9800998ecf8427e\""}, ErrNone},
+		// Test case - 3.
+		// Mismatching ETag.
+		{map[string]string{"X-Amz-Copy-Source-If-Match": "abcd"}, ErrPreconditionFailed},
+		// Test case - 4.
+		// ETag matches if-none-match.
+		{map[string]string{"X-Amz-Copy-Source-If-None-Match": "d41d8cd98f00b204e9800998ecf8427e"}, ErrPreconditionFailed},
+		// Test case - 5.
+		// ETag differs from if-none-match.
+		{map[string]string{"X-Amz-Copy-Source-If-None-Match": "abcd"}, ErrNone},
+		// Test case - 6.
+		// Modified since an earlier time.
+		{map[string]string{"X-Amz-Copy-Source-If-Modified-Since": before}, ErrNone},
+		// Test case - 7.
+		// Not modified since a later time.
+		{map[string]string{"X-Amz-Copy-Source-If-Modified-Since": after}, ErrPreconditionFailed},
+		// Test case - 8.
+		// Unmodified since a later time.
+		{map[string]string{"X-Amz-Copy-Source-If-Unmodified-Since": after}, ErrNone},
+		// Test case - 9.
+		// Modified after an earlier time.
+		{map[string]string{"X-Amz-Copy-Source-If-Unmodified-Since": before}, ErrPreconditionFailed},
+		// Test case - 10.
+		// Matching if-match takes precedence over if-unmodified-since.
+		{map[string]string{
+			"X-Amz-Copy-Source-If-Match":            "d41d8cd98f00b204e9800998ecf8427e",
+			"X-Amz-Copy-Source-If-Unmodified-Since": before,
+		}, ErrNone},
+		// Test case - 11.
+		// Invalid dates are ignored.
+		{map[string]string{"X-Amz-Copy-Source-If-Modified-Since": "invalid"}, ErrNone},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("PUT", "http://localhost:9000/bucket/object", nil)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to create request: %s", i+1, err)
+		}
+		for k, v := range testCase.headers {
+			req.Header.Set(k, v)
+		}
+		if s3Error := checkCopySourceConditions(req, objInfo); s3Error != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error code %d, got %d", i+1, testCase.expectedErr, s3Error)
+		}
+	}
+}
+
+// Tests If-Match, If-Unmodified-Since, If-None-Match and
+// If-Modified-Since are evaluated in order.
+func TestCheckPreconditions(t *testing.T) {
+	modTime := time.Date(2016, time.July, 1, 10, 0, 0, 0, time.UTC)
+	etag := "\"d41d8cd98f00b204e9800998ecf8427e\""
+	before := modTime.Add(-time.Hour).Format(http.TimeFormat)
+	after := modTime.Add(time.Hour).Format(http.TimeFormat)
+
+	testCases := []struct {
+		headers        map[string]string
+		expectedStatus int
+	}{
+		// Test case - 1.
+		// No conditions.
+		{map[string]string{}, http.StatusOK},
+		// Test case - 2.
+		// ETag in the list of if-match.
+		{map[string]string{"If-Match": "\"abcd\", " + etag}, http.StatusOK},
+		// Test case - 3.
+		// Weak ETags never match if-match.
+		{map[string]string{"If-Match": "W/" + etag}, http.StatusPreconditionFailed},
+		// Test case - 4.
+		// Matching if-match takes precedence over if-unmodified-since.
+		{map[string]string{"If-Match": etag, "If-Unmodified-Since": before}, http.StatusOK},
+		// Test case - 5.
+		// Failing if-match takes precedence over if-none-match.
+		{map[string]string{"If-Match": "\"abcd\"", "If-None-Match": etag}, http.StatusPreconditionFailed},
+		// Test case - 6.
+		// Weak ETags match if-none-match.
+		{map[string]string{"If-None-Match": "\"abcd\", W/" + etag}, http.StatusNotModified},
+		// Test case - 7.
+		// Wildcard matches if-none-match.
+		{map[string]string{"If-None-Match": "*"}, http.StatusNotModified},
+		// Test case - 8.
+		// Mismatching if-none-match takes precedence over if-modified-since.
+		{map[string]string{"If-None-Match": "\"abcd\"", "If-Modified-Since": after}, http.StatusOK},
+		// Test case - 9.
+		// Not modified since a later time.
+		{map[string]string{"If-Modified-Since": after}, http.StatusNotModified},
+		// Test case - 10.
+		// Modified after an earlier time.
+		{map[string]string{"If-Unmodified-Since": before}, http.StatusPreconditionFailed},
+		// Test case - 11.
+		// Invalid dates are ignored.
+		{map[string]string{"If-Modified-Since": "invalid"}, http.StatusOK},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("GET", "http://localhost:9000/bucket/object", nil)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to create request: %s", i+1, err)
+		}
+		for k, v := range testCase.headers {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Length", "0")
+		if checkPreconditions(w, req, modTime) {
+			if w.Header().Get("Content-Length") != "" {
+				t.Errorf("Test %d: Expected Content-Length to be removed", i+1)
+			}
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+		if w.Code != testCase.expectedStatus {
+			t.Errorf("Test %d: Expected status %d, got %d", i+1, testCase.expectedStatus, w.Code)
+		}
+	}
+}
+
+// Tests ranges are served only if If-Range matches.
+func TestCheckIfRange(t *testing.T) {
+	objInfo := ObjectInfo{
+		MD5Sum:  "d41d8cd98f00b204e9800998ecf8427e",
+		ModTime: time.Date(2016, time.July, 1, 10, 0, 0, 500, time.UTC),
+	}
+
+	testCases := []struct {
+		ifRange     string
+		expectRange bool
+	}{
+		// Test case - 1.
+		// No If-Range.
+		{"", true},
+		// Test case - 2.
+		// Matching ETag.
+		{"\"d41d8cd98f00b204e9800998ecf8427e\"", true},
+		// Test case - 3.
+		// Mismatching ETag.
+		{"\"abcd\"", false},
+		// Test case - 4.
+		// Weak ETags never match.
+		{"W/\"d41d8cd98f00b204e9800998ecf8427e\"", false},
+		// Test case - 5.
+		// Date matching modification time.
+		{"Fri, 01 Jul 2016 10:00:00 GMT", true},
+		// Test case - 6.
+		// Later date doesn't match.
+		{"Fri, 01 Jul 2016 11:00:00 GMT", false},
+		// Test case - 7.
+		// Invalid date.
+		{"invalid", false},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("GET", "http://localhost:9000/bucket/object", nil)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to create request: %s", i+1, err)
+		}
+		if testCase.ifRange != "" {
+			req.Header.Set("If-Range", testCase.ifRange)
+		}
+		if ok := checkIfRange(req, objInfo); ok != testCase.expectRange {
+			t.Errorf("Test %d: Expected %t, got %t", i+1, testCase.expectRange, ok)
+		}
+	}
+}
@@ -22,25 +22,54 @@ import "io"
 type ObjectLayer interface {
 	// Storage operations.
 	StorageInfo() StorageInfo
+	Capabilities() BackendCapabilities
 
 	// Bucket operations.
 	MakeBucket(bucket string) error
 	GetBucketInfo(bucket string) (bucketInfo BucketInfo, err error)
 	ListBuckets() (buckets []BucketInfo, err error)
 	DeleteBucket(bucket string) error
+	EraseBucket(bucket string, overwrite bool) error
 	ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (result ListObjectsInfo, err error)
+	ListObjectsV2(bucket, prefix, continuationToken, delimiter string, maxKeys int, startAfter string) (result ListObjectsV2Info, err error)
+
+	// Bucket snapshot operations.
+	SnapshotBucket(bucket, snapshotID string) (snapshotInfo BucketSnapshotInfo, err error)
+	ListBucketSnapshots(bucket string) (snapshots []BucketSnapshotInfo, err error)
+	CloneBucketSnapshot(bucket, snapshotID, cloneBucket string) error
+	DeleteBucketSnapshot(bucket, snapshotID string) error
 
 	// Object operations.
 	GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer) (err error)
 	GetObjectInfo(bucket, object string) (objInfo ObjectInfo, err error)
 	PutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string) (md5 string, err error)
+	PatchObject(bucket, object string, offset, size int64, data io.Reader) (md5 string, err error)
+	ComposeObject(bucket, object string, sources []string, metadata map[string]string) (md5 string, err error)
+	CopyObject(srcBucket, srcObject, dstBucket, dstObject string, metadata map[string]string) (md5 string, err error)
 	DeleteObject(bucket, object string) error
+	DeleteObjects(bucket string, objects []string) (errs []error, err error)
+	PutObjectTags(bucket, object string, tags map[string]string) error
+	PutObjectRetention(bucket, object string, retention objectRetention) error
+
+	// Object version operations.
+	GetObjectVersion(bucket, object, versionID string, startOffset int64, length int64, writer io.Writer) (err error)
+	GetObjectVersionInfo(bucket, object, versionID string) (objInfo ObjectInfo, err error)
+	DeleteObjectVersion(bucket, object, versionID string) error
+	ListObjectVersions(bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int) (result ListObjectVersionsInfo, err error)
 
 	// Multipart operations.
 	ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (result ListMultipartsInfo, err error)
 	NewMultipartUpload(bucket, object string, metadata map[string]string) (uploadID string, err error)
 	PutObjectPart(bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (md5 string, err error)
+	CopyObjectPart(srcBucket, srcObject, bucket, object, uploadID string, partID int, startOffset, length int64) (md5 string, err error)
 	ListObjectParts(bucket, object, uploadID string, partNumberMarker int, maxParts int) (result ListPartsInfo, err error)
 	AbortMultipartUpload(bucket, object, uploadID string) error
 	CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []completePart) (md5 string, err error)
+
+	// Healing operations.
+	HealBucket(bucket string, dryRun bool) (info BucketHealInfo, err error)
+	HealObject(bucket, object string, dryRun bool) (info ObjectHealInfo, err error)
+
+	// Auditing operations.
+	GetObjectChecksums(bucket, object string) (info ObjectChecksumInfo, err error)
 }
@@ -0,0 +1,330 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"time"
+
+	mux "github.com/gorilla/mux"
+)
+
+// PutBucketObjectLockConfigHandler - PUT Bucket object-lock
+// -----------------
+// This implementation of the PUT operation uses the object-lock
+// subresource to lock a bucket, optionally with a default retention of
+// new objects. Object lock cannot be turned off once enabled.
+func (api objectAPIHandlers) PutBucketObjectLockConfigHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	// Retention is saved in object metadata, which not all backends
+	// keep.
+	if !api.ObjectAPI.Capabilities().ObjectLock {
+		writeErrorResponse(w, r, ErrNotImplemented, r.URL.Path)
+		return
+	}
+
+	// If Content-Length is greater than maximum allowed size.
+	if r.ContentLength > maxObjectLockConfigSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+
+	config, err := parseObjectLockConfiguration(r.Body)
+	if err != nil {
+		errorIf(err, "Unable to parse object lock.")
+		if err == errInvalidObjectLockConfig {
+			writeErrorResponse(w, r, ErrInvalidObjectLockConfig, r.URL.Path)
+		} else {
+			writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		}
+		return
+	}
+
+	if err = writeBucketObjectLockConfig(bucket, config); err != nil {
+		errorIf(err, "Unable to write object lock.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// GetBucketObjectLockConfigHandler - GET Bucket object-lock
+// -----------------
+// This operation uses the object-lock subresource to return object
+// lock of a bucket.
+func (api objectAPIHandlers) GetBucketObjectLockConfigHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+		errorIf(err, "Unable to fetch bucket info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+
+	config, err := readBucketObjectLockConfig(bucket)
+	if err == errConfigNotFound {
+		writeErrorResponse(w, r, ErrNoSuchObjectLockConfig, r.URL.Path)
+		return
+	}
+	if err != nil {
+		errorIf(err, "Unable to read object lock.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, encodeResponse(config))
+}
+
+// getLockedObjectInfo - returns info of an object of a locked bucket,
+// writes an error response if the bucket is not locked or the object
+// does not exist.
+func (api objectAPIHandlers) getLockedObjectInfo(w http.ResponseWriter, r *http.Request, bucket, object string) (ObjectInfo, bool) {
+	if _, locked := getBucketObjectLock(bucket); !locked {
+		if _, err := api.ObjectAPI.GetBucketInfo(bucket); err != nil {
+			errorIf(err, "Unable to fetch bucket info.")
+			writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+			return ObjectInfo{}, false
+		}
+		writeErrorResponse(w, r, ErrObjectLockNotEnabled, r.URL.Path)
+		return ObjectInfo{}, false
+	}
+	objInfo, err := api.ObjectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		errorIf(err, "Unable to fetch object info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return ObjectInfo{}, false
+	}
+	return objInfo, true
+}
+
+// PutObjectRetentionHandler - PUT Object retention
+// ----------
+// This implementation of the PUT operation uses the retention
+// subresource to retain an object of a locked bucket until a date.
+// Retention can be extended but never shortened.
+func (api objectAPIHandlers) PutObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	// If Content-Length is greater than maximum allowed size.
+	if r.ContentLength > maxObjectLockConfigSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+
+	retainUntil, err := parseObjectRetention(r.Body, time.Now().UTC())
+	if err != nil {
+		errorIf(err, "Unable to parse object retention.")
+		if err == errInvalidObjectRetention {
+			writeErrorResponse(w, r, ErrInvalidObjectRetention, r.URL.Path)
+		} else {
+			writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		}
+		return
+	}
+
+	lockObjectRetention(bucket, object)
+	defer unlockObjectRetention(bucket, object)
+
+	if _, ok := api.getLockedObjectInfo(w, r, bucket, object); !ok {
+		return
+	}
+	if err = setObjectRetainUntil(api.ObjectAPI, bucket, object, retainUntil); err != nil {
+		errorIf(err, "Unable to set object retention.")
+		if err == errObjectRetentionShortened {
+			writeErrorResponse(w, r, ErrInvalidObjectRetention, r.URL.Path)
+		} else {
+			writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		}
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// GetObjectRetentionHandler - GET Object retention
+// ----------
+// This operation uses the retention subresource to return the date
+// an object of a locked bucket is retained until.
+func (api objectAPIHandlers) GetObjectRetentionHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	objInfo, ok := api.getLockedObjectInfo(w, r, bucket, object)
+	if !ok {
+		return
+	}
+	retention := objInfo.Retention
+	if retention.RetainUntil.IsZero() {
+		writeErrorResponse(w, r, ErrNoSuchObjectRetention, r.URL.Path)
+		return
+	}
+	encodedSuccessResponse := encodeResponse(objectRetentionConfiguration{
+		Mode:            objectLockModeCompliance,
+		RetainUntilDate: retention.RetainUntil.UTC().Format(timeFormatAMZ),
+	})
+	// write headers
+	setCommonHeaders(w)
+	// write success response.
+	writeSuccessResponse(w, encodedSuccessResponse)
+}
+
+// PutObjectLegalHoldHandler - PUT Object legal-hold
+// ----------
+// This implementation of the PUT operation uses the legal-hold
+// subresource to turn legal hold of an object of a locked bucket on or
+// off. Objects under legal hold are retained regardless of retention.
+func (api objectAPIHandlers) PutObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	// If Content-Length is greater than maximum allowed size.
+	if r.ContentLength > maxObjectLockConfigSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+
+	legalHold, err := parseObjectLegalHold(r.Body)
+	if err != nil {
+		errorIf(err, "Unable to parse object legal hold.")
+		if err == errInvalidLegalHold {
+			writeErrorResponse(w, r, ErrInvalidLegalHold, r.URL.Path)
+		} else {
+			writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		}
+		return
+	}
+
+	lockObjectRetention(bucket, object)
+	defer unlockObjectRetention(bucket, object)
+
+	if _, ok := api.getLockedObjectInfo(w, r, bucket, object); !ok {
+		return
+	}
+	if err = setObjectLegalHold(api.ObjectAPI, bucket, object, legalHold); err != nil {
+		errorIf(err, "Unable to set object legal hold.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	writeSuccessResponse(w, nil)
+}
+
+// GetObjectLegalHoldHandler - GET Object legal-hold
+// ----------
+// This operation uses the legal-hold subresource to return legal hold
+// of an object of a locked bucket, which is off unless turned on.
+func (api objectAPIHandlers) GetObjectLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	objInfo, ok := api.getLockedObjectInfo(w, r, bucket, object)
+	if !ok {
+		return
+	}
+	config := objectLegalHoldConfiguration{Status: legalHoldOff}
+	if objInfo.Retention.LegalHold {
+		config.Status = legalHoldOn
+	}
+	encodedSuccessResponse := encodeResponse(config)
+	// write headers
+	setCommonHeaders(w)
+	// write success response.
+	writeSuccessResponse(w, encodedSuccessResponse)
+}
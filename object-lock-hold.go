@@ -0,0 +1,162 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Maximum size of a retention hold document.
+const maxRetentionHoldSize = 1 * 1024 * 1024 // 1MiB.
+
+// errInvalidRetentionHold - retention hold is malformed, holds nothing
+// or has an invalid retention or time range.
+var errInvalidRetentionHold = errors.New("Invalid retention hold")
+
+// retentionHold - retains, or puts a legal hold on, all objects of a
+// locked bucket matching prefix, tags and the modification time range
+// [ModifiedAfter, ModifiedBefore). Zero times leave the range open.
+type retentionHold struct {
+	Bucket         string            `json:"bucket"`
+	Prefix         string            `json:"prefix,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+	ModifiedAfter  time.Time         `json:"modifiedAfter,omitempty"`
+	ModifiedBefore time.Time         `json:"modifiedBefore,omitempty"`
+
+	// Retention of matching objects is extended until RetainUntil,
+	// never shortened, legal hold of matching objects is turned on.
+	RetainUntil time.Time `json:"retainUntil,omitempty"`
+	LegalHold   bool      `json:"legalHold,omitempty"`
+}
+
+// parseRetentionHold - parses and validates a retention hold, its
+// retention must end after now.
+func parseRetentionHold(holdBuf []byte, now time.Time) (hold retentionHold, err error) {
+	if err = json.Unmarshal(holdBuf, &hold); err != nil {
+		return retentionHold{}, err
+	}
+	if !IsValidBucketName(hold.Bucket) {
+		return retentionHold{}, errInvalidRetentionHold
+	}
+	if hold.RetainUntil.IsZero() && !hold.LegalHold {
+		return retentionHold{}, errInvalidRetentionHold
+	}
+	if !hold.RetainUntil.IsZero() && (!hold.RetainUntil.After(now) || hold.RetainUntil.Sub(now) > maxObjectRetention) {
+		return retentionHold{}, errInvalidRetentionHold
+	}
+	if !hold.ModifiedAfter.IsZero() && !hold.ModifiedBefore.IsZero() && !hold.ModifiedAfter.Before(hold.ModifiedBefore) {
+		return retentionHold{}, errInvalidRetentionHold
+	}
+	return hold, nil
+}
+
+// matchModTime - returns true if modTime is in the time range of the
+// hold.
+func (hold retentionHold) matchModTime(modTime time.Time) bool {
+	if !hold.ModifiedAfter.IsZero() && modTime.Before(hold.ModifiedAfter) {
+		return false
+	}
+	return hold.ModifiedBefore.IsZero() || modTime.Before(hold.ModifiedBefore)
+}
+
+// match - returns true if the hold applies to the object.
+func (hold retentionHold) match(objInfo ObjectInfo) bool {
+	if !strings.HasPrefix(objInfo.Name, hold.Prefix) || !hold.matchModTime(objInfo.ModTime) {
+		return false
+	}
+	for key, value := range hold.Tags {
+		if tagValue, ok := objInfo.Tags[key]; !ok || tagValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// holdObjects - applies the hold to all matching objects, returns plan
+// entries listing every matching object with its retention once held,
+// the manifest of held objects. Nothing is changed in dry-run. Callers
+// verify the bucket is locked.
+func holdObjects(objAPI ObjectLayer, hold retentionHold, dryRun bool) ([]planEntry, error) {
+	var entries []planEntry
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(hold.Bucket, hold.Prefix, marker, "", maxObjectList)
+		if err != nil {
+			return entries, err
+		}
+		for _, objInfo := range result.Objects {
+			// Tags are only matched once the object is read.
+			if !hold.matchModTime(objInfo.ModTime) {
+				continue
+			}
+			retention, ok, err := holdObject(objAPI, hold, objInfo.Name, dryRun)
+			if !ok && err == nil {
+				continue
+			}
+			errorIf(err, "Unable to hold %s/%s.", hold.Bucket, objInfo.Name)
+			entry := newPlanEntry(planActionHold, hold.Bucket, objInfo, err)
+			if err == nil {
+				entry.Retention = &retention
+			}
+			entries = append(entries, entry)
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+		if marker == "" && len(result.Objects) > 0 {
+			marker = result.Objects[len(result.Objects)-1].Name
+		}
+	}
+	return entries, nil
+}
+
+// holdObject - applies the hold to the object if it still matches,
+// returns its retention once held and true if it matched. Objects
+// deleted after listing do not match.
+func holdObject(objAPI ObjectLayer, hold retentionHold, object string, dryRun bool) (objectRetention, bool, error) {
+	lockObjectRetention(hold.Bucket, object)
+	defer unlockObjectRetention(hold.Bucket, object)
+
+	objInfo, err := objAPI.GetObjectInfo(hold.Bucket, object)
+	if err != nil {
+		if _, ok := err.(ObjectNotFound); ok {
+			return objectRetention{}, false, nil
+		}
+		return objectRetention{}, false, err
+	}
+	if !hold.match(objInfo) {
+		return objectRetention{}, false, nil
+	}
+	retention := objInfo.Retention
+	if hold.RetainUntil.After(retention.RetainUntil) {
+		retention.RetainUntil = hold.RetainUntil
+	}
+	if hold.LegalHold {
+		retention.LegalHold = true
+	}
+	if dryRun || retention == objInfo.Retention {
+		return retention, true, nil
+	}
+	if err = objAPI.PutObjectRetention(hold.Bucket, object, retention); err != nil {
+		return objectRetention{}, true, err
+	}
+	return retention, true, nil
+}
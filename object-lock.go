@@ -0,0 +1,510 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"time"
+)
+
+const (
+	// Object lock is saved alongside the bucket policy.
+	bucketObjectLockConfigFile = "object-lock.json"
+
+	// Internal metadata keys of retention and legal hold, saved with
+	// the object itself so that they follow it on every disk.
+	objectRetainUntilKey = "X-Minio-Internal-Retain-Until"
+	objectLegalHoldKey   = "X-Minio-Internal-Legal-Hold"
+
+	// Maximum size of PUT object-lock, retention and legal-hold
+	// request bodies.
+	maxObjectLockConfigSize = 1024 // 1KiB.
+
+	// Object lock can only be enabled, it is never turned off.
+	objectLockEnabled = "Enabled"
+
+	// Only mode of retention, retained objects cannot be replaced or
+	// deleted by anyone until their retention ends.
+	objectLockModeCompliance = "COMPLIANCE"
+
+	// Status of legal holds.
+	legalHoldOn  = "ON"
+	legalHoldOff = "OFF"
+
+	// Longest retention accepted, 100 years.
+	maxObjectRetention = 100 * 365 * 24 * time.Hour
+
+	// Writes, deletes and retention changes of objects of locked
+	// buckets hold the lock of the object below this meta prefix, so
+	// retention cannot change between checking and writing. The object
+	// layer takes the lock of the object itself while writing.
+	objectLockMetaPrefix = "object-lock"
+)
+
+var (
+	// errInvalidObjectLockConfig - object lock is not enabled or its
+	// default retention is invalid.
+	errInvalidObjectLockConfig = errors.New("Invalid object lock configuration")
+
+	// errInvalidObjectRetention - retention is not in compliance mode
+	// or ends in the past.
+	errInvalidObjectRetention = errors.New("Invalid object retention")
+
+	// errObjectRetentionShortened - retention of compliance mode can
+	// only be extended.
+	errObjectRetentionShortened = errors.New("Object retention cannot be shortened")
+
+	// errInvalidLegalHold - legal hold is neither on nor off.
+	errInvalidLegalHold = errors.New("Invalid legal hold status")
+)
+
+// objectLockDefaultRetention - retention of objects written to a
+// locked bucket, either in days or years.
+type objectLockDefaultRetention struct {
+	Mode  string `json:"mode"`
+	Days  int    `xml:",omitempty" json:"days,omitempty"`
+	Years int    `xml:",omitempty" json:"years,omitempty"`
+}
+
+// objectLockRule - default retention of a locked bucket.
+type objectLockRule struct {
+	DefaultRetention objectLockDefaultRetention `json:"defaultRetention"`
+}
+
+// objectLockConfiguration - object lock of a bucket, as sent and
+// returned by the object-lock subresource.
+type objectLockConfiguration struct {
+	XMLName           xml.Name        `xml:"ObjectLockConfiguration" json:"-"`
+	ObjectLockEnabled string          `json:"objectLockEnabled"`
+	Rule              *objectLockRule `xml:",omitempty" json:"rule,omitempty"`
+}
+
+// getDefaultRetention - returns the retention of new objects, zero if
+// the bucket has no default retention.
+func (config objectLockConfiguration) getDefaultRetention() time.Duration {
+	if config.Rule == nil {
+		return 0
+	}
+	retention := config.Rule.DefaultRetention
+	return time.Duration(retention.Days+365*retention.Years) * 24 * time.Hour
+}
+
+// objectRetentionConfiguration - retention of an object, as sent and
+// returned by the retention subresource.
+type objectRetentionConfiguration struct {
+	XMLName         xml.Name `xml:"Retention"`
+	Mode            string
+	RetainUntilDate string
+}
+
+// objectLegalHoldConfiguration - legal hold of an object, as sent and
+// returned by the legal-hold subresource.
+type objectLegalHoldConfiguration struct {
+	XMLName xml.Name `xml:"LegalHold"`
+	Status  string
+}
+
+// parseObjectLockConfiguration - parses object lock of a PUT
+// object-lock request, object lock must be enabled and default
+// retention has either days or years.
+func parseObjectLockConfiguration(reader io.Reader) (config objectLockConfiguration, err error) {
+	if err = xml.NewDecoder(io.LimitReader(reader, maxObjectLockConfigSize)).Decode(&config); err != nil {
+		return objectLockConfiguration{}, err
+	}
+	if config.ObjectLockEnabled != objectLockEnabled {
+		return objectLockConfiguration{}, errInvalidObjectLockConfig
+	}
+	if config.Rule == nil {
+		return config, nil
+	}
+	retention := config.Rule.DefaultRetention
+	if retention.Mode != objectLockModeCompliance || retention.Days < 0 || retention.Years < 0 {
+		return objectLockConfiguration{}, errInvalidObjectLockConfig
+	}
+	if (retention.Days == 0) == (retention.Years == 0) {
+		return objectLockConfiguration{}, errInvalidObjectLockConfig
+	}
+	if retention.Days > int(maxObjectRetention/(24*time.Hour)) || retention.Years > 100 {
+		return objectLockConfiguration{}, errInvalidObjectLockConfig
+	}
+	return config, nil
+}
+
+// parseObjectRetention - parses retention of a PUT retention request,
+// which must end after now.
+func parseObjectRetention(reader io.Reader, now time.Time) (time.Time, error) {
+	var config objectRetentionConfiguration
+	if err := xml.NewDecoder(io.LimitReader(reader, maxObjectLockConfigSize)).Decode(&config); err != nil {
+		return time.Time{}, err
+	}
+	if config.Mode != objectLockModeCompliance {
+		return time.Time{}, errInvalidObjectRetention
+	}
+	retainUntil, err := time.Parse(time.RFC3339, config.RetainUntilDate)
+	if err != nil || !retainUntil.After(now) || retainUntil.Sub(now) > maxObjectRetention {
+		return time.Time{}, errInvalidObjectRetention
+	}
+	return retainUntil.UTC(), nil
+}
+
+// parseObjectLegalHold - parses legal hold of a PUT legal-hold request,
+// returns true if it is on.
+func parseObjectLegalHold(reader io.Reader) (bool, error) {
+	var config objectLegalHoldConfiguration
+	if err := xml.NewDecoder(io.LimitReader(reader, maxObjectLockConfigSize)).Decode(&config); err != nil {
+		return false, err
+	}
+	switch config.Status {
+	case legalHoldOn:
+		return true, nil
+	case legalHoldOff:
+		return false, nil
+	}
+	return false, errInvalidLegalHold
+}
+
+// readBucketObjectLockConfig - read object lock, returns
+// errConfigNotFound unless the bucket is locked.
+func readBucketObjectLockConfig(bucket string) (objectLockConfiguration, error) {
+	configBuf, err := readBucketConfig(bucket, bucketObjectLockConfigFile)
+	if err != nil {
+		return objectLockConfiguration{}, err
+	}
+	var config objectLockConfiguration
+	if err = json.Unmarshal(configBuf, &config); err != nil {
+		return objectLockConfiguration{}, err
+	}
+	return config, nil
+}
+
+// writeBucketObjectLockConfig - save object lock.
+func writeBucketObjectLockConfig(bucket string, config objectLockConfiguration) error {
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	return writeBucketConfig(bucket, bucketObjectLockConfigFile, configBuf)
+}
+
+// removeBucketObjectLockConfig - remove object lock of a deleted
+// bucket.
+func removeBucketObjectLockConfig(bucket string) error {
+	return removeBucketConfig(bucket, bucketObjectLockConfigFile)
+}
+
+// getBucketObjectLock - returns object lock of a bucket, false if the
+// bucket is not locked.
+func getBucketObjectLock(bucket string) (objectLockConfiguration, bool) {
+	config, err := readBucketObjectLockConfig(bucket)
+	if err == errConfigNotFound {
+		return objectLockConfiguration{}, false
+	}
+	if err != nil {
+		// Retain objects if object lock is unknown.
+		errorIf(err, "Unable to read object lock of bucket %s.", bucket)
+		return objectLockConfiguration{}, true
+	}
+	return config, true
+}
+
+// objectRetention - retention and legal hold of an object, an object
+// is retained until both ended.
+type objectRetention struct {
+	RetainUntil time.Time `json:"retainUntil,omitempty"`
+	LegalHold   bool      `json:"legalHold,omitempty"`
+}
+
+// isRetained - returns true if the object cannot be replaced or
+// deleted at now.
+func (r objectRetention) isRetained(now time.Time) bool {
+	return r.LegalHold || now.Before(r.RetainUntil)
+}
+
+// getObjectRetention - returns retention saved in object metadata,
+// zero if the object was never retained.
+func getObjectRetention(meta map[string]string) objectRetention {
+	retainUntil, _ := time.Parse(time.RFC3339Nano, meta[objectRetainUntilKey])
+	return objectRetention{
+		RetainUntil: retainUntil,
+		LegalHold:   meta[objectLegalHoldKey] == legalHoldOn,
+	}
+}
+
+// setObjectRetention - saves retention into object metadata, zero
+// retention removes it.
+func setObjectRetention(meta map[string]string, retention objectRetention) {
+	if retention.RetainUntil.IsZero() {
+		delete(meta, objectRetainUntilKey)
+	} else {
+		meta[objectRetainUntilKey] = retention.RetainUntil.UTC().Format(time.RFC3339Nano)
+	}
+	if retention.LegalHold {
+		meta[objectLegalHoldKey] = legalHoldOn
+	} else {
+		delete(meta, objectLegalHoldKey)
+	}
+}
+
+// setObjectRetainUntil - retains an object until retainUntil, which
+// cannot be before its current retention. Callers hold the retention
+// lock of the object.
+func setObjectRetainUntil(objAPI ObjectLayer, bucket, object string, retainUntil time.Time) error {
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		return err
+	}
+	retention := objInfo.Retention
+	if retainUntil.Before(retention.RetainUntil) {
+		return errObjectRetentionShortened
+	}
+	retention.RetainUntil = retainUntil
+	return objAPI.PutObjectRetention(bucket, object, retention)
+}
+
+// setObjectLegalHold - turns legal hold of an object on or off.
+// Callers hold the retention lock of the object.
+func setObjectLegalHold(objAPI ObjectLayer, bucket, object string, legalHold bool) error {
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		return err
+	}
+	retention := objInfo.Retention
+	retention.LegalHold = legalHold
+	return objAPI.PutObjectRetention(bucket, object, retention)
+}
+
+// lockObjectRetention - locks retention of an object against changes
+// while it is checked and written.
+func lockObjectRetention(bucket, object string) {
+	nsMutex.Lock(minioMetaBucket, pathJoin(objectLockMetaPrefix, bucket, object))
+}
+
+// unlockObjectRetention - unlocks retention of an object.
+func unlockObjectRetention(bucket, object string) {
+	nsMutex.Unlock(minioMetaBucket, pathJoin(objectLockMetaPrefix, bucket, object))
+}
+
+// checkObjectRetention - returns ObjectLocked if the object is retained
+// now, callers must hold the retention lock of the object.
+func checkObjectRetention(objAPI ObjectLayer, bucket, object string) error {
+	objInfo, err := objAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		if _, ok := err.(ObjectNotFound); ok {
+			return nil
+		}
+		return err
+	}
+	if objInfo.Retention.isRetained(time.Now().UTC()) {
+		return ObjectLocked{Bucket: bucket, Object: object}
+	}
+	return nil
+}
+
+// retainedObjects - object layer refusing to replace or delete objects
+// of locked buckets while they are retained, so every API writing
+// objects honors retention and legal holds. Retention is read from the
+// metadata of the object, objects of other buckets are passed through.
+type retainedObjects struct {
+	ObjectLayer
+}
+
+// newRetainedObjects - wraps an object layer to enforce retention.
+func newRetainedObjects(objAPI ObjectLayer) ObjectLayer {
+	return retainedObjects{objAPI}
+}
+
+// writeObject - runs write of an object unless it is retained. New
+// objects get the default retention of the bucket, saved with the
+// object if the write carries metadata. Writes without metadata keep
+// the metadata of the object or upload, objects left unretained get
+// the default retention right after and the write fails if it cannot
+// be set.
+func (o retainedObjects) writeObject(bucket, object string, metadata map[string]string, write func() error) error {
+	config, locked := getBucketObjectLock(bucket)
+	if !locked {
+		return write()
+	}
+	lockObjectRetention(bucket, object)
+	defer unlockObjectRetention(bucket, object)
+
+	if err := checkObjectRetention(o.ObjectLayer, bucket, object); err != nil {
+		return err
+	}
+	var retention objectRetention
+	if defaultRetention := config.getDefaultRetention(); defaultRetention > 0 {
+		retention.RetainUntil = time.Now().UTC().Add(defaultRetention)
+	}
+	if metadata != nil {
+		setObjectRetention(metadata, retention)
+	}
+	if err := write(); err != nil {
+		return err
+	}
+	if metadata == nil && !retention.RetainUntil.IsZero() {
+		objInfo, err := o.ObjectLayer.GetObjectInfo(bucket, object)
+		if err != nil {
+			return err
+		}
+		if objInfo.Retention.isRetained(time.Now().UTC()) {
+			return nil
+		}
+		retention.LegalHold = objInfo.Retention.LegalHold
+		if err = o.ObjectLayer.PutObjectRetention(bucket, object, retention); err != nil {
+			errorIf(err, "Unable to set default retention of %s/%s.", bucket, object)
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteObject - runs delete of an object unless it is retained.
+func (o retainedObjects) deleteObject(bucket, object string, delete func() error) error {
+	if _, locked := getBucketObjectLock(bucket); !locked {
+		return delete()
+	}
+	lockObjectRetention(bucket, object)
+	defer unlockObjectRetention(bucket, object)
+
+	if err := checkObjectRetention(o.ObjectLayer, bucket, object); err != nil {
+		return err
+	}
+	return delete()
+}
+
+// EraseBucket - erases a bucket unless any of its objects is retained.
+func (o retainedObjects) EraseBucket(bucket string, overwrite bool) error {
+	if _, locked := getBucketObjectLock(bucket); locked {
+		now := time.Now().UTC()
+		marker := ""
+		for {
+			result, err := o.ObjectLayer.ListObjects(bucket, "", marker, "", maxObjectList)
+			if err != nil {
+				return err
+			}
+			for _, objInfo := range result.Objects {
+				if objInfo.Retention.isRetained(now) {
+					return ObjectLocked{Bucket: bucket, Object: objInfo.Name}
+				}
+			}
+			if !result.IsTruncated {
+				break
+			}
+			marker = result.NextMarker
+		}
+	}
+	return o.ObjectLayer.EraseBucket(bucket, overwrite)
+}
+
+// PutObject - writes an object unless it is retained.
+func (o retainedObjects) PutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string) (md5 string, err error) {
+	// No metadata is set, allocate a new one.
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	err = o.writeObject(bucket, object, metadata, func() error {
+		md5, err = o.ObjectLayer.PutObject(bucket, object, size, data, metadata)
+		return err
+	})
+	return md5, err
+}
+
+// PatchObject - patches an object unless it is retained.
+func (o retainedObjects) PatchObject(bucket, object string, offset, size int64, data io.Reader) (md5 string, err error) {
+	err = o.writeObject(bucket, object, nil, func() error {
+		md5, err = o.ObjectLayer.PatchObject(bucket, object, offset, size, data)
+		return err
+	})
+	return md5, err
+}
+
+// ComposeObject - composes an object unless it is retained.
+func (o retainedObjects) ComposeObject(bucket, object string, sources []string, metadata map[string]string) (md5 string, err error) {
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	err = o.writeObject(bucket, object, metadata, func() error {
+		md5, err = o.ObjectLayer.ComposeObject(bucket, object, sources, metadata)
+		return err
+	})
+	return md5, err
+}
+
+// CopyObject - copies an object unless the copy is retained.
+func (o retainedObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string, metadata map[string]string) (md5 string, err error) {
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	err = o.writeObject(dstBucket, dstObject, metadata, func() error {
+		md5, err = o.ObjectLayer.CopyObject(srcBucket, srcObject, dstBucket, dstObject, metadata)
+		return err
+	})
+	return md5, err
+}
+
+// NewMultipartUpload - initiates an upload saving the default retention
+// of locked buckets with it, completed objects get it at once.
+func (o retainedObjects) NewMultipartUpload(bucket, object string, metadata map[string]string) (string, error) {
+	if config, locked := getBucketObjectLock(bucket); locked {
+		if defaultRetention := config.getDefaultRetention(); defaultRetention > 0 {
+			if metadata == nil {
+				metadata = make(map[string]string)
+			}
+			setObjectRetention(metadata, objectRetention{RetainUntil: time.Now().UTC().Add(defaultRetention)})
+		}
+	}
+	return o.ObjectLayer.NewMultipartUpload(bucket, object, metadata)
+}
+
+// CompleteMultipartUpload - completes an upload unless the object is
+// retained.
+func (o retainedObjects) CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []completePart) (md5 string, err error) {
+	err = o.writeObject(bucket, object, nil, func() error {
+		md5, err = o.ObjectLayer.CompleteMultipartUpload(bucket, object, uploadID, uploadedParts)
+		return err
+	})
+	return md5, err
+}
+
+// DeleteObject - deletes an object unless it is retained.
+func (o retainedObjects) DeleteObject(bucket, object string) error {
+	return o.deleteObject(bucket, object, func() error {
+		return o.ObjectLayer.DeleteObject(bucket, object)
+	})
+}
+
+// DeleteObjects - deletes objects of a locked bucket one by one, so
+// each retained object fails on its own.
+func (o retainedObjects) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	if _, locked := getBucketObjectLock(bucket); !locked {
+		return o.ObjectLayer.DeleteObjects(bucket, objects)
+	}
+	if _, err := o.ObjectLayer.GetBucketInfo(bucket); err != nil {
+		return nil, err
+	}
+	return deleteObjects(o.DeleteObject, bucket, objects), nil
+}
+
+// DeleteObjectVersion - deletes a version of an object unless the
+// object is retained, retention protects all versions of an object.
+func (o retainedObjects) DeleteObjectVersion(bucket, object, versionID string) error {
+	return o.deleteObject(bucket, object, func() error {
+		return o.ObjectLayer.DeleteObjectVersion(bucket, object, versionID)
+	})
+}
@@ -0,0 +1,351 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Tests validate parsing of object lock.
+func TestParseObjectLockConfiguration(t *testing.T) {
+	testCases := []struct {
+		configBuf        string
+		expectedRetained time.Duration
+		expectedErr      error
+	}{
+		// Test case - 1.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled></ObjectLockConfiguration>`, 0, nil},
+		// Test case - 2.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>COMPLIANCE</Mode><Days>30</Days></DefaultRetention></Rule></ObjectLockConfiguration>`, 30 * 24 * time.Hour, nil},
+		// Test case - 3.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>COMPLIANCE</Mode><Years>1</Years></DefaultRetention></Rule></ObjectLockConfiguration>`, 365 * 24 * time.Hour, nil},
+		// Test case - 4.
+		// Object lock cannot be turned off.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Disabled</ObjectLockEnabled></ObjectLockConfiguration>`, 0, errInvalidObjectLockConfig},
+		// Test case - 5.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>GOVERNANCE</Mode><Days>30</Days></DefaultRetention></Rule></ObjectLockConfiguration>`, 0, errInvalidObjectLockConfig},
+		// Test case - 6.
+		// Both days and years.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>COMPLIANCE</Mode><Days>30</Days><Years>1</Years></DefaultRetention></Rule></ObjectLockConfiguration>`, 0, errInvalidObjectLockConfig},
+		// Test case - 7.
+		// Neither days nor years.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>COMPLIANCE</Mode></DefaultRetention></Rule></ObjectLockConfiguration>`, 0, errInvalidObjectLockConfig},
+		// Test case - 8.
+		{`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled><Rule><DefaultRetention><Mode>COMPLIANCE</Mode><Years>101</Years></DefaultRetention></Rule></ObjectLockConfiguration>`, 0, errInvalidObjectLockConfig},
+	}
+	for i, testCase := range testCases {
+		config, err := parseObjectLockConfiguration(strings.NewReader(testCase.configBuf))
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		if err == nil && config.getDefaultRetention() != testCase.expectedRetained {
+			t.Errorf("Test %d: Expected default retention %s, got %s", i+1, testCase.expectedRetained, config.getDefaultRetention())
+		}
+	}
+}
+
+// Tests validate parsing of object retention and legal hold.
+func TestParseObjectRetention(t *testing.T) {
+	now := time.Date(2016, time.August, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		retentionBuf string
+		expectedErr  error
+	}{
+		// Test case - 1.
+		{`<Retention><Mode>COMPLIANCE</Mode><RetainUntilDate>2016-09-01T00:00:00Z</RetainUntilDate></Retention>`, nil},
+		// Test case - 2.
+		{`<Retention><Mode>GOVERNANCE</Mode><RetainUntilDate>2016-09-01T00:00:00Z</RetainUntilDate></Retention>`, errInvalidObjectRetention},
+		// Test case - 3.
+		// Retention ending in the past.
+		{`<Retention><Mode>COMPLIANCE</Mode><RetainUntilDate>2016-07-01T00:00:00Z</RetainUntilDate></Retention>`, errInvalidObjectRetention},
+		// Test case - 4.
+		{`<Retention><Mode>COMPLIANCE</Mode><RetainUntilDate>next month</RetainUntilDate></Retention>`, errInvalidObjectRetention},
+	}
+	for i, testCase := range testCases {
+		_, err := parseObjectRetention(strings.NewReader(testCase.retentionBuf), now)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+	}
+
+	if legalHold, err := parseObjectLegalHold(strings.NewReader(`<LegalHold><Status>ON</Status></LegalHold>`)); err != nil || !legalHold {
+		t.Errorf("Expected legal hold to be on, got %t, %v", legalHold, err)
+	}
+	if _, err := parseObjectLegalHold(strings.NewReader(`<LegalHold><Status>YES</Status></LegalHold>`)); err != errInvalidLegalHold {
+		t.Errorf("Expected error %v, got %v", errInvalidLegalHold, err)
+	}
+}
+
+// Wrapper for calling object retention tests for both XL multiple
+// disks and single node setup.
+func TestObjectRetention(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	ExecObjectLayerTest(t, testObjectRetention)
+}
+
+// Tests validate retained objects of locked buckets cannot be replaced
+// or deleted.
+func testObjectRetention(obj ObjectLayer, instanceType string, t *testing.T) {
+	obj = newRetainedObjects(obj)
+	bucket := "locked-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	config := objectLockConfiguration{
+		ObjectLockEnabled: objectLockEnabled,
+		Rule:              &objectLockRule{objectLockDefaultRetention{Mode: objectLockModeCompliance, Days: 1}},
+	}
+	if err := writeBucketObjectLockConfig(bucket, config); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	defer removeBucketObjectLockConfig(bucket)
+
+	// New objects get the default retention.
+	data := []byte("hello")
+	if _, err := obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	objInfo, err := obj.GetObjectInfo(bucket, "object")
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if objInfo.Retention.RetainUntil.Before(time.Now().UTC().Add(23 * time.Hour)) {
+		t.Fatalf("%s: Expected default retention of a day, got %s", instanceType, objInfo.Retention.RetainUntil)
+	}
+
+	// Completed uploads get the default retention along with the object.
+	uploadID, err := obj.NewMultipartUpload(bucket, "upload", nil)
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	md5Hex, err := obj.PutObjectPart(bucket, "upload", uploadID, 1, int64(len(data)), bytes.NewReader(data), "")
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if _, err = obj.CompleteMultipartUpload(bucket, "upload", uploadID, []completePart{{PartNumber: 1, ETag: md5Hex}}); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if objInfo, err = obj.GetObjectInfo(bucket, "upload"); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if objInfo.Retention.RetainUntil.Before(time.Now().UTC().Add(23 * time.Hour)) {
+		t.Fatalf("%s: Expected default retention of a day, got %s", instanceType, objInfo.Retention.RetainUntil)
+	}
+	if err = obj.PutObjectRetention(bucket, "upload", objectRetention{}); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if err = obj.DeleteObject(bucket, "upload"); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+
+	if _, err = obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err == nil {
+		t.Fatalf("%s: Expected overwrite to fail.", instanceType)
+	} else if _, ok := err.(ObjectLocked); !ok {
+		t.Fatalf("%s: Expected ObjectLocked, got %s", instanceType, err)
+	}
+	if err = obj.DeleteObject(bucket, "object"); err == nil {
+		t.Fatalf("%s: Expected delete to fail.", instanceType)
+	} else if _, ok := err.(ObjectLocked); !ok {
+		t.Fatalf("%s: Expected ObjectLocked, got %s", instanceType, err)
+	}
+	errs, err := obj.DeleteObjects(bucket, []string{"object", "missing"})
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if _, ok := errs[0].(ObjectLocked); !ok {
+		t.Fatalf("%s: Expected ObjectLocked, got %v", instanceType, errs[0])
+	}
+	if err = obj.EraseBucket(bucket, false); err == nil {
+		t.Fatalf("%s: Expected erase to fail.", instanceType)
+	}
+	if err = setObjectRetainUntil(obj, bucket, "object", time.Now().UTC()); err != errObjectRetentionShortened {
+		t.Fatalf("%s: Expected %s, got %v", instanceType, errObjectRetentionShortened, err)
+	}
+
+	// Legal holds retain objects regardless of retention.
+	if err = obj.PutObjectRetention(bucket, "object", objectRetention{LegalHold: true}); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if err = obj.DeleteObject(bucket, "object"); err == nil {
+		t.Fatalf("%s: Expected delete under legal hold to fail.", instanceType)
+	}
+
+	// Objects can be deleted once released.
+	if err = setObjectLegalHold(obj, bucket, "object", false); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if err = obj.DeleteObject(bucket, "object"); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if err = obj.EraseBucket(bucket, false); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+
+	// Objects of other buckets are not retained.
+	if err = obj.MakeBucket("unlocked-bucket"); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	for i := 0; i < 2; i++ {
+		if _, err = obj.PutObject("unlocked-bucket", "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("%s : %s", instanceType, err.Error())
+		}
+	}
+	if err = obj.DeleteObject("unlocked-bucket", "object"); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+}
+
+// Tests validate parsing of retention holds.
+func TestParseRetentionHold(t *testing.T) {
+	now := time.Date(2016, time.August, 1, 0, 0, 0, 0, time.UTC)
+	testCases := []struct {
+		holdBuf     string
+		expectedErr error
+	}{
+		// Test case - 1.
+		{`{"bucket": "cases", "prefix": "2016/", "tags": {"case": "1"}, "retainUntil": "2017-01-01T00:00:00Z"}`, nil},
+		// Test case - 2.
+		{`{"bucket": "cases", "modifiedAfter": "2016-01-01T00:00:00Z", "modifiedBefore": "2016-07-01T00:00:00Z", "legalHold": true}`, nil},
+		// Test case - 3.
+		// Hold retaining nothing.
+		{`{"bucket": "cases", "prefix": "2016/"}`, errInvalidRetentionHold},
+		// Test case - 4.
+		// Retention ending in the past.
+		{`{"bucket": "cases", "retainUntil": "2016-07-01T00:00:00Z"}`, errInvalidRetentionHold},
+		// Test case - 5.
+		{`{"bucket": "cases", "retainUntil": "2117-01-01T00:00:00Z"}`, errInvalidRetentionHold},
+		// Test case - 6.
+		// Empty time range.
+		{`{"bucket": "cases", "modifiedAfter": "2016-07-01T00:00:00Z", "modifiedBefore": "2016-07-01T00:00:00Z", "legalHold": true}`, errInvalidRetentionHold},
+		// Test case - 7.
+		{`{"legalHold": true}`, errInvalidRetentionHold},
+	}
+	for i, testCase := range testCases {
+		_, err := parseRetentionHold([]byte(testCase.holdBuf), now)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+	}
+}
+
+// Wrapper for calling retention hold tests for both XL multiple disks
+// and single node setup.
+func TestHoldObjects(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	ExecObjectLayerTest(t, testHoldObjects)
+}
+
+// Tests validate retention holds only extend retention of matching
+// objects and list them with their retention.
+func testHoldObjects(obj ObjectLayer, instanceType string, t *testing.T) {
+	obj = newRetainedObjects(obj)
+	bucket := "cases"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if err := writeBucketObjectLockConfig(bucket, objectLockConfiguration{ObjectLockEnabled: objectLockEnabled}); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	defer removeBucketObjectLockConfig(bucket)
+
+	data := []byte("hello")
+	for _, object := range []string{"2016/a", "2016/b", "2015/a"} {
+		if _, err := obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("%s : %s", instanceType, err.Error())
+		}
+	}
+	// Retention held longer than the hold is kept.
+	later := time.Now().UTC().Add(48 * time.Hour).Truncate(time.Second)
+	if err := obj.PutObjectRetention(bucket, "2016/b", objectRetention{RetainUntil: later}); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+
+	hold := retentionHold{
+		Bucket:      bucket,
+		Prefix:      "2016/",
+		RetainUntil: time.Now().UTC().Add(24 * time.Hour).Truncate(time.Second),
+	}
+	for _, dryRun := range []bool{true, false} {
+		entries, err := holdObjects(obj, hold, dryRun)
+		if err != nil {
+			t.Fatalf("%s : %s", instanceType, err.Error())
+		}
+		if len(entries) != 2 || entries[0].Object != "2016/a" || entries[1].Object != "2016/b" {
+			t.Fatalf("%s: Expected 2016/a and 2016/b to be held, got %v", instanceType, entries)
+		}
+		if !entries[0].Retention.RetainUntil.Equal(hold.RetainUntil) || !entries[1].Retention.RetainUntil.Equal(later) {
+			t.Errorf("%s: Unexpected retention %v and %v", instanceType, entries[0].Retention, entries[1].Retention)
+		}
+		objInfo, err := obj.GetObjectInfo(bucket, "2016/a")
+		if err != nil {
+			t.Fatalf("%s : %s", instanceType, err.Error())
+		}
+		if objInfo.Retention.RetainUntil.IsZero() != dryRun {
+			t.Errorf("%s: Expected retention to be set only without dry-run, got %v", instanceType, objInfo.Retention)
+		}
+	}
+	if err := obj.DeleteObject(bucket, "2016/a"); err == nil {
+		t.Fatalf("%s: Expected delete of held object to fail.", instanceType)
+	}
+	if err := obj.DeleteObject(bucket, "2015/a"); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+
+	// FS saves no tags.
+	if instanceType == singleNodeTestStr {
+		return
+	}
+	if _, err := obj.PutObject(bucket, "tagged", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if err := obj.PutObjectTags(bucket, "tagged", map[string]string{"case": "1"}); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	hold = retentionHold{Bucket: bucket, Tags: map[string]string{"case": "1"}, LegalHold: true}
+	entries, err := holdObjects(obj, hold, false)
+	if err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if len(entries) != 1 || entries[0].Object != "tagged" || !entries[0].Retention.LegalHold {
+		t.Fatalf("%s: Expected legal hold on tagged, got %v", instanceType, entries)
+	}
+	if err = setObjectLegalHold(obj, bucket, "tagged", false); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+	if err = obj.DeleteObject(bucket, "tagged"); err != nil {
+		t.Fatalf("%s : %s", instanceType, err.Error())
+	}
+}
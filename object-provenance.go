@@ -0,0 +1,92 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net"
+	"os"
+	"time"
+)
+
+// Internal metadata recording where an object was written, never
+// accepted from clients.
+const (
+	provenanceDeploymentKey = "X-Minio-Internal-Deployment-Id"
+	provenanceNodeKey       = "X-Minio-Internal-Node"
+	provenanceWriteTimeKey  = "X-Minio-Internal-Write-Time"
+)
+
+// objectProvenance - deployment, node and time an object was written
+// at, zero for objects written before provenance was recorded.
+type objectProvenance struct {
+	DeploymentID string
+	Node         string
+	WriteTime    time.Time
+}
+
+// getNodeName - returns name of this node, the host of the server
+// address or the hostname if listening on all interfaces.
+func getNodeName(serverAddr string) string {
+	host, port, err := net.SplitHostPort(serverAddr)
+	if err != nil {
+		return serverAddr
+	}
+	if host == "" {
+		if host, err = os.Hostname(); err != nil {
+			host = "localhost"
+		}
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// stampObjectProvenance - records this deployment, node and the
+// write time in object metadata. Objects moved internally between sets
+// or tiers carry their provenance in metadata, which is kept.
+func stampObjectProvenance(metadata map[string]string, writeTime time.Time) {
+	if metadata[provenanceWriteTimeKey] != "" {
+		return
+	}
+	if serverConfig != nil {
+		metadata[provenanceDeploymentKey] = serverConfig.GetDeploymentID()
+	}
+	metadata[provenanceNodeKey] = globalNodeName
+	metadata[provenanceWriteTimeKey] = writeTime.UTC().Format(time.RFC3339Nano)
+}
+
+// setObjectProvenance - saves provenance of an object into object
+// metadata, zero provenance removes it.
+func setObjectProvenance(metadata map[string]string, provenance objectProvenance) {
+	if provenance.WriteTime.IsZero() {
+		delete(metadata, provenanceDeploymentKey)
+		delete(metadata, provenanceNodeKey)
+		delete(metadata, provenanceWriteTimeKey)
+		return
+	}
+	metadata[provenanceDeploymentKey] = provenance.DeploymentID
+	metadata[provenanceNodeKey] = provenance.Node
+	metadata[provenanceWriteTimeKey] = provenance.WriteTime.UTC().Format(time.RFC3339Nano)
+}
+
+// getObjectProvenance - returns provenance saved in object metadata.
+func getObjectProvenance(metadata map[string]string) objectProvenance {
+	writeTime, _ := time.Parse(time.RFC3339Nano, metadata[provenanceWriteTimeKey])
+	return objectProvenance{
+		DeploymentID: metadata[provenanceDeploymentKey],
+		Node:         metadata[provenanceNodeKey],
+		WriteTime:    writeTime,
+	}
+}
@@ -0,0 +1,115 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"os"
+	"testing"
+)
+
+// Tests validate node names derived from the server address.
+func TestGetNodeName(t *testing.T) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		serverAddr       string
+		expectedNodeName string
+	}{
+		// Test case - 1.
+		{"node1.example.com:9000", "node1.example.com:9000"},
+		// Test case - 2.
+		{"10.0.0.1:9000", "10.0.0.1:9000"},
+		// Test case - 3.
+		// Listening on all interfaces.
+		{":9000", hostname + ":9000"},
+		// Test case - 4.
+		// Address without a port.
+		{"node1", "node1"},
+	}
+	for i, testCase := range testCases {
+		if nodeName := getNodeName(testCase.serverAddr); nodeName != testCase.expectedNodeName {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, testCase.expectedNodeName, nodeName)
+		}
+	}
+}
+
+// Tests objects are stamped with provenance reported by
+// GetObjectAttributes.
+func TestGetObjectAttributes(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+
+	bucket := makeIntegrationBucket(t, client)
+	resp, respBody, err := client.do("PUT", bucket, "object", nil, nil, []byte("data"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+
+	testCases := []struct {
+		attributes         string
+		expectedSize       bool
+		expectedProvenance bool
+	}{
+		// Test case - 1.
+		// All attributes.
+		{"", true, true},
+		// Test case - 2.
+		{"ObjectSize", true, false},
+		// Test case - 3.
+		{"ETag, Provenance", false, true},
+	}
+	for i, testCase := range testCases {
+		headers := map[string]string{}
+		if testCase.attributes != "" {
+			headers["X-Amz-Object-Attributes"] = testCase.attributes
+		}
+		resp, respBody, err = client.do("GET", bucket, "object", url.Values{"attributes": {""}}, headers, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "GetObjectAttributes", resp, respBody, http.StatusOK)
+		response := GetObjectAttributesResponse{}
+		if err = xml.Unmarshal(respBody, &response); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if (response.ObjectSize != nil) != testCase.expectedSize {
+			t.Errorf("Test %d: Expected size %t, got %v", i+1, testCase.expectedSize, response.ObjectSize)
+		}
+		if response.ObjectSize != nil && *response.ObjectSize != 4 {
+			t.Errorf("Test %d: Expected size 4, got %d", i+1, *response.ObjectSize)
+		}
+		if (response.Provenance != nil) != testCase.expectedProvenance {
+			t.Errorf("Test %d: Expected provenance %t, got %v", i+1, testCase.expectedProvenance, response.Provenance)
+		}
+		if response.Provenance == nil {
+			continue
+		}
+		if response.Provenance.DeploymentID != serverConfig.GetDeploymentID() {
+			t.Errorf("Test %d: Expected deployment %s, got %s", i+1, serverConfig.GetDeploymentID(), response.Provenance.DeploymentID)
+		}
+		if response.Provenance.Node != globalNodeName || response.Provenance.WriteTime == "" {
+			t.Errorf("Test %d: Expected node %s and a write time, got %+v", i+1, globalNodeName, response.Provenance)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Bucket snapshots are kept in minioMetaBucket on every disk as
+//
+//	snapshots/<bucket>/<snapshotID>/snapshot.json
+//	snapshots/<bucket>/<snapshotID>/data/<bucket contents>
+//
+// where the data files are hard links to the bucket contents at the
+// time of the snapshot. Objects are never modified in place, they are
+// replaced by a rename, so a snapshot keeps referring to the data as
+// it was without copying it.
+const (
+	snapshotMetaPrefix = "snapshots"
+	snapshotJSONFile   = "snapshot.json"
+	snapshotDataDir    = "data"
+)
+
+// snapshotMetaV1 - snapshot.json, written once the snapshot is complete.
+type snapshotMetaV1 struct {
+	Version string    `json:"version"`
+	Bucket  string    `json:"bucket"`
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+}
+
+// getSnapshotPrefix - returns snapshot prefix inside minioMetaBucket.
+func getSnapshotPrefix(bucket, snapshotID string) string {
+	return path.Join(snapshotMetaPrefix, bucket, snapshotID)
+}
+
+// getSnapshotDataPrefix - returns prefix of the snapshot contents.
+func getSnapshotDataPrefix(bucket, snapshotID string) string {
+	return path.Join(getSnapshotPrefix(bucket, snapshotID), snapshotDataDir)
+}
+
+// isValidSnapshotID - snapshot id must be a single path element.
+func isValidSnapshotID(snapshotID string) bool {
+	if snapshotID == "" || snapshotID == "." || snapshotID == ".." {
+		return false
+	}
+	return !strings.ContainsAny(snapshotID, `/\`)
+}
+
+// writeSnapshotMetadata - writes snapshot.json marking the snapshot complete.
+func writeSnapshotMetadata(disk StorageAPI, snapshotInfo BucketSnapshotInfo) error {
+	snapshotBytes, err := json.Marshal(snapshotMetaV1{
+		Version: "1",
+		Bucket:  snapshotInfo.Bucket,
+		ID:      snapshotInfo.ID,
+		Created: snapshotInfo.Created,
+	})
+	if err != nil {
+		return err
+	}
+	snapshotJSONPath := path.Join(getSnapshotPrefix(snapshotInfo.Bucket, snapshotInfo.ID), snapshotJSONFile)
+	return disk.AppendFile(minioMetaBucket, snapshotJSONPath, snapshotBytes)
+}
+
+// readSnapshotMetadata - reads snapshot.json, incomplete snapshots
+// are reported as not found.
+func readSnapshotMetadata(disk StorageAPI, bucket, snapshotID string) (BucketSnapshotInfo, error) {
+	if !isValidSnapshotID(snapshotID) {
+		return BucketSnapshotInfo{}, BucketSnapshotNotFound{Bucket: bucket, SnapshotID: snapshotID}
+	}
+	buf, err := disk.ReadAll(minioMetaBucket, path.Join(getSnapshotPrefix(bucket, snapshotID), snapshotJSONFile))
+	if err != nil {
+		if err == errFileNotFound {
+			return BucketSnapshotInfo{}, BucketSnapshotNotFound{Bucket: bucket, SnapshotID: snapshotID}
+		}
+		return BucketSnapshotInfo{}, err
+	}
+	snapshotMeta := snapshotMetaV1{}
+	if err = json.Unmarshal(buf, &snapshotMeta); err != nil {
+		return BucketSnapshotInfo{}, err
+	}
+	return BucketSnapshotInfo{
+		Bucket:  snapshotMeta.Bucket,
+		ID:      snapshotMeta.ID,
+		Created: snapshotMeta.Created,
+	}, nil
+}
+
+// bySnapshotCreated is a collection satisfying sort.Interface.
+type bySnapshotCreated []BucketSnapshotInfo
+
+func (t bySnapshotCreated) Len() int           { return len(t) }
+func (t bySnapshotCreated) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+func (t bySnapshotCreated) Less(i, j int) bool { return t[i].Created.Before(t[j].Created) }
+
+// listSnapshots - lists all complete snapshots of a bucket sorted by
+// creation time.
+func listSnapshots(disk StorageAPI, bucket string) ([]BucketSnapshotInfo, error) {
+	entries, err := disk.ListDir(minioMetaBucket, path.Join(snapshotMetaPrefix, bucket))
+	if err != nil {
+		if err == errFileNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var snapshots []BucketSnapshotInfo
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry, slashSeparator) {
+			continue
+		}
+		snapshotInfo, err := readSnapshotMetadata(disk, bucket, strings.TrimSuffix(entry, slashSeparator))
+		if err != nil {
+			if _, ok := err.(BucketSnapshotNotFound); ok {
+				// Snapshot in progress or incomplete, skip it.
+				continue
+			}
+			return nil, err
+		}
+		snapshots = append(snapshots, snapshotInfo)
+	}
+	sort.Sort(bySnapshotCreated(snapshots))
+	return snapshots, nil
+}
@@ -0,0 +1,111 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+// Wrapper for calling bucket snapshot tests for both XL and FS.
+func TestBucketSnapshot(t *testing.T) {
+	ExecObjectLayerTest(t, testBucketSnapshot)
+}
+
+// Tests snapshot and clone of a bucket.
+func testBucketSnapshot(obj ObjectLayer, instanceType string, t *testing.T) {
+	bucket := "snapshot-bucket"
+	if err := obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("%s: Unable to make bucket. %s", instanceType, err)
+	}
+	objects := map[string][]byte{
+		"object":     []byte("hello world"),
+		"dir/object": []byte("hello nested world"),
+	}
+	for object, data := range objects {
+		if _, err := obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("%s: Unable to put object. %s", instanceType, err)
+		}
+	}
+
+	snapshotInfo, err := obj.SnapshotBucket(bucket, getUUID())
+	if err != nil {
+		t.Fatalf("%s: Unable to snapshot bucket. %s", instanceType, err)
+	}
+
+	// Changes after the snapshot are not part of it.
+	newData := []byte("overwritten")
+	if _, err = obj.PutObject(bucket, "object", int64(len(newData)), bytes.NewReader(newData), nil); err != nil {
+		t.Fatalf("%s: Unable to overwrite object. %s", instanceType, err)
+	}
+	if err = obj.DeleteObject(bucket, "dir/object"); err != nil {
+		t.Fatalf("%s: Unable to delete object. %s", instanceType, err)
+	}
+
+	snapshots, err := obj.ListBucketSnapshots(bucket)
+	if err != nil {
+		t.Fatalf("%s: Unable to list snapshots. %s", instanceType, err)
+	}
+	if len(snapshots) != 1 || snapshots[0].ID != snapshotInfo.ID {
+		t.Fatalf("%s: Expected snapshot %s, got %v", instanceType, snapshotInfo.ID, snapshots)
+	}
+
+	testCases := []struct {
+		bucket      string
+		snapshotID  string
+		cloneBucket string
+		expectedErr error
+	}{
+		// Test case - 1.
+		{bucket, snapshotInfo.ID, "clone-bucket", nil},
+		// Test case - 2.
+		// Clone bucket already exists.
+		{bucket, snapshotInfo.ID, "clone-bucket", BucketExists{Bucket: "clone-bucket"}},
+		// Test case - 3.
+		{bucket, "unknown", "other-bucket", BucketSnapshotNotFound{Bucket: bucket, SnapshotID: "unknown"}},
+		// Test case - 4.
+		{bucket, "..", "other-bucket", BucketSnapshotNotFound{Bucket: bucket, SnapshotID: ".."}},
+	}
+	for i, testCase := range testCases {
+		err = obj.CloneBucketSnapshot(testCase.bucket, testCase.snapshotID, testCase.cloneBucket)
+		if err != testCase.expectedErr {
+			t.Errorf("%s: Test %d: Expected error %v, got %v", instanceType, i+1, testCase.expectedErr, err)
+		}
+	}
+
+	// Clone has the contents at the time of the snapshot.
+	for object, data := range objects {
+		buffer := new(bytes.Buffer)
+		if err = obj.GetObject("clone-bucket", object, 0, int64(len(data)), buffer); err != nil {
+			t.Fatalf("%s: Unable to get cloned object %s. %s", instanceType, object, err)
+		}
+		if !bytes.Equal(buffer.Bytes(), data) {
+			t.Errorf("%s: Expected %s, got %s", instanceType, data, buffer.Bytes())
+		}
+	}
+
+	if err = obj.DeleteBucketSnapshot(bucket, snapshotInfo.ID); err != nil {
+		t.Fatalf("%s: Unable to delete snapshot. %s", instanceType, err)
+	}
+	if snapshots, err = obj.ListBucketSnapshots(bucket); err != nil || len(snapshots) != 0 {
+		t.Errorf("%s: Expected no snapshots, got %v %v", instanceType, snapshots, err)
+	}
+	// Clone is unaffected by snapshot removal.
+	if _, err = obj.GetObjectInfo("clone-bucket", "dir/object"); err != nil {
+		t.Errorf("%s: Expected cloned object to exist. %s", instanceType, err)
+	}
+}
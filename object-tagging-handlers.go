@@ -0,0 +1,134 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+
+	mux "github.com/gorilla/mux"
+)
+
+// PutObjectTaggingHandler - PUT Object tagging
+// ----------
+// This implementation of the PUT operation uses the tagging
+// subresource to replace the tags of an object.
+func (api objectAPIHandlers) PutObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	// If Content-Length is greater than maximum allowed size.
+	if r.ContentLength > maxObjectTaggingSize {
+		writeErrorResponse(w, r, ErrEntityTooLarge, r.URL.Path)
+		return
+	}
+
+	tags, err := parseObjectTagging(r.Body)
+	if err != nil {
+		errorIf(err, "Unable to parse object tags.")
+		if err == errInvalidObjectTags {
+			writeErrorResponse(w, r, ErrInvalidObjectTags, r.URL.Path)
+		} else {
+			writeErrorResponse(w, r, ErrMalformedXML, r.URL.Path)
+		}
+		return
+	}
+
+	if err = api.ObjectAPI.PutObjectTags(bucket, object, tags); err != nil {
+		errorIf(err, "Unable to set object tags.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	replicateObjectWrite(w, api.ObjectAPI, bucket, object)
+	writeSuccessResponse(w, nil)
+}
+
+// GetObjectTaggingHandler - GET Object tagging
+// ----------
+// This operation uses the tagging subresource to return the tags of
+// an object, an object without tags has an empty tag set.
+func (api objectAPIHandlers) GetObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	objInfo, err := api.ObjectAPI.GetObjectInfo(bucket, object)
+	if err != nil {
+		errorIf(err, "Unable to fetch object info.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	encodedSuccessResponse := encodeResponse(generateObjectTagging(objInfo.Tags))
+	// write headers
+	setCommonHeaders(w)
+	// write success response.
+	writeSuccessResponse(w, encodedSuccessResponse)
+}
+
+// DeleteObjectTaggingHandler - DELETE Object tagging
+// ----------
+// This implementation of the DELETE operation uses the tagging
+// subresource to remove all tags of an object.
+func (api objectAPIHandlers) DeleteObjectTaggingHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	if err := api.ObjectAPI.PutObjectTags(bucket, object, nil); err != nil {
+		errorIf(err, "Unable to remove object tags.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	replicateObjectWrite(w, api.ObjectAPI, bucket, object)
+	writeSuccessNoContent(w)
+}
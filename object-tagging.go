@@ -0,0 +1,205 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/xml"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"unicode/utf8"
+)
+
+const (
+	// Tags of an object written, in the query string form
+	// "key1=value1&key2=value2".
+	amzTaggingHeader = "X-Amz-Tagging"
+
+	// Number of tags of an object, sent with GET and HEAD object.
+	amzTaggingCountHeader = "X-Amz-Tagging-Count"
+
+	// Copies keep the tags of the source with "COPY", the default,
+	// or take the tags of X-Amz-Tagging with "REPLACE".
+	amzTaggingDirectiveHeader = "X-Amz-Tagging-Directive"
+
+	// Metadata key of the tags of an object, saved in the query
+	// string form of X-Amz-Tagging.
+	objectTagsMetaKey = "x-amz-tagging"
+
+	// Extension query parameter of ListObjects, each value restricts
+	// the listing to objects with a matching tag.
+	listTagFilterParam = "tag"
+
+	// Limits of object tags, as enforced by S3.
+	maxObjectTags        = 10
+	maxObjectTagKeyLen   = 128
+	maxObjectTagValueLen = 256
+
+	// Maximum size of a PUT tagging request body.
+	maxObjectTaggingSize = 64 * 1024
+)
+
+// errInvalidObjectTags - tags are malformed, too many or too long.
+var errInvalidObjectTags = errors.New("Invalid object tags")
+
+// objectTag - a single tag of an object.
+type objectTag struct {
+	Key   string
+	Value string
+}
+
+// objectTagging - represents the tag set of an object, as sent and
+// returned by the tagging subresource.
+type objectTagging struct {
+	XMLName xml.Name `xml:"Tagging"`
+	TagSet  struct {
+		Tags []objectTag `xml:"Tag"`
+	}
+}
+
+// validateObjectTags - returns errInvalidObjectTags if tags exceed
+// the limits of S3.
+func validateObjectTags(tags map[string]string) error {
+	if len(tags) > maxObjectTags {
+		return errInvalidObjectTags
+	}
+	for key, value := range tags {
+		if key == "" || utf8.RuneCountInString(key) > maxObjectTagKeyLen || utf8.RuneCountInString(value) > maxObjectTagValueLen {
+			return errInvalidObjectTags
+		}
+	}
+	return nil
+}
+
+// parseObjectTagsHeader - parses tags of the X-Amz-Tagging header,
+// keys may not repeat.
+func parseObjectTagsHeader(value string) (map[string]string, error) {
+	values, err := url.ParseQuery(value)
+	if err != nil {
+		return nil, errInvalidObjectTags
+	}
+	tags := make(map[string]string)
+	for key, tagValues := range values {
+		if len(tagValues) != 1 {
+			return nil, errInvalidObjectTags
+		}
+		tags[key] = tagValues[0]
+	}
+	if err = validateObjectTags(tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// parseObjectTagging - parses the tag set of a PUT tagging request,
+// keys may not repeat.
+func parseObjectTagging(reader io.Reader) (map[string]string, error) {
+	var tagging objectTagging
+	if err := xml.NewDecoder(io.LimitReader(reader, maxObjectTaggingSize)).Decode(&tagging); err != nil {
+		return nil, err
+	}
+	tags := make(map[string]string)
+	for _, tag := range tagging.TagSet.Tags {
+		if _, ok := tags[tag.Key]; ok {
+			return nil, errInvalidObjectTags
+		}
+		tags[tag.Key] = tag.Value
+	}
+	if err := validateObjectTags(tags); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// generateObjectTagging - returns the tag set of an object, sorted by
+// key.
+func generateObjectTagging(tags map[string]string) objectTagging {
+	var tagging objectTagging
+	tagging.TagSet.Tags = []objectTag{}
+	for key, value := range tags {
+		tagging.TagSet.Tags = append(tagging.TagSet.Tags, objectTag{Key: key, Value: value})
+	}
+	sort.Sort(byObjectTagKey(tagging.TagSet.Tags))
+	return tagging
+}
+
+// byObjectTagKey is a collection satisfying sort.Interface.
+type byObjectTagKey []objectTag
+
+func (s byObjectTagKey) Len() int           { return len(s) }
+func (s byObjectTagKey) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byObjectTagKey) Less(i, j int) bool { return s[i].Key < s[j].Key }
+
+// encodeObjectTags - returns tags in the query string form saved in
+// object metadata, sorted by key.
+func encodeObjectTags(tags map[string]string) string {
+	values := make(url.Values)
+	for key, value := range tags {
+		values.Set(key, value)
+	}
+	return values.Encode()
+}
+
+// getObjectTags - returns tags saved in object metadata, nil if the
+// object has none.
+func getObjectTags(meta map[string]string) map[string]string {
+	encodedTags, ok := meta[objectTagsMetaKey]
+	if !ok || encodedTags == "" {
+		return nil
+	}
+	values, err := url.ParseQuery(encodedTags)
+	if err != nil {
+		return nil
+	}
+	tags := make(map[string]string)
+	for key := range values {
+		tags[key] = values.Get(key)
+	}
+	return tags
+}
+
+// setObjectTags - saves tags into object metadata, no tags remove
+// them.
+func setObjectTags(meta map[string]string, tags map[string]string) {
+	if len(tags) == 0 {
+		delete(meta, objectTagsMetaKey)
+		return
+	}
+	meta[objectTagsMetaKey] = encodeObjectTags(tags)
+}
+
+// setObjectTagsFromHeader - saves tags of the X-Amz-Tagging header, if
+// set, into object metadata.
+func setObjectTagsFromHeader(header http.Header, metadata map[string]string) APIErrorCode {
+	value := header.Get(amzTaggingHeader)
+	if value == "" {
+		return ErrNone
+	}
+	tags, err := parseObjectTagsHeader(value)
+	if err != nil {
+		return ErrInvalidObjectTags
+	}
+	setObjectTags(metadata, tags)
+	return ErrNone
+}
+
+// hasObjectTags - returns true if object metadata carries tags.
+func hasObjectTags(meta map[string]string) bool {
+	return meta[objectTagsMetaKey] != ""
+}
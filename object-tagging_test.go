@@ -0,0 +1,118 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Tests parsing tags of the X-Amz-Tagging header.
+func TestParseObjectTagsHeader(t *testing.T) {
+	testCases := []struct {
+		value        string
+		expectedTags map[string]string
+		expectedErr  error
+	}{
+		// Test case - 1.
+		{"env=prod&team=storage", map[string]string{"env": "prod", "team": "storage"}, nil},
+		// Test case - 2.
+		// Tags without a value.
+		{"env", map[string]string{"env": ""}, nil},
+		// Test case - 3.
+		// Escaped keys and values.
+		{"my%20env=a%26b", map[string]string{"my env": "a&b"}, nil},
+		// Test case - 4.
+		// Repeated keys.
+		{"env=prod&env=dev", nil, errInvalidObjectTags},
+		// Test case - 5.
+		// Malformed escapes.
+		{"env=%zz", nil, errInvalidObjectTags},
+		// Test case - 6.
+		// Too many tags.
+		{"a=1&b=2&c=3&d=4&e=5&f=6&g=7&h=8&i=9&j=10&k=11", nil, errInvalidObjectTags},
+		// Test case - 7.
+		// Too long values.
+		{"env=" + strings.Repeat("a", 257), nil, errInvalidObjectTags},
+	}
+	for i, testCase := range testCases {
+		tags, err := parseObjectTagsHeader(testCase.value)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		if err == nil && !reflect.DeepEqual(tags, testCase.expectedTags) {
+			t.Errorf("Test %d: Expected tags %v, got %v", i+1, testCase.expectedTags, tags)
+		}
+	}
+}
+
+// Tests parsing tag sets of PUT tagging requests.
+func TestParseObjectTagging(t *testing.T) {
+	testCases := []struct {
+		body         string
+		expectedTags map[string]string
+		shouldPass   bool
+	}{
+		// Test case - 1.
+		{"<Tagging><TagSet><Tag><Key>env</Key><Value>prod</Value></Tag></TagSet></Tagging>", map[string]string{"env": "prod"}, true},
+		// Test case - 2.
+		// Empty tag sets remove all tags.
+		{"<Tagging><TagSet></TagSet></Tagging>", map[string]string{}, true},
+		// Test case - 3.
+		// Repeated keys.
+		{"<Tagging><TagSet><Tag><Key>a</Key></Tag><Tag><Key>a</Key></Tag></TagSet></Tagging>", nil, false},
+		// Test case - 4.
+		// Empty keys.
+		{"<Tagging><TagSet><Tag><Value>prod</Value></Tag></TagSet></Tagging>", nil, false},
+		// Test case - 5.
+		// Malformed XML.
+		{"<Tagging><TagSet>", nil, false},
+	}
+	for i, testCase := range testCases {
+		tags, err := parseObjectTagging(strings.NewReader(testCase.body))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed", i+1)
+		}
+		if err == nil && !reflect.DeepEqual(tags, testCase.expectedTags) {
+			t.Errorf("Test %d: Expected tags %v, got %v", i+1, testCase.expectedTags, tags)
+		}
+	}
+}
+
+// Tests tags saved into and read from object metadata.
+func TestObjectTagsMetadata(t *testing.T) {
+	tags := map[string]string{"env": "prod", "team": "a b&c=d"}
+	metadata := make(map[string]string)
+	setObjectTags(metadata, tags)
+	if metadata[objectTagsMetaKey] != "env=prod&team=a+b%26c%3Dd" {
+		t.Fatalf("Expected tags sorted in query string form, got %s", metadata[objectTagsMetaKey])
+	}
+	if !hasObjectTags(metadata) {
+		t.Fatal("Expected metadata to carry tags")
+	}
+	if savedTags := getObjectTags(metadata); !reflect.DeepEqual(savedTags, tags) {
+		t.Fatalf("Expected tags %v, got %v", tags, savedTags)
+	}
+	setObjectTags(metadata, nil)
+	if hasObjectTags(metadata) || getObjectTags(metadata) != nil {
+		t.Fatalf("Expected tags to be removed, got %v", metadata)
+	}
+}
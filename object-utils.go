@@ -30,7 +30,9 @@ import (
 
 const (
 	// Minio meta bucket.
-	minioMetaBucket = ".minio"
+	minioMetaBucket = ".minio.sys"
+	// Minio meta bucket of older releases, migrated on startup.
+	oldMinioMetaBucket = ".minio"
 	// Multipart meta prefix.
 	mpartMetaPrefix = "multipart"
 	// Tmp meta prefix.
@@ -54,6 +56,12 @@ func IsValidBucketName(bucket string) bool {
 	return validBucket.MatchString(bucket)
 }
 
+// isMinioMetaBucketName - returns true if bucket is used for internal
+// state, by this or older releases.
+func isMinioMetaBucketName(bucket string) bool {
+	return bucket == minioMetaBucket || bucket == oldMinioMetaBucket
+}
+
 // IsValidObjectName verifies an object name in accordance with Amazon's
 // requirements. It cannot exceed 1024 characters and must be a valid UTF8
 // string.
@@ -0,0 +1,118 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// isWebsiteRequest - returns true for anonymous reads, objects served
+// publicly through bucket policies, usually from behind a CDN.
+func isWebsiteRequest(r *http.Request) bool {
+	if r.Method != "GET" && r.Method != "HEAD" {
+		return false
+	}
+	return getRequestAuthType(r) == authTypeAnonymous
+}
+
+// acceptsEncoding - returns true if Accept-Encoding of the request
+// allows the content coding. Any coding is acceptable if the header is
+// not present, codings listed with q=0 are not.
+func acceptsEncoding(r *http.Request, coding string) bool {
+	if _, ok := r.Header["Accept-Encoding"]; !ok {
+		return true
+	}
+	var accepted, wildcard, listed bool
+	for _, field := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(field, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name != coding && name != "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		// Coding listed by name takes precedence over "*".
+		if name == coding {
+			accepted, listed = quality > 0, true
+		} else if !listed {
+			wildcard = quality > 0
+		}
+	}
+	if listed {
+		return accepted
+	}
+	return wildcard
+}
+
+// isGzipEncoded - returns true if the object was uploaded gzip encoded.
+func isGzipEncoded(objInfo ObjectInfo) bool {
+	encoding := strings.ToLower(strings.TrimSpace(objInfo.ContentEncoding))
+	return encoding == "gzip" || encoding == "x-gzip"
+}
+
+// isDecodedRepresentation - returns true if a gzip encoded object is
+// to be served decoded, to website clients which don't accept gzip.
+func isDecodedRepresentation(r *http.Request, objInfo ObjectInfo) bool {
+	return isWebsiteRequest(r) && isGzipEncoded(objInfo) && !acceptsEncoding(r, "gzip")
+}
+
+// setWebsiteHeaders - sets caching headers of website responses, must
+// be called after setObjectHeaders. Responses of encoded objects vary
+// by Accept-Encoding, the decoded representation has its own strong
+// ETag and is not served in ranges.
+func setWebsiteHeaders(w http.ResponseWriter, objInfo ObjectInfo, decoded bool) {
+	if objInfo.ContentEncoding != "" {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+	if !decoded {
+		return
+	}
+	if objInfo.MD5Sum != "" {
+		w.Header().Set("ETag", "\""+objInfo.MD5Sum+"-identity\"")
+	}
+	w.Header().Set("Accept-Ranges", "none")
+	w.Header().Del("Content-Encoding")
+	// Length of the decoded representation is not known.
+	w.Header().Del("Content-Length")
+}
+
+// getDecodedObject - writes the gzip decoded object to the writer.
+func getDecodedObject(objAPI ObjectLayer, bucket, object string, size int64, writer io.Writer) error {
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(objAPI.GetObject(bucket, object, 0, size, pipeWriter))
+	}()
+	defer pipeReader.Close()
+	gzipReader, err := gzip.NewReader(pipeReader)
+	if err != nil {
+		return err
+	}
+	defer gzipReader.Close()
+	_, err = io.Copy(writer, gzipReader)
+	return err
+}
@@ -0,0 +1,157 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// Tests content codings acceptable by Accept-Encoding.
+func TestAcceptsEncoding(t *testing.T) {
+	testCases := []struct {
+		acceptEncoding string
+		present        bool
+		expected       bool
+	}{
+		// Test case - 1.
+		// Any coding is acceptable without Accept-Encoding.
+		{"", false, true},
+		// Test case - 2.
+		// Empty Accept-Encoding accepts only identity.
+		{"", true, false},
+		// Test case - 3.
+		{"gzip, deflate", true, true},
+		// Test case - 4.
+		{"deflate, br", true, false},
+		// Test case - 5.
+		// Coding excluded with q=0.
+		{"GZIP;q=0, deflate", true, false},
+		// Test case - 6.
+		{"*;q=0.5", true, true},
+		// Test case - 7.
+		// Coding listed by name takes precedence over "*".
+		{"*, gzip;q=0", true, false},
+		// Test case - 8.
+		{"identity", true, false},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("GET", "http://localhost:9000/bucket/object", nil)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to create request: %s", i+1, err)
+		}
+		if testCase.present {
+			req.Header.Set("Accept-Encoding", testCase.acceptEncoding)
+		}
+		if accepted := acceptsEncoding(req, "gzip"); accepted != testCase.expected {
+			t.Errorf("Test %d: Expected %t, got %t", i+1, testCase.expected, accepted)
+		}
+	}
+}
+
+// Tests caching headers and decoding of gzip encoded objects served
+// to anonymous clients.
+func TestWebsiteObject(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+	bucket := makeIntegrationBucket(t, client)
+
+	policy := `{"Version":"2012-10-17","Statement":[{"Action":["s3:GetObject"],"Effect":"Allow","Principal":{"AWS":["*"]},"Resource":["arn:aws:s3:::` + bucket + `/*"]}]}`
+	resp, respBody, err := client.do("PUT", bucket, "", map[string][]string{"policy": {""}}, nil, []byte(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutBucketPolicy", resp, respBody, http.StatusNoContent)
+
+	data := []byte("website object served to clients")
+	var encoded bytes.Buffer
+	gzipWriter := gzip.NewWriter(&encoded)
+	gzipWriter.Write(data)
+	gzipWriter.Close()
+	headers := map[string]string{
+		"Content-Encoding": "gzip",
+		"Cache-Control":    "public, max-age=300",
+	}
+	resp, respBody, err = client.do("PUT", bucket, "object", nil, headers, encoded.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+	etag := resp.Header.Get("ETag")
+
+	testCases := []struct {
+		acceptEncoding   string
+		expectedBody     []byte
+		expectedEncoding string
+		expectedETag     string
+	}{
+		// Test case - 1.
+		// Clients accepting gzip get the stored object.
+		{"gzip", encoded.Bytes(), "gzip", etag},
+		// Test case - 2.
+		// Other clients get the object decoded with its own ETag.
+		{"identity", data, "", etag[:len(etag)-1] + "-identity\""},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("GET", makeTestTargetURL(testServer.Server.URL, bucket, "object", nil), nil)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to create request: %s", i+1, err)
+		}
+		req.Header.Set("Accept-Encoding", testCase.acceptEncoding)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		respBody, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "GetObject", resp, respBody, http.StatusOK)
+		if !bytes.Equal(respBody, testCase.expectedBody) {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expectedBody, respBody)
+		}
+		if encoding := resp.Header.Get("Content-Encoding"); encoding != testCase.expectedEncoding {
+			t.Errorf("Test %d: Expected Content-Encoding %q, got %q", i+1, testCase.expectedEncoding, encoding)
+		}
+		if resp.Header.Get("ETag") != testCase.expectedETag {
+			t.Errorf("Test %d: Expected ETag %s, got %s", i+1, testCase.expectedETag, resp.Header.Get("ETag"))
+		}
+		if !strings.Contains(strings.Join(resp.Header["Vary"], ","), "Accept-Encoding") {
+			t.Errorf("Test %d: Expected Vary Accept-Encoding, got %q", i+1, resp.Header["Vary"])
+		}
+		if resp.Header.Get("Cache-Control") != "public, max-age=300" {
+			t.Errorf("Test %d: Expected Cache-Control from object metadata, got %q", i+1, resp.Header.Get("Cache-Control"))
+		}
+
+		// Same representation is not modified.
+		req.Header.Set("If-None-Match", testCase.expectedETag)
+		resp, err = http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusNotModified {
+			t.Errorf("Test %d: Expected status %d, got %d", i+1, http.StatusNotModified, resp.StatusCode)
+		}
+	}
+}
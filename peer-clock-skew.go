@@ -0,0 +1,105 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Interval at which clock skew with peers is measured.
+const clockSkewInterval = 1 * time.Minute
+
+// peerClockSkew - last measured clock skew of a peer, positive if
+// the peer clock is ahead.
+type peerClockSkew struct {
+	Peer     string        `json:"peer"`
+	Skew     time.Duration `json:"skew"`
+	Measured time.Time     `json:"measured"`
+}
+
+// clockSkewMonitor - keeps last measured clock skew of all peers.
+type clockSkewMonitor struct {
+	mutex *sync.Mutex
+	skews map[string]peerClockSkew
+}
+
+// Clock skew of peers, measured only in distributed setups.
+var globalClockSkew = &clockSkewMonitor{
+	mutex: &sync.Mutex{},
+	skews: make(map[string]peerClockSkew),
+}
+
+// absDuration - returns absolute value of a duration.
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// update - saves measured clock skew of a peer, returns true if the
+// skew exceeds the tolerance of request signatures.
+func (m *clockSkewMonitor) update(peer string, skew time.Duration) bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.skews[peer] = peerClockSkew{
+		Peer:     peer,
+		Skew:     skew,
+		Measured: time.Now().UTC(),
+	}
+	return absDuration(skew) > globalMaxSkewTime
+}
+
+// GetSkews - returns last measured clock skew of all peers.
+func (m *clockSkewMonitor) GetSkews() []peerClockSkew {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	skews := make([]peerClockSkew, 0, len(m.skews))
+	for _, skew := range m.skews {
+		skews = append(skews, skew)
+	}
+	return skews
+}
+
+// measureClockSkew - measures clock skew of all peers once, peers which
+// are not reachable keep their previous measurement.
+func measureClockSkew() {
+	token, err := newPeerToken(serverConfig.GetCredential())
+	if err != nil {
+		errorIf(err, "Unable to generate peer token.")
+		return
+	}
+	for _, peer := range globalPeers {
+		skew, err := getPeerClockSkew(peer, token)
+		if err != nil {
+			continue
+		}
+		if globalClockSkew.update(peer.addr, skew) {
+			errorIf(fmt.Errorf("clock skewed by %s", skew), "Clock of peer %s exceeds the tolerance of request signatures, synchronize clocks of all nodes with NTP.", peer.addr)
+		}
+	}
+}
+
+// clockSkewJob - measures clock skew of all peers periodically.
+func clockSkewJob() {
+	for {
+		measureClockSkew()
+		time.Sleep(clockSkewInterval)
+	}
+}
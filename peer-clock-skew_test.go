@@ -0,0 +1,65 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// Tests clock skew measurements against signature tolerance.
+func TestClockSkewMonitor(t *testing.T) {
+	monitor := &clockSkewMonitor{
+		mutex: &sync.Mutex{},
+		skews: make(map[string]peerClockSkew),
+	}
+	testCases := []struct {
+		peer           string
+		skew           time.Duration
+		expectExceeded bool
+	}{
+		// Test case - 1.
+		{"peer1:9000", 0, false},
+		// Test case - 2.
+		{"peer2:9000", globalMaxSkewTime, false},
+		// Test case - 3.
+		// Peer clock ahead.
+		{"peer3:9000", globalMaxSkewTime + time.Second, true},
+		// Test case - 4.
+		// Peer clock behind.
+		{"peer4:9000", -globalMaxSkewTime - time.Second, true},
+		// Test case - 5.
+		// Newer measurement replaces the previous one.
+		{"peer1:9000", time.Second, false},
+	}
+	for i, testCase := range testCases {
+		if exceeded := monitor.update(testCase.peer, testCase.skew); exceeded != testCase.expectExceeded {
+			t.Errorf("Test %d: Expected exceeded to be %v, got %v", i+1, testCase.expectExceeded, exceeded)
+		}
+	}
+
+	skews := monitor.GetSkews()
+	if len(skews) != 4 {
+		t.Fatalf("Expected 4 peers, got %d", len(skews))
+	}
+	for _, skew := range skews {
+		if skew.Peer == "peer1:9000" && skew.Skew != time.Second {
+			t.Errorf("Expected latest skew of peer1 to be %s, got %s", time.Second, skew.Skew)
+		}
+	}
+}
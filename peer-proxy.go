@@ -0,0 +1,160 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Set on requests proxied to a peer with the name of the node, the
+// peer serves them itself so requests never bounce between nodes.
+const peerProxyHeader = "X-Minio-Proxied-By"
+
+// isProxiedRequest - returns true if a peer proxied the request.
+func isProxiedRequest(r *http.Request) bool {
+	return r.Header.Get(peerProxyHeader) != ""
+}
+
+// getPeer - returns the peer with the address, nil if not a peer.
+func getPeer(addr string) *peerClient {
+	for _, peer := range globalPeers {
+		if peer.addr == addr {
+			return peer
+		}
+	}
+	return nil
+}
+
+// proxyToPeer - serves the request on the peer, the response of the
+// peer is returned to the client.
+func proxyToPeer(w http.ResponseWriter, r *http.Request, peerAddr string) {
+	scheme := "http"
+	if isSSL() {
+		scheme = "https"
+	}
+	// Host header is left untouched, signatures are verified against
+	// the host the client signed for.
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: scheme, Host: peerAddr})
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		errorIf(err, "Unable to proxy request to peer %s.", peerAddr)
+		writeErrorResponse(w, r, ErrInternalError, r.URL.Path)
+	}
+	r.Header.Set(peerProxyHeader, globalNodeName)
+	proxy.ServeHTTP(w, r)
+}
+
+// proxyToNode - proxies the request to the peer named by its `node`
+// query parameter, returns false if the request is for this node.
+func proxyToNode(w http.ResponseWriter, r *http.Request) bool {
+	node := r.URL.Query().Get("node")
+	if node == "" || node == globalNodeName || isProxiedRequest(r) {
+		return false
+	}
+	if getPeer(node) == nil {
+		writeErrorResponse(w, r, ErrInvalidQueryParams, r.URL.Path)
+		return true
+	}
+	proxyToPeer(w, r, node)
+	return true
+}
+
+// listContinuation - identifies the next page of a truncated listing,
+// tree walks of listings are kept by the node which listed the page.
+type listContinuation struct {
+	Bucket         string
+	Prefix         string
+	Delimiter      string
+	KeyMarker      string
+	UploadIDMarker string
+}
+
+// continuationRegistry - truncated listings of this node, kept as long
+// as their tree walks.
+type continuationRegistry struct {
+	mutex   *sync.Mutex
+	entries map[listContinuation]time.Time
+	timeout time.Duration
+}
+
+// Truncated listings of this node.
+var globalContinuations = newContinuationRegistry(globalLookupTimeout)
+
+// newContinuationRegistry - initialize a registry of truncated
+// listings, expiring after timeout.
+func newContinuationRegistry(timeout time.Duration) *continuationRegistry {
+	return &continuationRegistry{
+		mutex:   &sync.Mutex{},
+		entries: make(map[listContinuation]time.Time),
+		timeout: timeout,
+	}
+}
+
+// add - registers a truncated listing, expired entries are removed.
+func (c *continuationRegistry) add(continuation listContinuation) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	now := time.Now().UTC()
+	for entry, added := range c.entries {
+		if now.Sub(added) > c.timeout {
+			delete(c.entries, entry)
+		}
+	}
+	c.entries[continuation] = now
+}
+
+// has - returns true if the listing was truncated by this node.
+func (c *continuationRegistry) has(continuation listContinuation) bool {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	added, ok := c.entries[continuation]
+	return ok && time.Since(added) <= c.timeout
+}
+
+// remove - removes a listing which is continued.
+func (c *continuationRegistry) remove(continuation listContinuation) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.entries, continuation)
+}
+
+// findContinuationPeer - returns address of the peer which truncated
+// the listing, empty if this node did or no peer did.
+func findContinuationPeer(r *http.Request, continuation listContinuation) string {
+	if isProxiedRequest(r) || len(globalPeers) == 0 || globalContinuations.has(continuation) {
+		return ""
+	}
+	token, err := newPeerToken(serverConfig.GetCredential())
+	if err != nil {
+		errorIf(err, "Unable to generate peer token.")
+		return ""
+	}
+	args := &ContinuationPeerArgs{Token: token, Continuation: continuation}
+	for _, peer := range globalPeers {
+		reply := ContinuationPeerReply{}
+		if err = peer.Call("Peer.ContinuationHandler", args, &reply); err != nil {
+			continue
+		}
+		if reply.Found {
+			return peer.addr
+		}
+	}
+	return ""
+}
@@ -0,0 +1,161 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Tests truncated listings are found until continued or expired.
+func TestContinuationRegistry(t *testing.T) {
+	registry := newContinuationRegistry(time.Hour)
+	continuation := listContinuation{"bucket", "prefix/", "/", "prefix/a", "upload-id"}
+	if registry.has(continuation) {
+		t.Fatalf("Expected empty registry")
+	}
+	registry.add(continuation)
+	if !registry.has(continuation) {
+		t.Errorf("Expected listing to be found")
+	}
+	other := continuation
+	other.UploadIDMarker = "other-upload-id"
+	if registry.has(other) {
+		t.Errorf("Expected listing with other marker not to be found")
+	}
+	registry.remove(continuation)
+	if registry.has(continuation) {
+		t.Errorf("Expected continued listing not to be found")
+	}
+
+	// Expired listings are not found and removed on add.
+	registry.timeout = time.Millisecond
+	registry.add(continuation)
+	time.Sleep(10 * time.Millisecond)
+	if registry.has(continuation) {
+		t.Errorf("Expected expired listing not to be found")
+	}
+	registry.add(other)
+	if len(registry.entries) != 1 {
+		t.Errorf("Expected expired listing to be removed, got %d entries", len(registry.entries))
+	}
+}
+
+// Tests peers report listings truncated by them.
+func TestContinuationPeerHandler(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	validToken, err := newPeerToken(serverConfig.GetCredential())
+	if err != nil {
+		t.Fatalf("Unable to generate peer token. %s", err)
+	}
+
+	continuation := listContinuation{Bucket: "bucket", KeyMarker: "a", UploadIDMarker: "upload-id"}
+	defer globalContinuations.remove(continuation)
+	globalContinuations.add(continuation)
+
+	peer := &peerServer{}
+	reply := &ContinuationPeerReply{}
+	if err = peer.ContinuationHandler(&ContinuationPeerArgs{Token: "invalid", Continuation: continuation}, reply); err != errInvalidToken {
+		t.Errorf("Expected %s, got %v", errInvalidToken, err)
+	}
+	if err = peer.ContinuationHandler(&ContinuationPeerArgs{Token: validToken, Continuation: continuation}, reply); err != nil || !reply.Found {
+		t.Errorf("Expected listing to be found, got %t %v", reply.Found, err)
+	}
+	continuation.KeyMarker = "b"
+	reply = &ContinuationPeerReply{}
+	if err = peer.ContinuationHandler(&ContinuationPeerArgs{Token: validToken, Continuation: continuation}, reply); err != nil || reply.Found {
+		t.Errorf("Expected listing not to be found, got %t %v", reply.Found, err)
+	}
+}
+
+// Tests requests for a peer are proxied to it.
+func TestProxyToNode(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("peer " + r.Header.Get(peerProxyHeader)))
+	}))
+	defer peer.Close()
+	peerAddr := peer.Listener.Addr().String()
+
+	defer func(peers []*peerClient) { globalPeers = peers }(globalPeers)
+	globalPeers = []*peerClient{newPeerClient(peerAddr)}
+	defer func(nodeName string) { globalNodeName = nodeName }(globalNodeName)
+	globalNodeName = "node1"
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !proxyToNode(w, r) {
+			w.Write([]byte("local"))
+		}
+	})
+
+	testCases := []struct {
+		node           string
+		header         string
+		expectedStatus int
+		expectedBody   string
+	}{
+		// Test case - 1.
+		{"", "", http.StatusOK, "local"},
+		// Test case - 2.
+		{"node1", "", http.StatusOK, "local"},
+		// Test case - 3.
+		{peerAddr, "", http.StatusOK, "peer node1"},
+		// Test case - 4.
+		// Proxied by a peer already.
+		{peerAddr, "node2", http.StatusOK, "local"},
+		// Test case - 5.
+		// Unknown node.
+		{"node3:9000", "", http.StatusBadRequest, ""},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("GET", "http://localhost/minio/admin/locks?node="+testCase.node, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if testCase.header != "" {
+			req.Header.Set(peerProxyHeader, testCase.header)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != testCase.expectedStatus {
+			t.Errorf("Test %d: Expected status %d, got %d", i+1, testCase.expectedStatus, rec.Code)
+		}
+		body, _ := ioutil.ReadAll(rec.Body)
+		if testCase.expectedBody != "" && string(body) != testCase.expectedBody {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expectedBody, body)
+		}
+	}
+}
@@ -0,0 +1,458 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+)
+
+// errInvalidSealedSecret - sealed secret key cannot be decrypted with
+// the local credential.
+var errInvalidSealedSecret = errors.New("Unable to decrypt sealed secret key.")
+
+// peerClient is a lazily connected rpc client to a peer node.
+type peerClient struct {
+	addr      string
+	diskCount int
+	mutex     *sync.Mutex
+	rpcClient *rpc.Client
+}
+
+// List of all peers of this node, initialized in initPeers.
+var globalPeers []*peerClient
+
+// Total number of disks exported by this node and all its peers.
+var globalDiskCount int
+
+// newPeerClient - initialize a new peer client for the address.
+func newPeerClient(addr string) *peerClient {
+	return &peerClient{
+		addr:  addr,
+		mutex: &sync.Mutex{},
+	}
+}
+
+// Call - dials the peer if not already connected and invokes the rpc
+// method, connection is reset upon any network error.
+func (p *peerClient) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.rpcClient == nil {
+		rpcClient, err := rpc.DialHTTPPath("tcp", p.addr, peerRPCPath)
+		if err != nil {
+			return err
+		}
+		p.rpcClient = rpcClient
+	}
+	err := p.rpcClient.Call(serviceMethod, args, reply)
+	if _, ok := err.(rpc.ServerError); err != nil && !ok {
+		p.rpcClient.Close()
+		p.rpcClient = nil
+	}
+	return err
+}
+
+// isLocalPeer - returns true if the peer address points to this server.
+func isLocalPeer(addr, serverAddr string) bool {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	_, serverPort, err := net.SplitHostPort(serverAddr)
+	if err != nil || port != serverPort {
+		return false
+	}
+	if host == "" || host == "localhost" {
+		return true
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return false
+	}
+	for _, ip := range ips {
+		if ip.IsLoopback() {
+			return true
+		}
+		ifaceAddrs, err := net.InterfaceAddrs()
+		if err != nil {
+			return false
+		}
+		for _, ifaceAddr := range ifaceAddrs {
+			if ipNet, ok := ifaceAddr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// initPeers - initialize peers from all network export paths, local
+// server address is skipped.
+func initPeers(exportPaths []string, serverAddr string) {
+	var peers []*peerClient
+	seen := make(map[string]*peerClient)
+	for _, exportPath := range exportPaths {
+		if !strings.ContainsRune(exportPath, ':') || filepath.VolumeName(exportPath) != "" {
+			continue
+		}
+		netAddr, _ := splitNetPath(exportPath)
+		if peer, ok := seen[netAddr]; ok {
+			peer.diskCount++
+			continue
+		}
+		if isLocalPeer(netAddr, serverAddr) {
+			continue
+		}
+		peer := newPeerClient(netAddr)
+		peer.diskCount = 1
+		seen[netAddr] = peer
+		peers = append(peers, peer)
+	}
+	globalPeers = peers
+	globalDiskCount = len(exportPaths)
+}
+
+// Purposes of keys derived from the secret key for peer rpc.
+const (
+	peerTokenKeyPurpose  = "minio-peer-token"
+	peerSecretKeyPurpose = "minio-peer-secret"
+)
+
+// getPeerKey - derives a key for purpose from the secret key, peer
+// tokens and sealed secrets are never signed with the secret key
+// itself so browser login tokens are not valid for peer rpc.
+func getPeerKey(cred credential, purpose string) []byte {
+	mac := hmac.New(sha256.New, []byte(cred.SecretAccessKey))
+	mac.Write([]byte(purpose))
+	return mac.Sum(nil)
+}
+
+// newPeerToken - generates a token for peer rpc signed with a key
+// derived from the credential.
+func newPeerToken(cred credential) (string, error) {
+	token := jwtgo.New(jwtgo.SigningMethodHS512)
+	token.Claims["exp"] = time.Now().Add(time.Hour * tokenExpires).Unix()
+	token.Claims["iat"] = time.Now().Unix()
+	token.Claims["sub"] = cred.AccessKeyID
+	token.Claims["aud"] = peerTokenKeyPurpose
+	return token.SignedString(getPeerKey(cred, peerTokenKeyPurpose))
+}
+
+// sealPeerSecret - encrypts secretKey for peers sharing the credential,
+// secret keys are never sent in the clear.
+func sealPeerSecret(cred credential, secretKey string) ([]byte, error) {
+	block, err := aes.NewCipher(getPeerKey(cred, peerSecretKeyPurpose))
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err = rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, []byte(secretKey), nil), nil
+}
+
+// openPeerSecret - decrypts a secret key sealed with the credential.
+func openPeerSecret(cred credential, sealed []byte) (string, error) {
+	block, err := aes.NewCipher(getPeerKey(cred, peerSecretKeyPurpose))
+	if err != nil {
+		return "", err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errInvalidSealedSecret
+	}
+	secretKey, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+	if err != nil {
+		return "", errInvalidSealedSecret
+	}
+	return string(secretKey), nil
+}
+
+// getPeerClockSkew - returns clock skew of the peer relative to this
+// node, half of the round trip time is attributed to each direction.
+func getPeerClockSkew(peer *peerClient, token string) (time.Duration, error) {
+	start := time.Now().UTC()
+	reply := PingPeerReply{}
+	if err := peer.Call("Peer.PingHandler", &PeerAuthArgs{Token: token}, &reply); err != nil {
+		return 0, err
+	}
+	end := time.Now().UTC()
+	return reply.ServerTime.Sub(start.Add(end.Sub(start) / 2)), nil
+}
+
+// broadcastBucketPolicy - sends the bucket policy to all peers, empty
+// policy removes the bucket policy on all peers.
+func broadcastBucketPolicy(bucket string, policy []byte) {
+	token, err := newPeerToken(serverConfig.GetCredential())
+	if err != nil {
+		errorIf(err, "Unable to generate peer token.")
+		return
+	}
+	args := SetBucketPolicyPeerArgs{
+		Token:  token,
+		Bucket: bucket,
+		Policy: policy,
+	}
+	var wg = &sync.WaitGroup{}
+	for _, peer := range globalPeers {
+		wg.Add(1)
+		go func(peer *peerClient) {
+			defer wg.Done()
+			err := peer.Call("Peer.SetBucketPolicyHandler", &args, &GenericReply{})
+			errorIf(err, "Unable to send bucket policy of %s to peer %s.", bucket, peer.addr)
+		}(peer)
+	}
+	wg.Wait()
+}
+
+// broadcastBucketConfig - sends the named bucket config to all peers,
+// empty config removes the bucket config on all peers.
+func broadcastBucketConfig(bucket, configFile string, configBytes []byte) {
+	if len(globalPeers) == 0 {
+		return
+	}
+	token, err := newPeerToken(serverConfig.GetCredential())
+	if err != nil {
+		errorIf(err, "Unable to generate peer token.")
+		return
+	}
+	args := SetBucketConfigPeerArgs{
+		Token:      token,
+		Bucket:     bucket,
+		ConfigFile: configFile,
+		Config:     configBytes,
+	}
+	var wg = &sync.WaitGroup{}
+	for _, peer := range globalPeers {
+		wg.Add(1)
+		go func(peer *peerClient) {
+			defer wg.Done()
+			err := peer.Call("Peer.SetBucketConfigHandler", &args, &GenericReply{})
+			errorIf(err, "Unable to send bucket config %s of %s to peer %s.", configFile, bucket, peer.addr)
+		}(peer)
+	}
+	wg.Wait()
+}
+
+// broadcastServerConfig - sends current credential and region to all
+// peers, the token is signed and the secret key sealed with the
+// previous credential since peers are not yet aware of the new
+// credential.
+func broadcastServerConfig(prevCred credential) {
+	token, err := newPeerToken(prevCred)
+	if err != nil {
+		errorIf(err, "Unable to generate peer token.")
+		return
+	}
+	cred := serverConfig.GetCredential()
+	sealedSecretKey, err := sealPeerSecret(prevCred, cred.SecretAccessKey)
+	if err != nil {
+		errorIf(err, "Unable to seal secret key.")
+		return
+	}
+	args := SetServerConfigPeerArgs{
+		Token:           token,
+		AccessKey:       cred.AccessKeyID,
+		SealedSecretKey: sealedSecretKey,
+		Region:          serverConfig.GetRegion(),
+	}
+	var wg = &sync.WaitGroup{}
+	for _, peer := range globalPeers {
+		wg.Add(1)
+		go func(peer *peerClient) {
+			defer wg.Done()
+			err := peer.Call("Peer.SetServerConfigHandler", &args, &GenericReply{})
+			errorIf(err, "Unable to send server config to peer %s.", peer.addr)
+		}(peer)
+	}
+	wg.Wait()
+}
+
+// reconcileWithPeers - adopts any bucket policies, bucket configs and
+// server config which are newer on peers than the local copies. This
+// is done once at startup so that a node which missed updates while it
+// was offline catches up without a restart of the rest of the cluster.
+func reconcileWithPeers() {
+	token, err := newPeerToken(serverConfig.GetCredential())
+	if err != nil {
+		errorIf(err, "Unable to generate peer token.")
+		return
+	}
+	args := PeerAuthArgs{Token: token}
+	for _, peer := range globalPeers {
+		reply := ListBucketPoliciesPeerReply{}
+		if err = peer.Call("Peer.ListBucketPoliciesHandler", &args, &reply); err != nil {
+			errorIf(err, "Unable to list bucket policies from peer %s.", peer.addr)
+			continue
+		}
+		if err = reconcileBucketPolicies(reply.Policies); err != nil {
+			errorIf(err, "Unable to reconcile bucket policies from peer %s.", peer.addr)
+		}
+
+		bucketConfigsReply := ListBucketConfigsPeerReply{}
+		if err = peer.Call("Peer.ListBucketConfigsHandler", &args, &bucketConfigsReply); err != nil {
+			errorIf(err, "Unable to list bucket configs from peer %s.", peer.addr)
+			continue
+		}
+		if err = reconcileBucketConfigs(bucketConfigsReply.Configs); err != nil {
+			errorIf(err, "Unable to reconcile bucket configs from peer %s.", peer.addr)
+		}
+
+		configReply := ServerConfigPeerReply{}
+		if err = peer.Call("Peer.GetServerConfigHandler", &args, &configReply); err != nil {
+			errorIf(err, "Unable to get server config from peer %s.", peer.addr)
+			continue
+		}
+		if err = reconcileServerConfig(configReply); err != nil {
+			errorIf(err, "Unable to reconcile server config from peer %s.", peer.addr)
+		}
+		if err = reconcileDeploymentID(configReply.DeploymentID); err != nil {
+			errorIf(err, "Unable to reconcile deployment ID from peer %s.", peer.addr)
+		}
+	}
+}
+
+// reconcileBucketPolicies - saves peer bucket policies which are
+// missing or older locally, modified time of the peer is preserved.
+func reconcileBucketPolicies(peerPolicies map[string]BucketPolicyInfo) error {
+	localPolicies, err := listBucketPolicies()
+	if err != nil {
+		return err
+	}
+	for bucket, peerPolicy := range peerPolicies {
+		localPolicy, ok := localPolicies[bucket]
+		if ok && !peerPolicy.ModTime.After(localPolicy.ModTime) {
+			continue
+		}
+		if err = writeBucketPolicy(bucket, peerPolicy.Policy); err != nil {
+			return err
+		}
+		bucketConfigPath, err := getBucketConfigPath(bucket)
+		if err != nil {
+			return err
+		}
+		bucketPolicyFile := filepath.Join(bucketConfigPath, "access-policy.json")
+		if err = os.Chtimes(bucketPolicyFile, peerPolicy.ModTime, peerPolicy.ModTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileBucketConfigs - saves peer bucket configs which are missing
+// or older locally, modified time of the peer is preserved.
+func reconcileBucketConfigs(peerConfigs []BucketConfigInfo) error {
+	localConfigs, err := listBucketConfigs()
+	if err != nil {
+		return err
+	}
+	localModTimes := make(map[string]time.Time)
+	for _, localConfig := range localConfigs {
+		localModTimes[localConfig.Bucket+"/"+localConfig.ConfigFile] = localConfig.ModTime
+	}
+	for _, peerConfig := range peerConfigs {
+		if !isBucketConfigFile(peerConfig.ConfigFile) {
+			continue
+		}
+		localModTime, ok := localModTimes[peerConfig.Bucket+"/"+peerConfig.ConfigFile]
+		if ok && !peerConfig.ModTime.After(localModTime) {
+			continue
+		}
+		if err = writeLocalBucketConfig(peerConfig.Bucket, peerConfig.ConfigFile, peerConfig.Config); err != nil {
+			return err
+		}
+		bucketConfigPath, err := getBucketConfigPath(peerConfig.Bucket)
+		if err != nil {
+			return err
+		}
+		bucketConfigFile := filepath.Join(bucketConfigPath, peerConfig.ConfigFile)
+		if err = os.Chtimes(bucketConfigFile, peerConfig.ModTime, peerConfig.ModTime); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileServerConfig - saves peer credential and region if the peer
+// config is newer than the local config.
+func reconcileServerConfig(peerConfig ServerConfigPeerReply) error {
+	modTime, err := getServerConfigModTime()
+	if err != nil {
+		return err
+	}
+	if !peerConfig.ModTime.After(modTime) {
+		return nil
+	}
+	// Credentials are not reconciled, a node with a stale credential
+	// cannot authenticate with its peers to begin with.
+	if peerConfig.Region == serverConfig.GetRegion() {
+		return nil
+	}
+	if err = applyServerConfig(serverConfig.GetCredential(), peerConfig.Region); err != nil {
+		return err
+	}
+	configFile, err := getConfigFile()
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(configFile, peerConfig.ModTime, peerConfig.ModTime)
+}
+
+// reconcileDeploymentID - adopts the deployment ID of a peer if it
+// sorts before the local one, all nodes converge on the same ID. The
+// modified time of the config is preserved, the deployment ID is not
+// a config change to be propagated.
+func reconcileDeploymentID(peerDeploymentID string) error {
+	if peerDeploymentID == "" || peerDeploymentID >= serverConfig.GetDeploymentID() {
+		return nil
+	}
+	modTime, err := getServerConfigModTime()
+	if err != nil {
+		return err
+	}
+	serverConfig.SetDeploymentID(peerDeploymentID)
+	if err = serverConfig.Save(); err != nil {
+		return err
+	}
+	configFile, err := getConfigFile()
+	if err != nil {
+		return err
+	}
+	return os.Chtimes(configFile, modTime, modTime)
+}
@@ -0,0 +1,162 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "time"
+
+// PeerAuthArgs represents authentication arguments carried by all
+// peer RPC calls.
+type PeerAuthArgs struct {
+	// JWT token signed with the credential of the calling peer.
+	Token string
+}
+
+// SetBucketPolicyPeerArgs represents set bucket policy peer RPC arguments.
+type SetBucketPolicyPeerArgs struct {
+	// Authentication token.
+	Token string
+
+	// Name of the bucket.
+	Bucket string
+
+	// Bucket policy document, empty policy removes the policy.
+	Policy []byte
+}
+
+// SetBucketConfigPeerArgs represents set bucket config peer RPC arguments.
+type SetBucketConfigPeerArgs struct {
+	// Authentication token.
+	Token string
+
+	// Name of the bucket.
+	Bucket string
+
+	// Name of the bucket config file.
+	ConfigFile string
+
+	// Bucket config contents, empty config removes the config.
+	Config []byte
+}
+
+// SetServerConfigPeerArgs represents set server config peer RPC arguments.
+type SetServerConfigPeerArgs struct {
+	// Authentication token.
+	Token string
+
+	// New access key to be used by the peer.
+	AccessKey string
+
+	// New secret key to be used by the peer, sealed with the previous
+	// credential.
+	SealedSecretKey []byte
+
+	// New region to be used by the peer.
+	Region string
+}
+
+// BucketPolicyInfo represents a saved bucket policy along with the
+// time it was last modified.
+type BucketPolicyInfo struct {
+	// Bucket policy document.
+	Policy []byte
+
+	// Last modified time of the bucket policy.
+	ModTime time.Time
+}
+
+// ListBucketPoliciesPeerReply represents list bucket policies peer RPC reply.
+type ListBucketPoliciesPeerReply struct {
+	// Bucket policies indexed by bucket name.
+	Policies map[string]BucketPolicyInfo
+}
+
+// BucketConfigInfo represents a saved bucket config along with the
+// time it was last modified.
+type BucketConfigInfo struct {
+	// Name of the bucket.
+	Bucket string
+
+	// Name of the bucket config file.
+	ConfigFile string
+
+	// Bucket config contents.
+	Config []byte
+
+	// Last modified time of the bucket config.
+	ModTime time.Time
+}
+
+// ListBucketConfigsPeerReply represents list bucket configs peer RPC reply.
+type ListBucketConfigsPeerReply struct {
+	// All bucket configs saved on the peer.
+	Configs []BucketConfigInfo
+}
+
+// ServerConfigPeerReply represents get server config peer RPC reply.
+type ServerConfigPeerReply struct {
+	// Region currently used by the peer.
+	Region string
+
+	// Deployment ID currently used by the peer.
+	DeploymentID string
+
+	// Last modified time of the server config.
+	ModTime time.Time
+}
+
+// UpdatePeerArgs represents download update peer RPC arguments.
+type UpdatePeerArgs struct {
+	// Authentication token.
+	Token string
+
+	// URL to download the new binary from.
+	URL string
+
+	// Expected hex encoded sha256 checksum of the new binary.
+	SHA256 string
+}
+
+// PingPeerReply represents ping peer RPC reply.
+type PingPeerReply struct {
+	// Time at which the peer process was started.
+	BootTime time.Time
+	// Current time of the peer, used to measure clock skew.
+	ServerTime time.Time
+}
+
+// HealthPeerReply represents health peer RPC reply.
+type HealthPeerReply struct {
+	// Disks reachable from the peer have read quorum.
+	ReadQuorum bool
+	// Disks reachable from the peer have write quorum.
+	WriteQuorum bool
+}
+
+// ContinuationPeerArgs represents continuation peer RPC arguments.
+type ContinuationPeerArgs struct {
+	// Authentication token.
+	Token string
+
+	// Next page of the truncated listing.
+	Continuation listContinuation
+}
+
+// ContinuationPeerReply represents continuation peer RPC reply.
+type ContinuationPeerReply struct {
+	// Listing was truncated by the peer.
+	Found bool
+}
@@ -0,0 +1,237 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"net/rpc"
+	"os"
+	"time"
+
+	jwtgo "github.com/dgrijalva/jwt-go"
+	router "github.com/gorilla/mux"
+)
+
+const (
+	peerRPCPath = reservedBucket + "/peer"
+)
+
+// Peer server implements rpc primitives to receive config and bucket
+// policy changes made on other nodes. Changes received here are only
+// applied locally and never broadcasted again.
+type peerServer struct {
+	objAPI ObjectLayer
+}
+
+// isPeerTokenValid - validates the token sent by a peer against the
+// peer token key of the local credential, other tokens signed with
+// the credential such as browser login tokens are rejected.
+func isPeerTokenValid(token string) bool {
+	cred := serverConfig.GetCredential()
+	jwttoken, e := jwtgo.Parse(token, func(token *jwtgo.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwtgo.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+		return getPeerKey(cred, peerTokenKeyPurpose), nil
+	})
+	if e != nil {
+		return false
+	}
+	return jwttoken.Valid && jwttoken.Claims["aud"] == peerTokenKeyPurpose
+}
+
+// SetBucketPolicyHandler - set bucket policy handler is rpc wrapper to
+// save or remove a bucket policy received from a peer.
+func (p *peerServer) SetBucketPolicyHandler(arg *SetBucketPolicyPeerArgs, reply *GenericReply) error {
+	if !isPeerTokenValid(arg.Token) {
+		return errInvalidToken
+	}
+	if len(arg.Policy) == 0 {
+		err := removeBucketPolicy(arg.Bucket)
+		if _, ok := err.(BucketPolicyNotFound); ok {
+			return nil
+		}
+		return err
+	}
+	return writeBucketPolicy(arg.Bucket, arg.Policy)
+}
+
+// ListBucketPoliciesHandler - list bucket policies handler is rpc
+// wrapper to list all locally saved bucket policies.
+func (p *peerServer) ListBucketPoliciesHandler(arg *PeerAuthArgs, reply *ListBucketPoliciesPeerReply) error {
+	if !isPeerTokenValid(arg.Token) {
+		return errInvalidToken
+	}
+	policies, err := listBucketPolicies()
+	if err != nil {
+		return err
+	}
+	reply.Policies = policies
+	return nil
+}
+
+// SetBucketConfigHandler - set bucket config handler is rpc wrapper to
+// save or remove a bucket config received from a peer, only known
+// bucket config files are accepted.
+func (p *peerServer) SetBucketConfigHandler(arg *SetBucketConfigPeerArgs, reply *GenericReply) error {
+	if !isPeerTokenValid(arg.Token) {
+		return errInvalidToken
+	}
+	if !isBucketConfigFile(arg.ConfigFile) {
+		return errInvalidArgument
+	}
+	if len(arg.Config) == 0 {
+		err := removeLocalBucketConfig(arg.Bucket, arg.ConfigFile)
+		if err == errConfigNotFound {
+			return nil
+		}
+		return err
+	}
+	return writeLocalBucketConfig(arg.Bucket, arg.ConfigFile, arg.Config)
+}
+
+// ListBucketConfigsHandler - list bucket configs handler is rpc
+// wrapper to list all locally saved bucket configs.
+func (p *peerServer) ListBucketConfigsHandler(arg *PeerAuthArgs, reply *ListBucketConfigsPeerReply) error {
+	if !isPeerTokenValid(arg.Token) {
+		return errInvalidToken
+	}
+	configs, err := listBucketConfigs()
+	if err != nil {
+		return err
+	}
+	reply.Configs = configs
+	return nil
+}
+
+// SetServerConfigHandler - set server config handler is rpc wrapper to
+// save credential and region received from a peer, the secret key is
+// sealed with the current credential.
+func (p *peerServer) SetServerConfigHandler(arg *SetServerConfigPeerArgs, reply *GenericReply) error {
+	if !isPeerTokenValid(arg.Token) {
+		return errInvalidToken
+	}
+	secretKey, err := openPeerSecret(serverConfig.GetCredential(), arg.SealedSecretKey)
+	if err != nil {
+		return err
+	}
+	return applyServerConfig(credential{arg.AccessKey, secretKey}, arg.Region)
+}
+
+// GetServerConfigHandler - get server config handler is rpc wrapper to
+// return current region, the credential is never returned.
+func (p *peerServer) GetServerConfigHandler(arg *PeerAuthArgs, reply *ServerConfigPeerReply) error {
+	if !isPeerTokenValid(arg.Token) {
+		return errInvalidToken
+	}
+	modTime, err := getServerConfigModTime()
+	if err != nil {
+		return err
+	}
+	reply.Region = serverConfig.GetRegion()
+	reply.DeploymentID = serverConfig.GetDeploymentID()
+	reply.ModTime = modTime
+	return nil
+}
+
+// PingHandler - ping handler is rpc wrapper to report liveness along
+// with the time at which this process was started.
+func (p *peerServer) PingHandler(arg *PeerAuthArgs, reply *PingPeerReply) error {
+	if !isPeerTokenValid(arg.Token) {
+		return errInvalidToken
+	}
+	reply.BootTime = globalBootTime
+	reply.ServerTime = time.Now().UTC()
+	return nil
+}
+
+// HealthHandler - health handler is rpc wrapper to report whether the
+// disks reachable from this node have read and write quorum.
+func (p *peerServer) HealthHandler(arg *PeerAuthArgs, reply *HealthPeerReply) error {
+	if !isPeerTokenValid(arg.Token) {
+		return errInvalidToken
+	}
+	if p.objAPI == nil {
+		return nil
+	}
+	status := getQuorumStatus(p.objAPI)
+	reply.ReadQuorum = status.read
+	reply.WriteQuorum = status.write
+	return nil
+}
+
+// ContinuationHandler - continuation handler is rpc wrapper to report
+// whether this node truncated the listing.
+func (p *peerServer) ContinuationHandler(arg *ContinuationPeerArgs, reply *ContinuationPeerReply) error {
+	if !isPeerTokenValid(arg.Token) {
+		return errInvalidToken
+	}
+	reply.Found = globalContinuations.has(arg.Continuation)
+	return nil
+}
+
+// DownloadUpdateHandler - download update handler is rpc wrapper to
+// download and verify a new binary without applying it.
+func (p *peerServer) DownloadUpdateHandler(arg *UpdatePeerArgs, reply *GenericReply) error {
+	if !isPeerTokenValid(arg.Token) {
+		return errInvalidToken
+	}
+	return stageUpdate(arg.URL, arg.SHA256)
+}
+
+// RestartHandler - restart handler is rpc wrapper to replace the
+// binary with the staged update and restart the process, restart
+// happens after the reply is sent.
+func (p *peerServer) RestartHandler(arg *PeerAuthArgs, reply *GenericReply) error {
+	if !isPeerTokenValid(arg.Token) {
+		return errInvalidToken
+	}
+	if !isUpdateStaged() {
+		return errUpdateNotStaged
+	}
+	go restartWithUpdate()
+	return nil
+}
+
+// applyServerConfig - set credential and region and save the config.
+func applyServerConfig(cred credential, region string) error {
+	serverConfig.SetCredential(cred)
+	serverConfig.SetRegion(region)
+	return serverConfig.Save()
+}
+
+// getServerConfigModTime - returns last modified time of the config file.
+func getServerConfigModTime() (modTime time.Time, err error) {
+	configFile, err := getConfigFile()
+	if err != nil {
+		return modTime, err
+	}
+	st, err := os.Stat(configFile)
+	if err != nil {
+		return modTime, err
+	}
+	return st.ModTime(), nil
+}
+
+// registerPeerRPCRouter - register peer rpc router.
+func registerPeerRPCRouter(mux *router.Router, objAPI ObjectLayer) {
+	peerRPCServer := rpc.NewServer()
+	peerRPCServer.RegisterName("Peer", &peerServer{objAPI: objAPI})
+	peerRouter := mux.NewRoute().PathPrefix(reservedBucket).Subrouter()
+	// Add minio peer routes.
+	peerRouter.Path("/peer").Handler(peerRPCServer)
+}
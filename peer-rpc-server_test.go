@@ -0,0 +1,256 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+// Tests peer rpc handlers for bucket policy and server config changes.
+func TestPeerServerHandlers(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+
+	cred := serverConfig.GetCredential()
+	validToken, err := newPeerToken(cred)
+	if err != nil {
+		t.Fatalf("Unable to generate peer token. %s", err)
+	}
+	invalidToken, err := newPeerToken(credential{cred.AccessKeyID, "invalid-secret-key"})
+	if err != nil {
+		t.Fatalf("Unable to generate peer token. %s", err)
+	}
+
+	// Browser login tokens signed with the credential are rejected.
+	loginToken, err := initJWT().GenerateToken(cred.AccessKeyID)
+	if err != nil {
+		t.Fatalf("Unable to generate login token. %s", err)
+	}
+	if isPeerTokenValid(loginToken) {
+		t.Errorf("Expected login token to be rejected.")
+	}
+
+	peer := &peerServer{}
+	policy := []byte(`{"Version":"2012-10-17","Statement":[]}`)
+
+	testCases := []struct {
+		token     string
+		bucket    string
+		policy    []byte
+		expectErr bool
+		expectSet bool
+	}{
+		// Test case - 1.
+		// Invalid token is rejected.
+		{invalidToken, "peer-bucket", policy, true, false},
+		// Test case - 2.
+		// Valid token saves the policy.
+		{validToken, "peer-bucket", policy, false, true},
+		// Test case - 3.
+		// Empty policy removes the policy.
+		{validToken, "peer-bucket", nil, false, false},
+		// Test case - 4.
+		// Removing a policy which doesn't exist is not an error.
+		{validToken, "peer-bucket", nil, false, false},
+	}
+	for i, testCase := range testCases {
+		args := &SetBucketPolicyPeerArgs{Token: testCase.token, Bucket: testCase.bucket, Policy: testCase.policy}
+		err = peer.SetBucketPolicyHandler(args, &GenericReply{})
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("Test %d: Expected error %t, got %v", i+1, testCase.expectErr, err)
+		}
+		reply := &ListBucketPoliciesPeerReply{}
+		if err = peer.ListBucketPoliciesHandler(&PeerAuthArgs{Token: validToken}, reply); err != nil {
+			t.Fatalf("Test %d: Unable to list bucket policies. %s", i+1, err)
+		}
+		policyInfo, ok := reply.Policies[testCase.bucket]
+		if ok != testCase.expectSet {
+			t.Errorf("Test %d: Expected policy set %t, got %t", i+1, testCase.expectSet, ok)
+		}
+		if ok && !bytes.Equal(policyInfo.Policy, testCase.policy) {
+			t.Errorf("Test %d: Expected policy %s, got %s", i+1, testCase.policy, policyInfo.Policy)
+		}
+	}
+
+	// Secret keys sealed with another credential are rejected.
+	newCred := credential{"NEWACCESSKEYEXAMPLE1", "newsecretkeyexample1234567890abcdefghijk"}
+	invalidSealed, err := sealPeerSecret(credential{cred.AccessKeyID, "invalid-secret-key"}, newCred.SecretAccessKey)
+	if err != nil {
+		t.Fatalf("Unable to seal secret key. %s", err)
+	}
+	args := &SetServerConfigPeerArgs{Token: validToken, AccessKey: newCred.AccessKeyID, SealedSecretKey: invalidSealed, Region: "us-west-1"}
+	if err = peer.SetServerConfigHandler(args, &GenericReply{}); err != errInvalidSealedSecret {
+		t.Fatalf("Expected %s, got %v", errInvalidSealedSecret, err)
+	}
+
+	// Credential change is accepted with the previous credential.
+	sealed, err := sealPeerSecret(cred, newCred.SecretAccessKey)
+	if err != nil {
+		t.Fatalf("Unable to seal secret key. %s", err)
+	}
+	args = &SetServerConfigPeerArgs{Token: validToken, AccessKey: newCred.AccessKeyID, SealedSecretKey: sealed, Region: "us-west-1"}
+	if err = peer.SetServerConfigHandler(args, &GenericReply{}); err != nil {
+		t.Fatalf("Unable to set server config. %s", err)
+	}
+	if serverConfig.GetCredential() != newCred || serverConfig.GetRegion() != "us-west-1" {
+		t.Errorf("Expected server config to be updated, got %v %s", serverConfig.GetCredential(), serverConfig.GetRegion())
+	}
+	// Old token is no longer valid now.
+	if err = peer.GetServerConfigHandler(&PeerAuthArgs{Token: validToken}, &ServerConfigPeerReply{}); err != errInvalidToken {
+		t.Errorf("Expected %s, got %v", errInvalidToken, err)
+	}
+
+	// Credential is never returned to peers.
+	newToken, err := newPeerToken(newCred)
+	if err != nil {
+		t.Fatalf("Unable to generate peer token. %s", err)
+	}
+	configReply := &ServerConfigPeerReply{}
+	if err = peer.GetServerConfigHandler(&PeerAuthArgs{Token: newToken}, configReply); err != nil {
+		t.Fatalf("Unable to get server config. %s", err)
+	}
+	if configReply.Region != "us-west-1" {
+		t.Errorf("Expected region us-west-1, got %s", configReply.Region)
+	}
+
+	// Newer peer config is adopted, older one is ignored.
+	if err = reconcileServerConfig(ServerConfigPeerReply{Region: "us-east-1", ModTime: time.Now().Add(-time.Hour)}); err != nil {
+		t.Fatalf("Unable to reconcile server config. %s", err)
+	}
+	if serverConfig.GetRegion() != "us-west-1" {
+		t.Errorf("Expected older peer config to be ignored.")
+	}
+	if err = reconcileServerConfig(ServerConfigPeerReply{Region: "us-east-1", ModTime: time.Now().Add(time.Hour)}); err != nil {
+		t.Fatalf("Unable to reconcile server config. %s", err)
+	}
+	if serverConfig.GetRegion() != "us-east-1" || serverConfig.GetCredential() != newCred {
+		t.Errorf("Expected region of newer peer config to be adopted.")
+	}
+
+	// Smaller peer deployment ID is adopted, larger one is ignored.
+	serverConfig.SetDeploymentID("b-deployment")
+	if err = reconcileDeploymentID("c-deployment"); err != nil {
+		t.Fatalf("Unable to reconcile deployment ID. %s", err)
+	}
+	if serverConfig.GetDeploymentID() != "b-deployment" {
+		t.Errorf("Expected larger peer deployment ID to be ignored, got %s", serverConfig.GetDeploymentID())
+	}
+	if err = reconcileDeploymentID("a-deployment"); err != nil {
+		t.Fatalf("Unable to reconcile deployment ID. %s", err)
+	}
+	if serverConfig.GetDeploymentID() != "a-deployment" {
+		t.Errorf("Expected smaller peer deployment ID to be adopted, got %s", serverConfig.GetDeploymentID())
+	}
+}
+
+// Tests peer rpc handlers for bucket config changes.
+func TestPeerServerBucketConfigHandlers(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+
+	cred := serverConfig.GetCredential()
+	validToken, err := newPeerToken(cred)
+	if err != nil {
+		t.Fatalf("Unable to generate peer token. %s", err)
+	}
+	invalidToken, err := newPeerToken(credential{cred.AccessKeyID, "invalid-secret-key"})
+	if err != nil {
+		t.Fatalf("Unable to generate peer token. %s", err)
+	}
+
+	peer := &peerServer{}
+	config := []byte(`{"status":"Enabled"}`)
+
+	testCases := []struct {
+		token      string
+		configFile string
+		config     []byte
+		expectErr  bool
+		expectSet  bool
+	}{
+		// Test case - 1.
+		// Invalid token is rejected.
+		{invalidToken, bucketVersioningConfigFile, config, true, false},
+		// Test case - 2.
+		// Unknown config file is rejected.
+		{validToken, "../../" + globalMinioConfigFile, config, true, false},
+		// Test case - 3.
+		// Valid token saves the config.
+		{validToken, bucketVersioningConfigFile, config, false, true},
+		// Test case - 4.
+		// Empty config removes the config.
+		{validToken, bucketVersioningConfigFile, nil, false, false},
+		// Test case - 5.
+		// Removing a config which doesn't exist is not an error.
+		{validToken, bucketVersioningConfigFile, nil, false, false},
+	}
+	for i, testCase := range testCases {
+		args := &SetBucketConfigPeerArgs{Token: testCase.token, Bucket: "peer-bucket", ConfigFile: testCase.configFile, Config: testCase.config}
+		err = peer.SetBucketConfigHandler(args, &GenericReply{})
+		if testCase.expectErr != (err != nil) {
+			t.Errorf("Test %d: Expected error %t, got %v", i+1, testCase.expectErr, err)
+		}
+		reply := &ListBucketConfigsPeerReply{}
+		if err = peer.ListBucketConfigsHandler(&PeerAuthArgs{Token: validToken}, reply); err != nil {
+			t.Fatalf("Test %d: Unable to list bucket configs. %s", i+1, err)
+		}
+		if (len(reply.Configs) != 0) != testCase.expectSet {
+			t.Errorf("Test %d: Expected config set %t, got %v", i+1, testCase.expectSet, reply.Configs)
+		}
+		if testCase.expectSet && !bytes.Equal(reply.Configs[0].Config, testCase.config) {
+			t.Errorf("Test %d: Expected config %s, got %s", i+1, testCase.config, reply.Configs[0].Config)
+		}
+	}
+
+	// Newer peer config is adopted, older one is ignored.
+	if err = writeLocalBucketConfig("peer-bucket", bucketVersioningConfigFile, config); err != nil {
+		t.Fatalf("Unable to save bucket config. %s", err)
+	}
+	suspended := []byte(`{"status":"Suspended"}`)
+	peerConfigs := []BucketConfigInfo{
+		{Bucket: "peer-bucket", ConfigFile: bucketVersioningConfigFile, Config: suspended, ModTime: time.Now().Add(-time.Hour)},
+	}
+	if err = reconcileBucketConfigs(peerConfigs); err != nil {
+		t.Fatalf("Unable to reconcile bucket configs. %s", err)
+	}
+	if configBytes, _ := readBucketConfig("peer-bucket", bucketVersioningConfigFile); !bytes.Equal(configBytes, config) {
+		t.Errorf("Expected older peer config to be ignored, got %s", configBytes)
+	}
+	peerConfigs[0].ModTime = time.Now().Add(time.Hour)
+	if err = reconcileBucketConfigs(peerConfigs); err != nil {
+		t.Fatalf("Unable to reconcile bucket configs. %s", err)
+	}
+	if configBytes, _ := readBucketConfig("peer-bucket", bucketVersioningConfigFile); !bytes.Equal(configBytes, suspended) {
+		t.Errorf("Expected newer peer config to be adopted, got %s", configBytes)
+	}
+}
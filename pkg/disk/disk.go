@@ -20,8 +20,12 @@ package disk
 // Total - total size of the volume / disk
 // Free - free size of the volume / disk
 // Type - file system type string
+// Files - total inodes available, 0 if unknown
+// Ffree - free inodes available, 0 if unknown
 type Info struct {
 	Total  int64
 	Free   int64
+	Files  int64
+	Ffree  int64
 	FSType string
 }
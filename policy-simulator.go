@@ -0,0 +1,127 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Maximum size of a policy simulation request.
+const maxPolicySimulationSize = 64 * 1024 // 64KiB.
+
+// policySimulation - hypothetical request evaluated against the
+// current bucket policy.
+type policySimulation struct {
+	// Access key of the request, anonymous if empty or "*".
+	Principal string `json:"principal"`
+	Action    string `json:"action"`
+	Bucket    string `json:"bucket"`
+	Object    string `json:"object,omitempty"`
+	// Condition values of the request, "prefix" and "max-keys".
+	Conditions map[string]string `json:"conditions,omitempty"`
+}
+
+// policySimulationResult - decision of a policy simulation with the
+// statement deciding it, if any.
+type policySimulationResult struct {
+	Allowed        bool             `json:"allowed"`
+	Reason         string           `json:"reason"`
+	Statement      *policyStatement `json:"statement,omitempty"`
+	StatementIndex int              `json:"statementIndex"`
+}
+
+// isAnonymous - returns true if the simulated request is anonymous.
+func (sim policySimulation) isAnonymous() bool {
+	return sim.Principal == "" || sim.Principal == "*"
+}
+
+// parsePolicySimulation - parses and validates a policy simulation.
+func parsePolicySimulation(simBuf []byte) (sim policySimulation, err error) {
+	if err = json.Unmarshal(simBuf, &sim); err != nil {
+		return policySimulation{}, err
+	}
+	if err = isValidActions([]string{sim.Action}); err != nil {
+		return policySimulation{}, err
+	}
+	if !IsValidBucketName(sim.Bucket) {
+		return policySimulation{}, errors.New("Invalid bucket name.")
+	}
+	if sim.Object != "" && !IsValidObjectName(sim.Object) {
+		return policySimulation{}, errors.New("Invalid object name.")
+	}
+	return sim, nil
+}
+
+// simulateBucketPolicy - evaluates the simulated request the way
+// requests are authorized, signed requests are authorized by their
+// credential and anonymous requests by the first statement of the
+// bucket policy matching the request.
+func simulateBucketPolicy(sim policySimulation) (policySimulationResult, error) {
+	result := policySimulationResult{StatementIndex: -1}
+	if !sim.isAnonymous() {
+		if sim.Principal == serverConfig.GetCredential().AccessKeyID {
+			result.Allowed = true
+			result.Reason = "Requests signed with the server credential are allowed, bucket policies do not apply."
+		} else if t, ok := globalTenants.GetByAccessKey(sim.Principal); !ok {
+			result.Reason = "Access key is unknown, signed requests are denied."
+		} else if !t.ownsBucket(sim.Bucket) {
+			result.Reason = "Bucket is outside the namespace of tenant " + t.Name + "."
+		} else {
+			result.Allowed = true
+			result.Reason = "Bucket is in the namespace of tenant " + t.Name + ", bucket policies do not apply."
+		}
+		return result, nil
+	}
+
+	policyBuf, err := readBucketPolicy(sim.Bucket)
+	if err != nil {
+		if _, ok := err.(BucketPolicyNotFound); ok {
+			result.Reason = "Bucket has no policy, anonymous requests are denied."
+			return result, nil
+		}
+		return policySimulationResult{}, err
+	}
+	policy, err := parseBucketPolicy(policyBuf)
+	if err != nil {
+		return policySimulationResult{}, err
+	}
+
+	resource := AWSResourcePrefix + sim.Bucket
+	if sim.Object != "" {
+		resource += "/" + sim.Object
+	}
+	conditions := sim.Conditions
+	if conditions == nil {
+		conditions = make(map[string]string)
+	}
+	for index, statement := range policy.Statements {
+		if !bucketPolicyMatchStatement(sim.Action, resource, conditions, statement) {
+			continue
+		}
+		// Effect of the first matching statement decides, as with
+		// bucketPolicyEvalStatements.
+		result.Allowed = statement.Effect == "Allow"
+		result.Reason = fmt.Sprintf("Statement %d matches with effect %s.", index+1, statement.Effect)
+		result.Statement = &policy.Statements[index]
+		result.StatementIndex = index
+		return result, nil
+	}
+	result.Reason = "No statement of the bucket policy matches, anonymous requests are denied."
+	return result, nil
+}
@@ -0,0 +1,136 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+// Tests parsing of policy simulations.
+func TestParsePolicySimulation(t *testing.T) {
+	testCases := []struct {
+		simBuf     string
+		shouldPass bool
+	}{
+		// Test case - 1.
+		{`{"action":"s3:GetObject","bucket":"bucket","object":"object"}`, true},
+		// Test case - 2.
+		{`{"principal":"*","action":"s3:ListBucket","bucket":"bucket","conditions":{"prefix":"logs/"}}`, true},
+		// Test case - 3.
+		// Unsupported action.
+		{`{"action":"s3:PutBucketPolicy","bucket":"bucket"}`, false},
+		// Test case - 4.
+		{`{"action":"s3:GetObject","bucket":"b"}`, false},
+		// Test case - 5.
+		{`not json`, false},
+	}
+	for i, testCase := range testCases {
+		_, err := parsePolicySimulation([]byte(testCase.simBuf))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, but failed with: %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, but passed", i+1)
+		}
+	}
+}
+
+// Tests simulated requests are decided like requests.
+func TestSimulateBucketPolicy(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+	bucket := makeIntegrationBucket(t, client)
+	noPolicyBucket := makeIntegrationBucket(t, client)
+
+	policy := `{"Version":"2012-10-17","Statement":[` +
+		`{"Sid":"DenySecret","Action":["s3:GetObject"],"Effect":"Deny","Principal":{"AWS":["*"]},"Resource":["arn:aws:s3:::` + bucket + `/secret/*"]},` +
+		`{"Sid":"AllowRead","Action":["s3:GetObject"],"Effect":"Allow","Principal":{"AWS":["*"]},"Resource":["arn:aws:s3:::` + bucket + `/public/*"]}]}`
+	resp, respBody, err := client.do("PUT", bucket, "", url.Values{"policy": {""}}, nil, []byte(policy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutBucketPolicy", resp, respBody, http.StatusNoContent)
+	for _, object := range []string{"public/object", "secret/object"} {
+		resp, respBody, err = client.do("PUT", bucket, object, nil, nil, []byte("data"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+	}
+
+	testCases := []struct {
+		sim              policySimulation
+		expectedAllowed  bool
+		expectedStmtSid  string
+		expectedStmtIdx  int
+		expectedAnonResp int
+	}{
+		// Test case - 1.
+		{policySimulation{Action: "s3:GetObject", Bucket: bucket, Object: "public/object"}, true, "AllowRead", 1, http.StatusOK},
+		// Test case - 2.
+		{policySimulation{Principal: "*", Action: "s3:GetObject", Bucket: bucket, Object: "secret/object"}, false, "DenySecret", 0, http.StatusForbidden},
+		// Test case - 3.
+		// No statement matches.
+		{policySimulation{Action: "s3:DeleteObject", Bucket: bucket, Object: "public/object"}, false, "", -1, http.StatusForbidden},
+		// Test case - 4.
+		// No bucket policy.
+		{policySimulation{Action: "s3:GetObject", Bucket: noPolicyBucket, Object: "object"}, false, "", -1, http.StatusForbidden},
+		// Test case - 5.
+		// Server credential.
+		{policySimulation{Principal: testServer.AccessKey, Action: "s3:DeleteObject", Bucket: bucket, Object: "object"}, true, "", -1, 0},
+		// Test case - 6.
+		// Unknown access key.
+		{policySimulation{Principal: "UNKNOWNACCESSKEY", Action: "s3:GetObject", Bucket: bucket, Object: "object"}, false, "", -1, 0},
+	}
+	for i, testCase := range testCases {
+		result, err := simulateBucketPolicy(testCase.sim)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to simulate policy. %s", i+1, err)
+		}
+		if result.Allowed != testCase.expectedAllowed {
+			t.Errorf("Test %d: Expected allowed %t, got %t: %s", i+1, testCase.expectedAllowed, result.Allowed, result.Reason)
+		}
+		if result.StatementIndex != testCase.expectedStmtIdx {
+			t.Errorf("Test %d: Expected statement %d, got %d", i+1, testCase.expectedStmtIdx, result.StatementIndex)
+		}
+		if result.Statement != nil && result.Statement.Sid != testCase.expectedStmtSid {
+			t.Errorf("Test %d: Expected statement %s, got %s", i+1, testCase.expectedStmtSid, result.Statement.Sid)
+		}
+		if testCase.expectedAnonResp == 0 {
+			continue
+		}
+		// Anonymous requests are decided the same way.
+		resp, err := http.Get(makeTestTargetURL(testServer.Server.URL, testCase.sim.Bucket, testCase.sim.Object, nil))
+		if testCase.sim.Action == "s3:DeleteObject" {
+			req, rErr := http.NewRequest("DELETE", makeTestTargetURL(testServer.Server.URL, testCase.sim.Bucket, testCase.sim.Object, nil), nil)
+			if rErr != nil {
+				t.Fatal(rErr)
+			}
+			resp, err = http.DefaultClient.Do(req)
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != testCase.expectedAnonResp {
+			t.Errorf("Test %d: Expected anonymous request status %d, got %d", i+1, testCase.expectedAnonResp, resp.StatusCode)
+		}
+	}
+}
@@ -16,7 +16,13 @@
 
 package main
 
-import "strings"
+import (
+	"os"
+	"strings"
+)
+
+// Size of the buffer files are overwritten with.
+const overwriteBufSize = 1 * 1024 * 1024 // 1MiB.
 
 // List of reserved words for files, includes old and new ones.
 var posixReservedPrefix = []string{
@@ -35,3 +41,28 @@ func hasPosixReservedPrefix(name string) (isReserved bool) {
 	}
 	return isReserved
 }
+
+// overwriteFile - overwrites size bytes of the file at filePath with
+// zeros and syncs them to the disk.
+func overwriteFile(filePath string, size int64) (err error) {
+	file, err := os.OpenFile(preparePath(filePath), os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cErr := file.Close(); err == nil {
+			err = cErr
+		}
+	}()
+	buf := make([]byte, overwriteBufSize)
+	for size > 0 {
+		if size < int64(len(buf)) {
+			buf = buf[:size]
+		}
+		if _, err = file.Write(buf); err != nil {
+			return err
+		}
+		size -= int64(len(buf))
+	}
+	return file.Sync()
+}
@@ -21,6 +21,7 @@ package main
 import (
 	"os"
 	"strings"
+	"syscall"
 )
 
 // isValidVolname verifies a volname name in accordance with object
@@ -49,3 +50,11 @@ func mkdirAll(path string, perm os.FileMode) error {
 func removeAll(path string) error {
 	return os.RemoveAll(path)
 }
+
+// getLinkCount - returns number of hard links of a file.
+func getLinkCount(st os.FileInfo) uint64 {
+	if stat, ok := st.Sys().(*syscall.Stat_t); ok {
+		return uint64(stat.Nlink)
+	}
+	return 0
+}
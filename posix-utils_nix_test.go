@@ -19,6 +19,7 @@
 package main
 
 import (
+	"bytes"
 	"io/ioutil"
 	"os"
 	"path"
@@ -124,3 +125,76 @@ func TestIsValidUmaskFile(t *testing.T) {
 		t.Fatalf("Umask check failed expected %d, got %d", testCase.expectedUmask, currentUmask)
 	}
 }
+
+// Tests files are overwritten before they are deleted, unless they are
+// hard linked elsewhere.
+func TestShredFile(t *testing.T) {
+	tmpPath, err := ioutil.TempDir(os.TempDir(), "minio-")
+	if err != nil {
+		t.Fatalf("Unable to create a temporary directory, %s", err)
+	}
+	defer removeAll(tmpPath)
+
+	disk, err := newPosix(tmpPath)
+	if err != nil {
+		t.Fatalf("Unable to initialize posix, %s", err)
+	}
+	if err = disk.MakeVol("exists"); err != nil {
+		t.Fatalf("Unable to create a volume \"exists\", %s", err)
+	}
+	// Larger than the overwrite buffer.
+	data := bytes.Repeat([]byte("a"), 2*overwriteBufSize+10)
+	for _, file := range []string{"dir/shredded", "linked"} {
+		if err = disk.AppendFile("exists", file, data); err != nil {
+			t.Fatalf("Unable to create a file \"%s\", %s", file, err)
+		}
+	}
+	if err = disk.LinkFile("exists", "linked", "exists", "link"); err != nil {
+		t.Fatalf("Unable to link a file, %s", err)
+	}
+
+	// Data of the deleted file remains readable from an open file.
+	file, err := os.Open(path.Join(tmpPath, "exists", "dir", "shredded"))
+	if err != nil {
+		t.Fatalf("Unable to open a file, %s", err)
+	}
+	defer file.Close()
+
+	testCases := []struct {
+		volume string
+		path   string
+		err    error
+	}{
+		// Test case - 1.
+		{"i-dont-exist", "dir/shredded", errVolumeNotFound},
+		// Test case - 2.
+		{"exists", "i-dont-exist", errFileNotFound},
+		// Test case - 3.
+		{"exists", "dir/shredded", nil},
+		// Test case - 4.
+		// Hard linked file is only deleted.
+		{"exists", "linked", nil},
+	}
+	for i, testCase := range testCases {
+		if err = disk.ShredFile(testCase.volume, testCase.path); err != testCase.err {
+			t.Errorf("Test %d: Expected err \"%v\", got \"%v\"", i+1, testCase.err, err)
+		}
+	}
+
+	buf, err := ioutil.ReadAll(file)
+	if err != nil {
+		t.Fatalf("Unable to read a file, %s", err)
+	}
+	if !bytes.Equal(buf, make([]byte, len(data))) {
+		t.Error("Expected shredded file to be overwritten with zeros")
+	}
+	if _, err = disk.StatFile("exists", "dir/shredded"); err != errFileNotFound {
+		t.Errorf("Expected shredded file to be deleted, got \"%v\"", err)
+	}
+	if _, err = os.Stat(path.Join(tmpPath, "exists", "dir")); !os.IsNotExist(err) {
+		t.Errorf("Expected empty parent directory to be deleted, got \"%v\"", err)
+	}
+	if buf, err = disk.ReadAll("exists", "link"); err != nil || !bytes.Equal(buf, data) {
+		t.Errorf("Expected data of hard linked file to be intact, got \"%v\"", err)
+	}
+}
@@ -161,3 +161,9 @@ func removeAll(path string) error {
 	}
 	return err
 }
+
+// getLinkCount - returns number of hard links of a file, not known on
+// windows hence 0.
+func getLinkCount(st os.FileInfo) uint64 {
+	return 0
+}
@@ -639,10 +639,14 @@ func deleteFile(basePath, deletePath string) error {
 	}
 	// Attempt to remove path.
 	if err := os.Remove(preparePath(deletePath)); err != nil {
+		if os.IsNotExist(err) {
+			return errFileNotFound
+		}
 		return err
 	}
-	// Recursively go down the next path and delete again.
-	if err := deleteFile(basePath, slashpath.Dir(deletePath)); err != nil {
+	// Recursively go down the next path and delete again, parents
+	// removed by concurrent deletes are already gone.
+	if err := deleteFile(basePath, slashpath.Dir(deletePath)); err != nil && err != errFileNotFound {
 		return err
 	}
 	return nil
@@ -689,6 +693,56 @@ func (s *posix) DeleteFile(volume, path string) (err error) {
 	return deleteFile(volumeDir, filePath)
 }
 
+// ShredFile - overwrite a file at path with zeros before deleting it,
+// files with other hard links are only deleted as their data is still
+// in use.
+func (s *posix) ShredFile(volume, path string) (err error) {
+	defer func() {
+		if err == syscall.EIO {
+			atomic.AddInt32(&s.ioErrCount, 1)
+		}
+	}()
+
+	if s.ioErrCount > maxAllowedIOError {
+		return errFaultyDisk
+	}
+
+	volumeDir, err := s.getVolDir(volume)
+	if err != nil {
+		return err
+	}
+	// Stat a volume entry.
+	_, err = os.Stat(preparePath(volumeDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errVolumeNotFound
+		}
+		return err
+	}
+
+	filePath := pathJoin(volumeDir, path)
+	if err = checkPathLength(filePath); err != nil {
+		return err
+	}
+	st, err := os.Stat(preparePath(filePath))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return errFileNotFound
+		} else if os.IsPermission(err) {
+			return errFileAccessDenied
+		}
+		return err
+	}
+	if !st.IsDir() && getLinkCount(st) == 1 {
+		if err = overwriteFile(filePath, st.Size()); err != nil {
+			return err
+		}
+	}
+
+	// Delete file and delete parent directory as well if its empty.
+	return deleteFile(volumeDir, filePath)
+}
+
 // RenameFile - rename source path to destination path atomically.
 func (s *posix) RenameFile(srcVolume, srcPath, dstVolume, dstPath string) (err error) {
 	defer func() {
@@ -777,3 +831,66 @@ func (s *posix) RenameFile(srcVolume, srcPath, dstVolume, dstPath string) (err e
 	}
 	return nil
 }
+
+// LinkFile - hard link source file to destination path, the file data
+// is shared until either path is replaced.
+func (s *posix) LinkFile(srcVolume, srcPath, dstVolume, dstPath string) (err error) {
+	defer func() {
+		if err == syscall.EIO {
+			atomic.AddInt32(&s.ioErrCount, 1)
+		}
+	}()
+
+	if s.ioErrCount > maxAllowedIOError {
+		return errFaultyDisk
+	}
+
+	srcVolumeDir, err := s.getVolDir(srcVolume)
+	if err != nil {
+		return err
+	}
+	dstVolumeDir, err := s.getVolDir(dstVolume)
+	if err != nil {
+		return err
+	}
+	// Stat a volume entry.
+	for _, volumeDir := range []string{srcVolumeDir, dstVolumeDir} {
+		if _, err = os.Stat(preparePath(volumeDir)); err != nil {
+			if os.IsNotExist(err) {
+				return errVolumeNotFound
+			}
+			return err
+		}
+	}
+
+	// Only regular files can be linked.
+	if strings.HasSuffix(srcPath, slashSeparator) || strings.HasSuffix(dstPath, slashSeparator) {
+		return errFileAccessDenied
+	}
+	srcFilePath := slashpath.Join(srcVolumeDir, srcPath)
+	if err = checkPathLength(srcFilePath); err != nil {
+		return err
+	}
+	dstFilePath := slashpath.Join(dstVolumeDir, dstPath)
+	if err = checkPathLength(dstFilePath); err != nil {
+		return err
+	}
+	// Creates all the parent directories, with mode 0777 mkdir honors system umask.
+	if err = mkdirAll(preparePath(slashpath.Dir(dstFilePath)), 0777); err != nil {
+		// File path cannot be verified since one of the parents is a file.
+		if strings.Contains(err.Error(), "not a directory") {
+			return errFileAccessDenied
+		}
+		return err
+	}
+	if err = os.Link(preparePath(srcFilePath), preparePath(dstFilePath)); err != nil {
+		if os.IsNotExist(err) {
+			return errFileNotFound
+		}
+		if os.IsExist(err) {
+			return errFileAccessDenied
+		}
+		return err
+	}
+	return nil
+}
@@ -19,6 +19,8 @@ package main
 import (
 	"io/ioutil"
 	"os"
+	"strconv"
+	"sync"
 	"testing"
 )
 
@@ -90,3 +92,54 @@ func TestReadAll(t *testing.T) {
 		}
 	}
 }
+
+// Tests concurrent deletes of the last files of a directory all
+// succeed, only one of them removes the directory.
+func TestDeleteFileConcurrent(t *testing.T) {
+	path, err := ioutil.TempDir(os.TempDir(), "minio-")
+	if err != nil {
+		t.Fatalf("Unable to create a temporary directory, %s", err)
+	}
+	defer removeAll(path)
+
+	// Initialize posix storage layer.
+	posix, err := newPosix(path)
+	if err != nil {
+		t.Fatalf("Unable to initialize posix, %s", err)
+	}
+	if err = posix.MakeVol("exists"); err != nil {
+		t.Fatalf("Unable to create a volume \"exists\", %s", err)
+	}
+
+	for round := 0; round < 100; round++ {
+		var files []string
+		for i := 0; i < 8; i++ {
+			file := "dir/" + strconv.Itoa(i)
+			if err = posix.AppendFile("exists", file, []byte("Hello, World")); err != nil {
+				t.Fatalf("Unable to create a file %q, %s", file, err)
+			}
+			files = append(files, file)
+		}
+		errs := make([]error, len(files))
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i, file := range files {
+			wg.Add(1)
+			go func(i int, file string) {
+				defer wg.Done()
+				<-start
+				errs[i] = posix.DeleteFile("exists", file)
+			}(i, file)
+		}
+		close(start)
+		wg.Wait()
+		for i, err := range errs {
+			if err != nil {
+				t.Fatalf("Round %d: Unable to delete %q, %s", round+1, files[i], err)
+			}
+		}
+	}
+	if _, err = posix.StatFile("exists", "dir"); err != errFileNotFound {
+		t.Errorf("Expected empty directory to be removed, got %v", err)
+	}
+}
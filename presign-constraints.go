@@ -0,0 +1,186 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Constraints embedded in presigned URLs, signed as part of the query
+// string so they cannot be altered by the holder of the URL.
+const (
+	// Objects which can be accessed must have this key prefix, the URL
+	// is then valid for any object with the prefix.
+	presignKeyPrefix = "X-Minio-Key-Prefix"
+	// Content-Type uploads must be sent with.
+	presignContentType = "X-Minio-Content-Type"
+	// Maximum size of uploads in bytes.
+	presignMaxSize = "X-Minio-Max-Size"
+)
+
+// errPresignMaxSize - body of a presigned upload is larger than the
+// maximum size embedded in the URL.
+var errPresignMaxSize = errors.New("Upload exceeds the maximum size of the presigned URL")
+
+// presignConstraints - constraints embedded in a presigned URL, empty
+// values are not enforced.
+type presignConstraints struct {
+	KeyPrefix   string
+	ContentType string
+	MaxSize     int64
+}
+
+// encode - adds constraints to the presigned URL query.
+func (constraints presignConstraints) encode(query url.Values) {
+	if constraints.KeyPrefix != "" {
+		query.Set(presignKeyPrefix, constraints.KeyPrefix)
+	}
+	if constraints.ContentType != "" {
+		query.Set(presignContentType, constraints.ContentType)
+	}
+	if constraints.MaxSize > 0 {
+		query.Set(presignMaxSize, strconv.FormatInt(constraints.MaxSize, 10))
+	}
+}
+
+// checkPresignConstraints - verifies request satisfies the constraints
+// embedded in the presigned URL, returns the URL path the presigned
+// signature was calculated for.
+func checkPresignConstraints(r *http.Request) (string, APIErrorCode) {
+	query := r.URL.Query()
+	urlPath := r.URL.Path
+	if _, ok := query[presignKeyPrefix]; ok {
+		bucket, object := urlPath2BucketObjectName(r.URL)
+		keyPrefix := query.Get(presignKeyPrefix)
+		if !strings.HasPrefix(object, keyPrefix) {
+			return "", ErrPresignConstraintMismatch
+		}
+		urlPath = "/" + bucket + "/" + keyPrefix
+	}
+	if _, ok := query[presignContentType]; ok {
+		if r.Header.Get("Content-Type") != query.Get(presignContentType) {
+			return "", ErrPresignConstraintMismatch
+		}
+	}
+	if _, ok := query[presignMaxSize]; ok {
+		maxSize, err := strconv.ParseInt(query.Get(presignMaxSize), 10, 64)
+		if err != nil || maxSize < 0 {
+			return "", ErrMalformedPresignConstraints
+		}
+		if r.ContentLength < 0 {
+			return "", ErrMissingContentLength
+		}
+		if r.ContentLength > maxSize {
+			return "", ErrEntityTooLarge
+		}
+	}
+	return urlPath, ErrNone
+}
+
+// getPresignMaxSize - returns the maximum upload size embedded in the
+// presigned URL, -1 if the size is not constrained. Malformed sizes
+// are rejected by checkPresignConstraints, they allow no bytes here.
+func getPresignMaxSize(r *http.Request) int64 {
+	query := r.URL.Query()
+	if _, ok := query[presignMaxSize]; !ok || !isRequestPresignedSignatureV4(r) {
+		return -1
+	}
+	maxSize, err := strconv.ParseInt(query.Get(presignMaxSize), 10, 64)
+	if err != nil || maxSize < 0 {
+		return 0
+	}
+	return maxSize
+}
+
+// checkPresignSize - verifies an object written with a presigned URL
+// is within its maximum size, for copies and resumable uploads whose
+// size is not the size of the request body.
+func checkPresignSize(r *http.Request, size int64) APIErrorCode {
+	if maxSize := getPresignMaxSize(r); maxSize >= 0 && size > maxSize {
+		return ErrEntityTooLarge
+	}
+	return ErrNone
+}
+
+// checkPresignContentType - verifies an object copied with a presigned
+// URL has its content type, copies keep the content type of the source
+// unless metadata is replaced.
+func checkPresignContentType(r *http.Request, contentType string) APIErrorCode {
+	query := r.URL.Query()
+	if _, ok := query[presignContentType]; !ok || !isRequestPresignedSignatureV4(r) {
+		return ErrNone
+	}
+	if contentType != query.Get(presignContentType) {
+		return ErrPresignConstraintMismatch
+	}
+	return ErrNone
+}
+
+// presignBodyReader - fails reads once more bytes than the maximum
+// size of the presigned URL are read, Content-Length of the request is
+// not relied upon.
+type presignBodyReader struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (p *presignBodyReader) Read(b []byte) (int, error) {
+	n, err := p.ReadCloser.Read(b)
+	if p.remaining -= int64(n); p.remaining < 0 {
+		return n, errPresignMaxSize
+	}
+	return n, err
+}
+
+// limitPresignedBody - limits the body of a presigned upload to the
+// maximum size of the URL.
+func limitPresignedBody(r *http.Request) {
+	if maxSize := getPresignMaxSize(r); maxSize >= 0 {
+		r.Body = &presignBodyReader{ReadCloser: r.Body, remaining: maxSize}
+	}
+}
+
+// presignURL - returns URL presigned with signature v4 for the object,
+// object is taken as a key prefix if constraints have one set.
+func presignURL(method, scheme, host, bucket, object string, constraints presignConstraints, cred credential, region string, expires time.Duration) string {
+	t := time.Now().UTC()
+	urlPath := "/" + bucket + "/" + object
+	if constraints.KeyPrefix != "" {
+		urlPath = "/" + bucket + "/" + constraints.KeyPrefix
+	}
+
+	query := make(url.Values)
+	query.Set("X-Amz-Algorithm", signV4Algorithm)
+	query.Set("X-Amz-Date", t.Format(iso8601Format))
+	query.Set("X-Amz-Expires", strconv.Itoa(int(expires/time.Second)))
+	query.Set("X-Amz-SignedHeaders", getSignedHeaders(http.Header{}))
+	query.Set("X-Amz-Credential", cred.AccessKeyID+"/"+getScope(t, region))
+	constraints.encode(query)
+
+	canonicalRequest := getCanonicalRequest(http.Header{}, "UNSIGNED-PAYLOAD", query.Encode(), urlPath, method, host)
+	stringToSign := getStringToSign(canonicalRequest, t, region)
+	query.Set("X-Amz-Signature", getSignature(getSigningKey(cred.SecretAccessKey, t, region), stringToSign))
+
+	u := url.URL{Scheme: scheme, Host: host, Path: urlPath, RawQuery: query.Encode()}
+	return u.String()
+}
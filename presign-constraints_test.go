@@ -0,0 +1,165 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Tests uploads with presigned URLs enforce embedded constraints.
+func TestPresignConstraints(t *testing.T) {
+	testServer := StartTestServer(t, "FS")
+	defer testServer.Stop()
+
+	bucketName := getRandomBucketName()
+	req, err := newTestRequest("PUT", getMakeBucketURL(testServer.Server.URL, bucketName), 0, nil, testServer.AccessKey, testServer.SecretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Unable to create bucket, got status %d", resp.StatusCode)
+	}
+
+	u, err := url.Parse(testServer.Server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cred := credential{AccessKeyID: testServer.AccessKey, SecretAccessKey: testServer.SecretKey}
+	scoped := presignConstraints{KeyPrefix: "uploads/", ContentType: "text/plain", MaxSize: 10}
+	scopedURL := presignURL("PUT", u.Scheme, u.Host, bucketName, "", scoped, cred, "us-east-1", time.Hour)
+	objectURL := presignURL("PUT", u.Scheme, u.Host, bucketName, "object", presignConstraints{}, cred, "us-east-1", time.Hour)
+	smallURL := presignURL("PUT", u.Scheme, u.Host, bucketName, "small", presignConstraints{}, cred, "us-east-1", time.Hour)
+
+	// withObject - replaces the key prefix in the URL path by object.
+	withObject := func(presignedURL, object string) string {
+		return strings.Replace(presignedURL, "/uploads/?", "/"+object+"?", 1)
+	}
+	testCases := []struct {
+		urlStr          string
+		contentType     string
+		data            string
+		copySource      string
+		expectedErrCode APIErrorCode
+	}{
+		// Test case - 1.
+		// Object under the key prefix.
+		{withObject(scopedURL, "uploads/a.txt"), "text/plain", "hello", "", ErrNone},
+		// Test case - 2.
+		// Object outside the key prefix.
+		{withObject(scopedURL, "other/a.txt"), "text/plain", "hello", "", ErrPresignConstraintMismatch},
+		// Test case - 3.
+		// Mismatching content type.
+		{withObject(scopedURL, "uploads/a.txt"), "image/png", "hello", "", ErrPresignConstraintMismatch},
+		// Test case - 4.
+		// Upload larger than maximum size.
+		{withObject(scopedURL, "uploads/a.txt"), "text/plain", "hello, world", "", ErrEntityTooLarge},
+		// Test case - 5.
+		// Constraints are signed.
+		{strings.Replace(withObject(scopedURL, "uploads/a.txt"), "X-Minio-Max-Size=10", "X-Minio-Max-Size=100", 1), "text/plain", "hello, world", "", ErrSignatureDoesNotMatch},
+		// Test case - 6.
+		// Unconstrained URL is valid only for its object.
+		{objectURL, "image/png", "hello, world", "", ErrNone},
+		// Test case - 7.
+		{strings.Replace(objectURL, "/object?", "/other?", 1), "image/png", "hello, world", "", ErrSignatureDoesNotMatch},
+		// Test case - 8.
+		{smallURL, "image/png", "hello", "", ErrNone},
+		// Test case - 9.
+		// Copy satisfying the constraints.
+		{withObject(scopedURL, "uploads/b.txt"), "text/plain", "", "/" + bucketName + "/uploads/a.txt", ErrNone},
+		// Test case - 10.
+		// Copy larger than maximum size.
+		{withObject(scopedURL, "uploads/c.txt"), "text/plain", "", "/" + bucketName + "/object", ErrEntityTooLarge},
+		// Test case - 11.
+		// Copy keeping a mismatching content type of the source.
+		{withObject(scopedURL, "uploads/d.txt"), "text/plain", "", "/" + bucketName + "/small", ErrPresignConstraintMismatch},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("PUT", testCase.urlStr, bytes.NewReader([]byte(testCase.data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", testCase.contentType)
+		if testCase.copySource != "" {
+			req.Header.Set("X-Amz-Copy-Source", testCase.copySource)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		respBody, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if testCase.expectedErrCode == ErrNone {
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("Test %d: Expected status 200, got %d: %s", i+1, resp.StatusCode, respBody)
+			}
+			continue
+		}
+		apiErr := getAPIError(testCase.expectedErrCode)
+		errResp := APIErrorResponse{}
+		if err = xml.Unmarshal(respBody, &errResp); err != nil {
+			t.Fatalf("Test %d: Unable to parse error response: %s", i+1, err)
+		}
+		if resp.StatusCode != apiErr.HTTPStatusCode || errResp.Code != apiErr.Code {
+			t.Errorf("Test %d: Expected %d %s, got %d %s", i+1, apiErr.HTTPStatusCode, apiErr.Code, resp.StatusCode, errResp.Code)
+		}
+	}
+}
+
+// Tests bodies of presigned uploads are limited to the maximum size
+// regardless of Content-Length.
+func TestLimitPresignedBody(t *testing.T) {
+	cred := credential{AccessKeyID: "minio", SecretAccessKey: "minio123"}
+	presignedURL := presignURL("PUT", "http", "localhost:9000", "bucket", "object", presignConstraints{MaxSize: 10}, cred, "us-east-1", time.Hour)
+	testCases := []struct {
+		data        string
+		expectedErr error
+	}{
+		// Test case - 1.
+		{"hello", nil},
+		// Test case - 2.
+		{"hello, world", errPresignMaxSize},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest("PUT", presignedURL, ioutil.NopCloser(strings.NewReader(testCase.data)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.ContentLength = -1
+		limitPresignedBody(req)
+		if _, err = ioutil.ReadAll(req.Body); err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expectedErr, err)
+		}
+	}
+	if toAPIErrorCode(errPresignMaxSize) != ErrEntityTooLarge {
+		t.Errorf("Expected %s to map to EntityTooLarge", errPresignMaxSize)
+	}
+}
@@ -0,0 +1,273 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strconv"
+
+	mux "github.com/gorilla/mux"
+)
+
+const (
+	// Total size of the object of a resumable upload, sent with
+	// every request of the upload.
+	resumableSizeHeader = "X-Minio-Resumable-Size"
+
+	// Bytes received for a resumable upload, sent by clients with
+	// the offset the request body starts at.
+	resumableOffsetHeader = "X-Minio-Resumable-Offset"
+
+	// Payload hash of requests which cannot be checked before the
+	// whole body is read.
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+
+	// Resumable uploads are saved in parts of the minimum part size,
+	// objects of the maximum size fit in the maximum number of parts.
+	resumablePartSize = minPartSize
+)
+
+// getResumableOffset - returns bytes received for a resumable upload
+// and its parts, parts are counted from the first one up to the first
+// missing part.
+func getResumableOffset(objAPI ObjectLayer, bucket, object, uploadID string) (int64, []completePart, error) {
+	var offset int64
+	var parts []completePart
+	partNumberMarker := 0
+	for {
+		listPartsInfo, err := objAPI.ListObjectParts(bucket, object, uploadID, partNumberMarker, maxPartsList)
+		if err != nil {
+			return 0, nil, err
+		}
+		for _, part := range listPartsInfo.Parts {
+			if part.PartNumber != len(parts)+1 {
+				return offset, parts, nil
+			}
+			offset += part.Size
+			parts = append(parts, completePart{PartNumber: part.PartNumber, ETag: part.ETag})
+		}
+		if !listPartsInfo.IsTruncated {
+			return offset, parts, nil
+		}
+		partNumberMarker = listPartsInfo.NextPartNumberMarker
+	}
+}
+
+// getResumableSize - returns total size of the object of a resumable
+// upload.
+func getResumableSize(r *http.Request) (int64, APIErrorCode) {
+	size, err := strconv.ParseInt(r.Header.Get(resumableSizeHeader), 10, 64)
+	if err != nil || size < 0 {
+		return 0, ErrInvalidResumableSize
+	}
+	/// maximum Upload size for objects in a single operation
+	if isMaxObjectSize(size) {
+		return 0, ErrEntityTooLarge
+	}
+	return size, ErrNone
+}
+
+// isResumableReqAuthenticated - verifies the signature of a resumable
+// upload request before its body is read, against the payload hash
+// claimed by the request. Returns the claimed payload hash, checked
+// once the whole body is read.
+func isResumableReqAuthenticated(r *http.Request) (string, APIErrorCode) {
+	validateRegion := true // Validate region.
+	if isRequestSignatureV4(r) {
+		hashedPayload := r.Header.Get("X-Amz-Content-Sha256")
+		return hashedPayload, doesSignatureMatch(hashedPayload, r, validateRegion)
+	} else if isRequestPresignedSignatureV4(r) {
+		if _, s3Error := checkPresignConstraints(r); s3Error != ErrNone {
+			return "", s3Error
+		}
+		hashedPayload := r.URL.Query().Get("X-Amz-Content-Sha256")
+		if hashedPayload == "" {
+			hashedPayload = unsignedPayload
+		}
+		return hashedPayload, doesPresignedSignatureMatch(hashedPayload, r, validateRegion)
+	}
+	return "", ErrAccessDenied
+}
+
+// ResumableUploadOffsetHandler - HEAD Object ?resumable=<uploadId>
+// ----------
+// This operation returns the bytes received for a resumable upload in
+// X-Minio-Resumable-Offset, the offset the upload resumes at.
+func (api objectAPIHandlers) ResumableUploadOffsetHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+	uploadID := vars["resumable"]
+
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypeAnonymous:
+		// Resuming is writing the object.
+		if s3Error := enforceBucketPolicy("s3:PutObject", bucket, r.URL); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	case authTypePresigned, authTypeSigned:
+		if s3Error := isReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	}
+
+	offset, _, err := getResumableOffset(api.ObjectAPI, bucket, object, uploadID)
+	if err != nil {
+		errorIf(err, "Unable to list object parts.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	setCommonHeaders(w)
+	w.Header().Set(resumableOffsetHeader, strconv.FormatInt(offset, 10))
+	writeSuccessResponse(w, nil)
+}
+
+// ResumablePutObjectHandler - PUT Object ?resumable=<uploadId>
+// ----------
+// This operation saves the request body at X-Minio-Resumable-Offset of
+// the object of a resumable upload, in parts of the multipart upload.
+// Parts received whole are kept when the request is interrupted, the
+// upload is completed once all X-Minio-Resumable-Size bytes are
+// received.
+func (api objectAPIHandlers) ResumablePutObjectHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	bucket := vars["bucket"]
+	object := vars["object"]
+	uploadID := vars["resumable"]
+
+	size, s3Error := getResumableSize(r)
+	if s3Error != ErrNone {
+		writeErrorResponse(w, r, s3Error, r.URL.Path)
+		return
+	}
+	var requestOffset int64
+	if offsetStr := r.Header.Get(resumableOffsetHeader); offsetStr != "" {
+		var err error
+		if requestOffset, err = strconv.ParseInt(offsetStr, 10, 64); err != nil {
+			writeErrorResponse(w, r, ErrInvalidResumableOffset, r.URL.Path)
+			return
+		}
+	}
+
+	// Parts are saved as the body is read, signatures are verified
+	// against the claimed payload hash before.
+	hashedPayload := unsignedPayload
+	switch getRequestAuthType(r) {
+	default:
+		// For all unknown auth types return error.
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	case authTypeAnonymous:
+		// Resuming is writing the object.
+		if s3Error = enforceBucketPolicy("s3:PutObject", bucket, r.URL); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+	case authTypePresigned, authTypeSigned:
+		if hashedPayload, s3Error = isResumableReqAuthenticated(r); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+		// Presigned URL constraints apply to the whole object.
+		if s3Error = checkPresignSize(r, size); s3Error != ErrNone {
+			writeErrorResponse(w, r, s3Error, r.URL.Path)
+			return
+		}
+		limitPresignedBody(r)
+	}
+
+	offset, parts, err := getResumableOffset(api.ObjectAPI, bucket, object, uploadID)
+	if err != nil {
+		errorIf(err, "Unable to list object parts.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	// Body has to start where the received bytes end.
+	w.Header().Set(resumableOffsetHeader, strconv.FormatInt(offset, 10))
+	if requestOffset != offset || offset > size {
+		writeErrorResponse(w, r, ErrInvalidResumableOffset, r.URL.Path)
+		return
+	}
+
+	shaWriter := sha256.New()
+	body := io.TeeReader(r.Body, shaWriter)
+	var bodyErr error
+	// Objects of size 0 are saved in one empty part.
+	for bodyErr == nil && (offset < size || len(parts) == 0) {
+		partLen := size - offset
+		if partLen > resumablePartSize {
+			partLen = resumablePartSize
+		}
+		partBuf := make([]byte, partLen)
+		// Short parts of interrupted requests are dropped.
+		if _, bodyErr = io.ReadFull(body, partBuf); bodyErr != nil {
+			break
+		}
+		partID := len(parts) + 1
+		partMD5, pErr := api.ObjectAPI.PutObjectPart(bucket, object, uploadID, partID, int64(len(partBuf)), bytes.NewReader(partBuf), "")
+		if pErr != nil {
+			errorIf(pErr, "Unable to create object part.")
+			writeErrorResponse(w, r, toAPIErrorCode(pErr), r.URL.Path)
+			return
+		}
+		parts = append(parts, completePart{PartNumber: partID, ETag: partMD5})
+		offset += int64(len(partBuf))
+	}
+	w.Header().Set(resumableOffsetHeader, strconv.FormatInt(offset, 10))
+
+	// Whole body is checked against the claimed payload hash, the
+	// upload is aborted on mismatch.
+	if bodyErr == nil {
+		if _, err = io.Copy(shaWriter, r.Body); err != nil {
+			bodyErr = err
+		}
+	}
+	if bodyErr == nil && hashedPayload != unsignedPayload && hashedPayload != hex.EncodeToString(shaWriter.Sum(nil)) {
+		errorIf(api.ObjectAPI.AbortMultipartUpload(bucket, object, uploadID), "Unable to abort multipart upload.")
+		writeErrorResponse(w, r, ErrContentSHA256Mismatch, r.URL.Path)
+		return
+	}
+	if offset < size {
+		// Rest of the object is sent with later requests.
+		setCommonHeaders(w)
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	md5Sum, err := api.ObjectAPI.CompleteMultipartUpload(bucket, object, uploadID, parts)
+	if err != nil {
+		errorIf(err, "Unable to complete multipart upload.")
+		writeErrorResponse(w, r, toAPIErrorCode(err), r.URL.Path)
+		return
+	}
+	replicateObjectWrite(w, api.ObjectAPI, bucket, object)
+
+	// Set standard S3 headers.
+	w.Header().Set("ETag", "\""+md5Sum+"\"")
+	setCommonHeaders(w)
+	writeSuccessResponse(w, nil)
+}
@@ -0,0 +1,147 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"testing"
+)
+
+// Tests resuming interrupted uploads from the bytes received.
+func TestResumablePutObject(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+	bucket := makeIntegrationBucket(t, client)
+
+	newUpload := func(object string) string {
+		resp, respBody, err := client.do("POST", bucket, object, url.Values{"uploads": {""}}, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "NewMultipartUpload", resp, respBody, http.StatusOK)
+		initResponse := &InitiateMultipartUploadResponse{}
+		if err = xml.Unmarshal(respBody, initResponse); err != nil {
+			t.Fatal(err)
+		}
+		return initResponse.UploadID
+	}
+	resumableHeaders := func(size, offset int) map[string]string {
+		return map[string]string{
+			resumableSizeHeader:   strconv.Itoa(size),
+			resumableOffsetHeader: strconv.Itoa(offset),
+		}
+	}
+	expectOffset := func(testName string, resp *http.Response, offset int) {
+		if resp.Header.Get(resumableOffsetHeader) != strconv.Itoa(offset) {
+			t.Errorf("%s: Expected offset %d, got %q", testName, offset, resp.Header.Get(resumableOffsetHeader))
+		}
+	}
+
+	data := bytes.Repeat([]byte("a"), minPartSize+1024)
+	uploadID := newUpload("object")
+	query := url.Values{"resumable": {uploadID}}
+
+	resp, respBody, err := client.do("HEAD", bucket, "object", query, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "ResumableUploadOffset", resp, respBody, http.StatusOK)
+	expectOffset("ResumableUploadOffset", resp, 0)
+
+	// Body cut short within the second part, only the first part
+	// is kept.
+	resp, respBody, err = client.do("PUT", bucket, "object", query, resumableHeaders(len(data), 0), data[:minPartSize+100])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "ResumablePutObject", resp, respBody, http.StatusAccepted)
+	expectOffset("ResumablePutObject", resp, minPartSize)
+
+	// Resuming at another offset is rejected.
+	resp, respBody, err = client.do("PUT", bucket, "object", query, resumableHeaders(len(data), 0), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "ResumablePutObject", resp, respBody, ErrInvalidResumableOffset)
+	expectOffset("ResumablePutObject", resp, minPartSize)
+
+	// Missing size is rejected.
+	resp, respBody, err = client.do("PUT", bucket, "object", query, nil, data[minPartSize:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "ResumablePutObject", resp, respBody, ErrInvalidResumableSize)
+
+	// Rest of the object completes the upload.
+	resp, respBody, err = client.do("PUT", bucket, "object", query, resumableHeaders(len(data), minPartSize), data[minPartSize:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "ResumablePutObject", resp, respBody, http.StatusOK)
+	if resp.Header.Get("ETag") == "" {
+		t.Errorf("Expected ETag of the object")
+	}
+	resp, respBody, err = client.do("GET", bucket, "object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "GetObject", resp, respBody, http.StatusOK)
+	if !bytes.Equal(respBody, data) {
+		t.Errorf("Expected object of %d bytes, got %d bytes", len(data), len(respBody))
+	}
+	// Completed upload cannot be resumed.
+	resp, respBody, err = client.do("HEAD", bucket, "object", query, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "ResumableUploadOffset", resp, respBody, http.StatusNotFound)
+
+	// Empty objects.
+	uploadID = newUpload("empty")
+	resp, respBody, err = client.do("PUT", bucket, "empty", url.Values{"resumable": {uploadID}}, resumableHeaders(0, 0), []byte{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "ResumablePutObject", resp, respBody, http.StatusOK)
+
+	// Body not matching its signed payload hash aborts the upload.
+	uploadID = newUpload("tampered")
+	req, err := newTestRequest("PUT", makeTestTargetURL(client.endpoint, bucket, "tampered", url.Values{"resumable": {uploadID}}),
+		3, bytes.NewReader([]byte("abc")), client.accessKey, client.secretKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Body = ioutil.NopCloser(bytes.NewReader([]byte("xyz")))
+	req.Header.Set(resumableSizeHeader, "3")
+	if resp, err = client.client.Do(req); err != nil {
+		t.Fatal(err)
+	}
+	respBody, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	expectErrorCode(t, "ResumablePutObject", resp, respBody, ErrContentSHA256Mismatch)
+	resp, respBody, err = client.do("HEAD", bucket, "tampered", url.Values{"resumable": {uploadID}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "ResumableUploadOffset", resp, respBody, http.StatusNotFound)
+}
@@ -26,6 +26,57 @@ import (
 // newObjectLayer - initialize any object layer depending on the
 // number of export paths.
 func newObjectLayer(exportPaths []string) (ObjectLayer, error) {
+	objAPI, err := newSetsObjectLayer(exportPaths)
+	if err != nil || len(globalTierColdPaths) == 0 {
+		return objAPI, err
+	}
+	// Initialize cold storage tier.
+	coldObjAPI, err := newExportObjectLayer(globalTierColdPaths)
+	if err != nil {
+		return nil, err
+	}
+	return newTierObjects(objAPI, coldObjAPI, globalTierDemoteAfter), nil
+}
+
+// getStorageObjectLayer - returns the object layer storing objects,
+// below layers enforcing retention and quotas and indexing metadata.
+func getStorageObjectLayer(objAPI ObjectLayer) ObjectLayer {
+	for {
+		switch l := objAPI.(type) {
+		case indexedObjects:
+			objAPI = l.ObjectLayer
+		case retainedObjects:
+			objAPI = l.ObjectLayer
+		case tenantObjects:
+			objAPI = l.ObjectLayer
+		default:
+			return objAPI
+		}
+	}
+}
+
+// newSetsObjectLayer - initialize object layer of all erasure sets,
+// export paths are the first set.
+func newSetsObjectLayer(exportPaths []string) (ObjectLayer, error) {
+	if len(globalErasureSets) > 0 {
+		return newSetsObjects(append([][]string{exportPaths}, globalErasureSets...))
+	}
+	objAPI, err := newExportObjectLayer(exportPaths)
+	if err != nil {
+		return nil, err
+	}
+	// Disks of an expanded deployment never serve a single set.
+	if xl, ok := objAPI.(xlObjects); ok {
+		if err = formatSets([][]StorageAPI{xl.storageDisks}); err != nil {
+			return nil, err
+		}
+	}
+	return objAPI, nil
+}
+
+// newExportObjectLayer - initialize FS or XL object layer depending
+// on the number of export paths.
+func newExportObjectLayer(exportPaths []string) (ObjectLayer, error) {
 	if len(exportPaths) == 1 {
 		exportPath := exportPaths[0]
 		// Initialize FS object layer.
@@ -44,10 +95,48 @@ func configureServerHandler(srvCmdConfig serverCmdConfig) http.Handler {
 	objAPI, err := newObjectLayer(srvCmdConfig.exportPaths)
 	fatalIf(err, "Unable to intialize object layer.")
 
-	// Initialize storage rpc server.
-	storageRPC, err := newRPCServer(srvCmdConfig.exportPaths[0]) // FIXME: should only have one path.
+	// Refuse to replace or delete retained objects of locked buckets.
+	objAPI = newRetainedObjects(objAPI)
+
+	// Enforce storage quotas of tenants on writes to their buckets.
+	objAPI = newTenantObjects(objAPI)
+
+	// Index object metadata of all writes, if enabled.
+	if globalMetadataIndexEnabled {
+		objAPI = newIndexedObjects(objAPI, globalMetadataIndex)
+		initMetadataIndex(objAPI)
+	}
+
+	// Load tenants, their credentials are accepted along with the
+	// server credential.
+	fatalIf(initTenants(), "Unable to load tenants.")
+
+	// Load limits on healing in favour of client traffic.
+	fatalIf(initHealThrottle(), "Unable to load heal throttle.")
+
+	// Initialize storage rpc servers of all disks of this node,
+	// including disks of erasure sets.
+	storagePaths := append([]string{}, srvCmdConfig.exportPaths...)
+	for _, setPaths := range globalErasureSets {
+		storagePaths = append(storagePaths, setPaths...)
+	}
+	storageRPCs, err := newRPCServers(storagePaths, srvCmdConfig.serverAddr)
 	fatalIf(err, "Unable to initialize storage RPC server.")
 
+	// Initialize peers from network export paths.
+	initPeers(srvCmdConfig.exportPaths, srvCmdConfig.serverAddr)
+
+	// Lock name space on all nodes sharing the disks.
+	initDistNSLock()
+
+	// Answer for peers when disks lack quorum, if enabled.
+	if globalFailoverEnabled && len(globalPeers) > 0 {
+		globalFailover = newFailoverMonitor(objAPI)
+	}
+
+	// Name this node after its address.
+	globalNodeName = getNodeName(srvCmdConfig.serverAddr)
+
 	// Initialize API.
 	apiHandlers := objectAPIHandlers{
 		ObjectAPI: objAPI,
@@ -58,11 +147,28 @@ func configureServerHandler(srvCmdConfig serverCmdConfig) http.Handler {
 		ObjectAPI: objAPI,
 	}
 
+	// Initialize Admin.
+	adminHandlers := adminAPIHandlers{
+		ObjectAPI: objAPI,
+	}
+
+	// Initialize WebDAV.
+	webDAVHandlers := webDAVHandlers{
+		ObjectAPI: objAPI,
+	}
+
 	// Initialize router.
 	mux := router.NewRouter()
 
 	// Register all routers.
-	registerStorageRPCRouter(mux, storageRPC)
+	registerStorageRPCRouter(mux, storageRPCs)
+	registerPeerRPCRouter(mux, objAPI)
+	registerLockRPCRouter(mux)
+	// Admin and WebDAV routers are registered before the web router,
+	// which serves the browser for all other paths of the reserved
+	// bucket.
+	registerAdminRouter(mux, adminHandlers)
+	registerWebDAVRouter(mux, webDAVHandlers)
 	registerWebRouter(mux, webHandlers)
 	registerAPIRouter(mux, apiHandlers)
 	// Add new routers here.
@@ -72,6 +178,18 @@ func configureServerHandler(srvCmdConfig serverCmdConfig) http.Handler {
 	var handlerFns = []HandlerFunc{
 		// Limits the number of concurrent http requests.
 		setRateLimitHandler,
+		// Proxies requests to a healthy peer while disks of this
+		// node lack quorum.
+		setFailoverHandler,
+		// Proxies writes of replica buckets to their primary,
+		// after all other request validations.
+		setBucketReplicaHandler,
+		// Rejects requests of API groups disabled in the server
+		// config, before they are proxied to peers or primaries.
+		setDisabledAPIsHandler,
+		// Restricts tenants to buckets of their namespace and
+		// enforces their storage quota.
+		setTenantHandler,
 		// Redirect some pre-defined browser request paths to a static
 		// location prefix.
 		setBrowserRedirectHandler,
@@ -90,9 +208,59 @@ func configureServerHandler(srvCmdConfig serverCmdConfig) http.Handler {
 		// routes them accordingly. Client receives a HTTP error for
 		// invalid/unsupported signatures.
 		setAuthHandler,
+		// Validates expected bucket owner if requested by the client.
+		setExpectedBucketOwnerHandler,
+		// Injects faults configured via admin API, only when
+		// server is started with fault injection enabled.
+		setFaultInjectionHandler,
+		// Pins request goroutines to CPUs, only when server is
+		// started with network CPUs set.
+		setNetworkAffinityHandler,
+		// Meters requests and bytes transferred per authenticated
+		// access key, anonymous and rejected requests together.
+		setMeteringHandler,
+		// Counts reads and writes of objects per bucket.
+		setBucketRatesHandler,
+		// Lists requests currently served via admin API.
+		setActiveRequestsHandler,
 		// Add new handlers here.
 	}
 
+	// Catch up on config and bucket policy changes made on peers.
+	go reconcileWithPeers()
+
+	// Delete objects uploaded with a TTL once expired.
+	go objectExpiryJob(objAPI)
+
+	// Replicate writes of buckets replicated asynchronously.
+	go replicationJob(objAPI)
+
+	// Notify the bucket rate hook, if configured.
+	go bucketRatesJob()
+
+	// Measure storage used by tenants for their quotas.
+	go tenantUsageJob(objAPI)
+
+	// Export metering records periodically, if configured.
+	meteringConfigPath, err := getMeteringConfigPath()
+	fatalIf(err, "Unable to get metering config path.")
+	go meteringJob(objAPI, meteringConfigPath)
+
+	// Monitor clock skew with peers.
+	if len(globalPeers) > 0 {
+		go clockSkewJob()
+	}
+
+	// Log name space locks held too long.
+	if globalStuckLockTimeout > 0 {
+		go stuckLockJob(globalStuckLockTimeout)
+	}
+
+	// Monitor health of peers for failover.
+	if globalFailover != nil {
+		go globalFailover.run(failoverInterval)
+	}
+
 	// Register rest of the handlers.
 	return registerHandlers(mux, handlerFns...)
 }
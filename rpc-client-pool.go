@@ -0,0 +1,179 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/rpc"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// Connections to a network disk, calls are spread across them.
+	rpcConnsPerDisk = 4
+	// Time allowed to connect to a node.
+	rpcDialTimeout = 5 * time.Second
+	// Time allowed for a call, nodes slower than this are taken for
+	// gone.
+	rpcCallTimeout = 1 * time.Minute
+	// Connections are replaced well before the server closes them at
+	// its read timeout.
+	rpcConnMaxAge = 5 * time.Minute
+)
+
+// Response of rpc server to a successful HTTP CONNECT.
+const rpcConnected = "200 Connected to Go RPC"
+
+// rpcConn - pooled connection of an rpc client.
+type rpcConn struct {
+	client *rpc.Client
+	dialed time.Time
+}
+
+// rpcClientPool - fixed number of lazily made connections to an rpc
+// path of a node. Connections failing a call are dropped and made
+// again by the next call.
+type rpcClientPool struct {
+	addr  string
+	path  string
+	mutex *sync.Mutex
+	conns []*rpcConn
+	next  uint32 // Round robin counter of connections.
+}
+
+// newRPCClientPool - initialize connections to rpc path of the node
+// at addr.
+func newRPCClientPool(addr, path string) *rpcClientPool {
+	return &rpcClientPool{
+		addr:  addr,
+		path:  path,
+		mutex: &sync.Mutex{},
+		conns: make([]*rpcConn, rpcConnsPerDisk),
+	}
+}
+
+// dialRPC - connects to rpc path of the node at addr, like
+// rpc.DialHTTPPath with a timeout.
+func dialRPC(addr, path string) (*rpc.Client, error) {
+	conn, err := net.DialTimeout("tcp", addr, rpcDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(rpcDialTimeout))
+	if _, err = io.WriteString(conn, "CONNECT "+path+" HTTP/1.0\n\n"); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), &http.Request{Method: "CONNECT"})
+	if err == nil && resp.Status != rpcConnected {
+		err = errors.New("unexpected HTTP response: " + resp.Status)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	conn.SetDeadline(time.Time{})
+	return rpc.NewClient(conn), nil
+}
+
+// getConn - returns connection at index, connecting if there is none
+// or it is too old.
+func (p *rpcClientPool) getConn(index int) (*rpcConn, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	conn := p.conns[index]
+	if conn != nil && time.Since(conn.dialed) < rpcConnMaxAge {
+		return conn, nil
+	}
+	if conn != nil {
+		// Calls in progress are given time to complete.
+		p.conns[index] = nil
+		time.AfterFunc(rpcCallTimeout, func() { conn.client.Close() })
+	}
+	client, err := dialRPC(p.addr, p.path)
+	if err != nil {
+		return nil, err
+	}
+	conn = &rpcConn{client: client, dialed: time.Now()}
+	p.conns[index] = conn
+	return conn, nil
+}
+
+// dropConn - closes the connection at index, unless it was replaced
+// meanwhile.
+func (p *rpcClientPool) dropConn(index int, conn *rpcConn) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.conns[index] == conn {
+		p.conns[index] = nil
+	}
+	conn.client.Close()
+}
+
+// connect - makes a connection, verifying the node is reachable.
+func (p *rpcClientPool) connect() error {
+	_, err := p.getConn(0)
+	return err
+}
+
+// call - invokes the rpc method on the connection at index, waiting
+// at most rpcCallTimeout.
+func (p *rpcClientPool) call(index int, conn *rpcConn, serviceMethod string, args interface{}, reply interface{}) error {
+	timer := time.NewTimer(rpcCallTimeout)
+	defer timer.Stop()
+	call := conn.client.Go(serviceMethod, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		return call.Error
+	case <-timer.C:
+		p.dropConn(index, conn)
+		return errDiskNotFound
+	}
+}
+
+// Call - invokes the rpc method on the next connection. Calls failing
+// for network errors or timeouts return errDiskNotFound, calls on a
+// connection closed by the node are sent again on a new connection
+// since they were never sent.
+func (p *rpcClientPool) Call(serviceMethod string, args interface{}, reply interface{}) error {
+	index := int(atomic.AddUint32(&p.next, 1) % rpcConnsPerDisk)
+	for retry := 0; ; retry++ {
+		conn, err := p.getConn(index)
+		if err != nil {
+			return errDiskNotFound
+		}
+		err = p.call(index, conn, serviceMethod, args, reply)
+		if err == nil {
+			return nil
+		}
+		if _, ok := err.(rpc.ServerError); ok {
+			return err
+		}
+		if err != errDiskNotFound {
+			p.dropConn(index, conn)
+		}
+		if err != rpc.ErrShutdown || retry > 0 {
+			return errDiskNotFound
+		}
+	}
+}
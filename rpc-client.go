@@ -17,18 +17,15 @@
 package main
 
 import (
-	"net/http"
-	"net/rpc"
+	"io"
 	"strings"
-	"time"
 )
 
 type networkStorage struct {
-	netScheme  string
-	netAddr    string
-	netPath    string
-	rpcClient  *rpc.Client
-	httpClient *http.Client
+	netScheme string
+	netAddr   string
+	netPath   string
+	rpcClient *rpcClientPool
 }
 
 const (
@@ -43,6 +40,11 @@ func splitNetPath(networkPath string) (netAddr, netPath string) {
 	return netAddr, netPath
 }
 
+// getStorageRPCPath - returns rpc path of the disk at path of a node.
+func getStorageRPCPath(diskPath string) string {
+	return storageRPCPath + diskPath
+}
+
 // Converts rpc.ServerError to underlying error. This function is
 // written so that the storageAPI errors are consistent across network
 // disks as well.
@@ -50,12 +52,18 @@ func toStorageErr(err error) error {
 	switch err.Error() {
 	case errDiskFull.Error():
 		return errDiskFull
+	case errDiskNotFound.Error():
+		return errDiskNotFound
+	case errFaultyDisk.Error():
+		return errFaultyDisk
 	case errVolumeNotFound.Error():
 		return errVolumeNotFound
 	case errVolumeExists.Error():
 		return errVolumeExists
 	case errFileNotFound.Error():
 		return errFileNotFound
+	case errFileNameTooLong.Error():
+		return errFileNameTooLong
 	case errIsNotRegular.Error():
 		return errIsNotRegular
 	case errVolumeNotEmpty.Error():
@@ -68,7 +76,9 @@ func toStorageErr(err error) error {
 	return err
 }
 
-// Initialize new rpc client.
+// Initialize new rpc client, connections are made upon the first call.
+// Returns errDiskNotFound along with the client if the node cannot be
+// reached, calls reconnect once it is back.
 func newRPCClient(networkPath string) (StorageAPI, error) {
 	// Input validation.
 	if networkPath == "" || strings.LastIndex(networkPath, ":") == -1 {
@@ -78,28 +88,17 @@ func newRPCClient(networkPath string) (StorageAPI, error) {
 	// TODO validate netAddr and netPath.
 	netAddr, netPath := splitNetPath(networkPath)
 
-	// Dial minio rpc storage http path.
-	rpcClient, err := rpc.DialHTTPPath("tcp", netAddr, storageRPCPath)
-	if err != nil {
-		return nil, err
-	}
-
-	// Initialize http client.
-	httpClient := &http.Client{
-		// Setting a sensible time out of 6minutes to wait for
-		// response headers. Request is pro-actively cancelled
-		// after 6minutes if no response was received from server.
-		Timeout:   6 * time.Minute,
-		Transport: http.DefaultTransport,
-	}
-
 	// Initialize network storage.
 	ndisk := &networkStorage{
-		netScheme:  "http", // TODO: fix for ssl rpc support.
-		netAddr:    netAddr,
-		netPath:    netPath,
-		rpcClient:  rpcClient,
-		httpClient: httpClient,
+		netScheme: "http", // TODO: fix for ssl rpc support.
+		netAddr:   netAddr,
+		netPath:   netPath,
+		rpcClient: newRPCClientPool(netAddr, getStorageRPCPath(netPath)),
+	}
+
+	// Verify the node is reachable.
+	if err := ndisk.rpcClient.connect(); err != nil {
+		return ndisk, errDiskNotFound
 	}
 
 	// Returns successfully here.
@@ -182,16 +181,25 @@ func (n networkStorage) ReadAll(volume, path string) (buf []byte, err error) {
 	return buf, nil
 }
 
-// ReadFile - reads a file.
+// ReadFile - reads a file, like io.ReadFull returns io.EOF or
+// io.ErrUnexpectedEOF if the file ends before the buffer is filled.
 func (n networkStorage) ReadFile(volume string, path string, offset int64, buffer []byte) (m int64, err error) {
+	var buf []byte
 	if err = n.rpcClient.Call("Storage.ReadFileHandler", ReadFileArgs{
 		Vol:    volume,
 		Path:   path,
 		Offset: offset,
-		Buffer: buffer,
-	}, &m); err != nil {
+		Size:   int64(len(buffer)),
+	}, &buf); err != nil {
 		return 0, toStorageErr(err)
 	}
+	m = int64(copy(buffer, buf))
+	if m == 0 && len(buffer) > 0 {
+		return 0, io.EOF
+	}
+	if m < int64(len(buffer)) {
+		return m, io.ErrUnexpectedEOF
+	}
 	return m, nil
 }
 
@@ -219,6 +227,18 @@ func (n networkStorage) DeleteFile(volume, path string) (err error) {
 	return nil
 }
 
+// ShredFile - Shred a file at path.
+func (n networkStorage) ShredFile(volume, path string) (err error) {
+	reply := GenericReply{}
+	if err = n.rpcClient.Call("Storage.ShredFileHandler", ShredFileArgs{
+		Vol:  volume,
+		Path: path,
+	}, &reply); err != nil {
+		return toStorageErr(err)
+	}
+	return nil
+}
+
 // RenameFile - Rename file.
 func (n networkStorage) RenameFile(srcVolume, srcPath, dstVolume, dstPath string) (err error) {
 	reply := GenericReply{}
@@ -232,3 +252,17 @@ func (n networkStorage) RenameFile(srcVolume, srcPath, dstVolume, dstPath string
 	}
 	return nil
 }
+
+// LinkFile - Link file.
+func (n networkStorage) LinkFile(srcVolume, srcPath, dstVolume, dstPath string) (err error) {
+	reply := GenericReply{}
+	if err = n.rpcClient.Call("Storage.LinkFileHandler", LinkFileArgs{
+		SrcVol:  srcVolume,
+		SrcPath: srcPath,
+		DstVol:  dstVolume,
+		DstPath: dstPath,
+	}, &reply); err != nil {
+		return toStorageErr(err)
+	}
+	return nil
+}
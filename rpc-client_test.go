@@ -0,0 +1,111 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	router "github.com/gorilla/mux"
+)
+
+// Tests disks of another node are reachable once the node is up, with
+// storage errors and short reads reported like local disks.
+func TestNetworkStorage(t *testing.T) {
+	diskPath, err := ioutil.TempDir(os.TempDir(), "minio-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(diskPath)
+
+	// Reserve an address for the node, started later.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	disk, err := newRPCClient(addr + ":" + diskPath)
+	if err != errDiskNotFound {
+		t.Fatalf("Expected %s, got %v", errDiskNotFound, err)
+	}
+	if err = disk.MakeVol("bucket"); err != errDiskNotFound {
+		t.Fatalf("Expected %s, got %v", errDiskNotFound, err)
+	}
+
+	stServers, err := newRPCServers([]string{diskPath}, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := router.NewRouter()
+	registerStorageRPCRouter(mux, stServers)
+	server := httptest.NewUnstartedServer(mux)
+	if server.Listener, err = net.Listen("tcp", addr); err != nil {
+		t.Fatal(err)
+	}
+	server.Start()
+	defer server.Close()
+
+	if err = disk.MakeVol("bucket"); err != nil {
+		t.Fatalf("Expected disk to be reachable once the node is up. %v", err)
+	}
+	if err = disk.AppendFile("bucket", "object", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	if buf, err := disk.ReadAll("bucket", "object"); err != nil || string(buf) != "hello" {
+		t.Fatalf("Expected \"hello\", got %q. %v", buf, err)
+	}
+	if err = disk.MakeVol("bucket"); err != errVolumeExists {
+		t.Errorf("Expected %s, got %v", errVolumeExists, err)
+	}
+	if _, err = disk.StatFile("bucket", "missing"); err != errFileNotFound {
+		t.Errorf("Expected %s, got %v", errFileNotFound, err)
+	}
+
+	testCases := []struct {
+		offset       int64
+		expectedData string
+		expectedErr  error
+	}{
+		// Test case - 1.
+		{0, "hello", nil},
+		// Test case - 2.
+		{3, "lo", io.ErrUnexpectedEOF},
+		// Test case - 3.
+		{5, "", io.EOF},
+	}
+	for i, testCase := range testCases {
+		buf := make([]byte, 5)
+		n, err := disk.ReadFile("bucket", "object", testCase.offset, buf)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		if string(buf[:n]) != testCase.expectedData {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expectedData, buf[:n])
+		}
+	}
+
+	// Disks not served by the node are not found.
+	if _, err = newRPCClient(addr + ":" + diskPath + "-missing"); err != errDiskNotFound {
+		t.Errorf("Expected %s, got %v", errDiskNotFound, err)
+	}
+}
@@ -45,11 +45,11 @@ type ReadFileArgs struct {
 	// Name of the path.
 	Path string
 
-	// Starting offset to start reading into Buffer.
+	// Starting offset to start reading from.
 	Offset int64
 
-	// Data buffer read from the path at offset.
-	Buffer []byte
+	// Number of bytes to read from offset.
+	Size int64
 }
 
 // AppendFileArgs represents append file RPC arguments.
@@ -82,6 +82,15 @@ type DeleteFileArgs struct {
 	Path string
 }
 
+// ShredFileArgs represents shred file RPC arguments.
+type ShredFileArgs struct {
+	// Name of the volume.
+	Vol string
+
+	// Name of the path.
+	Path string
+}
+
 // ListDirArgs represents list contents RPC arguments.
 type ListDirArgs struct {
 	// Name of the volume.
@@ -105,3 +114,18 @@ type RenameFileArgs struct {
 	// Destination path of renamed file.
 	DstPath string
 }
+
+// LinkFileArgs represents link file RPC arguments.
+type LinkFileArgs struct {
+	// Name of source volume.
+	SrcVol string
+
+	// Source path to be linked.
+	SrcPath string
+
+	// Name of destination volume.
+	DstVol string
+
+	// Destination path of the link.
+	DstPath string
+}
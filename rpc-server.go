@@ -1,7 +1,10 @@
 package main
 
 import (
+	"io"
 	"net/rpc"
+	"path/filepath"
+	"strings"
 
 	router "github.com/gorilla/mux"
 )
@@ -76,22 +79,28 @@ func (s *storageServer) ListDirHandler(arg *ListDirArgs, reply *[]string) error
 }
 
 // ReadAllHandler - read all handler is rpc wrapper to read all storage API.
-func (s *storageServer) ReadAllHandler(arg *ReadFileArgs, reply *[]byte) error {
+func (s *storageServer) ReadAllHandler(arg *ReadAllArgs, reply *[]byte) error {
 	buf, err := s.storage.ReadAll(arg.Vol, arg.Path)
 	if err != nil {
 		return err
 	}
-	reply = &buf
+	*reply = buf
 	return nil
 }
 
-// ReadFileHandler - read file handler is rpc wrapper to read file.
-func (s *storageServer) ReadFileHandler(arg *ReadFileArgs, reply *int64) error {
-	n, err := s.storage.ReadFile(arg.Vol, arg.Path, arg.Offset, arg.Buffer)
-	if err != nil {
+// ReadFileHandler - read file handler is rpc wrapper to read file,
+// bytes read before the end of the file are sent back without error
+// and the client reports the short read.
+func (s *storageServer) ReadFileHandler(arg *ReadFileArgs, reply *[]byte) error {
+	if arg.Size < 0 {
+		return errInvalidArgument
+	}
+	buf := make([]byte, arg.Size)
+	n, err := s.storage.ReadFile(arg.Vol, arg.Path, arg.Offset, buf)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
 		return err
 	}
-	reply = &n
+	*reply = buf[:n]
 	return nil
 }
 
@@ -105,11 +114,21 @@ func (s *storageServer) DeleteFileHandler(arg *DeleteFileArgs, reply *GenericRep
 	return s.storage.DeleteFile(arg.Vol, arg.Path)
 }
 
+// ShredFileHandler - shred file handler is rpc wrapper to shred file.
+func (s *storageServer) ShredFileHandler(arg *ShredFileArgs, reply *GenericReply) error {
+	return s.storage.ShredFile(arg.Vol, arg.Path)
+}
+
 // RenameFileHandler - rename file handler is rpc wrapper to rename file.
 func (s *storageServer) RenameFileHandler(arg *RenameFileArgs, reply *GenericReply) error {
 	return s.storage.RenameFile(arg.SrcVol, arg.SrcPath, arg.DstVol, arg.DstPath)
 }
 
+// LinkFileHandler - link file handler is rpc wrapper to link file.
+func (s *storageServer) LinkFileHandler(arg *LinkFileArgs, reply *GenericReply) error {
+	return s.storage.LinkFile(arg.SrcVol, arg.SrcPath, arg.DstVol, arg.DstPath)
+}
+
 // Initialize new storage rpc.
 func newRPCServer(exportPath string) (*storageServer, error) {
 	// Initialize posix storage API.
@@ -122,11 +141,51 @@ func newRPCServer(exportPath string) (*storageServer, error) {
 	}, nil
 }
 
-// registerStorageRPCRouter - register storage rpc router.
-func registerStorageRPCRouter(mux *router.Router, stServer *storageServer) {
-	storageRPCServer := rpc.NewServer()
-	storageRPCServer.RegisterName("Storage", stServer)
+// getLocalStoragePaths - returns paths of all disks of this node,
+// local export paths and network export paths pointing to this
+// server, served to other nodes.
+func getLocalStoragePaths(exportPaths []string, serverAddr string) []string {
+	var diskPaths []string
+	for _, exportPath := range exportPaths {
+		if isLocalExportPath(exportPath) {
+			if absPath, err := filepath.Abs(exportPath); err == nil {
+				exportPath = absPath
+			}
+			diskPaths = append(diskPaths, filepath.ToSlash(exportPath))
+			continue
+		}
+		if netAddr, netPath := splitNetPath(exportPath); isLocalPeer(netAddr, serverAddr) {
+			diskPaths = append(diskPaths, netPath)
+		}
+	}
+	return diskPaths
+}
+
+// newRPCServers - initialize storage rpc of every disk of this node,
+// by disk path.
+func newRPCServers(exportPaths []string, serverAddr string) (map[string]*storageServer, error) {
+	stServers := make(map[string]*storageServer)
+	for _, diskPath := range getLocalStoragePaths(exportPaths, serverAddr) {
+		if _, ok := stServers[diskPath]; ok {
+			continue
+		}
+		stServer, err := newRPCServer(diskPath)
+		if err != nil {
+			return nil, err
+		}
+		stServers[diskPath] = stServer
+	}
+	return stServers, nil
+}
+
+// registerStorageRPCRouter - register storage rpc router, each disk
+// is served at the storage rpc path followed by its path.
+func registerStorageRPCRouter(mux *router.Router, stServers map[string]*storageServer) {
 	storageRouter := mux.NewRoute().PathPrefix(reservedBucket).Subrouter()
-	// Add minio storage routes.
-	storageRouter.Path("/storage").Handler(storageRPCServer)
+	for diskPath, stServer := range stServers {
+		storageRPCServer := rpc.NewServer()
+		storageRPCServer.RegisterName("Storage", stServer)
+		// Add minio storage routes.
+		storageRouter.Path(strings.TrimPrefix(getStorageRPCPath(diskPath), reservedBucket)).Handler(storageRPCServer)
+	}
 }
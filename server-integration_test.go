@@ -0,0 +1,996 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// s3TestClient - minimal S3 client signing requests with signature v4,
+// drives a TestServer over HTTP.
+type s3TestClient struct {
+	endpoint  string
+	accessKey string
+	secretKey string
+	client    *http.Client
+}
+
+// newS3TestClient - returns a client for the test server.
+func newS3TestClient(testServer TestServer) s3TestClient {
+	return s3TestClient{
+		endpoint:  testServer.Server.URL,
+		accessKey: testServer.AccessKey,
+		secretKey: testServer.SecretKey,
+		client:    http.DefaultClient,
+	}
+}
+
+// do - sends a signed request, headers are sent unsigned.
+func (c s3TestClient) do(method, bucket, object string, queryValues url.Values, headers map[string]string, body []byte) (*http.Response, []byte, error) {
+	var bodyReader *bytes.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	urlStr := makeTestTargetURL(c.endpoint, bucket, object, queryValues)
+	var req *http.Request
+	var err error
+	if bodyReader != nil {
+		req, err = newTestRequest(method, urlStr, int64(len(body)), bodyReader, c.accessKey, c.secretKey)
+	} else {
+		req, err = newTestRequest(method, urlStr, 0, nil, c.accessKey, c.secretKey)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	return resp, respBody, err
+}
+
+// expectStatus - fails the test unless response has the status.
+func expectStatus(t *testing.T, testName string, resp *http.Response, respBody []byte, status int) {
+	if resp.StatusCode != status {
+		t.Fatalf("%s: Expected status %d, got %d: %s", testName, status, resp.StatusCode, respBody)
+	}
+}
+
+// expectErrorCode - fails the test unless response is an S3 error
+// with the status and code of the API error.
+func expectErrorCode(t *testing.T, testName string, resp *http.Response, respBody []byte, errCode APIErrorCode) {
+	apiErr := getAPIError(errCode)
+	expectStatus(t, testName, resp, respBody, apiErr.HTTPStatusCode)
+	if resp.Request.Method == "HEAD" {
+		return
+	}
+	expectErrorBody(t, testName, respBody, errCode)
+}
+
+// expectErrorBody - fails the test unless response body is an S3 error
+// with the code of the API error.
+func expectErrorBody(t *testing.T, testName string, respBody []byte, errCode APIErrorCode) {
+	apiErr := getAPIError(errCode)
+	errResp := APIErrorResponse{}
+	if err := xml.Unmarshal(respBody, &errResp); err != nil {
+		t.Fatalf("%s: Unable to parse error response: %s", testName, err)
+	}
+	if errResp.Code != apiErr.Code {
+		t.Fatalf("%s: Expected error code %s, got %s", testName, apiErr.Code, errResp.Code)
+	}
+}
+
+// Integration tests against a server running the FS backend.
+func TestIntegrationFS(t *testing.T) {
+	runIntegrationTests(t, "FS")
+}
+
+// Integration tests against a server running the XL backend.
+func TestIntegrationXL(t *testing.T) {
+	runIntegrationTests(t, "XL")
+}
+
+// runIntegrationTests - boots the full HTTP server for the backend
+// and runs all integration tests against it.
+func runIntegrationTests(t *testing.T, instanceType string) {
+	testServer := StartTestServer(t, instanceType)
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+
+	integrationTests := []func(t *testing.T, client s3TestClient){
+		testIntegrationObject,
+		testIntegrationRange,
+		testIntegrationConditional,
+		testIntegrationMultipart,
+		testIntegrationDeleteObjects,
+		testIntegrationListObjectsV2,
+		testIntegrationCopyObject,
+		testIntegrationObjectTagging,
+		testIntegrationBucketVersioning,
+		testIntegrationObjectLock,
+		testIntegrationErrors,
+	}
+	for _, integrationTest := range integrationTests {
+		integrationTest(t, client)
+	}
+}
+
+// makeIntegrationBucket - creates a random bucket.
+func makeIntegrationBucket(t *testing.T, client s3TestClient) string {
+	bucket := getRandomBucketName()
+	resp, respBody, err := client.do("PUT", bucket, "", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "MakeBucket", resp, respBody, http.StatusOK)
+	return bucket
+}
+
+// Tests put, get, head and delete of an object.
+func testIntegrationObject(t *testing.T, client s3TestClient) {
+	bucket := makeIntegrationBucket(t, client)
+	data := []byte("hello, integration")
+
+	resp, respBody, err := client.do("PUT", bucket, "dir/object", nil, nil, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+	etag := resp.Header.Get("ETag")
+
+	resp, respBody, err = client.do("GET", bucket, "dir/object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "GetObject", resp, respBody, http.StatusOK)
+	if !bytes.Equal(respBody, data) {
+		t.Fatalf("GetObject: Expected %q, got %q", data, respBody)
+	}
+	// FS backend does not save md5sum of objects.
+	if getETag := resp.Header.Get("ETag"); getETag != "" && getETag != etag {
+		t.Fatalf("GetObject: Expected ETag %s, got %s", etag, getETag)
+	}
+
+	resp, respBody, err = client.do("HEAD", bucket, "dir/object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "HeadObject", resp, respBody, http.StatusOK)
+	if resp.ContentLength != int64(len(data)) {
+		t.Fatalf("HeadObject: Expected Content-Length %d, got %d", len(data), resp.ContentLength)
+	}
+
+	resp, respBody, err = client.do("DELETE", bucket, "dir/object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "DeleteObject", resp, respBody, http.StatusNoContent)
+
+	resp, respBody, err = client.do("GET", bucket, "dir/object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "GetObject after DeleteObject", resp, respBody, ErrNoSuchKey)
+}
+
+// Tests deleting objects in a single request, in verbose and quiet
+// mode.
+func testIntegrationDeleteObjects(t *testing.T, client s3TestClient) {
+	bucket := makeIntegrationBucket(t, client)
+	for _, object := range []string{"a", "dir/b"} {
+		resp, respBody, err := client.do("PUT", bucket, object, nil, nil, []byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+	}
+	deleteObjects := func(quiet bool, objects ...string) DeleteObjectsResponse {
+		deleteReq := DeleteObjectsRequest{Quiet: quiet}
+		for _, object := range objects {
+			deleteReq.Objects = append(deleteReq.Objects, ObjectIdentifier{ObjectName: object})
+		}
+		deleteXML, err := xml.Marshal(deleteReq)
+		if err != nil {
+			t.Fatal(err)
+		}
+		md5Sum := md5.Sum(deleteXML)
+		headers := map[string]string{"Content-Md5": base64.StdEncoding.EncodeToString(md5Sum[:])}
+		resp, respBody, err := client.do("POST", bucket, "", url.Values{"delete": {""}}, headers, deleteXML)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "DeleteMultipleObjects", resp, respBody, http.StatusOK)
+		deleteResp := DeleteObjectsResponse{}
+		if err = xml.Unmarshal(respBody, &deleteResp); err != nil {
+			t.Fatal(err)
+		}
+		return deleteResp
+	}
+
+	// Missing keys are reported as deleted, invalid keys as errors.
+	deleteResp := deleteObjects(false, "a", "missing", "")
+	if len(deleteResp.DeletedObjects) != 2 || deleteResp.DeletedObjects[0].ObjectName != "a" || deleteResp.DeletedObjects[1].ObjectName != "missing" {
+		t.Fatalf("DeleteMultipleObjects: Expected a and missing to be deleted, got %v", deleteResp.DeletedObjects)
+	}
+	if len(deleteResp.Errors) != 1 || deleteResp.Errors[0].Code != getAPIError(ErrNoSuchKey).Code {
+		t.Fatalf("DeleteMultipleObjects: Expected one %s error, got %v", getAPIError(ErrNoSuchKey).Code, deleteResp.Errors)
+	}
+
+	// Quiet mode only reports errors.
+	deleteResp = deleteObjects(true, "dir/b")
+	if len(deleteResp.DeletedObjects) != 0 || len(deleteResp.Errors) != 0 {
+		t.Fatalf("DeleteMultipleObjects: Expected an empty quiet response, got %v", deleteResp)
+	}
+	resp, respBody, err := client.do("GET", bucket, "dir/b", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "GetObject after DeleteMultipleObjects", resp, respBody, ErrNoSuchKey)
+
+	// Content-Md5 must match the body.
+	deleteXML := []byte("<Delete><Object><Key>a</Key></Object></Delete>")
+	md5Sum := md5.Sum([]byte("other"))
+	headers := map[string]string{"Content-Md5": base64.StdEncoding.EncodeToString(md5Sum[:])}
+	resp, respBody, err = client.do("POST", bucket, "", url.Values{"delete": {""}}, headers, deleteXML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "DeleteMultipleObjects with bad Content-Md5", resp, respBody, ErrBadDigest)
+}
+
+// Tests paging through a bucket with ListObjects V2.
+func testIntegrationListObjectsV2(t *testing.T, client s3TestClient) {
+	bucket := makeIntegrationBucket(t, client)
+	objects := []string{"a", "b", "c"}
+	for _, object := range objects {
+		resp, respBody, err := client.do("PUT", bucket, object, nil, nil, []byte("hello"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+	}
+	listObjectsV2 := func(query url.Values) ListObjectsV2Response {
+		query.Set("list-type", "2")
+		resp, respBody, err := client.do("GET", bucket, "", query, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "ListObjectsV2", resp, respBody, http.StatusOK)
+		listResp := ListObjectsV2Response{}
+		if err = xml.Unmarshal(respBody, &listResp); err != nil {
+			t.Fatal(err)
+		}
+		return listResp
+	}
+
+	var listed []string
+	query := url.Values{"max-keys": {"2"}, "fetch-owner": {"true"}}
+	for page := 0; page < len(objects); page++ {
+		listResp := listObjectsV2(query)
+		for _, content := range listResp.Contents {
+			if content.Owner == nil {
+				t.Fatalf("ListObjectsV2: Expected owner of %s with fetch-owner", content.Key)
+			}
+			listed = append(listed, content.Key)
+		}
+		if listResp.KeyCount != len(listResp.Contents) {
+			t.Fatalf("ListObjectsV2: Expected KeyCount %d, got %d", len(listResp.Contents), listResp.KeyCount)
+		}
+		if !listResp.IsTruncated {
+			break
+		}
+		// Continuation tokens are opaque.
+		if listResp.NextContinuationToken == "" || listResp.NextContinuationToken == listed[len(listed)-1] {
+			t.Fatalf("ListObjectsV2: Expected an opaque next continuation token, got %q", listResp.NextContinuationToken)
+		}
+		query.Set("continuation-token", listResp.NextContinuationToken)
+	}
+	if strings.Join(listed, ",") != strings.Join(objects, ",") {
+		t.Fatalf("ListObjectsV2: Expected %v, got %v", objects, listed)
+	}
+
+	// Owner is only listed with fetch-owner.
+	listResp := listObjectsV2(url.Values{"start-after": {"a"}})
+	if len(listResp.Contents) != 2 || listResp.Contents[0].Key != "b" || listResp.Contents[0].Owner != nil {
+		t.Fatalf("ListObjectsV2: Expected b and c without owner, got %v", listResp.Contents)
+	}
+
+	resp, respBody, err := client.do("GET", bucket, "", url.Values{"list-type": {"2"}, "continuation-token": {"not base64!"}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "ListObjectsV2 with invalid continuation token", resp, respBody, ErrIncorrectContinuationToken)
+}
+
+// Tests ranged reads of an object.
+func testIntegrationRange(t *testing.T, client s3TestClient) {
+	bucket := makeIntegrationBucket(t, client)
+	data := []byte("0123456789")
+	resp, respBody, err := client.do("PUT", bucket, "object", nil, nil, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+
+	testCases := []struct {
+		byteRange     string
+		expectedBody  []byte
+		expectedRange string
+	}{
+		// Test case - 1.
+		{"bytes=2-5", data[2:6], "bytes 2-5/10"},
+		// Test case - 2.
+		// Suffix range.
+		{"bytes=-3", data[7:], "bytes 7-9/10"},
+		// Test case - 3.
+		// Open ended range.
+		{"bytes=8-", data[8:], "bytes 8-9/10"},
+		// Test case - 4.
+		// End beyond object size is truncated.
+		{"bytes=5-100", data[5:], "bytes 5-9/10"},
+	}
+	for i, testCase := range testCases {
+		resp, respBody, err := client.do("GET", bucket, "object", nil, map[string]string{"Range": testCase.byteRange}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "Range test "+strconv.Itoa(i+1), resp, respBody, http.StatusPartialContent)
+		if !bytes.Equal(respBody, testCase.expectedBody) {
+			t.Errorf("Test %d: Expected %q, got %q", i+1, testCase.expectedBody, respBody)
+		}
+		if resp.Header.Get("Content-Range") != testCase.expectedRange {
+			t.Errorf("Test %d: Expected Content-Range %s, got %s", i+1, testCase.expectedRange, resp.Header.Get("Content-Range"))
+		}
+	}
+
+	resp, respBody, err = client.do("GET", bucket, "object", nil, map[string]string{"Range": "bytes=20-30"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "Unsatisfiable range", resp, respBody, ErrInvalidRange)
+
+	// FS backend does not save md5sum of objects.
+	resp, respBody, err = client.do("HEAD", bucket, "object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	etag := resp.Header.Get("ETag")
+	if etag == "" {
+		return
+	}
+	conditionalCases := []struct {
+		headers        map[string]string
+		expectedStatus int
+	}{
+		// Test case - 1.
+		// Preconditions are evaluated before serving ranges.
+		{map[string]string{"If-None-Match": etag, "Range": "bytes=0-1"}, http.StatusNotModified},
+		// Test case - 2.
+		{map[string]string{"If-Range": etag, "Range": "bytes=0-1"}, http.StatusPartialContent},
+		// Test case - 3.
+		// Mismatching If-Range serves the whole object.
+		{map[string]string{"If-Range": "\"mismatch\"", "Range": "bytes=0-1"}, http.StatusOK},
+	}
+	for i, testCase := range conditionalCases {
+		resp, respBody, err := client.do("GET", bucket, "object", nil, testCase.headers, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "Conditional range test "+strconv.Itoa(i+1), resp, respBody, testCase.expectedStatus)
+	}
+}
+
+// Tests conditional reads of an object.
+func testIntegrationConditional(t *testing.T, client s3TestClient) {
+	bucket := makeIntegrationBucket(t, client)
+	resp, respBody, err := client.do("PUT", bucket, "object", nil, nil, []byte("conditional"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+	resp, respBody, err = client.do("HEAD", bucket, "object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "HeadObject", resp, respBody, http.StatusOK)
+	past := time.Now().UTC().Add(-time.Hour).Format(http.TimeFormat)
+	future := time.Now().UTC().Add(time.Hour).Format(http.TimeFormat)
+
+	testCases := []struct {
+		headers        map[string]string
+		expectedStatus int
+	}{
+		// Test case - 1.
+		{map[string]string{"If-Modified-Since": past}, http.StatusOK},
+		// Test case - 2.
+		{map[string]string{"If-Modified-Since": future}, http.StatusNotModified},
+		// Test case - 3.
+		{map[string]string{"If-Unmodified-Since": future}, http.StatusOK},
+		// Test case - 4.
+		{map[string]string{"If-Unmodified-Since": past}, http.StatusPreconditionFailed},
+	}
+	// FS backend does not save md5sum of objects, ETag conditions
+	// are verified only if the backend returns an ETag.
+	if etag := resp.Header.Get("ETag"); etag != "" {
+		testCases = append(testCases, []struct {
+			headers        map[string]string
+			expectedStatus int
+		}{
+			// Test case - 5.
+			{map[string]string{"If-Match": etag}, http.StatusOK},
+			// Test case - 6.
+			{map[string]string{"If-Match": "\"mismatch\""}, http.StatusPreconditionFailed},
+			// Test case - 7.
+			{map[string]string{"If-None-Match": etag}, http.StatusNotModified},
+			// Test case - 8.
+			{map[string]string{"If-None-Match": "\"mismatch\""}, http.StatusOK},
+		}...)
+	}
+	for i, testCase := range testCases {
+		for _, method := range []string{"GET", "HEAD"} {
+			resp, _, err := client.do(method, bucket, "object", nil, testCase.headers, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.StatusCode != testCase.expectedStatus {
+				t.Errorf("Test %d: %s expected status %d, got %d", i+1, method, testCase.expectedStatus, resp.StatusCode)
+			}
+		}
+	}
+
+	// Uploads with 'If-None-Match: *' only create objects.
+	createOnly := map[string]string{"If-None-Match": "*"}
+	resp, respBody, err = client.do("PUT", bucket, "object", nil, createOnly, []byte("replaced"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "PutObject create-only existing", resp, respBody, ErrPreconditionFailed)
+	resp, respBody, err = client.do("PUT", bucket, "new-object", nil, createOnly, []byte("created"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject create-only", resp, respBody, http.StatusOK)
+	resp, respBody, err = client.do("PUT", bucket, "new-object", nil, map[string]string{"If-None-Match": "\"etag\""}, []byte("created"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "PutObject If-None-Match etag", resp, respBody, ErrNotImplemented)
+}
+
+// Tests multipart upload of an object.
+func testIntegrationMultipart(t *testing.T, client s3TestClient) {
+	bucket := makeIntegrationBucket(t, client)
+	resp, respBody, err := client.do("POST", bucket, "object", url.Values{"uploads": {""}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "NewMultipartUpload", resp, respBody, http.StatusOK)
+	initResp := InitiateMultipartUploadResponse{}
+	if err = xml.Unmarshal(respBody, &initResp); err != nil {
+		t.Fatal(err)
+	}
+
+	parts := [][]byte{
+		bytes.Repeat([]byte("a"), minPartSize),
+		[]byte("last part"),
+	}
+	complete := completeMultipartUpload{}
+	for i, part := range parts {
+		queryValues := url.Values{
+			"partNumber": {strconv.Itoa(i + 1)},
+			"uploadId":   {initResp.UploadID},
+		}
+		resp, respBody, err = client.do("PUT", bucket, "object", queryValues, nil, part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "PutObjectPart", resp, respBody, http.StatusOK)
+		complete.Parts = append(complete.Parts, completePart{PartNumber: i + 1, ETag: resp.Header.Get("ETag")})
+	}
+
+	uploadID := initResp.UploadID
+	completeBuf, err := xml.Marshal(complete)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, respBody, err = client.do("POST", bucket, "object", url.Values{"uploadId": {uploadID}}, nil, completeBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "CompleteMultipartUpload", resp, respBody, http.StatusOK)
+	completeResp := CompleteMultipartUploadResponse{}
+	if err = xml.Unmarshal(respBody, &completeResp); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, respBody, err = client.do("GET", bucket, "object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "GetObject", resp, respBody, http.StatusOK)
+	if !bytes.Equal(respBody, bytes.Join(parts, nil)) {
+		t.Fatal("GetObject: Multipart object content mismatch")
+	}
+	if getETag := resp.Header.Get("ETag"); getETag != "" && getETag != "\""+completeResp.ETag+"\"" {
+		t.Fatalf("GetObject: Expected ETag %s, got %s", completeResp.ETag, getETag)
+	}
+
+	// Completed upload cannot be completed again.
+	resp, respBody, err = client.do("POST", bucket, "object", url.Values{"uploadId": {uploadID}}, nil, completeBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Complete multipart upload sends status before the upload is
+	// completed, errors are only reported in the body.
+	expectStatus(t, "CompleteMultipartUpload twice", resp, respBody, http.StatusOK)
+	expectErrorBody(t, "CompleteMultipartUpload twice", respBody, ErrNoSuchUpload)
+
+	// Too small parts are rejected on completion.
+	resp, respBody, err = client.do("POST", bucket, "small", url.Values{"uploads": {""}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "NewMultipartUpload", resp, respBody, http.StatusOK)
+	if err = xml.Unmarshal(respBody, &initResp); err != nil {
+		t.Fatal(err)
+	}
+	complete = completeMultipartUpload{}
+	for i := 1; i <= 2; i++ {
+		queryValues := url.Values{
+			"partNumber": {strconv.Itoa(i)},
+			"uploadId":   {initResp.UploadID},
+		}
+		resp, respBody, err = client.do("PUT", bucket, "small", queryValues, nil, []byte("small part"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "PutObjectPart", resp, respBody, http.StatusOK)
+		complete.Parts = append(complete.Parts, completePart{PartNumber: i, ETag: resp.Header.Get("ETag")})
+	}
+	if completeBuf, err = xml.Marshal(complete); err != nil {
+		t.Fatal(err)
+	}
+	resp, respBody, err = client.do("POST", bucket, "small", url.Values{"uploadId": {initResp.UploadID}}, nil, completeBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "CompleteMultipartUpload with small parts", resp, respBody, http.StatusOK)
+	expectErrorBody(t, "CompleteMultipartUpload with small parts", respBody, ErrEntityTooSmall)
+
+	resp, respBody, err = client.do("DELETE", bucket, "small", url.Values{"uploadId": {initResp.UploadID}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "AbortMultipartUpload", resp, respBody, http.StatusNoContent)
+}
+
+// Tests error codes returned to clients.
+// Tests copying objects onto themselves replaces only their metadata.
+func testIntegrationCopyObject(t *testing.T, client s3TestClient) {
+	bucket := makeIntegrationBucket(t, client)
+	data := []byte("hello, copy")
+	headers := map[string]string{"Content-Type": "text/plain", "X-Amz-Meta-A": "1"}
+	resp, respBody, err := client.do("PUT", bucket, "object", nil, headers, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+	resp, respBody, err = client.do("HEAD", bucket, "object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "HeadObject", resp, respBody, http.StatusOK)
+	etag := resp.Header.Get("ETag")
+	// FS backend does not save metadata of objects.
+	savesMetadata := resp.Header.Get("X-Amz-Meta-A") == "1"
+
+	copySource := "/" + bucket + "/object"
+	resp, respBody, err = client.do("PUT", bucket, "object", nil, map[string]string{"X-Amz-Copy-Source": copySource}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "CopyObject onto itself", resp, respBody, ErrInvalidCopyDest)
+	headers = map[string]string{"X-Amz-Copy-Source": copySource, "X-Amz-Metadata-Directive": "MERGE"}
+	resp, respBody, err = client.do("PUT", bucket, "object", nil, headers, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "CopyObject with unknown directive", resp, respBody, ErrInvalidMetadataDirective)
+
+	headers = map[string]string{
+		"X-Amz-Copy-Source":        copySource,
+		"X-Amz-Metadata-Directive": "REPLACE",
+		"Content-Type":             "application/json",
+		"X-Amz-Meta-B":             "2",
+	}
+	resp, respBody, err = client.do("PUT", bucket, "object", nil, headers, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "CopyObject replacing metadata", resp, respBody, http.StatusOK)
+
+	resp, respBody, err = client.do("GET", bucket, "object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "GetObject", resp, respBody, http.StatusOK)
+	if !bytes.Equal(respBody, data) {
+		t.Fatalf("GetObject: Expected %q, got %q", data, respBody)
+	}
+	if resp.Header.Get("ETag") != etag {
+		t.Errorf("GetObject: Expected ETag %s, got %s", etag, resp.Header.Get("ETag"))
+	}
+	if !savesMetadata {
+		return
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "application/json" {
+		t.Errorf("GetObject: Expected Content-Type application/json, got %s", contentType)
+	}
+	if resp.Header.Get("X-Amz-Meta-A") != "" || resp.Header.Get("X-Amz-Meta-B") != "2" {
+		t.Errorf("GetObject: Expected user metadata to be replaced, got %v", resp.Header)
+	}
+}
+
+func testIntegrationObjectTagging(t *testing.T, client s3TestClient) {
+	bucket := makeIntegrationBucket(t, client)
+	headers := map[string]string{"X-Amz-Tagging": "env=prod&team=storage"}
+	resp, respBody, err := client.do("PUT", bucket, "object", nil, headers, []byte("hello, tags"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// FS backend does not save tags of objects.
+	if resp.StatusCode == http.StatusNotImplemented {
+		expectErrorCode(t, "PutObject with tags", resp, respBody, ErrNotImplemented)
+		return
+	}
+	expectStatus(t, "PutObject with tags", resp, respBody, http.StatusOK)
+
+	resp, respBody, err = client.do("HEAD", bucket, "object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "HeadObject", resp, respBody, http.StatusOK)
+	if count := resp.Header.Get("X-Amz-Tagging-Count"); count != "2" {
+		t.Errorf("HeadObject: Expected 2 tags, got %q", count)
+	}
+
+	tagging := url.Values{"tagging": {""}}
+	resp, respBody, err = client.do("GET", bucket, "object", tagging, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "GetObjectTagging", resp, respBody, http.StatusOK)
+	expected := generateObjectTagging(map[string]string{"env": "prod", "team": "storage"})
+	if !bytes.Equal(respBody, encodeResponse(expected)) {
+		t.Errorf("GetObjectTagging: Expected %s, got %s", encodeResponse(expected), respBody)
+	}
+
+	newTags := []byte(`<Tagging><TagSet><Tag><Key>env</Key><Value>dev</Value></Tag></TagSet></Tagging>`)
+	resp, respBody, err = client.do("PUT", bucket, "object", tagging, nil, newTags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObjectTagging", resp, respBody, http.StatusOK)
+	duplicateTags := []byte(`<Tagging><TagSet><Tag><Key>a</Key></Tag><Tag><Key>a</Key></Tag></TagSet></Tagging>`)
+	resp, respBody, err = client.do("PUT", bucket, "object", tagging, nil, duplicateTags)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "PutObjectTagging with duplicate keys", resp, respBody, ErrInvalidObjectTags)
+
+	// Copies keep the tags of the source by default.
+	headers = map[string]string{"X-Amz-Copy-Source": "/" + bucket + "/object"}
+	resp, respBody, err = client.do("PUT", bucket, "copy", nil, headers, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "CopyObject", resp, respBody, http.StatusOK)
+	headers["X-Amz-Tagging-Directive"] = "REPLACE"
+	headers["X-Amz-Tagging"] = "env=test"
+	resp, respBody, err = client.do("PUT", bucket, "replaced", nil, headers, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "CopyObject replacing tags", resp, respBody, http.StatusOK)
+
+	resp, respBody, err = client.do("GET", bucket, "", url.Values{"tag": {"env=dev"}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "ListObjects by tag", resp, respBody, http.StatusOK)
+	var listResult ListObjectsResponse
+	if err = xml.Unmarshal(respBody, &listResult); err != nil {
+		t.Fatal(err)
+	}
+	var keys []string
+	for _, content := range listResult.Contents {
+		keys = append(keys, content.Key)
+	}
+	if !reflect.DeepEqual(keys, []string{"copy", "object"}) {
+		t.Errorf("ListObjects by tag: Expected copy and object, got %v", keys)
+	}
+
+	resp, respBody, err = client.do("DELETE", bucket, "object", tagging, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "DeleteObjectTagging", resp, respBody, http.StatusNoContent)
+	resp, respBody, err = client.do("GET", bucket, "object", tagging, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "GetObjectTagging", resp, respBody, http.StatusOK)
+	if !bytes.Equal(respBody, encodeResponse(generateObjectTagging(nil))) {
+		t.Errorf("GetObjectTagging: Expected an empty tag set, got %s", respBody)
+	}
+}
+
+// Tests retained objects of locked buckets cannot be replaced or
+// deleted, and retention cannot be shortened.
+func testIntegrationObjectLock(t *testing.T, client s3TestClient) {
+	bucket := makeIntegrationBucket(t, client)
+	objectLock := url.Values{"object-lock": {""}}
+	retention := url.Values{"retention": {""}}
+	legalHold := url.Values{"legal-hold": {""}}
+	resp, respBody, err := client.do("GET", bucket, "", objectLock, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "GetBucketObjectLockConfig unlocked", resp, respBody, ErrNoSuchObjectLockConfig)
+
+	resp, respBody, err = client.do("PUT", bucket, "object", nil, nil, []byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+	retainUntil := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	retentionBuf := []byte(`<Retention><Mode>COMPLIANCE</Mode><RetainUntilDate>` + retainUntil.Format(time.RFC3339) + `</RetainUntilDate></Retention>`)
+	resp, respBody, err = client.do("PUT", bucket, "object", retention, nil, retentionBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "PutObjectRetention unlocked", resp, respBody, ErrObjectLockNotEnabled)
+
+	resp, respBody, err = client.do("PUT", bucket, "", objectLock, nil, []byte(`<ObjectLockConfiguration><ObjectLockEnabled>Enabled</ObjectLockEnabled></ObjectLockConfiguration>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutBucketObjectLockConfig", resp, respBody, http.StatusOK)
+	resp, respBody, err = client.do("PUT", bucket, "object", retention, nil, retentionBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObjectRetention", resp, respBody, http.StatusOK)
+	resp, respBody, err = client.do("GET", bucket, "object", retention, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "GetObjectRetention", resp, respBody, http.StatusOK)
+	var config objectRetentionConfiguration
+	if err = xml.Unmarshal(respBody, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.RetainUntilDate != retainUntil.Format(timeFormatAMZ) {
+		t.Errorf("GetObjectRetention: Expected %s, got %s", retainUntil.Format(timeFormatAMZ), config.RetainUntilDate)
+	}
+
+	shortenedBuf := []byte(`<Retention><Mode>COMPLIANCE</Mode><RetainUntilDate>` + retainUntil.Add(-time.Minute).Format(time.RFC3339) + `</RetainUntilDate></Retention>`)
+	resp, respBody, err = client.do("PUT", bucket, "object", retention, nil, shortenedBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "PutObjectRetention shortened", resp, respBody, ErrInvalidObjectRetention)
+	resp, respBody, err = client.do("PUT", bucket, "object", nil, nil, []byte("world"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "PutObject retained", resp, respBody, ErrObjectLocked)
+	resp, respBody, err = client.do("DELETE", bucket, "object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "DeleteObject retained", resp, respBody, ErrObjectLocked)
+
+	resp, respBody, err = client.do("PUT", bucket, "object", legalHold, nil, []byte(`<LegalHold><Status>ON</Status></LegalHold>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObjectLegalHold", resp, respBody, http.StatusOK)
+	resp, respBody, err = client.do("GET", bucket, "object", legalHold, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "GetObjectLegalHold", resp, respBody, http.StatusOK)
+	var holdConfig objectLegalHoldConfiguration
+	if err = xml.Unmarshal(respBody, &holdConfig); err != nil {
+		t.Fatal(err)
+	}
+	if holdConfig.Status != legalHoldOn {
+		t.Errorf("GetObjectLegalHold: Expected ON, got %q", holdConfig.Status)
+	}
+}
+
+// Tests versions kept by a versioned bucket.
+func testIntegrationBucketVersioning(t *testing.T, client s3TestClient) {
+	bucket := makeIntegrationBucket(t, client)
+	versioning := url.Values{"versioning": {""}}
+	enabled := []byte(`<VersioningConfiguration><Status>Enabled</Status></VersioningConfiguration>`)
+	resp, respBody, err := client.do("PUT", bucket, "", versioning, nil, enabled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// FS backend does not keep versions of objects.
+	if resp.StatusCode == http.StatusNotImplemented {
+		expectErrorCode(t, "PutBucketVersioning", resp, respBody, ErrNotImplemented)
+		return
+	}
+	expectStatus(t, "PutBucketVersioning", resp, respBody, http.StatusOK)
+	resp, respBody, err = client.do("PUT", bucket, "", versioning, nil, []byte(`<VersioningConfiguration/>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "PutBucketVersioning without status", resp, respBody, ErrIllegalVersioningConfig)
+
+	resp, respBody, err = client.do("GET", bucket, "", versioning, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "GetBucketVersioning", resp, respBody, http.StatusOK)
+	var config versioningConfiguration
+	if err = xml.Unmarshal(respBody, &config); err != nil {
+		t.Fatal(err)
+	}
+	if config.Status != versioningEnabled {
+		t.Errorf("GetBucketVersioning: Expected Enabled, got %q", config.Status)
+	}
+
+	var versionIDs []string
+	for _, data := range []string{"version-1", "version-2"} {
+		resp, respBody, err = client.do("PUT", bucket, "object", nil, nil, []byte(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		expectStatus(t, "PutObject", resp, respBody, http.StatusOK)
+		versionID := resp.Header.Get("X-Amz-Version-Id")
+		if !isValidVersionID(versionID) {
+			t.Fatalf("PutObject: Expected a version id, got %q", versionID)
+		}
+		versionIDs = append(versionIDs, versionID)
+	}
+
+	resp, respBody, err = client.do("GET", bucket, "object", url.Values{"versionId": {versionIDs[0]}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "GetObject version", resp, respBody, http.StatusOK)
+	if string(respBody) != "version-1" || resp.Header.Get("X-Amz-Version-Id") != versionIDs[0] {
+		t.Errorf("GetObject version: Expected version-1 of %s, got %s of %s", versionIDs[0], respBody, resp.Header.Get("X-Amz-Version-Id"))
+	}
+	resp, respBody, err = client.do("GET", bucket, "object", url.Values{"versionId": {getUUID()}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "GetObject unknown version", resp, respBody, ErrNoSuchVersion)
+
+	resp, respBody, err = client.do("DELETE", bucket, "object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "DeleteObject", resp, respBody, http.StatusNoContent)
+	if resp.Header.Get("X-Amz-Delete-Marker") != "true" {
+		t.Errorf("DeleteObject: Expected a delete marker.")
+	}
+	resp, respBody, err = client.do("GET", bucket, "object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "GetObject deleted", resp, respBody, ErrNoSuchKey)
+
+	// Version listings are not streamed, max-keys stays limited.
+	resp, respBody, err = client.do("GET", bucket, "", url.Values{"versions": {""}, "max-keys": {strconv.Itoa(maxStreamList)}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "ListObjectVersions", resp, respBody, http.StatusOK)
+	var listResult struct {
+		MaxKeys       int
+		Versions      []ObjectVersion       `xml:"Version"`
+		DeleteMarkers []DeleteMarkerVersion `xml:"DeleteMarker"`
+	}
+	if err = xml.Unmarshal(respBody, &listResult); err != nil {
+		t.Fatal(err)
+	}
+	if listResult.MaxKeys != maxObjectList {
+		t.Errorf("ListObjectVersions: Expected max-keys %d, got %d", maxObjectList, listResult.MaxKeys)
+	}
+	if len(listResult.Versions) != 2 || listResult.Versions[0].VersionID != versionIDs[1] || listResult.Versions[0].IsLatest {
+		t.Fatalf("ListObjectVersions: Unexpected versions %s", respBody)
+	}
+	if len(listResult.DeleteMarkers) != 1 || !listResult.DeleteMarkers[0].IsLatest {
+		t.Fatalf("ListObjectVersions: Unexpected delete markers %s", respBody)
+	}
+
+	// Deleting the delete marker brings the object back.
+	resp, respBody, err = client.do("DELETE", bucket, "object", url.Values{"versionId": {listResult.DeleteMarkers[0].VersionID}}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "DeleteObject version", resp, respBody, http.StatusNoContent)
+	if resp.Header.Get("X-Amz-Delete-Marker") != "true" {
+		t.Errorf("DeleteObject version: Expected the delete marker to be deleted.")
+	}
+	resp, respBody, err = client.do("GET", bucket, "object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "GetObject restored", resp, respBody, http.StatusOK)
+	if string(respBody) != "version-2" {
+		t.Errorf("GetObject restored: Expected version-2, got %s", respBody)
+	}
+}
+
+func testIntegrationErrors(t *testing.T, client s3TestClient) {
+	bucket := makeIntegrationBucket(t, client)
+
+	resp, respBody, err := client.do("PUT", bucket, "", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "MakeBucket twice", resp, respBody, ErrBucketAlreadyOwnedByYou)
+
+	resp, respBody, err = client.do("GET", getRandomBucketName(), "object", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "GetObject from missing bucket", resp, respBody, ErrNoSuchBucket)
+
+	resp, respBody, err = client.do("PUT", "ab", "", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "MakeBucket with invalid name", resp, respBody, ErrInvalidBucketName)
+
+	badClient := client
+	badClient.secretKey = "invalid-secret-key-invalid-secret-key"
+	resp, respBody, err = badClient.do("GET", bucket, "", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectErrorCode(t, "ListObjects with invalid signature", resp, respBody, ErrSignatureDoesNotMatch)
+
+	resp, respBody, err = client.do("DELETE", bucket, "", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "DeleteBucket", resp, respBody, http.StatusNoContent)
+}
@@ -17,6 +17,7 @@
 package main
 
 import (
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
@@ -28,6 +29,7 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/minio/cli"
 	"github.com/minio/mc/pkg/console"
 )
@@ -40,6 +42,11 @@ var serverCmd = cli.Command{
 			Name:  "address",
 			Value: ":9000",
 		},
+		cli.BoolFlag{
+			Name:  "debug-faults",
+			Usage: "Enable fault injection via admin API, only for testing clients.",
+			Hide:  true,
+		},
 	},
 	Action: serverMain,
 	CustomHelpTemplate: `NAME:
@@ -141,6 +148,145 @@ func initServerConfig(c *cli.Context) {
 		fatalIf(err, "Unable to convert MINIO_MAXCONN=%s environment variable into its integer value.", maxConnStr)
 	}
 
+	// Fetch erasure sets added to the deployment.
+	if setsStr := os.Getenv("MINIO_ERASURE_SETS"); setsStr != "" {
+		globalErasureSets = parseErasureSets(setsStr)
+	}
+
+	// Fetch cold storage tier from environment variables.
+	if coldPathsStr := os.Getenv("MINIO_TIER_COLD_PATHS"); coldPathsStr != "" {
+		globalTierColdPaths = strings.Fields(coldPathsStr)
+	}
+	if demoteAfterStr := os.Getenv("MINIO_TIER_DEMOTE_AFTER"); demoteAfterStr != "" {
+		var err error
+		globalTierDemoteAfter, err = time.ParseDuration(demoteAfterStr)
+		fatalIf(err, "Unable to convert MINIO_TIER_DEMOTE_AFTER=%s environment variable into a duration.", demoteAfterStr)
+	}
+
+	// Scrub XL objects periodically, healing damaged shards.
+	if scrubIntervalStr := os.Getenv("MINIO_SCRUB_INTERVAL"); scrubIntervalStr != "" {
+		var err error
+		globalScrubInterval, err = time.ParseDuration(scrubIntervalStr)
+		fatalIf(err, "Unable to convert MINIO_SCRUB_INTERVAL=%s environment variable into a duration.", scrubIntervalStr)
+	}
+
+	// Verify a sample of XL objects continuously, healing buckets
+	// with too many damaged objects.
+	if samplePercentStr := os.Getenv("MINIO_VERIFY_SAMPLE_PERCENT"); samplePercentStr != "" {
+		samplePercent, err := strconv.ParseFloat(samplePercentStr, 64)
+		if err != nil || samplePercent <= 0 || samplePercent > 100 {
+			fatalIf(errInvalidArgument, "MINIO_VERIFY_SAMPLE_PERCENT=%s has to be a percentage between 0 and 100.", samplePercentStr)
+		}
+		globalVerifySamplePercent = samplePercent
+	}
+	if errorThresholdStr := os.Getenv("MINIO_VERIFY_ERROR_THRESHOLD"); errorThresholdStr != "" {
+		errorThreshold, err := strconv.ParseFloat(errorThresholdStr, 64)
+		if err != nil || errorThreshold < 0 || errorThreshold > 100 {
+			fatalIf(errInvalidArgument, "MINIO_VERIFY_ERROR_THRESHOLD=%s has to be a percentage between 0 and 100.", errorThresholdStr)
+		}
+		globalVerifyErrorThreshold = errorThreshold
+	}
+
+	// Store large single PUTs as parts of the given size.
+	if putPartSize := os.Getenv("MINIO_PUT_PART_SIZE"); putPartSize != "" {
+		partSize, err := humanize.ParseBytes(putPartSize)
+		fatalIf(err, "Unable to parse MINIO_PUT_PART_SIZE=%s environment variable as a size.", putPartSize)
+		if partSize < blockSizeV1 {
+			fatalIf(errInvalidArgument, "MINIO_PUT_PART_SIZE=%s has to be at least %s.", putPartSize, humanize.IBytes(blockSizeV1))
+		}
+		globalPutPartSize = int64(partSize)
+	}
+
+	// Enable dedup of identical objects if requested.
+	globalDedup = os.Getenv("MINIO_DEDUP") == "1"
+
+	// Serve WebDAV frontend if requested.
+	globalWebDAVEnabled = os.Getenv("MINIO_WEBDAV") == "1"
+
+	// Index object metadata for metadata queries if requested.
+	globalMetadataIndexEnabled = os.Getenv("MINIO_METADATA_INDEX") == "1"
+
+	// Name the check denying requests in responses if requested.
+	globalDebugDenials = os.Getenv("MINIO_DEBUG_DENIALS") == "1"
+
+	// Require a checksum on uploads to all buckets, unless configured
+	// otherwise per bucket.
+	globalRequiredChecksum = os.Getenv("MINIO_REQUIRE_CHECKSUM")
+	if !isValidRequiredChecksum(globalRequiredChecksum) {
+		fatalIf(errInvalidArgument, "MINIO_REQUIRE_CHECKSUM=%s has to be one of MD5 or SHA256.", globalRequiredChecksum)
+	}
+
+	// Refuse to start on failed preflight checks if requested.
+	globalPreflightStrict = os.Getenv("MINIO_PREFLIGHT_STRICT") == "1"
+
+	// Answer for healthy peers while disks lack quorum if requested.
+	globalFailoverEnabled = os.Getenv("MINIO_FAILOVER") == "1"
+
+	// Wait for disks mounted or nodes started late.
+	if disksWaitStr := os.Getenv("MINIO_DISKS_WAIT"); disksWaitStr != "" {
+		var err error
+		globalDisksWaitTimeout, err = time.ParseDuration(disksWaitStr)
+		fatalIf(err, "Unable to convert MINIO_DISKS_WAIT=%s environment variable into a duration.", disksWaitStr)
+	}
+
+	// Log name space locks held longer than the given duration.
+	if stuckLockTimeoutStr := os.Getenv("MINIO_STUCK_LOCK_TIMEOUT"); stuckLockTimeoutStr != "" {
+		var err error
+		globalStuckLockTimeout, err = time.ParseDuration(stuckLockTimeoutStr)
+		fatalIf(err, "Unable to convert MINIO_STUCK_LOCK_TIMEOUT=%s environment variable into a duration.", stuckLockTimeoutStr)
+	}
+
+	// Caps on in-memory listing and multipart bookkeeping, 0 is unlimited.
+	if maxTreeWalks := os.Getenv("MINIO_MAX_TREE_WALKS"); maxTreeWalks != "" {
+		var err error
+		globalMaxTreeWalks, err = strconv.Atoi(maxTreeWalks)
+		fatalIf(err, "Unable to convert MINIO_MAX_TREE_WALKS=%s environment variable into its integer value.", maxTreeWalks)
+	}
+	if maxUploads := os.Getenv("MINIO_MAX_UPLOADS_PER_OBJECT"); maxUploads != "" {
+		var err error
+		globalMaxUploadsPerObject, err = strconv.Atoi(maxUploads)
+		fatalIf(err, "Unable to convert MINIO_MAX_UPLOADS_PER_OBJECT=%s environment variable into its integer value.", maxUploads)
+	}
+
+	// Override automatic sizing of erasure workers.
+	if codecWorkers := os.Getenv("MINIO_ERASURE_CODEC_WORKERS"); codecWorkers != "" {
+		var err error
+		globalErasureCodecWorkers, err = strconv.Atoi(codecWorkers)
+		fatalIf(err, "Unable to convert MINIO_ERASURE_CODEC_WORKERS=%s environment variable into its integer value.", codecWorkers)
+	}
+	if ioWorkers := os.Getenv("MINIO_ERASURE_IO_WORKERS"); ioWorkers != "" {
+		var err error
+		globalErasureIOWorkers, err = strconv.Atoi(ioWorkers)
+		fatalIf(err, "Unable to convert MINIO_ERASURE_IO_WORKERS=%s environment variable into its integer value.", ioWorkers)
+	}
+
+	// Blocks of an object read concurrently by a GET.
+	if readConcurrency := os.Getenv("MINIO_ERASURE_READ_CONCURRENCY"); readConcurrency != "" {
+		var err error
+		globalErasureReadConcurrency, err = strconv.Atoi(readConcurrency)
+		fatalIf(err, "Unable to convert MINIO_ERASURE_READ_CONCURRENCY=%s environment variable into its integer value.", readConcurrency)
+	}
+
+	// Override parity blocks of new XL objects, validated against the
+	// number of disks while initializing XL.
+	if parity := os.Getenv("MINIO_ERASURE_PARITY"); parity != "" {
+		var err error
+		globalErasureParity, err = strconv.Atoi(parity)
+		fatalIf(err, "Unable to convert MINIO_ERASURE_PARITY=%s environment variable into its integer value.", parity)
+	}
+
+	// Pin erasure workers and request goroutines to CPUs, experimental.
+	if erasureCPUs := os.Getenv("MINIO_ERASURE_CPUS"); erasureCPUs != "" {
+		var err error
+		globalErasureCPUs, err = parseCPUList(erasureCPUs)
+		fatalIf(err, "Unable to parse MINIO_ERASURE_CPUS=%s environment variable as a CPU list.", erasureCPUs)
+	}
+	if networkCPUs := os.Getenv("MINIO_NETWORK_CPUS"); networkCPUs != "" {
+		var err error
+		globalNetworkCPUs, err = parseCPUList(networkCPUs)
+		fatalIf(err, "Unable to parse MINIO_NETWORK_CPUS=%s environment variable as a CPU list.", networkCPUs)
+	}
+
 	// Fetch access keys from environment variables if any and update the config.
 	accessKey := os.Getenv("MINIO_ACCESS_KEY")
 	secretKey := os.Getenv("MINIO_SECRET_KEY")
@@ -257,6 +403,9 @@ func serverMain(c *cli.Context) {
 	// Initialize server config.
 	initServerConfig(c)
 
+	// Enable fault injection if requested.
+	globalDebugFaults = c.Bool("debug-faults")
+
 	// Server address.
 	serverAddress := c.String("address")
 
@@ -282,6 +431,21 @@ func serverMain(c *cli.Context) {
 		exportPaths: exportPaths,
 	})
 
+	// Verify the environment before serving traffic.
+	runPreflightChecks(exportPaths)
+
+	// Serve the certificate through the cert store so it can be
+	// replaced on reload.
+	if isSSL() {
+		cert, err := loadCertificate()
+		fatalIf(err, "Unable to load certificate.")
+		globalCerts.Set(cert)
+		apiServer.TLSConfig = &tls.Config{GetCertificate: globalCerts.GetCertificate}
+	}
+
+	// Reload config on SIGHUP without dropping connections.
+	go reloadConfigOnSIGHUP()
+
 	// Credential.
 	cred := serverConfig.GetCredential()
 
@@ -324,7 +488,8 @@ func serverMain(c *cli.Context) {
 	var err error
 	// Configure TLS if certs are available.
 	if isSSL() {
-		err = apiServer.ListenAndServeTLS(mustGetCertFile(), mustGetKeyFile())
+		// Certificate is served by TLSConfig.
+		err = apiServer.ListenAndServeTLS("", "")
 	} else {
 		// Fallback to http.
 		err = apiServer.ListenAndServe()
@@ -0,0 +1,168 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/minio/mc/pkg/console"
+	"github.com/minio/minio/pkg/disk"
+)
+
+const (
+	// Disk round trip of a small write and read back, slower disks
+	// are usually failing or heavily overloaded.
+	preflightMaxDiskLatency = 1 * time.Second
+	// Size of the preflight disk probe.
+	preflightDiskProbeSize = 4 * 1024 // 4KiB.
+	// Minimum percentage of free inodes.
+	preflightMinFreeInodesPercent = 5
+	// Minimum limit of open files.
+	preflightMinOpenFiles = 4096
+)
+
+// isLocalExportPath - returns true if export path is on this node.
+func isLocalExportPath(exportPath string) bool {
+	return !strings.ContainsRune(exportPath, ':') || filepath.VolumeName(exportPath) != ""
+}
+
+// checkPreflightDiskLatency - writes and reads back a small probe,
+// verifying the disk is usable and responsive.
+func checkPreflightDiskLatency(exportPath string) error {
+	probeDir := filepath.Join(exportPath, minioMetaBucket, tmpMetaPrefix)
+	if err := os.MkdirAll(probeDir, 0700); err != nil {
+		return fmt.Errorf("Disk %s is not writable (%s), verify the permissions of the export path.", exportPath, err)
+	}
+	probePath := filepath.Join(probeDir, "preflight-"+getUUID())
+	defer os.Remove(probePath)
+
+	probe := bytes.Repeat([]byte("a"), preflightDiskProbeSize)
+	start := time.Now()
+	if err := ioutil.WriteFile(probePath, probe, 0600); err != nil {
+		return fmt.Errorf("Disk %s is not writable (%s), verify the permissions of the export path.", exportPath, err)
+	}
+	buf, err := ioutil.ReadFile(probePath)
+	if err != nil {
+		return fmt.Errorf("Disk %s is not readable (%s), verify the permissions of the export path.", exportPath, err)
+	}
+	if !bytes.Equal(buf, probe) {
+		return fmt.Errorf("Disk %s returned corrupted data, verify the disk is healthy.", exportPath)
+	}
+	if latency := time.Since(start); latency > preflightMaxDiskLatency {
+		return fmt.Errorf("Disk %s took %s for a %d byte write and read, verify the disk is healthy and not overloaded.", exportPath, latency, preflightDiskProbeSize)
+	}
+	return nil
+}
+
+// checkPreflightDiskSpace - verifies free space and inodes are above
+// the thresholds.
+func checkPreflightDiskSpace(exportPath string) error {
+	if err := checkDiskFree(exportPath, fsMinSpacePercent); err != nil {
+		if err == errDiskFull {
+			return fmt.Errorf("Disk %s has less than %d%% free space, free up space or add more disks.", exportPath, fsMinSpacePercent)
+		}
+		return fmt.Errorf("Unable to get disk info of %s (%s), verify the export path exists.", exportPath, err)
+	}
+	di, err := disk.GetInfo(exportPath)
+	if err != nil {
+		return fmt.Errorf("Unable to get disk info of %s (%s), verify the export path exists.", exportPath, err)
+	}
+	// Inodes are not reported by all filesystems.
+	if di.Files > 0 && di.Ffree*100/di.Files < preflightMinFreeInodesPercent {
+		return fmt.Errorf("Disk %s has less than %d%% free inodes, remove unused files or reformat with more inodes.", exportPath, preflightMinFreeInodesPercent)
+	}
+	return nil
+}
+
+// checkPreflightOpenFiles - verifies limit of open files is high
+// enough for a server.
+func checkPreflightOpenFiles() error {
+	maxOpenFiles, err := getMaxOpenFiles()
+	if err != nil {
+		return fmt.Errorf("Unable to get limit of open files (%s).", err)
+	}
+	// 0 indicates there is no limit.
+	if maxOpenFiles > 0 && maxOpenFiles < preflightMinOpenFiles {
+		return fmt.Errorf("Limit of open files is %d, raise it to at least %d with 'ulimit -n' or in /etc/security/limits.conf.", maxOpenFiles, preflightMinOpenFiles)
+	}
+	return nil
+}
+
+// checkPreflightClockSkew - verifies clock skew with all reachable
+// peers is within the tolerance of request signatures, peers which are
+// not yet up are skipped.
+func checkPreflightClockSkew() []error {
+	if len(globalPeers) == 0 {
+		return nil
+	}
+	token, err := newPeerToken(serverConfig.GetCredential())
+	if err != nil {
+		return []error{fmt.Errorf("Unable to generate peer token (%s).", err)}
+	}
+	var errs []error
+	for _, peer := range globalPeers {
+		skew, err := getPeerClockSkew(peer, token)
+		if err != nil {
+			continue
+		}
+		if globalClockSkew.update(peer.addr, skew) {
+			errs = append(errs, fmt.Errorf("Clock of peer %s is skewed by %s, synchronize clocks of all nodes with NTP.", peer.addr, skew))
+		}
+	}
+	return errs
+}
+
+// preflightChecks - returns all problems found with this node.
+func preflightChecks(exportPaths []string) []error {
+	var errs []error
+	for _, exportPath := range exportPaths {
+		if !isLocalExportPath(exportPath) {
+			continue
+		}
+		if err := checkPreflightDiskLatency(exportPath); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := checkPreflightDiskSpace(exportPath); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := checkPreflightOpenFiles(); err != nil {
+		errs = append(errs, err)
+	}
+	return append(errs, checkPreflightClockSkew()...)
+}
+
+// runPreflightChecks - verifies the environment before serving traffic,
+// problems are printed as warnings, the server refuses to start on any
+// problem if started with MINIO_PREFLIGHT_STRICT=1.
+func runPreflightChecks(exportPaths []string) {
+	errs := preflightChecks(exportPaths)
+	for _, err := range errs {
+		console.Println(colorYellow("Preflight: ") + err.Error())
+	}
+	if len(errs) > 0 && globalPreflightStrict {
+		fatalIf(errors.New("Preflight checks failed."), "Refusing to start, fix the problems above or unset MINIO_PREFLIGHT_STRICT.")
+	}
+}
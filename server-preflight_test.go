@@ -0,0 +1,77 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tests preflight disk checks.
+func TestPreflightDiskChecks(t *testing.T) {
+	root, err := ioutil.TempDir("", "minio-")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir. %s", err)
+	}
+	defer removeAll(root)
+
+	// A file in place of the export path is not writable.
+	filePath := filepath.Join(root, "file")
+	if err = ioutil.WriteFile(filePath, []byte("hello"), 0600); err != nil {
+		t.Fatalf("Unable to create file. %s", err)
+	}
+
+	testCases := []struct {
+		exportPath string
+		shouldPass bool
+	}{
+		// Test case - 1.
+		// Healthy export path.
+		{root, true},
+		// Test case - 2.
+		// Export path which is not a directory.
+		{filePath, false},
+	}
+	for i, testCase := range testCases {
+		err = checkPreflightDiskLatency(testCase.exportPath)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+	}
+
+	// Free space of the temp dir is expected to be sufficient.
+	if err = checkPreflightDiskSpace(root); err != nil {
+		t.Errorf("Expected disk space check to pass, failed with %s", err)
+	}
+	if err = checkPreflightDiskSpace(filepath.Join(root, "does-not-exist")); err == nil {
+		t.Errorf("Expected disk space check of missing path to fail")
+	}
+
+	// Probe is always removed.
+	entries, err := ioutil.ReadDir(filepath.Join(root, minioMetaBucket, tmpMetaPrefix))
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatalf("Unable to read probe dir. %s", err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected probe to be removed, found %d entries", len(entries))
+	}
+}
@@ -43,3 +43,12 @@ func setMaxOpenFiles() error {
 	}
 	return nil
 }
+
+// getMaxOpenFiles - returns current limit of open files.
+func getMaxOpenFiles() (uint64, error) {
+	var rLimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rLimit); err != nil {
+		return 0, err
+	}
+	return uint64(rLimit.Cur), nil
+}
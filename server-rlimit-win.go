@@ -24,3 +24,8 @@ func setMaxOpenFiles() error {
 	// (well, you do but it is based on your resources like memory).
 	return nil
 }
+
+// getMaxOpenFiles - returns 0 as there is no limit on open files.
+func getMaxOpenFiles() (uint64, error) {
+	return 0, nil
+}
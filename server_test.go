@@ -554,6 +554,38 @@ func (s *MyAPISuite) TestPutObject(c *C) {
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 }
 
+// Tests storage classes are denied on backends without tiering.
+func (s *MyAPISuite) TestPutObjectStorageClass(c *C) {
+	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/put-object-storage-class",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer1 := bytes.NewReader([]byte("hello world"))
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/put-object-storage-class/object",
+		int64(buffer1.Len()), buffer1, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Storage-Class", "STANDARD")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer2 := bytes.NewReader([]byte("hello world"))
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/put-object-storage-class/object",
+		int64(buffer2.Len()), buffer2, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Storage-Class", "STANDARD_IA")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NotImplemented", "A header you provided implies functionality that is not implemented.", http.StatusNotImplemented)
+}
+
 func (s *MyAPISuite) TestListBuckets(c *C) {
 	request, err := newTestRequest("GET", s.testServer.Server.URL+"/",
 		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
@@ -1580,3 +1612,168 @@ func (s *MyAPISuite) TestObjectMultipart(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 }
+
+func (s *MyAPISuite) TestCopyObjectPart(c *C) {
+	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/copyobjectpart",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("hello world, copy me"))
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/copyobjectpart/source",
+		int64(buffer.Len()), buffer, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = newTestRequest("POST", s.testServer.Server.URL+"/copyobjectpart/object?uploads",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	newResponse := &InitiateMultipartUploadResponse{}
+	err = xml.NewDecoder(response.Body).Decode(newResponse)
+	c.Assert(err, IsNil)
+	uploadID := newResponse.UploadID
+
+	// Copy an invalid range of the source.
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/copyobjectpart/object?uploadId="+uploadID+"&partNumber=1",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Copy-Source", "/copyobjectpart/source")
+	request.Header.Set("X-Amz-Copy-Source-Range", "bytes=100-200")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidRange", "The requested range cannot be satisfied.", http.StatusRequestedRangeNotSatisfiable)
+
+	// Copy "world" from the source as the only part.
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/copyobjectpart/object?uploadId="+uploadID+"&partNumber=1",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Copy-Source", "/copyobjectpart/source")
+	request.Header.Set("X-Amz-Copy-Source-Range", "bytes=6-10")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	copyPartResponse := &CopyObjectPartResponse{}
+	err = xml.NewDecoder(response.Body).Decode(copyPartResponse)
+	c.Assert(err, IsNil)
+
+	completeBytes, err := xml.Marshal(&completeMultipartUpload{
+		Parts: []completePart{{PartNumber: 1, ETag: copyPartResponse.ETag}},
+	})
+	c.Assert(err, IsNil)
+	request, err = newTestRequest("POST", s.testServer.Server.URL+"/copyobjectpart/object?uploadId="+uploadID,
+		int64(len(completeBytes)), bytes.NewReader(completeBytes), s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = newTestRequest("GET", s.testServer.Server.URL+"/copyobjectpart/object",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	object, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(object), Equals, "world")
+}
+
+func (s *MyAPISuite) TestAnonymousObjectMultipart(c *C) {
+	request, err := newTestRequest("PUT", s.testServer.Server.URL+"/anonymousmultiparts",
+		0, nil, s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// Anonymous multipart is denied without a bucket policy.
+	request, err = http.NewRequest("POST", s.testServer.Server.URL+"/anonymousmultiparts/object?uploads", nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusForbidden)
+
+	// Allow anonymous uploads.
+	bucketPolicyBuf := `{
+    "Version": "2012-10-17",
+    "Statement": [
+        {
+            "Action": [
+                "s3:PutObject",
+                "s3:AbortMultipartUpload",
+                "s3:ListMultipartUploadParts"
+            ],
+            "Effect": "Allow",
+            "Principal": {
+                "AWS": [
+                    "*"
+                ]
+            },
+            "Resource": [
+                "arn:aws:s3:::anonymousmultiparts/*"
+            ]
+        }
+    ]
+}`
+	request, err = newTestRequest("PUT", s.testServer.Server.URL+"/anonymousmultiparts?policy",
+		int64(len(bucketPolicyBuf)), bytes.NewReader([]byte(bucketPolicyBuf)), s.testServer.AccessKey, s.testServer.SecretKey)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+
+	request, err = http.NewRequest("POST", s.testServer.Server.URL+"/anonymousmultiparts/object?uploads", nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	decoder := xml.NewDecoder(response.Body)
+	newResponse := &InitiateMultipartUploadResponse{}
+	err = decoder.Decode(newResponse)
+	c.Assert(err, IsNil)
+	uploadID := newResponse.UploadID
+
+	data := []byte("0")
+	request, err = http.NewRequest("PUT", s.testServer.Server.URL+"/anonymousmultiparts/object?uploadId="+uploadID+"&partNumber=1", bytes.NewReader(data))
+	c.Assert(err, IsNil)
+	response1, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response1.StatusCode, Equals, http.StatusOK)
+
+	request, err = http.NewRequest("GET", s.testServer.Server.URL+"/anonymousmultiparts/object?uploadId="+uploadID, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	completeUploads := &completeMultipartUpload{
+		Parts: []completePart{
+			{
+				PartNumber: 1,
+				ETag:       response1.Header.Get("ETag"),
+			},
+		},
+	}
+	completeBytes, err := xml.Marshal(completeUploads)
+	c.Assert(err, IsNil)
+
+	request, err = http.NewRequest("POST", s.testServer.Server.URL+"/anonymousmultiparts/object?uploadId="+uploadID, bytes.NewReader(completeBytes))
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+}
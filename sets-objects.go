@@ -0,0 +1,1007 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Separates erasure sets in MINIO_ERASURE_SETS, disks of a set are
+// separated by whitespace.
+const erasureSetsSeparator = ","
+
+// XL format version of disks which belong to a deployment of several
+// erasure sets, older releases refuse to start with these instead of
+// serving objects of one set only.
+const xlFormatVersionSets = "2"
+
+// setsObjects - object layer spreading objects over erasure sets, the
+// first set being the deployment before it was expanded. New objects
+// are placed on the set owning their name on the hash ring. Lookups
+// go to that set first and fall back to all other sets, so objects
+// written before an expansion stay reachable until rebalanced onto
+// their set. Multipart uploads live on the set of their object when
+// initiated.
+type setsObjects struct {
+	sets []ObjectLayer
+	ring hashRing
+
+	// Objects are write locked while moved between sets, writes of
+	// clients read lock them.
+	moveMutex *nsLockMap
+}
+
+// parseErasureSets - parses disks of each erasure set.
+func parseErasureSets(setsStr string) (setPaths [][]string) {
+	for _, setStr := range strings.Split(setsStr, erasureSetsSeparator) {
+		if paths := strings.Fields(setStr); len(paths) > 0 {
+			setPaths = append(setPaths, paths)
+		}
+	}
+	return setPaths
+}
+
+// newSetsObjects - initialize XL object layers of all erasure sets and
+// save the sets of the deployment in their `format.json`.
+func newSetsObjects(setPaths [][]string) (ObjectLayer, error) {
+	sets := make([]ObjectLayer, len(setPaths))
+	setDisks := make([][]StorageAPI, len(setPaths))
+	var diskCount int
+	for index, paths := range setPaths {
+		if len(paths) == 1 {
+			return nil, fmt.Errorf("Erasure set %d has a single disk, erasure sets need at least %d disks.", index+1, minErasureBlocks)
+		}
+		objAPI, err := newExportObjectLayer(paths)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to initialize erasure set %d, %s", index+1, err)
+		}
+		xl := objAPI.(xlObjects)
+		sets[index] = xl
+		setDisks[index] = xl.storageDisks
+		diskCount += len(xl.storageDisks)
+	}
+	if err := formatSets(setDisks); err != nil {
+		return nil, err
+	}
+
+	// Size erasure workers for the disks of all sets.
+	initErasureWorkers(diskCount)
+
+	s := setsObjects{
+		sets:      sets,
+		ring:      newHashRing(len(sets)),
+		moveMutex: newNSLockMap(),
+	}
+	// Buckets made before the expansion are made on the added sets.
+	buckets, err := s.ListBuckets()
+	if err != nil {
+		return nil, err
+	}
+	for _, bucket := range buckets {
+		for _, set := range s.sets[1:] {
+			if err = set.MakeBucket(bucket.Name); err != nil {
+				if _, ok := err.(BucketExists); !ok {
+					return nil, err
+				}
+			}
+		}
+	}
+	return s, nil
+}
+
+// formatSets - verifies erasure sets are given in the order of the
+// deployment and saves uuids of added sets in `format.json` of all
+// disks. Disks which cannot be read are updated on a later start. A
+// single set which was never expanded keeps its format.
+func formatSets(setDisks [][]StorageAPI) error {
+	formats := make([][]*formatConfigV1, len(setDisks))
+	setUUIDs := make([]string, len(setDisks))
+	var savedSets []string
+	for index, disks := range setDisks {
+		formats[index] = make([]*formatConfigV1, len(disks))
+		for diskIndex, disk := range disks {
+			format, err := loadFormat(disk)
+			if err != nil {
+				continue
+			}
+			formats[index][diskIndex] = format
+			if format.XL.Set == "" {
+				continue
+			}
+			if setUUIDs[index] != "" && setUUIDs[index] != format.XL.Set {
+				return fmt.Errorf("Disks of erasure set %d belong to different erasure sets.", index+1)
+			}
+			setUUIDs[index] = format.XL.Set
+			// Disks offline while sets were added miss the latest sets.
+			if len(format.XL.Sets) > len(savedSets) {
+				savedSets = format.XL.Sets
+			}
+		}
+	}
+	for _, disksFormats := range formats {
+		for _, format := range disksFormats {
+			if format == nil || len(format.XL.Sets) == 0 {
+				continue
+			}
+			if strings.Join(format.XL.Sets, ".") != strings.Join(savedSets[:len(format.XL.Sets)], ".") {
+				return errors.New("Inconsistent erasure sets found.")
+			}
+		}
+	}
+
+	if len(setDisks) < len(savedSets) {
+		return fmt.Errorf("Deployment has %d erasure sets, only %d given, add the missing sets to MINIO_ERASURE_SETS.", len(savedSets), len(setDisks))
+	}
+	if len(setDisks) == 1 {
+		return nil
+	}
+	for index, setUUID := range savedSets {
+		if setUUIDs[index] != setUUID {
+			return fmt.Errorf("Erasure set %d is not set %d of the deployment, erasure sets have to be given in the order they were added.", index+1, index+1)
+		}
+	}
+	for index := len(savedSets); index < len(setDisks); index++ {
+		if setUUIDs[index] != "" {
+			return fmt.Errorf("Erasure set %d belongs to another deployment.", index+1)
+		}
+		setUUIDs[index] = getUUID()
+	}
+
+	// Save sets on all disks which do not have them yet.
+	for index, disks := range setDisks {
+		for diskIndex, disk := range disks {
+			format := formats[index][diskIndex]
+			if format == nil {
+				continue
+			}
+			if format.XL.Set == setUUIDs[index] && strings.Join(format.XL.Sets, ".") == strings.Join(setUUIDs, ".") {
+				continue
+			}
+			format.XL.Version = xlFormatVersionSets
+			format.XL.Set = setUUIDs[index]
+			format.XL.Sets = setUUIDs
+			if err := saveFormatXL([]StorageAPI{disk}, []*formatConfigV1{format}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getSetIndex - returns index of the set an object is placed on.
+// Adding a set only moves objects onto the added set, objects placed
+// on the other sets stay where they are.
+func (s setsObjects) getSetIndex(bucket, object string) int {
+	return s.ring.getIndex(hashRingKey(pathJoin(bucket, object)))
+}
+
+// Virtual nodes of each set on the hash ring, more virtual nodes
+// spread objects more evenly over sets.
+const setVirtualNodes = 256
+
+// ringNode - virtual node of a set on the hash ring.
+type ringNode struct {
+	key   uint64
+	index int
+}
+
+// byRingNodeKey - sorts ring nodes by key, nodes of the same key by
+// set index.
+type byRingNodeKey []ringNode
+
+func (n byRingNodeKey) Len() int      { return len(n) }
+func (n byRingNodeKey) Swap(i, j int) { n[i], n[j] = n[j], n[i] }
+func (n byRingNodeKey) Less(i, j int) bool {
+	if n[i].key == n[j].key {
+		return n[i].index < n[j].index
+	}
+	return n[i].key < n[j].key
+}
+
+// hashRing - consistent hash ring of sets, a name belongs to the set
+// of the first virtual node at or after its key. Virtual nodes of a
+// set only depend on its index, so the ring of the same sets is the
+// same on every node and start, and an added set only takes names
+// from the other sets.
+type hashRing []ringNode
+
+// newHashRing - returns the hash ring of count sets.
+func newHashRing(count int) hashRing {
+	ring := make(hashRing, 0, count*setVirtualNodes)
+	for index := 0; index < count; index++ {
+		for node := 0; node < setVirtualNodes; node++ {
+			key := hashRingKey("set" + strconv.Itoa(index) + "/" + strconv.Itoa(node))
+			ring = append(ring, ringNode{key, index})
+		}
+	}
+	sort.Sort(byRingNodeKey(ring))
+	return ring
+}
+
+// hashRingKey - returns key of name on the hash ring.
+func hashRingKey(name string) uint64 {
+	return binary.BigEndian.Uint64(sum256([]byte(name)))
+}
+
+// getIndex - returns index of the set key belongs to.
+func (r hashRing) getIndex(key uint64) int {
+	i := sort.Search(len(r), func(i int) bool { return r[i].key >= key })
+	if i == len(r) {
+		i = 0
+	}
+	return r[i].index
+}
+
+// getLookupOrder - returns indexes of all sets, starting with the set
+// the object is placed on.
+func (s setsObjects) getLookupOrder(bucket, object string) []int {
+	order := []int{s.getSetIndex(bucket, object)}
+	for index := range s.sets {
+		if index != order[0] {
+			order = append(order, index)
+		}
+	}
+	return order
+}
+
+// deleteFromOtherSets - removes any older copy of the object from all
+// sets except the set at keep.
+func (s setsObjects) deleteFromOtherSets(bucket, object string, keep int) {
+	for index, set := range s.sets {
+		if index != keep {
+			set.DeleteObject(bucket, object)
+		}
+	}
+}
+
+// newStagingFile - creates a temporary file staging objects copied
+// between sets or tiers. Objects of the same name share their
+// namespace lock on all sets and tiers, an object cannot be streamed
+// from one while written to another.
+func newStagingFile() (*os.File, error) {
+	return ioutil.TempFile("", "minio-sets-")
+}
+
+// removeStagingFile - closes and removes a staging file.
+func removeStagingFile(stagingFile *os.File) {
+	stagingFile.Close()
+	os.Remove(stagingFile.Name())
+}
+
+/// Storage operations
+
+// Capabilities - returns features supported by all sets.
+func (s setsObjects) Capabilities() BackendCapabilities {
+	capabilities := s.sets[0].Capabilities()
+	// Versions would be left behind by objects moving between sets.
+	capabilities.Versioning = false
+	return capabilities
+}
+
+// StorageInfo - returns combined storage info of all sets.
+func (s setsObjects) StorageInfo() StorageInfo {
+	var info StorageInfo
+	for _, set := range s.sets {
+		setInfo := set.StorageInfo()
+		info.Total += setInfo.Total
+		info.Free += setInfo.Free
+	}
+	return info
+}
+
+/// Bucket operations
+
+// MakeBucket - make a bucket on all sets.
+func (s setsObjects) MakeBucket(bucket string) error {
+	for index, set := range s.sets {
+		if err := set.MakeBucket(bucket); err != nil {
+			// Undo bucket on previous sets.
+			for _, prevSet := range s.sets[:index] {
+				prevSet.DeleteBucket(bucket)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// GetBucketInfo - returns bucket info from first set.
+func (s setsObjects) GetBucketInfo(bucket string) (BucketInfo, error) {
+	return s.sets[0].GetBucketInfo(bucket)
+}
+
+// ListBuckets - lists buckets from first set.
+func (s setsObjects) ListBuckets() ([]BucketInfo, error) {
+	return s.sets[0].ListBuckets()
+}
+
+// DeleteBucket - deletes a bucket on all sets, bucket must be empty
+// on all.
+func (s setsObjects) DeleteBucket(bucket string) error {
+	for _, set := range s.sets[1:] {
+		result, err := set.ListObjects(bucket, "", "", "", 1)
+		if err != nil {
+			if _, ok := err.(BucketNotFound); !ok {
+				return err
+			}
+		} else if len(result.Objects) > 0 || len(result.Prefixes) > 0 {
+			return BucketNotEmpty{Bucket: bucket}
+		}
+	}
+	if err := s.sets[0].DeleteBucket(bucket); err != nil {
+		return err
+	}
+	for _, set := range s.sets[1:] {
+		if err := set.DeleteBucket(bucket); err != nil {
+			if _, ok := err.(BucketNotFound); !ok {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EraseBucket - erases a bucket on all sets.
+func (s setsObjects) EraseBucket(bucket string, overwrite bool) error {
+	if err := s.sets[0].EraseBucket(bucket, overwrite); err != nil {
+		return err
+	}
+	for _, set := range s.sets[1:] {
+		if err := set.EraseBucket(bucket, overwrite); err != nil {
+			if _, ok := err.(BucketNotFound); !ok {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ListObjects - lists objects from all sets merged in lexical order.
+func (s setsObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	var merged ListObjectsInfo
+	for index, set := range s.sets {
+		result, err := set.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+		if err != nil {
+			return ListObjectsInfo{}, err
+		}
+		if index == 0 {
+			merged = result
+			continue
+		}
+		merged = mergeListObjectsInfo(merged, result, maxKeys)
+	}
+	return merged, nil
+}
+
+// ListObjectsV2 - lists objects from all sets after the continuation
+// token, or after startAfter for the first page.
+func (s setsObjects) ListObjectsV2(bucket, prefix, continuationToken, delimiter string, maxKeys int, startAfter string) (ListObjectsV2Info, error) {
+	return listObjectsV2(s.ListObjects, bucket, prefix, continuationToken, delimiter, maxKeys, startAfter)
+}
+
+/// Bucket snapshot operations, snapshots span all sets.
+
+// SnapshotBucket - snapshot a bucket on all sets with the same id.
+func (s setsObjects) SnapshotBucket(bucket, snapshotID string) (BucketSnapshotInfo, error) {
+	snapshotInfo, err := s.sets[0].SnapshotBucket(bucket, snapshotID)
+	if err != nil {
+		return BucketSnapshotInfo{}, err
+	}
+	for index, set := range s.sets[1:] {
+		if _, err = set.SnapshotBucket(bucket, snapshotID); err != nil {
+			// Undo snapshot on previous sets.
+			for _, prevSet := range s.sets[:index+1] {
+				prevSet.DeleteBucketSnapshot(bucket, snapshotID)
+			}
+			return BucketSnapshotInfo{}, err
+		}
+	}
+	return snapshotInfo, nil
+}
+
+// ListBucketSnapshots - lists snapshots from first set.
+func (s setsObjects) ListBucketSnapshots(bucket string) ([]BucketSnapshotInfo, error) {
+	return s.sets[0].ListBucketSnapshots(bucket)
+}
+
+// CloneBucketSnapshot - clones the snapshot on all sets.
+func (s setsObjects) CloneBucketSnapshot(bucket, snapshotID, cloneBucket string) error {
+	// Clones on previous sets are kept on failure, objects of the
+	// remaining sets are missing in the clone in that case.
+	for _, set := range s.sets {
+		if err := set.CloneBucketSnapshot(bucket, snapshotID, cloneBucket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteBucketSnapshot - deletes the snapshot on all sets.
+func (s setsObjects) DeleteBucketSnapshot(bucket, snapshotID string) error {
+	for _, set := range s.sets {
+		if err := set.DeleteBucketSnapshot(bucket, snapshotID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/// Object operations
+
+// getObjectSet - returns index of the set on which the object lives.
+func (s setsObjects) getObjectSet(bucket, object string) (int, ObjectInfo, error) {
+	var err error
+	for _, index := range s.getLookupOrder(bucket, object) {
+		var objInfo ObjectInfo
+		objInfo, err = s.sets[index].GetObjectInfo(bucket, object)
+		if err == nil {
+			return index, objInfo, nil
+		}
+		if _, ok := err.(ObjectNotFound); !ok {
+			return -1, ObjectInfo{}, err
+		}
+	}
+	return -1, ObjectInfo{}, err
+}
+
+// GetObject - reads an object from the set it lives on.
+func (s setsObjects) GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer) error {
+	index, _, err := s.getObjectSet(bucket, object)
+	if err != nil {
+		return err
+	}
+	return s.sets[index].GetObject(bucket, object, startOffset, length, writer)
+}
+
+// GetObjectInfo - returns object info from the set it lives on.
+func (s setsObjects) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
+	_, objInfo, err := s.getObjectSet(bucket, object)
+	return objInfo, err
+}
+
+// PutObject - places the object on its set, any older copy on other
+// sets is removed.
+func (s setsObjects) PutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string) (string, error) {
+	s.moveMutex.RLock(bucket, object)
+	defer s.moveMutex.RUnlock(bucket, object)
+
+	target := s.getSetIndex(bucket, object)
+	md5Sum, err := s.sets[target].PutObject(bucket, object, size, data, metadata)
+	if err != nil {
+		return "", err
+	}
+	s.deleteFromOtherSets(bucket, object, target)
+	return md5Sum, nil
+}
+
+// PatchObject - patches the object on the set it lives on.
+func (s setsObjects) PatchObject(bucket, object string, offset, size int64, data io.Reader) (string, error) {
+	s.moveMutex.RLock(bucket, object)
+	defer s.moveMutex.RUnlock(bucket, object)
+
+	index, _, err := s.getObjectSet(bucket, object)
+	if err != nil {
+		return "", err
+	}
+	return s.sets[index].PatchObject(bucket, object, offset, size, data)
+}
+
+// ComposeObject - composes the object on the set all sources live on,
+// sources on different sets are copied into the object on its set.
+// Any older copy on other sets is removed.
+func (s setsObjects) ComposeObject(bucket, object string, sources []string, metadata map[string]string) (string, error) {
+	s.moveMutex.RLock(bucket, object)
+	defer s.moveMutex.RUnlock(bucket, object)
+
+	if len(sources) == 0 {
+		return "", errInvalidArgument
+	}
+	target := -1
+	var mixed bool
+	var size int64
+	sourceSets := make([]int, len(sources))
+	sourceInfos := make([]ObjectInfo, len(sources))
+	for index, source := range sources {
+		set, objInfo, err := s.getObjectSet(bucket, source)
+		if err != nil {
+			return "", err
+		}
+		if target != -1 && set != target {
+			mixed = true
+		}
+		target = set
+		size += objInfo.Size
+		sourceSets[index], sourceInfos[index] = set, objInfo
+	}
+	if mixed {
+		target = s.getSetIndex(bucket, object)
+	}
+
+	var md5Sum string
+	var err error
+	if !mixed {
+		md5Sum, err = s.sets[target].ComposeObject(bucket, object, sources, metadata)
+	} else {
+		var stagingFile *os.File
+		if stagingFile, err = newStagingFile(); err != nil {
+			return "", err
+		}
+		defer removeStagingFile(stagingFile)
+		for index, source := range sources {
+			if err = s.sets[sourceSets[index]].GetObject(bucket, source, 0, sourceInfos[index].Size, stagingFile); err != nil {
+				return "", err
+			}
+		}
+		if _, err = stagingFile.Seek(0, 0); err != nil {
+			return "", err
+		}
+		md5Sum, err = s.sets[target].PutObject(bucket, object, size, stagingFile, metadata)
+	}
+	if err != nil {
+		return "", err
+	}
+	s.deleteFromOtherSets(bucket, object, target)
+	return md5Sum, nil
+}
+
+// CopyObject - copies the object on the set the source lives on.
+// Any older copy on other sets is removed.
+func (s setsObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string, metadata map[string]string) (string, error) {
+	s.moveMutex.RLock(dstBucket, dstObject)
+	defer s.moveMutex.RUnlock(dstBucket, dstObject)
+
+	target, _, err := s.getObjectSet(srcBucket, srcObject)
+	if err != nil {
+		return "", err
+	}
+	md5Sum, err := s.sets[target].CopyObject(srcBucket, srcObject, dstBucket, dstObject, metadata)
+	if err != nil {
+		return "", err
+	}
+	s.deleteFromOtherSets(dstBucket, dstObject, target)
+	return md5Sum, nil
+}
+
+// DeleteObject - deletes the object from all sets.
+func (s setsObjects) DeleteObject(bucket, object string) (err error) {
+	s.moveMutex.RLock(bucket, object)
+	defer s.moveMutex.RUnlock(bucket, object)
+
+	var deleted bool
+	for _, set := range s.sets {
+		setErr := set.DeleteObject(bucket, object)
+		if setErr == nil {
+			deleted = true
+			continue
+		}
+		if _, ok := setErr.(ObjectNotFound); !ok || err == nil {
+			err = setErr
+		}
+	}
+	if deleted {
+		return nil
+	}
+	return err
+}
+
+// DeleteObjects - deletes a batch of objects from all sets
+// concurrently, each object is deleted as by DeleteObject.
+func (s setsObjects) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	if _, err := s.GetBucketInfo(bucket); err != nil {
+		return nil, err
+	}
+	return deleteObjects(s.DeleteObject, bucket, objects), nil
+}
+
+// PutObjectTags - sets tags of the object on the set it lives on.
+func (s setsObjects) PutObjectTags(bucket, object string, tags map[string]string) error {
+	s.moveMutex.RLock(bucket, object)
+	defer s.moveMutex.RUnlock(bucket, object)
+
+	index, _, err := s.getObjectSet(bucket, object)
+	if err != nil {
+		return err
+	}
+	return s.sets[index].PutObjectTags(bucket, object, tags)
+}
+
+// PutObjectRetention - sets retention of the object on the set it
+// lives on.
+func (s setsObjects) PutObjectRetention(bucket, object string, retention objectRetention) error {
+	s.moveMutex.RLock(bucket, object)
+	defer s.moveMutex.RUnlock(bucket, object)
+
+	index, _, err := s.getObjectSet(bucket, object)
+	if err != nil {
+		return err
+	}
+	return s.sets[index].PutObjectRetention(bucket, object, retention)
+}
+
+/// Object version operations, not supported since versions would not
+/// follow objects moving between sets.
+
+// GetObjectVersion - versioning is not supported.
+func (s setsObjects) GetObjectVersion(bucket, object, versionID string, startOffset int64, length int64, writer io.Writer) error {
+	return VersioningNotSupported{}
+}
+
+// GetObjectVersionInfo - versioning is not supported.
+func (s setsObjects) GetObjectVersionInfo(bucket, object, versionID string) (ObjectInfo, error) {
+	return ObjectInfo{}, VersioningNotSupported{}
+}
+
+// DeleteObjectVersion - versioning is not supported.
+func (s setsObjects) DeleteObjectVersion(bucket, object, versionID string) error {
+	return VersioningNotSupported{}
+}
+
+// ListObjectVersions - versioning is not supported.
+func (s setsObjects) ListObjectVersions(bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int) (ListObjectVersionsInfo, error) {
+	return ListObjectVersionsInfo{}, VersioningNotSupported{}
+}
+
+/// Multipart operations
+
+// ListMultipartUploads - lists multipart uploads of all sets merged in
+// lexical order.
+func (s setsObjects) ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (ListMultipartsInfo, error) {
+	var merged ListMultipartsInfo
+	for index, set := range s.sets {
+		result, err := set.ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter, maxUploads)
+		if err != nil {
+			return ListMultipartsInfo{}, err
+		}
+		if index == 0 {
+			merged = result
+			continue
+		}
+		merged = mergeListMultipartsInfo(merged, result, maxUploads)
+	}
+	return merged, nil
+}
+
+// uploadEntry - an upload or common prefix of a listing of uploads,
+// prefixes have no upload id.
+type uploadEntry struct {
+	upload   uploadMetadata
+	isPrefix bool
+}
+
+// lessUploadEntry - returns true if a is listed before b.
+func lessUploadEntry(a, b uploadMetadata) bool {
+	if a.Object != b.Object {
+		return a.Object < b.Object
+	}
+	return a.UploadID < b.UploadID
+}
+
+// byUploadEntry - sorts upload entries in listing order.
+type byUploadEntry []uploadEntry
+
+func (e byUploadEntry) Len() int           { return len(e) }
+func (e byUploadEntry) Swap(i, j int)      { e[i], e[j] = e[j], e[i] }
+func (e byUploadEntry) Less(i, j int) bool { return lessUploadEntry(e[i].upload, e[j].upload) }
+
+// mergeListMultipartsInfo - merges two sorted listings of uploads,
+// common prefixes present in both are returned once.
+func mergeListMultipartsInfo(first, second ListMultipartsInfo, maxUploads int) ListMultipartsInfo {
+	if maxUploads < 0 || maxUploads > maxUploadsList {
+		maxUploads = maxUploadsList
+	}
+
+	var entries []uploadEntry
+	prefixes := make(map[string]bool)
+	for _, result := range []ListMultipartsInfo{first, second} {
+		for _, upload := range result.Uploads {
+			entries = append(entries, uploadEntry{upload: upload})
+		}
+		for _, prefix := range result.CommonPrefixes {
+			if !prefixes[prefix] {
+				prefixes[prefix] = true
+				entries = append(entries, uploadEntry{upload: uploadMetadata{Object: prefix}, isPrefix: true})
+			}
+		}
+	}
+	sort.Sort(byUploadEntry(entries))
+
+	// Entries beyond the end of a truncated listing are not known to
+	// be complete, stop there.
+	var limit *uploadMetadata
+	for _, result := range []ListMultipartsInfo{first, second} {
+		if !result.IsTruncated {
+			continue
+		}
+		last := uploadMetadata{Object: result.NextKeyMarker, UploadID: result.NextUploadIDMarker}
+		if limit == nil || lessUploadEntry(last, *limit) {
+			limit = &last
+		}
+	}
+
+	merged := first
+	merged.MaxUploads = maxUploads
+	merged.IsTruncated = false
+	merged.Uploads = nil
+	merged.CommonPrefixes = nil
+	for _, entry := range entries {
+		if limit != nil && lessUploadEntry(*limit, entry.upload) {
+			merged.IsTruncated = true
+			break
+		}
+		if len(merged.Uploads)+len(merged.CommonPrefixes) == maxUploads {
+			merged.IsTruncated = true
+			break
+		}
+		if entry.isPrefix {
+			merged.CommonPrefixes = append(merged.CommonPrefixes, entry.upload.Object)
+		} else {
+			merged.Uploads = append(merged.Uploads, entry.upload)
+		}
+		merged.NextKeyMarker = entry.upload.Object
+		merged.NextUploadIDMarker = entry.upload.UploadID
+	}
+	if !merged.IsTruncated {
+		merged.NextKeyMarker = ""
+		merged.NextUploadIDMarker = ""
+	}
+	return merged
+}
+
+// getUploadSet - returns index of the set an upload lives on, uploads
+// initiated before an expansion may live on another set than their
+// object.
+func (s setsObjects) getUploadSet(bucket, object, uploadID string) (int, error) {
+	var err error
+	for _, index := range s.getLookupOrder(bucket, object) {
+		if _, err = s.sets[index].ListObjectParts(bucket, object, uploadID, 0, 1); err == nil {
+			return index, nil
+		}
+		if _, ok := err.(InvalidUploadID); !ok {
+			return -1, err
+		}
+	}
+	return -1, err
+}
+
+// NewMultipartUpload - initiates a multipart upload on the set of the
+// object.
+func (s setsObjects) NewMultipartUpload(bucket, object string, metadata map[string]string) (string, error) {
+	return s.sets[s.getSetIndex(bucket, object)].NewMultipartUpload(bucket, object, metadata)
+}
+
+// PutObjectPart - uploads a part on the set of the upload.
+func (s setsObjects) PutObjectPart(bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (string, error) {
+	index, err := s.getUploadSet(bucket, object, uploadID)
+	if err != nil {
+		return "", err
+	}
+	return s.sets[index].PutObjectPart(bucket, object, uploadID, partID, size, data, md5Hex)
+}
+
+// CopyObjectPart - uploads a part on the set of the upload from a
+// range of the source, sources on other sets are copied through the
+// server.
+func (s setsObjects) CopyObjectPart(srcBucket, srcObject, bucket, object, uploadID string, partID int, startOffset, length int64) (string, error) {
+	index, err := s.getUploadSet(bucket, object, uploadID)
+	if err != nil {
+		return "", err
+	}
+	source, _, err := s.getObjectSet(srcBucket, srcObject)
+	if err != nil {
+		return "", err
+	}
+	if source == index {
+		return s.sets[index].CopyObjectPart(srcBucket, srcObject, bucket, object, uploadID, partID, startOffset, length)
+	}
+	return copyObjectPartData(s.sets[source], srcBucket, srcObject, s.sets[index], bucket, object, uploadID, partID, startOffset, length)
+}
+
+// ListObjectParts - lists uploaded parts on the set of the upload.
+func (s setsObjects) ListObjectParts(bucket, object, uploadID string, partNumberMarker int, maxParts int) (ListPartsInfo, error) {
+	index, err := s.getUploadSet(bucket, object, uploadID)
+	if err != nil {
+		return ListPartsInfo{}, err
+	}
+	return s.sets[index].ListObjectParts(bucket, object, uploadID, partNumberMarker, maxParts)
+}
+
+// AbortMultipartUpload - aborts a multipart upload on the set of the
+// upload.
+func (s setsObjects) AbortMultipartUpload(bucket, object, uploadID string) error {
+	index, err := s.getUploadSet(bucket, object, uploadID)
+	if err != nil {
+		return err
+	}
+	return s.sets[index].AbortMultipartUpload(bucket, object, uploadID)
+}
+
+// CompleteMultipartUpload - completes a multipart upload on the set of
+// the upload, any older copy on other sets is removed.
+func (s setsObjects) CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []completePart) (string, error) {
+	s.moveMutex.RLock(bucket, object)
+	defer s.moveMutex.RUnlock(bucket, object)
+
+	index, err := s.getUploadSet(bucket, object, uploadID)
+	if err != nil {
+		return "", err
+	}
+	md5Sum, err := s.sets[index].CompleteMultipartUpload(bucket, object, uploadID, uploadedParts)
+	if err != nil {
+		return "", err
+	}
+	s.deleteFromOtherSets(bucket, object, index)
+	return md5Sum, nil
+}
+
+/// Healing operations
+
+// HealBucket - heals the bucket on all sets.
+func (s setsObjects) HealBucket(bucket string, dryRun bool) (BucketHealInfo, error) {
+	var info BucketHealInfo
+	for index, set := range s.sets {
+		setInfo, err := set.HealBucket(bucket, dryRun)
+		if err != nil {
+			return BucketHealInfo{}, err
+		}
+		if index == 0 {
+			info = setInfo
+			continue
+		}
+		info.MissingDisks = append(info.MissingDisks, setInfo.MissingDisks...)
+		info.Objects = append(info.Objects, setInfo.Objects...)
+	}
+	return info, nil
+}
+
+// HealObject - heals the object on the set it lives on.
+func (s setsObjects) HealObject(bucket, object string, dryRun bool) (ObjectHealInfo, error) {
+	index, _, err := s.getObjectSet(bucket, object)
+	if err != nil {
+		return ObjectHealInfo{}, err
+	}
+	return s.sets[index].HealObject(bucket, object, dryRun)
+}
+
+/// Auditing operations
+
+// GetObjectChecksums - returns checksums of the object on the set it
+// lives on.
+func (s setsObjects) GetObjectChecksums(bucket, object string) (ObjectChecksumInfo, error) {
+	index, _, err := s.getObjectSet(bucket, object)
+	if err != nil {
+		return ObjectChecksumInfo{}, err
+	}
+	return s.sets[index].GetObjectChecksums(bucket, object)
+}
+
+/// Rebalance
+
+// rebalanceObjects - moves objects of bucket, or of all buckets if
+// empty, which do not live on their set onto it. Returns plan entries
+// of moved objects. Moves are paced by the heal throttle, nothing is
+// moved in dry-run.
+func (s setsObjects) rebalanceObjects(bucket string, dryRun bool) ([]planEntry, error) {
+	var buckets []BucketInfo
+	if bucket != "" {
+		bucketInfo, err := s.GetBucketInfo(bucket)
+		if err != nil {
+			return nil, err
+		}
+		buckets = []BucketInfo{bucketInfo}
+	} else {
+		var err error
+		if buckets, err = s.ListBuckets(); err != nil {
+			return nil, err
+		}
+	}
+	var entries []planEntry
+	for _, bucket := range buckets {
+		for index, set := range s.sets {
+			marker := ""
+			for {
+				result, err := set.ListObjects(bucket.Name, "", marker, "", maxObjectList)
+				if err != nil {
+					errorIf(err, "Unable to list objects of %s for rebalance.", bucket.Name)
+					break
+				}
+				for _, objInfo := range result.Objects {
+					if s.getSetIndex(bucket.Name, objInfo.Name) == index {
+						continue
+					}
+					if !dryRun {
+						globalHealThrottle.acquire()
+						err = s.moveObject(bucket.Name, objInfo, index)
+						globalHealThrottle.release()
+						errorIf(err, "Unable to rebalance %s/%s.", bucket.Name, objInfo.Name)
+					}
+					entries = append(entries, newPlanEntry(planActionMove, bucket.Name, objInfo, err))
+				}
+				if !result.IsTruncated {
+					break
+				}
+				marker = result.NextMarker
+				if marker == "" && len(result.Objects) > 0 {
+					marker = result.Objects[len(result.Objects)-1].Name
+				}
+			}
+		}
+	}
+	return entries, nil
+}
+
+// moveObject - copies the object from the set at index onto its set
+// and removes it from the set at index. Objects uploaded with
+// multipart get a plain md5 ETag once moved.
+func (s setsObjects) moveObject(bucket string, objInfo ObjectInfo, index int) error {
+	s.moveMutex.Lock(bucket, objInfo.Name)
+	defer s.moveMutex.Unlock(bucket, objInfo.Name)
+
+	// Object was written meanwhile, the new object was placed on its
+	// set and replaced the copy on source.
+	source := s.sets[index]
+	curInfo, err := source.GetObjectInfo(bucket, objInfo.Name)
+	if err != nil || !curInfo.ModTime.Equal(objInfo.ModTime) || curInfo.MD5Sum != objInfo.MD5Sum {
+		return err
+	}
+	objInfo = curInfo
+	target := s.sets[s.getSetIndex(bucket, objInfo.Name)]
+	// Copy of an interrupted move, the object on its set is the same
+	// or newer.
+	if _, err = target.GetObjectInfo(bucket, objInfo.Name); err == nil {
+		return source.DeleteObject(bucket, objInfo.Name)
+	}
+
+	metadata := map[string]string{
+		"content-type":     objInfo.ContentType,
+		"content-encoding": objInfo.ContentEncoding,
+		"cache-control":    objInfo.CacheControl,
+	}
+	for key, value := range objInfo.UserDefined {
+		metadata[key] = value
+	}
+	setObjectTags(metadata, objInfo.Tags)
+	setObjectRetention(metadata, objInfo.Retention)
+	setObjectExpiry(metadata, objInfo.Expires)
+	setObjectProvenance(metadata, objInfo.Provenance)
+	if !strings.Contains(objInfo.MD5Sum, "-") {
+		metadata["md5Sum"] = objInfo.MD5Sum
+	}
+
+	stagingFile, err := newStagingFile()
+	if err != nil {
+		return err
+	}
+	defer removeStagingFile(stagingFile)
+	if err = source.GetObject(bucket, objInfo.Name, 0, objInfo.Size, stagingFile); err != nil {
+		return err
+	}
+	if _, err = stagingFile.Seek(0, 0); err != nil {
+		return err
+	}
+	if _, err = target.PutObject(bucket, objInfo.Name, objInfo.Size, stagingFile, metadata); err != nil {
+		return err
+	}
+	return source.DeleteObject(bucket, objInfo.Name)
+}
@@ -0,0 +1,353 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// getErasureSetPaths - returns temporary disks of erasure sets.
+func getErasureSetPaths(setCount, diskCount int) ([][]string, error) {
+	setPaths := make([][]string, setCount)
+	for index := range setPaths {
+		for i := 0; i < diskCount; i++ {
+			path, err := ioutil.TempDir(os.TempDir(), "minio-")
+			if err != nil {
+				return nil, err
+			}
+			setPaths[index] = append(setPaths[index], path)
+		}
+	}
+	return setPaths, nil
+}
+
+// Tests parsing of erasure sets in MINIO_ERASURE_SETS.
+func TestParseErasureSets(t *testing.T) {
+	testCases := []struct {
+		setsStr  string
+		expected [][]string
+	}{
+		// Test case - 1.
+		{"/mnt/disk1 /mnt/disk2", [][]string{{"/mnt/disk1", "/mnt/disk2"}}},
+		// Test case - 2.
+		{"/mnt/disk1  /mnt/disk2, /mnt/disk3 /mnt/disk4", [][]string{{"/mnt/disk1", "/mnt/disk2"}, {"/mnt/disk3", "/mnt/disk4"}}},
+		// Test case - 3.
+		// Empty sets are ignored.
+		{"/mnt/disk1,,/mnt/disk2,", [][]string{{"/mnt/disk1"}, {"/mnt/disk2"}}},
+	}
+	for i, testCase := range testCases {
+		setPaths := parseErasureSets(testCase.setsStr)
+		if !reflect.DeepEqual(setPaths, testCase.expected) {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, setPaths)
+		}
+	}
+}
+
+// Tests merging of multipart upload listings from erasure sets.
+func TestMergeListMultipartsInfo(t *testing.T) {
+	uploads := func(keys ...string) (uploads []uploadMetadata) {
+		for _, key := range keys {
+			uploads = append(uploads, uploadMetadata{Object: key[:1], UploadID: key[1:]})
+		}
+		return uploads
+	}
+	testCases := []struct {
+		first, second ListMultipartsInfo
+		maxUploads    int
+		expected      ListMultipartsInfo
+	}{
+		// Test case - 1.
+		// Uploads of the same object are ordered by upload id.
+		{
+			ListMultipartsInfo{Uploads: uploads("a2", "c1")},
+			ListMultipartsInfo{Uploads: uploads("a1", "b1")},
+			10,
+			ListMultipartsInfo{MaxUploads: 10, Uploads: uploads("a1", "a2", "b1", "c1")},
+		},
+		// Test case - 2.
+		// Common prefixes are returned once.
+		{
+			ListMultipartsInfo{CommonPrefixes: []string{"dir/"}},
+			ListMultipartsInfo{Uploads: uploads("a1"), CommonPrefixes: []string{"dir/"}},
+			10,
+			ListMultipartsInfo{MaxUploads: 10, Uploads: uploads("a1"), CommonPrefixes: []string{"dir/"}},
+		},
+		// Test case - 3.
+		// Merged listing is truncated at maxUploads.
+		{
+			ListMultipartsInfo{Uploads: uploads("a1", "c1")},
+			ListMultipartsInfo{Uploads: uploads("b1", "d1")},
+			3,
+			ListMultipartsInfo{MaxUploads: 3, IsTruncated: true, NextKeyMarker: "c", NextUploadIDMarker: "1", Uploads: uploads("a1", "b1", "c1")},
+		},
+		// Test case - 4.
+		// Uploads beyond a truncated listing are left for the next page.
+		{
+			ListMultipartsInfo{IsTruncated: true, NextKeyMarker: "b", NextUploadIDMarker: "1", Uploads: uploads("a1", "b1")},
+			ListMultipartsInfo{Uploads: uploads("b2", "c1")},
+			10,
+			ListMultipartsInfo{MaxUploads: 10, IsTruncated: true, NextKeyMarker: "b", NextUploadIDMarker: "1", Uploads: uploads("a1", "b1")},
+		},
+	}
+	for i, testCase := range testCases {
+		result := mergeListMultipartsInfo(testCase.first, testCase.second, testCase.maxUploads)
+		if !reflect.DeepEqual(result, testCase.expected) {
+			t.Errorf("Test %d: Expected %+v, got %+v", i+1, testCase.expected, result)
+		}
+	}
+}
+
+// Tests objects stay readable once a deployment is expanded by an
+// erasure set and are moved onto their set by a rebalance.
+func TestSetsObjectsExpansion(t *testing.T) {
+	setPaths, err := getErasureSetPaths(2, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, paths := range setPaths {
+		defer removeRoots(paths)
+	}
+	initNSLock()
+
+	// Deployment of a single set.
+	obj, err := newSetsObjectLayer(setPaths[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello world")
+	// TTL and provenance of objects move with them between sets.
+	expires := time.Now().UTC().Add(time.Hour)
+	var objects []string
+	for i := 0; i < 10; i++ {
+		object := "object" + strconv.Itoa(i)
+		metadata := make(map[string]string)
+		setObjectExpiry(metadata, expires)
+		if _, err = obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), metadata); err != nil {
+			t.Fatal(err)
+		}
+		objects = append(objects, object)
+	}
+	written := time.Now().UTC()
+
+	// Expand the deployment by a second set.
+	obj, err = newSetsObjects(setPaths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets := obj.(setsObjects)
+	if _, err = sets.sets[1].GetBucketInfo(bucket); err != nil {
+		t.Errorf("Expected bucket to be made on added set, failed with %s", err)
+	}
+	for _, object := range objects {
+		buffer := new(bytes.Buffer)
+		if err = obj.GetObject(bucket, object, 0, int64(len(data)), buffer); err != nil {
+			t.Fatalf("Unable to get %s after expansion. %s", object, err)
+		}
+		if !bytes.Equal(buffer.Bytes(), data) {
+			t.Errorf("Expected %s, got %s", data, buffer.Bytes())
+		}
+	}
+
+	// New objects are placed on the set their name hashes to.
+	for i := 10; i < 20; i++ {
+		object := "object" + strconv.Itoa(i)
+		if _, err = obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatal(err)
+		}
+		if _, err = sets.sets[sets.getSetIndex(bucket, object)].GetObjectInfo(bucket, object); err != nil {
+			t.Errorf("Expected %s on its set, failed with %s", object, err)
+		}
+		objects = append(objects, object)
+	}
+	result, err := obj.ListObjects(bucket, "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Objects) != len(objects) {
+		t.Errorf("Expected %d objects, got %d", len(objects), len(result.Objects))
+	}
+
+	// Dry-run reports objects off their set without moving them.
+	var misplaced int
+	for _, object := range objects[:10] {
+		if sets.getSetIndex(bucket, object) != 0 {
+			misplaced++
+		}
+	}
+	if misplaced == 0 {
+		t.Fatal("Expected some objects to hash to the added set")
+	}
+	entries, err := sets.rebalanceObjects("", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != misplaced {
+		t.Errorf("Expected plan to move %d objects, got %v", misplaced, entries)
+	}
+	for _, entry := range entries {
+		if _, err = sets.sets[0].GetObjectInfo(bucket, entry.Object); err != nil {
+			t.Errorf("Expected %s to remain on first set after dry-run, failed with %s", entry.Object, err)
+		}
+	}
+
+	// Rebalance moves all objects onto their set.
+	if entries, err = sets.rebalanceObjects(bucket, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != misplaced {
+		t.Errorf("Expected %d objects to be moved, got %v", misplaced, entries)
+	}
+	for i, object := range objects {
+		index := sets.getSetIndex(bucket, object)
+		objInfo, err := sets.sets[index].GetObjectInfo(bucket, object)
+		if err != nil {
+			t.Errorf("Expected %s on its set after rebalance, failed with %s", object, err)
+		} else if i < 10 && !objInfo.Expires.Equal(expires) {
+			t.Errorf("Expected expiry %s of %s, got %s", expires, object, objInfo.Expires)
+		} else if i < 10 && objInfo.Provenance.WriteTime.After(written) {
+			t.Errorf("Expected write time of %s before %s, got %s", object, written, objInfo.Provenance.WriteTime)
+		}
+		if _, err = sets.sets[1-index].GetObjectInfo(bucket, object); err == nil {
+			t.Errorf("Expected %s to be removed from the other set", object)
+		}
+	}
+	if entries, _ = sets.rebalanceObjects("", true); len(entries) != 0 {
+		t.Errorf("Expected nothing to move after rebalance, got %v", entries)
+	}
+
+	// Sources on different sets are copied into the composed object.
+	if _, err = obj.ComposeObject(bucket, "composed", objects[:4], nil); err != nil {
+		t.Fatal(err)
+	}
+	objInfo, err := obj.GetObjectInfo(bucket, "composed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if objInfo.Size != int64(4*len(data)) {
+		t.Errorf("Expected composed size %d, got %d", 4*len(data), objInfo.Size)
+	}
+
+	// Sets have to be given in the order of the deployment.
+	if _, err = newSetsObjects([][]string{setPaths[1], setPaths[0]}); err == nil {
+		t.Error("Expected erasure sets out of order to fail")
+	}
+	if _, err = newSetsObjectLayer(setPaths[0]); err == nil {
+		t.Error("Expected single set of an expanded deployment to fail")
+	}
+	if _, err = newSetsObjects(setPaths); err != nil {
+		t.Errorf("Expected restart with all erasure sets to succeed, failed with %s", err)
+	}
+}
+
+// Tests multipart uploads live on the set of their object.
+func TestSetsObjectsMultipart(t *testing.T) {
+	setPaths, err := getErasureSetPaths(2, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, paths := range setPaths {
+		defer removeRoots(paths)
+	}
+	initNSLock()
+
+	obj, err := newSetsObjects(setPaths)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sets := obj.(setsObjects)
+	bucket, object := "bucket", "object"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := obj.NewMultipartUpload(bucket, object, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := obj.ListMultipartUploads(bucket, "", "", "", "", 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Uploads) != 1 || result.Uploads[0].UploadID != uploadID {
+		t.Errorf("Expected upload %s to be listed, got %v", uploadID, result.Uploads)
+	}
+	data := []byte("hello world")
+	md5Hex, err := obj.PutObjectPart(bucket, object, uploadID, 1, int64(len(data)), bytes.NewReader(data), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = obj.CompleteMultipartUpload(bucket, object, uploadID, []completePart{{PartNumber: 1, ETag: md5Hex}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = sets.sets[sets.getSetIndex(bucket, object)].GetObjectInfo(bucket, object); err != nil {
+		t.Errorf("Expected object on its set, failed with %s", err)
+	}
+	if _, err = obj.PutObjectPart(bucket, object, uploadID, 1, int64(len(data)), bytes.NewReader(data), ""); err == nil {
+		t.Error("Expected completed upload to be unknown")
+	}
+
+	// Deleting the object removes it from all sets.
+	if err = obj.DeleteObject(bucket, object); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = obj.GetObjectInfo(bucket, object); err == nil {
+		t.Error("Expected object to be deleted")
+	}
+}
+
+// Tests validate adding a set only moves objects onto the added set.
+func TestGetSetIndex(t *testing.T) {
+	for sets := 1; sets < 8; sets++ {
+		before := setsObjects{sets: make([]ObjectLayer, sets), ring: newHashRing(sets)}
+		after := setsObjects{sets: make([]ObjectLayer, sets+1), ring: newHashRing(sets + 1)}
+		moved := 0
+		placed := make([]int, sets)
+		for i := 0; i < 10000; i++ {
+			object := "object" + strconv.Itoa(i)
+			index, newIndex := before.getSetIndex("bucket", object), after.getSetIndex("bucket", object)
+			if index < 0 || index >= sets {
+				t.Fatalf("%d sets: Expected set index of %s below %d, got %d", sets, object, sets, index)
+			}
+			placed[index]++
+			if newIndex != index {
+				if newIndex != sets {
+					t.Fatalf("%d sets: Expected %s to stay on set %d or move to the added set, got %d", sets, object, index, newIndex)
+				}
+				moved++
+			}
+		}
+		// About 1/(sets+1) of the objects move.
+		if expected := 10000 / (sets + 1); moved < expected*8/10 || moved > expected*12/10 {
+			t.Errorf("%d sets: Expected about %d objects to move, got %d", sets, expected, moved)
+		}
+		// Objects are spread evenly over sets.
+		for index, count := range placed {
+			if expected := 10000 / sets; count < expected*8/10 || count > expected*12/10 {
+				t.Errorf("%d sets: Expected about %d objects on set %d, got %d", sets, expected, index, count)
+			}
+		}
+	}
+}
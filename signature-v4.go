@@ -150,9 +150,6 @@ func getSignature(signingKey []byte, stringToSign string) string {
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-HTTPPOSTConstructPolicy.html
 // returns true if matches, false otherwise. if error is not nil then it is always false
 func doesPolicySignatureMatch(formValues map[string]string) APIErrorCode {
-	// Access credentials.
-	cred := serverConfig.GetCredential()
-
 	// Server region.
 	region := serverConfig.GetRegion()
 
@@ -162,8 +159,9 @@ func doesPolicySignatureMatch(formValues map[string]string) APIErrorCode {
 		return ErrMissingFields
 	}
 
-	// Verify if the access key id matches.
-	if credHeader.accessKey != cred.AccessKeyID {
+	// Access credentials of the access key, server or tenant.
+	cred, ok := getCredentialByAccessKey(credHeader.accessKey)
+	if !ok {
 		return ErrInvalidAccessKeyID
 	}
 
@@ -196,9 +194,6 @@ func doesPolicySignatureMatch(formValues map[string]string) APIErrorCode {
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sigv4-query-string-auth.html
 // returns true if matches, false otherwise. if error is not nil then it is always false
 func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, validateRegion bool) APIErrorCode {
-	// Access credentials.
-	cred := serverConfig.GetCredential()
-
 	// Server region.
 	region := serverConfig.GetRegion()
 
@@ -211,8 +206,9 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, validate
 		return err
 	}
 
-	// Verify if the access key id matches.
-	if preSignValues.Credential.accessKey != cred.AccessKeyID {
+	// Access credentials of the access key, server or tenant.
+	cred, ok := getCredentialByAccessKey(preSignValues.Credential.accessKey)
+	if !ok {
 		return ErrInvalidAccessKeyID
 	}
 
@@ -288,10 +284,17 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, validate
 		}
 	}
 
+	// Verify embedded constraints, URLs scoped to a key prefix are
+	// signed for the prefix instead of the object.
+	urlPath, err := checkPresignConstraints(&req)
+	if err != ErrNone {
+		return err
+	}
+
 	/// Verify finally if signature is same.
 
 	// Get canonical request.
-	presignedCanonicalReq := getCanonicalRequest(extractedSignedHeaders, hashedPayload, encodedQuery, req.URL.Path, req.Method, req.Host)
+	presignedCanonicalReq := getCanonicalRequest(extractedSignedHeaders, hashedPayload, encodedQuery, urlPath, req.Method, req.Host)
 
 	// Get string to sign from canonical request.
 	presignedStringToSign := getStringToSign(presignedCanonicalReq, t, region)
@@ -306,6 +309,7 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, validate
 	if req.URL.Query().Get("X-Amz-Signature") != newSignature {
 		return ErrSignatureDoesNotMatch
 	}
+	setRequestAuthenticated(r, preSignValues.Credential.accessKey)
 	return ErrNone
 }
 
@@ -313,9 +317,6 @@ func doesPresignedSignatureMatch(hashedPayload string, r *http.Request, validate
 //     - http://docs.aws.amazon.com/AmazonS3/latest/API/sig-v4-authenticating-requests.html
 // returns true if matches, false otherwise. if error is not nil then it is always false
 func doesSignatureMatch(hashedPayload string, r *http.Request, validateRegion bool) APIErrorCode {
-	// Access credentials.
-	cred := serverConfig.GetCredential()
-
 	// Server region.
 	region := serverConfig.GetRegion()
 
@@ -334,8 +335,9 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, validateRegion bo
 	// Extract all the signed headers along with its values.
 	extractedSignedHeaders := extractSignedHeaders(signV4Values.SignedHeaders, req.Header)
 
-	// Verify if the access key id matches.
-	if signV4Values.Credential.accessKey != cred.AccessKeyID {
+	// Access credentials of the access key, server or tenant.
+	cred, ok := getCredentialByAccessKey(signV4Values.Credential.accessKey)
+	if !ok {
 		return ErrInvalidAccessKeyID
 	}
 
@@ -383,5 +385,6 @@ func doesSignatureMatch(hashedPayload string, r *http.Request, validateRegion bo
 	if newSignature != signV4Values.Signature {
 		return ErrSignatureDoesNotMatch
 	}
+	setRequestAuthenticated(r, signV4Values.Credential.accessKey)
 	return ErrNone
 }
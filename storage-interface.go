@@ -29,8 +29,10 @@ type StorageAPI interface {
 	ReadFile(volume string, path string, offset int64, buf []byte) (n int64, err error)
 	AppendFile(volume string, path string, buf []byte) (err error)
 	RenameFile(srcVolume, srcPath, dstVolume, dstPath string) error
+	LinkFile(srcVolume, srcPath, dstVolume, dstPath string) error
 	StatFile(volume string, path string) (file FileInfo, err error)
 	DeleteFile(volume string, path string) (err error)
+	ShredFile(volume string, path string) (err error)
 
 	// Read all.
 	ReadAll(volume string, path string) (buf []byte, err error)
@@ -0,0 +1,247 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// storageCounters - I/O counters of a disk.
+type storageCounters struct {
+	bytesRead    int64
+	bytesWritten int64
+	reads        int64 // ReadFile and ReadAll calls.
+	writes       int64 // AppendFile calls.
+	ops          int64 // All calls.
+	errors       int64 // Calls failed for other reasons than missing or existing files and volumes.
+	time         int64 // Total nanoseconds spent in calls.
+}
+
+// diskIOStats - I/O statistics of a disk.
+type diskIOStats struct {
+	Disk         string        `json:"disk,omitempty"`
+	BytesRead    int64         `json:"bytesRead"`
+	BytesWritten int64         `json:"bytesWritten"`
+	Reads        int64         `json:"reads"`
+	Writes       int64         `json:"writes"`
+	Ops          int64         `json:"ops"`
+	Errors       int64         `json:"errors"`
+	Time         time.Duration `json:"time"`
+}
+
+// storageIOStats - I/O statistics of all disks and their total.
+type storageIOStats struct {
+	Disks []diskIOStats `json:"disks"`
+	Total diskIOStats   `json:"total"`
+}
+
+// isDiskIOError - returns true if err tells the disk failed the call,
+// errors of missing or existing files and volumes are part of normal
+// operation.
+func isDiskIOError(err error) bool {
+	switch err {
+	case nil, io.EOF, io.ErrUnexpectedEOF:
+		return false
+	case errFileNotFound, errVolumeNotFound, errVolumeExists, errVolumeNotEmpty, errIsNotRegular, errFileNameTooLong:
+		return false
+	}
+	return true
+}
+
+// record - counts a call which started at start and returned err.
+func (c *storageCounters) record(start time.Time, err error) error {
+	atomic.AddInt64(&c.time, int64(time.Since(start)))
+	atomic.AddInt64(&c.ops, 1)
+	if isDiskIOError(err) {
+		atomic.AddInt64(&c.errors, 1)
+	}
+	return err
+}
+
+// stats - returns I/O statistics of the counters.
+func (c *storageCounters) stats(disk string) diskIOStats {
+	return diskIOStats{
+		Disk:         disk,
+		BytesRead:    atomic.LoadInt64(&c.bytesRead),
+		BytesWritten: atomic.LoadInt64(&c.bytesWritten),
+		Reads:        atomic.LoadInt64(&c.reads),
+		Writes:       atomic.LoadInt64(&c.writes),
+		Ops:          atomic.LoadInt64(&c.ops),
+		Errors:       atomic.LoadInt64(&c.errors),
+		Time:         time.Duration(atomic.LoadInt64(&c.time)),
+	}
+}
+
+// storageStats - I/O counters of all disks, by disk path. Counters
+// of a disk are kept while it is offline and continue once it is
+// back.
+type storageStats struct {
+	mutex    *sync.Mutex
+	disks    []string // Disks in the order first seen.
+	counters map[string]*storageCounters
+}
+
+// newStorageStats - initialize I/O counters of disks.
+func newStorageStats() *storageStats {
+	return &storageStats{
+		mutex:    &sync.Mutex{},
+		counters: make(map[string]*storageCounters),
+	}
+}
+
+// I/O counters of all disks of this server.
+var globalStorageStats = newStorageStats()
+
+// getCounters - returns counters of the disk, creating them if needed.
+func (s *storageStats) getCounters(disk string) *storageCounters {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	disk = normalizeDiskPath(disk)
+	c, ok := s.counters[disk]
+	if !ok {
+		c = &storageCounters{}
+		s.counters[disk] = c
+		s.disks = append(s.disks, disk)
+	}
+	return c
+}
+
+// stats - returns I/O statistics of every disk and their total.
+func (s *storageStats) stats() storageIOStats {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	ioStats := storageIOStats{Disks: []diskIOStats{}}
+	for _, disk := range s.disks {
+		diskStats := s.counters[disk].stats(disk)
+		ioStats.Disks = append(ioStats.Disks, diskStats)
+		ioStats.Total.BytesRead += diskStats.BytesRead
+		ioStats.Total.BytesWritten += diskStats.BytesWritten
+		ioStats.Total.Reads += diskStats.Reads
+		ioStats.Total.Writes += diskStats.Writes
+		ioStats.Total.Ops += diskStats.Ops
+		ioStats.Total.Errors += diskStats.Errors
+		ioStats.Total.Time += diskStats.Time
+	}
+	return ioStats
+}
+
+// statsStorage - counts I/O of calls to the disk.
+type statsStorage struct {
+	storage  StorageAPI
+	counters *storageCounters
+}
+
+// newStatsStorage - wraps storage counting its I/O as disk.
+func newStatsStorage(disk string, storage StorageAPI) StorageAPI {
+	return &statsStorage{storage: storage, counters: globalStorageStats.getCounters(disk)}
+}
+
+// MakeVol - StorageAPI of the disk.
+func (s *statsStorage) MakeVol(volume string) error {
+	start := time.Now()
+	return s.counters.record(start, s.storage.MakeVol(volume))
+}
+
+// ListVols - StorageAPI of the disk.
+func (s *statsStorage) ListVols() ([]VolInfo, error) {
+	start := time.Now()
+	vols, err := s.storage.ListVols()
+	return vols, s.counters.record(start, err)
+}
+
+// StatVol - StorageAPI of the disk.
+func (s *statsStorage) StatVol(volume string) (VolInfo, error) {
+	start := time.Now()
+	vol, err := s.storage.StatVol(volume)
+	return vol, s.counters.record(start, err)
+}
+
+// DeleteVol - StorageAPI of the disk.
+func (s *statsStorage) DeleteVol(volume string) error {
+	start := time.Now()
+	return s.counters.record(start, s.storage.DeleteVol(volume))
+}
+
+// ListDir - StorageAPI of the disk.
+func (s *statsStorage) ListDir(volume, dirPath string) ([]string, error) {
+	start := time.Now()
+	entries, err := s.storage.ListDir(volume, dirPath)
+	return entries, s.counters.record(start, err)
+}
+
+// ReadFile - StorageAPI of the disk.
+func (s *statsStorage) ReadFile(volume string, path string, offset int64, buf []byte) (int64, error) {
+	start := time.Now()
+	n, err := s.storage.ReadFile(volume, path, offset, buf)
+	atomic.AddInt64(&s.counters.reads, 1)
+	atomic.AddInt64(&s.counters.bytesRead, n)
+	return n, s.counters.record(start, err)
+}
+
+// AppendFile - StorageAPI of the disk.
+func (s *statsStorage) AppendFile(volume string, path string, buf []byte) error {
+	start := time.Now()
+	err := s.storage.AppendFile(volume, path, buf)
+	atomic.AddInt64(&s.counters.writes, 1)
+	if err == nil {
+		atomic.AddInt64(&s.counters.bytesWritten, int64(len(buf)))
+	}
+	return s.counters.record(start, err)
+}
+
+// RenameFile - StorageAPI of the disk.
+func (s *statsStorage) RenameFile(srcVolume, srcPath, dstVolume, dstPath string) error {
+	start := time.Now()
+	return s.counters.record(start, s.storage.RenameFile(srcVolume, srcPath, dstVolume, dstPath))
+}
+
+// LinkFile - StorageAPI of the disk.
+func (s *statsStorage) LinkFile(srcVolume, srcPath, dstVolume, dstPath string) error {
+	start := time.Now()
+	return s.counters.record(start, s.storage.LinkFile(srcVolume, srcPath, dstVolume, dstPath))
+}
+
+// StatFile - StorageAPI of the disk.
+func (s *statsStorage) StatFile(volume string, path string) (FileInfo, error) {
+	start := time.Now()
+	file, err := s.storage.StatFile(volume, path)
+	return file, s.counters.record(start, err)
+}
+
+// DeleteFile - StorageAPI of the disk.
+func (s *statsStorage) DeleteFile(volume string, path string) error {
+	start := time.Now()
+	return s.counters.record(start, s.storage.DeleteFile(volume, path))
+}
+
+// ShredFile - StorageAPI of the disk.
+func (s *statsStorage) ShredFile(volume string, path string) error {
+	start := time.Now()
+	return s.counters.record(start, s.storage.ShredFile(volume, path))
+}
+
+// ReadAll - StorageAPI of the disk.
+func (s *statsStorage) ReadAll(volume string, path string) ([]byte, error) {
+	start := time.Now()
+	buf, err := s.storage.ReadAll(volume, path)
+	atomic.AddInt64(&s.counters.reads, 1)
+	atomic.AddInt64(&s.counters.bytesRead, int64(len(buf)))
+	return buf, s.counters.record(start, err)
+}
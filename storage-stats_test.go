@@ -0,0 +1,93 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// Tests I/O of disks is counted, with missing files not counted as
+// errors.
+func TestStorageStats(t *testing.T) {
+	diskPath, err := ioutil.TempDir(os.TempDir(), "minio-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(diskPath)
+
+	disk, err := newStorageAPI(diskPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = disk.MakeVol("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	if err = disk.AppendFile("bucket", "object", []byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	buf := make([]byte, 5)
+	if _, err = disk.ReadFile("bucket", "object", 0, buf); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = disk.StatFile("bucket", "missing"); err != errFileNotFound {
+		t.Fatalf("Expected %s, got %v", errFileNotFound, err)
+	}
+	// Failed reads of a disk are errors.
+	faultyDisk := newStatsStorage(diskPath, faultyReadDisk{disk})
+	if _, err = faultyDisk.ReadFile("bucket", "object", 0, buf); err != errDiskNotFound {
+		t.Fatalf("Expected %s, got %v", errDiskNotFound, err)
+	}
+
+	var diskStats *diskIOStats
+	ioStats := globalStorageStats.stats()
+	for i := range ioStats.Disks {
+		if ioStats.Disks[i].Disk == normalizeDiskPath(diskPath) {
+			diskStats = &ioStats.Disks[i]
+		}
+	}
+	if diskStats == nil {
+		t.Fatalf("Expected statistics of %s", diskPath)
+	}
+	testCases := []struct {
+		name     string
+		value    int64
+		expected int64
+	}{
+		// Test case - 1.
+		{"bytesRead", diskStats.BytesRead, 5},
+		// Test case - 2.
+		{"bytesWritten", diskStats.BytesWritten, 5},
+		// Test case - 3.
+		{"reads", diskStats.Reads, 2},
+		// Test case - 4.
+		{"writes", diskStats.Writes, 1},
+		// Test case - 5.
+		{"ops", diskStats.Ops, 5},
+		// Test case - 6.
+		{"errors", diskStats.Errors, 1},
+	}
+	for i, testCase := range testCases {
+		if testCase.value != testCase.expected {
+			t.Errorf("Test %d: Expected %s %d, got %d", i+1, testCase.name, testCase.expected, testCase.value)
+		}
+	}
+	if ioStats.Total.Ops < diskStats.Ops {
+		t.Errorf("Expected total ops at least %d, got %d", diskStats.Ops, ioStats.Total.Ops)
+	}
+}
@@ -0,0 +1,560 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Tenants are saved in the config directory.
+	tenantsFile = "tenants.json"
+
+	// Maximum size of tenants document.
+	maxTenantsSize = 1 * 1024 * 1024 // 1MiB.
+
+	// Interval at which storage used by tenants is measured.
+	tenantUsageInterval = 5 * time.Minute
+)
+
+// isValidTenantName - tenant names are usable as bucket name prefix.
+var isValidTenantName = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,30}[a-z0-9])?$`)
+
+// isValidBucketPrefix - bucket name prefix of a tenant.
+var isValidBucketPrefix = regexp.MustCompile(`^[a-z0-9][a-z0-9.-]{0,31}$`)
+
+// tenant - isolated user of the server, a tenant can only access
+// buckets named with its bucket prefix.
+type tenant struct {
+	Name       string     `json:"name"`
+	Credential credential `json:"credential"`
+	// Prefix of bucket names of the tenant, defaults to the name
+	// followed by "-".
+	BucketPrefix string `json:"bucketPrefix,omitempty"`
+	// Maximum number of buckets, 0 is unlimited.
+	MaxBuckets int `json:"maxBuckets,omitempty"`
+	// Maximum bytes stored in all buckets, 0 is unlimited.
+	MaxSize int64 `json:"maxSize,omitempty"`
+	// Labels reported along with usage of the tenant.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ownsBucket - returns true if bucket is in the namespace of the tenant.
+func (t tenant) ownsBucket(bucket string) bool {
+	return strings.HasPrefix(bucket, t.BucketPrefix)
+}
+
+// tenantsConfig - all tenants of the server.
+type tenantsConfig struct {
+	Tenants []tenant `json:"tenants"`
+}
+
+// parseTenantsConfig - parses and validates tenants, names, access
+// keys and bucket prefixes must not overlap.
+func parseTenantsConfig(configBuf []byte) (config tenantsConfig, err error) {
+	if err = json.Unmarshal(configBuf, &config); err != nil {
+		return tenantsConfig{}, err
+	}
+	names := make(map[string]bool)
+	accessKeys := map[string]bool{serverConfig.GetCredential().AccessKeyID: true}
+	for i, t := range config.Tenants {
+		if !isValidTenantName.MatchString(t.Name) {
+			return tenantsConfig{}, errors.New("Invalid tenant name " + t.Name + ".")
+		}
+		if names[t.Name] {
+			return tenantsConfig{}, errors.New("Tenant " + t.Name + " is listed more than once.")
+		}
+		names[t.Name] = true
+		if !isValidAccessKey.MatchString(t.Credential.AccessKeyID) || !isValidSecretKey.MatchString(t.Credential.SecretAccessKey) {
+			return tenantsConfig{}, errors.New("Invalid credential of tenant " + t.Name + ".")
+		}
+		if accessKeys[t.Credential.AccessKeyID] {
+			return tenantsConfig{}, errors.New("Access key of tenant " + t.Name + " is already in use.")
+		}
+		accessKeys[t.Credential.AccessKeyID] = true
+		if t.BucketPrefix == "" {
+			t.BucketPrefix = t.Name + "-"
+		}
+		if !isValidBucketPrefix.MatchString(t.BucketPrefix) {
+			return tenantsConfig{}, errors.New("Invalid bucket prefix of tenant " + t.Name + ".")
+		}
+		if t.MaxBuckets < 0 || t.MaxSize < 0 {
+			return tenantsConfig{}, errors.New("Quotas of tenant " + t.Name + " cannot be negative.")
+		}
+		config.Tenants[i] = t
+	}
+	for i, t := range config.Tenants {
+		for j, other := range config.Tenants {
+			if i != j && strings.HasPrefix(t.BucketPrefix, other.BucketPrefix) {
+				return tenantsConfig{}, errors.New("Bucket prefix of tenant " + t.Name + " overlaps with tenant " + other.Name + ".")
+			}
+		}
+	}
+	return config, nil
+}
+
+// getTenantsPath - get tenants path.
+func getTenantsPath() (string, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configPath, tenantsFile), nil
+}
+
+// readTenantsConfig - read tenants, the server has no tenants unless
+// configured.
+func readTenantsConfig() (tenantsConfig, error) {
+	tenantsPath, err := getTenantsPath()
+	if err != nil {
+		return tenantsConfig{}, err
+	}
+	configBuf, err := ioutil.ReadFile(tenantsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return tenantsConfig{}, nil
+		}
+		return tenantsConfig{}, err
+	}
+	return parseTenantsConfig(configBuf)
+}
+
+// writeTenantsConfig - save tenants.
+func writeTenantsConfig(config tenantsConfig) error {
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		return err
+	}
+	tenantsPath, err := getTenantsPath()
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(tenantsPath, configBuf, 0600)
+}
+
+// tenantUsage - storage used by a tenant, as last measured plus bytes
+// written since.
+type tenantUsage struct {
+	Name       string            `json:"name"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Buckets    int               `json:"buckets"`
+	Objects    int64             `json:"objects"`
+	Size       int64             `json:"size"`
+	MaxBuckets int               `json:"maxBuckets"`
+	MaxSize    int64             `json:"maxSize"`
+	Measured   time.Time         `json:"measured"`
+}
+
+// tenantStore - tenants of the server and their usage.
+type tenantStore struct {
+	mutex   *sync.RWMutex
+	tenants []tenant
+	usage   map[string]tenantUsage
+}
+
+// Tenants of the server, loaded from the config directory.
+var globalTenants = &tenantStore{
+	mutex: &sync.RWMutex{},
+	usage: make(map[string]tenantUsage),
+}
+
+// initTenants - loads tenants from the config directory.
+func initTenants() error {
+	config, err := readTenantsConfig()
+	if err != nil {
+		return err
+	}
+	globalTenants.Set(config.Tenants)
+	return nil
+}
+
+// Set - replaces all tenants, usage of removed tenants is dropped.
+func (s *tenantStore) Set(tenants []tenant) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	usage := make(map[string]tenantUsage)
+	for _, t := range tenants {
+		if u, ok := s.usage[t.Name]; ok {
+			usage[t.Name] = u
+		}
+	}
+	s.tenants = tenants
+	s.usage = usage
+}
+
+// GetByBucket - returns tenant owning the bucket.
+func (s *tenantStore) GetByBucket(bucket string) (tenant, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, t := range s.tenants {
+		if t.ownsBucket(bucket) {
+			return t, true
+		}
+	}
+	return tenant{}, false
+}
+
+// GetByAccessKey - returns tenant of the access key.
+func (s *tenantStore) GetByAccessKey(accessKey string) (tenant, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	for _, t := range s.tenants {
+		if t.Credential.AccessKeyID == accessKey {
+			return t, true
+		}
+	}
+	return tenant{}, false
+}
+
+// List - returns all tenants.
+func (s *tenantStore) List() []tenant {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return append([]tenant(nil), s.tenants...)
+}
+
+// setUsage - saves measured usage of a tenant.
+func (s *tenantStore) setUsage(usage tenantUsage) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.usage[usage.Name] = usage
+}
+
+// getUsage - returns usage of a tenant.
+func (s *tenantStore) getUsage(name string) tenantUsage {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.usage[name]
+}
+
+// addUsage - accounts bytes written by a tenant since last measured.
+func (s *tenantStore) addUsage(name string, size int64) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	usage := s.usage[name]
+	usage.Size += size
+	s.usage[name] = usage
+}
+
+// GetUsage - returns usage of all tenants along with their labels and
+// quotas.
+func (s *tenantStore) GetUsage() []tenantUsage {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	usages := make([]tenantUsage, 0, len(s.tenants))
+	for _, t := range s.tenants {
+		usage := s.usage[t.Name]
+		usage.Name = t.Name
+		usage.Labels = t.Labels
+		usage.MaxBuckets = t.MaxBuckets
+		usage.MaxSize = t.MaxSize
+		usages = append(usages, usage)
+	}
+	return usages
+}
+
+// getCredentialByAccessKey - returns credential of the server or of a
+// tenant for the access key.
+func getCredentialByAccessKey(accessKey string) (credential, bool) {
+	if cred := serverConfig.GetCredential(); cred.AccessKeyID == accessKey {
+		return cred, true
+	}
+	if t, ok := globalTenants.GetByAccessKey(accessKey); ok {
+		return t.Credential, true
+	}
+	return credential{}, false
+}
+
+// checkTenantBucket - verifies bucket is accessible by the access key,
+// other than the server credential access keys of tenants can only
+// access buckets of their namespace.
+func checkTenantBucket(accessKey, bucket string) APIErrorCode {
+	if t, ok := globalTenants.GetByAccessKey(accessKey); ok && !t.ownsBucket(bucket) {
+		return ErrAccessDenied
+	}
+	return ErrNone
+}
+
+// filterTenantBuckets - returns buckets accessible by the access key.
+func filterTenantBuckets(accessKey string, bucketsInfo []BucketInfo) []BucketInfo {
+	t, ok := globalTenants.GetByAccessKey(accessKey)
+	if !ok {
+		return bucketsInfo
+	}
+	var owned []BucketInfo
+	for _, bucketInfo := range bucketsInfo {
+		if t.ownsBucket(bucketInfo.Name) {
+			owned = append(owned, bucketInfo)
+		}
+	}
+	return owned
+}
+
+// checkTenantQuota - verifies tenant can store size more bytes, usage
+// is as last measured plus bytes written since.
+func checkTenantQuota(t tenant, size int64) error {
+	if t.MaxSize == 0 {
+		return nil
+	}
+	if globalTenants.getUsage(t.Name).Size+size > t.MaxSize {
+		return TenantQuotaExceeded{Tenant: t.Name}
+	}
+	return nil
+}
+
+// tenantHandler - restricts requests signed by tenants to buckets of
+// their namespace, storage quotas are enforced by tenantObjects.
+type tenantHandler struct {
+	handler http.Handler
+}
+
+// setTenantHandler to isolate tenants from each other.
+func setTenantHandler(h http.Handler) http.Handler {
+	return tenantHandler{h}
+}
+
+func (h tenantHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	t, ok := globalTenants.GetByAccessKey(getRequestAccessKey(r))
+	// WebDAV frontend restricts tenants by itself.
+	if !ok || isWebDAVRequest(r) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	// Admin, web and RPC APIs are not available to tenants.
+	if strings.HasPrefix(r.URL.Path, reservedBucket) {
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	}
+	bucket, _ := urlPath2BucketObjectName(r.URL)
+	if bucket != "" && !t.ownsBucket(bucket) {
+		writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+		return
+	}
+	if copySource := r.Header.Get("X-Amz-Copy-Source"); copySource != "" {
+		sourceBucket, _ := getCopySource(copySource)
+		if !t.ownsBucket(sourceBucket) {
+			writeErrorResponse(w, r, ErrAccessDenied, r.URL.Path)
+			return
+		}
+	}
+	h.handler.ServeHTTP(w, r)
+}
+
+// tenantObjects - object layer enforcing storage quotas of tenants on
+// writes to their buckets, whoever makes them. Writes reach the object
+// layer once authenticated, only bytes actually written are accounted,
+// objects of other buckets are passed through.
+type tenantObjects struct {
+	ObjectLayer
+}
+
+// newTenantObjects - wraps an object layer to enforce tenant quotas.
+func newTenantObjects(objAPI ObjectLayer) ObjectLayer {
+	return tenantObjects{objAPI}
+}
+
+// writeObject - runs write of size bytes to bucket if within the quota
+// of the tenant owning it, size is -1 if not known beforehand. Bytes
+// written as returned by write are accounted to the tenant.
+func (o tenantObjects) writeObject(bucket string, size int64, write func() (int64, error)) error {
+	t, ok := globalTenants.GetByBucket(bucket)
+	if !ok {
+		_, err := write()
+		return err
+	}
+	if size < 0 {
+		size = 0
+	}
+	if err := checkTenantQuota(t, size); err != nil {
+		return err
+	}
+	written, err := write()
+	if err != nil {
+		return err
+	}
+	globalTenants.addUsage(t.Name, written)
+	return nil
+}
+
+// tenantReader - counts bytes read from the data of a write, failing
+// once the quota of the tenant is exceeded.
+type tenantReader struct {
+	reader    io.Reader
+	tenant    tenant
+	bytesRead int64
+}
+
+func (r *tenantReader) Read(b []byte) (int, error) {
+	n, err := r.reader.Read(b)
+	r.bytesRead += int64(n)
+	if qErr := checkTenantQuota(r.tenant, r.bytesRead); qErr != nil {
+		return n, qErr
+	}
+	return n, err
+}
+
+// writeData - runs write of data to bucket, data is counted as read.
+func (o tenantObjects) writeData(bucket string, size int64, data io.Reader, write func(io.Reader) error) error {
+	t, ok := globalTenants.GetByBucket(bucket)
+	if !ok {
+		return write(data)
+	}
+	reader := &tenantReader{reader: data, tenant: t}
+	return o.writeObject(bucket, size, func() (int64, error) {
+		err := write(reader)
+		return reader.bytesRead, err
+	})
+}
+
+// PutObject - writes an object within the quota of the tenant.
+func (o tenantObjects) PutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string) (md5 string, err error) {
+	err = o.writeData(bucket, size, data, func(reader io.Reader) error {
+		md5, err = o.ObjectLayer.PutObject(bucket, object, size, reader, metadata)
+		return err
+	})
+	return md5, err
+}
+
+// PatchObject - patches an object within the quota of the tenant.
+func (o tenantObjects) PatchObject(bucket, object string, offset, size int64, data io.Reader) (md5 string, err error) {
+	err = o.writeData(bucket, size, data, func(reader io.Reader) error {
+		md5, err = o.ObjectLayer.PatchObject(bucket, object, offset, size, reader)
+		return err
+	})
+	return md5, err
+}
+
+// PutObjectPart - writes a part within the quota of the tenant.
+func (o tenantObjects) PutObjectPart(bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (md5 string, err error) {
+	err = o.writeData(bucket, size, data, func(reader io.Reader) error {
+		md5, err = o.ObjectLayer.PutObjectPart(bucket, object, uploadID, partID, size, reader, md5Hex)
+		return err
+	})
+	return md5, err
+}
+
+// CopyObject - copies an object within the quota of the tenant owning
+// the destination.
+func (o tenantObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string, metadata map[string]string) (md5 string, err error) {
+	objInfo, err := o.ObjectLayer.GetObjectInfo(srcBucket, srcObject)
+	if err != nil {
+		return "", err
+	}
+	err = o.writeObject(dstBucket, objInfo.Size, func() (int64, error) {
+		md5, err = o.ObjectLayer.CopyObject(srcBucket, srcObject, dstBucket, dstObject, metadata)
+		return objInfo.Size, err
+	})
+	return md5, err
+}
+
+// CopyObjectPart - copies a part within the quota of the tenant owning
+// the destination.
+func (o tenantObjects) CopyObjectPart(srcBucket, srcObject, bucket, object, uploadID string, partID int, startOffset, length int64) (md5 string, err error) {
+	err = o.writeObject(bucket, length, func() (int64, error) {
+		md5, err = o.ObjectLayer.CopyObjectPart(srcBucket, srcObject, bucket, object, uploadID, partID, startOffset, length)
+		return length, err
+	})
+	return md5, err
+}
+
+// ComposeObject - composes an object within the quota of the tenant.
+func (o tenantObjects) ComposeObject(bucket, object string, sources []string, metadata map[string]string) (md5 string, err error) {
+	var size int64
+	for _, source := range sources {
+		objInfo, err := o.ObjectLayer.GetObjectInfo(bucket, source)
+		if err != nil {
+			return "", err
+		}
+		size += objInfo.Size
+	}
+	err = o.writeObject(bucket, size, func() (int64, error) {
+		md5, err = o.ObjectLayer.ComposeObject(bucket, object, sources, metadata)
+		return size, err
+	})
+	return md5, err
+}
+
+// measureTenantUsage - measures storage used by all tenants once.
+func measureTenantUsage(objAPI ObjectLayer) {
+	tenants := globalTenants.List()
+	if len(tenants) == 0 {
+		return
+	}
+	bucketsInfo, err := objAPI.ListBuckets()
+	if err != nil {
+		errorIf(err, "Unable to list buckets.")
+		return
+	}
+	for _, t := range tenants {
+		usage := tenantUsage{Name: t.Name, Measured: time.Now().UTC()}
+		for _, bucketInfo := range bucketsInfo {
+			if !t.ownsBucket(bucketInfo.Name) {
+				continue
+			}
+			usage.Buckets++
+			objects, size, err := getBucketUsage(objAPI, bucketInfo.Name)
+			if err != nil {
+				errorIf(err, "Unable to measure usage of bucket %s.", bucketInfo.Name)
+				continue
+			}
+			usage.Objects += objects
+			usage.Size += size
+		}
+		globalTenants.setUsage(usage)
+	}
+}
+
+// getBucketUsage - returns number of objects and bytes stored in the
+// bucket.
+func getBucketUsage(objAPI ObjectLayer, bucket string) (objects int64, size int64, err error) {
+	marker := ""
+	for {
+		result, err := objAPI.ListObjects(bucket, "", marker, "", 1000)
+		if err != nil {
+			return 0, 0, err
+		}
+		for _, objInfo := range result.Objects {
+			objects++
+			size += objInfo.Size
+		}
+		if !result.IsTruncated {
+			return objects, size, nil
+		}
+		marker = result.NextMarker
+		if marker == "" && len(result.Objects) > 0 {
+			marker = result.Objects[len(result.Objects)-1].Name
+		}
+	}
+}
+
+// tenantUsageJob - measures storage used by all tenants periodically.
+func tenantUsageJob(objAPI ObjectLayer) {
+	for {
+		measureTenantUsage(objAPI)
+		time.Sleep(tenantUsageInterval)
+	}
+}
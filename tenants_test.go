@@ -0,0 +1,201 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+// Tests validate parsing of tenants.
+func TestParseTenantsConfig(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	serverKey := serverConfig.GetCredential().AccessKeyID
+
+	testCases := []struct {
+		configBuf      string
+		shouldPass     bool
+		expectedPrefix string
+	}{
+		// Test case - 1.
+		// Bucket prefix defaults to the name.
+		{`{"tenants":[{"name":"acme","credential":{"accessKey":"ACMEACCESSKEY","secretKey":"acmesecretkey"}}]}`, true, "acme-"},
+		// Test case - 2.
+		// Explicit bucket prefix and quotas.
+		{`{"tenants":[{"name":"acme","credential":{"accessKey":"ACMEACCESSKEY","secretKey":"acmesecretkey"},"bucketPrefix":"acme.","maxBuckets":2,"maxSize":1024,"labels":{"plan":"small"}}]}`, true, "acme."},
+		// Test case - 3.
+		// Invalid name.
+		{`{"tenants":[{"name":"Acme","credential":{"accessKey":"ACMEACCESSKEY","secretKey":"acmesecretkey"}}]}`, false, ""},
+		// Test case - 4.
+		// Invalid secret key.
+		{`{"tenants":[{"name":"acme","credential":{"accessKey":"ACMEACCESSKEY","secretKey":"short"}}]}`, false, ""},
+		// Test case - 5.
+		// Access key of the server credential.
+		{`{"tenants":[{"name":"acme","credential":{"accessKey":"` + serverKey + `","secretKey":"acmesecretkey"}}]}`, false, ""},
+		// Test case - 6.
+		// Duplicate access keys.
+		{`{"tenants":[{"name":"acme","credential":{"accessKey":"ACMEACCESSKEY","secretKey":"acmesecretkey"}},{"name":"other","credential":{"accessKey":"ACMEACCESSKEY","secretKey":"othersecretkey"}}]}`, false, ""},
+		// Test case - 7.
+		// Overlapping bucket prefixes.
+		{`{"tenants":[{"name":"acme","credential":{"accessKey":"ACMEACCESSKEY","secretKey":"acmesecretkey"},"bucketPrefix":"a"},{"name":"other","credential":{"accessKey":"OTHERACCESSKEY","secretKey":"othersecretkey"},"bucketPrefix":"ab"}]}`, false, ""},
+		// Test case - 8.
+		// Negative quota.
+		{`{"tenants":[{"name":"acme","credential":{"accessKey":"ACMEACCESSKEY","secretKey":"acmesecretkey"},"maxSize":-1}]}`, false, ""},
+		// Test case - 9.
+		// Malformed document.
+		{`{"tenants":`, false, ""},
+	}
+	for i, testCase := range testCases {
+		config, err := parseTenantsConfig([]byte(testCase.configBuf))
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+		if err == nil && config.Tenants[0].BucketPrefix != testCase.expectedPrefix {
+			t.Errorf("Test %d: Expected bucket prefix %s, got %s", i+1, testCase.expectedPrefix, config.Tenants[0].BucketPrefix)
+		}
+	}
+}
+
+// Tests tenants are restricted to buckets of their namespace and to
+// their quotas.
+func TestTenantIsolation(t *testing.T) {
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	client := newS3TestClient(testServer)
+
+	configBuf := []byte(`{"tenants":[{"name":"acme","credential":{"accessKey":"ACMEACCESSKEY","secretKey":"acmesecretkey"},"maxBuckets":1,"maxSize":16}]}`)
+	resp, respBody, err := client.do("PUT", "minio", "admin/tenants", nil, nil, configBuf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutTenants", resp, respBody, http.StatusNoContent)
+	defer globalTenants.Set(nil)
+	tenantClient := client
+	tenantClient.accessKey = "ACMEACCESSKEY"
+	tenantClient.secretKey = "acmesecretkey"
+
+	// Server credential can create buckets outside of tenants.
+	resp, respBody, err = client.do("PUT", "other-bucket", "", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "MakeBucket", resp, respBody, http.StatusOK)
+
+	testCases := []struct {
+		method         string
+		bucket         string
+		object         string
+		headers        map[string]string
+		body           []byte
+		expectedStatus int
+	}{
+		// Test case - 1.
+		// Bucket of the tenant namespace.
+		{"PUT", "acme-photos", "", nil, nil, http.StatusOK},
+		// Test case - 2.
+		// Bucket outside of the tenant namespace.
+		{"PUT", "photos", "", nil, nil, http.StatusForbidden},
+		// Test case - 3.
+		// Bucket quota of the tenant.
+		{"PUT", "acme-videos", "", nil, nil, http.StatusBadRequest},
+		// Test case - 4.
+		{"PUT", "acme-photos", "object", nil, []byte("tenant object"), http.StatusOK},
+		// Test case - 5.
+		// Storage quota of the tenant.
+		{"PUT", "acme-photos", "large", nil, []byte("larger than the tenant quota"), http.StatusForbidden},
+		// Test case - 6.
+		// Copies count towards the storage quota.
+		{"PUT", "acme-photos", "copy", map[string]string{"X-Amz-Copy-Source": "/acme-photos/object"}, nil, http.StatusForbidden},
+		// Test case - 7.
+		{"PUT", "acme-photos", "small", nil, []byte("ok"), http.StatusOK},
+		// Test case - 8.
+		{"GET", "acme-photos", "object", nil, nil, http.StatusOK},
+		// Test case - 9.
+		// Buckets of others are not accessible.
+		{"GET", "other-bucket", "", nil, nil, http.StatusForbidden},
+		// Test case - 10.
+		// Admin APIs are not available to tenants.
+		{"GET", "minio", "admin/tenants", nil, nil, http.StatusForbidden},
+	}
+	for i, testCase := range testCases {
+		resp, respBody, err := tenantClient.do(testCase.method, testCase.bucket, testCase.object, nil, testCase.headers, testCase.body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != testCase.expectedStatus {
+			t.Errorf("Test %d: Expected status %d, got %d: %s", i+1, testCase.expectedStatus, resp.StatusCode, respBody)
+		}
+	}
+
+	// Requests failing authentication do not count towards the quota.
+	invalidClient := tenantClient
+	invalidClient.secretKey = "invalidsecretkey"
+	resp, respBody, err = invalidClient.do("PUT", "acme-photos", "invalid", nil, nil, []byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "PutObject", resp, respBody, http.StatusForbidden)
+	if size := globalTenants.getUsage("acme").Size; size != int64(len("tenant object")+len("ok")) {
+		t.Errorf("Expected usage of only written bytes, got %d", size)
+	}
+
+	// Tenant lists only buckets of its namespace.
+	resp, respBody, err = tenantClient.do("GET", "", "", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "ListBuckets", resp, respBody, http.StatusOK)
+	var listResponse ListBucketsResponse
+	if err = xmlDecoder(bytes.NewReader(respBody), &listResponse); err != nil {
+		t.Fatal(err)
+	}
+	if len(listResponse.Buckets.Buckets) != 1 || listResponse.Buckets.Buckets[0].Name != "acme-photos" {
+		t.Errorf("Expected only acme-photos to be listed, got %v", listResponse.Buckets.Buckets)
+	}
+
+	// Usage is reported along with quotas.
+	objAPI, err := newObjectLayer(testServer.Disks)
+	if err != nil {
+		t.Fatalf("Unable to initialize object layer. %s", err)
+	}
+	measureTenantUsage(objAPI)
+	resp, respBody, err = client.do("GET", "minio", "admin/tenant-usage", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectStatus(t, "TenantUsage", resp, respBody, http.StatusOK)
+	var usages []tenantUsage
+	if err = json.Unmarshal(respBody, &usages); err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 1 || usages[0].Buckets != 1 || usages[0].Objects != 2 || usages[0].Size != int64(len("tenant object")+len("ok")) {
+		t.Errorf("Unexpected tenant usage %v", usages)
+	}
+}
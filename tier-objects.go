@@ -0,0 +1,679 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Storage classes understood by the tiered object layer, all other
+// classes are stored on the hot tier.
+const (
+	storageClassStandard = "STANDARD"
+	storageClassColdIA   = "STANDARD_IA"
+	storageClassGlacier  = "GLACIER"
+)
+
+// Metadata key carrying the requested storage class of an object.
+const storageClassMetaKey = "X-Amz-Storage-Class"
+
+// Interval at which the demotion job scans the hot tier.
+const tierDemotionInterval = time.Hour
+
+// tierObjects - object layer placing objects on a hot (e.g. NVMe) or
+// a cold (e.g. HDD) set of disks. Objects are placed by storage class
+// and demoted from hot to cold once older than demoteAfter. Lookups
+// go to the hot tier first and fall back to the cold tier, so an
+// object is always reachable irrespective of where it lives.
+// Multipart uploads are always staged on the hot tier.
+type tierObjects struct {
+	hot  ObjectLayer
+	cold ObjectLayer
+
+	// Objects older than this are moved to the cold tier, zero
+	// disables demotion.
+	demoteAfter time.Duration
+}
+
+// newTierObjects - initialize tiered object layer and start the
+// demotion job.
+func newTierObjects(hot, cold ObjectLayer, demoteAfter time.Duration) ObjectLayer {
+	tier := tierObjects{
+		hot:         hot,
+		cold:        cold,
+		demoteAfter: demoteAfter,
+	}
+	if demoteAfter > 0 {
+		go tier.demotionJob()
+	}
+	return tier
+}
+
+// isColdStorageClass - returns true if class should be placed on cold tier.
+func isColdStorageClass(class string) bool {
+	return class == storageClassColdIA || class == storageClassGlacier
+}
+
+/// Storage operations
+
+// Capabilities - returns features supported by the hot tier, storage
+// classes decide the tier objects are placed on.
+func (t tierObjects) Capabilities() BackendCapabilities {
+	capabilities := t.hot.Capabilities()
+	capabilities.Snapshots = capabilities.Snapshots && t.cold.Capabilities().Snapshots
+	capabilities.StorageClasses = true
+	capabilities.MetadataFilter = capabilities.MetadataFilter && t.cold.Capabilities().MetadataFilter
+	capabilities.Healing = capabilities.Healing && t.cold.Capabilities().Healing
+	capabilities.Tagging = capabilities.Tagging && t.cold.Capabilities().Tagging
+	capabilities.ObjectLock = capabilities.ObjectLock && t.cold.Capabilities().ObjectLock
+	// Versions would be left behind by objects moving between tiers.
+	capabilities.Versioning = false
+	return capabilities
+}
+
+// StorageInfo - returns combined storage info of both tiers.
+func (t tierObjects) StorageInfo() StorageInfo {
+	hotInfo := t.hot.StorageInfo()
+	coldInfo := t.cold.StorageInfo()
+	return StorageInfo{
+		Total: hotInfo.Total + coldInfo.Total,
+		Free:  hotInfo.Free + coldInfo.Free,
+	}
+}
+
+/// Bucket operations
+
+// MakeBucket - make a bucket on both tiers.
+func (t tierObjects) MakeBucket(bucket string) error {
+	if err := t.hot.MakeBucket(bucket); err != nil {
+		return err
+	}
+	if err := t.cold.MakeBucket(bucket); err != nil {
+		// Undo bucket on hot tier.
+		t.hot.DeleteBucket(bucket)
+		return err
+	}
+	return nil
+}
+
+// GetBucketInfo - returns bucket info from hot tier.
+func (t tierObjects) GetBucketInfo(bucket string) (BucketInfo, error) {
+	return t.hot.GetBucketInfo(bucket)
+}
+
+// ListBuckets - lists buckets from hot tier.
+func (t tierObjects) ListBuckets() ([]BucketInfo, error) {
+	return t.hot.ListBuckets()
+}
+
+// DeleteBucket - deletes a bucket on both tiers, bucket must be empty
+// on both.
+func (t tierObjects) DeleteBucket(bucket string) error {
+	result, err := t.cold.ListObjects(bucket, "", "", "", 1)
+	if err != nil {
+		if _, ok := err.(BucketNotFound); !ok {
+			return err
+		}
+	} else if len(result.Objects) > 0 || len(result.Prefixes) > 0 {
+		return BucketNotEmpty{Bucket: bucket}
+	}
+	if err = t.hot.DeleteBucket(bucket); err != nil {
+		return err
+	}
+	if err = t.cold.DeleteBucket(bucket); err != nil {
+		if _, ok := err.(BucketNotFound); !ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// EraseBucket - erases a bucket on both tiers, cold tier may not have
+// the bucket.
+func (t tierObjects) EraseBucket(bucket string, overwrite bool) error {
+	if err := t.hot.EraseBucket(bucket, overwrite); err != nil {
+		return err
+	}
+	if err := t.cold.EraseBucket(bucket, overwrite); err != nil {
+		if _, ok := err.(BucketNotFound); !ok {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListObjects - lists objects from both tiers merged in lexical order.
+func (t tierObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKeys int) (ListObjectsInfo, error) {
+	hotResult, err := t.hot.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	if err != nil {
+		return ListObjectsInfo{}, err
+	}
+	coldResult, err := t.cold.ListObjects(bucket, prefix, marker, delimiter, maxKeys)
+	if err != nil {
+		return ListObjectsInfo{}, err
+	}
+	return mergeListObjectsInfo(hotResult, coldResult, maxKeys), nil
+}
+
+// ListObjectsV2 - lists objects from both tiers after the continuation
+// token, or after startAfter for the first page.
+func (t tierObjects) ListObjectsV2(bucket, prefix, continuationToken, delimiter string, maxKeys int, startAfter string) (ListObjectsV2Info, error) {
+	return listObjectsV2(t.ListObjects, bucket, prefix, continuationToken, delimiter, maxKeys, startAfter)
+}
+
+// mergeListObjectsInfo - merges two sorted listings, entries present
+// in both are returned once preferring the first listing.
+func mergeListObjectsInfo(first, second ListObjectsInfo, maxKeys int) ListObjectsInfo {
+	if maxKeys < 0 || maxKeys > maxObjectList {
+		maxKeys = maxObjectList
+	}
+
+	// Collect all entries, prefixes are represented as directories.
+	entries := make(map[string]ObjectInfo)
+	for _, result := range []ListObjectsInfo{second, first} {
+		for _, objInfo := range result.Objects {
+			entries[objInfo.Name] = objInfo
+		}
+		for _, prefix := range result.Prefixes {
+			entries[prefix] = ObjectInfo{Name: prefix, IsDir: true}
+		}
+	}
+	var names []string
+	for name := range entries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	// Entries beyond the end of a truncated listing are not known to
+	// be complete, stop there.
+	var limit string
+	for _, result := range []ListObjectsInfo{first, second} {
+		if !result.IsTruncated {
+			continue
+		}
+		last := lastListEntry(result)
+		if limit == "" || last < limit {
+			limit = last
+		}
+	}
+
+	merged := ListObjectsInfo{}
+	for _, name := range names {
+		if limit != "" && name > limit {
+			merged.IsTruncated = true
+			break
+		}
+		if len(merged.Objects)+len(merged.Prefixes) == maxKeys {
+			merged.IsTruncated = true
+			break
+		}
+		if entries[name].IsDir {
+			merged.Prefixes = append(merged.Prefixes, name)
+		} else {
+			merged.Objects = append(merged.Objects, entries[name])
+		}
+		merged.NextMarker = name
+	}
+	if !merged.IsTruncated {
+		merged.NextMarker = ""
+	}
+	return merged
+}
+
+// lastListEntry - returns the lexically last entry of a listing.
+func lastListEntry(result ListObjectsInfo) (last string) {
+	for _, objInfo := range result.Objects {
+		if objInfo.Name > last {
+			last = objInfo.Name
+		}
+	}
+	for _, prefix := range result.Prefixes {
+		if prefix > last {
+			last = prefix
+		}
+	}
+	return last
+}
+
+/// Bucket snapshot operations, snapshots span both tiers.
+
+// SnapshotBucket - snapshot a bucket on both tiers with the same id.
+func (t tierObjects) SnapshotBucket(bucket, snapshotID string) (BucketSnapshotInfo, error) {
+	snapshotInfo, err := t.hot.SnapshotBucket(bucket, snapshotID)
+	if err != nil {
+		return BucketSnapshotInfo{}, err
+	}
+	if _, err = t.cold.SnapshotBucket(bucket, snapshotID); err != nil {
+		// Undo snapshot on hot tier.
+		t.hot.DeleteBucketSnapshot(bucket, snapshotID)
+		return BucketSnapshotInfo{}, err
+	}
+	return snapshotInfo, nil
+}
+
+// ListBucketSnapshots - lists snapshots from hot tier.
+func (t tierObjects) ListBucketSnapshots(bucket string) ([]BucketSnapshotInfo, error) {
+	return t.hot.ListBucketSnapshots(bucket)
+}
+
+// CloneBucketSnapshot - clones the snapshot on both tiers.
+func (t tierObjects) CloneBucketSnapshot(bucket, snapshotID, cloneBucket string) error {
+	if err := t.hot.CloneBucketSnapshot(bucket, snapshotID, cloneBucket); err != nil {
+		return err
+	}
+	// Clone on hot tier is kept on failure, objects of the cold tier
+	// are missing in the clone in that case.
+	return t.cold.CloneBucketSnapshot(bucket, snapshotID, cloneBucket)
+}
+
+// DeleteBucketSnapshot - deletes the snapshot on both tiers.
+func (t tierObjects) DeleteBucketSnapshot(bucket, snapshotID string) error {
+	if err := t.hot.DeleteBucketSnapshot(bucket, snapshotID); err != nil {
+		return err
+	}
+	return t.cold.DeleteBucketSnapshot(bucket, snapshotID)
+}
+
+/// Object operations
+
+// getObjectTier - returns the tier on which the object lives.
+func (t tierObjects) getObjectTier(bucket, object string) (ObjectLayer, ObjectInfo, error) {
+	objInfo, err := t.hot.GetObjectInfo(bucket, object)
+	if err == nil {
+		return t.hot, objInfo, nil
+	}
+	if _, ok := err.(ObjectNotFound); !ok {
+		return nil, ObjectInfo{}, err
+	}
+	objInfo, err = t.cold.GetObjectInfo(bucket, object)
+	if err != nil {
+		return nil, ObjectInfo{}, err
+	}
+	return t.cold, objInfo, nil
+}
+
+// GetObject - reads an object from the tier it lives on.
+func (t tierObjects) GetObject(bucket, object string, startOffset int64, length int64, writer io.Writer) error {
+	objLayer, _, err := t.getObjectTier(bucket, object)
+	if err != nil {
+		return err
+	}
+	return objLayer.GetObject(bucket, object, startOffset, length, writer)
+}
+
+// GetObjectInfo - returns object info from the tier it lives on.
+func (t tierObjects) GetObjectInfo(bucket, object string) (ObjectInfo, error) {
+	_, objInfo, err := t.getObjectTier(bucket, object)
+	return objInfo, err
+}
+
+// PutObject - places the object on the tier matching its storage
+// class, any older copy on the other tier is removed.
+func (t tierObjects) PutObject(bucket, object string, size int64, data io.Reader, metadata map[string]string) (string, error) {
+	target, other := t.hot, t.cold
+	if isColdStorageClass(metadata[storageClassMetaKey]) {
+		target, other = t.cold, t.hot
+	}
+	// Create-only uploads are checked atomically on the target tier,
+	// objects on the other tier are checked before.
+	if metadata[objectCreateOnlyKey] == "true" {
+		if _, err := other.GetObjectInfo(bucket, object); err == nil {
+			return "", ObjectPreconditionFailed{Bucket: bucket, Object: object}
+		}
+	}
+	md5Sum, err := target.PutObject(bucket, object, size, data, metadata)
+	if err != nil {
+		return "", err
+	}
+	other.DeleteObject(bucket, object)
+	return md5Sum, nil
+}
+
+// PatchObject - patches the object on the tier it lives on.
+func (t tierObjects) PatchObject(bucket, object string, offset, size int64, data io.Reader) (string, error) {
+	objLayer, _, err := t.getObjectTier(bucket, object)
+	if err != nil {
+		return "", err
+	}
+	return objLayer.PatchObject(bucket, object, offset, size, data)
+}
+
+// ComposeObject - composes the object on the tier all sources live
+// on, any older copy on the other tier is removed.
+func (t tierObjects) ComposeObject(bucket, object string, sources []string, metadata map[string]string) (string, error) {
+	var target ObjectLayer
+	for _, source := range sources {
+		objLayer, _, err := t.getObjectTier(bucket, source)
+		if err != nil {
+			return "", err
+		}
+		if target != nil && objLayer != target {
+			return "", ComposeTiersMixed{Bucket: bucket, Object: object}
+		}
+		target = objLayer
+	}
+	if target == nil {
+		return "", errInvalidArgument
+	}
+	md5Sum, err := target.ComposeObject(bucket, object, sources, metadata)
+	if err != nil {
+		return "", err
+	}
+	other := t.hot
+	if target == t.hot {
+		other = t.cold
+	}
+	other.DeleteObject(bucket, object)
+	return md5Sum, nil
+}
+
+// CopyObject - copies the object to the tier matching its storage
+// class, within a tier the copy is done by the tier itself. Any older
+// copy on the other tier is removed.
+func (t tierObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string, metadata map[string]string) (string, error) {
+	source, _, err := t.getObjectTier(srcBucket, srcObject)
+	if err != nil {
+		return "", err
+	}
+	target, other := t.hot, t.cold
+	if isColdStorageClass(metadata[storageClassMetaKey]) {
+		target, other = t.cold, t.hot
+	}
+	var md5Sum string
+	if source == target {
+		md5Sum, err = target.CopyObject(srcBucket, srcObject, dstBucket, dstObject, metadata)
+	} else {
+		md5Sum, err = copyObjectData(source, srcBucket, srcObject, target, dstBucket, dstObject, metadata)
+	}
+	if err != nil {
+		return "", err
+	}
+	other.DeleteObject(dstBucket, dstObject)
+	return md5Sum, nil
+}
+
+// DeleteObject - deletes the object from both tiers.
+func (t tierObjects) DeleteObject(bucket, object string) error {
+	hotErr := t.hot.DeleteObject(bucket, object)
+	coldErr := t.cold.DeleteObject(bucket, object)
+	if hotErr == nil || coldErr == nil {
+		return nil
+	}
+	if _, ok := hotErr.(ObjectNotFound); !ok {
+		return hotErr
+	}
+	return coldErr
+}
+
+// DeleteObjects - deletes a batch of objects from both tiers.
+func (t tierObjects) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	hotErrs, err := t.hot.DeleteObjects(bucket, objects)
+	if err != nil {
+		return nil, err
+	}
+	coldErrs, err := t.cold.DeleteObjects(bucket, objects)
+	if err != nil {
+		return nil, err
+	}
+	errs := make([]error, len(objects))
+	for index := range objects {
+		if hotErrs[index] == nil || coldErrs[index] == nil {
+			continue
+		}
+		if _, ok := hotErrs[index].(ObjectNotFound); !ok {
+			errs[index] = hotErrs[index]
+			continue
+		}
+		errs[index] = coldErrs[index]
+	}
+	return errs, nil
+}
+
+// PutObjectTags - sets tags of the object on the tier it lives on.
+func (t tierObjects) PutObjectTags(bucket, object string, tags map[string]string) error {
+	objLayer, _, err := t.getObjectTier(bucket, object)
+	if err != nil {
+		return err
+	}
+	return objLayer.PutObjectTags(bucket, object, tags)
+}
+
+// PutObjectRetention - sets retention of the object on the tier it
+// lives on.
+func (t tierObjects) PutObjectRetention(bucket, object string, retention objectRetention) error {
+	objLayer, _, err := t.getObjectTier(bucket, object)
+	if err != nil {
+		return err
+	}
+	return objLayer.PutObjectRetention(bucket, object, retention)
+}
+
+/// Object version operations, not supported since versions would not
+/// follow objects moving between tiers.
+
+// GetObjectVersion - versioning is not supported.
+func (t tierObjects) GetObjectVersion(bucket, object, versionID string, startOffset int64, length int64, writer io.Writer) error {
+	return VersioningNotSupported{}
+}
+
+// GetObjectVersionInfo - versioning is not supported.
+func (t tierObjects) GetObjectVersionInfo(bucket, object, versionID string) (ObjectInfo, error) {
+	return ObjectInfo{}, VersioningNotSupported{}
+}
+
+// DeleteObjectVersion - versioning is not supported.
+func (t tierObjects) DeleteObjectVersion(bucket, object, versionID string) error {
+	return VersioningNotSupported{}
+}
+
+// ListObjectVersions - versioning is not supported.
+func (t tierObjects) ListObjectVersions(bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int) (ListObjectVersionsInfo, error) {
+	return ListObjectVersionsInfo{}, VersioningNotSupported{}
+}
+
+/// Multipart operations, always staged on the hot tier.
+
+// ListMultipartUploads - lists multipart uploads on hot tier.
+func (t tierObjects) ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter string, maxUploads int) (ListMultipartsInfo, error) {
+	return t.hot.ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMarker, delimiter, maxUploads)
+}
+
+// NewMultipartUpload - initiates a multipart upload on hot tier.
+func (t tierObjects) NewMultipartUpload(bucket, object string, metadata map[string]string) (string, error) {
+	return t.hot.NewMultipartUpload(bucket, object, metadata)
+}
+
+// PutObjectPart - uploads a part on hot tier.
+func (t tierObjects) PutObjectPart(bucket, object, uploadID string, partID int, size int64, data io.Reader, md5Hex string) (string, error) {
+	return t.hot.PutObjectPart(bucket, object, uploadID, partID, size, data, md5Hex)
+}
+
+// CopyObjectPart - uploads a part on hot tier from a range of the
+// source, sources on cold tier are copied through the server.
+func (t tierObjects) CopyObjectPart(srcBucket, srcObject, bucket, object, uploadID string, partID int, startOffset, length int64) (string, error) {
+	source, _, err := t.getObjectTier(srcBucket, srcObject)
+	if err != nil {
+		return "", err
+	}
+	if source == t.hot {
+		return t.hot.CopyObjectPart(srcBucket, srcObject, bucket, object, uploadID, partID, startOffset, length)
+	}
+	return copyObjectPartData(source, srcBucket, srcObject, t.hot, bucket, object, uploadID, partID, startOffset, length)
+}
+
+// ListObjectParts - lists uploaded parts on hot tier.
+func (t tierObjects) ListObjectParts(bucket, object, uploadID string, partNumberMarker int, maxParts int) (ListPartsInfo, error) {
+	return t.hot.ListObjectParts(bucket, object, uploadID, partNumberMarker, maxParts)
+}
+
+// AbortMultipartUpload - aborts a multipart upload on hot tier.
+func (t tierObjects) AbortMultipartUpload(bucket, object, uploadID string) error {
+	return t.hot.AbortMultipartUpload(bucket, object, uploadID)
+}
+
+// CompleteMultipartUpload - completes a multipart upload on hot tier,
+// any older copy on cold tier is removed.
+func (t tierObjects) CompleteMultipartUpload(bucket, object, uploadID string, uploadedParts []completePart) (string, error) {
+	md5Sum, err := t.hot.CompleteMultipartUpload(bucket, object, uploadID, uploadedParts)
+	if err != nil {
+		return "", err
+	}
+	t.cold.DeleteObject(bucket, object)
+	return md5Sum, nil
+}
+
+/// Healing operations
+
+// HealBucket - heals the bucket on both tiers.
+func (t tierObjects) HealBucket(bucket string, dryRun bool) (BucketHealInfo, error) {
+	info, err := t.hot.HealBucket(bucket, dryRun)
+	if err != nil {
+		return BucketHealInfo{}, err
+	}
+	coldInfo, err := t.cold.HealBucket(bucket, dryRun)
+	if err != nil {
+		return BucketHealInfo{}, err
+	}
+	info.MissingDisks = append(info.MissingDisks, coldInfo.MissingDisks...)
+	info.Objects = append(info.Objects, coldInfo.Objects...)
+	return info, nil
+}
+
+// HealObject - heals the object on the tier it lives on.
+func (t tierObjects) HealObject(bucket, object string, dryRun bool) (ObjectHealInfo, error) {
+	objLayer, _, err := t.getObjectTier(bucket, object)
+	if err != nil {
+		return ObjectHealInfo{}, err
+	}
+	return objLayer.HealObject(bucket, object, dryRun)
+}
+
+/// Auditing operations
+
+// GetObjectChecksums - returns checksums of the object on the tier it
+// lives on.
+func (t tierObjects) GetObjectChecksums(bucket, object string) (ObjectChecksumInfo, error) {
+	objLayer, _, err := t.getObjectTier(bucket, object)
+	if err != nil {
+		return ObjectChecksumInfo{}, err
+	}
+	return objLayer.GetObjectChecksums(bucket, object)
+}
+
+/// Demotion
+
+// demotionJob - periodically demotes cold objects, runs forever.
+func (t tierObjects) demotionJob() {
+	for {
+		t.demoteObjects(time.Now().UTC().Add(-t.demoteAfter), false)
+		time.Sleep(tierDemotionInterval)
+	}
+}
+
+// demoteObjects - moves all objects on hot tier last modified before
+// olderThan to cold tier, returns plan entries of demoted objects.
+// Nothing is moved in dry-run.
+func (t tierObjects) demoteObjects(olderThan time.Time, dryRun bool) []planEntry {
+	buckets, err := t.hot.ListBuckets()
+	if err != nil {
+		errorIf(err, "Unable to list buckets for demotion.")
+		return nil
+	}
+	var entries []planEntry
+	for _, bucket := range buckets {
+		marker := ""
+		for {
+			result, err := t.hot.ListObjects(bucket.Name, "", marker, "", maxObjectList)
+			if err != nil {
+				errorIf(err, "Unable to list objects of %s for demotion.", bucket.Name)
+				break
+			}
+			for _, objInfo := range result.Objects {
+				if objInfo.ModTime.After(olderThan) {
+					continue
+				}
+				if !dryRun {
+					err = t.demoteObject(bucket.Name, objInfo)
+					errorIf(err, "Unable to demote %s/%s.", bucket.Name, objInfo.Name)
+				}
+				entries = append(entries, newPlanEntry(planActionDemote, bucket.Name, objInfo, err))
+			}
+			if !result.IsTruncated {
+				break
+			}
+			marker = result.NextMarker
+			if marker == "" && len(result.Objects) > 0 {
+				marker = result.Objects[len(result.Objects)-1].Name
+			}
+		}
+	}
+	return entries
+}
+
+// demoteObject - copies the object to cold tier and removes it from
+// hot tier if it was not modified meanwhile. Objects uploaded with
+// multipart get a plain md5 ETag once demoted.
+func (t tierObjects) demoteObject(bucket string, objInfo ObjectInfo) error {
+	metadata := map[string]string{
+		"content-type":      objInfo.ContentType,
+		"content-encoding":  objInfo.ContentEncoding,
+		"cache-control":     objInfo.CacheControl,
+		storageClassMetaKey: storageClassColdIA,
+	}
+	// User metadata, client side encryption envelopes included, moves
+	// with the object.
+	for key, value := range objInfo.UserDefined {
+		metadata[key] = value
+	}
+	setObjectTags(metadata, objInfo.Tags)
+	setObjectRetention(metadata, objInfo.Retention)
+	setObjectExpiry(metadata, objInfo.Expires)
+	setObjectProvenance(metadata, objInfo.Provenance)
+	metadata[storageClassMetaKey] = storageClassColdIA
+	if !strings.Contains(objInfo.MD5Sum, "-") {
+		metadata["md5Sum"] = objInfo.MD5Sum
+	}
+
+	// Both tiers share the namespace lock, the object is staged before
+	// it is written to cold tier.
+	stagingFile, err := newStagingFile()
+	if err != nil {
+		return err
+	}
+	defer removeStagingFile(stagingFile)
+	if err = t.hot.GetObject(bucket, objInfo.Name, 0, objInfo.Size, stagingFile); err != nil {
+		return err
+	}
+	if _, err = stagingFile.Seek(0, 0); err != nil {
+		return err
+	}
+	if _, err = t.cold.PutObject(bucket, objInfo.Name, objInfo.Size, stagingFile, metadata); err != nil {
+		return err
+	}
+
+	// Object was overwritten during demotion, keep the new one on hot
+	// tier and discard the demoted copy.
+	curInfo, err := t.hot.GetObjectInfo(bucket, objInfo.Name)
+	if err != nil || !curInfo.ModTime.Equal(objInfo.ModTime) || curInfo.MD5Sum != objInfo.MD5Sum {
+		t.cold.DeleteObject(bucket, objInfo.Name)
+		return err
+	}
+	return t.hot.DeleteObject(bucket, objInfo.Name)
+}
@@ -0,0 +1,228 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// Tests merging of listings from hot and cold tiers.
+func TestMergeListObjectsInfo(t *testing.T) {
+	objects := func(names ...string) (objInfos []ObjectInfo) {
+		for _, name := range names {
+			objInfos = append(objInfos, ObjectInfo{Name: name})
+		}
+		return objInfos
+	}
+	testCases := []struct {
+		first, second ListObjectsInfo
+		maxKeys       int
+		expected      ListObjectsInfo
+	}{
+		// Test case - 1.
+		// Disjoint listings are interleaved.
+		{
+			ListObjectsInfo{Objects: objects("a", "c")},
+			ListObjectsInfo{Objects: objects("b", "d")},
+			10,
+			ListObjectsInfo{Objects: objects("a", "b", "c", "d")},
+		},
+		// Test case - 2.
+		// Duplicates and common prefixes are returned once.
+		{
+			ListObjectsInfo{Objects: objects("a"), Prefixes: []string{"dir/"}},
+			ListObjectsInfo{Objects: objects("a", "b"), Prefixes: []string{"dir/"}},
+			10,
+			ListObjectsInfo{Objects: objects("a", "b"), Prefixes: []string{"dir/"}},
+		},
+		// Test case - 3.
+		// Merged listing is truncated at maxKeys.
+		{
+			ListObjectsInfo{Objects: objects("a", "c")},
+			ListObjectsInfo{Objects: objects("b", "d")},
+			3,
+			ListObjectsInfo{IsTruncated: true, NextMarker: "c", Objects: objects("a", "b", "c")},
+		},
+		// Test case - 4.
+		// Entries beyond a truncated listing are left for the next page.
+		{
+			ListObjectsInfo{IsTruncated: true, NextMarker: "b", Objects: objects("a", "b")},
+			ListObjectsInfo{Objects: objects("c")},
+			2,
+			ListObjectsInfo{IsTruncated: true, NextMarker: "b", Objects: objects("a", "b")},
+		},
+	}
+	for i, testCase := range testCases {
+		result := mergeListObjectsInfo(testCase.first, testCase.second, testCase.maxKeys)
+		if !reflect.DeepEqual(result, testCase.expected) {
+			t.Errorf("Test %d: Expected %+v, got %+v", i+1, testCase.expected, result)
+		}
+	}
+}
+
+// Tests object placement by storage class and demotion to cold tier.
+func TestTierObjects(t *testing.T) {
+	hot, hotDir, err := getSingleNodeObjectLayer()
+	if err != nil {
+		t.Fatalf("Unable to initialize hot tier. %s", err)
+	}
+	defer removeAll(hotDir)
+	cold, coldDir, err := getSingleNodeObjectLayer()
+	if err != nil {
+		t.Fatalf("Unable to initialize cold tier. %s", err)
+	}
+	defer removeAll(coldDir)
+	tier := newTierObjects(hot, cold, 0).(tierObjects)
+	if err = tier.MakeBucket("bucket"); err != nil {
+		t.Fatalf("Unable to make bucket. %s", err)
+	}
+
+	data := []byte("hello world")
+	testCases := []struct {
+		object       string
+		storageClass string
+		expectCold   bool
+	}{
+		// Test case - 1.
+		{"standard", "", false},
+		// Test case - 2.
+		{"infrequent", storageClassColdIA, true},
+		// Test case - 3.
+		{"archive", storageClassGlacier, true},
+		// Test case - 4.
+		// Unknown classes stay on hot tier.
+		{"reduced", "REDUCED_REDUNDANCY", false},
+	}
+	// TTL of objects moves with them between tiers.
+	expires := time.Now().UTC().Add(time.Hour)
+	for i, testCase := range testCases {
+		metadata := map[string]string{storageClassMetaKey: testCase.storageClass}
+		setObjectExpiry(metadata, expires)
+		if _, err = tier.PutObject("bucket", testCase.object, int64(len(data)), bytes.NewReader(data), metadata); err != nil {
+			t.Fatalf("Test %d: Unable to put object. %s", i+1, err)
+		}
+		_, err = cold.GetObjectInfo("bucket", testCase.object)
+		if testCase.expectCold != (err == nil) {
+			t.Errorf("Test %d: Expected object on cold tier %t, got error %v", i+1, testCase.expectCold, err)
+		}
+		buffer := new(bytes.Buffer)
+		if err = tier.GetObject("bucket", testCase.object, 0, int64(len(data)), buffer); err != nil {
+			t.Fatalf("Test %d: Unable to get object. %s", i+1, err)
+		}
+		if !bytes.Equal(buffer.Bytes(), data) {
+			t.Errorf("Test %d: Expected %s, got %s", i+1, data, buffer.Bytes())
+		}
+	}
+
+	result, err := tier.ListObjects("bucket", "", "", "", 1000)
+	if err != nil {
+		t.Fatalf("Unable to list objects. %s", err)
+	}
+	if len(result.Objects) != len(testCases) {
+		t.Errorf("Expected %d objects, got %d", len(testCases), len(result.Objects))
+	}
+
+	// Dry-run reports objects on hot tier without moving them.
+	entries := tier.demoteObjects(time.Now().UTC().Add(time.Minute), true)
+	if len(entries) != 2 {
+		t.Errorf("Expected plan to demote 2 objects, got %v", entries)
+	}
+	for _, entry := range entries {
+		if _, err = hot.GetObjectInfo("bucket", entry.Object); err != nil {
+			t.Errorf("Expected %s to remain on hot tier after dry-run, failed with %s", entry.Object, err)
+		}
+	}
+
+	// All objects are demoted to cold tier and remain readable.
+	if entries = tier.demoteObjects(time.Now().UTC().Add(time.Minute), false); len(entries) != 2 {
+		t.Errorf("Expected 2 objects to be demoted, got %v", entries)
+	}
+	for i, testCase := range testCases {
+		if _, err = hot.GetObjectInfo("bucket", testCase.object); err == nil {
+			t.Errorf("Test %d: Expected object to be removed from hot tier.", i+1)
+		}
+		objInfo, err := tier.GetObjectInfo("bucket", testCase.object)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to get object info. %s", i+1, err)
+		}
+		if objInfo.Size != int64(len(data)) {
+			t.Errorf("Test %d: Expected size %d, got %d", i+1, len(data), objInfo.Size)
+		}
+		if !objInfo.Expires.Equal(expires) {
+			t.Errorf("Test %d: Expected expiry %s, got %s", i+1, expires, objInfo.Expires)
+		}
+	}
+
+	// Create-only uploads fail for objects on the other tier.
+	createOnly := map[string]string{objectCreateOnlyKey: "true"}
+	if _, err = tier.PutObject("bucket", "standard", int64(len(data)), bytes.NewReader(data), createOnly); err == nil {
+		t.Errorf("Expected create-only upload of an object on cold tier to fail.")
+	} else if _, ok := err.(ObjectPreconditionFailed); !ok {
+		t.Errorf("Expected ObjectPreconditionFailed, got %s", err)
+	}
+
+	// Deleting the object removes it from all tiers.
+	if err = tier.DeleteObject("bucket", "standard"); err != nil {
+		t.Fatalf("Unable to delete object. %s", err)
+	}
+	if _, err = tier.GetObjectInfo("bucket", "standard"); err == nil {
+		t.Errorf("Expected object to be deleted.")
+	}
+}
+
+// Tests demoted objects keep the provenance of their write.
+func TestTierObjectsProvenance(t *testing.T) {
+	hot, hotDisks, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatalf("Unable to initialize hot tier. %s", err)
+	}
+	defer removeRoots(hotDisks)
+	cold, coldDisks, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatalf("Unable to initialize cold tier. %s", err)
+	}
+	defer removeRoots(coldDisks)
+	tier := newTierObjects(hot, cold, 0).(tierObjects)
+	if err = tier.MakeBucket("bucket"); err != nil {
+		t.Fatalf("Unable to make bucket. %s", err)
+	}
+
+	data := []byte("hello world")
+	if _, err = tier.PutObject("bucket", "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("Unable to put object. %s", err)
+	}
+	objInfo, err := hot.GetObjectInfo("bucket", "object")
+	if err != nil {
+		t.Fatalf("Unable to get object info. %s", err)
+	}
+	if objInfo.Provenance.WriteTime.IsZero() {
+		t.Fatal("Expected object to be stamped with provenance.")
+	}
+	if entries := tier.demoteObjects(time.Now().UTC().Add(time.Minute), false); len(entries) != 1 {
+		t.Fatalf("Expected 1 object to be demoted, got %v", entries)
+	}
+	demotedInfo, err := cold.GetObjectInfo("bucket", "object")
+	if err != nil {
+		t.Fatalf("Unable to get demoted object info. %s", err)
+	}
+	if demotedInfo.Provenance != objInfo.Provenance {
+		t.Errorf("Expected provenance %+v, got %+v", objInfo.Provenance, demotedInfo.Provenance)
+	}
+}
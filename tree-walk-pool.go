@@ -46,6 +46,7 @@ type treeWalk struct {
 	resultCh   chan treeWalkResult
 	endWalkCh  chan struct{}   // To signal when treeWalk go-routine should end.
 	endTimerCh chan<- struct{} // To signal when timer go-routine should end.
+	added      time.Time       // Time at which treeWalk was added to the pool.
 }
 
 // treeWalkPool - pool of treeWalk go routines.
@@ -54,17 +55,19 @@ type treeWalk struct {
 // treeWalkPool's purpose is to maintain active treeWalk go-routines in a map so that
 // it can be looked up across related list calls.
 type treeWalkPool struct {
-	pool    map[listParams][]treeWalk
-	timeOut time.Duration
-	lock    *sync.Mutex
+	pool     map[listParams][]treeWalk
+	timeOut  time.Duration
+	maxWalks int // Maximum treeWalks in the pool, 0 is unlimited.
+	lock     *sync.Mutex
 }
 
 // newTreeWalkPool - initialize new tree walk pool.
 func newTreeWalkPool(timeout time.Duration) *treeWalkPool {
 	tPool := &treeWalkPool{
-		pool:    make(map[listParams][]treeWalk),
-		timeOut: timeout,
-		lock:    &sync.Mutex{},
+		pool:     make(map[listParams][]treeWalk),
+		timeOut:  timeout,
+		maxWalks: globalMaxTreeWalks,
+		lock:     &sync.Mutex{},
 	}
 	return tPool
 }
@@ -95,6 +98,33 @@ func (t treeWalkPool) Release(params listParams) (resultCh chan treeWalkResult,
 	return nil, nil
 }
 
+// evictOldest - removes the oldest treeWalk from the pool and ends
+// its go-routine, must be called with the lock held.
+func (t treeWalkPool) evictOldest() {
+	var oldestParams listParams
+	var oldestIndex = -1
+	var oldest treeWalk
+	for params, walks := range t.pool {
+		for i, walk := range walks {
+			if oldestIndex == -1 || walk.added.Before(oldest.added) {
+				oldestParams, oldestIndex, oldest = params, i, walk
+			}
+		}
+	}
+	if oldestIndex == -1 {
+		return
+	}
+	walks := t.pool[oldestParams]
+	walks = append(walks[:oldestIndex], walks[oldestIndex+1:]...)
+	if len(walks) > 0 {
+		t.pool[oldestParams] = walks
+	} else {
+		delete(t.pool, oldestParams)
+	}
+	oldest.endTimerCh <- struct{}{}
+	close(oldest.endWalkCh)
+}
+
 // Set - adds a treeWalk to the treeWalkPool.
 // Also starts a timer go-routine that ends when:
 // 1) time.After() expires after t.timeOut seconds.
@@ -107,12 +137,25 @@ func (t treeWalkPool) Set(params listParams, resultCh chan treeWalkResult, endWa
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	// Each treeWalk buffers results in memory, evict the oldest
+	// once the pool is full.
+	if t.maxWalks > 0 {
+		var count int
+		for _, walks := range t.pool {
+			count += len(walks)
+		}
+		if count >= t.maxWalks {
+			t.evictOldest()
+		}
+	}
+
 	// Should be a buffered channel so that Release() never blocks.
 	endTimerCh := make(chan struct{}, 1)
 	walkInfo := treeWalk{
 		resultCh:   resultCh,
 		endWalkCh:  endWalkCh,
 		endTimerCh: endTimerCh,
+		added:      time.Now().UTC(),
 	}
 	// Append new walk info.
 	t.pool[params] = append(t.pool[params], walkInfo)
@@ -125,12 +168,16 @@ func (t treeWalkPool) Set(params listParams, resultCh chan treeWalkResult, endWa
 			// Timeout has expired. Remove the treeWalk from treeWalkPool and
 			// end the treeWalk go-routine.
 			t.lock.Lock()
+			// treeWalk might have been released or evicted meanwhile.
+			var found bool
 			walks, ok := t.pool[params]
 			if ok {
 				// Look for walkInfo, remove it from the walks list.
 				for i, walk := range walks {
 					if walk == walkInfo {
 						walks = append(walks[:i], walks[i+1:]...)
+						found = true
+						break
 					}
 				}
 				if len(walks) == 0 {
@@ -144,7 +191,9 @@ func (t treeWalkPool) Set(params listParams, resultCh chan treeWalkResult, endWa
 				}
 			}
 			// Signal the treeWalk go-routine to die.
-			close(endWalkCh)
+			if found {
+				close(endWalkCh)
+			}
 			t.lock.Unlock()
 		case <-endTimerCh:
 			return
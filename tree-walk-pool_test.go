@@ -0,0 +1,62 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests oldest treeWalks are evicted once the pool is full.
+func TestTreeWalkPoolEviction(t *testing.T) {
+	tPool := newTreeWalkPool(time.Minute)
+	tPool.maxWalks = 2
+
+	var endWalkChs []chan struct{}
+	var params []listParams
+	for i, marker := range []string{"a", "b", "c"} {
+		param := listParams{bucket: "bucket", marker: marker}
+		endWalkCh := make(chan struct{})
+		tPool.Set(param, make(chan treeWalkResult), endWalkCh)
+		params = append(params, param)
+		endWalkChs = append(endWalkChs, endWalkCh)
+		// Ensure distinct insertion times.
+		if i == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// Oldest treeWalk is ended and removed from the pool.
+	select {
+	case <-endWalkChs[0]:
+	default:
+		t.Errorf("Expected oldest treeWalk to be ended")
+	}
+	if resultCh, _ := tPool.Release(params[0]); resultCh != nil {
+		t.Errorf("Expected oldest treeWalk to be evicted")
+	}
+	for i := 1; i < len(params); i++ {
+		if resultCh, _ := tPool.Release(params[i]); resultCh == nil {
+			t.Errorf("Test %d: Expected treeWalk to be in the pool", i+1)
+		}
+		select {
+		case <-endWalkChs[i]:
+			t.Errorf("Test %d: Expected treeWalk to be running", i+1)
+		default:
+		}
+	}
+}
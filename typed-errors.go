@@ -29,3 +29,30 @@ var errSignatureMismatch = errors.New("Signature does not match")
 
 // used when token used for authentication by the MinioBrowser has expired
 var errInvalidToken = errors.New("Invalid token")
+
+// errUpdateChecksumMismatch - downloaded update binary doesn't match expected checksum.
+var errUpdateChecksumMismatch = errors.New("Update binary checksum mismatch")
+
+// errUpdateNotStaged - restart requested without a downloaded update binary.
+var errUpdateNotStaged = errors.New("Update binary not staged")
+
+// errUpdateQuorumLost - restarting the node would lose write quorum.
+var errUpdateQuorumLost = errors.New("Restarting node would lose write quorum")
+
+// errUpdateNodeOffline - node did not come back online after restart.
+var errUpdateNodeOffline = errors.New("Node did not come back online after restart")
+
+// errConfigNotFound - requested bucket config is not present.
+var errConfigNotFound = errors.New("Config not found")
+
+// errLockNotRenewed - lease of a distributed lock was not renewed on a
+// majority of nodes.
+var errLockNotRenewed = errors.New("Lock lease not renewed")
+
+// errLockTimeout - distributed lock was not granted by a majority of
+// nodes within the stuck lock timeout.
+var errLockTimeout = errors.New("Lock not granted before timeout")
+
+// errLockStuck - name space lock was held longer than the stuck lock
+// timeout.
+var errLockStuck = errors.New("Lock held past stuck lock timeout")
@@ -42,6 +42,16 @@ const (
 	minPartSize = 1024 * 1024 * 5
 	// maximum Part ID for multipart upload is 10000 (Acceptable values range from 1 to 10000 inclusive)
 	maxPartID = 10000
+	// maximum number of source objects of ComposeObject, same as GCS.
+	maxComposeSources = 32
+	// maximum number of objects of DeleteMultipleObjects, same as S3.
+	maxDeleteObjects = 1000
+	// maximum size of DeleteMultipleObjects request, 1000 keys of at
+	// most 1024 bytes each.
+	maxDeleteObjectsSize = 2 * 1024 * 1024 // 2MiB.
+	// maximum number of objects of GetMultipleObjects, same as
+	// DeleteMultipleObjects.
+	maxGetMultipleObjects = 1000
 )
 
 // isMaxObjectSize - verify if max object size
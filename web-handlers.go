@@ -43,10 +43,11 @@ func isJWTReqAuthenticated(req *http.Request) bool {
 		}
 		return []byte(jwt.SecretAccessKey), nil
 	})
-	if e != nil {
+	if e != nil || !token.Valid {
 		return false
 	}
-	return token.Valid
+	setRequestAuthenticated(req, jwt.AccessKeyID)
+	return true
 }
 
 // WebGenericArgs - empty struct for calls that don't accept arguments
@@ -124,9 +125,15 @@ func (web *webAPIHandlers) MakeBucket(r *http.Request, args *MakeBucketArgs, rep
 		return &json2.Error{Message: "Unauthorized request"}
 	}
 	reply.UIVersion = miniobrowser.UIVersion
+	// Browser requests are always made with the server credential.
+	accessKey := serverConfig.GetCredential().AccessKeyID
+	if s3Error := checkBucketLimits(web.ObjectAPI, args.BucketName, accessKey); s3Error != ErrNone {
+		return &json2.Error{Message: getAPIError(s3Error).Description}
+	}
 	if err := web.ObjectAPI.MakeBucket(args.BucketName); err != nil {
 		return &json2.Error{Message: err.Error()}
 	}
+	errorIf(writeBucketOwner(args.BucketName, accessKey), "Unable to save bucket owner.")
 	return nil
 }
 
@@ -238,6 +245,64 @@ func (web *webAPIHandlers) RemoveObject(r *http.Request, args *RemoveObjectArgs,
 	if err := web.ObjectAPI.DeleteObject(args.BucketName, args.ObjectName); err != nil {
 		return &json2.Error{Message: err.Error()}
 	}
+	replicateChange(web.ObjectAPI, replicationOp{bucket: args.BucketName, object: args.ObjectName, delete: true})
+	return nil
+}
+
+// PresignedPutArgs - args to generate a presigned upload URL, the URL
+// is valid for any object with the key prefix if one is set.
+type PresignedPutArgs struct {
+	BucketName  string `json:"bucketName"`
+	ObjectName  string `json:"objectName"`
+	KeyPrefix   string `json:"keyPrefix"`
+	ContentType string `json:"contentType"`
+	MaxSize     int64  `json:"maxSize"`
+	// Expiry in seconds, defaults to an hour.
+	Expiry int64 `json:"expiry"`
+}
+
+// PresignedPutRep - presigned upload URL reply.
+type PresignedPutRep struct {
+	URL       string `json:"url"`
+	UIVersion string `json:"uiVersion"`
+}
+
+// Maximum expiry of presigned URLs, same as S3.
+const maxPresignedExpiry = 7 * 24 * time.Hour
+
+// PresignedPut - generates a presigned upload URL scoped by the
+// requested constraints.
+func (web *webAPIHandlers) PresignedPut(r *http.Request, args *PresignedPutArgs, reply *PresignedPutRep) error {
+	if !isJWTReqAuthenticated(r) {
+		return &json2.Error{Message: "Unauthorized request"}
+	}
+	if !IsValidBucketName(args.BucketName) {
+		return &json2.Error{Message: BucketNameInvalid{Bucket: args.BucketName}.Error()}
+	}
+	if args.KeyPrefix == "" && !IsValidObjectName(args.ObjectName) {
+		return &json2.Error{Message: ObjectNameInvalid{Bucket: args.BucketName, Object: args.ObjectName}.Error()}
+	}
+	if args.MaxSize < 0 {
+		return &json2.Error{Message: "Maximum size cannot be negative"}
+	}
+	expiry := time.Duration(args.Expiry) * time.Second
+	if expiry == 0 {
+		expiry = time.Hour
+	}
+	if expiry < 0 || expiry > maxPresignedExpiry {
+		return &json2.Error{Message: "Expiry must be between 1 second and 7 days"}
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	constraints := presignConstraints{
+		KeyPrefix:   args.KeyPrefix,
+		ContentType: args.ContentType,
+		MaxSize:     args.MaxSize,
+	}
+	reply.URL = presignURL("PUT", scheme, r.Host, args.BucketName, args.ObjectName, constraints, serverConfig.GetCredential(), serverConfig.GetRegion(), expiry)
+	reply.UIVersion = miniobrowser.UIVersion
 	return nil
 }
 
@@ -309,12 +374,16 @@ func (web *webAPIHandlers) SetAuth(r *http.Request, args *SetAuthArgs, reply *Se
 	if !isValidSecretKey.MatchString(args.SecretKey) {
 		return &json2.Error{Message: "Invalid Secret Key"}
 	}
+	prevCred := serverConfig.GetCredential()
 	cred := credential{args.AccessKey, args.SecretKey}
 	serverConfig.SetCredential(cred)
 	if err := serverConfig.Save(); err != nil {
 		return &json2.Error{Message: err.Error()}
 	}
 
+	// Propagate new credentials to all peers.
+	broadcastServerConfig(prevCred)
+
 	jwt := initJWT()
 	if !jwt.Authenticate(args.AccessKey, args.SecretKey) {
 		return &json2.Error{Message: "Invalid credentials"}
@@ -356,9 +425,26 @@ func (web *webAPIHandlers) Upload(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	bucket := vars["bucket"]
 	object := vars["object"]
-	if _, err := web.ObjectAPI.PutObject(bucket, object, -1, r.Body, nil); err != nil {
+	// Browser uploads carry no verified checksum.
+	if s3Error := checkRequiredChecksum(nil, bucket, false); s3Error != ErrNone {
+		apiErr := getAPIError(s3Error)
+		w.WriteHeader(apiErr.HTTPStatusCode)
+		w.Write([]byte(apiErr.Description))
+		return
+	}
+	// TTL of the object, the same header as S3 uploads.
+	metadata := make(map[string]string)
+	if s3Error := setObjectExpiryFromHeader(r.Header, metadata); s3Error != ErrNone {
+		apiErr := getAPIError(s3Error)
+		w.WriteHeader(apiErr.HTTPStatusCode)
+		w.Write([]byte(apiErr.Description))
+		return
+	}
+	if _, err := web.ObjectAPI.PutObject(bucket, object, -1, r.Body, metadata); err != nil {
 		writeWebErrorResponse(w, err)
+		return
 	}
+	replicateObjectWrite(w, web.ObjectAPI, bucket, object)
 }
 
 // Download - file download handler.
@@ -0,0 +1,569 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+
+	router "github.com/gorilla/mux"
+)
+
+// WebDAV frontend is served under reserved bucket.
+const webDAVPrefix = reservedBucket + "/webdav"
+
+// Methods supported by the WebDAV frontend, DAV class 1 without locks.
+const webDAVAllowedMethods = "OPTIONS, PROPFIND, GET, HEAD, PUT, DELETE, MKCOL, COPY, MOVE"
+
+// isRequestBasicAuth - returns true if request has HTTP basic
+// authentication.
+func isRequestBasicAuth(r *http.Request) bool {
+	if _, ok := r.Header["Authorization"]; ok {
+		if strings.HasPrefix(r.Header.Get("Authorization"), "Basic ") {
+			return true
+		}
+	}
+	return false
+}
+
+// isWebDAVRequest - returns true if request is for the WebDAV
+// frontend and the frontend is enabled.
+func isWebDAVRequest(r *http.Request) bool {
+	if !globalWebDAVEnabled {
+		return false
+	}
+	return r.URL.Path == webDAVPrefix || strings.HasPrefix(r.URL.Path, webDAVPrefix+slashSeparator)
+}
+
+// getWebDAVPath - returns bucket and object of a WebDAV path, object
+// is empty for buckets and the root.
+func getWebDAVPath(urlPath string) (bucket, object string) {
+	urlPath = strings.TrimPrefix(strings.TrimPrefix(urlPath, webDAVPrefix), slashSeparator)
+	splits := strings.SplitN(urlPath, slashSeparator, 2)
+	bucket = splits[0]
+	if len(splits) == 2 {
+		object = strings.TrimSuffix(splits[1], slashSeparator)
+	}
+	return bucket, object
+}
+
+// getWebDAVHref - returns escaped WebDAV path of a bucket and object,
+// path of collections ends with a slash.
+func getWebDAVHref(bucket, object string, isCollection bool) string {
+	href := webDAVPrefix + slashSeparator
+	if bucket != "" {
+		href += bucket + slashSeparator
+	}
+	if object != "" {
+		href += object
+		if isCollection {
+			href += slashSeparator
+		}
+	}
+	return (&url.URL{Path: href}).EscapedPath()
+}
+
+// webDAVResource - a bucket, prefix or object as seen by WebDAV
+// clients, buckets and prefixes are collections.
+type webDAVResource struct {
+	Bucket       string
+	Object       string
+	IsCollection bool
+	ObjInfo      ObjectInfo
+}
+
+// XML elements of PROPFIND responses, RFC 4918 section 14.
+type webDAVMultistatus struct {
+	XMLName   xml.Name         `xml:"D:multistatus"`
+	XMLNS     string           `xml:"xmlns:D,attr"`
+	Responses []webDAVResponse `xml:"D:response"`
+}
+
+type webDAVResponse struct {
+	Href     string         `xml:"D:href"`
+	Propstat webDAVPropstat `xml:"D:propstat"`
+}
+
+type webDAVPropstat struct {
+	Prop   webDAVProp `xml:"D:prop"`
+	Status string     `xml:"D:status"`
+}
+
+type webDAVProp struct {
+	DisplayName   string             `xml:"D:displayname"`
+	ResourceType  webDAVResourceType `xml:"D:resourcetype"`
+	ContentLength *int64             `xml:"D:getcontentlength,omitempty"`
+	ContentType   string             `xml:"D:getcontenttype,omitempty"`
+	ETag          string             `xml:"D:getetag,omitempty"`
+	LastModified  string             `xml:"D:getlastmodified,omitempty"`
+}
+
+type webDAVResourceType struct {
+	Collection *struct{} `xml:"D:collection"`
+}
+
+// toWebDAVResponse - returns PROPFIND response of a resource.
+func (res webDAVResource) toWebDAVResponse() webDAVResponse {
+	prop := webDAVProp{}
+	switch {
+	case res.Object != "":
+		prop.DisplayName = path.Base(res.Object)
+	case res.Bucket != "":
+		prop.DisplayName = res.Bucket
+	}
+	if !res.ObjInfo.ModTime.IsZero() {
+		prop.LastModified = res.ObjInfo.ModTime.UTC().Format(http.TimeFormat)
+	}
+	if res.IsCollection {
+		prop.ResourceType.Collection = &struct{}{}
+	} else {
+		size := res.ObjInfo.Size
+		prop.ContentLength = &size
+		prop.ContentType = res.ObjInfo.ContentType
+		if res.ObjInfo.MD5Sum != "" {
+			prop.ETag = "\"" + res.ObjInfo.MD5Sum + "\""
+		}
+	}
+	return webDAVResponse{
+		Href: getWebDAVHref(res.Bucket, res.Object, res.IsCollection),
+		Propstat: webDAVPropstat{
+			Prop:   prop,
+			Status: "HTTP/1.1 200 OK",
+		},
+	}
+}
+
+// webDAVHandlers implements WebDAV frontend of the object layer.
+type webDAVHandlers struct {
+	ObjectAPI ObjectLayer
+}
+
+// registerWebDAVRouter - registers WebDAV frontend under reserved
+// bucket, if enabled.
+func registerWebDAVRouter(mux *router.Router, handlers webDAVHandlers) {
+	if !globalWebDAVEnabled {
+		return
+	}
+	mux.NewRoute().PathPrefix(webDAVPrefix).Handler(handlers)
+}
+
+// writeWebDAVError - writes status and description of the API error
+// of an object layer error.
+func writeWebDAVError(w http.ResponseWriter, err error) {
+	// Storage quota of the tenant, RFC 4918 section 11.5.
+	if _, ok := err.(TenantQuotaExceeded); ok {
+		writeWebDAVStatus(w, http.StatusInsufficientStorage)
+		return
+	}
+	apiErr := getAPIError(toAPIErrorCode(err))
+	if apiErr.HTTPStatusCode == http.StatusInternalServerError {
+		errorIf(err, "Unable to serve WebDAV request.")
+	}
+	writeWebDAVStatus(w, apiErr.HTTPStatusCode)
+}
+
+// writeWebDAVStatus - writes status with its text as body.
+func writeWebDAVStatus(w http.ResponseWriter, statusCode int) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(statusCode)
+	w.Write([]byte(http.StatusText(statusCode)))
+}
+
+// getWebDAVAccessKey - verifies basic authentication of the request
+// against the server credential and tenants, returns the access key.
+func getWebDAVAccessKey(r *http.Request) (string, bool) {
+	accessKey, secretKey, ok := r.BasicAuth()
+	if !ok {
+		return "", false
+	}
+	cred, ok := getCredentialByAccessKey(accessKey)
+	if !ok || subtle.ConstantTimeCompare([]byte(cred.SecretAccessKey), []byte(secretKey)) != 1 {
+		return "", false
+	}
+	setRequestAuthenticated(r, accessKey)
+	return accessKey, true
+}
+
+func (h webDAVHandlers) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !isWebDAVRequest(r) {
+		writeWebDAVStatus(w, http.StatusNotFound)
+		return
+	}
+	if r.Method == "OPTIONS" {
+		w.Header().Set("DAV", "1")
+		w.Header().Set("Allow", webDAVAllowedMethods)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	accessKey, ok := getWebDAVAccessKey(r)
+	if !ok {
+		w.Header().Set("WWW-Authenticate", `Basic realm="minio"`)
+		writeWebDAVStatus(w, http.StatusUnauthorized)
+		return
+	}
+	bucket, object := getWebDAVPath(r.URL.Path)
+	if bucket != "" && checkTenantBucket(accessKey, bucket) != ErrNone {
+		writeWebDAVStatus(w, http.StatusForbidden)
+		return
+	}
+	switch r.Method {
+	case "PROPFIND":
+		h.propfind(w, r, accessKey, bucket, object)
+	case "GET", "HEAD":
+		h.get(w, r, bucket, object)
+	case "PUT":
+		h.put(w, r, accessKey, bucket, object)
+	case "DELETE":
+		h.delete(w, r, bucket, object)
+	case "MKCOL":
+		h.mkcol(w, r, bucket, object)
+	case "COPY", "MOVE":
+		h.copyMove(w, r, accessKey, bucket, object)
+	default:
+		w.Header().Set("Allow", webDAVAllowedMethods)
+		writeWebDAVStatus(w, http.StatusMethodNotAllowed)
+	}
+}
+
+// stat - returns resource of a bucket and object, an object which
+// does not exist is a collection if objects with its prefix exist.
+func (h webDAVHandlers) stat(bucket, object string) (webDAVResource, error) {
+	res := webDAVResource{Bucket: bucket, Object: object, IsCollection: true}
+	if bucket == "" {
+		return res, nil
+	}
+	if object == "" {
+		bucketInfo, err := h.ObjectAPI.GetBucketInfo(bucket)
+		if err != nil {
+			return webDAVResource{}, err
+		}
+		res.ObjInfo.ModTime = bucketInfo.Created
+		return res, nil
+	}
+	if !IsValidObjectName(object) {
+		return webDAVResource{}, ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	objInfo, err := h.ObjectAPI.GetObjectInfo(bucket, object)
+	if err == nil {
+		res.IsCollection = false
+		res.ObjInfo = objInfo
+		return res, nil
+	}
+	if _, ok := err.(ObjectNotFound); !ok {
+		return webDAVResource{}, err
+	}
+	result, lerr := h.ObjectAPI.ListObjects(bucket, object+slashSeparator, "", slashSeparator, 1)
+	if lerr != nil {
+		return webDAVResource{}, lerr
+	}
+	if len(result.Objects) == 0 && len(result.Prefixes) == 0 {
+		return webDAVResource{}, err
+	}
+	return res, nil
+}
+
+// listChildren - returns members of a collection.
+func (h webDAVHandlers) listChildren(accessKey string, res webDAVResource) ([]webDAVResource, error) {
+	var children []webDAVResource
+	if res.Bucket == "" {
+		bucketsInfo, err := h.ObjectAPI.ListBuckets()
+		if err != nil {
+			return nil, err
+		}
+		for _, bucketInfo := range filterTenantBuckets(accessKey, bucketsInfo) {
+			children = append(children, webDAVResource{
+				Bucket:       bucketInfo.Name,
+				IsCollection: true,
+				ObjInfo:      ObjectInfo{ModTime: bucketInfo.Created},
+			})
+		}
+		return children, nil
+	}
+	prefix := ""
+	if res.Object != "" {
+		prefix = res.Object + slashSeparator
+	}
+	var marker string
+	for {
+		result, err := h.ObjectAPI.ListObjects(res.Bucket, prefix, marker, slashSeparator, maxObjectList)
+		if err != nil {
+			return nil, err
+		}
+		for _, objInfo := range result.Objects {
+			children = append(children, webDAVResource{Bucket: res.Bucket, Object: objInfo.Name, ObjInfo: objInfo})
+		}
+		for _, childPrefix := range result.Prefixes {
+			children = append(children, webDAVResource{
+				Bucket:       res.Bucket,
+				Object:       strings.TrimSuffix(childPrefix, slashSeparator),
+				IsCollection: true,
+			})
+		}
+		if !result.IsTruncated {
+			return children, nil
+		}
+		marker = result.NextMarker
+	}
+}
+
+// propfind - PROPFIND returns all properties of a resource, and of
+// its members for depth 1. Depth infinity is not supported.
+func (h webDAVHandlers) propfind(w http.ResponseWriter, r *http.Request, accessKey, bucket, object string) {
+	depth := r.Header.Get("Depth")
+	if depth != "0" && depth != "1" {
+		writeWebDAVStatus(w, http.StatusForbidden)
+		return
+	}
+	res, err := h.stat(bucket, object)
+	if err != nil {
+		writeWebDAVError(w, err)
+		return
+	}
+	resources := []webDAVResource{res}
+	if depth == "1" && res.IsCollection {
+		children, err := h.listChildren(accessKey, res)
+		if err != nil {
+			writeWebDAVError(w, err)
+			return
+		}
+		resources = append(resources, children...)
+	}
+	multistatus := webDAVMultistatus{XMLNS: "DAV:"}
+	for _, res := range resources {
+		multistatus.Responses = append(multistatus.Responses, res.toWebDAVResponse())
+	}
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	w.Write([]byte(xml.Header))
+	xml.NewEncoder(w).Encode(multistatus)
+}
+
+// get - GET and HEAD of objects, collections have no content.
+func (h webDAVHandlers) get(w http.ResponseWriter, r *http.Request, bucket, object string) {
+	res, err := h.stat(bucket, object)
+	if err != nil {
+		writeWebDAVError(w, err)
+		return
+	}
+	if res.IsCollection {
+		w.Header().Set("Allow", webDAVAllowedMethods)
+		writeWebDAVStatus(w, http.StatusMethodNotAllowed)
+		return
+	}
+	objInfo := res.ObjInfo
+	if objInfo.ContentType != "" {
+		w.Header().Set("Content-Type", objInfo.ContentType)
+	}
+	if objInfo.MD5Sum != "" {
+		w.Header().Set("ETag", "\""+objInfo.MD5Sum+"\"")
+	}
+	w.Header().Set("Last-Modified", objInfo.ModTime.UTC().Format(http.TimeFormat))
+	w.Header().Set("Content-Length", strconv.FormatInt(objInfo.Size, 10))
+	w.WriteHeader(http.StatusOK)
+	if r.Method == "HEAD" {
+		return
+	}
+	// Response is already written, errors can only be logged.
+	errorIf(h.ObjectAPI.GetObject(bucket, object, 0, objInfo.Size, w), "Unable to write object to WebDAV client.")
+}
+
+// put - PUT writes an object, buckets must already exist.
+func (h webDAVHandlers) put(w http.ResponseWriter, r *http.Request, accessKey, bucket, object string) {
+	if object == "" || strings.HasSuffix(r.URL.Path, slashSeparator) {
+		w.Header().Set("Allow", webDAVAllowedMethods)
+		writeWebDAVStatus(w, http.StatusMethodNotAllowed)
+		return
+	}
+	if s3Error := checkRequiredChecksum(r, bucket, true); s3Error != ErrNone {
+		writeWebDAVStatus(w, getAPIError(s3Error).HTTPStatusCode)
+		return
+	}
+	md5Bytes, err := checkValidMD5(r.Header.Get("Content-Md5"))
+	if err != nil {
+		writeWebDAVStatus(w, http.StatusBadRequest)
+		return
+	}
+	metadata := make(map[string]string)
+	// Content-Md5 is verified while writing the object.
+	metadata["md5Sum"] = hex.EncodeToString(md5Bytes)
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		metadata["content-type"] = contentType
+	}
+	// TTL of the object, the same header as S3 uploads.
+	if s3Error := setObjectExpiryFromHeader(r.Header, metadata); s3Error != ErrNone {
+		writeWebDAVStatus(w, getAPIError(s3Error).HTTPStatusCode)
+		return
+	}
+	if _, err = h.ObjectAPI.PutObject(bucket, object, r.ContentLength, r.Body, metadata); err != nil {
+		// Missing parent collection, RFC 4918 section 9.7.1.
+		if _, ok := err.(BucketNotFound); ok {
+			writeWebDAVStatus(w, http.StatusConflict)
+			return
+		}
+		writeWebDAVError(w, err)
+		return
+	}
+	replicateObjectWrite(w, h.ObjectAPI, bucket, object)
+	writeWebDAVStatus(w, http.StatusCreated)
+}
+
+// deletePrefix - deletes all objects with the prefix.
+func (h webDAVHandlers) deletePrefix(bucket, prefix string) error {
+	for {
+		result, err := h.ObjectAPI.ListObjects(bucket, prefix, "", "", maxObjectList)
+		if err != nil {
+			return err
+		}
+		for _, objInfo := range result.Objects {
+			if err = h.ObjectAPI.DeleteObject(bucket, objInfo.Name); err != nil {
+				return err
+			}
+			replicateChange(h.ObjectAPI, replicationOp{bucket: bucket, object: objInfo.Name, delete: true})
+		}
+		if !result.IsTruncated {
+			return nil
+		}
+	}
+}
+
+// delete - DELETE removes an object, or all objects of a prefix.
+// Buckets are managed with the S3 API only.
+func (h webDAVHandlers) delete(w http.ResponseWriter, r *http.Request, bucket, object string) {
+	if object == "" {
+		writeWebDAVStatus(w, http.StatusForbidden)
+		return
+	}
+	res, err := h.stat(bucket, object)
+	if err != nil {
+		writeWebDAVError(w, err)
+		return
+	}
+	if res.IsCollection {
+		err = h.deletePrefix(bucket, object+slashSeparator)
+	} else if err = h.ObjectAPI.DeleteObject(bucket, object); err == nil {
+		replicateChange(h.ObjectAPI, replicationOp{bucket: bucket, object: object, delete: true})
+	}
+	if err != nil {
+		writeWebDAVError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// mkcol - MKCOL of a prefix, prefixes exist only as long as they have
+// objects so nothing is saved. Buckets are managed with the S3 API
+// only.
+func (h webDAVHandlers) mkcol(w http.ResponseWriter, r *http.Request, bucket, object string) {
+	if object == "" {
+		writeWebDAVStatus(w, http.StatusForbidden)
+		return
+	}
+	if _, err := h.stat(bucket, object); err == nil {
+		w.Header().Set("Allow", webDAVAllowedMethods)
+		writeWebDAVStatus(w, http.StatusMethodNotAllowed)
+		return
+	}
+	parent := path.Dir(object)
+	if parent == "." {
+		parent = ""
+	}
+	if _, err := h.stat(bucket, parent); err != nil {
+		writeWebDAVStatus(w, http.StatusConflict)
+		return
+	}
+	writeWebDAVStatus(w, http.StatusCreated)
+}
+
+// copyMove - COPY and MOVE of objects to the Destination header,
+// collections cannot be copied or moved.
+func (h webDAVHandlers) copyMove(w http.ResponseWriter, r *http.Request, accessKey, bucket, object string) {
+	destURL, err := url.Parse(r.Header.Get("Destination"))
+	if err != nil || !strings.HasPrefix(destURL.Path, webDAVPrefix+slashSeparator) {
+		writeWebDAVStatus(w, http.StatusBadRequest)
+		return
+	}
+	destBucket, destObject := getWebDAVPath(destURL.Path)
+	if destObject == "" || checkTenantBucket(accessKey, destBucket) != ErrNone {
+		writeWebDAVStatus(w, http.StatusForbidden)
+		return
+	}
+	res, err := h.stat(bucket, object)
+	if err != nil {
+		writeWebDAVError(w, err)
+		return
+	}
+	if res.IsCollection {
+		writeWebDAVStatus(w, http.StatusForbidden)
+		return
+	}
+	if destBucket == bucket && destObject == object {
+		writeWebDAVStatus(w, http.StatusForbidden)
+		return
+	}
+	_, err = h.ObjectAPI.GetObjectInfo(destBucket, destObject)
+	destExists := err == nil
+	if destExists && r.Header.Get("Overwrite") == "F" {
+		writeWebDAVStatus(w, http.StatusPreconditionFailed)
+		return
+	}
+	metadata := make(map[string]string)
+	for key, value := range res.ObjInfo.UserDefined {
+		metadata[key] = value
+	}
+	setObjectTags(metadata, res.ObjInfo.Tags)
+	// Moved objects keep their TTL, copies are like S3 copies.
+	if r.Method == "MOVE" {
+		setObjectExpiry(metadata, res.ObjInfo.Expires)
+	}
+	if res.ObjInfo.ContentType != "" {
+		metadata["content-type"] = res.ObjInfo.ContentType
+	}
+	pipeReader, pipeWriter := io.Pipe()
+	go func() {
+		pipeWriter.CloseWithError(h.ObjectAPI.GetObject(bucket, object, 0, res.ObjInfo.Size, pipeWriter))
+	}()
+	_, err = h.ObjectAPI.PutObject(destBucket, destObject, res.ObjInfo.Size, pipeReader, metadata)
+	pipeReader.Close()
+	if err != nil {
+		if _, ok := err.(BucketNotFound); ok {
+			writeWebDAVStatus(w, http.StatusConflict)
+			return
+		}
+		writeWebDAVError(w, err)
+		return
+	}
+	replicateObjectWrite(w, h.ObjectAPI, destBucket, destObject)
+	if r.Method == "MOVE" {
+		if err = h.ObjectAPI.DeleteObject(bucket, object); err != nil {
+			writeWebDAVError(w, err)
+			return
+		}
+		replicateChange(h.ObjectAPI, replicationOp{bucket: bucket, object: object, delete: true})
+	}
+	if destExists {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	writeWebDAVStatus(w, http.StatusCreated)
+}
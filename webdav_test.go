@@ -0,0 +1,157 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// Tests mapping of WebDAV paths to buckets and objects.
+func TestGetWebDAVPath(t *testing.T) {
+	testCases := []struct {
+		urlPath        string
+		expectedBucket string
+		expectedObject string
+	}{
+		// Test case - 1.
+		{webDAVPrefix, "", ""},
+		// Test case - 2.
+		{webDAVPrefix + "/", "", ""},
+		// Test case - 3.
+		{webDAVPrefix + "/bucket/", "bucket", ""},
+		// Test case - 4.
+		{webDAVPrefix + "/bucket/dir/", "bucket", "dir"},
+		// Test case - 5.
+		{webDAVPrefix + "/bucket/dir/object", "bucket", "dir/object"},
+	}
+	for i, testCase := range testCases {
+		bucket, object := getWebDAVPath(testCase.urlPath)
+		if bucket != testCase.expectedBucket || object != testCase.expectedObject {
+			t.Errorf("Test %d: Expected %s/%s, got %s/%s", i+1, testCase.expectedBucket, testCase.expectedObject, bucket, object)
+		}
+	}
+}
+
+// Tests WebDAV frontend operations on objects and collections.
+func TestWebDAV(t *testing.T) {
+	globalWebDAVEnabled = true
+	defer func() { globalWebDAVEnabled = false }()
+	testServer := StartTestServer(t, "XL")
+	defer testServer.Stop()
+	bucket := makeIntegrationBucket(t, newS3TestClient(testServer))
+	bucketURL := testServer.Server.URL + webDAVPrefix + "/" + bucket
+
+	testCases := []struct {
+		method         string
+		url            string
+		headers        map[string]string
+		body           string
+		noAuth         bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		// Test case - 1.
+		{"OPTIONS", bucketURL + "/", nil, "", true, http.StatusOK, ""},
+		// Test case - 2.
+		{"PROPFIND", bucketURL + "/", map[string]string{"Depth": "1"}, "", true, http.StatusUnauthorized, ""},
+		// Test case - 3.
+		{"PUT", bucketURL + "/dir/object", nil, "hello", false, http.StatusCreated, ""},
+		// Test case - 4.
+		{"GET", bucketURL + "/dir/object", nil, "", false, http.StatusOK, "hello"},
+		// Test case - 5.
+		{"PROPFIND", bucketURL + "/", map[string]string{"Depth": "1"}, "", false, http.StatusMultiStatus, "/webdav/" + bucket + "/dir/</D:href>"},
+		// Test case - 6.
+		{"PROPFIND", bucketURL + "/dir", map[string]string{"Depth": "1"}, "", false, http.StatusMultiStatus, "<D:getcontentlength>5</D:getcontentlength>"},
+		// Test case - 7.
+		{"PROPFIND", bucketURL + "/", map[string]string{"Depth": "infinity"}, "", false, http.StatusForbidden, ""},
+		// Test case - 8.
+		{"COPY", bucketURL + "/dir/object", map[string]string{"Destination": bucketURL + "/copy"}, "", false, http.StatusCreated, ""},
+		// Test case - 9.
+		{"COPY", bucketURL + "/dir/object", map[string]string{"Destination": bucketURL + "/copy", "Overwrite": "F"}, "", false, http.StatusPreconditionFailed, ""},
+		// Test case - 10.
+		{"MOVE", bucketURL + "/copy", map[string]string{"Destination": bucketURL + "/moved"}, "", false, http.StatusCreated, ""},
+		// Test case - 11.
+		{"GET", bucketURL + "/copy", nil, "", false, http.StatusNotFound, ""},
+		// Test case - 12.
+		{"GET", bucketURL + "/moved", nil, "", false, http.StatusOK, "hello"},
+		// Test case - 13.
+		{"MKCOL", bucketURL + "/newdir", nil, "", false, http.StatusCreated, ""},
+		// Test case - 14.
+		{"MKCOL", bucketURL + "/dir", nil, "", false, http.StatusMethodNotAllowed, ""},
+		// Test case - 15.
+		{"MKCOL", bucketURL + "/missing/newdir", nil, "", false, http.StatusConflict, ""},
+		// Test case - 16.
+		// Buckets are managed with the S3 API only.
+		{"MKCOL", testServer.Server.URL + webDAVPrefix + "/newbucket", nil, "", false, http.StatusForbidden, ""},
+		// Test case - 17.
+		{"PUT", testServer.Server.URL + webDAVPrefix + "/missing-bucket/object", nil, "hello", false, http.StatusConflict, ""},
+		// Test case - 18.
+		{"DELETE", bucketURL + "/dir", nil, "", false, http.StatusNoContent, ""},
+		// Test case - 19.
+		{"GET", bucketURL + "/dir/object", nil, "", false, http.StatusNotFound, ""},
+		// Test case - 20.
+		{"DELETE", bucketURL, nil, "", false, http.StatusForbidden, ""},
+	}
+	for i, testCase := range testCases {
+		req, err := http.NewRequest(testCase.method, testCase.url, bytes.NewReader([]byte(testCase.body)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for key, value := range testCase.headers {
+			req.Header.Set(key, value)
+		}
+		if !testCase.noAuth {
+			req.SetBasicAuth(testServer.AccessKey, testServer.SecretKey)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		respBody, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != testCase.expectedStatus {
+			t.Errorf("Test %d: Expected status %d, got %d: %s", i+1, testCase.expectedStatus, resp.StatusCode, respBody)
+			continue
+		}
+		if !strings.Contains(string(respBody), testCase.expectedBody) {
+			t.Errorf("Test %d: Expected body to contain %q, got %q", i+1, testCase.expectedBody, respBody)
+		}
+	}
+
+	// Wrong secret key is not accepted.
+	req, err := http.NewRequest("PROPFIND", bucketURL+"/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Depth", "0")
+	req.SetBasicAuth(testServer.AccessKey, "wrongsecretkey")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, resp.StatusCode)
+	}
+}
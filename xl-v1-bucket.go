@@ -215,6 +215,11 @@ func (xl xlObjects) DeleteBucket(bucket string) error {
 	nsMutex.Lock(bucket, "")
 	defer nsMutex.Unlock(bucket, "")
 
+	// Buckets keeping versions of objects are not empty.
+	if xl.hasObjectVersions(bucket) {
+		return BucketNotEmpty{Bucket: bucket}
+	}
+
 	// Collect if all disks report volume not found.
 	var volumeNotFoundErrCnt int
 
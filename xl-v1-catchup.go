@@ -0,0 +1,166 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Interval at which objects written with disks missing are caught up.
+const catchUpInterval = 1 * time.Minute
+
+// degradedObject - object written with disks missing.
+type degradedObject struct {
+	Bucket string
+	Object string
+	Queued time.Time
+}
+
+// byQueued is a collection satisfying sort.Interface.
+type byQueued []degradedObject
+
+func (d byQueued) Len() int           { return len(d) }
+func (d byQueued) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d byQueued) Less(i, j int) bool { return d[i].Queued.Before(d[j].Queued) }
+
+// degradedObjectsStats - counts of objects written with disks missing.
+type degradedObjectsStats struct {
+	// Objects waiting for their missing disks to be backfilled.
+	Pending int `json:"pending"`
+	// Objects written with disks missing, objects whose missing disks
+	// were backfilled and reads reconstructing objects from the other
+	// disks since the server started.
+	Written       int64 `json:"written"`
+	CaughtUp      int64 `json:"caughtUp"`
+	DegradedReads int64 `json:"degradedReads"`
+}
+
+// degradedObjects - keeps objects of an XL object layer written with
+// disks missing until catch-up backfills them.
+type degradedObjects struct {
+	mutex   *sync.Mutex
+	pending map[string]degradedObject // Keyed by bucket/object.
+
+	written       int64
+	caughtUp      int64
+	degradedReads int64
+}
+
+// newDegradedObjects - initialize degraded objects.
+func newDegradedObjects() *degradedObjects {
+	return &degradedObjects{
+		mutex:   &sync.Mutex{},
+		pending: make(map[string]degradedObject),
+	}
+}
+
+// Degraded objects of the XL object layer.
+var globalDegradedObjects = newDegradedObjects()
+
+// queue - marks the object as pending catch-up.
+func (d *degradedObjects) queue(bucket, object string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	key := pathJoin(bucket, object)
+	if _, ok := d.pending[key]; !ok {
+		d.pending[key] = degradedObject{bucket, object, time.Now().UTC()}
+	}
+}
+
+// add - records an object written with disks missing.
+func (d *degradedObjects) add(bucket, object string) {
+	d.queue(bucket, object)
+	atomic.AddInt64(&d.written, 1)
+}
+
+// update - keeps the object pending while disks are missing, pending
+// objects no longer missing disks are caught up.
+func (d *degradedObjects) update(bucket, object string, missing bool) {
+	if missing {
+		d.queue(bucket, object)
+		return
+	}
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	key := pathJoin(bucket, object)
+	if _, ok := d.pending[key]; ok {
+		delete(d.pending, key)
+		atomic.AddInt64(&d.caughtUp, 1)
+	}
+}
+
+// remove - drops a deleted object.
+func (d *degradedObjects) remove(bucket, object string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	delete(d.pending, pathJoin(bucket, object))
+}
+
+// read - records a read reconstructing from the other disks.
+func (d *degradedObjects) read() {
+	atomic.AddInt64(&d.degradedReads, 1)
+}
+
+// getPending - returns all pending objects, oldest first.
+func (d *degradedObjects) getPending() []degradedObject {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	objects := make([]degradedObject, 0, len(d.pending))
+	for _, object := range d.pending {
+		objects = append(objects, object)
+	}
+	sort.Sort(byQueued(objects))
+	return objects
+}
+
+// stats - returns counts of degraded objects.
+func (d *degradedObjects) stats() degradedObjectsStats {
+	d.mutex.Lock()
+	pending := len(d.pending)
+	d.mutex.Unlock()
+	return degradedObjectsStats{
+		Pending:       pending,
+		Written:       atomic.LoadInt64(&d.written),
+		CaughtUp:      atomic.LoadInt64(&d.caughtUp),
+		DegradedReads: atomic.LoadInt64(&d.degradedReads),
+	}
+}
+
+// catchUp - heals all pending objects, objects with disks still
+// offline stay pending.
+func (xl xlObjects) catchUp() {
+	for _, object := range xl.degraded.getPending() {
+		// Objects deleted since they were written are dropped.
+		if !xl.isObjectOnAnyDisk(object.Bucket, object.Object) {
+			xl.degraded.remove(object.Bucket, object.Object)
+			continue
+		}
+		_, _, err := xl.healObject(object.Bucket, object.Object, false)
+		errorIf(err, "Unable to catch up object %s/%s.", object.Bucket, object.Object)
+	}
+}
+
+// catchUpJob - catches up pending objects periodically.
+func (xl xlObjects) catchUpJob(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		xl.catchUp()
+	}
+}
@@ -0,0 +1,104 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import "sort"
+
+// GetObjectChecksums - returns checksums of the shards of each part of
+// an object as recorded in `xl.json` of the disks holding them, shards
+// of stale disks and disks recorded missing are left out. Inline
+// objects are reported as a single shard, the md5sum of their data.
+func (xl xlObjects) GetObjectChecksums(bucket, object string) (ObjectChecksumInfo, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return ObjectChecksumInfo{}, BucketNameInvalid{Bucket: bucket}
+	}
+	// Verify if object is valid.
+	if !IsValidObjectName(object) {
+		return ObjectChecksumInfo{}, ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	nsMutex.RLock(bucket, object)
+	defer nsMutex.RUnlock(bucket, object)
+
+	// Validate object exists.
+	if !xl.isObject(bucket, object) {
+		return ObjectChecksumInfo{}, ObjectNotFound{Bucket: bucket, Object: object}
+	}
+	partsMetadata, errs := xl.readAllXLMetadata(bucket, object)
+	onlineDisks, _, err := xl.listOnlineDisks(partsMetadata, errs)
+	if err != nil {
+		return ObjectChecksumInfo{}, toObjectErr(err, bucket, object)
+	}
+	var xlMeta xlMetaV1
+	for index, disk := range onlineDisks {
+		if disk != nil {
+			xlMeta = partsMetadata[index]
+			break
+		}
+	}
+	info := ObjectChecksumInfo{
+		Object: object,
+		Size:   xlMeta.Stat.Size,
+		MD5Sum: xlMeta.Meta["md5Sum"],
+		Parts:  make([]PartChecksumInfo, len(xlMeta.Parts)),
+	}
+	for i, part := range xlMeta.Parts {
+		info.Parts[i] = PartChecksumInfo{
+			Number: part.Number,
+			Size:   part.Size,
+			Shards: []ShardChecksumInfo{},
+		}
+	}
+	if xlMeta.Inline {
+		if len(info.Parts) == 1 {
+			info.Parts[0].Shards = append(info.Parts[0].Shards, ShardChecksumInfo{
+				Algorithm: "md5",
+				Hash:      xlMeta.Parts[0].ETag,
+			})
+		}
+		return info, nil
+	}
+	for index, disk := range onlineDisks {
+		if disk == nil || !partsMetadata[index].IsValid() || xlMeta.Erasure.isMissingDisk(index) {
+			continue
+		}
+		eInfo := partsMetadata[index].Erasure
+		shard := eInfo.shardIndex(index)
+		for i, part := range xlMeta.Parts {
+			checksum := eInfo.PartObjectChecksum(part.Name)
+			if checksum.Hash == "" {
+				continue
+			}
+			info.Parts[i].Shards = append(info.Parts[i].Shards, ShardChecksumInfo{
+				Index:     shard,
+				Algorithm: checksum.Algorithm,
+				Hash:      checksum.Hash,
+			})
+		}
+	}
+	for _, part := range info.Parts {
+		sort.Sort(shardChecksumsByIndex(part.Shards))
+	}
+	return info, nil
+}
+
+// shardChecksumsByIndex - sorts shard checksums by shard index.
+type shardChecksumsByIndex []ShardChecksumInfo
+
+func (s shardChecksumsByIndex) Len() int           { return len(s) }
+func (s shardChecksumsByIndex) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s shardChecksumsByIndex) Less(i, j int) bool { return s[i].Index < s[j].Index }
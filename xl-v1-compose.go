@@ -0,0 +1,428 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sync"
+	"time"
+)
+
+// sameErasureLayout - returns true if shards laid out by both erasure
+// infos are held by the same disks, shard files of one can then be
+// linked as shard files of the other.
+func sameErasureLayout(eInfos, layoutEInfos []erasureInfo) bool {
+	eInfo, layoutEInfo := pickValidErasureInfo(eInfos), pickValidErasureInfo(layoutEInfos)
+	if eInfo.Algorithm != layoutEInfo.Algorithm || eInfo.DataBlocks != layoutEInfo.DataBlocks ||
+		eInfo.ParityBlocks != layoutEInfo.ParityBlocks || eInfo.BlockSize != layoutEInfo.BlockSize {
+		return false
+	}
+	shards, layoutShards := shardIndexes(eInfos), shardIndexes(layoutEInfos)
+	for index := range shards {
+		if shards[index] != layoutShards[index] {
+			return false
+		}
+	}
+	return true
+}
+
+// composeSource - appends parts of a source object to the composed
+// object at tempObj, starting at part number partNumber+1. Shard files
+// are hard linked if all disks of the source are online and its
+// layout matches the composed object, otherwise the part is read and
+// erasure coded again. Layout of the composed object is taken from the
+// first source, returns the updated erasure infos, appended parts and
+// md5sum of the source.
+func (xl xlObjects) composeSource(bucket, source, tempObj string, partNumber int, eInfos []erasureInfo) ([]erasureInfo, []objectPartInfo, string, error) {
+	nsMutex.RLock(bucket, source)
+	defer nsMutex.RUnlock(bucket, source)
+
+	if !xl.isObject(bucket, source) {
+		return nil, nil, "", ObjectNotFound{Bucket: bucket, Object: source}
+	}
+
+	// Read metadata associated with the object from all disks.
+	metaArr, errs := xl.readAllXLMetadata(bucket, source)
+
+	// List all online disks.
+	onlineDisks, highestVersion, err := xl.listOnlineDisks(metaArr, errs)
+	if err != nil {
+		return nil, nil, "", toObjectErr(err, bucket, source)
+	}
+
+	// Pick latest valid metadata.
+	var srcMeta xlMetaV1
+	for _, meta := range metaArr {
+		if meta.IsValid() && meta.Stat.Version == highestVersion {
+			srcMeta = meta
+			break
+		}
+	}
+
+	// Collect all the previous erasure infos across the disk.
+	var srcEInfos []erasureInfo
+	for index := range onlineDisks {
+		srcEInfos = append(srcEInfos, metaArr[index].Erasure)
+	}
+
+	// First source lays out the composed object, disks without
+	// valid erasure info are laid out by position.
+	if eInfos == nil {
+		validEInfo := pickValidErasureInfo(srcEInfos)
+		validEInfo.Index = 0
+		for _, eInfo := range srcEInfos {
+			if !eInfo.IsValid() {
+				eInfo = validEInfo
+			}
+			eInfo.Checksum = nil
+			eInfos = append(eInfos, eInfo)
+		}
+	}
+	// Inline sources have no shard files, their data is erasure coded.
+	var inlineData []byte
+	if srcMeta.Inline {
+		if inlineData, err = pickInlineData(metaArr, onlineDisks); err != nil {
+			return nil, nil, "", toObjectErr(err, bucket, source)
+		}
+	}
+	canLink := !srcMeta.Inline && diskCount(onlineDisks) == len(xl.storageDisks) && sameErasureLayout(srcEInfos, eInfos)
+
+	var parts []objectPartInfo
+	for _, part := range srcMeta.Parts {
+		partNumber++
+		partName := fmt.Sprintf("object%d", partNumber)
+		srcPartPath := pathJoin(source, part.Name)
+		dstPartPath := pathJoin(tempObj, partName)
+		if canLink {
+			err = xl.reduceWriteQuorumErrs(xl.doOnAllDisks(func(disk StorageAPI) error {
+				return disk.LinkFile(bucket, srcPartPath, minioMetaBucket, dstPartPath)
+			}))
+			if err != nil {
+				return nil, nil, "", toObjectErr(err, bucket, source)
+			}
+			// Linked shards keep their checksums.
+			newEInfos := make([]erasureInfo, len(eInfos))
+			for index, eInfo := range eInfos {
+				checksum := srcEInfos[index].PartObjectChecksum(part.Name)
+				checksum.Name = partName
+				newEInfos[index] = eInfo
+				newEInfos[index].Checksum = append(append([]checkSumInfo{}, eInfo.Checksum...), checksum)
+			}
+			eInfos = newEInfos
+		} else {
+			pipeReader, pipeWriter := io.Pipe()
+			go func(part objectPartInfo) {
+				var wErr error
+				if srcMeta.Inline {
+					_, wErr = writeInlineData(pipeWriter, inlineData, 0, part.Size)
+				} else if part.Size > 0 {
+					_, wErr = erasureReadFile(pipeWriter, onlineDisks, bucket, srcPartPath, part.Name, srcEInfos, 0, part.Size, part.Size)
+				}
+				pipeWriter.CloseWithError(wErr)
+			}(part)
+			var n int64
+			eInfos, n, err = erasureCreateFile(xl.storageDisks, minioMetaBucket, dstPartPath, partName, pipeReader, eInfos, xl.writeQuorum)
+			pipeReader.Close()
+			if err != nil {
+				return nil, nil, "", toObjectErr(err, bucket, source)
+			}
+			if n != part.Size {
+				return nil, nil, "", toObjectErr(errUnexpected, bucket, source)
+			}
+		}
+		parts = append(parts, objectPartInfo{
+			Number: partNumber,
+			Name:   partName,
+			ETag:   part.ETag,
+			Size:   part.Size,
+		})
+	}
+	return eInfos, parts, srcMeta.Meta["md5Sum"], nil
+}
+
+// ComposeObject - creates an object from the concatenation of source
+// objects of the bucket, parts of all sources become parts of the
+// object. Shard files are hard linked where possible so no data is
+// copied, see composeSource.
+func (xl xlObjects) ComposeObject(bucket, object string, sources []string, metadata map[string]string) (string, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return "", BucketNameInvalid{Bucket: bucket}
+	}
+	// Verify bucket exists.
+	if !xl.isBucketExist(bucket) {
+		return "", BucketNotFound{Bucket: bucket}
+	}
+	for _, name := range append([]string{object}, sources...) {
+		if !IsValidObjectName(name) {
+			return "", ObjectNameInvalid{Bucket: bucket, Object: name}
+		}
+	}
+	if len(sources) == 0 {
+		return "", errInvalidArgument
+	}
+	// No metadata is set, allocate a new one.
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+
+	// Sources are composed one at a time before locking the object,
+	// no two objects are ever locked together.
+	tempObj := path.Join(tmpMetaPrefix, getUUID())
+	var eInfos []erasureInfo
+	var parts []objectPartInfo
+	for _, source := range sources {
+		newEInfos, sourceParts, _, err := xl.composeSource(bucket, source, tempObj, len(parts), eInfos)
+		if err != nil {
+			xl.deleteObject(minioMetaBucket, tempObj)
+			return "", err
+		}
+		eInfos = newEInfos
+		parts = append(parts, sourceParts...)
+	}
+
+	var completeParts []completePart
+	for _, part := range parts {
+		completeParts = append(completeParts, completePart{PartNumber: part.Number, ETag: part.ETag})
+	}
+	md5Hex := parts[0].ETag
+	if len(parts) > 1 {
+		var err error
+		if md5Hex, err = completeMultipartMD5(completeParts...); err != nil {
+			xl.deleteObject(minioMetaBucket, tempObj)
+			return "", toObjectErr(err, bucket, object)
+		}
+	}
+	if err := xl.commitComposedObject(bucket, object, tempObj, eInfos, parts, md5Hex, metadata); err != nil {
+		return "", err
+	}
+	return md5Hex, nil
+}
+
+// CopyObject - copies the object, parts of the source become parts of
+// the copy. Shard files are hard linked where possible so no data is
+// copied, see composeSource. Copies keep the md5sum of the source.
+func (xl xlObjects) CopyObject(srcBucket, srcObject, dstBucket, dstObject string, metadata map[string]string) (string, error) {
+	for _, bucket := range []string{srcBucket, dstBucket} {
+		// Verify if bucket is valid.
+		if !IsValidBucketName(bucket) {
+			return "", BucketNameInvalid{Bucket: bucket}
+		}
+		// Verify bucket exists.
+		if !xl.isBucketExist(bucket) {
+			return "", BucketNotFound{Bucket: bucket}
+		}
+	}
+	if !IsValidObjectName(srcObject) {
+		return "", ObjectNameInvalid{Bucket: srcBucket, Object: srcObject}
+	}
+	if !IsValidObjectName(dstObject) {
+		return "", ObjectNameInvalid{Bucket: dstBucket, Object: dstObject}
+	}
+	// No metadata is set, allocate a new one.
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	// Copies onto the source only replace its metadata, unless the
+	// bucket keeps the replaced version.
+	if srcBucket == dstBucket && srcObject == dstObject && getBucketVersioning(dstBucket) == "" {
+		return xl.updateObjectMetadata(dstBucket, dstObject, metadata)
+	}
+
+	// Source is linked before locking the copy, no two objects are
+	// ever locked together.
+	tempObj := path.Join(tmpMetaPrefix, getUUID())
+	eInfos, parts, md5Hex, err := xl.composeSource(srcBucket, srcObject, tempObj, 0, nil)
+	if err != nil {
+		xl.deleteObject(minioMetaBucket, tempObj)
+		return "", err
+	}
+	if err = xl.commitComposedObject(dstBucket, dstObject, tempObj, eInfos, parts, md5Hex, metadata); err != nil {
+		return "", err
+	}
+	return md5Hex, nil
+}
+
+// updateObjectMetadata - replaces metadata of an object by rewriting
+// only its `xl.json`, shards are left untouched. Disks without the
+// object are not updated, they become stale.
+func (xl xlObjects) updateObjectMetadata(bucket, object string, metadata map[string]string) (string, error) {
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	// Validate object exists.
+	if !xl.isObject(bucket, object) {
+		return "", ObjectNotFound{Bucket: bucket, Object: object}
+	}
+	// Existing objects of protected buckets are never replaced.
+	if isBucketOverwriteProtected(bucket) {
+		return "", ObjectAlreadyExists{Bucket: bucket, Object: object}
+	}
+
+	modTime := time.Now().UTC()
+	stampObjectProvenance(metadata, modTime)
+	var md5Hex string
+	err := xl.rewriteXLMetadata(bucket, object, func(xlMeta *xlMetaV1) {
+		// Every disk gets its own copy, the copies are marshalled
+		// concurrently.
+		newMeta := make(map[string]string, len(metadata)+3)
+		for key, value := range metadata {
+			newMeta[key] = value
+		}
+		// Metadata describing the data is kept.
+		md5Hex = xlMeta.Meta["md5Sum"]
+		newMeta["md5Sum"] = md5Hex
+		for _, key := range []string{dedupSumKey, dedupRefKey} {
+			if value, ok := xlMeta.Meta[key]; ok {
+				newMeta[key] = value
+			}
+		}
+		xlMeta.Meta = newMeta
+		xlMeta.Stat.ModTime = modTime
+	})
+	if err != nil {
+		return "", err
+	}
+	return md5Hex, nil
+}
+
+// rewriteXLMetadata - rewrites `xl.json` of an object on all disks
+// holding it, after applying update to the metadata of each disk.
+// update must not share maps between disks. Callers hold the object
+// lock and validated the object exists.
+func (xl xlObjects) rewriteXLMetadata(bucket, object string, update func(xlMeta *xlMetaV1)) error {
+	partsMetadata, errs := xl.readAllXLMetadata(bucket, object)
+	onlineDisks, higherVersion, err := xl.listOnlineDisks(partsMetadata, errs)
+	if err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	// Increment version only if we have online disks less than configured storage disks.
+	if diskCount(onlineDisks) < len(xl.storageDisks) {
+		higherVersion++
+	}
+
+	tempMeta := path.Join(tmpMetaPrefix, getUUID())
+	dErrs := make([]error, len(xl.storageDisks))
+	var wg = &sync.WaitGroup{}
+	for index, disk := range onlineDisks {
+		if disk == nil || errs[index] != nil {
+			dErrs[index] = errDiskNotFound
+			continue
+		}
+		updatedMeta := partsMetadata[index]
+		update(&updatedMeta)
+		updatedMeta.Stat.Version = higherVersion
+		wg.Add(1)
+		go func(index int, disk StorageAPI, updatedMeta xlMetaV1) {
+			defer wg.Done()
+			dErrs[index] = replaceXLMetadata(disk, bucket, object, tempMeta, updatedMeta)
+		}(index, disk, updatedMeta)
+	}
+	wg.Wait()
+	xl.deleteObject(minioMetaBucket, tempMeta)
+	if err = xl.reduceWriteQuorumErrs(dErrs); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	return nil
+}
+
+// commitComposedObject - writes `xl.json` of the parts composed at
+// tempObj and renames them to the object, replacing any existing
+// object. tempObj is removed on failure.
+func (xl xlObjects) commitComposedObject(bucket, object, tempObj string, eInfos []erasureInfo, parts []objectPartInfo, md5Hex string, metadata map[string]string) error {
+	var size int64
+	for _, part := range parts {
+		size += part.Size
+	}
+
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	// Existing objects of protected buckets are never replaced.
+	if isBucketOverwriteProtected(bucket) && xl.isObject(bucket, object) {
+		xl.deleteObject(minioMetaBucket, tempObj)
+		return ObjectAlreadyExists{Bucket: bucket, Object: object}
+	}
+
+	// Check if an object is present as one of the parent dir.
+	if xl.parentDirIsObject(bucket, path.Dir(object)) {
+		xl.deleteObject(minioMetaBucket, tempObj)
+		return toObjectErr(errFileAccessDenied, bucket, object)
+	}
+
+	// Read metadata associated with the object from all disks.
+	partsMetadata, errs := xl.readAllXLMetadata(bucket, object)
+
+	// List all online disks.
+	onlineDisks, higherVersion, err := xl.listOnlineDisks(partsMetadata, errs)
+	if err != nil {
+		xl.deleteObject(minioMetaBucket, tempObj)
+		return toObjectErr(err, bucket, object)
+	}
+
+	// Increment version only if we have online disks less than configured storage disks.
+	if diskCount(onlineDisks) < len(xl.storageDisks) {
+		higherVersion++
+	}
+
+	modTime := time.Now().UTC()
+	metadata["md5Sum"] = md5Hex
+	stampObjectProvenance(metadata, modTime)
+	stampObjectVersion(bucket, metadata)
+	xlMeta := newXLMetaV1(object, xl.dataBlocks, xl.parityBlocks)
+	xlMeta.Meta = metadata
+	xlMeta.Parts = parts
+	xlMeta.Stat.Size = size
+	xlMeta.Stat.ModTime = modTime
+	xlMeta.Stat.Version = higherVersion
+
+	// Update `xl.json` content on each disks.
+	for index := range partsMetadata {
+		partsMetadata[index] = xlMeta
+		partsMetadata[index].Erasure = eInfos[index]
+	}
+
+	// Write unique `xl.json` for each disk.
+	if err = xl.writeUniqueXLMetadata(minioMetaBucket, tempObj, partsMetadata); err != nil {
+		xl.deleteObject(minioMetaBucket, tempObj)
+		return toObjectErr(err, bucket, object)
+	}
+
+	// Rename if an object already exists to temporary location.
+	trashObj := path.Join(tmpMetaPrefix, getUUID())
+	var prevXLMeta xlMetaV1
+	if xl.isObject(bucket, object) {
+		// Save previous metadata to release its dedup reference.
+		prevXLMeta, _ = xl.readXLMetadata(bucket, object)
+		if err = xl.renameObject(bucket, object, minioMetaBucket, trashObj); err != nil {
+			xl.deleteObject(minioMetaBucket, tempObj)
+			return toObjectErr(err, bucket, object)
+		}
+	}
+
+	// Rename the successfully written temporary object to final location.
+	if err = xl.renameObject(minioMetaBucket, tempObj, bucket, object); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+
+	// Keep or delete the replaced object.
+	xl.retireObject(bucket, object, trashObj, prevXLMeta)
+
+	return nil
+}
@@ -0,0 +1,190 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"path"
+)
+
+// Content addressed dedup keeps one copy of the erasure coded shards
+// per sha256sum in minioMetaBucket on every disk as
+//
+//	dedup/<sha256sum>/xl.json
+//	dedup/<sha256sum>/object1
+//	dedup/<sha256sum>/refs/<refID>
+//
+// Objects with identical content hard link their shards to the dedup
+// shards and carry the erasure info of the dedup entry in their own
+// `xl.json`, user metadata stays per object. Each such object holds a
+// reference in refs/ and the dedup entry is removed with its last
+// reference. Since the shards are hard links, data of an object remains
+// valid irrespective of the lifetime of the dedup entry. Content is
+// keyed by sha256 computed while erasure coding, colliding md5sums
+// cannot be crafted to link an object to the shards of another.
+const (
+	dedupMetaPrefix = "dedup"
+	dedupRefsDir    = "refs"
+	dedupPartName   = "object1"
+
+	// Internal metadata keys of deduped objects.
+	dedupSumKey = "X-Minio-Internal-Dedup-Sum"
+	dedupRefKey = "X-Minio-Internal-Dedup-Ref"
+)
+
+// errDedupUnavailable - dedup entry is not present on all disks.
+var errDedupUnavailable = errors.New("Dedup entry is not available on all disks.")
+
+// errDedupShardsMixed - shards were replaced on some disks only.
+var errDedupShardsMixed = errors.New("Dedup shards were replaced on some disks only.")
+
+// getDedupPrefix - returns dedup prefix inside minioMetaBucket.
+func getDedupPrefix(sha256Hex string) string {
+	return path.Join(dedupMetaPrefix, sha256Hex)
+}
+
+// dedupObject - dedups a single part object written at tempObj, if a
+// dedup entry with the same content exists the shards are replaced by
+// links to the dedup shards, otherwise the shards become a new dedup
+// entry. On success partsMetadata and metadata are updated to refer
+// to the dedup entry. Any failure leaves the object untouched.
+func (xl xlObjects) dedupObject(tempObj, sha256Hex string, size int64, partsMetadata []xlMetaV1, metadata map[string]string) error {
+	dedupPrefix := getDedupPrefix(sha256Hex)
+	nsMutex.Lock(minioMetaBucket, dedupPrefix)
+	defer nsMutex.Unlock(minioMetaBucket, dedupPrefix)
+
+	dedupMetas, errs := xl.readAllXLMetadata(minioMetaBucket, dedupPrefix)
+	found, missing := 0, 0
+	for index, disk := range xl.storageDisks {
+		if disk == nil {
+			continue
+		}
+		if errs[index] == nil && dedupMetas[index].IsValid() && dedupMetas[index].Stat.Size == size {
+			found++
+		} else if errs[index] == errFileNotFound {
+			missing++
+		}
+	}
+
+	refID := getUUID()
+	switch {
+	case found > 0 && found == diskCount(xl.storageDisks):
+		if err := xl.linkDedupShards(tempObj, dedupPrefix); err != nil {
+			return err
+		}
+		// Shards are replaced, use erasure info of the dedup entry.
+		for index := range partsMetadata {
+			if xl.storageDisks[index] != nil {
+				partsMetadata[index].Erasure = dedupMetas[index].Erasure
+			}
+		}
+	case missing == diskCount(xl.storageDisks):
+		if err := xl.newDedupEntry(tempObj, dedupPrefix, size, partsMetadata); err != nil {
+			return err
+		}
+	default:
+		// Partially present dedup entry, store the object as is.
+		return errDedupUnavailable
+	}
+
+	refPath := path.Join(dedupPrefix, dedupRefsDir, refID)
+	err := xl.reduceWriteQuorumErrs(xl.doOnAllDisks(func(disk StorageAPI) error {
+		return disk.AppendFile(minioMetaBucket, refPath, []byte{})
+	}))
+	if err != nil {
+		return err
+	}
+	metadata[dedupSumKey] = sha256Hex
+	metadata[dedupRefKey] = refID
+	return nil
+}
+
+// linkDedupShards - replaces shards of tempObj with links to the dedup
+// shards, links are created on all disks before any shard is replaced.
+func (xl xlObjects) linkDedupShards(tempObj, dedupPrefix string) error {
+	tempPartPath := path.Join(tempObj, dedupPartName)
+	tempLinkPath := tempPartPath + ".dedup"
+	err := xl.reduceWriteQuorumErrs(xl.doOnAllDisks(func(disk StorageAPI) error {
+		return disk.LinkFile(minioMetaBucket, path.Join(dedupPrefix, dedupPartName), minioMetaBucket, tempLinkPath)
+	}))
+	if err == nil {
+		for _, dErr := range xl.doOnAllDisks(func(disk StorageAPI) error {
+			return disk.RenameFile(minioMetaBucket, tempLinkPath, minioMetaBucket, tempPartPath)
+		}) {
+			if dErr != nil && dErr != errDiskNotFound {
+				// Shards are mixed now, object cannot be stored.
+				return errDedupShardsMixed
+			}
+		}
+		return nil
+	}
+	xl.doOnAllDisks(func(disk StorageAPI) error {
+		return disk.DeleteFile(minioMetaBucket, tempLinkPath)
+	})
+	return err
+}
+
+// newDedupEntry - creates a dedup entry from the shards of tempObj.
+func (xl xlObjects) newDedupEntry(tempObj, dedupPrefix string, size int64, partsMetadata []xlMetaV1) error {
+	err := xl.reduceWriteQuorumErrs(xl.doOnAllDisks(func(disk StorageAPI) error {
+		return disk.LinkFile(minioMetaBucket, path.Join(tempObj, dedupPartName), minioMetaBucket, path.Join(dedupPrefix, dedupPartName))
+	}))
+	if err == nil {
+		// Dedup entry carries no user metadata.
+		dedupMetas := make([]xlMetaV1, len(partsMetadata))
+		for index := range partsMetadata {
+			dedupMetas[index] = partsMetadata[index]
+			dedupMetas[index].Meta = nil
+			dedupMetas[index].Stat.Size = size
+		}
+		err = xl.writeUniqueXLMetadata(minioMetaBucket, dedupPrefix, dedupMetas)
+	}
+	if err != nil {
+		xl.doOnAllDisks(func(disk StorageAPI) error {
+			return cleanupDir(disk, minioMetaBucket, dedupPrefix)
+		})
+	}
+	return err
+}
+
+// releaseDedupRef - releases the dedup reference of a replaced or
+// deleted object, the dedup entry is removed with its last reference.
+func (xl xlObjects) releaseDedupRef(xlMeta xlMetaV1) {
+	sumHex, refID := xlMeta.Meta[dedupSumKey], xlMeta.Meta[dedupRefKey]
+	if sumHex == "" || refID == "" {
+		return
+	}
+	dedupPrefix := getDedupPrefix(sumHex)
+	nsMutex.Lock(minioMetaBucket, dedupPrefix)
+	defer nsMutex.Unlock(minioMetaBucket, dedupPrefix)
+
+	xl.doOnAllDisks(func(disk StorageAPI) error {
+		return disk.DeleteFile(minioMetaBucket, path.Join(dedupPrefix, dedupRefsDir, refID))
+	})
+	for _, disk := range xl.storageDisks {
+		if disk == nil {
+			continue
+		}
+		if refs, err := disk.ListDir(minioMetaBucket, path.Join(dedupPrefix, dedupRefsDir)); err != errFileNotFound || len(refs) != 0 {
+			// Still referenced or unable to verify, keep the entry.
+			return
+		}
+	}
+	xl.doOnAllDisks(func(disk StorageAPI) error {
+		return cleanupDir(disk, minioMetaBucket, dedupPrefix)
+	})
+}
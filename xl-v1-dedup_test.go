@@ -0,0 +1,130 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+// Tests dedup of objects with identical content and reference counting.
+func TestXLDedup(t *testing.T) {
+	globalDedup = true
+	defer func() {
+		globalDedup = false
+	}()
+
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatalf("Unable to initialize XL object layer. %s", err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "dedup-bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("Unable to make bucket. %s", err)
+	}
+	data := bytes.Repeat([]byte("a"), 1024*1024)
+	sha256Sum := sha256.Sum256(data)
+	dedupPrefix := getDedupPrefix(hex.EncodeToString(sha256Sum[:]))
+
+	// Returns the number of references of the dedup entry.
+	refCount := func() int {
+		refs, err := xl.storageDisks[0].ListDir(minioMetaBucket, path.Join(dedupPrefix, dedupRefsDir))
+		if err == errFileNotFound {
+			return 0
+		}
+		if err != nil {
+			t.Fatalf("Unable to list dedup references. %s", err)
+		}
+		return len(refs)
+	}
+
+	testCases := []struct {
+		object   string
+		data     []byte
+		refCount int
+	}{
+		// Test case - 1.
+		// First object creates the dedup entry.
+		{"object1", data, 1},
+		// Test case - 2.
+		// Identical content is deduped.
+		{"object2", data, 2},
+		// Test case - 3.
+		// Overwrite with identical content keeps a single reference.
+		{"object2", data, 2},
+		// Test case - 4.
+		// Overwrite with different content releases the reference.
+		{"object2", []byte("different"), 1},
+		// Test case - 5.
+		{"object3", data, 2},
+	}
+	for i, testCase := range testCases {
+		if _, err = obj.PutObject(bucket, testCase.object, int64(len(testCase.data)), bytes.NewReader(testCase.data), nil); err != nil {
+			t.Fatalf("Test %d: Unable to put object. %s", i+1, err)
+		}
+		if count := refCount(); count != testCase.refCount {
+			t.Errorf("Test %d: Expected %d references, got %d", i+1, testCase.refCount, count)
+		}
+		buffer := new(bytes.Buffer)
+		if err = obj.GetObject(bucket, testCase.object, 0, int64(len(testCase.data)), buffer); err != nil {
+			t.Fatalf("Test %d: Unable to get object. %s", i+1, err)
+		}
+		if !bytes.Equal(buffer.Bytes(), testCase.data) {
+			t.Errorf("Test %d: Object content mismatch.", i+1)
+		}
+	}
+
+	// Deduped objects share their shards.
+	st1, err := os.Stat(filepath.Join(fsDirs[0], bucket, "object1", "object1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	st3, err := os.Stat(filepath.Join(fsDirs[0], bucket, "object3", "object1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !os.SameFile(st1, st3) {
+		t.Errorf("Expected deduped objects to share shards.")
+	}
+
+	// Dedup entry is removed with its last reference, data of the
+	// remaining object is still available until then.
+	if err = obj.DeleteObject(bucket, "object1"); err != nil {
+		t.Fatalf("Unable to delete object. %s", err)
+	}
+	if count := refCount(); count != 1 {
+		t.Errorf("Expected 1 reference, got %d", count)
+	}
+	buffer := new(bytes.Buffer)
+	if err = obj.GetObject(bucket, "object3", 0, int64(len(data)), buffer); err != nil || !bytes.Equal(buffer.Bytes(), data) {
+		t.Errorf("Expected deduped object to be readable. %v", err)
+	}
+	if err = obj.DeleteObject(bucket, "object3"); err != nil {
+		t.Fatalf("Unable to delete object. %s", err)
+	}
+	if _, err = xl.storageDisks[0].StatFile(minioMetaBucket, path.Join(dedupPrefix, xlMetaJSONFile)); err != errFileNotFound {
+		t.Errorf("Expected dedup entry to be removed, got %v", err)
+	}
+}
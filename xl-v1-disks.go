@@ -0,0 +1,465 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Interval of health pings of XL disks.
+const diskPingInterval = 10 * time.Second
+
+// xlDisk - disk at a position of XL, taken offline once it fails and
+// admitted again once the disk of the position is back. Calls of an
+// offline disk fail with errDiskNotFound.
+type xlDisk struct {
+	uuid  string // UUID of the position in `format.json`.
+	mutex *sync.RWMutex
+	disk  StorageAPI // nil while offline.
+	path  string     // Last path of the disk, empty if never online.
+	since time.Time  // Time of the last state change.
+}
+
+// xlDiskState - state of an XL disk.
+type xlDiskState struct {
+	Disk   string    `json:"disk"`
+	UUID   string    `json:"uuid"`
+	Online bool      `json:"online"`
+	Since  time.Time `json:"since"`
+}
+
+// getDisk - returns the disk, nil while offline.
+func (d *xlDisk) getDisk() StorageAPI {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.disk
+}
+
+// getPath - returns last path of the disk.
+func (d *xlDisk) getPath() string {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.path
+}
+
+// setOnline - admits disk found at path.
+func (d *xlDisk) setOnline(disk StorageAPI, path string) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.disk = disk
+	d.path = path
+	d.since = time.Now().UTC()
+}
+
+// setOffline - takes the disk offline, unless another disk was
+// admitted meanwhile.
+func (d *xlDisk) setOffline(disk StorageAPI) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.disk != disk {
+		return
+	}
+	d.disk = nil
+	d.since = time.Now().UTC()
+}
+
+// state - returns state of the disk.
+func (d *xlDisk) state() xlDiskState {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return xlDiskState{Disk: d.path, UUID: d.uuid, Online: d.disk != nil, Since: d.since}
+}
+
+// check - takes the disk offline if err tells it is gone or faulty.
+func (d *xlDisk) check(disk StorageAPI, err error) error {
+	if err == errDiskNotFound || err == errFaultyDisk {
+		d.setOffline(disk)
+	}
+	return err
+}
+
+// MakeVol - StorageAPI of the disk.
+func (d *xlDisk) MakeVol(volume string) error {
+	disk := d.getDisk()
+	if disk == nil {
+		return errDiskNotFound
+	}
+	return d.check(disk, disk.MakeVol(volume))
+}
+
+// ListVols - StorageAPI of the disk.
+func (d *xlDisk) ListVols() ([]VolInfo, error) {
+	disk := d.getDisk()
+	if disk == nil {
+		return nil, errDiskNotFound
+	}
+	vols, err := disk.ListVols()
+	return vols, d.check(disk, err)
+}
+
+// StatVol - StorageAPI of the disk.
+func (d *xlDisk) StatVol(volume string) (VolInfo, error) {
+	disk := d.getDisk()
+	if disk == nil {
+		return VolInfo{}, errDiskNotFound
+	}
+	vol, err := disk.StatVol(volume)
+	return vol, d.check(disk, err)
+}
+
+// DeleteVol - StorageAPI of the disk.
+func (d *xlDisk) DeleteVol(volume string) error {
+	disk := d.getDisk()
+	if disk == nil {
+		return errDiskNotFound
+	}
+	return d.check(disk, disk.DeleteVol(volume))
+}
+
+// ListDir - StorageAPI of the disk.
+func (d *xlDisk) ListDir(volume, dirPath string) ([]string, error) {
+	disk := d.getDisk()
+	if disk == nil {
+		return nil, errDiskNotFound
+	}
+	entries, err := disk.ListDir(volume, dirPath)
+	return entries, d.check(disk, err)
+}
+
+// ReadFile - StorageAPI of the disk.
+func (d *xlDisk) ReadFile(volume string, path string, offset int64, buf []byte) (int64, error) {
+	disk := d.getDisk()
+	if disk == nil {
+		return 0, errDiskNotFound
+	}
+	n, err := disk.ReadFile(volume, path, offset, buf)
+	return n, d.check(disk, err)
+}
+
+// AppendFile - StorageAPI of the disk.
+func (d *xlDisk) AppendFile(volume string, path string, buf []byte) error {
+	disk := d.getDisk()
+	if disk == nil {
+		return errDiskNotFound
+	}
+	return d.check(disk, disk.AppendFile(volume, path, buf))
+}
+
+// RenameFile - StorageAPI of the disk.
+func (d *xlDisk) RenameFile(srcVolume, srcPath, dstVolume, dstPath string) error {
+	disk := d.getDisk()
+	if disk == nil {
+		return errDiskNotFound
+	}
+	return d.check(disk, disk.RenameFile(srcVolume, srcPath, dstVolume, dstPath))
+}
+
+// LinkFile - StorageAPI of the disk.
+func (d *xlDisk) LinkFile(srcVolume, srcPath, dstVolume, dstPath string) error {
+	disk := d.getDisk()
+	if disk == nil {
+		return errDiskNotFound
+	}
+	return d.check(disk, disk.LinkFile(srcVolume, srcPath, dstVolume, dstPath))
+}
+
+// StatFile - StorageAPI of the disk.
+func (d *xlDisk) StatFile(volume string, path string) (FileInfo, error) {
+	disk := d.getDisk()
+	if disk == nil {
+		return FileInfo{}, errDiskNotFound
+	}
+	file, err := disk.StatFile(volume, path)
+	return file, d.check(disk, err)
+}
+
+// DeleteFile - StorageAPI of the disk.
+func (d *xlDisk) DeleteFile(volume string, path string) error {
+	disk := d.getDisk()
+	if disk == nil {
+		return errDiskNotFound
+	}
+	return d.check(disk, disk.DeleteFile(volume, path))
+}
+
+// ShredFile - StorageAPI of the disk.
+func (d *xlDisk) ShredFile(volume string, path string) error {
+	disk := d.getDisk()
+	if disk == nil {
+		return errDiskNotFound
+	}
+	return d.check(disk, disk.ShredFile(volume, path))
+}
+
+// ReadAll - StorageAPI of the disk.
+func (d *xlDisk) ReadAll(volume string, path string) ([]byte, error) {
+	disk := d.getDisk()
+	if disk == nil {
+		return nil, errDiskNotFound
+	}
+	buf, err := disk.ReadAll(volume, path)
+	return buf, d.check(disk, err)
+}
+
+// diskMonitor - pings XL disks, taking failed disks offline and
+// admitting disks which are back.
+type diskMonitor struct {
+	jbod  []string
+	disks []*xlDisk
+	// Serializes pings.
+	mutex *sync.Mutex
+	// Disks given on the command line which were not found while
+	// the server started, their position is unknown.
+	unplaced []string
+	// Set while disks admitted are healed, and while disks admitted
+	// meanwhile are pending a heal.
+	healing int32
+	pending int32
+}
+
+// Disk monitor of the XL object layer.
+var globalDiskMonitor = newDiskMonitor(nil, nil)
+
+// normalizeDiskPath - returns local disks as absolute paths.
+func normalizeDiskPath(disk string) string {
+	if !isLocalExportPath(disk) {
+		return disk
+	}
+	if absPath, err := filepath.Abs(disk); err == nil {
+		return absPath
+	}
+	return disk
+}
+
+// newDiskMonitor - wraps disks ordered by `format.json` as XL disks,
+// disks given on the command line but not found to hold a position
+// are placed once they are back.
+func newDiskMonitor(disks []string, orderedDisks []StorageAPI) *diskMonitor {
+	m := &diskMonitor{mutex: &sync.Mutex{}}
+	for _, disk := range orderedDisks {
+		if disk == nil {
+			continue
+		}
+		if format, err := loadFormat(disk); err == nil {
+			m.jbod = format.XL.JBOD
+			break
+		}
+	}
+	placed := make(map[string]bool)
+	for index, disk := range orderedDisks {
+		d := &xlDisk{mutex: &sync.RWMutex{}, since: time.Now().UTC()}
+		if index < len(m.jbod) {
+			d.uuid = m.jbod[index]
+		}
+		if disk != nil {
+			d.disk = disk
+			d.path = getDiskName(disk, index)
+			placed[d.path] = true
+		}
+		m.disks = append(m.disks, d)
+	}
+	for _, disk := range disks {
+		if path := normalizeDiskPath(disk); !placed[path] {
+			m.unplaced = append(m.unplaced, path)
+		}
+	}
+	return m
+}
+
+// storageDisks - returns XL disks of all positions.
+func (m *diskMonitor) storageDisks() []StorageAPI {
+	disks := make([]StorageAPI, len(m.disks))
+	for index, d := range m.disks {
+		disks[index] = d
+	}
+	return disks
+}
+
+// states - returns state of all disks, disks never online are named
+// by position.
+func (m *diskMonitor) states() []xlDiskState {
+	states := make([]xlDiskState, len(m.disks))
+	for index, d := range m.disks {
+		states[index] = d.state()
+		if states[index].Disk == "" {
+			states[index].Disk = getDiskName(nil, index)
+		}
+	}
+	return states
+}
+
+// pingDisk - takes a disk offline unless it holds `format.json` of its
+// position.
+func (m *diskMonitor) pingDisk(d *xlDisk) {
+	disk := d.getDisk()
+	if disk == nil {
+		return
+	}
+	if format, err := loadFormat(disk); err != nil || format.XL.Disk != d.uuid {
+		d.setOffline(disk)
+	}
+}
+
+// admitDisk - admits disk at path to the offline position it was
+// formatted for. Fresh disks are formatted for the offline position
+// last at path, or the only offline position never online if path
+// was not found while the server started. Returns true if admitted.
+func (m *diskMonitor) admitDisk(path string, isUnplaced bool) bool {
+	disk, err := newStorageAPI(path)
+	if err != nil {
+		return false
+	}
+	// Creates the meta bucket on fresh disks.
+	xlHouseKeeping([]StorageAPI{disk})
+	format, err := loadFormat(disk)
+	switch {
+	case err == nil:
+		for _, d := range m.disks {
+			if d.uuid == format.XL.Disk && d.getDisk() == nil {
+				d.setOnline(disk, path)
+				return true
+			}
+		}
+		return false
+	case err == errUnformattedDisk:
+		var slot *xlDisk
+		var pathless []*xlDisk
+		for _, d := range m.disks {
+			if d.getDisk() != nil || d.uuid == "" {
+				continue
+			}
+			if d.getPath() == path {
+				slot = d
+				break
+			}
+			if d.getPath() == "" {
+				pathless = append(pathless, d)
+			}
+		}
+		if slot == nil && isUnplaced && len(pathless) == 1 {
+			slot = pathless[0]
+		}
+		if slot == nil {
+			return false
+		}
+		format := &formatConfigV1{
+			Version: "1",
+			Format:  "xl",
+			XL: &xlFormat{
+				Version: "1",
+				Disk:    slot.uuid,
+				JBOD:    m.jbod,
+			},
+		}
+		// Disks of erasure sets are formatted for the set of the
+		// online disks.
+		if refFormat := m.referenceFormat(); refFormat != nil {
+			format.XL.Version = refFormat.XL.Version
+			format.XL.Set = refFormat.XL.Set
+			format.XL.Sets = refFormat.XL.Sets
+		}
+		if err = saveFormatXL([]StorageAPI{disk}, []*formatConfigV1{format}); err != nil {
+			errorIf(err, "Unable to format disk %s.", path)
+			return false
+		}
+		slot.setOnline(disk, path)
+		return true
+	}
+	return false
+}
+
+// referenceFormat - returns format of the first online disk which
+// can be read, nil if none.
+func (m *diskMonitor) referenceFormat() *formatConfigV1 {
+	for _, d := range m.disks {
+		disk := d.getDisk()
+		if disk == nil {
+			continue
+		}
+		if format, err := loadFormat(disk); err == nil {
+			return format
+		}
+	}
+	return nil
+}
+
+// ping - pings all disks, failed disks are taken offline and disks of
+// offline positions which are back are admitted. Returns true if any
+// disk was admitted.
+func (m *diskMonitor) ping() (admitted bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var wg = &sync.WaitGroup{}
+	for _, d := range m.disks {
+		wg.Add(1)
+		go func(d *xlDisk) {
+			defer wg.Done()
+			m.pingDisk(d)
+		}(d)
+	}
+	wg.Wait()
+
+	// Positions are looked up one disk at a time, so that no two
+	// disks are admitted to a position.
+	var paths []string
+	for _, d := range m.disks {
+		if path := d.getPath(); path != "" && d.getDisk() == nil {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if m.admitDisk(path, false) {
+			admitted = true
+		}
+	}
+	var unplaced []string
+	for _, path := range m.unplaced {
+		if m.admitDisk(path, true) {
+			admitted = true
+			continue
+		}
+		unplaced = append(unplaced, path)
+	}
+	m.unplaced = unplaced
+	return admitted
+}
+
+// run - pings disks periodically, heal is called in the background
+// once disks are admitted. Disks admitted while a heal is running are
+// healed once it is done.
+func (m *diskMonitor) run(interval time.Duration, heal func()) {
+	for {
+		time.Sleep(interval)
+		if m.ping() {
+			atomic.StoreInt32(&m.pending, 1)
+		}
+		if atomic.LoadInt32(&m.pending) == 0 || !atomic.CompareAndSwapInt32(&m.healing, 0, 1) {
+			continue
+		}
+		atomic.StoreInt32(&m.pending, 0)
+		go func() {
+			defer atomic.StoreInt32(&m.healing, 0)
+			heal()
+		}()
+	}
+}
@@ -0,0 +1,146 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tests disks are taken offline once pulled and admitted again once
+// back or replaced, without restarting XL.
+func TestXLDiskHotSwap(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+	monitor := xl.diskMonitor
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("a"), 1024)
+	if _, err = obj.PutObject(bucket, "object1", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Pull the first disk.
+	diskPath := getDiskName(xl.storageDisks[0], 0)
+	if err = os.Rename(diskPath, diskPath+".pulled"); err != nil {
+		t.Fatal(err)
+	}
+	if monitor.ping() {
+		t.Error("Expected no disk to be admitted")
+	}
+	if monitor.states()[0].Online {
+		t.Fatal("Expected pulled disk to be offline")
+	}
+	if _, err = obj.PutObject(bucket, "object2", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Plug the disk back in.
+	if err = os.Rename(diskPath+".pulled", diskPath); err != nil {
+		t.Fatal(err)
+	}
+	if !monitor.ping() {
+		t.Fatal("Expected disk to be admitted")
+	}
+	if !monitor.states()[0].Online {
+		t.Fatal("Expected disk to be online")
+	}
+	xl.scrub()
+	if _, err = os.Stat(filepath.Join(diskPath, bucket, "object2", xlMetaJSONFile)); err != nil {
+		t.Errorf("Expected object written while offline to be healed, got %s", err)
+	}
+
+	// Replace the second disk with a fresh disk.
+	diskPath = getDiskName(xl.storageDisks[1], 1)
+	uuid := monitor.states()[1].UUID
+	if err = os.RemoveAll(diskPath); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Mkdir(diskPath, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if !monitor.ping() {
+		t.Fatal("Expected fresh disk to be admitted")
+	}
+	format, err := loadFormat(xl.storageDisks[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format.XL.Disk != uuid {
+		t.Errorf("Expected fresh disk formatted as %s, got %s", uuid, format.XL.Disk)
+	}
+	xl.scrub()
+	var buf bytes.Buffer
+	if err = obj.GetObject(bucket, "object1", 0, int64(len(data)), &buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, object := range []string{"object1", "object2"} {
+		if _, err = os.Stat(filepath.Join(diskPath, bucket, object, xlMetaJSONFile)); err != nil {
+			t.Errorf("Expected %s to be healed on fresh disk, got %s", object, err)
+		}
+	}
+}
+
+// Tests disks not found while XL starts are placed once they are back.
+func TestXLDiskAdmitUnplaced(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	if err = obj.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restart with the last disk missing.
+	diskPath := fsDirs[len(fsDirs)-1]
+	if err = os.Rename(diskPath, diskPath+".pulled"); err != nil {
+		t.Fatal(err)
+	}
+	obj, err = newXLObjects(fsDirs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	monitor := obj.(xlObjects).diskMonitor
+	if len(monitor.unplaced) != 1 {
+		t.Fatalf("Expected 1 unplaced disk, got %v", monitor.unplaced)
+	}
+
+	if err = os.Rename(diskPath+".pulled", diskPath); err != nil {
+		t.Fatal(err)
+	}
+	if !monitor.ping() {
+		t.Fatal("Expected disk to be admitted")
+	}
+	for index, state := range monitor.states() {
+		if !state.Online {
+			t.Errorf("Expected disk %d to be online", index+1)
+		}
+	}
+	if len(monitor.unplaced) != 0 {
+		t.Errorf("Expected no unplaced disks, got %v", monitor.unplaced)
+	}
+}
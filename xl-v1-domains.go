@@ -0,0 +1,153 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Failure domains of disks are saved in the config directory.
+const failureDomainsFile = "failure-domains.json"
+
+// Failure domain of local disks without a label.
+const localFailureDomain = "localhost"
+
+// failureDomainsConfig - failure domain labels, such as racks, of disks
+// and hosts.
+type failureDomainsConfig struct {
+	// Failure domain of disks, by disk as given on the command line.
+	Disks map[string]string `json:"disks,omitempty"`
+	// Failure domain of all disks of a host.
+	Hosts map[string]string `json:"hosts,omitempty"`
+}
+
+// isSet - returns true if any label is configured.
+func (config failureDomainsConfig) isSet() bool {
+	return len(config.Disks) > 0 || len(config.Hosts) > 0
+}
+
+// getFailureDomain - returns failure domain of a disk, disks without a
+// label of their own or their host are a failure domain with all
+// disks of their host.
+func (config failureDomainsConfig) getFailureDomain(disk string) string {
+	if domain, ok := config.Disks[disk]; ok {
+		return domain
+	}
+	host := localFailureDomain
+	if !isLocalExportPath(disk) {
+		host, _ = splitNetPath(disk)
+	}
+	if domain, ok := config.Hosts[host]; ok {
+		return domain
+	}
+	return host
+}
+
+// parseFailureDomains - parses and validates failure domain labels.
+func parseFailureDomains(configBuf []byte) (config failureDomainsConfig, err error) {
+	if err = json.Unmarshal(configBuf, &config); err != nil {
+		return failureDomainsConfig{}, err
+	}
+	for _, labels := range []map[string]string{config.Disks, config.Hosts} {
+		for name, domain := range labels {
+			if strings.TrimSpace(domain) == "" {
+				return failureDomainsConfig{}, fmt.Errorf("Failure domain of %s cannot be empty.", name)
+			}
+		}
+	}
+	return config, nil
+}
+
+// readFailureDomains - read failure domain labels, disks are labeled
+// by their host if none were configured.
+func readFailureDomains() (failureDomainsConfig, error) {
+	configPath, err := getConfigPath()
+	if err != nil {
+		return failureDomainsConfig{}, err
+	}
+	configBuf, err := ioutil.ReadFile(filepath.Join(configPath, failureDomainsFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return failureDomainsConfig{}, nil
+		}
+		return failureDomainsConfig{}, err
+	}
+	return parseFailureDomains(configBuf)
+}
+
+// failureDomainInfo - disks of a failure domain, every disk holds one
+// shard of every object.
+type failureDomainInfo struct {
+	Domain string   `json:"domain"`
+	Disks  []string `json:"disks"`
+	// Objects remain readable after losing the domain.
+	Tolerated bool `json:"tolerated"`
+}
+
+// failureDomainsInfo - failure domains of all XL disks.
+type failureDomainsInfo struct {
+	Parity  int                 `json:"parity"`
+	Domains []failureDomainInfo `json:"domains"`
+}
+
+// Failure domains of the disks of the XL object layer.
+var globalFailureDomains failureDomainsInfo
+
+// getFailureDomains - groups disks by failure domain, sorted by
+// domain. Domains with more disks than parity blocks hold too many
+// shards of every object to lose.
+func getFailureDomains(disks []string, config failureDomainsConfig, parityBlocks int) failureDomainsInfo {
+	domainDisks := make(map[string][]string)
+	for _, disk := range disks {
+		domain := config.getFailureDomain(disk)
+		domainDisks[domain] = append(domainDisks[domain], disk)
+	}
+	info := failureDomainsInfo{Parity: parityBlocks}
+	for domain, disks := range domainDisks {
+		info.Domains = append(info.Domains, failureDomainInfo{
+			Domain:    domain,
+			Disks:     disks,
+			Tolerated: len(disks) <= parityBlocks,
+		})
+	}
+	sort.Sort(byFailureDomain(info.Domains))
+	return info
+}
+
+// byFailureDomain is a collection satisfying sort.Interface.
+type byFailureDomain []failureDomainInfo
+
+func (d byFailureDomain) Len() int           { return len(d) }
+func (d byFailureDomain) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d byFailureDomain) Less(i, j int) bool { return d[i].Domain < d[j].Domain }
+
+// checkFailureDomains - returns an error for the first failure domain
+// whose loss loses data.
+func checkFailureDomains(info failureDomainsInfo) error {
+	for _, domain := range info.Domains {
+		if !domain.Tolerated {
+			return fmt.Errorf("Failure domain %s holds %d shards of every object, more than the %d parity blocks, losing it loses data. Spread disks across more failure domains or raise MINIO_ERASURE_PARITY.", domain.Domain, len(domain.Disks), info.Parity)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Tests grouping of disks by failure domain.
+func TestGetFailureDomains(t *testing.T) {
+	// Four hosts with four disks each.
+	var disks []string
+	for host := 1; host <= 4; host++ {
+		for disk := 1; disk <= 4; disk++ {
+			disks = append(disks, fmt.Sprintf("host%d:/mnt/disk%d", host, disk))
+		}
+	}
+	testCases := []struct {
+		disks             []string
+		config            failureDomainsConfig
+		parityBlocks      int
+		expectedDomains   []string
+		expectedTolerated bool
+	}{
+		// Test case - 1.
+		// Local disks are a single domain.
+		{[]string{"/mnt/disk1", "/mnt/disk2"}, failureDomainsConfig{}, 1, []string{localFailureDomain}, false},
+		// Test case - 2.
+		// Disks are labeled by their host.
+		{disks, failureDomainsConfig{}, 4, []string{"host1", "host2", "host3", "host4"}, true},
+		// Test case - 3.
+		{disks, failureDomainsConfig{}, 3, []string{"host1", "host2", "host3", "host4"}, false},
+		// Test case - 4.
+		// Two racks of two hosts.
+		{disks, failureDomainsConfig{Hosts: map[string]string{
+			"host1": "rack1", "host2": "rack1", "host3": "rack2", "host4": "rack2",
+		}}, 8, []string{"rack1", "rack2"}, true},
+		// Test case - 5.
+		// Disk labels take precedence over host labels.
+		{disks, failureDomainsConfig{
+			Disks: map[string]string{"host3:/mnt/disk1": "rack1"},
+			Hosts: map[string]string{"host1": "rack1", "host2": "rack1", "host3": "rack2", "host4": "rack2"},
+		}, 8, []string{"rack1", "rack2"}, false},
+	}
+	for i, testCase := range testCases {
+		info := getFailureDomains(testCase.disks, testCase.config, testCase.parityBlocks)
+		var domains []string
+		var diskCount int
+		for _, domain := range info.Domains {
+			domains = append(domains, domain.Domain)
+			diskCount += len(domain.Disks)
+		}
+		if fmt.Sprint(domains) != fmt.Sprint(testCase.expectedDomains) {
+			t.Errorf("Test %d: Expected domains %v, got %v", i+1, testCase.expectedDomains, domains)
+		}
+		if diskCount != len(testCase.disks) {
+			t.Errorf("Test %d: Expected %d disks, got %d", i+1, len(testCase.disks), diskCount)
+		}
+		err := checkFailureDomains(info)
+		if testCase.expectedTolerated && err != nil {
+			t.Errorf("Test %d: Expected domains to be tolerated, failed with %s", i+1, err)
+		}
+		if !testCase.expectedTolerated && err == nil {
+			t.Errorf("Test %d: Expected domains not to be tolerated", i+1)
+		}
+	}
+
+	if _, err := parseFailureDomains([]byte(`{"hosts": {"host1": " "}}`)); err == nil {
+		t.Error("Expected empty failure domain to fail")
+	}
+}
+
+// Tests fresh disks are not formatted with failure domains which
+// cannot be lost.
+func TestXLFormatFailureDomains(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+
+	var disks []string
+	for i := 0; i < 16; i++ {
+		disk, err := ioutil.TempDir(os.TempDir(), "minio-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		disks = append(disks, disk)
+	}
+	defer removeRoots(disks)
+
+	// Nine disks of the first rack exceed the 8 parity blocks.
+	config := failureDomainsConfig{Disks: make(map[string]string)}
+	for index, disk := range disks {
+		config.Disks[disk] = "rack1"
+		if index >= 9 {
+			config.Disks[disk] = "rack2"
+		}
+	}
+	configBuf, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configFile := filepath.Join(root, failureDomainsFile)
+	if err = ioutil.WriteFile(configFile, configBuf, 0600); err != nil {
+		t.Fatal(err)
+	}
+	initNSLock()
+	if _, err = newXLObjects(disks); err == nil {
+		t.Fatal("Expected format to fail")
+	}
+	if _, err = os.Stat(filepath.Join(disks[0], minioMetaBucket, formatConfigFile)); !os.IsNotExist(err) {
+		t.Errorf("Expected disks not to be formatted, got %v", err)
+	}
+
+	// Eight disks of each rack.
+	config.Disks[disks[8]] = "rack2"
+	if configBuf, err = json.Marshal(config); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(configFile, configBuf, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = newXLObjects(disks); err != nil {
+		t.Fatal(err)
+	}
+	if len(globalFailureDomains.Domains) != 2 {
+		t.Errorf("Expected 2 failure domains, got %d", len(globalFailureDomains.Domains))
+	}
+}
@@ -0,0 +1,79 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+/// Bucket erase operations
+
+// releaseBucketDedupRefs - releases dedup references of all objects of
+// a bucket, so that shards not used by other objects are last linked
+// from the bucket.
+func (xl xlObjects) releaseBucketDedupRefs(bucket string) error {
+	marker := ""
+	for {
+		result, err := xl.listObjects(bucket, "", marker, "", maxObjectList)
+		if err != nil {
+			return toObjectErr(err, bucket)
+		}
+		for _, objInfo := range result.Objects {
+			nsMutex.Lock(bucket, objInfo.Name)
+			xlMeta, err := xl.readXLMetadata(bucket, objInfo.Name)
+			if err == nil {
+				xl.releaseDedupRef(xlMeta)
+			}
+			nsMutex.Unlock(bucket, objInfo.Name)
+			// Objects deleted meanwhile hold no reference.
+			if err != nil && err != errFileNotFound {
+				return toObjectErr(err, bucket, objInfo.Name)
+			}
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+	}
+	return nil
+}
+
+// EraseBucket - erase a bucket along with all of its objects, versions,
+// multipart uploads and snapshots on all disks.
+func (xl xlObjects) EraseBucket(bucket string, overwrite bool) error {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if !xl.isBucketExist(bucket) {
+		return BucketNotFound{Bucket: bucket}
+	}
+	if err := xl.releaseBucketDedupRefs(bucket); err != nil {
+		return err
+	}
+	if err := xl.releaseVersionDedupRefs(bucket); err != nil {
+		return err
+	}
+	err := xl.reduceWriteQuorumErrs(xl.doOnAllDisks(func(disk StorageAPI) error {
+		err := eraseBucketContents(disk, bucket, overwrite)
+		if err == errVolumeNotFound {
+			// Bucket is missing on this disk.
+			return nil
+		}
+		return err
+	}))
+	if err != nil {
+		return toObjectErr(err, bucket)
+	}
+	return xl.DeleteBucket(bucket)
+}
@@ -16,7 +16,11 @@
 
 package main
 
-import "sync"
+import (
+	"sort"
+	"strings"
+	"sync"
+)
 
 // Get the highest integer from a given integer slice.
 func highestInt(intSlice []int64, highestInt int64) (highestInteger int64) {
@@ -124,3 +128,192 @@ func (xl xlObjects) listOnlineDisks(partsMetadata []xlMetaV1, errs []error) (onl
 	}
 	return onlineDisks, highestVersion, nil
 }
+
+// getDiskNames - returns names of disks by their index.
+func (xl xlObjects) getDiskNames(indexes []int) (names []string) {
+	for _, index := range indexes {
+		names = append(names, getDiskName(xl.storageDisks[index], index))
+	}
+	return names
+}
+
+// walkAllDisks - calls fn with all objects under prefixDir on any
+// disk, objects missing from some disks are included unlike listing
+// which reads a single disk.
+func (xl xlObjects) walkAllDisks(bucket, prefixDir string, fn func(object string)) {
+	entriesMap := make(map[string]struct{})
+	for _, disk := range xl.storageDisks {
+		if disk == nil {
+			continue
+		}
+		entries, err := disk.ListDir(bucket, prefixDir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			entriesMap[entry] = struct{}{}
+		}
+	}
+	entries := make([]string, 0, len(entriesMap))
+	for entry := range entriesMap {
+		if strings.HasSuffix(entry, slashSeparator) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Strings(entries)
+	for _, entry := range entries {
+		entryPath := pathJoin(prefixDir, entry)
+		if xl.isObjectOnAnyDisk(bucket, entryPath) {
+			fn(strings.TrimSuffix(entryPath, slashSeparator))
+			continue
+		}
+		xl.walkAllDisks(bucket, entryPath, fn)
+	}
+}
+
+// healAllObjects - calls fn with all objects of a bucket on any disk,
+// as many objects concurrently as the heal throttle allows.
+func (xl xlObjects) healAllObjects(bucket string, fn func(object string)) {
+	var wg = &sync.WaitGroup{}
+	xl.walkAllDisks(bucket, "", func(object string) {
+		globalHealThrottle.acquire()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer globalHealThrottle.release()
+			fn(object)
+		}()
+	})
+	wg.Wait()
+}
+
+// objectHealInfoByName - sorts heal reports by object name.
+type objectHealInfoByName []ObjectHealInfo
+
+func (o objectHealInfoByName) Len() int           { return len(o) }
+func (o objectHealInfoByName) Swap(i, j int)      { o[i], o[j] = o[j], o[i] }
+func (o objectHealInfoByName) Less(i, j int) bool { return o[i].Object < o[j].Object }
+
+// isObjectOnAnyDisk - returns true if any disk holds metadata of the
+// object.
+func (xl xlObjects) isObjectOnAnyDisk(bucket, object string) bool {
+	for _, disk := range xl.storageDisks {
+		if disk == nil {
+			continue
+		}
+		if _, err := disk.StatFile(bucket, pathJoin(object, xlMetaJSONFile)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// isBucketOnAnyDisk - returns true if any disk holds the bucket.
+func (xl xlObjects) isBucketOnAnyDisk(bucket string) bool {
+	for _, disk := range xl.storageDisks {
+		if disk == nil {
+			continue
+		}
+		if _, err := disk.StatVol(bucket); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// healBucketVolume - creates the bucket on disks missing it unless
+// dryRun, returns disks missing the bucket.
+func (xl xlObjects) healBucketVolume(bucket string, dryRun bool) (missing []int, err error) {
+	nsMutex.Lock(bucket, "")
+	defer nsMutex.Unlock(bucket, "")
+
+	for index, disk := range xl.storageDisks {
+		if disk == nil {
+			continue
+		}
+		if _, sErr := disk.StatVol(bucket); sErr != errVolumeNotFound {
+			continue
+		}
+		missing = append(missing, index)
+		if dryRun {
+			continue
+		}
+		if mErr := disk.MakeVol(bucket); mErr != nil && mErr != errVolumeExists {
+			err = mErr
+		}
+	}
+	return missing, err
+}
+
+// HealObject - rebuilds missing and corrupted shards of an object from
+// the other disks, only reports disks holding them if dryRun.
+func (xl xlObjects) HealObject(bucket, object string, dryRun bool) (ObjectHealInfo, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return ObjectHealInfo{}, BucketNameInvalid{Bucket: bucket}
+	}
+	// Verify if object is valid.
+	if !IsValidObjectName(object) {
+		return ObjectHealInfo{}, ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	// Disks which came back empty miss the bucket, look it up on
+	// all disks.
+	if !xl.isBucketOnAnyDisk(bucket) {
+		return ObjectHealInfo{}, BucketNotFound{Bucket: bucket}
+	}
+	if !xl.isObjectOnAnyDisk(bucket, object) {
+		return ObjectHealInfo{}, ObjectNotFound{Bucket: bucket, Object: object}
+	}
+	missing, corrupted, err := xl.healObject(bucket, object, dryRun)
+	info := ObjectHealInfo{
+		Bucket:         bucket,
+		Object:         object,
+		MissingDisks:   xl.getDiskNames(missing),
+		CorruptedDisks: xl.getDiskNames(corrupted),
+		Healed:         !dryRun && err == nil && len(missing)+len(corrupted) > 0,
+	}
+	if err != nil {
+		return info, toObjectErr(err, bucket, object)
+	}
+	return info, nil
+}
+
+// HealBucket - creates a bucket on disks missing it and heals all its
+// objects, only reports disks and objects to heal if dryRun. Objects
+// failing to heal are reported with their error.
+func (xl xlObjects) HealBucket(bucket string, dryRun bool) (BucketHealInfo, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketHealInfo{}, BucketNameInvalid{Bucket: bucket}
+	}
+	if !xl.isBucketOnAnyDisk(bucket) {
+		return BucketHealInfo{}, BucketNotFound{Bucket: bucket}
+	}
+	missing, err := xl.healBucketVolume(bucket, dryRun)
+	if err != nil {
+		return BucketHealInfo{}, toObjectErr(err, bucket)
+	}
+	info := BucketHealInfo{
+		Bucket:       bucket,
+		MissingDisks: xl.getDiskNames(missing),
+		Objects:      []ObjectHealInfo{},
+	}
+	var mutex = &sync.Mutex{}
+	xl.healAllObjects(bucket, func(object string) {
+		objInfo, hErr := xl.HealObject(bucket, object, dryRun)
+		if hErr != nil {
+			// Object deleted since it was listed.
+			if _, ok := hErr.(ObjectNotFound); ok {
+				return
+			}
+			objInfo.Error = hErr.Error()
+		}
+		if len(objInfo.MissingDisks)+len(objInfo.CorruptedDisks) > 0 || objInfo.Error != "" {
+			mutex.Lock()
+			info.Objects = append(info.Objects, objInfo)
+			mutex.Unlock()
+		}
+	})
+	sort.Sort(objectHealInfoByName(info.Objects))
+	return info, nil
+}
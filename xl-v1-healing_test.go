@@ -0,0 +1,170 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Tests damaged shards of an object are reported and healed.
+func TestHealObject(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello, world")
+	if _, err = obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+	xlMeta, err := xl.readXLMetadata(bucket, "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	diskPath := func(index int) string {
+		return getDiskName(xl.storageDisks[index], index)
+	}
+	if err = os.RemoveAll(filepath.Join(diskPath(3), bucket, "object")); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(diskPath(9), bucket, "object", xlMeta.Parts[0].Name), []byte("corrupted"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	damaged := ObjectHealInfo{
+		Bucket:         bucket,
+		Object:         "object",
+		MissingDisks:   []string{diskPath(3)},
+		CorruptedDisks: []string{diskPath(9)},
+	}
+	healed := damaged
+	healed.Healed = true
+	testCases := []struct {
+		object       string
+		dryRun       bool
+		expectedInfo ObjectHealInfo
+		expectedErr  error
+	}{
+		// Test case - 1.
+		// Dry-run only reports damaged shards.
+		{"object", true, damaged, nil},
+		// Test case - 2.
+		{"object", true, damaged, nil},
+		// Test case - 3.
+		{"object", false, healed, nil},
+		// Test case - 4.
+		// Nothing left to heal.
+		{"object", false, ObjectHealInfo{Bucket: bucket, Object: "object"}, nil},
+		// Test case - 5.
+		{"missing", false, ObjectHealInfo{}, ObjectNotFound{Bucket: bucket, Object: "missing"}},
+	}
+	for i, testCase := range testCases {
+		info, err := obj.HealObject(bucket, testCase.object, testCase.dryRun)
+		if err != testCase.expectedErr {
+			t.Fatalf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		if !reflect.DeepEqual(info, testCase.expectedInfo) {
+			t.Errorf("Test %d: Expected %+v, got %+v", i+1, testCase.expectedInfo, info)
+		}
+	}
+
+	fsObj, fsDir, err := getSingleNodeObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeAll(fsDir)
+	if _, err = fsObj.HealObject(bucket, "object", false); err != (HealingNotSupported{}) {
+		t.Errorf("Expected %v for FS, got %v", HealingNotSupported{}, err)
+	}
+}
+
+// Tests disks missing a bucket are repopulated.
+func TestHealBucket(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello, world")
+	objects := []string{"a", "photos/2016/b", "photos/c"}
+	for _, object := range objects {
+		if _, err = obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Disk replaced with an empty one.
+	diskPath := getDiskName(xl.storageDisks[6], 6)
+	if err = os.RemoveAll(filepath.Join(diskPath, bucket)); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := obj.HealBucket(bucket, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(info.MissingDisks, []string{diskPath}) {
+		t.Errorf("Expected disks missing the bucket %v, got %v", []string{diskPath}, info.MissingDisks)
+	}
+	if len(info.Objects) != len(objects) {
+		t.Fatalf("Expected %d damaged objects, got %d", len(objects), len(info.Objects))
+	}
+	for i, objInfo := range info.Objects {
+		if objInfo.Object != objects[i] || objInfo.Healed || !reflect.DeepEqual(objInfo.MissingDisks, []string{diskPath}) {
+			t.Errorf("Expected %s missing on %s, got %+v", objects[i], diskPath, objInfo)
+		}
+	}
+	if _, err = os.Stat(filepath.Join(diskPath, bucket)); !os.IsNotExist(err) {
+		t.Fatalf("Expected dry-run to leave the bucket missing, got %v", err)
+	}
+
+	// Objects are healed concurrently.
+	globalHealThrottle.Set(healThrottleConfig{Concurrency: len(objects)})
+	defer globalHealThrottle.Set(healThrottleConfig{})
+	if info, err = obj.HealBucket(bucket, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Objects) != len(objects) {
+		t.Fatalf("Expected %d healed objects, got %d", len(objects), len(info.Objects))
+	}
+	for _, objInfo := range info.Objects {
+		if !objInfo.Healed {
+			t.Errorf("Expected %s to be healed, got %+v", objInfo.Object, objInfo)
+		}
+	}
+	if info, err = obj.HealBucket(bucket, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(info.MissingDisks) != 0 || len(info.Objects) != 0 {
+		t.Errorf("Expected nothing left to heal, got %+v", info)
+	}
+}
@@ -0,0 +1,122 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// Largest inline threshold, `xl.json` is read as a whole by every
+// metadata operation and holds the data base64 encoded.
+const maxInlineThreshold = 128 * 1024 // 128KiB.
+
+// Name of the single part of inline objects.
+const inlinePartName = "object1"
+
+// validateInlineThreshold - verifies the inline threshold is not
+// negative and not above 128KiB.
+func validateInlineThreshold(threshold int64) error {
+	if threshold < 0 || threshold > maxInlineThreshold {
+		return fmt.Errorf("Inline threshold %d has to be between 0 and %d.", threshold, maxInlineThreshold)
+	}
+	return nil
+}
+
+// getInlineThreshold - returns size up to which new objects are
+// inlined, 0 if objects are never inlined.
+func getInlineThreshold() int64 {
+	if serverConfig == nil {
+		return 0
+	}
+	return serverConfig.GetInlineThreshold()
+}
+
+// readInlineData - reads ahead data of objects which may fit the
+// threshold, returns the data read and true if data ended within the
+// threshold. Otherwise the data read has to be prepended to the rest
+// of the stream.
+func readInlineData(data io.Reader, size, threshold int64) ([]byte, bool, error) {
+	if threshold == 0 || size > threshold {
+		return nil, false, nil
+	}
+	// One byte more than expected tells whether data ended.
+	if size >= 0 {
+		threshold = size
+	}
+	buf := make([]byte, threshold+1)
+	n, err := io.ReadFull(data, buf)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return buf[:n], true, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return buf, false, nil
+}
+
+// newInlineEInfos - returns erasure infos and the single part of an
+// inline object, erasure infos of offline disks are invalid.
+func newInlineEInfos(onlineDisks []StorageAPI, eInfos []erasureInfo, data []byte) ([]erasureInfo, []objectPartInfo) {
+	newEInfos := make([]erasureInfo, len(eInfos))
+	for index, disk := range onlineDisks {
+		if disk != nil {
+			newEInfos[index] = eInfos[index]
+		}
+	}
+	md5Sum := md5.Sum(data)
+	parts := []objectPartInfo{{
+		Number: 1,
+		Name:   inlinePartName,
+		ETag:   hex.EncodeToString(md5Sum[:]),
+		Size:   int64(len(data)),
+	}}
+	return newEInfos, parts
+}
+
+// isValidInlineData - returns true if inline data of the disk matches
+// the checksum of its part.
+func (m xlMetaV1) isValidInlineData() bool {
+	if !m.Inline || len(m.Parts) != 1 || int64(len(m.Data)) != m.Parts[0].Size {
+		return false
+	}
+	md5Sum := md5.Sum(m.Data)
+	return hex.EncodeToString(md5Sum[:]) == m.Parts[0].ETag
+}
+
+// pickInlineData - returns inline data of the first online disk whose
+// copy is intact, copies of other disks may be corrupted.
+func pickInlineData(metaArr []xlMetaV1, onlineDisks []StorageAPI) ([]byte, error) {
+	for index, disk := range onlineDisks {
+		if disk != nil && metaArr[index].isValidInlineData() {
+			return metaArr[index].Data, nil
+		}
+	}
+	return nil, errXLReadQuorum
+}
+
+// writeInlineData - writes length bytes of inline data at offset to
+// writer.
+func writeInlineData(writer io.Writer, data []byte, offset, length int64) (int64, error) {
+	if offset < 0 || length < 0 || offset+length > int64(len(data)) {
+		return 0, InvalidRange{}
+	}
+	n, err := writer.Write(data[offset : offset+length])
+	return int64(n), err
+}
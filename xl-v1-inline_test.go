@@ -0,0 +1,191 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"testing"
+)
+
+// Tests objects up to the inline threshold are kept in `xl.json`.
+func TestXLInlineObject(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	serverConfig.SetInlineThreshold(4 * 1024)
+	defer serverConfig.SetInlineThreshold(0)
+
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		size           int64
+		declaredSize   int64
+		expectedInline bool
+	}{
+		// Test case - 1.
+		{0, 0, true},
+		// Test case - 2.
+		{1024, 1024, true},
+		// Test case - 3.
+		// Size unknown until data ends.
+		{1024, -1, true},
+		// Test case - 4.
+		{4 * 1024, 4 * 1024, true},
+		// Test case - 5.
+		{4*1024 + 1, 4*1024 + 1, false},
+		// Test case - 6.
+		{4*1024 + 1, -1, false},
+	}
+	for i, testCase := range testCases {
+		data := make([]byte, testCase.size)
+		for j := range data {
+			data[j] = byte(j % 251)
+		}
+		object := fmt.Sprintf("object%d", i+1)
+		if _, err = obj.PutObject(bucket, object, testCase.declaredSize, bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		xlMeta, err := xl.readXLMetadata(bucket, object)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if xlMeta.Inline != testCase.expectedInline {
+			t.Errorf("Test %d: Expected inline %t, got %t", i+1, testCase.expectedInline, xlMeta.Inline)
+		}
+		if xlMeta.Stat.Size != testCase.size {
+			t.Errorf("Test %d: Expected size %d, got %d", i+1, testCase.size, xlMeta.Stat.Size)
+		}
+		// Inline objects have no shard files.
+		_, err = os.Stat(filepath.Join(fsDirs[0], bucket, object, "object1"))
+		if testCase.expectedInline && !os.IsNotExist(err) {
+			t.Errorf("Test %d: Expected no shard file, got %v", i+1, err)
+		}
+		if !testCase.expectedInline && err != nil {
+			t.Errorf("Test %d: Expected shard file, got %s", i+1, err)
+		}
+		offset, length := testCase.size/3, testCase.size/2
+		var buf bytes.Buffer
+		if err = obj.GetObject(bucket, object, offset, length, &buf); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if !bytes.Equal(buf.Bytes(), data[offset:offset+length]) {
+			t.Errorf("Test %d: Object does not match", i+1)
+		}
+	}
+}
+
+// Tests corrupted copies of inline objects are skipped by reads and
+// healed, patched inline objects are erasure coded.
+func TestXLInlineObjectHeal(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	serverConfig.SetInlineThreshold(4 * 1024)
+	defer serverConfig.SetInlineThreshold(0)
+
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket, object := "bucket", "object"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello, inline world")
+	if _, err = obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Corrupt the copy of the first disk.
+	disk := xl.storageDisks[0]
+	xlMeta, err := readXLMeta(disk, bucket, object)
+	if err != nil {
+		t.Fatal(err)
+	}
+	xlMeta.Data = []byte("hello, corrupt world")
+	if err = replaceXLMetadata(disk, bucket, object, path.Join(tmpMetaPrefix, getUUID()), xlMeta); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = obj.GetObject(bucket, object, 0, int64(len(data)), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Errorf("Expected %q, got %q", data, buf.Bytes())
+	}
+
+	missing, corrupted, err := xl.healObject(bucket, object, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(missing) != 0 || len(corrupted) != 1 || corrupted[0] != 0 {
+		t.Errorf("Expected first disk corrupted, got missing %v, corrupted %v", missing, corrupted)
+	}
+	if xlMeta, err = readXLMeta(disk, bucket, object); err != nil {
+		t.Fatal(err)
+	}
+	if !xlMeta.isValidInlineData() {
+		t.Error("Expected healed copy to be intact")
+	}
+
+	// Patch the object.
+	if _, err = xl.PatchObject(bucket, object, 7, 6, bytes.NewReader([]byte("patchd"))); err != nil {
+		t.Fatal(err)
+	}
+	if xlMeta, err = xl.readXLMetadata(bucket, object); err != nil {
+		t.Fatal(err)
+	}
+	if xlMeta.Inline || len(xlMeta.Data) != 0 {
+		t.Error("Expected patched object to be erasure coded")
+	}
+	buf.Reset()
+	if err = obj.GetObject(bucket, object, 0, int64(len(data)), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if expected := []byte("hello, patchd world"); !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("Expected %q, got %q", expected, buf.Bytes())
+	}
+}
@@ -92,10 +92,19 @@ func (xl xlObjects) listObjects(bucket, prefix, marker, delimiter string, maxKey
 			continue
 		}
 		result.Objects = append(result.Objects, ObjectInfo{
-			Name:    objInfo.Name,
-			ModTime: objInfo.ModTime,
-			Size:    objInfo.Size,
-			IsDir:   false,
+			Name:            objInfo.Name,
+			ModTime:         objInfo.ModTime,
+			Size:            objInfo.Size,
+			IsDir:           false,
+			MD5Sum:          objInfo.MD5Sum,
+			ContentType:     objInfo.ContentType,
+			ContentEncoding: objInfo.ContentEncoding,
+			CacheControl:    objInfo.CacheControl,
+			UserDefined:     objInfo.UserDefined,
+			Tags:            objInfo.Tags,
+			Provenance:      objInfo.Provenance,
+			Retention:       objInfo.Retention,
+			Expires:         objInfo.Expires,
 		})
 	}
 	return result, nil
@@ -158,3 +167,9 @@ func (xl xlObjects) ListObjects(bucket, prefix, marker, delimiter string, maxKey
 	// Return error at the end.
 	return ListObjectsInfo{}, toObjectErr(err, bucket, prefix)
 }
+
+// ListObjectsV2 - lists objects after the continuation token, or
+// after startAfter for the first page.
+func (xl xlObjects) ListObjectsV2(bucket, prefix, continuationToken, delimiter string, maxKeys int, startAfter string) (ListObjectsV2Info, error) {
+	return listObjectsV2(xl.ListObjects, bucket, prefix, continuationToken, delimiter, maxKeys, startAfter)
+}
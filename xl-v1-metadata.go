@@ -51,6 +51,9 @@ type checkSumInfo struct {
 	Name      string `json:"name"`
 	Algorithm string `json:"algorithm"`
 	Hash      string `json:"hash"`
+	// Checksum of each block of the shard, not recorded for objects
+	// written by older versions.
+	Blocks []string `json:"blocks,omitempty"`
 }
 
 // erasureInfo - carries erasure coding related information, block
@@ -63,6 +66,10 @@ type erasureInfo struct {
 	Index        int            `json:"index"`
 	Distribution []int          `json:"distribution"`
 	Checksum     []checkSumInfo `json:"checksum,omitempty"`
+	// Positions of disks, starting at 1 like Index, which were
+	// offline or failed while the object was written and hold no
+	// shards until healed.
+	Missing []int `json:"missing,omitempty"`
 }
 
 // IsValid - tells if the erasure info is sane by validating the data
@@ -71,6 +78,54 @@ func (e erasureInfo) IsValid() bool {
 	return e.DataBlocks != 0 && e.ParityBlocks != 0 && len(e.Distribution) != 0
 }
 
+// shardIndex - returns 0-based index of the shard held by the disk at
+// diskIndex. Disks save their position at the time shards were laid
+// out in Index, so shards are found wherever the disk is mounted
+// later. Fresh erasure info without Index lays out by position.
+func (e erasureInfo) shardIndex(diskIndex int) int {
+	if e.Index > 0 && e.Index <= len(e.Distribution) {
+		diskIndex = e.Index - 1
+	}
+	return e.Distribution[diskIndex] - 1
+}
+
+// shardIndexes - returns index of the shard held by each disk, disks
+// without valid erasure info are laid out by position.
+func shardIndexes(eInfos []erasureInfo) []int {
+	validEInfo := pickValidErasureInfo(eInfos)
+	validEInfo.Index = 0
+	indexes := make([]int, len(eInfos))
+	for index, eInfo := range eInfos {
+		if !eInfo.IsValid() {
+			eInfo = validEInfo
+		}
+		indexes[index] = eInfo.shardIndex(index)
+	}
+	return indexes
+}
+
+// getMissingDisks - returns positions of disks without valid erasure
+// info, starting at 1.
+func getMissingDisks(eInfos []erasureInfo) (missing []int) {
+	for index, eInfo := range eInfos {
+		if !eInfo.IsValid() {
+			missing = append(missing, index+1)
+		}
+	}
+	return missing
+}
+
+// isMissingDisk - returns true if the disk at diskIndex is recorded
+// as missing the shards.
+func (e erasureInfo) isMissingDisk(diskIndex int) bool {
+	for _, position := range e.Missing {
+		if position == diskIndex+1 {
+			return true
+		}
+	}
+	return false
+}
+
 // pickValidErasureInfo - picks one valid erasure info content and returns, from a
 // slice of erasure info content. If no value is found this function panics
 // and dies.
@@ -105,11 +160,15 @@ type xlMetaV1 struct {
 	Meta map[string]string `json:"meta"`
 	// Captures all the individual object `xl.json`.
 	Parts []objectPartInfo `json:"parts,omitempty"`
+	// Data of objects small enough to be kept in `xl.json` instead of
+	// erasure coded, every disk holds a copy of the single part.
+	Inline bool   `json:"inline,omitempty"`
+	Data   []byte `json:"data,omitempty"`
 }
 
 // newXLMetaV1 - initializes new xlMetaV1, adds version, allocates a
-// fresh erasure info.
-func newXLMetaV1(dataBlocks, parityBlocks int) (xlMeta xlMetaV1) {
+// fresh erasure info with shards distributed by the object name.
+func newXLMetaV1(object string, dataBlocks, parityBlocks int) (xlMeta xlMetaV1) {
 	xlMeta = xlMetaV1{}
 	xlMeta.Version = "1"
 	xlMeta.Format = "xl"
@@ -119,7 +178,7 @@ func newXLMetaV1(dataBlocks, parityBlocks int) (xlMeta xlMetaV1) {
 		DataBlocks:   dataBlocks,
 		ParityBlocks: parityBlocks,
 		BlockSize:    blockSizeV1,
-		Distribution: randInts(dataBlocks + parityBlocks),
+		Distribution: hashOrder(object, dataBlocks+parityBlocks),
 	}
 	return xlMeta
 }
@@ -257,6 +316,15 @@ func writeXLMetadata(disk StorageAPI, bucket, prefix string, xlMeta xlMetaV1) er
 	return disk.AppendFile(bucket, jsonFile, metadataBytes)
 }
 
+// replaceXLMetadata - replaces `xl.json` of an object on a disk, the
+// new `xl.json` is written at tempPrefix first.
+func replaceXLMetadata(disk StorageAPI, bucket, object, tempPrefix string, xlMeta xlMetaV1) error {
+	if err := writeXLMetadata(disk, minioMetaBucket, tempPrefix, xlMeta); err != nil {
+		return err
+	}
+	return disk.RenameFile(minioMetaBucket, path.Join(tempPrefix, xlMetaJSONFile), bucket, path.Join(object, xlMetaJSONFile))
+}
+
 // deleteAllXLMetadata - deletes all partially written `xl.json` depending on errs.
 func (xl xlObjects) deleteAllXLMetadata(bucket, prefix string, errs []error) {
 	var wg = &sync.WaitGroup{}
@@ -278,14 +346,16 @@ func (xl xlObjects) deleteAllXLMetadata(bucket, prefix string, errs []error) {
 	wg.Wait()
 }
 
-// writeUniqueXLMetadata - writes unique `xl.json` content for each disk in order.
+// writeUniqueXLMetadata - writes unique `xl.json` content for each disk
+// in order. Disks without valid erasure info failed to write the data
+// and are skipped like offline disks.
 func (xl xlObjects) writeUniqueXLMetadata(bucket, prefix string, xlMetas []xlMetaV1) error {
 	var wg = &sync.WaitGroup{}
 	var mErrs = make([]error, len(xl.storageDisks))
 
 	// Start writing `xl.json` to all disks in parallel.
 	for index, disk := range xl.storageDisks {
-		if disk == nil {
+		if disk == nil || !xlMetas[index].Erasure.IsValid() {
 			mErrs[index] = errDiskNotFound
 			continue
 		}
@@ -294,8 +364,11 @@ func (xl xlObjects) writeUniqueXLMetadata(bucket, prefix string, xlMetas []xlMet
 		go func(index int, disk StorageAPI) {
 			defer wg.Done()
 
-			// Pick one xlMeta for a disk at index.
-			xlMetas[index].Erasure.Index = index + 1
+			// Save the disk order index, unless the shards were laid
+			// out before and the disk already knows its position.
+			if xlMetas[index].Erasure.Index == 0 {
+				xlMetas[index].Erasure.Index = index + 1
+			}
 
 			// Write unique `xl.json` for a disk at index.
 			err := writeXLMetadata(disk, bucket, prefix, xlMetas[index])
@@ -320,6 +393,12 @@ func (xl xlObjects) writeUniqueXLMetadata(bucket, prefix string, xlMetas []xlMet
 		return errXLWriteQuorum
 	}
 
+	// Disks failing the write are healed later once write quorum
+	// disks succeeded.
+	if isWriteSuccess(mErrs, xl.writeQuorum) {
+		return nil
+	}
+
 	// For all other errors return.
 	for _, err := range mErrs {
 		if err != nil && err != errDiskNotFound {
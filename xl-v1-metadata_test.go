@@ -17,6 +17,7 @@
 package main
 
 import (
+	"reflect"
 	"testing"
 )
 
@@ -39,7 +40,7 @@ func TestXLMetaV1(t *testing.T) {
 	}
 
 	// Create a XLMetaV1 structure to test on.
-	meta := newXLMetaV1(8, 8)
+	meta := newXLMetaV1("object", 8, 8)
 
 	// Add 5 parts.
 	for _, test := range testCases {
@@ -84,3 +85,68 @@ func TestXLMetaV1(t *testing.T) {
 		}
 	}
 }
+
+// Tests validate shard distribution is a deterministic permutation.
+func TestHashOrder(t *testing.T) {
+	testCases := []struct {
+		key   string
+		count int
+	}{
+		// Test case - 1.
+		{"object", 16},
+		// Test case - 2.
+		{"prefix/object", 16},
+		// Test case - 3.
+		{"", 4},
+		// Test case - 4.
+		{"object", 1},
+	}
+	for i, testCase := range testCases {
+		order := hashOrder(testCase.key, testCase.count)
+		if len(order) != testCase.count {
+			t.Fatalf("Test %d: Expected %d entries, got %d", i+1, testCase.count, len(order))
+		}
+		seen := make(map[int]bool)
+		for _, shard := range order {
+			if shard < 1 || shard > testCase.count || seen[shard] {
+				t.Errorf("Test %d: Expected a permutation of 1..%d, got %v", i+1, testCase.count, order)
+				break
+			}
+			seen[shard] = true
+		}
+		if again := hashOrder(testCase.key, testCase.count); !reflect.DeepEqual(order, again) {
+			t.Errorf("Test %d: Expected the same order %v, got %v", i+1, order, again)
+		}
+	}
+	if order := hashOrder("object", 0); order != nil {
+		t.Errorf("Expected no order for no disks, got %v", order)
+	}
+}
+
+// Tests validate disks find their shard from their saved position.
+func TestShardIndex(t *testing.T) {
+	eInfo := erasureInfo{DataBlocks: 2, ParityBlocks: 2, Distribution: []int{3, 4, 1, 2}}
+	testCases := []struct {
+		index         int
+		diskIndex     int
+		expectedShard int
+	}{
+		// Test case - 1.
+		// Fresh erasure info is laid out by position.
+		{0, 0, 2},
+		// Test case - 2.
+		{0, 3, 1},
+		// Test case - 3.
+		// Disk laid out at position 1 mounted at position 3.
+		{1, 3, 2},
+		// Test case - 4.
+		// Disk laid out at position 4 mounted at position 1.
+		{4, 0, 1},
+	}
+	for i, testCase := range testCases {
+		eInfo.Index = testCase.index
+		if shard := eInfo.shardIndex(testCase.diskIndex); shard != testCase.expectedShard {
+			t.Errorf("Test %d: Expected shard %d, got %d", i+1, testCase.expectedShard, shard)
+		}
+	}
+}
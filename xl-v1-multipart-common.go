@@ -17,6 +17,8 @@
 package main
 
 import (
+	"crypto/md5"
+	"encoding/hex"
 	"encoding/json"
 	"path"
 	"sort"
@@ -198,6 +200,10 @@ func writeUploadJSON(bucket, object, uploadID string, initiated time.Time, stora
 			uploadsJSON = newUploadsV1("xl")
 		}
 	}
+	// Uploads of an object are always read into memory as a whole.
+	if globalMaxUploadsPerObject > 0 && len(uploadsJSON.Uploads) >= globalMaxUploadsPerObject {
+		return TooManyUploads{Bucket: bucket, Object: object}
+	}
 	// Add a new upload id.
 	uploadsJSON.AddUploadID(uploadID, initiated)
 
@@ -491,3 +497,33 @@ func (xl xlObjects) commitXLMetadata(srcPrefix, dstPrefix string) error {
 	}
 	return nil
 }
+
+// verifyPart - reads the part back through the erasure layer and
+// verifies its content against the part ETag, returns InvalidPart
+// if the part was corrupted after upload.
+func (xl xlObjects) verifyPart(uploadIDPath string, partsMetadata []xlMetaV1, errs []error, part objectPartInfo) error {
+	// List all online disks.
+	onlineDisks, _, err := xl.listOnlineDisks(partsMetadata, errs)
+	if err != nil {
+		return toObjectErr(err, minioMetaBucket, uploadIDPath)
+	}
+
+	// Collect all the previous erasure infos across the disk.
+	var eInfos []erasureInfo
+	for index := range onlineDisks {
+		eInfos = append(eInfos, partsMetadata[index].Erasure)
+	}
+
+	md5Writer := md5.New()
+	if part.Size > 0 {
+		_, err = erasureReadFile(md5Writer, onlineDisks, minioMetaBucket, pathJoin(uploadIDPath, part.Name), part.Name, eInfos, 0, part.Size, part.Size)
+		if err != nil {
+			errorIf(err, "Unable to read part %s of %s.", part.Name, uploadIDPath)
+			return InvalidPart{}
+		}
+	}
+	if hex.EncodeToString(md5Writer.Sum(nil)) != part.ETag {
+		return InvalidPart{}
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"path"
+)
+
+// linkObjectPart - uploads a part by hard linking shard files of the
+// source part covering exactly the range, no data is read. Returns
+// false if no source part covers the range, not all disks of the
+// source are online or its layout differs from the upload. Uploads
+// without other parts take the layout of the part linked.
+func (xl xlObjects) linkObjectPart(srcBucket, srcObject, bucket, object, uploadID string, partID int, startOffset, length int64) (string, bool, error) {
+	partSuffix := fmt.Sprintf("object%d", partID)
+	tmpPartPath := path.Join(tmpMetaPrefix, getUUID())
+
+	// Source is linked before locking the upload, the source and
+	// the upload are never locked together.
+	srcPart, srcEInfos, linked, err := xl.linkSourcePart(srcBucket, srcObject, tmpPartPath, startOffset, length)
+	if err != nil || !linked {
+		return "", false, err
+	}
+
+	uploadIDPath := pathJoin(mpartMetaPrefix, bucket, object, uploadID)
+	nsMutex.Lock(minioMetaBucket, uploadIDPath)
+	defer nsMutex.Unlock(minioMetaBucket, uploadIDPath)
+
+	if !xl.isUploadIDExists(bucket, object, uploadID) {
+		xl.deleteObject(minioMetaBucket, tmpPartPath)
+		return "", false, InvalidUploadID{UploadID: uploadID}
+	}
+
+	// Read metadata associated with the upload from all disks.
+	partsMetadata, errs := xl.readAllXLMetadata(minioMetaBucket, uploadIDPath)
+
+	// List all online disks.
+	onlineDisks, higherVersion, err := xl.listOnlineDisks(partsMetadata, errs)
+	if err != nil {
+		xl.deleteObject(minioMetaBucket, tmpPartPath)
+		return "", false, toObjectErr(err, bucket, object)
+	}
+	if diskCount(onlineDisks) < len(xl.storageDisks) {
+		xl.deleteObject(minioMetaBucket, tmpPartPath)
+		return "", false, nil
+	}
+
+	xlMeta := pickValidXLMeta(partsMetadata)
+	var eInfos []erasureInfo
+	for index := range onlineDisks {
+		eInfos = append(eInfos, partsMetadata[index].Erasure)
+	}
+	var otherParts int
+	for _, part := range xlMeta.Parts {
+		if part.Number != partID {
+			otherParts++
+		}
+	}
+	if otherParts == 0 {
+		eInfos = srcEInfos
+	} else if !sameErasureLayout(srcEInfos, eInfos) {
+		xl.deleteObject(minioMetaBucket, tmpPartPath)
+		return "", false, nil
+	}
+
+	// Linked shards keep their checksums, replacing any checksum of
+	// an earlier upload of the part.
+	newEInfos := make([]erasureInfo, len(eInfos))
+	for index, eInfo := range eInfos {
+		checksum := srcEInfos[index].PartObjectChecksum(srcPart.Name)
+		checksum.Name = partSuffix
+		newEInfos[index] = eInfo
+		newEInfos[index].Checksum = []checkSumInfo{checksum}
+		for _, partChecksum := range eInfo.Checksum {
+			if partChecksum.Name != partSuffix {
+				newEInfos[index].Checksum = append(newEInfos[index].Checksum, partChecksum)
+			}
+		}
+	}
+
+	// Rename linked part to its final location.
+	partPath := path.Join(uploadIDPath, partSuffix)
+	if err = xl.renamePart(minioMetaBucket, tmpPartPath, minioMetaBucket, partPath); err != nil {
+		return "", false, toObjectErr(err, minioMetaBucket, partPath)
+	}
+
+	// Once part is successfully committed, proceed with updating XL metadata.
+	xlMeta.Stat.Version = higherVersion
+	xlMeta.AddObjectPart(partID, partSuffix, srcPart.ETag, srcPart.Size)
+	for index := range partsMetadata {
+		partsMetadata[index].Parts = xlMeta.Parts
+		partsMetadata[index].Erasure = newEInfos[index]
+	}
+	tempUploadIDPath := path.Join(tmpMetaPrefix, uploadID)
+	if err = xl.writeUniqueXLMetadata(minioMetaBucket, tempUploadIDPath, partsMetadata); err != nil {
+		return "", false, toObjectErr(err, minioMetaBucket, tempUploadIDPath)
+	}
+	if err = xl.commitXLMetadata(tempUploadIDPath, uploadIDPath); err != nil {
+		return "", false, toObjectErr(err, minioMetaBucket, uploadIDPath)
+	}
+	return srcPart.ETag, true, nil
+}
+
+// linkSourcePart - hard links shard files of the source part covering
+// exactly the range to tmpPartPath, returns the part and erasure infos
+// of the source. Returns false if the part cannot be linked.
+func (xl xlObjects) linkSourcePart(bucket, object, tmpPartPath string, startOffset, length int64) (objectPartInfo, []erasureInfo, bool, error) {
+	nsMutex.RLock(bucket, object)
+	defer nsMutex.RUnlock(bucket, object)
+
+	if !xl.isObject(bucket, object) {
+		return objectPartInfo{}, nil, false, ObjectNotFound{Bucket: bucket, Object: object}
+	}
+
+	// Read metadata associated with the object from all disks.
+	metaArr, errs := xl.readAllXLMetadata(bucket, object)
+
+	// List all online disks.
+	onlineDisks, highestVersion, err := xl.listOnlineDisks(metaArr, errs)
+	if err != nil {
+		return objectPartInfo{}, nil, false, toObjectErr(err, bucket, object)
+	}
+	if diskCount(onlineDisks) < len(xl.storageDisks) {
+		return objectPartInfo{}, nil, false, nil
+	}
+
+	// Pick latest valid metadata.
+	var srcMeta xlMetaV1
+	for _, meta := range metaArr {
+		if meta.IsValid() && meta.Stat.Version == highestVersion {
+			srcMeta = meta
+			break
+		}
+	}
+	// Inline sources have no shard files.
+	if srcMeta.Inline {
+		return objectPartInfo{}, nil, false, nil
+	}
+
+	// Look for the part starting at the offset.
+	var srcPart objectPartInfo
+	var partOffset int64
+	for _, part := range srcMeta.Parts {
+		if partOffset == startOffset {
+			srcPart = part
+			break
+		}
+		partOffset += part.Size
+	}
+	if srcPart.Name == "" || srcPart.Size != length || length == 0 {
+		return objectPartInfo{}, nil, false, nil
+	}
+
+	var srcEInfos []erasureInfo
+	for index := range onlineDisks {
+		srcEInfos = append(srcEInfos, metaArr[index].Erasure)
+	}
+	err = xl.reduceWriteQuorumErrs(xl.doOnAllDisks(func(disk StorageAPI) error {
+		return disk.LinkFile(bucket, pathJoin(object, srcPart.Name), minioMetaBucket, tmpPartPath)
+	}))
+	if err != nil {
+		xl.deleteObject(minioMetaBucket, tmpPartPath)
+		return objectPartInfo{}, nil, false, toObjectErr(err, bucket, object)
+	}
+	return srcPart, srcEInfos, true, nil
+}
+
+// CopyObjectPart - uploads a part from a range of the source object.
+// Shard files of a source part covering exactly the range are hard
+// linked, see linkObjectPart, other ranges are read from the shards
+// holding them and erasure coded into the part.
+func (xl xlObjects) CopyObjectPart(srcBucket, srcObject, bucket, object, uploadID string, partID int, startOffset, length int64) (string, error) {
+	for _, b := range []string{srcBucket, bucket} {
+		// Verify if bucket is valid.
+		if !IsValidBucketName(b) {
+			return "", BucketNameInvalid{Bucket: b}
+		}
+		// Verify whether the bucket exists.
+		if !xl.isBucketExist(b) {
+			return "", BucketNotFound{Bucket: b}
+		}
+	}
+	if !IsValidObjectName(srcObject) {
+		return "", ObjectNameInvalid{Bucket: srcBucket, Object: srcObject}
+	}
+	if !IsValidObjectName(object) {
+		return "", ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	md5Hex, linked, err := xl.linkObjectPart(srcBucket, srcObject, bucket, object, uploadID, partID, startOffset, length)
+	if err != nil || linked {
+		return md5Hex, err
+	}
+	return copyObjectPartData(xl, srcBucket, srcObject, xl, bucket, object, uploadID, partID, startOffset, length)
+}
@@ -232,11 +232,11 @@ func (xl xlObjects) ListMultipartUploads(bucket, prefix, keyMarker, uploadIDMark
 // request, returns back a unique upload id.
 //
 // Internally this function creates 'uploads.json' associated for the
-// incoming object at '.minio/multipart/bucket/object/uploads.json' on
+// incoming object at '.minio.sys/multipart/bucket/object/uploads.json' on
 // all the disks. `uploads.json` carries metadata regarding on going
 // multipart operation on the object.
 func (xl xlObjects) newMultipartUpload(bucket string, object string, meta map[string]string) (uploadID string, err error) {
-	xlMeta := newXLMetaV1(xl.dataBlocks, xl.parityBlocks)
+	xlMeta := newXLMetaV1(object, xl.dataBlocks, xl.parityBlocks)
 	// If not set default to "application/octet-stream"
 	if meta["content-type"] == "" {
 		contentType := "application/octet-stream"
@@ -252,7 +252,7 @@ func (xl xlObjects) newMultipartUpload(bucket string, object string, meta map[st
 	xlMeta.Stat.Version = 1
 	xlMeta.Meta = meta
 
-	// This lock needs to be held for any changes to the directory contents of ".minio/multipart/object/"
+	// This lock needs to be held for any changes to the directory contents of ".minio.sys/multipart/object/"
 	nsMutex.Lock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, object))
 	defer nsMutex.Unlock(minioMetaBucket, pathJoin(mpartMetaPrefix, bucket, object))
 
@@ -586,7 +586,12 @@ func (xl xlObjects) CompleteMultipartUpload(bucket string, object string, upload
 
 		// All parts should have same ETag as previously generated.
 		if currentXLMeta.Parts[partIdx].ETag != part.ETag {
-			return "", BadDigest{}
+			return "", InvalidPart{}
+		}
+
+		// Part content should still match its ETag.
+		if err = xl.verifyPart(uploadIDPath, partsMetadata, errs, currentXLMeta.Parts[partIdx]); err != nil {
+			return "", err
 		}
 
 		// All parts except the last part has to be atleast 5MB.
@@ -624,6 +629,8 @@ func (xl xlObjects) CompleteMultipartUpload(bucket string, object string, upload
 
 	// Save successfully calculated md5sum.
 	xlMeta.Meta["md5Sum"] = s3MD5
+	stampObjectProvenance(xlMeta.Meta, xlMeta.Stat.ModTime)
+	stampObjectVersion(bucket, xlMeta.Meta)
 	uploadIDPath = path.Join(mpartMetaPrefix, bucket, object, uploadID)
 	tempUploadIDPath := path.Join(tmpMetaPrefix, uploadID)
 
@@ -647,9 +654,17 @@ func (xl xlObjects) CompleteMultipartUpload(bucket string, object string, upload
 	nsMutex.Lock(bucket, object)
 	defer nsMutex.Unlock(bucket, object)
 
+	// Existing objects of protected buckets are never replaced.
+	if isBucketOverwriteProtected(bucket) && xl.isObject(bucket, object) {
+		return "", ObjectAlreadyExists{Bucket: bucket, Object: object}
+	}
+
 	// Rename if an object already exists to temporary location.
 	uniqueID := getUUID()
+	var prevXLMeta xlMetaV1
 	if xl.isObject(bucket, object) {
+		// Save previous metadata to release its dedup reference.
+		prevXLMeta, _ = xl.readXLMetadata(bucket, object)
 		err = xl.renameObject(bucket, object, minioMetaBucket, path.Join(tmpMetaPrefix, uniqueID))
 		if err != nil {
 			return "", toObjectErr(err, bucket, object)
@@ -674,8 +689,8 @@ func (xl xlObjects) CompleteMultipartUpload(bucket string, object string, upload
 		return "", toObjectErr(err, bucket, object)
 	}
 
-	// Delete the previously successfully renamed object.
-	xl.deleteObject(minioMetaBucket, path.Join(tmpMetaPrefix, uniqueID))
+	// Keep or delete the replaced object.
+	xl.retireObject(bucket, object, path.Join(tmpMetaPrefix, uniqueID), prevXLMeta)
 
 	// Hold the lock so that two parallel complete-multipart-uploads do not
 	// leave a stale uploads.json behind.
@@ -712,7 +727,7 @@ func (xl xlObjects) CompleteMultipartUpload(bucket string, object string, upload
 		// Return success.
 		return s3MD5, nil
 	} // No more pending uploads for the object, proceed to delete
-	// object completely from '.minio/multipart'.
+	// object completely from '.minio.sys/multipart'.
 	if err = xl.deleteObject(minioMetaBucket, path.Join(mpartMetaPrefix, bucket, object)); err != nil {
 		return "", toObjectErr(err, minioMetaBucket, path.Join(mpartMetaPrefix, bucket, object))
 	}
@@ -723,7 +738,7 @@ func (xl xlObjects) CompleteMultipartUpload(bucket string, object string, upload
 
 // abortMultipartUpload - wrapper for purging an ongoing multipart
 // transaction, deletes uploadID entry from `uploads.json` and purges
-// the directory at '.minio/multipart/bucket/object/uploadID' holding
+// the directory at '.minio.sys/multipart/bucket/object/uploadID' holding
 // all the upload parts.
 func (xl xlObjects) abortMultipartUpload(bucket, object, uploadID string) (err error) {
 	// Cleanup all uploaded parts.
@@ -763,7 +778,7 @@ func (xl xlObjects) abortMultipartUpload(bucket, object, uploadID string) (err e
 		}
 		return nil
 	} // No more pending uploads for the object, we purge the entire
-	// entry at '.minio/multipart/bucket/object'.
+	// entry at '.minio.sys/multipart/bucket/object'.
 	if err = xl.deleteObject(minioMetaBucket, path.Join(mpartMetaPrefix, bucket, object)); err != nil {
 		return toObjectErr(err, minioMetaBucket, path.Join(mpartMetaPrefix, bucket, object))
 	}
@@ -0,0 +1,45 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+// PutObjectRetention - replaces retention and legal hold of an object
+// by rewriting only its `xl.json`. Retention is not object data, it
+// leaves the modification time untouched.
+func (xl xlObjects) PutObjectRetention(bucket, object string, retention objectRetention) error {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	// Verify if object is valid.
+	if !IsValidObjectName(object) {
+		return ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	// Validate object exists.
+	if !xl.isObject(bucket, object) {
+		return ObjectNotFound{Bucket: bucket, Object: object}
+	}
+	return xl.rewriteXLMetadata(bucket, object, func(xlMeta *xlMetaV1) {
+		if xlMeta.Meta == nil {
+			xlMeta.Meta = make(map[string]string)
+		}
+		setObjectRetention(xlMeta.Meta, retention)
+	})
+}
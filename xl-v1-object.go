@@ -17,8 +17,11 @@
 package main
 
 import (
+	"bytes"
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
 	"io"
 	"path"
 	"path/filepath"
@@ -51,7 +54,12 @@ func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length i
 	// Lock the object before reading.
 	nsMutex.RLock(bucket, object)
 	defer nsMutex.RUnlock(bucket, object)
+	return xl.getObject(bucket, object, startOffset, length, writer)
+}
 
+// getObject - wrapper for reading an object, also reads versions kept
+// in minioMetaBucket. Callers hold the object lock.
+func (xl xlObjects) getObject(bucket, object string, startOffset int64, length int64, writer io.Writer) error {
 	// Read metadata associated with the object from all disks.
 	metaArr, errs := xl.readAllXLMetadata(bucket, object)
 
@@ -70,6 +78,32 @@ func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length i
 		}
 	}
 
+	// Disks missed by the write hold no shards until caught up, the
+	// object is reconstructed from the other disks.
+	if len(xlMeta.Erasure.Missing) > 0 {
+		for index := range onlineDisks {
+			if xlMeta.Erasure.isMissingDisk(index) {
+				onlineDisks[index] = nil
+			}
+		}
+		xl.degraded.read()
+	}
+
+	// Nothing to read for empty objects.
+	if length == 0 {
+		return nil
+	}
+
+	// Inline objects are read from `xl.json`.
+	if xlMeta.Inline {
+		data, err := pickInlineData(metaArr, onlineDisks)
+		if err != nil {
+			return toObjectErr(err, bucket, object)
+		}
+		_, err = writeInlineData(writer, data, startOffset, length)
+		return toObjectErr(err, bucket, object)
+	}
+
 	// Get start part index and offset.
 	partIndex, partOffset, err := xlMeta.ObjectToPartOffset(startOffset)
 	if err != nil {
@@ -107,7 +141,7 @@ func (xl xlObjects) GetObject(bucket, object string, startOffset int64, length i
 		// Start reading the part name.
 		n, err := erasureReadFile(writer, onlineDisks, bucket, pathJoin(object, partName), partName, eInfos, partOffset, readSize, partSize)
 		if err != nil {
-			return err
+			return toObjectErr(err, bucket, object)
 		}
 
 		totalBytesRead += n
@@ -157,6 +191,14 @@ func (xl xlObjects) getObjectInfo(bucket, object string) (objInfo ObjectInfo, er
 		MD5Sum:          xlMeta.Meta["md5Sum"],
 		ContentType:     xlMeta.Meta["content-type"],
 		ContentEncoding: xlMeta.Meta["content-encoding"],
+		CacheControl:    xlMeta.Meta["cache-control"],
+		UserDefined:     getUserMetadata(xlMeta.Meta),
+		Tags:            getObjectTags(xlMeta.Meta),
+		Provenance:      getObjectProvenance(xlMeta.Meta),
+		Retention:       getObjectRetention(xlMeta.Meta),
+		Expires:         getObjectExpiry(xlMeta.Meta),
+		VersionID:       xlMeta.Meta[objectVersionIDKey],
+		DeleteMarker:    xlMeta.Meta[objectDeleteMarkerKey] == "true",
 	}
 	return objInfo, nil
 }
@@ -244,6 +286,11 @@ func (xl xlObjects) rename(srcBucket, srcEntry, dstBucket, dstEntry string, isPa
 		xl.undoRename(srcBucket, srcEntry, dstBucket, dstEntry, isPart, errs)
 		return errXLWriteQuorum
 	}
+	// Disks failing the rename are healed later once write quorum
+	// disks succeeded.
+	if isWriteSuccess(errs, xl.writeQuorum) {
+		return nil
+	}
 	// Return on first error, also undo any partially successful rename operations.
 	for _, err := range errs {
 		if err != nil && err != errDiskNotFound {
@@ -264,6 +311,18 @@ func (xl xlObjects) renamePart(srcBucket, srcObject, dstBucket, dstObject string
 	return xl.rename(srcBucket, srcObject, dstBucket, dstObject, isPart)
 }
 
+// linkPart - hard links a part of the source object to the
+// destination across all disks in parallel, disks missing the part
+// are healed later.
+func (xl xlObjects) linkPart(srcBucket, srcPart, dstBucket, dstPart string) error {
+	return xl.reduceWriteQuorumErrs(xl.doOnAllDisks(func(disk StorageAPI) error {
+		if err := disk.LinkFile(srcBucket, srcPart, dstBucket, dstPart); err != errFileNotFound {
+			return err
+		}
+		return nil
+	}))
+}
+
 // renameObject - renames all source objects to destination object
 // across all disks in parallel. Additionally if we have errors and do
 // not have a readQuorum partially renamed files are renamed back to
@@ -292,19 +351,36 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 			Object: object,
 		}
 	}
+	// Names too long for the disks fail alike on all disks, which do
+	// not count as written.
+	if err := checkPathLength(object); err != nil {
+		return "", toObjectErr(err, bucket, object)
+	}
 	// No metadata is set, allocate a new one.
 	if metadata == nil {
 		metadata = make(map[string]string)
 	}
+	createOnly := takeCreateOnly(metadata)
 	nsMutex.Lock(bucket, object)
 	defer nsMutex.Unlock(bucket, object)
 
+	// Existing objects of protected buckets are never replaced.
+	if isBucketOverwriteProtected(bucket) && xl.isObject(bucket, object) {
+		return "", ObjectAlreadyExists{Bucket: bucket, Object: object}
+	}
+	// Create-only uploads fail if the object exists, checked under
+	// the lock so that concurrent creates succeed only once.
+	if createOnly && xl.isObject(bucket, object) {
+		return "", ObjectPreconditionFailed{Bucket: bucket, Object: object}
+	}
+
 	uniqueID := getUUID()
-	tempErasureObj := path.Join(tmpMetaPrefix, uniqueID, "object1")
 	tempObj := path.Join(tmpMetaPrefix, uniqueID)
 
-	// Initialize xl meta.
-	xlMeta := newXLMetaV1(xl.dataBlocks, xl.parityBlocks)
+	// Initialize xl meta, blocks are sized by the size class of the
+	// object.
+	xlMeta := newXLMetaV1(object, xl.dataBlocks, xl.parityBlocks)
+	xlMeta.Erasure.BlockSize = getObjectBlockSize(size)
 
 	// Read metadata associated with the object from all disks.
 	partsMetadata, errs := xl.readAllXLMetadata(bucket, object)
@@ -315,17 +391,18 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 		return "", toObjectErr(err, bucket, object)
 	}
 
-	// Increment version only if we have online disks less than configured storage disks.
-	if diskCount(onlineDisks) < len(xl.storageDisks) {
-		higherVersion++
-	}
-
-	// Initialize md5 writer.
+	// Initialize md5 writer, dedup also keys the content by its
+	// sha256.
 	md5Writer := md5.New()
+	sha256Writer := sha256.New()
+	hashWriter := io.Writer(md5Writer)
+	if globalDedup {
+		hashWriter = io.MultiWriter(md5Writer, sha256Writer)
+	}
 
 	// Tee reader combines incoming data stream and md5, data read
 	// from input stream is written to md5.
-	teeReader := io.TeeReader(data, md5Writer)
+	teeReader := io.TeeReader(data, hashWriter)
 
 	// Collect all the previous erasure infos across the disk.
 	var eInfos []erasureInfo
@@ -333,10 +410,41 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 		eInfos = append(eInfos, xlMeta.Erasure)
 	}
 
-	// Erasure code and write across all disks.
-	newEInfos, n, err := erasureCreateFile(onlineDisks, minioMetaBucket, tempErasureObj, "object1", teeReader, eInfos, xl.writeQuorum)
+	// Large objects are stored as parts of the configured size.
+	partSize := globalPutPartSize
+	if size != -1 && size <= partSize {
+		partSize = 0
+	}
+
+	// Objects up to the inline threshold are kept in `xl.json` of
+	// every disk, data read ahead is erasure coded otherwise.
+	inlineData, inline, err := readInlineData(teeReader, size, getInlineThreshold())
 	if err != nil {
-		return "", toObjectErr(err, minioMetaBucket, tempErasureObj)
+		return "", toObjectErr(err, bucket, object)
+	}
+	var newEInfos []erasureInfo
+	var parts []objectPartInfo
+	var n int64
+	if inline {
+		newEInfos, parts = newInlineEInfos(onlineDisks, eInfos, inlineData)
+		n = int64(len(inlineData))
+	} else {
+		// Erasure code and write across all disks.
+		newEInfos, parts, n, err = xl.putObjectParts(onlineDisks, tempObj, io.MultiReader(bytes.NewReader(inlineData), teeReader), eInfos, partSize)
+		if err != nil {
+			xl.deleteObject(minioMetaBucket, tempObj)
+			return "", err
+		}
+	}
+
+	// Disks which were offline or failed a write hold no shards, they
+	// are recorded for healing to backfill.
+	missing := getMissingDisks(newEInfos)
+
+	// Increment version only if we have written to less disks than
+	// configured storage disks, so that missing disks are stale.
+	if len(missing) > 0 {
+		higherVersion++
 	}
 	if size == -1 {
 		size = n
@@ -378,7 +486,10 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 
 	// Rename if an object already exists to temporary location.
 	newUniqueID := getUUID()
+	var prevXLMeta xlMetaV1
 	if xl.isObject(bucket, object) {
+		// Save previous metadata to release its dedup reference.
+		prevXLMeta, _ = xl.readXLMetadata(bucket, object)
 		err = xl.renameObject(bucket, object, minioMetaBucket, path.Join(tmpMetaPrefix, newUniqueID))
 		if err != nil {
 			return "", toObjectErr(err, bucket, object)
@@ -386,17 +497,35 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 	}
 
 	// Fill all the necessary metadata.
+	stampObjectProvenance(metadata, modTime)
+	stampObjectVersion(bucket, metadata)
 	xlMeta.Meta = metadata
 	xlMeta.Stat.Size = size
 	xlMeta.Stat.ModTime = modTime
 	xlMeta.Stat.Version = higherVersion
-	// Add the final part.
-	xlMeta.AddObjectPart(1, "object1", newMD5Hex, xlMeta.Stat.Size)
+	xlMeta.Parts = parts
+	xlMeta.Inline = inline
+	xlMeta.Data = inlineData
 
-	// Update `xl.json` content on each disks.
+	// Update `xl.json` content on each disks, missing disks are
+	// skipped.
 	for index := range partsMetadata {
 		partsMetadata[index] = xlMeta
 		partsMetadata[index].Erasure = newEInfos[index]
+		if newEInfos[index].IsValid() {
+			partsMetadata[index].Erasure.Missing = missing
+		}
+	}
+
+	// Dedup only single part objects if all disks are online, the
+	// object is stored as is upon any failure.
+	if globalDedup && !inline && len(parts) == 1 && len(missing) == 0 {
+		err = xl.dedupObject(tempObj, hex.EncodeToString(sha256Writer.Sum(nil)), size, partsMetadata, metadata)
+		if err == errDedupShardsMixed {
+			xl.deleteObject(minioMetaBucket, tempObj)
+			return "", toObjectErr(err, bucket, object)
+		}
+		errorIf(err, "Unable to dedup object %s/%s.", bucket, object)
 	}
 
 	// Write unique `xl.json` for each disk.
@@ -410,13 +539,60 @@ func (xl xlObjects) PutObject(bucket string, object string, size int64, data io.
 		return "", toObjectErr(err, bucket, object)
 	}
 
-	// Delete the temporary object.
-	xl.deleteObject(minioMetaBucket, path.Join(tmpMetaPrefix, newUniqueID))
+	// Keep or delete the replaced object.
+	xl.retireObject(bucket, object, path.Join(tmpMetaPrefix, newUniqueID), prevXLMeta)
+
+	// Missing disks are backfilled by catch-up.
+	if len(missing) > 0 {
+		xl.degraded.add(bucket, object)
+	}
 
 	// Return md5sum, successfully wrote object.
 	return newMD5Hex, nil
 }
 
+// putObjectParts - erasure codes data as parts of partSize bytes at
+// tempObj, the last part holds the remainder. Data is stored as a
+// single part if partSize is 0. Returns erasure infos with checksums
+// of all parts, the parts and the size of data. Erasure infos of disks
+// failing any part are invalid.
+func (xl xlObjects) putObjectParts(onlineDisks []StorageAPI, tempObj string, data io.Reader, eInfos []erasureInfo, partSize int64) ([]erasureInfo, []objectPartInfo, int64, error) {
+	var parts []objectPartInfo
+	var size int64
+	for partNumber := 1; ; partNumber++ {
+		partName := fmt.Sprintf("object%d", partNumber)
+		partPath := path.Join(tempObj, partName)
+		partReader := data
+		if partSize > 0 {
+			partReader = io.LimitReader(data, partSize)
+		}
+		md5Writer := md5.New()
+		newEInfos, n, err := erasureCreateFile(onlineDisks, minioMetaBucket, partPath, partName, io.TeeReader(partReader, md5Writer), eInfos, xl.writeQuorum)
+		if err != nil {
+			return nil, nil, 0, toObjectErr(err, minioMetaBucket, partPath)
+		}
+		// Data ended at a part boundary, the empty part is dropped.
+		if n == 0 && partNumber > 1 {
+			xl.doOnAllDisks(func(disk StorageAPI) error {
+				return disk.DeleteFile(minioMetaBucket, partPath)
+			})
+			break
+		}
+		eInfos = newEInfos
+		parts = append(parts, objectPartInfo{
+			Number: partNumber,
+			Name:   partName,
+			ETag:   hex.EncodeToString(md5Writer.Sum(nil)),
+			Size:   n,
+		})
+		size += n
+		if partSize == 0 || n < partSize {
+			break
+		}
+	}
+	return eInfos, parts, size, nil
+}
+
 // deleteObject - wrapper for delete object, deletes an object from
 // all the disks in parallel, including `xl.json` associated with the
 // object.
@@ -468,17 +644,41 @@ func (xl xlObjects) DeleteObject(bucket, object string) (err error) {
 	nsMutex.Lock(bucket, object)
 	defer nsMutex.Unlock(bucket, object)
 
+	// Versioned buckets keep the object and mark it deleted.
+	if status := getBucketVersioning(bucket); status != "" {
+		return xl.deleteVersionedObject(bucket, object, status)
+	}
+
 	// Validate object exists.
 	if !xl.isObject(bucket, object) {
 		return ObjectNotFound{bucket, object}
 	} // else proceed to delete the object.
 
+	// Save metadata to release its dedup reference.
+	xlMeta, _ := xl.readXLMetadata(bucket, object)
+
 	// Delete the object on all disks.
 	err = xl.deleteObject(bucket, object)
 	if err != nil {
 		return toObjectErr(err, bucket, object)
 	}
 
+	// Release dedup reference of the deleted object.
+	xl.releaseDedupRef(xlMeta)
+
 	// Success.
 	return nil
 }
+
+// DeleteObjects - deletes a batch of objects concurrently, returns the
+// error of each object in the order of the batch.
+func (xl xlObjects) DeleteObjects(bucket string, objects []string) ([]error, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return nil, BucketNameInvalid{Bucket: bucket}
+	}
+	if !xl.isBucketExist(bucket) {
+		return nil, BucketNotFound{Bucket: bucket}
+	}
+	return deleteObjects(xl.DeleteObject, bucket, objects), nil
+}
@@ -20,7 +20,11 @@ import (
 	"bytes"
 	"crypto/md5"
 	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func failDisks(xl xlObjects, n int) (removedDisks []StorageAPI) {
@@ -59,7 +63,7 @@ func TestRenameObjectWriteQuorum(t *testing.T) {
 	// Simulate failure of disks
 	xl := objLayer.(xlObjects)
 	removedDisks := failDisks(xl, 8)
-	if err = xl.renameObject("bucket1", "obj1", ".minio", "obj1"); err != errXLWriteQuorum {
+	if err = xl.renameObject("bucket1", "obj1", minioMetaBucket, "obj1"); err != errXLWriteQuorum {
 		t.Fatal(err)
 	}
 
@@ -67,12 +71,12 @@ func TestRenameObjectWriteQuorum(t *testing.T) {
 	xl.storageDisks = append(xl.storageDisks[:4], removedDisks...)
 
 	// With all disks back online, renameObject should succeed.
-	if err = xl.renameObject("bucket1", "obj1", ".minio", "obj1"); err != nil {
+	if err = xl.renameObject("bucket1", "obj1", minioMetaBucket, "obj1"); err != nil {
 		t.Fatal(err)
 	}
 
 	// ... so should renaming back (succeed).
-	if err = xl.renameObject(".minio", "obj1", "bucket1", "obj1"); err != nil {
+	if err = xl.renameObject(minioMetaBucket, "obj1", "bucket1", "obj1"); err != nil {
 		t.Fatal(err)
 	}
 }
@@ -114,3 +118,500 @@ func TestRepeatPutObjectPart(t *testing.T) {
 	}
 
 }
+
+// Tests validate objects are read back after disks are mounted in a
+// different order, including uploads in progress.
+func TestReorderedDisks(t *testing.T) {
+	objLayer, disks, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(disks)
+
+	if err = objLayer.MakeBucket("bucket"); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello, reordered disks")
+	if _, err = objLayer.PutObject("bucket", "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+	uploadID, err := objLayer.NewMultipartUpload("bucket", "multipart", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Mount disks in reverse order.
+	xl := objLayer.(xlObjects)
+	reversedDisks := make([]StorageAPI, len(xl.storageDisks))
+	for i, disk := range xl.storageDisks {
+		reversedDisks[len(reversedDisks)-1-i] = disk
+	}
+	xl.storageDisks = reversedDisks
+
+	md5Sum := md5.Sum(data)
+	md5Hex := hex.EncodeToString(md5Sum[:])
+	if _, err = xl.PutObjectPart("bucket", "multipart", uploadID, 1, int64(len(data)), bytes.NewReader(data), md5Hex); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = xl.CompleteMultipartUpload("bucket", "multipart", uploadID, []completePart{{PartNumber: 1, ETag: md5Hex}}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, object := range []string{"object", "multipart"} {
+		var buffer bytes.Buffer
+		if err = xl.GetObject("bucket", object, 0, int64(len(data)), &buffer); err != nil {
+			t.Fatalf("%s: %s", object, err)
+		}
+		if !bytes.Equal(buffer.Bytes(), data) {
+			t.Errorf("%s: Expected %q, got %q", object, data, buffer.Bytes())
+		}
+	}
+}
+
+// Tests large objects are stored as parts of the configured size.
+func TestXLPutObjectParts(t *testing.T) {
+	partSize := int64(1024 * 1024)
+	globalPutPartSize = partSize
+	defer func() {
+		globalPutPartSize = 0
+	}()
+
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatalf("Unable to initialize XL object layer. %s", err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "part-bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatalf("Unable to make bucket. %s", err)
+	}
+
+	testCases := []struct {
+		dataSize      int64
+		sizeKnown     bool
+		expectedParts []int64
+	}{
+		// Test case - 1.
+		{0, true, []int64{0}},
+		// Test case - 2.
+		{partSize - 1, true, []int64{partSize - 1}},
+		// Test case - 3.
+		{partSize, true, []int64{partSize}},
+		// Test case - 4.
+		{partSize*2 + partSize/2, true, []int64{partSize, partSize, partSize / 2}},
+		// Test case - 5.
+		// Unknown size ending at a part boundary.
+		{partSize * 2, false, []int64{partSize, partSize}},
+		// Test case - 6.
+		{partSize / 2, false, []int64{partSize / 2}},
+	}
+	for i, testCase := range testCases {
+		data := bytes.Repeat([]byte{byte('a' + i)}, int(testCase.dataSize))
+		size := int64(-1)
+		if testCase.sizeKnown {
+			size = testCase.dataSize
+		}
+		md5Hex, err := obj.PutObject(bucket, "object", size, bytes.NewReader(data), nil)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to put object. %s", i+1, err)
+		}
+		md5Sum := md5.Sum(data)
+		if expectedMD5Hex := hex.EncodeToString(md5Sum[:]); md5Hex != expectedMD5Hex {
+			t.Errorf("Test %d: Expected md5 %s, got %s", i+1, expectedMD5Hex, md5Hex)
+		}
+		xlMeta, err := xl.readXLMetadata(bucket, "object")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(xlMeta.Parts) != len(testCase.expectedParts) {
+			t.Fatalf("Test %d: Expected %d parts, got %d", i+1, len(testCase.expectedParts), len(xlMeta.Parts))
+		}
+		for j, part := range xlMeta.Parts {
+			if part.Size != testCase.expectedParts[j] {
+				t.Errorf("Test %d: Expected part %d of size %d, got %d", i+1, j+1, testCase.expectedParts[j], part.Size)
+			}
+		}
+		if xlMeta.Stat.Size != testCase.dataSize {
+			t.Errorf("Test %d: Expected size %d, got %d", i+1, testCase.dataSize, xlMeta.Stat.Size)
+		}
+
+		// Range reads across part boundaries.
+		if testCase.dataSize == 0 {
+			continue
+		}
+		offset := testCase.dataSize / 3
+		var buffer bytes.Buffer
+		if err = obj.GetObject(bucket, "object", offset, testCase.dataSize-offset, &buffer); err != nil {
+			t.Fatalf("Test %d: Unable to get object. %s", i+1, err)
+		}
+		if !bytes.Equal(buffer.Bytes(), data[offset:]) {
+			t.Errorf("Test %d: Object content mismatch.", i+1)
+		}
+	}
+}
+
+// Tests corrupted blocks are detected while reading and reconstructed
+// from the other disks.
+func TestXLGetObjectBitrot(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	// Two blocks, the second one is corrupted.
+	data := make([]byte, blockSizeV1+blockSizeV1/2)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	if _, err = obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+	xlMeta, err := xl.readXLMetadata(bucket, "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if blocks := xlMeta.Erasure.Checksum[0].Blocks; len(blocks) != 2 {
+		t.Fatalf("Expected checksums of 2 blocks, got %d", len(blocks))
+	}
+	chunkSize := getEncodedBlockLen(blockSizeV1, xl.dataBlocks)
+
+	// corruptShards - flips a byte of the second block of the first n shards.
+	corruptShards := func(n int) {
+		for index, disk := range xl.storageDisks {
+			if xlMeta.Erasure.Distribution[index] > n {
+				continue
+			}
+			partPath := filepath.Join(getDiskName(disk, index), bucket, "object", xlMeta.Parts[0].Name)
+			f, oErr := os.OpenFile(partPath, os.O_RDWR, 0)
+			if oErr != nil {
+				t.Fatal(oErr)
+			}
+			if _, oErr = f.WriteAt([]byte{0xff}, chunkSize+1); oErr != nil {
+				t.Fatal(oErr)
+			}
+			f.Close()
+		}
+	}
+
+	// A data shard is reconstructed from parity.
+	corruptShards(1)
+	testCases := []struct {
+		offset int64
+		length int64
+	}{
+		// Test case - 1.
+		{0, int64(len(data))},
+		// Test case - 2.
+		// Corrupted block only.
+		{blockSizeV1, int64(len(data)) - blockSizeV1},
+	}
+	for i, testCase := range testCases {
+		var buf bytes.Buffer
+		if err = obj.GetObject(bucket, "object", testCase.offset, testCase.length, &buf); err != nil {
+			t.Fatalf("Test %d: Unable to read corrupted object. %s", i+1, err)
+		}
+		if !bytes.Equal(buf.Bytes(), data[testCase.offset:testCase.offset+testCase.length]) {
+			t.Errorf("Test %d: Corrupted block was not reconstructed", i+1)
+		}
+	}
+
+	// Without enough valid blocks reads fail instead of returning
+	// corrupted data.
+	corruptShards(xl.parityBlocks)
+	var buf bytes.Buffer
+	if err = obj.GetObject(bucket, "object", 0, int64(len(data)), &buf); err == nil {
+		t.Fatal("Expected read of corrupted object to fail")
+	}
+}
+
+// Tests new writes use the configured bitrot algorithm, objects written
+// with other algorithms remain readable.
+func TestXLBitrotAlgorithm(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	defer serverConfig.SetBitrotAlgorithm("")
+
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("a"), blockSizeV1+10)
+	algorithms := []string{bitrotAlgorithmSHA256, bitrotAlgorithmCRC32C, ""}
+	for i, algo := range algorithms {
+		serverConfig.SetBitrotAlgorithm(algo)
+		object := fmt.Sprintf("object-%d", i)
+		if _, err = obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("Test %d: Unable to create object. %s", i+1, err)
+		}
+		xlMeta, err := xl.readXLMetadata(bucket, object)
+		if err != nil {
+			t.Fatalf("Test %d: Unable to read metadata. %s", i+1, err)
+		}
+		if expected := serverConfig.GetBitrotAlgorithm(); xlMeta.Erasure.Checksum[0].Algorithm != expected {
+			t.Errorf("Test %d: Expected algorithm %s, got %s", i+1, expected, xlMeta.Erasure.Checksum[0].Algorithm)
+		}
+	}
+
+	// Objects are verified by their own algorithm.
+	serverConfig.SetBitrotAlgorithm(bitrotAlgorithmBlake2b)
+	for i := range algorithms {
+		var buf bytes.Buffer
+		if err = obj.GetObject(bucket, fmt.Sprintf("object-%d", i), 0, int64(len(data)), &buf); err != nil {
+			t.Fatalf("Test %d: Unable to read object. %s", i+1, err)
+		}
+		if !bytes.Equal(buf.Bytes(), data) {
+			t.Errorf("Test %d: Object does not match", i+1)
+		}
+	}
+}
+
+// slowReadDisk - delays reads of shards.
+type slowReadDisk struct {
+	StorageAPI
+	delay time.Duration
+}
+
+func (d slowReadDisk) ReadFile(volume string, path string, offset int64, buf []byte) (int64, error) {
+	time.Sleep(d.delay)
+	return d.StorageAPI.ReadFile(volume, path, offset, buf)
+}
+
+// faultyReadDisk - fails reads of shards.
+type faultyReadDisk struct {
+	StorageAPI
+}
+
+func (d faultyReadDisk) ReadFile(volume string, path string, offset int64, buf []byte) (int64, error) {
+	return 0, errDiskNotFound
+}
+
+// faultyWriteDisk - fails writes of shards.
+type faultyWriteDisk struct {
+	StorageAPI
+}
+
+func (d faultyWriteDisk) AppendFile(volume string, path string, buf []byte) error {
+	return errDiskNotFound
+}
+
+// Tests blocks read concurrently are written in order, with a slow
+// and a failed disk.
+func TestXLGetObjectReadConcurrency(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, 3*blockSizeV1+blockSizeV1/2)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	if _, err = obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+	xl.storageDisks[0] = slowReadDisk{xl.storageDisks[0], time.Millisecond}
+	xl.storageDisks[1] = faultyReadDisk{xl.storageDisks[1]}
+
+	defer func(concurrency int) {
+		globalErasureReadConcurrency = concurrency
+	}(globalErasureReadConcurrency)
+
+	testCases := []struct {
+		concurrency int
+		offset      int64
+		length      int64
+	}{
+		// Test case - 1.
+		{1, 0, int64(len(data))},
+		// Test case - 2.
+		{2, 0, int64(len(data))},
+		// Test case - 3.
+		{8, 0, int64(len(data))},
+		// Test case - 4.
+		// Unset concurrency reads one block at a time.
+		{0, 0, int64(len(data))},
+		// Test case - 5.
+		// Range across blocks.
+		{4, blockSizeV1 / 2, 2 * blockSizeV1},
+		// Test case - 6.
+		// Range within the last block.
+		{4, 3*blockSizeV1 + 1, blockSizeV1/2 - 1},
+		// Test case - 7.
+		{4, 0, 0},
+	}
+	for i, testCase := range testCases {
+		globalErasureReadConcurrency = testCase.concurrency
+		var buf bytes.Buffer
+		if err = obj.GetObject(bucket, "object", testCase.offset, testCase.length, &buf); err != nil {
+			t.Fatalf("Test %d: Unable to read object. %s", i+1, err)
+		}
+		if !bytes.Equal(buf.Bytes(), data[testCase.offset:testCase.offset+testCase.length]) {
+			t.Errorf("Test %d: Object does not match", i+1)
+		}
+	}
+}
+
+// Tests objects are written with disks offline or failing writes,
+// missing disks are recorded in `xl.json` until healed.
+func TestXLPutObjectDegraded(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("a"), 1024*1024)
+	disks := append([]StorageAPI(nil), xl.storageDisks...)
+	xl.storageDisks[0] = nil
+	xl.storageDisks[1] = faultyWriteDisk{disks[1]}
+	if _, err = obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("Unable to write object with disks offline. %s", err)
+	}
+	var buf bytes.Buffer
+	if err = obj.GetObject(bucket, "object", 0, int64(len(data)), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Error("Object does not match")
+	}
+	copy(xl.storageDisks, disks)
+
+	xlMeta, err := readXLMeta(disks[2], bucket, "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(xlMeta.Erasure.Missing) != "[1 2]" {
+		t.Errorf("Expected missing disks [1 2], got %v", xlMeta.Erasure.Missing)
+	}
+	// Disk failing the write holds no `xl.json`.
+	if _, err = readXLMeta(disks[1], bucket, "object"); err != errFileNotFound {
+		t.Errorf("Expected %s, got %v", errFileNotFound, err)
+	}
+
+	info, err := obj.HealObject(bucket, "object", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.MissingDisks) != 2 || !info.Healed {
+		t.Errorf("Expected 2 missing disks healed, got %v", info)
+	}
+	for index, disk := range disks {
+		xlMeta, err = readXLMeta(disk, bucket, "object")
+		if err != nil {
+			t.Fatalf("Disk %d: %s", index+1, err)
+		}
+		if len(xlMeta.Erasure.Missing) != 0 {
+			t.Errorf("Disk %d: Expected no missing disks, got %v", index+1, xlMeta.Erasure.Missing)
+		}
+	}
+
+	// Writes fail once less than write quorum disks are left.
+	for i := 0; i <= len(disks)-xl.writeQuorum; i++ {
+		xl.storageDisks[i] = faultyWriteDisk{disks[i]}
+	}
+	_, err = obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil)
+	if _, ok := err.(InsufficientWriteQuorum); !ok {
+		t.Errorf("Expected InsufficientWriteQuorum, got %v", err)
+	}
+}
+
+// Tests objects are written with the block size of their size class
+// and read back.
+func TestXLObjectBlockSize(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	serverConfig.SetBlockSizeClasses([]blockSizeClass{{1024 * 1024, minBlockSize}, {4 * 1024 * 1024, 1024 * 1024}})
+	defer serverConfig.SetBlockSizeClasses(nil)
+
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		size              int64
+		expectedBlockSize int64
+	}{
+		// Test case - 1.
+		{100 * 1024, minBlockSize},
+		// Test case - 2.
+		{1024 * 1024, minBlockSize},
+		// Test case - 3.
+		{2*1024*1024 + 1, 1024 * 1024},
+		// Test case - 4.
+		// Larger than all classes.
+		{5 * 1024 * 1024, blockSizeV1},
+	}
+	for i, testCase := range testCases {
+		data := make([]byte, testCase.size)
+		for j := range data {
+			data[j] = byte(j % 251)
+		}
+		object := fmt.Sprintf("object%d", i+1)
+		if _, err = obj.PutObject(bucket, object, testCase.size, bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		xlMeta, err := xl.readXLMetadata(bucket, object)
+		if err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if xlMeta.Erasure.BlockSize != testCase.expectedBlockSize {
+			t.Errorf("Test %d: Expected block size %d, got %d", i+1, testCase.expectedBlockSize, xlMeta.Erasure.BlockSize)
+		}
+		// Range across blocks.
+		offset, length := testCase.size/3, testCase.size/2
+		var buf bytes.Buffer
+		if err = obj.GetObject(bucket, object, offset, length, &buf); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if !bytes.Equal(buf.Bytes(), data[offset:offset+length]) {
+			t.Errorf("Test %d: Object does not match", i+1)
+		}
+	}
+}
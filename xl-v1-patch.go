@@ -0,0 +1,299 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+	"path"
+	"time"
+)
+
+// Patching rewrites whole parts, bitrot checksums in `xl.json` are
+// kept per shard file of a part so a part is the smallest unit whose
+// shards can be replaced.
+
+// checkPatchEOF - verifies data carries no more than the patch,
+// surfacing errors of the data stream such as signature mismatch.
+func checkPatchEOF(data io.Reader) error {
+	var buf [1]byte
+	n, err := io.ReadFull(data, buf[:])
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return IncompleteBody{}
+	}
+	return nil
+}
+
+// withoutPartChecksum - returns erasure infos without the checksum of
+// partName, checksum slices are copied.
+func withoutPartChecksum(eInfos []erasureInfo, partName string) []erasureInfo {
+	newEInfos := make([]erasureInfo, len(eInfos))
+	for index, eInfo := range eInfos {
+		newEInfos[index] = eInfo
+		newEInfos[index].Checksum = nil
+		for _, checksum := range eInfo.Checksum {
+			if checksum.Name != partName {
+				newEInfos[index].Checksum = append(newEInfos[index].Checksum, checksum)
+			}
+		}
+	}
+	return newEInfos
+}
+
+// PatchObject - replaces size bytes of an object at offset with data,
+// the object grows if the patch extends past its end. Only parts
+// overlapping the patch are read and erasure coded again, shards of
+// other parts are kept as is.
+func (xl xlObjects) PatchObject(bucket, object string, offset, size int64, data io.Reader) (string, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return "", BucketNameInvalid{Bucket: bucket}
+	}
+	// Verify if object is valid.
+	if !IsValidObjectName(object) {
+		return "", ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	// Existing objects of protected buckets are never modified.
+	if isBucketOverwriteProtected(bucket) && xl.isObject(bucket, object) {
+		return "", ObjectAlreadyExists{Bucket: bucket, Object: object}
+	}
+	if !xl.isObject(bucket, object) {
+		return "", ObjectNotFound{Bucket: bucket, Object: object}
+	}
+
+	// Read metadata associated with the object from all disks.
+	metaArr, errs := xl.readAllXLMetadata(bucket, object)
+
+	// List all online disks.
+	onlineDisks, higherVersion, err := xl.listOnlineDisks(metaArr, errs)
+	if err != nil {
+		return "", toObjectErr(err, bucket, object)
+	}
+
+	// Pick latest valid metadata.
+	var xlMeta xlMetaV1
+	for _, meta := range metaArr {
+		if meta.IsValid() && meta.Stat.Version == higherVersion {
+			xlMeta = meta
+			break
+		}
+	}
+	if offset < 0 || offset > xlMeta.Stat.Size {
+		return "", InvalidRange{}
+	}
+	if size == 0 {
+		return xlMeta.Meta["md5Sum"], checkPatchEOF(data)
+	}
+
+	// Increment version only if we have online disks less than configured storage disks.
+	if diskCount(onlineDisks) < len(xl.storageDisks) {
+		higherVersion++
+	}
+
+	// Collect all the previous erasure infos across the disk.
+	var eInfos []erasureInfo
+	for index := range onlineDisks {
+		eInfos = append(eInfos, metaArr[index].Erasure)
+	}
+
+	// Patched inline objects are erasure coded.
+	var inlineData []byte
+	if xlMeta.Inline {
+		if inlineData, err = pickInlineData(metaArr, onlineDisks); err != nil {
+			return "", toObjectErr(err, bucket, object)
+		}
+	}
+	readPart := func(writer io.Writer, part objectPartInfo, partOffset, length int64) error {
+		if xlMeta.Inline {
+			_, rErr := writeInlineData(writer, inlineData, partOffset, length)
+			return rErr
+		}
+		_, rErr := erasureReadFile(writer, onlineDisks, bucket, pathJoin(object, part.Name), part.Name, eInfos, partOffset, length, part.Size)
+		return rErr
+	}
+
+	tempObj := path.Join(tmpMetaPrefix, getUUID())
+	patchEnd := offset + size
+	newEInfos := eInfos
+	newParts := make([]objectPartInfo, len(xlMeta.Parts))
+	copy(newParts, xlMeta.Parts)
+	// Parts not overlapping the patch stay in the object.
+	var keptParts []string
+
+	partStart := int64(0)
+	for index, part := range xlMeta.Parts {
+		partEnd := partStart + part.Size
+		isLastPart := index == len(xlMeta.Parts)-1
+		// Only the last part grows when the patch extends the object.
+		if !(offset < partEnd && patchEnd > partStart) && !(isLastPart && patchEnd > partEnd) {
+			keptParts = append(keptParts, part.Name)
+			partStart = partEnd
+			continue
+		}
+
+		// Patch range relative to the part.
+		patchOffset := offset - partStart
+		if patchOffset < 0 {
+			patchOffset = 0
+		}
+		patchLength := patchEnd - partStart - patchOffset
+		if !isLastPart && patchOffset+patchLength > part.Size {
+			patchLength = part.Size - patchOffset
+		}
+
+		// Part is read back with the patch applied while it is
+		// erasure coded again.
+		pipeReader, pipeWriter := io.Pipe()
+		go func(part objectPartInfo) {
+			var wErr error
+			if patchOffset > 0 {
+				wErr = readPart(pipeWriter, part, 0, patchOffset)
+			}
+			if wErr == nil {
+				if _, wErr = io.CopyN(pipeWriter, data, patchLength); wErr == io.EOF {
+					wErr = IncompleteBody{}
+				}
+			}
+			if tailOffset := patchOffset + patchLength; wErr == nil && tailOffset < part.Size {
+				wErr = readPart(pipeWriter, part, tailOffset, part.Size-tailOffset)
+			}
+			pipeWriter.CloseWithError(wErr)
+		}(part)
+
+		md5Writer := md5.New()
+		var n int64
+		newEInfos, n, err = erasureCreateFile(onlineDisks, minioMetaBucket, pathJoin(tempObj, part.Name), part.Name, io.TeeReader(pipeReader, md5Writer), withoutPartChecksum(newEInfos, part.Name), xl.writeQuorum)
+		pipeReader.Close()
+		if err != nil {
+			xl.deleteObject(minioMetaBucket, tempObj)
+			return "", toObjectErr(err, bucket, object)
+		}
+		newParts[index].ETag = hex.EncodeToString(md5Writer.Sum(nil))
+		newParts[index].Size = n
+		partStart = partEnd
+	}
+
+	// Object is committed only once all of data is consumed.
+	if err = checkPatchEOF(data); err != nil {
+		xl.deleteObject(minioMetaBucket, tempObj)
+		return "", toObjectErr(err, bucket, object)
+	}
+
+	// Move parts not patched into the temporary object, these are
+	// moved back on any failure. Versioned buckets keep the object
+	// as is, its parts are linked instead.
+	var movedParts []string
+	undoMoveParts := func() {
+		for _, partName := range movedParts {
+			xl.renamePart(minioMetaBucket, pathJoin(tempObj, partName), bucket, pathJoin(object, partName))
+		}
+		xl.deleteObject(minioMetaBucket, tempObj)
+	}
+	keepObject := getBucketVersioning(bucket) != ""
+	for _, partName := range keptParts {
+		if keepObject {
+			if err = xl.linkPart(bucket, pathJoin(object, partName), minioMetaBucket, pathJoin(tempObj, partName)); err != nil {
+				undoMoveParts()
+				return "", toObjectErr(err, bucket, object)
+			}
+			continue
+		}
+		if err = xl.renamePart(bucket, pathJoin(object, partName), minioMetaBucket, pathJoin(tempObj, partName)); err != nil {
+			undoMoveParts()
+			return "", toObjectErr(err, bucket, object)
+		}
+		movedParts = append(movedParts, partName)
+	}
+
+	// Patched object keeps metadata of the object, its shards are no
+	// longer those of any dedup entry. Patches are writes of clients,
+	// provenance is stamped anew.
+	metadata := make(map[string]string)
+	for key, value := range xlMeta.Meta {
+		metadata[key] = value
+	}
+	delete(metadata, dedupSumKey)
+	setObjectProvenance(metadata, objectProvenance{})
+	delete(metadata, dedupRefKey)
+
+	var objectSize int64
+	var completeParts []completePart
+	for _, part := range newParts {
+		objectSize += part.Size
+		completeParts = append(completeParts, completePart{PartNumber: part.Number, ETag: part.ETag})
+	}
+	md5Hex := newParts[0].ETag
+	if len(newParts) > 1 {
+		if md5Hex, err = completeMultipartMD5(completeParts...); err != nil {
+			undoMoveParts()
+			return "", toObjectErr(err, bucket, object)
+		}
+	}
+	metadata["md5Sum"] = md5Hex
+
+	modTime := time.Now().UTC()
+	stampObjectProvenance(metadata, modTime)
+	stampObjectVersion(bucket, metadata)
+	newXLMeta := xlMeta
+	newXLMeta.Meta = metadata
+	newXLMeta.Parts = newParts
+	newXLMeta.Inline = false
+	newXLMeta.Data = nil
+	newXLMeta.Stat.Size = objectSize
+	newXLMeta.Stat.ModTime = modTime
+	newXLMeta.Stat.Version = higherVersion
+
+	// Update `xl.json` content on each disks.
+	partsMetadata := make([]xlMetaV1, len(xl.storageDisks))
+	for index := range partsMetadata {
+		partsMetadata[index] = newXLMeta
+		partsMetadata[index].Erasure = newEInfos[index]
+	}
+
+	// Write unique `xl.json` for each disk.
+	if err = xl.writeUniqueXLMetadata(minioMetaBucket, tempObj, partsMetadata); err != nil {
+		undoMoveParts()
+		return "", toObjectErr(err, bucket, object)
+	}
+
+	// Replace the object with the patched object.
+	trashObj := path.Join(tmpMetaPrefix, getUUID())
+	if err = xl.renameObject(bucket, object, minioMetaBucket, trashObj); err != nil {
+		undoMoveParts()
+		return "", toObjectErr(err, bucket, object)
+	}
+	if err = xl.renameObject(minioMetaBucket, tempObj, bucket, object); err != nil {
+		xl.renameObject(minioMetaBucket, trashObj, bucket, object)
+		undoMoveParts()
+		return "", toObjectErr(err, bucket, object)
+	}
+
+	// Keep or delete the replaced object.
+	xl.retireObject(bucket, object, trashObj, xlMeta)
+
+	return md5Hex, nil
+}
@@ -0,0 +1,443 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// diskScrubStatus - progress of the current or last scrub of a disk.
+type diskScrubStatus struct {
+	Disk      string `json:"disk"`
+	Scrubbing bool   `json:"scrubbing"`
+	// Objects verified, missing or corrupted shards found and shards
+	// healed since the scrub started.
+	ObjectsScanned int64 `json:"objectsScanned"`
+	ShardsDamaged  int64 `json:"shardsDamaged"`
+	ShardsHealed   int64 `json:"shardsHealed"`
+	// Start and end of the last scrub, end is zero until the first
+	// scrub completes.
+	LastStarted  time.Time `json:"lastStarted"`
+	LastFinished time.Time `json:"lastFinished"`
+}
+
+// scrubMonitor - keeps scrub status of all disks.
+type scrubMonitor struct {
+	mutex *sync.Mutex
+	disks map[string]*diskScrubStatus
+}
+
+// Scrub status of disks of all XL object layers.
+var globalScrubStatus = &scrubMonitor{
+	mutex: &sync.Mutex{},
+	disks: make(map[string]*diskScrubStatus),
+}
+
+// start - resets progress of disks at the start of a scrub.
+func (m *scrubMonitor) start(disks []string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, disk := range disks {
+		status, ok := m.disks[disk]
+		if !ok {
+			status = &diskScrubStatus{Disk: disk}
+			m.disks[disk] = status
+		}
+		status.Scrubbing = true
+		status.ObjectsScanned = 0
+		status.ShardsDamaged = 0
+		status.ShardsHealed = 0
+		status.LastStarted = time.Now().UTC()
+	}
+}
+
+// update - records a scrubbed object, damaged disks had missing or
+// corrupted shards which were healed unless healing failed.
+func (m *scrubMonitor) update(disks []string, damaged []int, healed bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, disk := range disks {
+		m.disks[disk].ObjectsScanned++
+	}
+	for _, index := range damaged {
+		m.disks[disks[index]].ShardsDamaged++
+		if healed {
+			m.disks[disks[index]].ShardsHealed++
+		}
+	}
+}
+
+// finish - marks the scrub of disks as completed.
+func (m *scrubMonitor) finish(disks []string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for _, disk := range disks {
+		m.disks[disk].Scrubbing = false
+		m.disks[disk].LastFinished = time.Now().UTC()
+	}
+}
+
+// GetStatus - returns scrub status of all disks, sorted by disk.
+func (m *scrubMonitor) GetStatus() []diskScrubStatus {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	statuses := make([]diskScrubStatus, 0, len(m.disks))
+	for _, status := range m.disks {
+		statuses = append(statuses, *status)
+	}
+	sort.Sort(byScrubDisk(statuses))
+	return statuses
+}
+
+// byScrubDisk is a collection satisfying sort.Interface.
+type byScrubDisk []diskScrubStatus
+
+func (d byScrubDisk) Len() int           { return len(d) }
+func (d byScrubDisk) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+func (d byScrubDisk) Less(i, j int) bool { return d[i].Disk < d[j].Disk }
+
+// getDiskName - returns export path of a disk, position of the disk if
+// unknown.
+func getDiskName(disk StorageAPI, index int) string {
+	switch d := disk.(type) {
+	case *posix:
+		return d.diskPath
+	case *networkStorage:
+		return d.netAddr + ":" + d.netPath
+	case *xlDisk:
+		if path := d.getPath(); path != "" {
+			return path
+		}
+	case *statsStorage:
+		return getDiskName(d.storage, index)
+	}
+	return fmt.Sprintf("disk%d", index+1)
+}
+
+// shardsStatus - state of the shards of an object on a disk.
+type shardsStatus int
+
+const (
+	shardsValid shardsStatus = iota
+	shardsMissing
+	shardsCorrupted
+)
+
+// getShardsStatus - verifies shards of all parts of an object on a disk
+// against their checksums, disks without valid metadata are corrupted.
+// Inline objects are verified against the checksum of their part.
+func getShardsStatus(disk StorageAPI, bucket, object string, xlMeta xlMetaV1) shardsStatus {
+	if !xlMeta.IsValid() {
+		return shardsCorrupted
+	}
+	if xlMeta.Inline {
+		if !xlMeta.isValidInlineData() {
+			return shardsCorrupted
+		}
+		return shardsValid
+	}
+	for _, part := range xlMeta.Parts {
+		partPath := pathJoin(object, part.Name)
+		if _, err := disk.StatFile(bucket, partPath); err == errFileNotFound {
+			return shardsMissing
+		}
+		checksum := xlMeta.Erasure.PartObjectChecksum(part.Name)
+		if checksum.Hash == "" || !isValidBlock(disk, bucket, partPath, checksum) {
+			return shardsCorrupted
+		}
+	}
+	return shardsValid
+}
+
+// getHealShards - returns erasure info of damaged disks which receive
+// the shards not held by any valid disk, disks keep the shard of their
+// position where possible.
+func getHealShards(eInfo erasureInfo, validEInfos []erasureInfo, damaged []int) []erasureInfo {
+	held := make([]bool, len(eInfo.Distribution))
+	for index, validEInfo := range validEInfos {
+		if validEInfo.IsValid() {
+			held[validEInfo.shardIndex(index)] = true
+		}
+	}
+	healEInfos := make([]erasureInfo, len(eInfo.Distribution))
+	for _, index := range damaged {
+		shard := eInfo.Distribution[index] - 1
+		for held[shard] {
+			shard = (shard + 1) % len(held)
+		}
+		held[shard] = true
+		// Index of the position laying out the shard.
+		for position, dist := range eInfo.Distribution {
+			if dist-1 == shard {
+				healEInfos[index] = eInfo
+				healEInfos[index].Index = position + 1
+				healEInfos[index].Checksum = nil
+				healEInfos[index].Missing = nil
+			}
+		}
+	}
+	return healEInfos
+}
+
+// scrubObject - heals an object, returns disks with damaged shards.
+func (xl xlObjects) scrubObject(bucket, object string) (damaged []int, err error) {
+	missing, corrupted, err := xl.healObject(bucket, object, false)
+	damaged = append(missing, corrupted...)
+	sort.Ints(damaged)
+	return damaged, err
+}
+
+// healObject - verifies shards of all parts of an object on all disks,
+// missing, stale and corrupted shards are erasure coded again from the
+// valid shards unless dryRun. Returns disks with missing and disks with
+// corrupted shards, healing them failed if an error is returned.
+func (xl xlObjects) healObject(bucket, object string, dryRun bool) (missing, corrupted []int, err error) {
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	partsMetadata, errs := xl.readAllXLMetadata(bucket, object)
+	onlineDisks, _, err := xl.listOnlineDisks(partsMetadata, errs)
+	if err != nil {
+		// Object deleted since it was listed.
+		if !xl.isObject(bucket, object) {
+			xl.degraded.remove(bucket, object)
+			return nil, nil, nil
+		}
+		return nil, nil, err
+	}
+	var xlMeta xlMetaV1
+	for index, disk := range onlineDisks {
+		if disk != nil {
+			xlMeta = partsMetadata[index]
+			break
+		}
+	}
+	// Shards are verified and healed at the pace of the heal throttle.
+	onlineDisks = getHealDisks(onlineDisks)
+
+	// Verify shards of all disks in parallel, shards of stale disks
+	// and disks recorded missing while written are missing.
+	statuses := make([]shardsStatus, len(xl.storageDisks))
+	var wg = &sync.WaitGroup{}
+	for index, disk := range onlineDisks {
+		statuses[index] = shardsMissing
+		if disk == nil || errs[index] != nil || xlMeta.Erasure.isMissingDisk(index) {
+			continue
+		}
+		wg.Add(1)
+		go func(index int, disk StorageAPI) {
+			defer wg.Done()
+			statuses[index] = getShardsStatus(disk, bucket, object, partsMetadata[index])
+		}(index, disk)
+	}
+	wg.Wait()
+	var damaged []int
+	validEInfos := make([]erasureInfo, len(xl.storageDisks))
+	for index, disk := range xl.storageDisks {
+		switch {
+		case statuses[index] == shardsValid:
+			validEInfos[index] = partsMetadata[index].Erasure
+			continue
+		case disk == nil || errs[index] == errDiskNotFound:
+			// Offline disks cannot be healed.
+			continue
+		case errs[index] == errFileNotFound || errs[index] == errVolumeNotFound:
+			missing = append(missing, index)
+		case errs[index] != nil || statuses[index] == shardsCorrupted:
+			corrupted = append(corrupted, index)
+		default:
+			missing = append(missing, index)
+		}
+		damaged = append(damaged, index)
+		onlineDisks[index] = nil
+	}
+	// Recorded disks which are still offline stay missing.
+	isDamaged := make(map[int]bool)
+	for _, index := range damaged {
+		isDamaged[index+1] = true
+	}
+	var stillMissing []int
+	for _, position := range xlMeta.Erasure.Missing {
+		if !isDamaged[position] {
+			stillMissing = append(stillMissing, position)
+		}
+	}
+	// Objects written with disks missing stay pending catch-up while
+	// disks are offline or healing fails.
+	if !dryRun {
+		defer func() {
+			xl.degraded.update(bucket, object, len(xlMeta.Erasure.Missing) > 0 && (err != nil || len(stillMissing) > 0))
+		}()
+	}
+	if len(damaged) == 0 {
+		return nil, nil, nil
+	}
+	// Reads require one more block than the data blocks, inline
+	// objects a single intact copy.
+	readDisks := xlMeta.Erasure.DataBlocks + 1
+	if xlMeta.Inline {
+		readDisks = 1
+	}
+	if diskCount(onlineDisks) < readDisks {
+		return missing, corrupted, errXLReadQuorum
+	}
+	if dryRun {
+		return missing, corrupted, nil
+	}
+
+	// Shards of damaged disks are written to a temporary object.
+	tempObj := path.Join(tmpMetaPrefix, getUUID())
+	healDisks := make([]StorageAPI, len(xl.storageDisks))
+	for _, index := range damaged {
+		healDisks[index] = xl.storageDisks[index]
+	}
+	healDisks = getHealDisks(healDisks)
+	healEInfos := getHealShards(xlMeta.Erasure, validEInfos, damaged)
+	// Damaged disks of inline objects get a copy of an intact disk.
+	healParts := xlMeta.Parts
+	if xlMeta.Inline {
+		healParts = nil
+		if xlMeta.Data, err = pickInlineData(partsMetadata, onlineDisks); err != nil {
+			return missing, corrupted, err
+		}
+	}
+	for _, part := range healParts {
+		pipeReader, pipeWriter := io.Pipe()
+		go func(part objectPartInfo) {
+			_, rErr := erasureReadFile(pipeWriter, onlineDisks, bucket, pathJoin(object, part.Name), part.Name, validEInfos, 0, part.Size, part.Size)
+			pipeWriter.CloseWithError(rErr)
+		}(part)
+		var n int64
+		healEInfos, n, err = erasureCreateFile(healDisks, minioMetaBucket, pathJoin(tempObj, part.Name), part.Name, pipeReader, healEInfos, len(damaged))
+		pipeReader.Close()
+		if err == nil && n != part.Size {
+			err = errUnexpected
+		}
+		if err != nil {
+			xl.deleteObject(minioMetaBucket, tempObj)
+			return missing, corrupted, err
+		}
+	}
+
+	// Replace the object on each damaged disk, previous shards are
+	// moved out of the way first.
+	trashObj := path.Join(tmpMetaPrefix, getUUID())
+	healObject := func(disk StorageAPI, healMeta xlMetaV1) error {
+		if hErr := writeXLMetadata(disk, minioMetaBucket, tempObj, healMeta); hErr != nil {
+			return hErr
+		}
+		if hErr := disk.RenameFile(bucket, retainSlash(object), minioMetaBucket, retainSlash(trashObj)); hErr != nil && hErr != errFileNotFound {
+			return hErr
+		}
+		return disk.RenameFile(minioMetaBucket, retainSlash(tempObj), bucket, retainSlash(object))
+	}
+	for _, index := range damaged {
+		healMeta := xlMeta
+		healMeta.Erasure = healEInfos[index]
+		healMeta.Erasure.Missing = stillMissing
+		if hErr := healObject(xl.storageDisks[index], healMeta); hErr != nil {
+			err = hErr
+		}
+	}
+	xl.deleteObject(minioMetaBucket, tempObj)
+	xl.deleteObject(minioMetaBucket, trashObj)
+
+	// Valid disks no longer record the healed disks as missing.
+	if err == nil && len(stillMissing) < len(xlMeta.Erasure.Missing) {
+		tempMeta := path.Join(tmpMetaPrefix, getUUID())
+		for index, validEInfo := range validEInfos {
+			if !validEInfo.IsValid() {
+				continue
+			}
+			validMeta := partsMetadata[index]
+			validMeta.Erasure.Missing = stillMissing
+			if hErr := replaceXLMetadata(xl.storageDisks[index], bucket, object, tempMeta, validMeta); hErr != nil {
+				err = hErr
+			}
+		}
+		xl.deleteObject(minioMetaBucket, tempMeta)
+	}
+	return missing, corrupted, err
+}
+
+// scrubBucket - scrubs all objects of a bucket on any disk, creating
+// the bucket on disks missing it.
+func (xl xlObjects) scrubBucket(bucket string, disks []string) {
+	_, err := xl.healBucketVolume(bucket, false)
+	errorIf(err, "Unable to heal bucket %s.", bucket)
+	xl.healAllObjects(bucket, func(object string) {
+		damaged, err := xl.scrubObject(bucket, object)
+		errorIf(err, "Unable to heal object %s/%s.", bucket, object)
+		globalScrubStatus.update(disks, damaged, err == nil)
+	})
+}
+
+// scrub - scrubs all objects of all buckets once.
+func (xl xlObjects) scrub() {
+	disks := make([]string, len(xl.storageDisks))
+	for index, disk := range xl.storageDisks {
+		disks[index] = getDiskName(disk, index)
+	}
+	globalScrubStatus.start(disks)
+	defer globalScrubStatus.finish(disks)
+
+	// Objects written with disks missing are caught up first.
+	xl.catchUp()
+
+	for _, bucket := range xl.listAllBuckets() {
+		xl.scrubBucket(bucket, disks)
+	}
+}
+
+// listAllBuckets - lists buckets found on any disk, sorted by name.
+// Fresh disks have none of the buckets until healed.
+func (xl xlObjects) listAllBuckets() []string {
+	found := make(map[string]bool)
+	for _, disk := range xl.storageDisks {
+		if disk == nil {
+			continue
+		}
+		volsInfo, err := disk.ListVols()
+		if err != nil {
+			continue
+		}
+		for _, volInfo := range volsInfo {
+			if IsValidBucketName(volInfo.Name) {
+				found[volInfo.Name] = true
+			}
+		}
+	}
+	var buckets []string
+	for bucket := range found {
+		buckets = append(buckets, bucket)
+	}
+	sort.Strings(buckets)
+	return buckets
+}
+
+// scrubJob - scrubs all objects periodically.
+func (xl xlObjects) scrubJob(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+		xl.scrub()
+	}
+}
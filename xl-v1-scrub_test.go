@@ -0,0 +1,274 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// Tests shards damaged disks receive.
+func TestGetHealShards(t *testing.T) {
+	eInfo := erasureInfo{DataBlocks: 2, ParityBlocks: 2, Distribution: []int{3, 1, 4, 2}}
+	validEInfo := func(index int) erasureInfo {
+		validEInfo := eInfo
+		validEInfo.Index = index
+		return validEInfo
+	}
+	testCases := []struct {
+		validEInfos     []erasureInfo
+		damaged         []int
+		expectedIndexes []int
+	}{
+		// Test case - 1.
+		// Damaged disks keep the shard of their position.
+		{[]erasureInfo{validEInfo(1), {}, validEInfo(3), {}}, []int{1, 3}, []int{0, 2, 0, 4}},
+		// Test case - 2.
+		// Disks 1 and 2 swapped, disk 2 holds the shard of position 1.
+		{[]erasureInfo{{}, validEInfo(1), validEInfo(3), validEInfo(4)}, []int{0}, []int{2, 0, 0, 0}},
+	}
+	for i, testCase := range testCases {
+		healEInfos := getHealShards(eInfo, testCase.validEInfos, testCase.damaged)
+		indexes := make([]int, len(healEInfos))
+		for index, healEInfo := range healEInfos {
+			indexes[index] = healEInfo.Index
+		}
+		if !reflect.DeepEqual(indexes, testCase.expectedIndexes) {
+			t.Errorf("Test %d: Expected indexes %v, got %v", i+1, testCase.expectedIndexes, indexes)
+		}
+	}
+}
+
+// Tests damaged shards are found and healed from the other disks.
+func TestScrubObject(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, blockSizeV1+blockSizeV1/2)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	if _, err = obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+	xlMeta, err := xl.readXLMetadata(bucket, "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	objectPath := func(index int) string {
+		return filepath.Join(getDiskName(xl.storageDisks[index], index), bucket, "object")
+	}
+	partPath := func(index int) string {
+		return filepath.Join(objectPath(index), xlMeta.Parts[0].Name)
+	}
+
+	testCases := []struct {
+		damage          func()
+		expectedDamaged []int
+	}{
+		// Test case - 1.
+		// Nothing damaged.
+		{func() {}, nil},
+		// Test case - 2.
+		// Corrupted shard.
+		{func() {
+			f, oErr := os.OpenFile(partPath(2), os.O_RDWR, 0)
+			if oErr != nil {
+				t.Fatal(oErr)
+			}
+			defer f.Close()
+			if _, oErr = f.WriteAt([]byte{0xff}, 10); oErr != nil {
+				t.Fatal(oErr)
+			}
+		}, []int{2}},
+		// Test case - 3.
+		// Missing shards.
+		{func() {
+			if oErr := os.Remove(partPath(0)); oErr != nil {
+				t.Fatal(oErr)
+			}
+			if oErr := os.Remove(partPath(7)); oErr != nil {
+				t.Fatal(oErr)
+			}
+		}, []int{0, 7}},
+		// Test case - 4.
+		// Missing object.
+		{func() {
+			if oErr := os.RemoveAll(objectPath(15)); oErr != nil {
+				t.Fatal(oErr)
+			}
+		}, []int{15}},
+	}
+	for i, testCase := range testCases {
+		testCase.damage()
+		damaged, err := xl.scrubObject(bucket, "object")
+		if err != nil {
+			t.Fatalf("Test %d: Unable to scrub object. %s", i+1, err)
+		}
+		if !reflect.DeepEqual(damaged, testCase.expectedDamaged) {
+			t.Fatalf("Test %d: Expected damaged disks %v, got %v", i+1, testCase.expectedDamaged, damaged)
+		}
+		// Healed disks pass verification.
+		partsMetadata, _ := xl.readAllXLMetadata(bucket, "object")
+		for index, disk := range xl.storageDisks {
+			if getShardsStatus(disk, bucket, "object", partsMetadata[index]) != shardsValid {
+				t.Errorf("Test %d: Shards of disk %d are not valid after scrub", i+1, index+1)
+			}
+		}
+		if damaged, err = xl.scrubObject(bucket, "object"); err != nil || len(damaged) != 0 {
+			t.Errorf("Test %d: Expected no damage after scrub, got %v, %v", i+1, damaged, err)
+		}
+	}
+
+	// Read the object from the healed disks and the minimum number of
+	// other disks, forcing reconstruction from the healed shards.
+	for index := 0; index < 10; index++ {
+		if index != 0 && index != 2 && index != 7 {
+			xl.storageDisks[index] = nil
+		}
+	}
+	var buf bytes.Buffer
+	if err = obj.GetObject(bucket, "object", 0, int64(len(data)), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatal("Healed object does not match")
+	}
+}
+
+// Tests scrub status of disks.
+func TestScrubStatus(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello, world")
+	for _, object := range []string{"a", "b", "c"} {
+		if _, err = obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = os.RemoveAll(filepath.Join(getDiskName(xl.storageDisks[4], 4), bucket, "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	xl.scrub()
+	statuses := make(map[string]diskScrubStatus)
+	for _, status := range globalScrubStatus.GetStatus() {
+		statuses[status.Disk] = status
+	}
+	for index, disk := range xl.storageDisks {
+		status, ok := statuses[getDiskName(disk, index)]
+		if !ok {
+			t.Fatalf("Expected scrub status of disk %d", index+1)
+		}
+		if status.Scrubbing || status.LastFinished.IsZero() || status.LastFinished.Before(status.LastStarted) {
+			t.Errorf("Disk %d: Expected completed scrub, got %+v", index+1, status)
+		}
+		if status.ObjectsScanned != 3 {
+			t.Errorf("Disk %d: Expected 3 objects scanned, got %d", index+1, status.ObjectsScanned)
+		}
+		expectedHealed := int64(0)
+		if index == 4 {
+			expectedHealed = 1
+		}
+		if status.ShardsDamaged != expectedHealed || status.ShardsHealed != expectedHealed {
+			t.Errorf("Disk %d: Expected %d shards damaged and healed, got %d and %d", index+1, expectedHealed, status.ShardsDamaged, status.ShardsHealed)
+		}
+	}
+}
+
+// Tests objects written with disks missing are caught up once the
+// disks are back, and read from the other disks until then.
+func TestXLCatchUp(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("a"), 1024*1024)
+	disks := append([]StorageAPI(nil), xl.storageDisks...)
+	xl.storageDisks[0] = nil
+	xl.storageDisks[1] = faultyWriteDisk{disks[1]}
+	for _, object := range []string{"object", "deleted"} {
+		if _, err = obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	xl.storageDisks[1] = disks[1]
+	if err = obj.DeleteObject(bucket, "deleted"); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		disk0    StorageAPI
+		expected degradedObjectsStats
+	}{
+		// Test case - 1.
+		// Disk 1 still offline, the object stays pending.
+		{nil, degradedObjectsStats{Pending: 1, Written: 2, DegradedReads: 1}},
+		// Test case - 2.
+		{disks[0], degradedObjectsStats{Pending: 0, Written: 2, CaughtUp: 1, DegradedReads: 2}},
+	}
+	for i, testCase := range testCases {
+		xl.storageDisks[0] = testCase.disk0
+		var buf bytes.Buffer
+		if err = obj.GetObject(bucket, "object", 0, int64(len(data)), &buf); err != nil {
+			t.Fatalf("Test %d: %s", i+1, err)
+		}
+		if !bytes.Equal(buf.Bytes(), data) {
+			t.Errorf("Test %d: Object does not match", i+1)
+		}
+		xl.catchUp()
+		if stats := xl.degraded.stats(); stats != testCase.expected {
+			t.Errorf("Test %d: Expected %v, got %v", i+1, testCase.expected, stats)
+		}
+	}
+
+	// Caught up objects are read from all disks.
+	var buf bytes.Buffer
+	if err = obj.GetObject(bucket, "object", 0, int64(len(data)), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if reads := xl.degraded.stats().DegradedReads; reads != 2 {
+		t.Errorf("Expected 2 degraded reads, got %d", reads)
+	}
+}
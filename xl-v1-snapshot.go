@@ -0,0 +1,178 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"path"
+	"time"
+)
+
+/// Bucket snapshot operations
+
+// snapshotObject - links xl.json and all parts of an object into the
+// snapshot, object is read locked so that it is captured either before
+// or after a concurrent overwrite, never in between.
+func (xl xlObjects) snapshotObject(bucket, object, snapshotID string) error {
+	nsMutex.RLock(bucket, object)
+	defer nsMutex.RUnlock(bucket, object)
+
+	dstPrefix := path.Join(getSnapshotDataPrefix(bucket, snapshotID), object)
+	return xl.reduceWriteQuorumErrs(xl.doOnAllDisks(func(disk StorageAPI) error {
+		return linkDir(disk, bucket, object, minioMetaBucket, dstPrefix)
+	}))
+}
+
+// undoSnapshotBucket - removes a partially created snapshot.
+func (xl xlObjects) undoSnapshotBucket(bucket, snapshotID string) {
+	xl.doOnAllDisks(func(disk StorageAPI) error {
+		return cleanupDir(disk, minioMetaBucket, getSnapshotPrefix(bucket, snapshotID))
+	})
+}
+
+// SnapshotBucket - snapshot all objects of a bucket, objects are
+// linked one at a time and each of them is consistent by itself.
+func (xl xlObjects) SnapshotBucket(bucket, snapshotID string) (BucketSnapshotInfo, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketSnapshotInfo{}, BucketNameInvalid{Bucket: bucket}
+	}
+	if !xl.isBucketExist(bucket) {
+		return BucketSnapshotInfo{}, BucketNotFound{Bucket: bucket}
+	}
+	if !isValidSnapshotID(snapshotID) {
+		return BucketSnapshotInfo{}, BucketSnapshotNotFound{Bucket: bucket, SnapshotID: snapshotID}
+	}
+
+	marker := ""
+	for {
+		result, err := xl.listObjects(bucket, "", marker, "", maxObjectList)
+		if err != nil {
+			xl.undoSnapshotBucket(bucket, snapshotID)
+			return BucketSnapshotInfo{}, toObjectErr(err, bucket)
+		}
+		for _, objInfo := range result.Objects {
+			if err = xl.snapshotObject(bucket, objInfo.Name, snapshotID); err != nil {
+				xl.undoSnapshotBucket(bucket, snapshotID)
+				return BucketSnapshotInfo{}, toObjectErr(err, bucket, objInfo.Name)
+			}
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextMarker
+	}
+
+	snapshotInfo := BucketSnapshotInfo{
+		Bucket:  bucket,
+		ID:      snapshotID,
+		Created: time.Now().UTC(),
+	}
+	err := xl.reduceWriteQuorumErrs(xl.doOnAllDisks(func(disk StorageAPI) error {
+		return writeSnapshotMetadata(disk, snapshotInfo)
+	}))
+	if err != nil {
+		xl.undoSnapshotBucket(bucket, snapshotID)
+		return BucketSnapshotInfo{}, toObjectErr(err, bucket)
+	}
+	return snapshotInfo, nil
+}
+
+// ListBucketSnapshots - list all snapshots of a bucket.
+func (xl xlObjects) ListBucketSnapshots(bucket string) (snapshots []BucketSnapshotInfo, err error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return nil, BucketNameInvalid{Bucket: bucket}
+	}
+	for _, disk := range xl.getLoadBalancedQuorumDisks() {
+		if disk == nil {
+			continue
+		}
+		snapshots, err = listSnapshots(disk, bucket)
+		if err == nil {
+			return snapshots, nil
+		}
+	}
+	return nil, toObjectErr(err, bucket)
+}
+
+// readSnapshotInfo - reads snapshot metadata from any of the disks.
+func (xl xlObjects) readSnapshotInfo(bucket, snapshotID string) (snapshotInfo BucketSnapshotInfo, err error) {
+	err = errXLReadQuorum
+	for _, disk := range xl.getLoadBalancedQuorumDisks() {
+		if disk == nil {
+			continue
+		}
+		snapshotInfo, err = readSnapshotMetadata(disk, bucket, snapshotID)
+		if err == nil {
+			return snapshotInfo, nil
+		}
+		if _, ok := err.(BucketSnapshotNotFound); ok {
+			return BucketSnapshotInfo{}, err
+		}
+	}
+	return BucketSnapshotInfo{}, err
+}
+
+// CloneBucketSnapshot - create cloneBucket with the contents of the snapshot.
+func (xl xlObjects) CloneBucketSnapshot(bucket, snapshotID, cloneBucket string) error {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if _, err := xl.readSnapshotInfo(bucket, snapshotID); err != nil {
+		return toObjectErr(err, bucket)
+	}
+	if err := xl.MakeBucket(cloneBucket); err != nil {
+		return err
+	}
+
+	nsMutex.Lock(cloneBucket, "")
+	defer nsMutex.Unlock(cloneBucket, "")
+
+	dataPrefix := getSnapshotDataPrefix(bucket, snapshotID)
+	err := xl.reduceWriteQuorumErrs(xl.doOnAllDisks(func(disk StorageAPI) error {
+		return linkDir(disk, minioMetaBucket, dataPrefix, cloneBucket, "")
+	}))
+	if err != nil {
+		// Undo the partially cloned bucket.
+		xl.doOnAllDisks(func(disk StorageAPI) error {
+			return cleanupDir(disk, cloneBucket, "")
+		})
+		xl.undoMakeBucket(cloneBucket)
+		return toObjectErr(err, cloneBucket)
+	}
+	return nil
+}
+
+// DeleteBucketSnapshot - delete a snapshot, data is freed once it is no
+// longer referenced by the bucket or any other snapshot.
+func (xl xlObjects) DeleteBucketSnapshot(bucket, snapshotID string) error {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if _, err := xl.readSnapshotInfo(bucket, snapshotID); err != nil {
+		return toObjectErr(err, bucket)
+	}
+	err := xl.reduceWriteQuorumErrs(xl.doOnAllDisks(func(disk StorageAPI) error {
+		return cleanupDir(disk, minioMetaBucket, getSnapshotPrefix(bucket, snapshotID))
+	}))
+	if err != nil {
+		return toObjectErr(err, bucket)
+	}
+	return nil
+}
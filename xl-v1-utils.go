@@ -18,8 +18,10 @@ package main
 
 import (
 	"encoding/json"
+	"hash/crc32"
 	"math/rand"
 	"path"
+	"sync"
 	"time"
 )
 
@@ -35,6 +37,18 @@ func isQuorum(errs []error, minQuorumCount int) bool {
 	return diskFoundCount >= minQuorumCount
 }
 
+// isWriteSuccess - returns true if writes succeeded on write quorum
+// disks, failures of the other disks are left to healing.
+func isWriteSuccess(errs []error, writeQuorum int) bool {
+	var successCount int
+	for _, err := range errs {
+		if err == nil {
+			successCount++
+		}
+	}
+	return successCount >= writeQuorum
+}
+
 // Similar to 'len(slice)' but returns  the actual elements count
 // skipping the unallocated elements.
 func diskCount(disks []StorageAPI) int {
@@ -63,6 +77,21 @@ func randInts(count int) []int {
 	return ints
 }
 
+// hashOrder - returns a deterministic permutation of 1..count for the
+// key, a rotation starting at the crc32 of the key. Shards of an object
+// are laid out in the same order regardless of when it is written.
+func hashOrder(key string, count int) []int {
+	if count <= 0 {
+		return nil
+	}
+	start := int(crc32.ChecksumIEEE([]byte(key)) % uint32(count))
+	ints := make([]int, count)
+	for i := 0; i < count; i++ {
+		ints[i] = 1 + (start+i)%count
+	}
+	return ints
+}
+
 // readXLMeta reads `xl.json` and returns back XL metadata structure.
 func readXLMeta(disk StorageAPI, bucket string, object string) (xlMeta xlMetaV1, err error) {
 	// Reads entire `xl.json`.
@@ -79,3 +108,42 @@ func readXLMeta(disk StorageAPI, bucket string, object string) (xlMeta xlMetaV1,
 	// Return structured `xl.json`.
 	return xlMeta, nil
 }
+
+// doOnAllDisks - runs fn on all disks in parallel, returns errors per disk.
+func (xl xlObjects) doOnAllDisks(fn func(disk StorageAPI) error) []error {
+	// Initialize sync waitgroup.
+	var wg = &sync.WaitGroup{}
+
+	// Initialize list of errors.
+	var dErrs = make([]error, len(xl.storageDisks))
+
+	for index, disk := range xl.storageDisks {
+		if disk == nil {
+			dErrs[index] = errDiskNotFound
+			continue
+		}
+		wg.Add(1)
+		go func(index int, disk StorageAPI) {
+			defer wg.Done()
+			dErrs[index] = fn(disk)
+		}(index, disk)
+	}
+
+	// Wait for all disks to finish.
+	wg.Wait()
+	return dErrs
+}
+
+// reduceWriteQuorumErrs - returns write quorum error or the first error
+// other than a missing disk.
+func (xl xlObjects) reduceWriteQuorumErrs(dErrs []error) error {
+	if !isQuorum(dErrs, xl.writeQuorum) {
+		return errXLWriteQuorum
+	}
+	for _, err := range dErrs {
+		if err != nil && err != errDiskNotFound {
+			return err
+		}
+	}
+	return nil
+}
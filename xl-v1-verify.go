@@ -0,0 +1,251 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Sirupsen/logrus"
+)
+
+// Sampled objects are verified in rounds, the sample percentage is of
+// objects per day.
+const (
+	verifyRoundInterval = time.Hour
+	verifyRoundsPerDay  = 24
+)
+
+// bucketVerifyReport - counts of objects of a bucket verified by
+// sampling and of healing escalated from the samples.
+type bucketVerifyReport struct {
+	Bucket         string    `json:"bucket"`
+	ObjectsSampled int64     `json:"objectsSampled"`
+	ObjectsDamaged int64     `json:"objectsDamaged"`
+	Escalations    int64     `json:"escalations"`
+	LastEscalated  time.Time `json:"lastEscalated,omitempty"`
+}
+
+// verifyReport - integrity report of objects verified by sampling
+// during a day.
+type verifyReport struct {
+	Started        time.Time `json:"started"`
+	ObjectsScanned int64     `json:"objectsScanned"`
+	ObjectsSampled int64     `json:"objectsSampled"`
+	ObjectsDamaged int64     `json:"objectsDamaged"`
+	ShardsDamaged  int64     `json:"shardsDamaged"`
+	// Percentage of damaged objects estimated from the sample, and the
+	// upper bound of its 95% confidence interval.
+	ErrorRate      float64              `json:"errorRate"`
+	ErrorRateUpper float64              `json:"errorRateUpper"`
+	Buckets        []bucketVerifyReport `json:"buckets"`
+}
+
+// verifyStatus - configuration of sampling with the reports of today
+// and yesterday.
+type verifyStatus struct {
+	SamplePercent  float64       `json:"samplePercent"`
+	ErrorThreshold float64       `json:"errorThreshold"`
+	Today          *verifyReport `json:"today,omitempty"`
+	Yesterday      *verifyReport `json:"yesterday,omitempty"`
+}
+
+// verifyDay - counts of objects verified by sampling during a day.
+type verifyDay struct {
+	started        time.Time
+	objectsScanned int64
+	shardsDamaged  int64
+	buckets        map[string]*bucketVerifyReport
+}
+
+// sampleVerifier - keeps counts of objects verified by sampling.
+type sampleVerifier struct {
+	mutex     *sync.Mutex
+	today     *verifyDay
+	yesterday *verifyDay
+}
+
+// Counts of objects verified by sampling of all XL object layers.
+var globalSampleVerifier = &sampleVerifier{
+	mutex: &sync.Mutex{},
+}
+
+// getDay - returns counts of the current day, counts of the day before
+// are kept until the next day starts. Called with the lock held.
+func (v *sampleVerifier) getDay(now time.Time) *verifyDay {
+	if v.today != nil && now.Sub(v.today.started) < verifyRoundsPerDay*verifyRoundInterval {
+		return v.today
+	}
+	v.yesterday = v.today
+	v.today = &verifyDay{
+		started: now,
+		buckets: make(map[string]*bucketVerifyReport),
+	}
+	return v.today
+}
+
+// getBucket - returns counts of a bucket during the current day.
+// Called with the lock held.
+func (v *sampleVerifier) getBucket(bucket string) *bucketVerifyReport {
+	day := v.getDay(time.Now().UTC())
+	report, ok := day.buckets[bucket]
+	if !ok {
+		report = &bucketVerifyReport{Bucket: bucket}
+		day.buckets[bucket] = report
+	}
+	return report
+}
+
+// scanned - records an object seen by sampling, verified only if sampled.
+func (v *sampleVerifier) scanned() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.getDay(time.Now().UTC()).objectsScanned++
+}
+
+// update - records a verified object and the number of its damaged
+// shards.
+func (v *sampleVerifier) update(bucket string, damaged int) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	report := v.getBucket(bucket)
+	report.ObjectsSampled++
+	if damaged > 0 {
+		report.ObjectsDamaged++
+		v.getDay(time.Now().UTC()).shardsDamaged += int64(damaged)
+	}
+}
+
+// escalated - records healing of a bucket escalated from samples.
+func (v *sampleVerifier) escalated(bucket string) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	report := v.getBucket(bucket)
+	report.Escalations++
+	report.LastEscalated = time.Now().UTC()
+}
+
+// GetStatus - returns integrity reports of today and yesterday.
+func (v *sampleVerifier) GetStatus() verifyStatus {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return verifyStatus{
+		SamplePercent:  globalVerifySamplePercent,
+		ErrorThreshold: globalVerifyErrorThreshold,
+		Today:          v.today.report(),
+		Yesterday:      v.yesterday.report(),
+	}
+}
+
+// report - returns the integrity report of a day, nil if sampling
+// did not run.
+func (d *verifyDay) report() *verifyReport {
+	if d == nil {
+		return nil
+	}
+	report := &verifyReport{
+		Started:        d.started,
+		ObjectsScanned: d.objectsScanned,
+		ShardsDamaged:  d.shardsDamaged,
+		Buckets:        []bucketVerifyReport{},
+	}
+	for _, bucket := range d.buckets {
+		report.ObjectsSampled += bucket.ObjectsSampled
+		report.ObjectsDamaged += bucket.ObjectsDamaged
+		report.Buckets = append(report.Buckets, *bucket)
+	}
+	sort.Sort(byVerifyBucket(report.Buckets))
+	report.ErrorRate, report.ErrorRateUpper = getErrorRate(report.ObjectsDamaged, report.ObjectsSampled)
+	return report
+}
+
+// byVerifyBucket is a collection satisfying sort.Interface.
+type byVerifyBucket []bucketVerifyReport
+
+func (b byVerifyBucket) Len() int           { return len(b) }
+func (b byVerifyBucket) Swap(i, j int)      { b[i], b[j] = b[j], b[i] }
+func (b byVerifyBucket) Less(i, j int) bool { return b[i].Bucket < b[j].Bucket }
+
+// getErrorRate - returns percentage of damaged objects in a sample and
+// the upper bound of the Wilson score interval at 95% confidence,
+// which stays meaningful for small samples with few damaged objects.
+func getErrorRate(damaged, sampled int64) (rate, upper float64) {
+	if sampled == 0 {
+		return 0, 0
+	}
+	const z = 1.96
+	n := float64(sampled)
+	p := float64(damaged) / n
+	center := p + z*z/(2*n)
+	margin := z * math.Sqrt(p*(1-p)/n+z*z/(4*n*n))
+	upper = (center + margin) / (1 + z*z/n)
+	return p * 100, math.Min(upper, 1) * 100
+}
+
+// verifyRound - verifies each object of all buckets with the
+// probability, without healing. Buckets whose sampled objects are
+// damaged beyond the error threshold percentage are healed entirely.
+func (xl xlObjects) verifyRound(probability, errorThreshold float64) {
+	for _, bucket := range xl.listAllBuckets() {
+		var mutex sync.Mutex
+		var sampled, damaged int
+		xl.healAllObjects(bucket, func(object string) {
+			globalSampleVerifier.scanned()
+			if rand.Float64() >= probability {
+				return
+			}
+			missing, corrupted, err := xl.healObject(bucket, object, true)
+			if err != nil {
+				errorIf(err, "Unable to verify object %s/%s.", bucket, object)
+				return
+			}
+			globalSampleVerifier.update(bucket, len(missing)+len(corrupted))
+			mutex.Lock()
+			sampled++
+			if len(missing)+len(corrupted) > 0 {
+				damaged++
+			}
+			mutex.Unlock()
+		})
+		if rate, _ := getErrorRate(int64(damaged), int64(sampled)); damaged == 0 || rate <= errorThreshold {
+			continue
+		}
+		log.WithFields(logrus.Fields{
+			"bucket":  bucket,
+			"sampled": sampled,
+			"damaged": damaged,
+		}).Warn("Sampled objects are damaged beyond the error threshold, healing the bucket.")
+		globalSampleVerifier.escalated(bucket)
+		xl.healAllObjects(bucket, func(object string) {
+			_, err := xl.scrubObject(bucket, object)
+			errorIf(err, "Unable to heal object %s/%s.", bucket, object)
+		})
+	}
+}
+
+// verifyJob - verifies the percentage of objects per day, spread over
+// rounds.
+func (xl xlObjects) verifyJob(samplePercent, errorThreshold float64) {
+	probability := samplePercent / 100 / verifyRoundsPerDay
+	for {
+		time.Sleep(verifyRoundInterval)
+		xl.verifyRound(probability, errorThreshold)
+	}
+}
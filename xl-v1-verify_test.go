@@ -0,0 +1,140 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Tests error rates estimated from samples.
+func TestGetErrorRate(t *testing.T) {
+	testCases := []struct {
+		damaged       int64
+		sampled       int64
+		expectedRate  float64
+		expectedUpper float64
+	}{
+		// Test case - 1.
+		{0, 0, 0, 0},
+		// Test case - 2.
+		// Few samples leave a wide interval.
+		{0, 10, 0, 27.75},
+		// Test case - 3.
+		{1, 100, 1, 5.45},
+		// Test case - 4.
+		{100, 100, 100, 100},
+	}
+	for i, testCase := range testCases {
+		rate, upper := getErrorRate(testCase.damaged, testCase.sampled)
+		if math.Abs(rate-testCase.expectedRate) > 0.01 || math.Abs(upper-testCase.expectedUpper) > 0.01 {
+			t.Errorf("Test %d: Expected rate %.2f and upper bound %.2f, got %.2f and %.2f", i+1, testCase.expectedRate, testCase.expectedUpper, rate, upper)
+		}
+	}
+}
+
+// Tests reports of today are kept as yesterday once a day passed.
+func TestSampleVerifierDays(t *testing.T) {
+	verifier := &sampleVerifier{mutex: &sync.Mutex{}}
+	verifier.update("bucket", 1)
+	verifier.update("bucket", 0)
+	status := verifier.GetStatus()
+	if status.Today == nil || status.Yesterday != nil {
+		t.Fatalf("Expected only a report of today, got %+v", status)
+	}
+	if status.Today.ObjectsSampled != 2 || status.Today.ObjectsDamaged != 1 || status.Today.ErrorRate != 50 {
+		t.Errorf("Expected 1 of 2 objects damaged, got %+v", status.Today)
+	}
+
+	verifier.today.started = time.Now().UTC().Add(-verifyRoundsPerDay * verifyRoundInterval)
+	verifier.update("other-bucket", 0)
+	status = verifier.GetStatus()
+	if status.Yesterday == nil || status.Yesterday.ObjectsSampled != 2 {
+		t.Fatalf("Expected report of yesterday with 2 objects, got %+v", status.Yesterday)
+	}
+	if len(status.Today.Buckets) != 1 || status.Today.Buckets[0].Bucket != "other-bucket" {
+		t.Errorf("Expected report of today for other-bucket, got %+v", status.Today.Buckets)
+	}
+}
+
+// Tests sampled objects are verified, buckets are healed only beyond
+// the error threshold.
+func TestVerifyRound(t *testing.T) {
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+	globalSampleVerifier = &sampleVerifier{mutex: &sync.Mutex{}}
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := []byte("hello, world")
+	for _, object := range []string{"a", "b", "c"} {
+		if _, err = obj.PutObject(bucket, object, int64(len(data)), bytes.NewReader(data), nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = os.RemoveAll(filepath.Join(getDiskName(xl.storageDisks[4], 4), bucket, "b")); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		probability         float64
+		errorThreshold      float64
+		expectedSampled     int64
+		expectedDamaged     int64
+		expectedEscalations int64
+	}{
+		// Test case - 1.
+		// Nothing sampled.
+		{0, 1, 0, 0, 0},
+		// Test case - 2.
+		// Damage within the threshold is only reported.
+		{1, 50, 3, 1, 0},
+		// Test case - 3.
+		{1, 1, 6, 2, 1},
+		// Test case - 4.
+		// Healed by the previous round.
+		{1, 1, 9, 2, 1},
+	}
+	for i, testCase := range testCases {
+		xl.verifyRound(testCase.probability, testCase.errorThreshold)
+		report := globalSampleVerifier.GetStatus().Today
+		if report.ObjectsScanned != int64(3*(i+1)) {
+			t.Errorf("Test %d: Expected %d objects scanned, got %d", i+1, 3*(i+1), report.ObjectsScanned)
+		}
+		if report.ObjectsSampled != testCase.expectedSampled || report.ObjectsDamaged != testCase.expectedDamaged {
+			t.Errorf("Test %d: Expected %d objects sampled and %d damaged, got %d and %d", i+1, testCase.expectedSampled, testCase.expectedDamaged, report.ObjectsSampled, report.ObjectsDamaged)
+		}
+		var escalations int64
+		for _, bucketReport := range report.Buckets {
+			escalations += bucketReport.Escalations
+		}
+		if escalations != testCase.expectedEscalations {
+			t.Errorf("Test %d: Expected %d escalations, got %d", i+1, testCase.expectedEscalations, escalations)
+		}
+	}
+}
@@ -0,0 +1,568 @@
+/*
+ * Minio Cloud Storage, (C) 2016 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Latest versions of objects stay in the bucket, older versions and
+// delete markers of versioned buckets are kept in minioMetaBucket on
+// every disk under the object directory as
+//
+//	versions/<bucket>/<object>/^<versionID>/xl.json
+//	versions/<bucket>/<object>/^<versionID>/object1
+//
+// Versions are moved there as they are replaced or deleted, delete
+// markers are versions without data. Null versions are kept as
+// ^null, "^" is not allowed in object names so that versions never
+// clash with objects named after the path of another object. All
+// versions of an object are guarded by the namespace lock of the
+// object.
+const (
+	versionsMetaPrefix = "versions"
+	versionDirPrefix   = "^"
+)
+
+// getVersionsPrefix - returns the prefix of all versions of an object
+// inside minioMetaBucket.
+func getVersionsPrefix(bucket, object string) string {
+	return path.Join(versionsMetaPrefix, bucket, object)
+}
+
+// getVersionPath - returns the path of a version of an object inside
+// minioMetaBucket, empty version ids are the null version.
+func getVersionPath(bucket, object, versionID string) string {
+	return path.Join(getVersionsPrefix(bucket, object), versionDirPrefix+getVersionID(versionID))
+}
+
+// isVersionDir - returns true for entries holding a version.
+func isVersionDir(entry string) bool {
+	return strings.HasPrefix(entry, versionDirPrefix)
+}
+
+// Version directories are never objects for listDir.
+func isNotLeaf(bucket, entry string) bool {
+	return false
+}
+
+// byNewestVersion - sorts versions newest first.
+type byNewestVersion []ObjectInfo
+
+func (v byNewestVersion) Len() int           { return len(v) }
+func (v byNewestVersion) Swap(i, j int)      { v[i], v[j] = v[j], v[i] }
+func (v byNewestVersion) Less(i, j int) bool { return v[i].ModTime.After(v[j].ModTime) }
+
+// deleteEmptyDirs - removes dir on all disks if empty. DeleteFile
+// removes the parents of a removed entry as long as they are empty,
+// up to the volume, so the empty parents of dir are removed as well.
+func (xl xlObjects) deleteEmptyDirs(volume, dir string) {
+	if dir == "." || dir == "" {
+		return
+	}
+	xl.doOnAllDisks(func(disk StorageAPI) error {
+		return disk.DeleteFile(volume, dir)
+	})
+}
+
+// retireObject - disposes of the replaced version of an object moved
+// to trashObj. Versioned buckets keep it as a version, except for
+// null versions replaced while versioning is suspended, otherwise it
+// is deleted and releases its dedup reference.
+func (xl xlObjects) retireObject(bucket, object, trashObj string, prevXLMeta xlMetaV1) {
+	status := getBucketVersioning(bucket)
+	if status == versioningSuspended {
+		// The null version written replaces any older null version.
+		if err := xl.deleteVersion(bucket, object, ""); err != nil && err != errFileNotFound {
+			errorIf(err, "Unable to delete null version of %s/%s.", bucket, object)
+		}
+	}
+	versionID := prevXLMeta.Meta[objectVersionIDKey]
+	if prevXLMeta.IsValid() && (status == versioningEnabled || status == versioningSuspended && versionID != "") {
+		err := xl.renameObject(minioMetaBucket, trashObj, minioMetaBucket, getVersionPath(bucket, object, versionID))
+		if err == nil {
+			return
+		}
+		errorIf(err, "Unable to keep version %s of %s/%s.", getVersionID(versionID), bucket, object)
+	}
+	xl.deleteObject(minioMetaBucket, trashObj)
+	xl.releaseDedupRef(prevXLMeta)
+}
+
+// deleteVersion - deletes a version kept in minioMetaBucket and
+// releases its dedup reference, returns errFileNotFound if there is
+// no such version.
+func (xl xlObjects) deleteVersion(bucket, object, versionID string) error {
+	versionPath := getVersionPath(bucket, object, versionID)
+	if !xl.isObject(minioMetaBucket, versionPath) {
+		return errFileNotFound
+	}
+	xlMeta, err := xl.readXLMetadata(minioMetaBucket, versionPath)
+	if err != nil {
+		return err
+	}
+	if err = xl.deleteObject(minioMetaBucket, versionPath); err != nil {
+		return err
+	}
+	xl.releaseDedupRef(xlMeta)
+	return nil
+}
+
+// deleteVersionedObject - deletes the latest version of an object by
+// keeping it as a version and adding a delete marker, objects which
+// do not exist are marked deleted as well. Callers hold the object
+// lock.
+func (xl xlObjects) deleteVersionedObject(bucket, object, status string) error {
+	markerID := ""
+	if status == versioningEnabled {
+		markerID = getUUID()
+	}
+	if xl.isObject(bucket, object) {
+		prevXLMeta, _ := xl.readXLMetadata(bucket, object)
+		trashObj := path.Join(tmpMetaPrefix, getUUID())
+		if err := xl.renameObject(bucket, object, minioMetaBucket, trashObj); err != nil {
+			return toObjectErr(err, bucket, object)
+		}
+		xl.deleteEmptyDirs(bucket, path.Dir(object))
+		xl.retireObject(bucket, object, trashObj, prevXLMeta)
+	} else if status == versioningSuspended {
+		// The null delete marker replaces any older null version.
+		if err := xl.deleteVersion(bucket, object, ""); err != nil && err != errFileNotFound {
+			return toObjectErr(err, bucket, object)
+		}
+	}
+
+	modTime := time.Now().UTC()
+	xlMeta := newXLMetaV1(object, xl.dataBlocks, xl.parityBlocks)
+	xlMeta.Meta = map[string]string{objectDeleteMarkerKey: "true"}
+	if markerID != "" {
+		xlMeta.Meta[objectVersionIDKey] = markerID
+	}
+	stampObjectProvenance(xlMeta.Meta, modTime)
+	xlMeta.Stat.ModTime = modTime
+
+	tempObj := path.Join(tmpMetaPrefix, getUUID())
+	if err := xl.writeSameXLMetadata(minioMetaBucket, tempObj, xlMeta); err != nil {
+		xl.deleteObject(minioMetaBucket, tempObj)
+		return toObjectErr(err, bucket, object)
+	}
+	if err := xl.renameObject(minioMetaBucket, tempObj, minioMetaBucket, getVersionPath(bucket, object, markerID)); err != nil {
+		xl.deleteObject(minioMetaBucket, tempObj)
+		return toObjectErr(err, bucket, object)
+	}
+	return nil
+}
+
+// getVersionLocation - returns volume and path holding a version of an
+// object, the latest version is the object itself. Callers hold the
+// object lock.
+func (xl xlObjects) getVersionLocation(bucket, object, versionID string) (string, string, error) {
+	if !isValidVersionID(versionID) {
+		return "", "", VersionNotFound{Bucket: bucket, Object: object, VersionID: versionID}
+	}
+	if versionID == nullVersionID {
+		versionID = ""
+	}
+	if xl.isObject(bucket, object) {
+		xlMeta, err := xl.readXLMetadata(bucket, object)
+		if err != nil {
+			return "", "", toObjectErr(err, bucket, object)
+		}
+		if xlMeta.Meta[objectVersionIDKey] == versionID {
+			return bucket, object, nil
+		}
+	}
+	versionPath := getVersionPath(bucket, object, versionID)
+	if xl.isObject(minioMetaBucket, versionPath) {
+		return minioMetaBucket, versionPath, nil
+	}
+	return "", "", VersionNotFound{Bucket: bucket, Object: object, VersionID: versionID}
+}
+
+// getArchivedVersions - returns versions of an object kept in
+// minioMetaBucket, newest first.
+func (xl xlObjects) getArchivedVersions(bucket, object string) ([]ObjectInfo, error) {
+	versionsDir := retainSlash(getVersionsPrefix(bucket, object))
+	entries, err := xl.listDir(minioMetaBucket, versionsDir, isVersionDir, isNotLeaf)
+	if err == errFileNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var versions []ObjectInfo
+	for _, entry := range entries {
+		objInfo, err := xl.getObjectInfo(minioMetaBucket, pathJoin(versionsDir, entry))
+		if err != nil {
+			// Versions deleted meanwhile are skipped.
+			if err == errFileNotFound {
+				continue
+			}
+			return nil, err
+		}
+		objInfo.Bucket, objInfo.Name = bucket, object
+		versions = append(versions, objInfo)
+	}
+	sort.Sort(byNewestVersion(versions))
+	return versions, nil
+}
+
+// getObjectVersions - returns all versions of an object newest first,
+// the latest version is marked.
+func (xl xlObjects) getObjectVersions(bucket, object string) ([]ObjectInfo, error) {
+	var versions []ObjectInfo
+	objInfo, err := xl.getObjectInfo(bucket, object)
+	if err == nil {
+		versions = append(versions, objInfo)
+	} else if err != errFileNotFound {
+		return nil, err
+	}
+	archived, err := xl.getArchivedVersions(bucket, object)
+	if err != nil {
+		return nil, err
+	}
+	versions = append(versions, archived...)
+	if len(versions) > 0 {
+		versions[0].IsLatest = true
+	}
+	return versions, nil
+}
+
+// restoreLatestVersion - makes the newest kept version the latest if
+// the object was deleted, unless the version is a delete marker.
+// Callers hold the object lock.
+func (xl xlObjects) restoreLatestVersion(bucket, object string) error {
+	if xl.isObject(bucket, object) {
+		return nil
+	}
+	versions, err := xl.getArchivedVersions(bucket, object)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 || versions[0].DeleteMarker {
+		return nil
+	}
+	versionPath := getVersionPath(bucket, object, versions[0].VersionID)
+	if err = xl.renameObject(minioMetaBucket, versionPath, bucket, object); err != nil {
+		return err
+	}
+	xl.deleteEmptyDirs(minioMetaBucket, getVersionsPrefix(bucket, object))
+	return nil
+}
+
+// hasObjectVersions - returns true if versions of objects of the
+// bucket are kept.
+func (xl xlObjects) hasObjectVersions(bucket string) bool {
+	entries, err := xl.listDir(minioMetaBucket, retainSlash(path.Join(versionsMetaPrefix, bucket)), func(string) bool {
+		return true
+	}, isNotLeaf)
+	return err == nil && len(entries) > 0
+}
+
+// GetObjectVersion - reads a version of an object, like GetObject.
+func (xl xlObjects) GetObjectVersion(bucket, object, versionID string, startOffset int64, length int64, writer io.Writer) error {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	// Verify if object is valid.
+	if !IsValidObjectName(object) {
+		return ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+
+	// Lock the object before reading.
+	nsMutex.RLock(bucket, object)
+	defer nsMutex.RUnlock(bucket, object)
+
+	volume, prefix, err := xl.getVersionLocation(bucket, object, versionID)
+	if err != nil {
+		return err
+	}
+	return xl.getObject(volume, prefix, startOffset, length, writer)
+}
+
+// GetObjectVersionInfo - returns info of a version of an object,
+// delete markers are returned as such.
+func (xl xlObjects) GetObjectVersionInfo(bucket, object, versionID string) (ObjectInfo, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return ObjectInfo{}, BucketNameInvalid{Bucket: bucket}
+	}
+	// Verify if object is valid.
+	if !IsValidObjectName(object) {
+		return ObjectInfo{}, ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	nsMutex.RLock(bucket, object)
+	defer nsMutex.RUnlock(bucket, object)
+
+	volume, prefix, err := xl.getVersionLocation(bucket, object, versionID)
+	if err != nil {
+		return ObjectInfo{}, err
+	}
+	objInfo, err := xl.getObjectInfo(volume, prefix)
+	if err != nil {
+		return ObjectInfo{}, toObjectErr(err, bucket, object)
+	}
+	objInfo.Bucket, objInfo.Name = bucket, object
+	return objInfo, nil
+}
+
+// DeleteObjectVersion - permanently deletes a version of an object.
+// Deleting the latest version makes the newest remaining version the
+// latest.
+func (xl xlObjects) DeleteObjectVersion(bucket, object, versionID string) error {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return BucketNameInvalid{Bucket: bucket}
+	}
+	if !IsValidObjectName(object) {
+		return ObjectNameInvalid{Bucket: bucket, Object: object}
+	}
+	nsMutex.Lock(bucket, object)
+	defer nsMutex.Unlock(bucket, object)
+
+	volume, _, err := xl.getVersionLocation(bucket, object, versionID)
+	if err != nil {
+		return err
+	}
+	if volume == bucket {
+		// Save metadata to release its dedup reference.
+		xlMeta, _ := xl.readXLMetadata(bucket, object)
+		if err = xl.deleteObject(bucket, object); err != nil {
+			return toObjectErr(err, bucket, object)
+		}
+		xl.releaseDedupRef(xlMeta)
+	} else {
+		if versionID == nullVersionID {
+			versionID = ""
+		}
+		if err = xl.deleteVersion(bucket, object, versionID); err != nil && err != errFileNotFound {
+			return toObjectErr(err, bucket, object)
+		}
+	}
+	if err = xl.restoreLatestVersion(bucket, object); err != nil {
+		return toObjectErr(err, bucket, object)
+	}
+	return nil
+}
+
+// listVersionEntries - lists entries of prefixDir in the bucket and
+// among the kept versions, sorted. Objects and names with kept
+// versions are returned as is, "name/" entries are directories of
+// either.
+func (xl xlObjects) listVersionEntries(bucket, prefixDir, entryPrefixMatch string) ([]string, error) {
+	filter := func(entry string) bool {
+		return strings.HasPrefix(entry, entryPrefixMatch)
+	}
+	entries, err := xl.listDir(bucket, prefixDir, filter, xl.isObject)
+	if err != nil && err != errFileNotFound {
+		return nil, err
+	}
+	versionsDir := retainSlash(path.Join(versionsMetaPrefix, bucket, prefixDir))
+	dirs, err := xl.listDir(minioMetaBucket, versionsDir, func(entry string) bool {
+		return filter(entry) && !isVersionDir(entry)
+	}, isNotLeaf)
+	if err != nil && err != errFileNotFound {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		children, err := xl.listDir(minioMetaBucket, pathJoin(versionsDir, dir), func(string) bool {
+			return true
+		}, isNotLeaf)
+		if err == errFileNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var hasVersions, hasChildren bool
+		for _, child := range children {
+			if isVersionDir(child) {
+				hasVersions = true
+			} else {
+				hasChildren = true
+			}
+		}
+		if hasVersions {
+			entries = append(entries, strings.TrimSuffix(dir, slashSeparator))
+		}
+		if hasChildren {
+			entries = append(entries, dir)
+		}
+	}
+	sort.Strings(entries)
+	var uniqueEntries []string
+	for _, entry := range entries {
+		if len(uniqueEntries) == 0 || entry != uniqueEntries[len(uniqueEntries)-1] {
+			uniqueEntries = append(uniqueEntries, entry)
+		}
+	}
+	return uniqueEntries, nil
+}
+
+// ListObjectVersions - lists versions and delete markers of objects
+// in lexical order of keys, versions of each key newest first. Lists
+// after the version versionIDMarker of keyMarker, or after all
+// versions of keyMarker without versionIDMarker.
+func (xl xlObjects) ListObjectVersions(bucket, prefix, keyMarker, versionIDMarker, delimiter string, maxKeys int) (ListObjectVersionsInfo, error) {
+	// Verify if bucket is valid.
+	if !IsValidBucketName(bucket) {
+		return ListObjectVersionsInfo{}, BucketNameInvalid{Bucket: bucket}
+	}
+	// Verify if bucket exists.
+	if !xl.isBucketExist(bucket) {
+		return ListObjectVersionsInfo{}, BucketNotFound{Bucket: bucket}
+	}
+	if !IsValidObjectPrefix(prefix) {
+		return ListObjectVersionsInfo{}, ObjectNameInvalid{Bucket: bucket, Object: prefix}
+	}
+	// Verify if delimiter is anything other than '/', which we do not support.
+	if delimiter != "" && delimiter != slashSeparator {
+		return ListObjectVersionsInfo{}, UnsupportedDelimiter{
+			Delimiter: delimiter,
+		}
+	}
+	// Verify if marker has prefix.
+	if keyMarker != "" && !strings.HasPrefix(keyMarker, prefix) {
+		return ListObjectVersionsInfo{}, InvalidMarkerPrefixCombination{
+			Marker: keyMarker,
+			Prefix: prefix,
+		}
+	}
+	// With max keys of zero we have reached eof, return right here.
+	if maxKeys == 0 {
+		return ListObjectVersionsInfo{}, nil
+	}
+	// Over flowing count - reset to maxObjectList.
+	if maxKeys < 0 || maxKeys > maxObjectList {
+		maxKeys = maxObjectList
+	}
+	recursive := delimiter != slashSeparator
+
+	var result ListObjectVersionsInfo
+	isFull := func() bool {
+		if len(result.Objects)+len(result.Prefixes) < maxKeys {
+			return false
+		}
+		result.IsTruncated = true
+		return true
+	}
+	// Walks the bucket and its versions together, returns true once
+	// the listing is full.
+	var walk func(prefixDir, entryPrefixMatch string) (bool, error)
+	walk = func(prefixDir, entryPrefixMatch string) (bool, error) {
+		entries, err := xl.listVersionEntries(bucket, prefixDir, entryPrefixMatch)
+		if err != nil {
+			return false, err
+		}
+		for _, entry := range entries {
+			name := prefixDir + entry
+			if strings.HasSuffix(entry, slashSeparator) {
+				if !recursive {
+					// Prefixes are listed once, before all keys in them.
+					if name <= keyMarker {
+						continue
+					}
+					if isFull() {
+						return true, nil
+					}
+					result.Prefixes = append(result.Prefixes, name)
+					result.NextKeyMarker, result.NextVersionIDMarker = name, ""
+					continue
+				}
+				// Skip directories with all keys before the marker.
+				if name < keyMarker && !strings.HasPrefix(keyMarker, name) {
+					continue
+				}
+				if full, err := walk(name, ""); full || err != nil {
+					return full, err
+				}
+				continue
+			}
+			if name < keyMarker || name == keyMarker && versionIDMarker == "" {
+				continue
+			}
+			versions, err := xl.getObjectVersions(bucket, name)
+			if err != nil {
+				return false, toObjectErr(err, bucket, name)
+			}
+			if name == keyMarker {
+				// Skip versions up to the marker, all are listed if
+				// the marker is gone.
+				for index, objInfo := range versions {
+					if getVersionID(objInfo.VersionID) == versionIDMarker {
+						versions = versions[index+1:]
+						break
+					}
+				}
+			}
+			for _, objInfo := range versions {
+				if isFull() {
+					return true, nil
+				}
+				result.Objects = append(result.Objects, objInfo)
+				result.NextKeyMarker, result.NextVersionIDMarker = name, getVersionID(objInfo.VersionID)
+			}
+		}
+		return false, nil
+	}
+
+	prefixDir, entryPrefixMatch := "", prefix
+	if lastIndex := strings.LastIndex(prefix, slashSeparator); lastIndex != -1 {
+		prefixDir, entryPrefixMatch = prefix[:lastIndex+1], prefix[lastIndex+1:]
+	}
+	if _, err := walk(prefixDir, entryPrefixMatch); err != nil {
+		return ListObjectVersionsInfo{}, toObjectErr(err, bucket, prefix)
+	}
+	if !result.IsTruncated {
+		result.NextKeyMarker, result.NextVersionIDMarker = "", ""
+	}
+	return result, nil
+}
+
+// releaseVersionDedupRefs - releases dedup references of all versions
+// of a bucket kept in minioMetaBucket.
+func (xl xlObjects) releaseVersionDedupRefs(bucket string) error {
+	keyMarker, versionIDMarker := "", ""
+	for {
+		result, err := xl.ListObjectVersions(bucket, "", keyMarker, versionIDMarker, "", maxObjectList)
+		if err != nil {
+			return err
+		}
+		for _, objInfo := range result.Objects {
+			nsMutex.Lock(bucket, objInfo.Name)
+			xlMeta, err := xl.readXLMetadata(minioMetaBucket, getVersionPath(bucket, objInfo.Name, objInfo.VersionID))
+			if err == nil {
+				xl.releaseDedupRef(xlMeta)
+			}
+			nsMutex.Unlock(bucket, objInfo.Name)
+			// Latest versions are not kept in minioMetaBucket.
+			if err != nil && err != errFileNotFound {
+				return toObjectErr(err, bucket, objInfo.Name)
+			}
+		}
+		if !result.IsTruncated {
+			break
+		}
+		keyMarker, versionIDMarker = result.NextKeyMarker, result.NextVersionIDMarker
+	}
+	return nil
+}
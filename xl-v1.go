@@ -20,7 +20,10 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
+	"github.com/minio/mc/pkg/console"
 	"github.com/minio/minio/pkg/disk"
 )
 
@@ -37,6 +40,11 @@ const (
 
 	// Uploads metadata file carries per multipart object metadata.
 	uploadsJSONFile = "uploads.json"
+
+	// Interval of loading `format.json` of disks not yet found,
+	// doubled upon each retry up to disksMaxRetryInterval.
+	disksRetryInterval    = time.Second
+	disksMaxRetryInterval = 16 * time.Second
 )
 
 // xlObjects - Implements XL object layer.
@@ -50,6 +58,12 @@ type xlObjects struct {
 
 	// List pool management.
 	listPool *treeWalkPool
+
+	// Objects written with disks missing, pending catch-up.
+	degraded *degradedObjects
+
+	// Takes failed disks offline and admits them again once back.
+	diskMonitor *diskMonitor
 }
 
 // errXLMaxDisks - returned for reached maximum of disks.
@@ -61,12 +75,18 @@ var errXLMinDisks = errors.New("Number of disks are smaller than supported minim
 // errXLNumDisks - returned for odd number of disks.
 var errXLNumDisks = errors.New("Number of disks should be multiples of '2'")
 
+// errXLInvalidParity - returned for parity blocks outside the supported range.
+var errXLInvalidParity = errors.New("Number of parity blocks should be between '1' and half the number of disks")
+
 // errXLReadQuorum - did not meet read quorum.
 var errXLReadQuorum = errors.New("I/O error.  did not meet read quorum.")
 
 // errXLWriteQuorum - did not meet write quorum.
 var errXLWriteQuorum = errors.New("I/O error.  did not meet write quorum.")
 
+// errBitrotDetected - block read from a disk does not match its checksum.
+var errBitrotDetected = errors.New("Bit rot detected, block does not match its checksum")
+
 // errXLDataCorrupt - err data corrupt.
 var errXLDataCorrupt = errors.New("data likely corrupted, all blocks are zero in length")
 
@@ -103,6 +123,90 @@ func checkSufficientDisks(disks []string) error {
 	return nil
 }
 
+// getErasureBlocks - returns data and parity blocks of new objects,
+// parity of 0 uses half of the disks for parity.
+func getErasureBlocks(diskCount, parity int) (dataBlocks, parityBlocks int, err error) {
+	if parity == 0 {
+		parity = diskCount / 2
+	}
+	// More parity than data blocks cannot be read with a read quorum
+	// of half the disks.
+	if parity < 1 || parity > diskCount/2 {
+		return 0, 0, errXLInvalidParity
+	}
+	return diskCount - parity, parity, nil
+}
+
+// hasNetworkDisks - returns true if any disk is on another node.
+func hasNetworkDisks(disks []string) bool {
+	for _, disk := range disks {
+		if !isLocalExportPath(disk) {
+			return true
+		}
+	}
+	return false
+}
+
+// getMissingFormatDisks - returns disks not found, along with unformatted
+// disks while fewer than a quorum of disks is formatted. Those are
+// likely mount points whose disks are not mounted yet.
+func getMissingFormatDisks(disks []string, sErrs []error) (missing []string) {
+	var formattedDisks int
+	for _, sErr := range sErrs {
+		if sErr == nil {
+			formattedDisks++
+		}
+	}
+	formatQuorum := len(disks)/2 + 1
+	for index, sErr := range sErrs {
+		if sErr == errDiskNotFound {
+			missing = append(missing, disks[index])
+		} else if sErr == errUnformattedDisk && formattedDisks > 0 && formattedDisks < formatQuorum {
+			missing = append(missing, disks[index])
+		}
+	}
+	return missing
+}
+
+// waitForFormats - runs house keeping and loads all `format.json`,
+// disks are mounted and nodes of a distributed setup start
+// independently so loading is retried with backoff for at most
+// globalDisksWaitTimeout. Local setups wait while fewer than a quorum
+// of disks is found, distributed setups while any disk is not found.
+func waitForFormats(disks []string, storageDisks []StorageAPI) ([]*formatConfigV1, []error) {
+	deadline := time.Now().Add(globalDisksWaitTimeout)
+	retryInterval := disksRetryInterval
+	formatQuorum := len(disks)/2 + 1
+	for {
+		// Runs house keeping code before loading formats, it moves
+		// `format.json` of older releases into minioMetaBucket. Disks
+		// found while waiting are migrated upon the next retry.
+		xlHouseKeeping(storageDisks)
+
+		formatConfigs, sErrs := loadAllFormats(storageDisks)
+		missing := getMissingFormatDisks(disks, sErrs)
+		if len(missing) == 0 {
+			return formatConfigs, sErrs
+		}
+		underQuorum := len(disks)-len(missing) < formatQuorum
+		if !underQuorum && !hasNetworkDisks(disks) {
+			return formatConfigs, sErrs
+		}
+		if time.Now().Add(retryInterval).After(deadline) {
+			if underQuorum {
+				console.Println(colorYellow("Disks not found: ") + strings.Join(missing, ", "))
+			}
+			return formatConfigs, sErrs
+		}
+		console.Printf("Waiting for %d of %d disks to come online, retrying in %s: %s\n",
+			len(missing), len(disks), retryInterval, strings.Join(missing, ", "))
+		time.Sleep(retryInterval)
+		if retryInterval *= 2; retryInterval > disksMaxRetryInterval {
+			retryInterval = disksMaxRetryInterval
+		}
+	}
+}
+
 // newXLObjects - initialize new xl object layer.
 func newXLObjects(disks []string) (ObjectLayer, error) {
 	// Validate if input disks are sufficient.
@@ -122,26 +226,54 @@ func newXLObjects(disks []string) (ObjectLayer, error) {
 		}
 	}
 
-	// Runs house keeping code, like creating minioMetaBucket, cleaning up tmp files etc.
-	xlHouseKeeping(storageDisks)
+	// Size erasure workers for the number of disks.
+	initErasureWorkers(len(storageDisks))
 
-	// Attempt to load all `format.json`.
-	formatConfigs, sErrs := loadAllFormats(storageDisks)
+	// Attempt to load all `format.json`, waiting for disks not yet
+	// mounted and for disks of other nodes which are not yet up.
+	formatConfigs, sErrs := waitForFormats(disks, storageDisks)
 
 	// Generic format check validates all necessary cases.
 	if err := genericFormatCheck(formatConfigs, sErrs); err != nil {
 		return nil, err
 	}
 
+	// Calculate data and parity blocks.
+	dataBlocks, parityBlocks, err := getErasureBlocks(len(disks), globalErasureParity)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group disks by failure domain, disks of a single host without
+	// labels are a standalone setup.
+	domainsConfig, err := readFailureDomains()
+	if err != nil {
+		return nil, err
+	}
+	domains := getFailureDomains(disks, domainsConfig, parityBlocks)
+	var domainsErr error
+	if domainsConfig.isSet() || len(domains.Domains) > 1 {
+		domainsErr = checkFailureDomains(domains)
+	}
+
 	// Handles different cases properly.
 	switch reduceFormatErrs(sErrs, len(storageDisks)) {
 	case errUnformattedDisk:
+		// Fresh disks are never formatted with failure domains
+		// which cannot be lost.
+		if domainsErr != nil {
+			return nil, domainsErr
+		}
 		// All drives online but fresh, initialize format.
 		if err := initFormatXL(storageDisks); err != nil {
 			return nil, fmt.Errorf("Unable to initialize format, %s", err)
 		}
 	case errSomeDiskUnformatted:
-		// All drives online but some report missing format.json.
+		// All drives online but some report missing format.json,
+		// drives which may not be mounted yet are never formatted.
+		if missing := getMissingFormatDisks(disks, sErrs); len(missing) > 0 {
+			return nil, fmt.Errorf("Unable to find formatted disks, fewer than %d of %d disks are formatted, not formatting %s", len(disks)/2+1, len(disks), strings.Join(missing, ", "))
+		}
 		if err := healFormatXL(storageDisks); err != nil {
 			// There was an unexpected unrecoverable error during healing.
 			return nil, fmt.Errorf("Unable to heal backend %s", err)
@@ -158,32 +290,133 @@ func newXLObjects(disks []string) (ObjectLayer, error) {
 		return nil, fmt.Errorf("Unable to recognize backend format, %s", err)
 	}
 
-	// Calculate data and parity blocks.
-	dataBlocks, parityBlocks := len(newPosixDisks)/2, len(newPosixDisks)/2
+	// Disks formatted earlier keep serving, losing the domain is
+	// warned about.
+	if domainsErr != nil {
+		console.Println(colorYellow("Failure domains: ") + domainsErr.Error())
+	}
+	globalFailureDomains = domains
+
+	// Disks of all positions are pinged, including those not found.
+	monitor := newDiskMonitor(disks, newPosixDisks)
 
 	// Initialize xl objects.
 	xl := xlObjects{
 		physicalDisks: disks,
-		storageDisks:  newPosixDisks,
+		storageDisks:  monitor.storageDisks(),
 		dataBlocks:    dataBlocks,
 		parityBlocks:  parityBlocks,
 		listPool:      newTreeWalkPool(globalLookupTimeout),
+		degraded:      newDegradedObjects(),
+		diskMonitor:   monitor,
+	}
+	globalDegradedObjects = xl.degraded
+	globalDiskMonitor = xl.diskMonitor
+
+	// Figure out read and write quorum based on number of storage disks,
+	// configured quorum takes precedence.
+	readQuorum, writeQuorum := getConfiguredQuorum()
+	xl.readQuorum, xl.writeQuorum, err = getXLQuorum(len(xl.storageDisks), dataBlocks, readQuorum, writeQuorum)
+	if err != nil {
+		return nil, err
+	}
+	globalXLQuorum = &xlQuorumInfo{
+		Disks:        len(xl.storageDisks),
+		DataBlocks:   dataBlocks,
+		ParityBlocks: parityBlocks,
+		ReadQuorum:   xl.readQuorum,
+		WriteQuorum:  xl.writeQuorum,
+	}
+
+	// Scrub all objects periodically, if enabled.
+	if globalScrubInterval > 0 {
+		go xl.scrubJob(globalScrubInterval)
+	}
+	// Verify a sample of objects continuously, if enabled.
+	if globalVerifySamplePercent > 0 {
+		go xl.verifyJob(globalVerifySamplePercent, globalVerifyErrorThreshold)
+	}
+	// Backfill disks missed by writes.
+	go xl.catchUpJob(catchUpInterval)
+	// Admitted disks are healed by a scrub.
+	go xl.diskMonitor.run(diskPingInterval, xl.scrub)
+
+	// Return successfully initialized object layer.
+	return xl, nil
+}
+
+// xlQuorumInfo - represents the effective read and write quorum of XL,
+// along with the erasure layout they were validated against.
+type xlQuorumInfo struct {
+	Disks        int `json:"disks"`
+	DataBlocks   int `json:"dataBlocks"`
+	ParityBlocks int `json:"parityBlocks"`
+	ReadQuorum   int `json:"readQuorum"`
+	WriteQuorum  int `json:"writeQuorum"`
+}
+
+// Quorum of XL, nil for FS.
+var globalXLQuorum *xlQuorumInfo
+
+// getConfiguredQuorum - returns read and write quorum set in the
+// server config, 0 if not set.
+func getConfiguredQuorum() (readQuorum, writeQuorum int) {
+	if serverConfig == nil {
+		return 0, 0
 	}
+	return serverConfig.GetQuorum()
+}
 
-	// Figure out read and write quorum based on number of storage disks.
+// getXLQuorum - returns read and write quorum for the number of disks
+// and data blocks of new objects, non-zero overrides take precedence
+// if they are within safe bounds.
+func getXLQuorum(diskCount, dataBlocks, readOverride, writeOverride int) (readQuorum, writeQuorum int, err error) {
 	// Read quorum should be always N/2 + 1 (due to Vandermonde matrix
 	// erasure requirements)
-	xl.readQuorum = len(xl.storageDisks)/2 + 1
+	readQuorum = diskCount/2 + 1
 
 	// Write quorum is assumed if we have total disks + 2
 	// parity.
-	xl.writeQuorum = len(xl.storageDisks)/2 + 2
-	if xl.writeQuorum > len(xl.storageDisks) {
-		xl.writeQuorum = len(xl.storageDisks)
+	writeQuorum = diskCount/2 + 2
+	// Objects with fewer parity blocks are readable only if all their
+	// data blocks and one more block are written.
+	if writeQuorum < dataBlocks+1 {
+		writeQuorum = dataBlocks + 1
+	}
+	if writeQuorum > diskCount {
+		writeQuorum = diskCount
 	}
 
-	// Return successfully initialized object layer.
-	return xl, nil
+	// Write quorum of more than half of the disks makes sure two
+	// conflicting writes never both succeed.
+	if writeOverride > 0 {
+		minWriteQuorum := diskCount/2 + 1
+		if minWriteQuorum < dataBlocks+1 {
+			minWriteQuorum = dataBlocks + 1
+		}
+		if writeOverride < minWriteQuorum || writeOverride > diskCount {
+			return 0, 0, fmt.Errorf("Write quorum %d has to be between %d and %d for %d disks.", writeOverride, minWriteQuorum, diskCount, diskCount)
+		}
+		writeQuorum = writeOverride
+	}
+
+	// Read quorum overlapping the write quorum makes sure reads see the
+	// last successful write, and objects are reconstructed only from
+	// their data blocks.
+	if readOverride > 0 {
+		minReadQuorum := diskCount - writeQuorum + 1
+		if minReadQuorum < diskCount/2 {
+			minReadQuorum = diskCount / 2
+		}
+		if minReadQuorum < dataBlocks {
+			minReadQuorum = dataBlocks
+		}
+		if readOverride < minReadQuorum || readOverride > diskCount {
+			return 0, 0, fmt.Errorf("Read quorum %d has to be between %d and %d for %d disks with write quorum %d.", readOverride, minReadQuorum, diskCount, diskCount, writeQuorum)
+		}
+		readQuorum = readOverride
+	}
+	return readQuorum, writeQuorum, nil
 }
 
 // byDiskTotal is a collection satisfying sort.Interface.
@@ -195,6 +428,21 @@ func (d byDiskTotal) Less(i, j int) bool {
 	return d[i].Total < d[j].Total
 }
 
+// Capabilities - returns optional features supported by XL.
+func (xl xlObjects) Capabilities() BackendCapabilities {
+	return BackendCapabilities{
+		Backend:        "XL",
+		Multipart:      true,
+		Snapshots:      true,
+		Dedup:          globalDedup,
+		MetadataFilter: true,
+		Healing:        true,
+		Tagging:        true,
+		Versioning:     true,
+		ObjectLock:     true,
+	}
+}
+
 // StorageInfo - returns underlying storage statistics.
 func (xl xlObjects) StorageInfo() StorageInfo {
 	var disksInfo []disk.Info
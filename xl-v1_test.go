@@ -16,7 +16,16 @@
 
 package main
 
-import "testing"
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
 
 // Collection of disks verbatim used for tests.
 var disks = []string{
@@ -112,3 +121,367 @@ func TestStorageInfo(t *testing.T) {
 		t.Fatalf("Diskinfo total values should be greater 0")
 	}
 }
+
+// Tests data and parity blocks calculated for configured parity.
+func TestGetErasureBlocks(t *testing.T) {
+	testCases := []struct {
+		diskCount            int
+		parity               int
+		expectedDataBlocks   int
+		expectedParityBlocks int
+		expectedErr          error
+	}{
+		// Test case - 1.
+		// Half of the disks by default.
+		{16, 0, 8, 8, nil},
+		// Test case - 2.
+		{16, 2, 14, 2, nil},
+		// Test case - 3.
+		{8, 4, 4, 4, nil},
+		// Test case - 4.
+		{12, 1, 11, 1, nil},
+		// Test case - 5.
+		// More parity than data blocks.
+		{8, 5, 0, 0, errXLInvalidParity},
+		// Test case - 6.
+		{16, -1, 0, 0, errXLInvalidParity},
+	}
+	for i, testCase := range testCases {
+		dataBlocks, parityBlocks, err := getErasureBlocks(testCase.diskCount, testCase.parity)
+		if err != testCase.expectedErr {
+			t.Errorf("Test %d: Expected error %v, got %v", i+1, testCase.expectedErr, err)
+		}
+		if dataBlocks != testCase.expectedDataBlocks || parityBlocks != testCase.expectedParityBlocks {
+			t.Errorf("Test %d: Expected %d data and %d parity blocks, got %d and %d", i+1, testCase.expectedDataBlocks, testCase.expectedParityBlocks, dataBlocks, parityBlocks)
+		}
+	}
+}
+
+// Tests read and write quorum are calculated from the number of disks
+// and overrides are accepted only within safe bounds.
+func TestGetXLQuorum(t *testing.T) {
+	testCases := []struct {
+		diskCount           int
+		dataBlocks          int
+		readOverride        int
+		writeOverride       int
+		expectedReadQuorum  int
+		expectedWriteQuorum int
+		shouldPass          bool
+	}{
+		// Test case - 1.
+		// Calculated from the number of disks by default.
+		{16, 8, 0, 0, 9, 10, true},
+		// Test case - 2.
+		// Fewer parity blocks need a larger write quorum.
+		{16, 14, 0, 0, 9, 15, true},
+		// Test case - 3.
+		{16, 8, 0, 16, 9, 16, true},
+		// Test case - 4.
+		// Smaller read quorum with a larger write quorum.
+		{16, 8, 8, 12, 8, 12, true},
+		// Test case - 5.
+		// Read quorum does not overlap the write quorum.
+		{16, 8, 6, 10, 0, 0, false},
+		// Test case - 6.
+		// Write quorum of half of the disks.
+		{16, 8, 0, 8, 0, 0, false},
+		// Test case - 7.
+		// Write quorum cannot reconstruct data blocks.
+		{16, 14, 0, 12, 0, 0, false},
+		// Test case - 8.
+		// More than the number of disks.
+		{16, 8, 17, 0, 0, 0, false},
+		// Test case - 9.
+		{16, 8, 0, 17, 0, 0, false},
+		// Test case - 10.
+		// Read quorum cannot reconstruct data blocks.
+		{16, 12, 8, 14, 0, 0, false},
+		// Test case - 11.
+		{16, 12, 12, 14, 12, 14, true},
+	}
+	for i, testCase := range testCases {
+		readQuorum, writeQuorum, err := getXLQuorum(testCase.diskCount, testCase.dataBlocks, testCase.readOverride, testCase.writeOverride)
+		if err != nil && testCase.shouldPass {
+			t.Errorf("Test %d: Expected to pass, failed with %s", i+1, err)
+		}
+		if err == nil && !testCase.shouldPass {
+			t.Errorf("Test %d: Expected to fail, passed instead", i+1)
+		}
+		if readQuorum != testCase.expectedReadQuorum || writeQuorum != testCase.expectedWriteQuorum {
+			t.Errorf("Test %d: Expected read quorum %d and write quorum %d, got %d and %d", i+1, testCase.expectedReadQuorum, testCase.expectedWriteQuorum, readQuorum, writeQuorum)
+		}
+	}
+}
+
+// Tests configured quorum is used by XL and failing to meet it returns
+// typed errors sent as 503.
+func TestXLConfiguredQuorum(t *testing.T) {
+	root, err := getTestRoot()
+	if err != nil {
+		t.Fatalf("Unable to create temp root. %s", err)
+	}
+	defer removeAll(root)
+	setGlobalConfigPath(root)
+	if err = initConfig(); err != nil {
+		t.Fatalf("Unable to initialize config. %s", err)
+	}
+	serverConfig.SetQuorum(8, 16)
+	defer serverConfig.SetQuorum(0, 0)
+
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+	if xl.readQuorum != 8 || xl.writeQuorum != 16 {
+		t.Fatalf("Expected read quorum 8 and write quorum 16, got %d and %d", xl.readQuorum, xl.writeQuorum)
+	}
+	// Effective quorum is reported by the admin info API.
+	if globalXLQuorum == nil || globalXLQuorum.ReadQuorum != 8 || globalXLQuorum.WriteQuorum != 16 {
+		t.Fatalf("Expected reported read quorum 8 and write quorum 16, got %v", globalXLQuorum)
+	}
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("a"), 1024*1024)
+	if _, err = obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Writes need all disks.
+	disk := xl.storageDisks[0]
+	xl.storageDisks[0] = faultyWriteDisk{disk}
+	_, err = obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil)
+	if _, ok := err.(InsufficientWriteQuorum); !ok {
+		t.Errorf("Expected InsufficientWriteQuorum, got %v", err)
+	}
+	xl.storageDisks[0] = disk
+
+	// Reads with fewer disks than data blocks left fail.
+	for i := 0; i <= xl.parityBlocks; i++ {
+		xl.storageDisks[i] = faultyReadDisk{xl.storageDisks[i]}
+	}
+	var buf bytes.Buffer
+	err = obj.GetObject(bucket, "object", 0, int64(len(data)), &buf)
+	if _, ok := err.(InsufficientReadQuorum); !ok {
+		t.Errorf("Expected InsufficientReadQuorum, got %v", err)
+	}
+
+	for _, apiErr := range []APIErrorCode{ErrWriteQuorum, ErrReadQuorum} {
+		if status := getAPIError(apiErr).HTTPStatusCode; status != http.StatusServiceUnavailable {
+			t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, status)
+		}
+	}
+}
+
+// Tests objects are written with the configured parity and remain
+// readable with disks offline.
+func TestXLErasureParity(t *testing.T) {
+	globalErasureParity = 2
+	defer func() { globalErasureParity = 0 }()
+
+	obj, fsDirs, err := getXLObjectLayer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer removeRoots(fsDirs)
+	xl := obj.(xlObjects)
+	if xl.dataBlocks != 14 || xl.parityBlocks != 2 {
+		t.Fatalf("Expected 14 data and 2 parity blocks, got %d and %d", xl.dataBlocks, xl.parityBlocks)
+	}
+	if xl.writeQuorum != 15 {
+		t.Fatalf("Expected write quorum of 15, got %d", xl.writeQuorum)
+	}
+
+	bucket := "bucket"
+	if err = obj.MakeBucket(bucket); err != nil {
+		t.Fatal(err)
+	}
+	data := bytes.Repeat([]byte("a"), 1*1024*1024)
+	if _, err = obj.PutObject(bucket, "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatal(err)
+	}
+	xlMeta, err := xl.readXLMetadata(bucket, "object")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if xlMeta.Erasure.DataBlocks != 14 || xlMeta.Erasure.ParityBlocks != 2 {
+		t.Fatalf("Expected layout of 14 data and 2 parity blocks, got %d and %d", xlMeta.Erasure.DataBlocks, xlMeta.Erasure.ParityBlocks)
+	}
+
+	// Reads verify reconstructed data with one block more than the
+	// data blocks, one less disk than parity blocks may be offline.
+	xl.storageDisks[5] = nil
+	var buf bytes.Buffer
+	if err = obj.GetObject(bucket, "object", 0, int64(len(data)), &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), data) {
+		t.Fatal("Object read with disks offline does not match")
+	}
+}
+
+// Tests disks reported missing at startup.
+func TestGetMissingFormatDisks(t *testing.T) {
+	testCases := []struct {
+		sErrs   []error
+		missing []string
+	}{
+		// Test case - 1.
+		// All disks formatted.
+		{[]error{nil, nil, nil, nil}, nil},
+		// Test case - 2.
+		// Fresh disks, all unformatted.
+		{[]error{errUnformattedDisk, errUnformattedDisk, errUnformattedDisk, errUnformattedDisk}, nil},
+		// Test case - 3.
+		// Disks not found are missing.
+		{[]error{nil, errDiskNotFound, nil, errDiskNotFound}, []string{disks[1], disks[3]}},
+		// Test case - 4.
+		// Unformatted disks along with a quorum of formatted disks
+		// are replaced disks, healed later.
+		{[]error{nil, nil, nil, errUnformattedDisk}, nil},
+		// Test case - 5.
+		// Unformatted disks along with less than a quorum of
+		// formatted disks may not be mounted yet.
+		{[]error{nil, errUnformattedDisk, errDiskNotFound, errUnformattedDisk}, []string{disks[1], disks[2], disks[3]}},
+	}
+	for i, testCase := range testCases {
+		missing := getMissingFormatDisks(disks[:len(testCase.sErrs)], testCase.sErrs)
+		if !reflect.DeepEqual(missing, testCase.missing) {
+			t.Errorf("Test %d: Expected missing disks %v, got %v", i+1, testCase.missing, missing)
+		}
+	}
+}
+
+// Tests waiting at startup for disks mounted late.
+func TestXLWaitForDisks(t *testing.T) {
+	defer func(timeout time.Duration) { globalDisksWaitTimeout = timeout }(globalDisksWaitTimeout)
+	globalDisksWaitTimeout = time.Minute
+
+	var fsDirs []string
+	for i := 0; i < 8; i++ {
+		fsDir, err := ioutil.TempDir(os.TempDir(), "minio-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fsDirs = append(fsDirs, fsDir)
+	}
+	defer removeRoots(fsDirs)
+
+	// Disks are mounted after half a second, which is waited for as only
+	// 4 of 8 disks are found.
+	for _, fsDir := range fsDirs[:4] {
+		removeAll(fsDir)
+	}
+	go func() {
+		time.Sleep(500 * time.Millisecond)
+		for _, fsDir := range fsDirs[:4] {
+			os.MkdirAll(fsDir, 0777)
+		}
+	}()
+	initNSLock()
+	obj, err := newXLObjects(fsDirs)
+	if err != nil {
+		t.Fatalf("Unable to initialize XL with disks mounted late, %s", err)
+	}
+	xl := obj.(xlObjects)
+	for index, disk := range xl.storageDisks {
+		if _, err = loadFormat(disk); err != nil {
+			t.Fatalf("Disk %d: Expected to be formatted, got %s", index, err)
+		}
+	}
+
+	// Without waiting disks never found fail startup.
+	globalDisksWaitTimeout = 0
+	for _, fsDir := range fsDirs[:5] {
+		removeAll(fsDir)
+	}
+	if _, err = newXLObjects(fsDirs); err == nil {
+		t.Fatal("Expected XL to fail without a quorum of disks")
+	}
+
+	// Empty mount points of disks not mounted are never formatted.
+	for _, fsDir := range fsDirs[:5] {
+		os.MkdirAll(fsDir, 0777)
+	}
+	if _, err = newXLObjects(fsDirs); err == nil {
+		t.Fatal("Expected XL to fail with less than a quorum of formatted disks")
+	}
+	for index, fsDir := range fsDirs[:5] {
+		if _, err = os.Stat(filepath.Join(fsDir, minioMetaBucket, formatConfigFile)); !os.IsNotExist(err) {
+			t.Fatalf("Disk %d: Expected not to be formatted", index)
+		}
+	}
+}
+
+// Tests XL disks of older releases keep their format and objects once
+// the internal state is migrated out of the old meta bucket.
+func TestXLMigrateMinioMetaBucket(t *testing.T) {
+	var fsDirs []string
+	for i := 0; i < 8; i++ {
+		fsDir, err := ioutil.TempDir(os.TempDir(), "minio-")
+		if err != nil {
+			t.Fatal(err)
+		}
+		fsDirs = append(fsDirs, fsDir)
+	}
+	defer removeRoots(fsDirs)
+
+	initNSLock()
+	obj, err := newXLObjects(fsDirs)
+	if err != nil {
+		t.Fatalf("Unable to initialize XL, %s", err)
+	}
+	if err = obj.MakeBucket("bucket"); err != nil {
+		t.Fatalf("Unable to create bucket, %s", err)
+	}
+	data := []byte("hello, world")
+	if _, err = obj.PutObject("bucket", "object", int64(len(data)), bytes.NewReader(data), nil); err != nil {
+		t.Fatalf("Unable to create object, %s", err)
+	}
+	var formats []*formatConfigV1
+	for _, disk := range obj.(xlObjects).storageDisks {
+		format, lErr := loadFormat(disk)
+		if lErr != nil {
+			t.Fatalf("Unable to load format, %s", lErr)
+		}
+		formats = append(formats, format)
+	}
+
+	// Layout of older releases, all internal state lives in the old
+	// meta bucket.
+	for _, fsDir := range fsDirs {
+		if err = os.Rename(filepath.Join(fsDir, minioMetaBucket), filepath.Join(fsDir, oldMinioMetaBucket)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	initNSLock()
+	obj, err = newXLObjects(fsDirs)
+	if err != nil {
+		t.Fatalf("Unable to initialize XL with disks of older releases, %s", err)
+	}
+	for index, disk := range obj.(xlObjects).storageDisks {
+		format, lErr := loadFormat(disk)
+		if lErr != nil {
+			t.Fatalf("Disk %d: Unable to load format, %s", index, lErr)
+		}
+		if !reflect.DeepEqual(format, formats[index]) {
+			t.Errorf("Disk %d: Expected format %v to be kept, got %v", index, formats[index], format)
+		}
+		if _, err = os.Stat(filepath.Join(fsDirs[index], oldMinioMetaBucket)); !os.IsNotExist(err) {
+			t.Errorf("Disk %d: Expected %s to be removed", index, oldMinioMetaBucket)
+		}
+	}
+	var buffer bytes.Buffer
+	if err = obj.GetObject("bucket", "object", 0, int64(len(data)), &buffer); err != nil {
+		t.Fatalf("Unable to read object, %s", err)
+	}
+	if !bytes.Equal(buffer.Bytes(), data) {
+		t.Errorf("Expected %s, got %s", data, buffer.Bytes())
+	}
+}